@@ -18,6 +18,7 @@ import (
 	"github.com/pasarsuara/backend/internal/database"
 	"github.com/pasarsuara/backend/internal/handlers"
 	"github.com/pasarsuara/backend/internal/integrations"
+	"github.com/pasarsuara/backend/internal/storage"
 )
 
 func main() {
@@ -42,11 +43,9 @@ func main() {
 	}
 
 	// Check API keys
-	var kolosalClient *ai.KolosalClient
 	if cfg.KolosalAPIKey == "" {
-		log.Println("⚠️ KOLOSAL_API_KEY not set - intent extraction will fail")
+		log.Println("⚠️ KOLOSAL_API_KEY not set - intent extraction will use fallback providers")
 	} else {
-		kolosalClient = ai.NewKolosalClient(cfg.KolosalAPIKey, cfg.KolosalBaseURL)
 		log.Println("✅ Kolosal API configured")
 	}
 
@@ -61,37 +60,147 @@ func main() {
 		log.Printf("✅ Gemini API configured (key: %s)", keyPreview)
 	}
 
+	// One LLM router shared by every AI caller: ordered fallback,
+	// retries with jitter and a circuit breaker per provider
+	router := ai.NewDefaultRouter(ai.ProviderSettings{
+		KolosalKey:  cfg.KolosalAPIKey,
+		KolosalURL:  cfg.KolosalBaseURL,
+		GeminiKeys:  cfg.GeminiAPIKey,
+		OpenAIKey:   cfg.OpenAIAPIKey,
+		OpenAIURL:   cfg.OpenAIBaseURL,
+		OpenAIModel: cfg.OpenAIModel,
+		Order:       cfg.LLMProviders,
+	})
+	if router.Len() == 0 {
+		log.Println("⚠️ No LLM provider configured - AI features will fail")
+	} else {
+		log.Printf("✅ LLM providers: %s", router.Name())
+	}
+
+	// Every LLM call is metered per tenant and feature; tenants over their
+	// monthly plan fall back to rules, cached promos and templates
+	usagePolicy := ai.DefaultUsagePolicy()
+	if cfg.LLMUsagePolicyFile != "" {
+		policy, err := ai.LoadUsagePolicy(cfg.LLMUsagePolicyFile)
+		if err != nil {
+			log.Printf("⚠️ LLM usage policy not loaded, metering without quotas: %v", err)
+		} else {
+			usagePolicy = policy
+			log.Printf("✅ LLM usage policy loaded (%d plans)", len(policy.Plans))
+		}
+	}
+	usageMeter := ai.NewUsageMeter(usagePolicy)
+	if db != nil {
+		usageMeter.SetSink(api.UsageSink(db))
+		if restored, err := api.RestoreUsage(context.Background(), db, usageMeter); err != nil {
+			log.Printf("⚠️ LLM usage not restored, quotas restart at zero: %v", err)
+		} else {
+			log.Printf("✅ LLM usage restored (%d calls this month)", restored)
+		}
+	}
+	reportCtx, stopReports := context.WithCancel(context.Background())
+	defer stopReports()
+	go usageMeter.RunDailyReports(reportCtx)
+	llm := ai.NewMeteredProvider(router, usageMeter)
+
+	if cfg.PromptExperimentsFile != "" {
+		if err := ai.DefaultPrompts().LoadPromptExperiments(cfg.PromptExperimentsFile); err != nil {
+			log.Printf("⚠️ Prompt experiments not loaded, serving latest versions: %v", err)
+		} else {
+			log.Println("✅ Prompt experiments loaded")
+		}
+	}
+
 	// Create Intent Engine
-	intentEngine := ai.NewIntentEngine(
-		cfg.GeminiAPIKey,
-		cfg.KolosalAPIKey,
-		cfg.KolosalBaseURL,
-	)
+	intentEngine := ai.NewIntentEngineWithProvider(llm)
+
+	// Speech-to-text: cheapest transcriber first with fallback; Javanese and
+	// Sundanese prefer Gemini, which recognises them better than whisper
+	stt := ai.NewDefaultTranscriber(llm, ai.STTSettings{
+		WhisperURL:   cfg.WhisperURL,
+		WhisperKey:   cfg.WhisperKey,
+		WhisperModel: cfg.WhisperModel,
+		Providers:    cfg.STTProviders,
+		Costs:        cfg.STTCosts,
+		Prefer:       cfg.STTPrefer,
+	})
+	if stt.Len() == 0 {
+		log.Println("⚠️ No speech-to-text configured - voice notes will fail")
+	} else {
+		intentEngine.SetTranscriber(stt)
+		log.Printf("✅ Speech-to-text: %s", stt.Name())
+	}
 
 	// Create Conversation Manager (30 min TTL)
 	contextMgr := appcontext.NewConversationManager(30 * time.Minute)
 	log.Println("✅ Conversation Manager initialized")
 
 	// Create Agent Orchestrator
-	orchestrator := agents.NewAgentOrchestrator(db, intentEngine, kolosalClient, cfg.KolosalAPIKey, cfg.KolosalBaseURL, cfg.GeminiAPIKey, contextMgr)
+	orchestrator := agents.NewAgentOrchestrator(db, intentEngine, llm, contextMgr)
+
+	// Voice note replies are optional
+	if cfg.TTSAPIKey != "" {
+		orchestrator.SetSpeechSynthesizer(ai.NewGoogleTTSClient(cfg.TTSAPIKey))
+		log.Println("✅ Text-to-speech configured")
+	} else {
+		log.Println("⚠️ GOOGLE_TTS_API_KEY not set - voice note replies disabled")
+	}
+
+	// Confirm-before-commit thresholds, defaults unless a policy file is given
+	if cfg.ConfidencePolicyFile != "" {
+		policy, err := agents.LoadConfidencePolicy(cfg.ConfidencePolicyFile)
+		if err != nil {
+			log.Printf("⚠️ Confidence policy not loaded, using defaults: %v", err)
+		} else {
+			orchestrator.SetConfidencePolicy(policy)
+			log.Printf("✅ Confidence policy loaded (%d rules)", len(policy.Rules))
+		}
+	}
+
+	// Product photos become catalog images; without a store, entries are
+	// still created, just without a photo
+	var media http.Handler
+	mediaStore, err := storage.NewLocalStore(cfg.MediaDir, cfg.MediaBaseURL)
+	if err != nil {
+		log.Printf("⚠️ Media store not available - product photos won't be saved: %v", err)
+	} else {
+		orchestrator.SetObjectStore(mediaStore)
+		media = mediaStore.Handler()
+		log.Printf("✅ Media store: %s (%s)", cfg.MediaDir, cfg.MediaBaseURL)
+	}
+
+	// Kasbon and tax reminders are queued every morning, budget summaries
+	// every 1st of the month and recurring transactions when due; they need
+	// the database
+	if db != nil {
+		go orchestrator.GetCreditAgent().RunReminders(reportCtx)
+		log.Println("✅ Credit reminders scheduled")
+		go orchestrator.GetBudgetAgent().RunMonthEnd(reportCtx)
+		log.Println("✅ Budget summaries scheduled")
+		go orchestrator.GetTaxAgent().RunReminders(reportCtx)
+		log.Println("✅ Tax summaries and reminders scheduled")
+		go orchestrator.GetRecurringAgent().RunScheduler(reportCtx)
+		log.Println("✅ Recurring transactions scheduled")
+	}
 
 	// Create Catalog Handler
 	catalogHandler := api.NewCatalogHandler(orchestrator.GetPromoAgent())
 
 	// Create Integration Services
 	excelExporter := integrations.NewExcelExporter(db)
+	pdfExporter := integrations.NewPDFExporter(db)
 	whatsappBcast := integrations.NewWhatsAppBroadcaster(db, cfg.KolosalBaseURL, cfg.KolosalAPIKey)
-	socialMediaGen := integrations.NewSocialMediaGenerator(cfg.GeminiAPIKey)
+	socialMediaGen := integrations.NewSocialMediaGenerator(llm)
 
 	// Create Integrations Handler
-	integrationsHandler := handlers.NewIntegrationsHandler(excelExporter, whatsappBcast, socialMediaGen)
+	integrationsHandler := handlers.NewIntegrationsHandler(excelExporter, pdfExporter, whatsappBcast, socialMediaGen)
 
 	// Create Message Router (for registration, ambiguity, categorization)
 	// Note: This requires importing handlers package
 	// messageRouter := handlers.NewMessageRouter(db, intentEngine, contextMgr)
 
 	// Create router with integrations handler
-	router := api.NewRouter(orchestrator, catalogHandler, db, integrationsHandler)
+	httpRouter := api.NewRouter(orchestrator, catalogHandler, db, integrationsHandler, api.NewUsageAPI(usageMeter), media)
 
 	// TODO: Set message router on webhook handler
 	// webhook.SetMessageRouter(messageRouter)
@@ -99,7 +208,7 @@ func main() {
 	// Create server
 	server := &http.Server{
 		Addr:         ":" + cfg.Port,
-		Handler:      router,
+		Handler:      httpRouter,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 60 * time.Second, // Longer for AI processing
 		IdleTimeout:  60 * time.Second,
@@ -121,6 +230,8 @@ func main() {
 		log.Println("   GET  /api/integrations/broadcast/templates - Broadcast templates")
 		log.Println("   POST /api/integrations/social-content - Generate social media content")
 		log.Println("   POST /api/integrations/social-content/bulk - Generate bulk content")
+		log.Println("   GET  /api/admin/usage - LLM usage and cost (admin)")
+		log.Println("   GET  /media/* - Stored product photos")
 		log.Println("   GET  /health - Health check")
 		log.Println("")
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -32,6 +32,12 @@ func CheckAmbiguity(intent *ai.Intent) *AmbiguityCheck {
 		return checkRestockAmbiguity(intent)
 	case "CHECK_STOCK":
 		return checkStockAmbiguity(intent)
+	case "RECORD_DEBT", "RECORD_DEBT_PAYMENT":
+		return checkCreditAmbiguity(intent)
+	case "SET_BUDGET":
+		return checkBudgetAmbiguity(intent)
+	case "SET_RECURRING":
+		return checkRecurringAmbiguity(intent)
 	default:
 		return check
 	}
@@ -167,6 +173,102 @@ func checkStockAmbiguity(intent *ai.Intent) *AmbiguityCheck {
 	return check
 }
 
+func checkCreditAmbiguity(intent *ai.Intent) *AmbiguityCheck {
+	check := &AmbiguityCheck{
+		HasAmbiguity: false,
+		Missing:      []string{},
+	}
+
+	contact := getStringEntity(intent.Entities, "contact")
+	amount := getFloatEntity(intent.Entities, "amount")
+	payable := getStringEntity(intent.Entities, "credit_type") == ai.CreditPayable
+
+	// Check missing contact
+	if contact == "" {
+		check.HasAmbiguity = true
+		check.Missing = append(check.Missing, "contact")
+		check.Question = "Kasbon atas nama siapa?"
+		if payable {
+			check.Question = "Utang ke siapa?"
+		}
+		return check
+	}
+
+	// Check missing amount; "lunas" pays everything
+	if amount == 0 && !(intent.Action == "RECORD_DEBT_PAYMENT" && intent.Entities["settle"] == true) {
+		check.HasAmbiguity = true
+		check.Missing = append(check.Missing, "amount")
+		check.Question = fmt.Sprintf("Kasbon %s berapa?", contact)
+		if intent.Action == "RECORD_DEBT_PAYMENT" {
+			check.Question = fmt.Sprintf("%s bayar berapa?", contact)
+		}
+		check.Suggestions = []string{"Rp 20.000", "Rp 50.000", "Rp 100.000"}
+		return check
+	}
+
+	return check
+}
+
+func checkBudgetAmbiguity(intent *ai.Intent) *AmbiguityCheck {
+	check := &AmbiguityCheck{
+		HasAmbiguity: false,
+		Missing:      []string{},
+	}
+
+	category := getStringEntity(intent.Entities, "category")
+	amount := getFloatEntity(intent.Entities, "amount")
+
+	// Check missing category
+	if category == "" {
+		check.HasAmbiguity = true
+		check.Missing = append(check.Missing, "category")
+		check.Question = "Budget untuk kategori apa?"
+		check.Suggestions = []string{"Bahan Baku", "Operasional", "Transportasi"}
+		return check
+	}
+
+	// Check missing amount
+	if amount == 0 {
+		check.HasAmbiguity = true
+		check.Missing = append(check.Missing, "amount")
+		check.Question = fmt.Sprintf("Budget %s berapa sebulan?", category)
+		check.Suggestions = []string{"Rp 200.000", "Rp 500.000", "Rp 1.000.000"}
+		return check
+	}
+
+	return check
+}
+
+func checkRecurringAmbiguity(intent *ai.Intent) *AmbiguityCheck {
+	check := &AmbiguityCheck{
+		HasAmbiguity: false,
+		Missing:      []string{},
+	}
+
+	product := getStringEntity(intent.Entities, "product")
+	price := getFloatEntity(intent.Entities, "price")
+
+	// Check missing item
+	if product == "" {
+		check.HasAmbiguity = true
+		check.Missing = append(check.Missing, "product")
+		check.Question = "Transaksi rutin untuk apa?"
+		check.Suggestions = []string{"Sewa", "Listrik", "Gaji Karyawan"}
+		return check
+	}
+
+	// Check missing amount
+	if price == 0 {
+		check.HasAmbiguity = true
+		check.Missing = append(check.Missing, "price")
+		check.Question = fmt.Sprintf("%s berapa tiap kali?", product)
+		check.Suggestions = []string{"Rp 100.000", "Rp 500.000", "Rp 1.000.000"}
+		return check
+	}
+
+	return check
+}
+
 // FormatAmbiguityResponse formats ambiguity check as WhatsApp message
 func FormatAmbiguityResponse(check *AmbiguityCheck) string {
 	if !check.HasAmbiguity {
@@ -207,3 +309,61 @@ func FormatAmbiguityButtons(check *AmbiguityCheck) []string {
 
 	return buttons
 }
+
+// selectionKindAmbiguity marks button/list ids that answer an ambiguity question
+const selectionKindAmbiguity = "amb"
+
+// FormatAmbiguityReply builds an interactive reply for the ambiguity question.
+// Up to 3 suggestions become quick reply buttons, more become a list menu.
+func FormatAmbiguityReply(check *AmbiguityCheck) Reply {
+	text := FormatAmbiguityResponse(check)
+	if !check.HasAmbiguity || len(check.Suggestions) == 0 || len(check.Missing) == 0 {
+		return TextReply(text)
+	}
+
+	field := check.Missing[0]
+
+	if len(check.Suggestions) <= MaxReplyButtons {
+		labels := FormatAmbiguityButtons(check)
+		buttons := []ReplyButton{}
+		for i, suggestion := range check.Suggestions {
+			buttons = append(buttons, ReplyButton{
+				ID:    SelectionID(selectionKindAmbiguity, field, suggestionValue(field, suggestion)),
+				Title: labels[i],
+			})
+		}
+		return ButtonsReply(check.Question, buttons)
+	}
+
+	rows := []ReplyRow{}
+	for _, suggestion := range check.Suggestions {
+		rows = append(rows, ReplyRow{
+			ID:    SelectionID(selectionKindAmbiguity, field, suggestionValue(field, suggestion)),
+			Title: suggestion,
+		})
+	}
+	return ListReply(check.Question, &ReplyList{
+		Title:      check.Question,
+		ButtonText: "Pilih",
+		Sections:   []ReplySection{{Title: "Pilihan", Rows: rows}},
+	})
+}
+
+// suggestionValue converts a suggestion label into the entity value carried
+// in the selection id, e.g. "Rp 15.000" -> "15000", "10 porsi" -> "10"
+func suggestionValue(field, suggestion string) string {
+	switch field {
+	case "qty", "price", "max_price", "amount":
+		digits := strings.Builder{}
+		for _, r := range suggestion {
+			if r >= '0' && r <= '9' {
+				digits.WriteRune(r)
+			} else if digits.Len() > 0 && r == ' ' {
+				break
+			}
+		}
+		return digits.String()
+	default:
+		return suggestion
+	}
+}
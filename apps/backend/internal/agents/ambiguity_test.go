@@ -0,0 +1,101 @@
+package agents
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pasarsuara/backend/internal/ai"
+	appcontext "github.com/pasarsuara/backend/internal/context"
+)
+
+func TestFormatAmbiguityReply(t *testing.T) {
+	// Missing price: 4 suggestions -> list menu
+	intent := &ai.Intent{
+		Action:   "RECORD_SALE",
+		Entities: map[string]any{"product": "Nasi Goreng", "qty": 10.0},
+	}
+	reply := FormatAmbiguityReply(CheckAmbiguity(intent))
+	if reply.Type != ReplyTypeList {
+		t.Fatalf("Expected list reply, got %s", reply.Type)
+	}
+	if reply.List == nil || len(reply.List.Sections) != 1 || len(reply.List.Sections[0].Rows) != 4 {
+		t.Fatal("List should contain one section with 4 rows")
+	}
+	if id := reply.List.Sections[0].Rows[1].ID; id != "amb:price:15000" {
+		t.Errorf("Unexpected row id: %s", id)
+	}
+
+	// Missing budget: 3 suggestions -> buttons
+	intent = &ai.Intent{
+		Action:   "ORDER_RESTOCK",
+		Entities: map[string]any{"product": "Beras", "qty": 25.0},
+	}
+	reply = FormatAmbiguityReply(CheckAmbiguity(intent))
+	if reply.Type != ReplyTypeButtons {
+		t.Fatalf("Expected buttons reply, got %s", reply.Type)
+	}
+	if len(reply.Buttons) != 3 {
+		t.Fatalf("Expected 3 buttons, got %d", len(reply.Buttons))
+	}
+	if reply.Buttons[0].ID != "amb:max_price:10000" || reply.Buttons[0].Title != "10rb" {
+		t.Errorf("Unexpected button: %+v", reply.Buttons[0])
+	}
+
+	// No ambiguity -> plain text
+	reply = FormatAmbiguityReply(&AmbiguityCheck{})
+	if reply.Type != ReplyTypeText {
+		t.Errorf("Expected text reply, got %s", reply.Type)
+	}
+}
+
+func TestParseSelectionID(t *testing.T) {
+	tests := []struct {
+		id    string
+		ok    bool
+		field string
+		value string
+	}{
+		{"amb:qty:5", true, "qty", "5"},
+		{"amb:product:Nasi Goreng", true, "product", "Nasi Goreng"},
+		{"amb:note:a:b", true, "note", "a:b"},
+		{"btn_0", false, "", ""},
+		{"", false, "", ""},
+	}
+
+	for _, tt := range tests {
+		sel, ok := ParseSelectionID(tt.id)
+		if ok != tt.ok {
+			t.Errorf("ParseSelectionID(%q) ok = %v, want %v", tt.id, ok, tt.ok)
+			continue
+		}
+		if ok && (sel.Field != tt.field || sel.Value != tt.value) {
+			t.Errorf("ParseSelectionID(%q) = %+v", tt.id, sel)
+		}
+	}
+}
+
+func TestProcessSelectionFillsPendingIntent(t *testing.T) {
+	contextMgr := appcontext.NewConversationManager(time.Minute)
+	contextMgr.AddMessage("628123", "system", "waiting_for_clarification", "RECORD_EXPENSE",
+		map[string]any{"product": "Listrik"})
+
+	o := &AgentOrchestrator{finance: NewFinanceAgent(nil), contextMgr: contextMgr}
+	resp := o.ProcessSelection(context.Background(), "628123", "amb:price:50000", "50rb")
+
+	if resp.Intent == nil || resp.Intent.Action != "RECORD_EXPENSE" {
+		t.Fatalf("Pending intent should be restored, got %+v", resp.Intent)
+	}
+	if price := getFloatEntity(resp.Intent.Entities, "price"); price != 50000 {
+		t.Errorf("Expected price 50000, got %v", price)
+	}
+	if product := getStringEntity(resp.Intent.Entities, "product"); product != "Listrik" {
+		t.Errorf("Expected product from context, got %q", product)
+	}
+
+	// Unknown id without display text is rejected
+	resp = o.ProcessSelection(context.Background(), "628999", "unknown", "")
+	if resp.Success {
+		t.Error("Unknown selection without text should fail")
+	}
+}
@@ -41,7 +41,9 @@ type PriceRecommendation struct {
 	ProductName      string  `json:"product_name"`
 	CurrentPrice     float64 `json:"current_price"`
 	RecommendedPrice float64 `json:"recommended_price"`
-	ExpectedProfit   float64 `json:"expected_profit"`
+	UnitCost         float64 `json:"unit_cost,omitempty"` // FIFO cost per unit sold, 0 when unknown
+	ExpectedProfit   float64 `json:"expected_profit"`     // Gross profit at the recommended price
+	GrossMargin      float64 `json:"gross_margin_percent,omitempty"`
 	PriceChange      float64 `json:"price_change_percent"`
 	Reasoning        string  `json:"reasoning"`
 	Confidence       float64 `json:"confidence"`
@@ -141,25 +143,31 @@ func (a *AnalyticsAgent) RecommendOptimalPrice(ctx context.Context, userID strin
 	// Analyze price-demand relationship
 	pricePoints := a.analyzePriceDemand(transactions)
 	currentPrice := a.calculateAveragePrice(transactions)
+	unitCost := a.unitCost(ctx, userID, productName, transactions)
 
-	// Calculate optimal price (maximize revenue)
-	optimalPrice := a.calculateOptimalPrice(pricePoints, currentPrice)
-	expectedProfit := a.estimateProfit(pricePoints, optimalPrice)
+	// Calculate optimal price (maximize gross profit, or revenue if the cost is unknown)
+	optimalPrice := a.calculateOptimalPrice(pricePoints, currentPrice, unitCost)
+	expectedProfit := a.estimateProfit(pricePoints, optimalPrice, unitCost)
 	priceChange := ((optimalPrice - currentPrice) / currentPrice) * 100
 
 	// Generate reasoning
-	reasoning := a.generatePriceReasoning(currentPrice, optimalPrice, priceChange, pricePoints)
+	reasoning := a.generatePriceReasoning(currentPrice, optimalPrice, priceChange, unitCost)
 	confidence := a.calculatePriceConfidence(pricePoints)
 
-	return &PriceRecommendation{
+	recommendation := &PriceRecommendation{
 		ProductName:      productName,
 		CurrentPrice:     math.Round(currentPrice),
 		RecommendedPrice: math.Round(optimalPrice),
+		UnitCost:         math.Round(unitCost),
 		ExpectedProfit:   math.Round(expectedProfit),
 		PriceChange:      math.Round(priceChange*100) / 100,
 		Reasoning:        reasoning,
 		Confidence:       math.Round(confidence*100) / 100,
-	}, nil
+	}
+	if unitCost > 0 && optimalPrice > 0 {
+		recommendation.GrossMargin = math.Round((optimalPrice-unitCost)/optimalPrice*10000) / 100
+	}
+	return recommendation, nil
 }
 
 // OptimizeInventory provides smart reorder recommendations
@@ -421,19 +429,42 @@ func (a *AnalyticsAgent) analyzePriceDemand(transactions []*database.Transaction
 	return points
 }
 
-func (a *AnalyticsAgent) calculateOptimalPrice(pricePoints []pricePoint, currentPrice float64) float64 {
+// unitCost is the FIFO cost per unit of the costed sales in transactions,
+// else the latest purchase price, else 0 (unknown)
+func (a *AnalyticsAgent) unitCost(ctx context.Context, userID, productName string, transactions []*database.Transaction) float64 {
+	cogs, qty := 0.0, 0.0
+	for _, tx := range transactions {
+		if tx.Type == "SALE" && tx.COGS > 0 {
+			cogs += tx.COGS
+			qty += tx.Qty
+		}
+	}
+	if qty > 0 {
+		return cogs / qty
+	}
+	if a.db == nil {
+		return 0
+	}
+	layer, err := a.db.GetLatestInventoryLayer(ctx, userID, costKey(productName))
+	if err != nil || layer == nil {
+		return 0
+	}
+	return layer.UnitCost
+}
+
+func (a *AnalyticsAgent) calculateOptimalPrice(pricePoints []pricePoint, currentPrice, unitCost float64) float64 {
 	if len(pricePoints) == 0 {
 		return currentPrice
 	}
 
-	// Find price that maximizes revenue
-	maxRevenue := 0.0
+	// Find price that maximizes gross profit; with no known cost that is revenue
+	maxProfit := math.Inf(-1)
 	optimalPrice := currentPrice
 
 	for _, point := range pricePoints {
-		revenue := point.price * point.demand
-		if revenue > maxRevenue {
-			maxRevenue = revenue
+		profit := (point.price - unitCost) * point.demand
+		if profit > maxProfit {
+			maxProfit = profit
 			optimalPrice = point.price
 		}
 	}
@@ -448,10 +479,15 @@ func (a *AnalyticsAgent) calculateOptimalPrice(pricePoints []pricePoint, current
 		}
 	}
 
+	// Never recommend selling below cost
+	if unitCost > 0 && optimalPrice < unitCost {
+		optimalPrice = unitCost
+	}
+
 	return optimalPrice
 }
 
-func (a *AnalyticsAgent) estimateProfit(pricePoints []pricePoint, optimalPrice float64) float64 {
+func (a *AnalyticsAgent) estimateProfit(pricePoints []pricePoint, optimalPrice, unitCost float64) float64 {
 	// Estimate demand at optimal price
 	estimatedDemand := 0.0
 	for _, point := range pricePoints {
@@ -464,19 +500,26 @@ func (a *AnalyticsAgent) estimateProfit(pricePoints []pricePoint, optimalPrice f
 		estimatedDemand = pricePoints[0].demand
 	}
 
-	return optimalPrice * estimatedDemand
+	return (optimalPrice - unitCost) * estimatedDemand
 }
 
-func (a *AnalyticsAgent) generatePriceReasoning(current, optimal, changePercent float64, points []pricePoint) string {
-	if math.Abs(changePercent) < 2 {
-		return fmt.Sprintf("Harga saat ini (Rp %.0f) sudah optimal. Pertahankan harga ini.", current)
+func (a *AnalyticsAgent) generatePriceReasoning(current, optimal, changePercent, unitCost float64) string {
+	var reasoning string
+	switch {
+	case math.Abs(changePercent) < 2:
+		reasoning = fmt.Sprintf("Harga saat ini (Rp %.0f) sudah optimal. Pertahankan harga ini.", current)
+	case changePercent > 0:
+		reasoning = fmt.Sprintf("Naikkan harga %.1f%% ke Rp %.0f. Data menunjukkan demand masih tinggi di harga ini.", changePercent, optimal)
+	default:
+		reasoning = fmt.Sprintf("Turunkan harga %.1f%% ke Rp %.0f untuk meningkatkan volume penjualan.", math.Abs(changePercent), optimal)
 	}
 
-	if changePercent > 0 {
-		return fmt.Sprintf("Naikkan harga %.1f%% ke Rp %.0f. Data menunjukkan demand masih tinggi di harga ini.", changePercent, optimal)
+	if unitCost > 0 {
+		reasoning += fmt.Sprintf(" Modal per unit Rp %.0f, untung Rp %.0f per unit.", unitCost, optimal-unitCost)
+	} else {
+		reasoning += " Harga modal belum diketahui, catat pembelian stok supaya saran harga memperhitungkan untung."
 	}
-
-	return fmt.Sprintf("Turunkan harga %.1f%% ke Rp %.0f untuk meningkatkan volume penjualan.", math.Abs(changePercent), optimal)
+	return reasoning
 }
 
 func (a *AnalyticsAgent) calculatePriceConfidence(pricePoints []pricePoint) float64 {
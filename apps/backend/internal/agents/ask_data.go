@@ -0,0 +1,725 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pasarsuara/backend/internal/ai"
+	"github.com/pasarsuara/backend/internal/database"
+)
+
+// DataAgent answers questions about the user's own books (ASK_DATA). The
+// LLM only plans the query; rows are always fetched for the asking user.
+type DataAgent struct {
+	db  *database.SupabaseClient
+	llm ai.Provider
+}
+
+func NewDataAgent(db *database.SupabaseClient, llm ai.Provider) *DataAgent {
+	return &DataAgent{db: db, llm: llm}
+}
+
+// QueryResult is an executed query plan
+type QueryResult struct {
+	Plan     *ai.QueryPlan
+	Range    *ai.DateRange // nil for the whole history or current state
+	Value    float64       // Metric over all matching rows
+	Count    int           // Matching rows
+	Rows     []QueryRow    // Groups, or rows for list
+	Last     *database.Transaction
+	Profit   *ProfitBreakdown
+	Previous *QueryResult // Period before, when the plan compares
+}
+
+// QueryRow is one group or listed row
+type QueryRow struct {
+	Label string
+	Value float64
+	Count int
+	Unit  string
+}
+
+// ProfitBreakdown is what a profit figure is made of
+type ProfitBreakdown struct {
+	Sales         float64
+	COGS          float64
+	Expenses      float64
+	Purchases     float64 // Stock bought, costed into COGS as it sells
+	UncostedSales float64 // Sales without a cost of goods
+}
+
+// GrossProfit is sales minus the cost of the goods sold
+func (p *ProfitBreakdown) GrossProfit() float64 {
+	return p.Sales - p.COGS
+}
+
+// Profit is gross profit minus expenses, the net profit of the reports
+func (p *ProfitBreakdown) Profit() float64 {
+	return p.GrossProfit() - p.Expenses
+}
+
+const askDataHelp = "🤔 Maaf, pertanyaannya belum bisa saya jawab. Coba misalnya:\n" +
+	"• \"bulan ini paling laku apa?\"\n" +
+	"• \"kapan terakhir beli gas?\"\n" +
+	"• \"untung minggu ini dibanding minggu lalu?\""
+
+// Answer plans the question, runs it on the user's data and explains the numbers
+func (d *DataAgent) Answer(ctx context.Context, userID string, intent *ai.Intent, now time.Time) string {
+	plan, err := ai.PlanQuery(ctx, d.llm, intent.RawText, now)
+	if err != nil {
+		log.Printf("⚠️ Query plan failed, trying rules: %v", err)
+		plan = ai.RuleQueryPlan(intent.RawText)
+	}
+	if plan == nil {
+		return askDataHelp
+	}
+
+	r := plan.Range(now)
+	if plan.Source == "transactions" && plan.Period == "" {
+		// Dates named in the question ("tanggal 1 sampai 15"), else this month
+		if parsed := ai.ParseDateRange(intent.RawText, now); parsed != nil {
+			r = parsed
+			plan.Period = "custom"
+		} else if plan.Metric != "last" {
+			plan.Period = "this_month"
+			r = plan.Range(now)
+		}
+	}
+	log.Printf("🔎 ASK_DATA plan: %s %s group=%s period=%s filters=%v", plan.Source, plan.Metric, plan.GroupBy, plan.Period, plan.Filters)
+
+	result, err := d.Run(ctx, userID, plan, r)
+	if err != nil {
+		log.Printf("❌ ASK_DATA query failed: %v", err)
+		return "Maaf, datanya belum bisa diambil. Coba lagi ya!"
+	}
+	return FormatQueryAnswer(result, now)
+}
+
+// Run executes a validated plan over the user's rows in r
+func (d *DataAgent) Run(ctx context.Context, userID string, plan *ai.QueryPlan, r *ai.DateRange) (*QueryResult, error) {
+	switch plan.Source {
+	case "transactions":
+		txs, err := d.transactions(ctx, userID, r)
+		if err != nil {
+			return nil, err
+		}
+		result := evalTransactions(plan, txs, r)
+		if plan.Compare == "previous_period" && r != nil {
+			prev := ai.PreviousRange(r)
+			prevTxs, err := d.transactions(ctx, userID, prev)
+			if err != nil {
+				return nil, err
+			}
+			result.Previous = evalTransactions(plan, prevTxs, prev)
+		}
+		return result, nil
+
+	case "inventory":
+		var items []database.Inventory
+		if d.db != nil {
+			var err error
+			if items, err = d.db.GetInventory(ctx, userID); err != nil {
+				return nil, err
+			}
+		}
+		return evalInventory(plan, items), nil
+
+	case "contacts":
+		var contacts []database.Contact
+		if d.db != nil {
+			var err error
+			if contacts, err = d.db.GetContacts(ctx, userID, plan.TextFilter("type")); err != nil {
+				return nil, err
+			}
+		}
+		return evalContacts(plan, contacts), nil
+	}
+	return nil, fmt.Errorf("%w: source %q", ai.ErrInvalidQueryPlan, plan.Source)
+}
+
+// transactions fetches the user's transactions in r, or the last five years
+func (d *DataAgent) transactions(ctx context.Context, userID string, r *ai.DateRange) ([]database.Transaction, error) {
+	if d.db == nil {
+		return nil, nil
+	}
+	start := time.Now().AddDate(-5, 0, 0)
+	end := time.Now()
+	if r != nil {
+		start, end = r.Start, r.End.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	const layout = "2006-01-02T15:04:05Z"
+	return d.db.GetTransactionsByDateRange(ctx, userID, start.UTC().Format(layout), end.UTC().Format(layout))
+}
+
+// matchText applies an eq or contains filter, ignoring case
+func matchText(value string, f ai.QueryFilter) bool {
+	want, _ := f.Value.(string)
+	switch f.Op {
+	case "eq":
+		return strings.EqualFold(strings.TrimSpace(value), want)
+	case "contains":
+		return strings.Contains(strings.ToLower(value), strings.ToLower(want))
+	}
+	return false
+}
+
+// matchNumber applies a gt or lt filter
+func matchNumber(value float64, f ai.QueryFilter) bool {
+	want, _ := f.Value.(float64)
+	if f.Op == "gt" {
+		return value > want
+	}
+	return value < want
+}
+
+func transactionMatches(tx *database.Transaction, filters []ai.QueryFilter) bool {
+	for _, f := range filters {
+		ok := true
+		switch f.Field {
+		case "type":
+			ok = matchText(tx.Type, f)
+		case "product_name":
+			ok = matchText(tx.ProductName, f)
+		case "qty":
+			ok = matchNumber(tx.Qty, f)
+		case "total_amount":
+			ok = matchNumber(tx.TotalAmount, f)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// queryGroup accumulates one group of transactions
+type queryGroup struct {
+	label  string
+	order  string // Sort key for time groups
+	amount float64
+	qty    float64
+	count  int
+	profit ProfitBreakdown
+}
+
+func (g *queryGroup) add(tx *database.Transaction) {
+	g.amount += tx.TotalAmount
+	g.qty += tx.Qty
+	g.count++
+	switch tx.Type {
+	case "SALE":
+		g.profit.Sales += tx.TotalAmount
+		g.profit.COGS += tx.COGS
+		if tx.COGS == 0 {
+			g.profit.UncostedSales += tx.TotalAmount
+		}
+	case "PURCHASE":
+		g.profit.Purchases += tx.TotalAmount
+	case "EXPENSE":
+		g.profit.Expenses += tx.TotalAmount
+	}
+}
+
+func (g *queryGroup) value(metric string) float64 {
+	switch metric {
+	case "sum_qty":
+		return g.qty
+	case "count":
+		return float64(g.count)
+	case "avg_price":
+		if g.qty == 0 {
+			return 0
+		}
+		return g.amount / g.qty
+	case "profit":
+		return g.profit.Profit()
+	default:
+		return g.amount
+	}
+}
+
+// evalTransactions computes a plan over already fetched transactions
+func evalTransactions(plan *ai.QueryPlan, txs []database.Transaction, r *ai.DateRange) *QueryResult {
+	result := &QueryResult{Plan: plan, Range: r}
+	loc := ai.UserLocation("")
+	if r != nil {
+		loc = r.Start.Location()
+	}
+
+	var matched []database.Transaction
+	for i := range txs {
+		if transactionMatches(&txs[i], plan.Filters) {
+			matched = append(matched, txs[i])
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return createdAt(&matched[i], loc).After(createdAt(&matched[j], loc))
+	})
+
+	total := &queryGroup{}
+	for i := range matched {
+		total.add(&matched[i])
+	}
+	result.Count = total.count
+	result.Value = total.value(plan.Metric)
+	if plan.Metric == "profit" {
+		result.Profit = &total.profit
+	}
+	if len(matched) > 0 {
+		result.Last = &matched[0]
+	}
+
+	switch {
+	case plan.Metric == "list":
+		for i := range matched[:min(plan.Limit, len(matched))] {
+			tx := &matched[i]
+			label := fmt.Sprintf("%s — %s %s", ai.FormatDateShort(createdAt(tx, loc)), transactionTypeLabel(tx.Type), tx.ProductName)
+			result.Rows = append(result.Rows, QueryRow{Label: strings.TrimSpace(label), Value: tx.TotalAmount, Count: 1})
+		}
+	case plan.GroupBy != "" && plan.Metric != "last":
+		result.Rows = groupTransactions(plan, matched, loc)
+	}
+	return result
+}
+
+// groupTransactions sums matched transactions per group; time groups are
+// chronological, others ranked by value
+func groupTransactions(plan *ai.QueryPlan, matched []database.Transaction, loc *time.Location) []QueryRow {
+	groups := map[string]*queryGroup{}
+	var keys []string
+	for i := range matched {
+		tx := &matched[i]
+		t := createdAt(tx, loc)
+		var key, label, order string
+		switch plan.GroupBy {
+		case "product":
+			key, label = strings.ToLower(strings.TrimSpace(tx.ProductName)), tx.ProductName
+		case "type":
+			key, label = tx.Type, transactionTypeLabel(tx.Type)
+		case "day":
+			order = t.Format("2006-01-02")
+			key, label = order, ai.FormatDate(t)
+		case "week":
+			monday := t.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+			order = monday.Format("2006-01-02")
+			key, label = order, "Minggu "+ai.FormatDate(monday)
+		case "month":
+			order = t.Format("2006-01")
+			key, label = order, strings.SplitN(ai.FormatDate(t), " ", 2)[1]
+		}
+		if key == "" {
+			continue
+		}
+		g, ok := groups[key]
+		if !ok {
+			g = &queryGroup{label: label, order: order}
+			groups[key] = g
+			keys = append(keys, key)
+		} else if g.label == strings.ToLower(g.label) {
+			g.label = label // Prefer how the catalog capitalises it
+		}
+		g.add(tx)
+	}
+
+	timed := plan.GroupBy == "day" || plan.GroupBy == "week" || plan.GroupBy == "month"
+	sort.SliceStable(keys, func(i, j int) bool {
+		a, b := groups[keys[i]], groups[keys[j]]
+		if timed {
+			return a.order < b.order
+		}
+		if plan.Order == "asc" {
+			return a.value(plan.Metric) < b.value(plan.Metric)
+		}
+		return a.value(plan.Metric) > b.value(plan.Metric)
+	})
+	limit := plan.Limit
+	if timed {
+		limit = 31
+		keys = keys[max(0, len(keys)-limit):] // Most recent
+	}
+
+	var rows []QueryRow
+	for _, key := range keys[:min(limit, len(keys))] {
+		g := groups[key]
+		rows = append(rows, QueryRow{Label: g.label, Value: g.value(plan.Metric), Count: g.count})
+	}
+	return rows
+}
+
+// createdAt reads created_at in loc; unparseable times sort last
+func createdAt(tx *database.Transaction, loc *time.Location) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, tx.CreatedAt)
+	if err != nil {
+		t, err = time.Parse("2006-01-02T15:04:05.999999", tx.CreatedAt)
+	}
+	if err != nil {
+		return time.Time{}
+	}
+	return t.In(loc)
+}
+
+func transactionTypeLabel(txType string) string {
+	switch txType {
+	case "SALE":
+		return "Penjualan"
+	case "PURCHASE":
+		return "Pembelian"
+	case "EXPENSE":
+		return "Pengeluaran"
+	}
+	return "Transaksi"
+}
+
+// evalInventory computes a plan over the user's stock
+func evalInventory(plan *ai.QueryPlan, items []database.Inventory) *QueryResult {
+	result := &QueryResult{Plan: plan}
+	for _, item := range items {
+		ok := true
+		for _, f := range plan.Filters {
+			switch f.Field {
+			case "product_name":
+				ok = ok && matchText(item.ProductName, f)
+			case "stock_qty":
+				ok = ok && matchNumber(item.StockQty, f)
+			}
+		}
+		if !ok {
+			continue
+		}
+		result.Count++
+		result.Value += item.StockQty
+		result.Rows = append(result.Rows, QueryRow{Label: item.ProductName, Value: item.StockQty, Count: 1, Unit: item.Unit})
+	}
+	if plan.Metric == "count" {
+		result.Value = float64(result.Count)
+	}
+	sortRows(result.Rows, plan.Order)
+	result.Rows = result.Rows[:min(plan.Limit, len(result.Rows))]
+	return result
+}
+
+// evalContacts computes a plan over the user's contacts
+func evalContacts(plan *ai.QueryPlan, contacts []database.Contact) *QueryResult {
+	result := &QueryResult{Plan: plan}
+	groups := map[string]*QueryRow{}
+	var keys []string
+	for _, c := range contacts {
+		ok := true
+		for _, f := range plan.Filters {
+			switch f.Field {
+			case "type":
+				ok = ok && matchText(c.Type, f)
+			case "name":
+				ok = ok && matchText(c.Name, f)
+			case "city":
+				ok = ok && matchText(c.City, f)
+			}
+		}
+		if !ok {
+			continue
+		}
+		result.Count++
+
+		switch {
+		case plan.Metric == "list":
+			label := c.Name
+			if c.City != "" {
+				label += " (" + c.City + ")"
+			}
+			result.Rows = append(result.Rows, QueryRow{Label: label, Count: 1})
+		case plan.GroupBy != "":
+			key := contactTypeLabel(c.Type)
+			if plan.GroupBy == "city" {
+				key = c.City
+				if key == "" {
+					key = "Tanpa kota"
+				}
+			}
+			if groups[key] == nil {
+				groups[key] = &QueryRow{Label: key}
+				keys = append(keys, key)
+			}
+			groups[key].Value++
+			groups[key].Count++
+		}
+	}
+	for _, key := range keys {
+		result.Rows = append(result.Rows, *groups[key])
+	}
+	if plan.Metric != "list" {
+		sortRows(result.Rows, plan.Order)
+	}
+	result.Value = float64(result.Count)
+	result.Rows = result.Rows[:min(plan.Limit, len(result.Rows))]
+	return result
+}
+
+func sortRows(rows []QueryRow, order string) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		if order == "asc" {
+			return rows[i].Value < rows[j].Value
+		}
+		return rows[i].Value > rows[j].Value
+	})
+}
+
+func contactTypeLabel(contactType string) string {
+	if contactType == "CUSTOMER" {
+		return "pelanggan"
+	}
+	return "supplier"
+}
+
+// queryPeriodLabels name plan periods in replies
+var queryPeriodLabels = map[string]string{
+	"today": "hari ini", "yesterday": "kemarin", "this_week": "minggu ini", "last_week": "minggu lalu",
+	"this_month": "bulan ini", "last_month": "bulan lalu", "last_7_days": "7 hari terakhir",
+	"last_30_days": "30 hari terakhir", "this_year": "tahun ini",
+}
+
+// periodLabel is e.g. "minggu ini (13 - 19 Oktober 2026)", "" for the whole history
+func periodLabel(period string, r *ai.DateRange) string {
+	if r == nil {
+		return ""
+	}
+	if label, ok := queryPeriodLabels[period]; ok {
+		return fmt.Sprintf("%s (%s)", label, ai.FormatDateRange(r))
+	}
+	return ai.FormatDateRange(r)
+}
+
+// querySubject names what was asked about, e.g. "penjualan es teh"
+func querySubject(plan *ai.QueryPlan) string {
+	subject := "transaksi"
+	if t := plan.TextFilter("type"); t != "" {
+		subject = strings.ToLower(transactionTypeLabel(t))
+	}
+	if product := plan.TextFilter("product_name"); product != "" {
+		subject += " " + product
+	}
+	return subject
+}
+
+// FormatQueryAnswer explains a result in plain Bahasa with the numbers shown
+func FormatQueryAnswer(result *QueryResult, now time.Time) string {
+	plan := result.Plan
+	period := periodLabel(plan.Period, result.Range)
+	in := ""
+	if period != "" {
+		in = " " + period
+	}
+
+	switch plan.Source {
+	case "inventory":
+		return formatInventoryAnswer(result)
+	case "contacts":
+		return formatContactsAnswer(result)
+	}
+
+	if result.Count == 0 && plan.Metric != "profit" {
+		return fmt.Sprintf("📭 Belum ada catatan %s%s.", querySubject(plan), in)
+	}
+
+	var b strings.Builder
+	switch plan.Metric {
+	case "last":
+		tx := result.Last
+		verb := "beli"
+		if tx.Type == "SALE" {
+			verb = "jual"
+		}
+		item := tx.ProductName
+		if product := plan.TextFilter("product_name"); product != "" {
+			item = product
+		}
+		t := createdAt(tx, now.Location())
+		fmt.Fprintf(&b, "🕒 Terakhir %s %s: %s (%s)\n", verb, item, ai.FormatDate(t), daysAgo(t, now))
+		fmt.Fprintf(&b, "%s %s", transactionTypeLabel(tx.Type), tx.ProductName)
+		if tx.Qty > 0 {
+			fmt.Fprintf(&b, " %s", formatQty(tx.Qty))
+		}
+		fmt.Fprintf(&b, ", total Rp %s", formatCurrency(tx.TotalAmount))
+		return b.String()
+
+	case "profit":
+		p := result.Profit
+		fmt.Fprintf(&b, "💰 %s%s: Rp %s\n", profitWord(p.Profit()), in, formatCurrency(abs(p.Profit())))
+		fmt.Fprintf(&b, "• Penjualan: Rp %s\n• Modal terjual (HPP): Rp %s\n• Laba kotor: Rp %s\n• Pengeluaran: Rp %s",
+			formatCurrency(p.Sales), formatCurrency(p.COGS), formatCurrency(p.GrossProfit()), formatCurrency(p.Expenses))
+		if p.Purchases > 0 {
+			fmt.Fprintf(&b, "\n🛒 Belanja stok: Rp %s", formatCurrency(p.Purchases))
+		}
+		if p.UncostedSales > 0 {
+			fmt.Fprintf(&b, "\n⚠️ Rp %s penjualan belum ada harga modalnya, catat pembelian stoknya supaya untungnya pas.",
+				formatCurrency(p.UncostedSales))
+		}
+		if prev := result.Previous; prev != nil {
+			before := prev.Profit.Profit()
+			fmt.Fprintf(&b, "\n\n📊 %s %s: Rp %s\n", profitWord(before), periodLabel(previousPeriod(plan.Period), prev.Range), formatCurrency(abs(before)))
+			b.WriteString(formatChange(p.Profit(), before, plan.Metric))
+		}
+		b.WriteString(formatRows(result.Rows, plan))
+		return b.String()
+	}
+
+	subject := querySubject(plan)
+	switch plan.Metric {
+	case "sum_amount":
+		fmt.Fprintf(&b, "💵 Total %s%s: Rp %s (%d transaksi)", subject, in, formatCurrency(result.Value), result.Count)
+	case "sum_qty":
+		if plan.GroupBy == "product" && plan.Order == "desc" && plan.TextFilter("type") == "SALE" {
+			fmt.Fprintf(&b, "🏆 Paling laku%s:", in)
+		} else {
+			fmt.Fprintf(&b, "📦 Jumlah %s%s: %s", subject, in, formatQty(result.Value))
+		}
+	case "count":
+		fmt.Fprintf(&b, "🧾 Ada %d %s%s.", result.Count, subject, in)
+	case "avg_price":
+		fmt.Fprintf(&b, "🏷️ Harga rata-rata %s%s: Rp %s per unit", subject, in, formatCurrency(result.Value))
+	case "list":
+		fmt.Fprintf(&b, "📋 %s%s (%d dari %d):", strings.ToUpper(subject[:1])+subject[1:], in, len(result.Rows), result.Count)
+	}
+	b.WriteString(formatRows(result.Rows, plan))
+	if prev := result.Previous; prev != nil {
+		fmt.Fprintf(&b, "\n\n📊 Sebelumnya, %s: %s\n", periodLabel(previousPeriod(plan.Period), prev.Range), formatQueryValue(prev.Value, plan.Metric))
+		b.WriteString(formatChange(result.Value, prev.Value, plan.Metric))
+	}
+	return b.String()
+}
+
+func formatInventoryAnswer(result *QueryResult) string {
+	if result.Count == 0 {
+		return "📭 Belum ada stok yang cocok."
+	}
+	if result.Plan.Metric == "count" {
+		return fmt.Sprintf("📦 Ada %d produk di stok.", result.Count)
+	}
+	var b strings.Builder
+	b.WriteString("📦 Stok:")
+	for i, row := range result.Rows {
+		fmt.Fprintf(&b, "\n%d. %s — %s %s", i+1, row.Label, formatQty(row.Value), row.Unit)
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+func formatContactsAnswer(result *QueryResult) string {
+	subject := "kontak"
+	if t := result.Plan.TextFilter("type"); t != "" {
+		subject = contactTypeLabel(t)
+	}
+	if result.Count == 0 {
+		return fmt.Sprintf("📭 Belum ada %s yang tercatat.", subject)
+	}
+	var b strings.Builder
+	if result.Plan.Metric == "list" {
+		fmt.Fprintf(&b, "👥 Daftar %s (%d dari %d):", subject, len(result.Rows), result.Count)
+	} else {
+		fmt.Fprintf(&b, "👥 Ada %d %s.", result.Count, subject)
+	}
+	for i, row := range result.Rows {
+		fmt.Fprintf(&b, "\n%d. %s", i+1, row.Label)
+		if result.Plan.Metric != "list" {
+			fmt.Fprintf(&b, " — %d", row.Count)
+		}
+	}
+	return b.String()
+}
+
+// formatRows lists groups or rows with their values
+func formatRows(rows []QueryRow, plan *ai.QueryPlan) string {
+	var b strings.Builder
+	for i, row := range rows {
+		fmt.Fprintf(&b, "\n%d. %s — %s", i+1, row.Label, formatQueryValue(row.Value, plan.Metric))
+	}
+	return b.String()
+}
+
+func formatQueryValue(value float64, metric string) string {
+	switch metric {
+	case "sum_qty":
+		return formatQty(value)
+	case "count":
+		return fmt.Sprintf("%.0f transaksi", value)
+	case "profit":
+		if value < 0 {
+			return "rugi Rp " + formatCurrency(-value)
+		}
+		return "Rp " + formatCurrency(value)
+	default:
+		return "Rp " + formatCurrency(value)
+	}
+}
+
+// formatChange compares with the previous period, e.g. "📈 Naik Rp 70.000 (25%)"
+func formatChange(now, before float64, metric string) string {
+	diff := now - before
+	amount := "Rp " + formatCurrency(abs(diff))
+	if metric == "sum_qty" || metric == "count" {
+		amount = formatQty(abs(diff))
+	}
+	direction := "📈 Naik"
+	if diff < 0 {
+		direction = "📉 Turun"
+	}
+	switch {
+	case diff == 0:
+		return "➡️ Sama saja"
+	case before > 0:
+		return fmt.Sprintf("%s %s (%.0f%%)", direction, amount, abs(diff)/before*100)
+	default:
+		return fmt.Sprintf("%s %s", direction, amount)
+	}
+}
+
+// previousPeriod is the plan period compared with, "" when it has no name
+func previousPeriod(period string) string {
+	switch period {
+	case "today":
+		return "yesterday"
+	case "this_week":
+		return "last_week"
+	case "this_month":
+		return "last_month"
+	}
+	return ""
+}
+
+func profitWord(profit float64) string {
+	if profit < 0 {
+		return "Rugi"
+	}
+	return "Untung"
+}
+
+// daysAgo is e.g. "hari ini", "kemarin", "3 hari lalu"
+func daysAgo(t, now time.Time) string {
+	y1, m1, d1 := t.Date()
+	y2, m2, d2 := now.Date()
+	days := int(time.Date(y2, m2, d2, 0, 0, 0, 0, time.UTC).Sub(time.Date(y1, m1, d1, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+	switch days {
+	case 0:
+		return "hari ini"
+	case 1:
+		return "kemarin"
+	}
+	return fmt.Sprintf("%d hari lalu", days)
+}
+
+// formatQty drops the decimals of whole quantities
+func formatQty(qty float64) string {
+	if qty == float64(int64(qty)) {
+		return formatCurrency(qty)
+	}
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.2f", qty), "0"), ".")
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,98 @@
+package agents
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pasarsuara/backend/internal/ai"
+	"github.com/pasarsuara/backend/internal/database"
+)
+
+// askDataBooks is a week of transactions for one seller
+func askDataBooks() []database.Transaction {
+	tx := func(day int, txType, product string, qty, amount, cogs float64) database.Transaction {
+		at := time.Date(2026, time.October, day, 9, 0, 0, 0, time.UTC)
+		return database.Transaction{Type: txType, ProductName: product, Qty: qty, TotalAmount: amount, COGS: cogs,
+			CreatedAt: at.Format(time.RFC3339)}
+	}
+	return []database.Transaction{
+		tx(12, "SALE", "Es Teh", 40, 120000, 60000),
+		tx(13, "SALE", "Nasi Goreng", 10, 150000, 90000),
+		tx(13, "EXPENSE", "Gas", 2, 44000, 0),
+		tx(14, "SALE", "es teh", 30, 90000, 45000),
+		tx(14, "PURCHASE", "Beras", 25, 300000, 0),
+		tx(15, "EXPENSE", "Listrik", 0, 50000, 0),
+	}
+}
+
+func TestEvalTransactions(t *testing.T) {
+	now := time.Date(2026, time.October, 15, 12, 0, 0, 0, ai.UserLocation(""))
+
+	plan := ai.RuleQueryPlan("bulan ini paling laku apa?")
+	plan.Period = "this_month"
+	result := evalTransactions(plan, askDataBooks(), plan.Range(now))
+	if len(result.Rows) != 2 || result.Rows[0].Label != "Es Teh" || result.Rows[0].Value != 70 {
+		t.Errorf("Top products = %+v", result.Rows)
+	}
+	answer := FormatQueryAnswer(result, now)
+	if !strings.Contains(answer, "Paling laku bulan ini (1 - 15 Oktober 2026)") || !strings.Contains(answer, "1. Es Teh — 70") {
+		t.Errorf("Answer = %q", answer)
+	}
+
+	plan = ai.RuleQueryPlan("kapan terakhir beli gas?")
+	result = evalTransactions(plan, askDataBooks(), plan.Range(now))
+	answer = FormatQueryAnswer(result, now)
+	if !strings.Contains(answer, "Terakhir beli gas: 13 Oktober 2026 (2 hari lalu)") || !strings.Contains(answer, "Rp 44.000") {
+		t.Errorf("Answer = %q", answer)
+	}
+
+	plan = ai.RuleQueryPlan("kapan terakhir beli kopi?")
+	result = evalTransactions(plan, askDataBooks(), plan.Range(now))
+	if answer := FormatQueryAnswer(result, now); !strings.Contains(answer, "Belum ada catatan transaksi kopi") {
+		t.Errorf("No match answer = %q", answer)
+	}
+}
+
+func TestDataAgent_ProfitCompare(t *testing.T) {
+	now := time.Date(2026, time.October, 15, 12, 0, 0, 0, ai.UserLocation(""))
+	plan := ai.RuleQueryPlan("untung minggu ini dibanding minggu lalu?")
+	r := plan.Range(now)
+
+	thisWeek := evalTransactions(plan, askDataBooks(), r)
+	thisWeek.Previous = evalTransactions(plan, []database.Transaction{
+		{Type: "SALE", ProductName: "Es Teh", Qty: 50, TotalAmount: 150000, CreatedAt: "2026-10-07T09:00:00Z"},
+	}, ai.PreviousRange(r))
+
+	answer := FormatQueryAnswer(thisWeek, now)
+	for _, want := range []string{
+		"Untung minggu ini (12 - 15 Oktober 2026): Rp 71.000",
+		"Penjualan: Rp 360.000",
+		"Modal terjual (HPP): Rp 195.000",
+		"Laba kotor: Rp 165.000",
+		"Pengeluaran: Rp 94.000",
+		"Belanja stok: Rp 300.000",
+		"Untung minggu lalu (5 - 11 Oktober 2026): Rp 150.000",
+		"Turun Rp 79.000 (53%)",
+	} {
+		if !strings.Contains(answer, want) {
+			t.Errorf("Answer misses %q:\n%s", want, answer)
+		}
+	}
+}
+
+func TestDataAgent_AnswerWithoutLLM(t *testing.T) {
+	// Without a provider the rule planner answers; without a database there are no rows
+	agent := NewDataAgent(nil, nil)
+	now := time.Date(2026, time.October, 15, 12, 0, 0, 0, ai.UserLocation(""))
+
+	answer := agent.Answer(context.Background(), "user-1", &ai.Intent{Action: "ASK_DATA", RawText: "supplier saya ada berapa"}, now)
+	if !strings.Contains(answer, "Belum ada supplier") {
+		t.Errorf("Answer = %q", answer)
+	}
+	answer = agent.Answer(context.Background(), "user-1", &ai.Intent{Action: "ASK_DATA", RawText: "cuaca cerah ya"}, now)
+	if answer != askDataHelp {
+		t.Errorf("Unplannable question = %q", answer)
+	}
+}
@@ -0,0 +1,409 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pasarsuara/backend/internal/ai"
+	"github.com/pasarsuara/backend/internal/database"
+)
+
+const (
+	budgetWarnLevel    = 80               // Percent of a budget that warns
+	budgetOverLevel    = 100              // Percent of a budget that is overspent
+	budgetSuggestSpan  = 3                // Full months suggestions average over
+	budgetBuffer       = 1.1              // Suggestions leave 10% above average spending
+	budgetSummaryType  = "BUDGET_SUMMARY" // notification_queue type of month-end summaries
+	budgetSummaryHour  = 8                // Summaries are queued at 08:00 on the 1st
+	budgetRoundingStep = 10000            // Suggestions are rounded up to Rp 10.000
+)
+
+// BudgetAgent keeps monthly budgets per expense category and tracks every
+// recorded expense against its budget
+type BudgetAgent struct {
+	db           *database.SupabaseClient
+	notification *NotificationAgent
+}
+
+func NewBudgetAgent(db *database.SupabaseClient, notification *NotificationAgent) *BudgetAgent {
+	return &BudgetAgent{db: db, notification: notification}
+}
+
+// BudgetStatus is what a category spent against its budget in a month
+type BudgetStatus struct {
+	Category ExpenseCategory
+	Budget   float64
+	Spent    float64
+}
+
+// Percent is the share of the budget spent
+func (s BudgetStatus) Percent() float64 {
+	if s.Budget <= 0 {
+		return 0
+	}
+	return s.Spent / s.Budget * 100
+}
+
+// Remaining is what is left of the budget, negative when overspent
+func (s BudgetStatus) Remaining() float64 {
+	return s.Budget - s.Spent
+}
+
+// alertLevel is the highest alert level the spending reached, 0 for none
+func (s BudgetStatus) alertLevel() int {
+	switch {
+	case s.Budget <= 0:
+		return 0
+	case s.Spent >= s.Budget:
+		return budgetOverLevel
+	case s.Spent >= s.Budget*budgetWarnLevel/100:
+		return budgetWarnLevel
+	default:
+		return 0
+	}
+}
+
+// SetBudget sets the monthly budget of a category. A changed budget can
+// alert again this month.
+func (b *BudgetAgent) SetBudget(ctx context.Context, userID string, category ExpenseCategory, amount float64) (*database.ExpenseBudget, error) {
+	if b.db == nil {
+		return nil, fmt.Errorf("database not configured")
+	}
+	if amount <= 0 {
+		return nil, fmt.Errorf("budget must be more than 0")
+	}
+
+	budget, err := b.db.GetExpenseBudget(ctx, userID, string(category))
+	if err != nil {
+		return nil, err
+	}
+	if budget != nil {
+		updates := map[string]any{
+			"monthly_amount": amount,
+			"alert_level":    0,
+			"updated_at":     time.Now().UTC().Format(time.RFC3339),
+		}
+		if err := b.db.UpdateExpenseBudget(ctx, budget.ID, updates); err != nil {
+			return nil, err
+		}
+		budget.MonthlyAmount, budget.AlertLevel = amount, 0
+	} else {
+		budget = &database.ExpenseBudget{UserID: userID, Category: string(category), MonthlyAmount: amount}
+		if err := b.db.CreateExpenseBudget(ctx, budget); err != nil {
+			return nil, err
+		}
+	}
+	log.Printf("🎯 Budget set: %s Rp %.0f for user %s", category, amount, userID)
+	return budget, nil
+}
+
+// MonthStatus returns spending against every budget in the month of now,
+// which is in the user's time zone
+func (b *BudgetAgent) MonthStatus(ctx context.Context, userID string, now time.Time) ([]BudgetStatus, error) {
+	if b.db == nil {
+		return nil, fmt.Errorf("database not configured")
+	}
+	budgets, err := b.db.GetExpenseBudgets(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(budgets) == 0 {
+		return nil, nil
+	}
+	start := monthStart(now)
+	spent, err := b.spending(ctx, userID, start, start.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+	return budgetStatuses(budgets, spent), nil
+}
+
+// Track checks a recorded expense against its category's budget and returns
+// an alert the first time this month spending reaches 80% or 100%, else ""
+func (b *BudgetAgent) Track(ctx context.Context, tx *database.Transaction, now time.Time) string {
+	if b == nil || b.db == nil || tx == nil || tx.Type != "EXPENSE" {
+		return ""
+	}
+	category := TransactionCategory(tx)
+	budget, err := b.db.GetExpenseBudget(ctx, tx.UserID, string(category))
+	if err != nil {
+		log.Printf("⚠️ Failed to get budget: %v", err)
+		return ""
+	}
+	if budget == nil {
+		return ""
+	}
+
+	start := monthStart(now)
+	spent, err := b.spending(ctx, tx.UserID, start, start.AddDate(0, 1, 0))
+	if err != nil {
+		log.Printf("⚠️ Failed to get spending: %v", err)
+		return ""
+	}
+	status := BudgetStatus{Category: category, Budget: budget.MonthlyAmount, Spent: spent[category]}
+
+	month := now.Format("2006-01")
+	alerted := budget.AlertLevel
+	if budget.AlertMonth != month {
+		alerted = 0
+	}
+	level := status.alertLevel()
+	if level <= alerted {
+		return ""
+	}
+	if err := b.db.UpdateExpenseBudget(ctx, budget.ID, map[string]any{"alert_month": month, "alert_level": level}); err != nil {
+		log.Printf("⚠️ Failed to record budget alert: %v", err)
+	}
+	log.Printf("🚨 Budget alert: %s %.0f%% for user %s", category, status.Percent(), tx.UserID)
+	return formatBudgetAlert(status)
+}
+
+// Suggest returns a budget per category from the average spending of the
+// last full months, 10% above it
+func (b *BudgetAgent) Suggest(ctx context.Context, userID string, now time.Time) (map[ExpenseCategory]float64, error) {
+	if b.db == nil {
+		return nil, fmt.Errorf("database not configured")
+	}
+	end := monthStart(now)
+	spent, err := b.spending(ctx, userID, end.AddDate(0, -budgetSuggestSpan, 0), end)
+	if err != nil {
+		return nil, err
+	}
+	return suggestBudgets(spent, budgetSuggestSpan), nil
+}
+
+// ApplySuggestions sets the suggested budget of every category that has
+// none yet and returns what was set
+func (b *BudgetAgent) ApplySuggestions(ctx context.Context, userID string, now time.Time) ([]BudgetStatus, error) {
+	suggestions, err := b.Suggest(ctx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	budgets, err := b.db.GetExpenseBudgets(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[ExpenseCategory]bool, len(budgets))
+	for _, budget := range budgets {
+		existing[ExpenseCategory(budget.Category)] = true
+	}
+
+	var set []BudgetStatus
+	for _, category := range ExpenseCategories {
+		amount, ok := suggestions[category]
+		if !ok || existing[category] {
+			continue
+		}
+		if _, err := b.SetBudget(ctx, userID, category, amount); err != nil {
+			return set, err
+		}
+		set = append(set, BudgetStatus{Category: category, Budget: amount})
+	}
+	return set, nil
+}
+
+// QueueMonthEndSummaries queues a budget-versus-actual summary of the month
+// before now for every user with budgets
+func (b *BudgetAgent) QueueMonthEndSummaries(ctx context.Context, now time.Time) (int, error) {
+	if b.db == nil {
+		return 0, fmt.Errorf("database not configured")
+	}
+	budgets, err := b.db.GetAllExpenseBudgets(ctx)
+	if err != nil {
+		return 0, err
+	}
+	byUser := map[string][]database.ExpenseBudget{}
+	var users []string
+	for _, budget := range budgets {
+		if _, ok := byUser[budget.UserID]; !ok {
+			users = append(users, budget.UserID)
+		}
+		byUser[budget.UserID] = append(byUser[budget.UserID], budget)
+	}
+
+	end := monthStart(now)
+	start := end.AddDate(0, -1, 0)
+	queued := 0
+	for _, userID := range users {
+		spent, err := b.spending(ctx, userID, start, end)
+		if err != nil {
+			log.Printf("⚠️ Failed to get spending of user %s: %v", userID, err)
+			continue
+		}
+		statuses := budgetStatuses(byUser[userID], spent)
+		title := "📊 Ringkasan Budget " + formatMonth(start)
+		if err := b.notification.QueueNotification(ctx, userID, budgetSummaryType, title,
+			formatBudgetSummary(statuses, start), "whatsapp"); err != nil {
+			continue
+		}
+		queued++
+	}
+	return queued, nil
+}
+
+// RunMonthEnd queues the month-end summaries on the 1st of every month at
+// 08:00 until ctx is cancelled
+func (b *BudgetAgent) RunMonthEnd(ctx context.Context) {
+	loc := ai.UserLocation("")
+	for {
+		now := time.Now().In(loc)
+		next := time.Date(now.Year(), now.Month(), 1, budgetSummaryHour, 0, 0, 0, loc)
+		if !next.After(now) {
+			next = next.AddDate(0, 1, 0)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(next.Sub(now)):
+			queued, err := b.QueueMonthEndSummaries(ctx, time.Now().In(loc))
+			if err != nil {
+				log.Printf("❌ Budget summaries failed: %v", err)
+				continue
+			}
+			log.Printf("📬 Budget summaries queued: %d", queued)
+		}
+	}
+}
+
+// spending sums expenses per category from start up to end
+func (b *BudgetAgent) spending(ctx context.Context, userID string, start, end time.Time) (map[ExpenseCategory]float64, error) {
+	const layout = "2006-01-02T15:04:05Z"
+	transactions, err := b.db.GetTransactionsByDateRange(ctx, userID,
+		start.UTC().Format(layout), end.Add(-time.Second).UTC().Format(layout))
+	if err != nil {
+		return nil, err
+	}
+	return spendingByCategory(transactions), nil
+}
+
+// spendingByCategory sums expenses per category
+func spendingByCategory(transactions []database.Transaction) map[ExpenseCategory]float64 {
+	spent := map[ExpenseCategory]float64{}
+	for i := range transactions {
+		if transactions[i].Type == "EXPENSE" {
+			spent[TransactionCategory(&transactions[i])] += transactions[i].TotalAmount
+		}
+	}
+	return spent
+}
+
+// suggestBudgets averages spending over months, adds the buffer and rounds
+// up so the suggestion reads well in chat
+func suggestBudgets(spent map[ExpenseCategory]float64, months int) map[ExpenseCategory]float64 {
+	suggestions := map[ExpenseCategory]float64{}
+	for category, total := range spent {
+		if total <= 0 {
+			continue
+		}
+		average := total / float64(months) * budgetBuffer
+		suggestions[category] = math.Ceil(average/budgetRoundingStep) * budgetRoundingStep
+	}
+	return suggestions
+}
+
+// budgetStatuses pairs budgets with spending, in category order
+func budgetStatuses(budgets []database.ExpenseBudget, spent map[ExpenseCategory]float64) []BudgetStatus {
+	statuses := make([]BudgetStatus, 0, len(budgets))
+	for _, budget := range budgets {
+		category := ExpenseCategory(budget.Category)
+		statuses = append(statuses, BudgetStatus{Category: category, Budget: budget.MonthlyAmount, Spent: spent[category]})
+	}
+	sort.SliceStable(statuses, func(i, j int) bool {
+		return categoryOrder(statuses[i].Category) < categoryOrder(statuses[j].Category)
+	})
+	return statuses
+}
+
+func categoryOrder(category ExpenseCategory) int {
+	for i, c := range ExpenseCategories {
+		if c == category {
+			return i
+		}
+	}
+	return len(ExpenseCategories)
+}
+
+// formatMonth formats the month of t, e.g. "Oktober 2026"
+func formatMonth(t time.Time) string {
+	return strings.SplitN(ai.FormatDate(t), " ", 2)[1]
+}
+
+func monthStart(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+}
+
+func formatBudgetAlert(s BudgetStatus) string {
+	category := FormatCategoryInfo(s.Category)
+	if s.alertLevel() >= budgetOverLevel {
+		return fmt.Sprintf("🚨 Budget %s bulan ini sudah habis!\n"+
+			"Terpakai Rp %s dari Rp %s (%.0f%%), lebih Rp %s.",
+			category, formatCurrency(s.Spent), formatCurrency(s.Budget), s.Percent(), formatCurrency(-s.Remaining()))
+	}
+	return fmt.Sprintf("⚠️ Budget %s bulan ini sudah terpakai %.0f%%.\n"+
+		"Terpakai Rp %s dari Rp %s, sisa Rp %s.",
+		category, s.Percent(), formatCurrency(s.Spent), formatCurrency(s.Budget), formatCurrency(s.Remaining()))
+}
+
+// formatBudgetLine is one category of a status or summary
+func formatBudgetLine(s BudgetStatus) string {
+	mark := "✅"
+	switch s.alertLevel() {
+	case budgetOverLevel:
+		mark = "🚨"
+	case budgetWarnLevel:
+		mark = "⚠️"
+	}
+	return fmt.Sprintf("%s %s\n   Rp %s / Rp %s (%.0f%%)\n",
+		mark, FormatCategoryInfo(s.Category), formatCurrency(s.Spent), formatCurrency(s.Budget), s.Percent())
+}
+
+func formatBudgetStatus(statuses []BudgetStatus, now time.Time) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🎯 Budget %s\n\n", formatMonth(now)))
+	budget, spent := 0.0, 0.0
+	for _, s := range statuses {
+		sb.WriteString(formatBudgetLine(s))
+		budget += s.Budget
+		spent += s.Spent
+	}
+	sb.WriteString(fmt.Sprintf("\n💵 Total: Rp %s dari Rp %s", formatCurrency(spent), formatCurrency(budget)))
+	return sb.String()
+}
+
+func formatBudgetSummary(statuses []BudgetStatus, month time.Time) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Budget vs realisasi %s:\n\n", formatMonth(month)))
+	budget, spent, over := 0.0, 0.0, 0
+	for _, s := range statuses {
+		sb.WriteString(formatBudgetLine(s))
+		budget += s.Budget
+		spent += s.Spent
+		if s.Spent > s.Budget {
+			over++
+		}
+	}
+	sb.WriteString(fmt.Sprintf("\n💵 Total: Rp %s dari Rp %s\n", formatCurrency(spent), formatCurrency(budget)))
+	if over > 0 {
+		sb.WriteString(fmt.Sprintf("\n🚨 %d kategori melebihi budget. Ketik \"saran budget\" untuk menyesuaikan.", over))
+	} else {
+		sb.WriteString("\n🎉 Semua pengeluaran sesuai budget. Mantap!")
+	}
+	return sb.String()
+}
+
+func formatBudgetSuggestions(suggestions map[ExpenseCategory]float64) string {
+	var sb strings.Builder
+	sb.WriteString("💡 Saran budget bulanan (rata-rata 3 bulan terakhir + 10%):\n\n")
+	for _, category := range ExpenseCategories {
+		if amount, ok := suggestions[category]; ok {
+			sb.WriteString(fmt.Sprintf("%s: Rp %s\n", FormatCategoryInfo(category), formatCurrency(amount)))
+		}
+	}
+	sb.WriteString("\nPakai saran ini, atau atur sendiri, misalnya \"budget gas 200 ribu sebulan\".")
+	return sb.String()
+}
@@ -0,0 +1,89 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pasarsuara/backend/internal/ai"
+)
+
+// selectionKindBudget is the kind of the "use the suggested budgets" button
+const selectionKindBudget = "budget"
+
+// handleSetBudget sets a monthly budget, e.g. "budget gas 200 ribu sebulan".
+// Budgets are per category, so "gas" sets the Operasional budget.
+func (o *AgentOrchestrator) handleSetBudget(ctx context.Context, userID string, intent *ai.Intent) string {
+	category := ParseExpenseCategory(getStringEntity(intent.Entities, "category"))
+	amount := getFloatEntity(intent.Entities, "amount")
+
+	if _, err := o.budget.SetBudget(ctx, userID, category, amount); err != nil {
+		return "Gagal menyimpan budget: " + err.Error()
+	}
+
+	msg := fmt.Sprintf("🎯 Budget %s: Rp %s sebulan\n", FormatCategoryInfo(category), formatCurrency(amount))
+	if named := getStringEntity(intent.Entities, "category"); named != "" && !isCategoryName(named) {
+		msg += fmt.Sprintf("(%s termasuk kategori %s)\n", named, GetCategoryName(category))
+	}
+
+	now := time.Now().In(o.userLocation(ctx, userID))
+	if statuses, err := o.budget.MonthStatus(ctx, userID, now); err == nil {
+		for _, s := range statuses {
+			if s.Category == category {
+				msg += fmt.Sprintf("\nBulan ini sudah terpakai Rp %s (%.0f%%).", formatCurrency(s.Spent), s.Percent())
+			}
+		}
+	}
+	return msg + "\n\nKami kabari kalau pengeluarannya sudah 80% dan 100% dari budget."
+}
+
+// handleAskBudget shows this month's budgets against spending, and suggests
+// budgets from past spending when there are none or the user asks for them
+func (o *AgentOrchestrator) handleAskBudget(ctx context.Context, userID string, intent *ai.Intent, response *AgentResponse) {
+	now := time.Now().In(o.userLocation(ctx, userID))
+	statuses, err := o.budget.MonthStatus(ctx, userID, now)
+	if err != nil {
+		response.Success = false
+		response.Message = "Maaf, budget belum bisa diambil. Coba lagi nanti ya!"
+		return
+	}
+	if len(statuses) > 0 && intent.Entities["suggest"] != true {
+		response.Message = formatBudgetStatus(statuses, now)
+		return
+	}
+
+	suggestions, err := o.budget.Suggest(ctx, userID, now)
+	if err != nil || len(suggestions) == 0 {
+		response.Message = "🎯 Belum ada budget pengeluaran.\n\n" +
+			"Atur budget per kategori, misalnya \"budget gas 200 ribu sebulan\" atau \"budget bahan baku 3 juta\"."
+		return
+	}
+	response.Message = formatBudgetSuggestions(suggestions)
+	if len(statuses) > 0 {
+		response.Message = formatBudgetStatus(statuses, now) + "\n\n" + response.Message
+	}
+	response.Replies = []Reply{ButtonsReply(response.Message, []ReplyButton{
+		{ID: SelectionID(selectionKindBudget, "suggest", "apply"), Title: "✅ Pakai saran"},
+	})}
+}
+
+// applyBudgetSuggestions sets the suggested budgets the user accepted
+func (o *AgentOrchestrator) applyBudgetSuggestions(ctx context.Context, userPhone string) *AgentResponse {
+	userID := o.getUserID(ctx, userPhone)
+	set, err := o.budget.ApplySuggestions(ctx, userID, time.Now().In(o.userLocation(ctx, userID)))
+	if err != nil {
+		return &AgentResponse{Success: false, Message: "Gagal menyimpan budget: " + err.Error()}
+	}
+	if len(set) == 0 {
+		return &AgentResponse{Success: true, Message: "👍 Semua kategori sudah punya budget. Ketik \"budget bulan ini\" untuk melihatnya."}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("✅ Budget bulanan tersimpan:\n\n")
+	for _, s := range set {
+		sb.WriteString(fmt.Sprintf("%s: Rp %s\n", FormatCategoryInfo(s.Category), formatCurrency(s.Budget)))
+	}
+	sb.WriteString("\nKami kabari kalau pengeluarannya sudah 80% dan 100% dari budget.")
+	return &AgentResponse{Success: true, Message: sb.String()}
+}
@@ -0,0 +1,96 @@
+package agents
+
+import (
+	"testing"
+
+	"github.com/pasarsuara/backend/internal/database"
+)
+
+func TestBudgetAlertLevel(t *testing.T) {
+	tests := []struct {
+		name  string
+		spent float64
+		want  int
+	}{
+		{"under", 150000, 0},
+		{"at 80%", 160000, budgetWarnLevel},
+		{"almost all", 199000, budgetWarnLevel},
+		{"all", 200000, budgetOverLevel},
+		{"over", 250000, budgetOverLevel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := BudgetStatus{Category: CategoryOperasional, Budget: 200000, Spent: tt.spent}
+			if got := s.alertLevel(); got != tt.want {
+				t.Errorf("alertLevel() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+	if got := (BudgetStatus{Spent: 1000}).alertLevel(); got != 0 {
+		t.Errorf("alertLevel() without budget = %d, want 0", got)
+	}
+}
+
+func TestSpendingByCategory(t *testing.T) {
+	spent := spendingByCategory([]database.Transaction{
+		{Type: "EXPENSE", ProductName: "gas", TotalAmount: 22000, ExpenseCategory: string(CategoryOperasional)},
+		{Type: "EXPENSE", ProductName: "listrik", TotalAmount: 300000}, // recorded before categories were kept
+		{Type: "EXPENSE", ProductName: "bensin", TotalAmount: 20000},
+		{Type: "EXPENSE", ProductName: "beras", TotalAmount: 50000, ExpenseCategory: string(CategoryLainnya)},
+		{Type: "SALE", ProductName: "gas", TotalAmount: 25000},
+	})
+	want := map[ExpenseCategory]float64{
+		CategoryOperasional:  322000,
+		CategoryTransportasi: 20000,
+		CategoryLainnya:      50000,
+	}
+	if len(spent) != len(want) {
+		t.Fatalf("spendingByCategory() = %v, want %v", spent, want)
+	}
+	for category, amount := range want {
+		if spent[category] != amount {
+			t.Errorf("spent[%s] = %.0f, want %.0f", category, spent[category], amount)
+		}
+	}
+}
+
+func TestSuggestBudgets(t *testing.T) {
+	got := suggestBudgets(map[ExpenseCategory]float64{
+		CategoryOperasional:  900000, // 300.000 a month + 10%
+		CategoryTransportasi: 100000, // 33.333 a month + 10% rounds up
+		CategoryPemasaran:    0,
+	}, 3)
+	want := map[ExpenseCategory]float64{
+		CategoryOperasional:  330000,
+		CategoryTransportasi: 40000,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("suggestBudgets() = %v, want %v", got, want)
+	}
+	for category, amount := range want {
+		if got[category] != amount {
+			t.Errorf("suggestion[%s] = %.0f, want %.0f", category, got[category], amount)
+		}
+	}
+}
+
+func TestParseExpenseCategory(t *testing.T) {
+	tests := []struct {
+		text string
+		want ExpenseCategory
+	}{
+		{"bahan baku", CategoryBahanBaku},
+		{"Transport", CategoryTransportasi},
+		{"Gaji & Upah", CategoryGaji},
+		{"OPERASIONAL", CategoryOperasional},
+		{"gas", CategoryOperasional},
+		{"iklan", CategoryPemasaran},
+		{"parkir", CategoryOperasional}, // in two categories, always the first
+		{"sumbangan", CategoryLainnya},
+	}
+	for _, tt := range tests {
+		if got := ParseExpenseCategory(tt.text); got != tt.want {
+			t.Errorf("ParseExpenseCategory(%q) = %s, want %s", tt.text, got, tt.want)
+		}
+	}
+}
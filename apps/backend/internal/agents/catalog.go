@@ -6,32 +6,28 @@ import (
 	"log"
 	"strings"
 
+	"github.com/pasarsuara/backend/internal/ai"
 	"github.com/pasarsuara/backend/internal/database"
 )
 
 // CatalogAgent handles product catalog management
 type CatalogAgent struct {
-	db *database.SupabaseClient
+	db  *database.SupabaseClient
+	llm ai.Provider
 }
 
-func NewCatalogAgent(db *database.SupabaseClient) *CatalogAgent {
-	return &CatalogAgent{db: db}
+func NewCatalogAgent(db *database.SupabaseClient, llm ai.Provider) *CatalogAgent {
+	return &CatalogAgent{db: db, llm: llm}
 }
 
 // AddProduct adds a new product to catalog
-func (c *CatalogAgent) AddProduct(ctx context.Context, userID, productName, category string, price float64, unit string) (*database.ProductCatalog, error) {
+func (c *CatalogAgent) AddProduct(ctx context.Context, userID string, product *database.ProductCatalog) (*database.ProductCatalog, error) {
 	if c.db == nil {
 		return nil, fmt.Errorf("database not configured")
 	}
 
-	product := &database.ProductCatalog{
-		UserID:       userID,
-		ProductName:  productName,
-		Category:     category,
-		DefaultPrice: price,
-		DefaultUnit:  unit,
-		IsActive:     true,
-	}
+	product.UserID = userID
+	product.IsActive = true
 
 	err := c.db.CreateProductCatalog(ctx, product)
 	if err != nil {
@@ -39,7 +35,7 @@ func (c *CatalogAgent) AddProduct(ctx context.Context, userID, productName, cate
 		return nil, err
 	}
 
-	log.Printf("✅ Product added to catalog: %s (Rp %.0f/%s)", productName, price, unit)
+	log.Printf("✅ Product added to catalog: %s (Rp %.0f/%s)", product.ProductName, product.DefaultPrice, product.DefaultUnit)
 
 	// Create audit log
 	auditLog := &database.AuditLog{
@@ -56,6 +52,22 @@ func (c *CatalogAgent) AddProduct(ctx context.Context, userID, productName, cate
 	return product, nil
 }
 
+// DraftFromPhoto proposes a catalog entry from a product photo and its
+// caption, reading the caption alone when no vision model answers
+func (c *CatalogAgent) DraftFromPhoto(ctx context.Context, image []byte, mimeType, caption string) (*ai.ProductDraft, error) {
+	draft, err := ai.DraftProduct(ctx, c.llm, image, mimeType, caption)
+	if err == nil {
+		return draft, nil
+	}
+	log.Printf("⚠️ Vision draft failed, reading caption only: %v", err)
+
+	draft = ai.CaptionProduct(caption)
+	if draft.ProductName == "" {
+		return nil, err
+	}
+	return draft, nil
+}
+
 // GetProducts gets all products from catalog
 func (c *CatalogAgent) GetProducts(ctx context.Context, userID string, activeOnly bool) ([]database.ProductCatalog, error) {
 	if c.db == nil {
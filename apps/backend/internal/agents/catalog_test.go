@@ -7,7 +7,7 @@ import (
 )
 
 func TestFormatProductList(t *testing.T) {
-	agent := NewCatalogAgent(nil)
+	agent := NewCatalogAgent(nil, nil)
 
 	// Test empty catalog
 	empty := agent.FormatProductList([]database.ProductCatalog{})
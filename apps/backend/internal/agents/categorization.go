@@ -3,6 +3,8 @@ package agents
 import (
 	"fmt"
 	"strings"
+
+	"github.com/pasarsuara/backend/internal/database"
 )
 
 // ExpenseCategory represents expense categories
@@ -17,6 +19,12 @@ const (
 	CategoryLainnya      ExpenseCategory = "LAINNYA"
 )
 
+// ExpenseCategories lists the categories in the order keywords are matched,
+// so a keyword in two categories ("parkir") always lands in the same one
+var ExpenseCategories = []ExpenseCategory{
+	CategoryBahanBaku, CategoryOperasional, CategoryGaji, CategoryTransportasi, CategoryPemasaran, CategoryLainnya,
+}
+
 // CategoryKeywords maps categories to their keywords
 var CategoryKeywords = map[ExpenseCategory][]string{
 	CategoryBahanBaku: {
@@ -55,8 +63,8 @@ func CategorizeExpense(productName string) ExpenseCategory {
 	normalized := strings.ToLower(strings.TrimSpace(productName))
 
 	// Check each category
-	for category, keywords := range CategoryKeywords {
-		for _, keyword := range keywords {
+	for _, category := range ExpenseCategories {
+		for _, keyword := range CategoryKeywords[category] {
 			if strings.Contains(normalized, keyword) {
 				return category
 			}
@@ -67,6 +75,53 @@ func CategorizeExpense(productName string) ExpenseCategory {
 	return CategoryLainnya
 }
 
+// TransactionCategory returns the category an expense was recorded under,
+// categorizing older expenses recorded before categories were kept
+func TransactionCategory(tx *database.Transaction) ExpenseCategory {
+	if tx.ExpenseCategory != "" {
+		return ExpenseCategory(tx.ExpenseCategory)
+	}
+	return CategorizeExpense(tx.ProductName)
+}
+
+// categoryNames are words users call a category by, e.g. "budget bahan baku"
+var categoryNames = map[string]ExpenseCategory{
+	"bahan baku": CategoryBahanBaku, "bahan": CategoryBahanBaku, "operasional": CategoryOperasional,
+	"gaji": CategoryGaji, "karyawan": CategoryGaji, "pegawai": CategoryGaji,
+	"transportasi": CategoryTransportasi, "transport": CategoryTransportasi,
+	"pemasaran": CategoryPemasaran, "marketing": CategoryPemasaran,
+	"lainnya": CategoryLainnya, "lain": CategoryLainnya, "lain lain": CategoryLainnya,
+}
+
+// categoryByName returns the category text names, e.g. "bahan baku" or "Gaji & Upah"
+func categoryByName(text string) (ExpenseCategory, bool) {
+	normalized := strings.ToLower(strings.TrimSpace(text))
+	if category, ok := categoryNames[normalized]; ok {
+		return category, true
+	}
+	for _, category := range ExpenseCategories {
+		if strings.EqualFold(normalized, string(category)) || strings.EqualFold(normalized, GetCategoryName(category)) {
+			return category, true
+		}
+	}
+	return "", false
+}
+
+// isCategoryName reports whether text names a category rather than an expense
+func isCategoryName(text string) bool {
+	_, ok := categoryByName(text)
+	return ok
+}
+
+// ParseExpenseCategory resolves what a user calls a category: its name
+// ("bahan baku", "transport") or an expense in it ("gas" is OPERASIONAL)
+func ParseExpenseCategory(text string) ExpenseCategory {
+	if category, ok := categoryByName(text); ok {
+		return category
+	}
+	return CategorizeExpense(text)
+}
+
 // GetCategoryName returns Indonesian name for category
 func GetCategoryName(category ExpenseCategory) string {
 	names := map[ExpenseCategory]string{
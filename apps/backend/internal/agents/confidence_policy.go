@@ -0,0 +1,320 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pasarsuara/backend/internal/ai"
+)
+
+// PendingEntityCheck is an intent held back until the user confirms a low-confidence entity
+const PendingEntityCheck = "ENTITY_CHECK"
+
+// selectionKindPick marks button ids that pick one of several entity readings
+const selectionKindPick = "pick"
+
+// Confidence policy decisions
+const (
+	DecisionCommit  = "commit"  // Record without asking
+	DecisionConfirm = "confirm" // Ask about the least certain entity first
+	DecisionReject  = "reject"  // Too unsure, ask the user to repeat
+)
+
+// ConfidenceRule sets the thresholds for one intent from a transaction amount up
+type ConfidenceRule struct {
+	Intent    string  `json:"intent"`     // "*" matches every committing intent
+	MinAmount float64 `json:"min_amount"` // Rp, rule applies at or above this total
+	Commit    float64 `json:"commit"`     // Lowest confidence recorded without asking
+	Reject    float64 `json:"reject"`     // Below this the user is asked to repeat
+}
+
+// ConfidencePolicy decides when an extracted transaction may be recorded.
+// The most specific rule wins: an exact intent beats "*", then the highest
+// MinAmount not above the transaction total.
+type ConfidencePolicy struct {
+	Rules []ConfidenceRule `json:"rules"`
+}
+
+// ConfidenceDecision is the policy outcome for one intent
+type ConfidenceDecision struct {
+	Decision   string
+	Field      string  // Least certain entity, empty when the action itself is unsure
+	Confidence float64 // Confidence of Field
+	Options    []any   // Readings to offer, the extracted value first
+}
+
+// policyFields are the entities checked per committing intent
+var policyFields = map[string][]string{
+	"RECORD_SALE":    {"product", "qty", "price"},
+	"RECORD_EXPENSE": {"product", "price"},
+	"ORDER_RESTOCK":  {"product", "qty", "max_price"},
+}
+
+// DefaultConfidencePolicy is stricter for large amounts and purchases. An
+// exact intent rule beats "*" at any amount, so purchases repeat the large
+// amount threshold.
+func DefaultConfidencePolicy() *ConfidencePolicy {
+	return &ConfidencePolicy{Rules: []ConfidenceRule{
+		{Intent: "*", Commit: 0.75, Reject: 0.3},
+		{Intent: "*", MinAmount: 500000, Commit: 0.85, Reject: 0.4},
+		{Intent: "ORDER_RESTOCK", Commit: 0.8, Reject: 0.35},
+		{Intent: "ORDER_RESTOCK", MinAmount: 500000, Commit: 0.85, Reject: 0.4},
+	}}
+}
+
+// ParseConfidencePolicy reads a policy from JSON
+func ParseConfidencePolicy(data []byte) (*ConfidencePolicy, error) {
+	var policy ConfidencePolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("invalid confidence policy: %w", err)
+	}
+	if len(policy.Rules) == 0 {
+		return nil, fmt.Errorf("confidence policy has no rules")
+	}
+	for _, rule := range policy.Rules {
+		if rule.Intent == "" || rule.Reject > rule.Commit || rule.Commit > 1 {
+			return nil, fmt.Errorf("invalid confidence rule: %+v", rule)
+		}
+	}
+	return &policy, nil
+}
+
+// LoadConfidencePolicy reads a policy file
+func LoadConfidencePolicy(path string) (*ConfidencePolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfidencePolicy(data)
+}
+
+// rule picks the most specific rule for an intent and amount
+func (p *ConfidencePolicy) rule(action string, amount float64) (ConfidenceRule, bool) {
+	var best ConfidenceRule
+	found := false
+	for _, rule := range p.Rules {
+		if (rule.Intent != action && rule.Intent != "*") || amount < rule.MinAmount {
+			continue
+		}
+		if !found ||
+			(rule.Intent != "*" && best.Intent == "*") ||
+			(rule.Intent == best.Intent && rule.MinAmount > best.MinAmount) {
+			best = rule
+			found = true
+		}
+	}
+	return best, found
+}
+
+// Evaluate decides whether intent can be recorded as extracted
+func (p *ConfidencePolicy) Evaluate(intent *ai.Intent) *ConfidenceDecision {
+	fields, ok := policyFields[intent.Action]
+	if !ok {
+		return &ConfidenceDecision{Decision: DecisionCommit}
+	}
+	rule, ok := p.rule(intent.Action, intentAmount(intent))
+	if !ok {
+		return &ConfidenceDecision{Decision: DecisionCommit}
+	}
+
+	if intent.Confidence > 0 && intent.Confidence < rule.Reject {
+		return &ConfidenceDecision{Decision: DecisionReject, Confidence: intent.Confidence}
+	}
+
+	decision := &ConfidenceDecision{Decision: DecisionCommit, Confidence: 1}
+	for _, field := range fields {
+		value, ok := intent.Entities[field]
+		if !ok || value == nil || value == "" {
+			continue
+		}
+		if confidence := intent.FieldConfidence(field); confidence < decision.Confidence {
+			decision.Field = field
+			decision.Confidence = confidence
+		}
+	}
+
+	switch {
+	case decision.Confidence >= rule.Commit:
+		return decision
+	case decision.Confidence < rule.Reject:
+		decision.Decision = DecisionReject
+	default:
+		decision.Decision = DecisionConfirm
+		decision.Options = entityOptions(intent, decision.Field)
+	}
+	return decision
+}
+
+// intentAmount is the transaction total used to pick a rule
+func intentAmount(intent *ai.Intent) float64 {
+	qty := getFloatEntity(intent.Entities, "qty")
+	if qty == 0 {
+		qty = 1
+	}
+	switch intent.Action {
+	case "RECORD_SALE":
+		return qty * getFloatEntity(intent.Entities, "price")
+	case "ORDER_RESTOCK":
+		return qty * getFloatEntity(intent.Entities, "max_price")
+	default:
+		return getFloatEntity(intent.Entities, "price")
+	}
+}
+
+// entityOptions lists the extracted value and its alternatives without duplicates
+func entityOptions(intent *ai.Intent, field string) []any {
+	options := []any{intent.Entities[field]}
+	seen := map[string]bool{fmt.Sprint(intent.Entities[field]): true}
+	for _, alt := range intent.Alternatives[field] {
+		key := fmt.Sprint(alt)
+		if alt == nil || seen[key] || len(options) >= MaxReplyButtons {
+			continue
+		}
+		seen[key] = true
+		options = append(options, alt)
+	}
+	return options
+}
+
+// SetConfidencePolicy replaces the default confirm-before-commit policy
+func (o *AgentOrchestrator) SetConfidencePolicy(policy *ConfidencePolicy) {
+	o.policy = policy
+}
+
+func (o *AgentOrchestrator) confidencePolicy() *ConfidencePolicy {
+	if o.policy == nil {
+		return DefaultConfidencePolicy()
+	}
+	return o.policy
+}
+
+// handleLowConfidence asks the user to repeat or to confirm the least certain entity
+func (o *AgentOrchestrator) handleLowConfidence(ctx context.Context, userPhone string, intent *ai.Intent, decision *ConfidenceDecision) *AgentResponse {
+	if decision.Decision == DecisionReject {
+		log.Printf("🙉 Intent %s rejected, confidence %.2f", intent.Action, decision.Confidence)
+		return &AgentResponse{
+			Success: false,
+			Intent:  intent,
+			Message: "🙉 Maaf, saya kurang yakin menangkap pesannya. Bisa diulang lebih jelas? " +
+				"Contoh: \"laku nasi goreng 10 porsi 15rb\"",
+		}
+	}
+
+	log.Printf("🤔 Confirming %s of %s, confidence %.2f", decision.Field, intent.Action, decision.Confidence)
+	action := o.pending.Create(userPhone, PendingEntityCheck, intent, nil, IdempotencyKeyFromContext(ctx))
+	action.Field = decision.Field
+
+	subject := "pesannya"
+	if label, ok := entityCheckLabels[decision.Field]; ok {
+		subject = label
+	}
+	unit := ""
+	if decision.Field == "qty" {
+		unit = getStringEntity(intent.Entities, "unit")
+	}
+
+	var buttons []ReplyButton
+	var question string
+	if len(decision.Options) > 1 {
+		labels := make([]string, len(decision.Options))
+		for i, option := range decision.Options {
+			labels[i] = formatEntityValue(decision.Field, option, "")
+			action.Options = append(action.Options, entityOptionValue(option))
+			buttons = append(buttons, ReplyButton{
+				ID:    SelectionID(selectionKindPick, action.ID, entityOptionValue(option)),
+				Title: formatEntityValue(decision.Field, option, unit),
+			})
+		}
+		question = fmt.Sprintf("🤔 Maaf, saya kurang yakin dengan %s. %s?",
+			subject, strings.TrimSpace(strings.Join(labels, " atau ")+" "+unit))
+	} else {
+		question = fmt.Sprintf("🤔 Maaf, saya kurang yakin dengan %s. %s, betul?",
+			subject, formatEntityValue(decision.Field, intent.Entities[decision.Field], unit))
+		buttons = []ReplyButton{
+			{ID: SelectionID(selectionKindConfirm, action.ID, "yes"), Title: "✅ Betul"},
+			{ID: SelectionID(selectionKindConfirm, action.ID, "no"), Title: "❌ Batal"},
+		}
+	}
+
+	text := question + "\n\nBalas pilihan atau \"ya\", \"batal\" untuk membatalkan."
+	return &AgentResponse{
+		Success:         true,
+		Intent:          intent,
+		Message:         text,
+		Replies:         []Reply{ButtonsReply(question, buttons)},
+		PendingActionID: action.ID,
+	}
+}
+
+// entityCheckLabels name an entity in a confirmation question
+var entityCheckLabels = map[string]string{
+	"product":   "nama barangnya",
+	"qty":       "jumlahnya",
+	"price":     "harganya",
+	"max_price": "budgetnya",
+}
+
+// formatEntityValue renders a value for the user, e.g. "Rp 15.000" or "15 porsi"
+func formatEntityValue(field string, value any, unit string) string {
+	switch field {
+	case "price", "max_price":
+		if amount, ok := value.(float64); ok {
+			return "Rp " + formatCurrency(amount)
+		}
+	case "qty":
+		if qty, ok := value.(float64); ok {
+			return strings.TrimSpace(strconv.FormatFloat(qty, 'f', -1, 64) + " " + unit)
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+// entityOptionValue is the value carried in a pick selection id
+func entityOptionValue(value any) string {
+	if number, ok := value.(float64); ok {
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// PickEntityValue fills the entity held by a pending check and records the intent
+func (o *AgentOrchestrator) PickEntityValue(ctx context.Context, userPhone, actionID, value string) *AgentResponse {
+	action := o.pending.Get(actionID)
+	if action == nil || action.UserPhone != userPhone || action.Kind != PendingEntityCheck {
+		return &AgentResponse{Success: false, Message: "Tidak ada aksi yang menunggu konfirmasi."}
+	}
+
+	switch action.Field {
+	case "qty", "price", "max_price":
+		var number float64
+		if _, err := fmt.Sscanf(value, "%f", &number); err != nil {
+			return &AgentResponse{Success: false, Message: "Pilihan tidak dikenali, coba lagi ya."}
+		}
+		action.Intent.Entities[action.Field] = number
+	default:
+		action.Intent.Entities[action.Field] = value
+	}
+	return o.ConfirmAction(ctx, userPhone, actionID, true)
+}
+
+// matchEntityOption finds the offered option a typed reply refers to, e.g. "50"
+func matchEntityOption(action *PendingAction, text string) (string, bool) {
+	reply := strings.ToLower(strings.Trim(ai.NormalizeText(strings.TrimSpace(text)), ".,!?~ "))
+	for _, option := range action.Options {
+		if strings.EqualFold(option, reply) {
+			return option, true
+		}
+	}
+	return "", false
+}
+
+// resumeIntent processes an intent whose entities the user just confirmed
+func (o *AgentOrchestrator) resumeIntent(ctx context.Context, userPhone string, intent *ai.Intent) *AgentResponse {
+	intent.ClearConfidence()
+	return o.processIntent(ctx, userPhone, intent)
+}
@@ -0,0 +1,164 @@
+package agents
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pasarsuara/backend/internal/ai"
+)
+
+func TestConfidencePolicy_Evaluate(t *testing.T) {
+	policy := DefaultConfidencePolicy()
+
+	tests := []struct {
+		name     string
+		intent   *ai.Intent
+		decision string
+		field    string
+	}{
+		{
+			name:     "unscored commits",
+			intent:   &ai.Intent{Action: "RECORD_SALE", Entities: map[string]any{"product": "bakso", "qty": 5.0, "price": 15000.0}},
+			decision: DecisionCommit,
+		},
+		{
+			name: "confident commits",
+			intent: &ai.Intent{Action: "RECORD_SALE", Confidence: 0.9,
+				Entities: map[string]any{"product": "bakso", "qty": 5.0, "price": 15000.0}},
+			decision: DecisionCommit,
+		},
+		{
+			name: "unsure qty confirms",
+			intent: &ai.Intent{Action: "RECORD_SALE", Confidence: 0.9, EntityConfidence: map[string]float64{"qty": 0.55},
+				Entities: map[string]any{"product": "nasi rames", "qty": 15.0, "price": 12000.0}},
+			decision: DecisionConfirm,
+			field:    "qty",
+		},
+		{
+			name: "large amount is stricter",
+			intent: &ai.Intent{Action: "RECORD_SALE", Confidence: 0.8,
+				Entities: map[string]any{"product": "beras", "qty": 50.0, "price": 12000.0}},
+			decision: DecisionConfirm,
+			field:    "product",
+		},
+		{
+			name: "low stt confidence",
+			intent: &ai.Intent{Action: "RECORD_EXPENSE", STTConfidence: 0.5,
+				Entities: map[string]any{"product": "gas", "price": 22000.0}},
+			decision: DecisionConfirm,
+			field:    "product",
+		},
+		{
+			name: "very unsure rejects",
+			intent: &ai.Intent{Action: "RECORD_SALE", Confidence: 0.2,
+				Entities: map[string]any{"product": "bakso", "qty": 5.0, "price": 15000.0}},
+			decision: DecisionReject,
+		},
+		{
+			name:     "non transaction commits",
+			intent:   &ai.Intent{Action: "CHECK_STOCK", Confidence: 0.2, Entities: map[string]any{"product": "beras"}},
+			decision: DecisionCommit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := policy.Evaluate(tt.intent)
+			if got.Decision != tt.decision || (tt.field != "" && got.Field != tt.field) {
+				t.Errorf("Evaluate() = %s %s (%.2f), want %s %s", got.Decision, got.Field, got.Confidence, tt.decision, tt.field)
+			}
+		})
+	}
+}
+
+func TestConfidencePolicy_Rule(t *testing.T) {
+	policy, err := ParseConfidencePolicy([]byte(`{"rules":[
+		{"intent":"*","commit":0.7,"reject":0.3},
+		{"intent":"*","min_amount":1000000,"commit":0.9,"reject":0.5},
+		{"intent":"RECORD_EXPENSE","commit":0.6,"reject":0.2}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		action string
+		amount float64
+		commit float64
+	}{
+		{"RECORD_SALE", 50000, 0.7},
+		{"RECORD_SALE", 2000000, 0.9},
+		{"RECORD_EXPENSE", 2000000, 0.6},
+	}
+	for _, tt := range tests {
+		rule, _ := policy.rule(tt.action, tt.amount)
+		if rule.Commit != tt.commit {
+			t.Errorf("rule(%s, %.0f).Commit = %v, want %v", tt.action, tt.amount, rule.Commit, tt.commit)
+		}
+	}
+
+	defaults := DefaultConfidencePolicy()
+	for _, tt := range []struct {
+		action string
+		amount float64
+		commit float64
+	}{
+		{"ORDER_RESTOCK", 100000, 0.8},
+		{"ORDER_RESTOCK", 500000, 0.85},
+		{"RECORD_SALE", 600000, 0.85},
+	} {
+		if rule, _ := defaults.rule(tt.action, tt.amount); rule.Commit != tt.commit {
+			t.Errorf("Default rule(%s, %.0f).Commit = %v, want %v", tt.action, tt.amount, rule.Commit, tt.commit)
+		}
+	}
+
+	for _, bad := range []string{`{"rules":[]}`, `{"rules":[{"intent":"*","commit":0.3,"reject":0.5}]}`, `nope`} {
+		if _, err := ParseConfidencePolicy([]byte(bad)); err == nil {
+			t.Errorf("ParseConfidencePolicy(%s) should fail", bad)
+		}
+	}
+}
+
+func TestProcessIntent_ConfirmUncertainEntity(t *testing.T) {
+	o := &AgentOrchestrator{finance: NewFinanceAgent(nil), pending: NewPendingActionStore(0)}
+	ctx := context.Background()
+
+	intent := &ai.Intent{
+		Action:           "RECORD_SALE",
+		Entities:         map[string]any{"product": "nasi rames", "qty": 15.0, "unit": "porsi", "price": 12000.0},
+		Confidence:       0.9,
+		EntityConfidence: map[string]float64{"qty": 0.55},
+		Alternatives:     map[string][]any{"qty": {50.0}},
+		RawText:          "laku nasi rames limolas porsi rolas ewu",
+	}
+	resp := o.processIntent(ctx, "628111", intent)
+	if resp.PendingActionID == "" || resp.Transaction != nil {
+		t.Fatalf("Expected an entity check, got %+v", resp)
+	}
+	if !strings.Contains(resp.Message, "15 atau 50 porsi?") {
+		t.Errorf("Question = %q", resp.Message)
+	}
+	if buttons := resp.Replies[0].Buttons; len(buttons) != 2 || buttons[1].ID != SelectionID(selectionKindPick, resp.PendingActionID, "50") {
+		t.Errorf("Buttons = %+v", buttons)
+	}
+
+	// Typed answer picks the alternative and records it
+	resp, handled := o.ProcessConfirmationReply(ctx, "628111", "", "seket")
+	if !handled || resp.Transaction == nil || resp.Transaction.Qty != 50 {
+		t.Fatalf("Expected sale of 50, got %+v", resp)
+	}
+}
+
+func TestProcessIntent_RejectUnsure(t *testing.T) {
+	o := &AgentOrchestrator{finance: NewFinanceAgent(nil), pending: NewPendingActionStore(0)}
+
+	resp := o.processIntent(context.Background(), "628111", &ai.Intent{
+		Action:     "RECORD_EXPENSE",
+		Entities:   map[string]any{"product": "gas", "price": 22000.0},
+		Confidence: 0.1,
+	})
+	if resp.Success || resp.Transaction != nil || !strings.Contains(resp.Message, "diulang") {
+		t.Errorf("Expected a repeat request, got %+v", resp)
+	}
+}
@@ -0,0 +1,260 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/pasarsuara/backend/internal/ai"
+)
+
+// selectionKindConfirm marks button ids that confirm or cancel a pending action
+const selectionKindConfirm = "confirm"
+
+// Words accepted as confirmation or cancellation (id, jv, su). "gas" and
+// "jadi" are left out: they are also an item and the start of a correction.
+var (
+	confirmWords = map[string]bool{
+		"ya": true, "iya": true, "y": true, "yes": true, "ok": true, "oke": true, "okay": true,
+		"betul": true, "benar": true, "lanjut": true, "setuju": true, "sip": true,
+		"nggih": true, "inggih": true, "muhun": true, "👍": true,
+	}
+	cancelWords = map[string]bool{
+		"tidak": true, "gak": true, "ga": true, "nggak": true, "enggak": true, "no": true,
+		"batal": true, "jangan": true, "cancel": true, "mboten": true, "ora": true,
+		"henteu": true, "teu": true, "👎": true,
+	}
+)
+
+// proposeAction stores a risky action and asks the user to confirm it
+func (o *AgentOrchestrator) proposeAction(ctx context.Context, userPhone, kind string, intent *ai.Intent, negotiation *NegotiationResult) *AgentResponse {
+	action := o.pending.Create(userPhone, kind, intent, negotiation, IdempotencyKeyFromContext(ctx))
+	log.Printf("⏳ Pending %s action %s for %s", kind, action.ID, userPhone)
+
+	var text string
+	switch kind {
+	case PendingPurchase:
+		text = o.formatPurchaseProposal(negotiation)
+	case PendingCatalog:
+		text = o.formatCatalogProposal(intent)
+	default:
+		text = o.formatSaleProposal(intent)
+	}
+
+	buttons := []ReplyButton{
+		{ID: SelectionID(selectionKindConfirm, action.ID, "yes"), Title: "✅ Ya, lanjut"},
+		{ID: SelectionID(selectionKindConfirm, action.ID, "no"), Title: "❌ Batal"},
+	}
+
+	// Keep the reply hint in the body too, buttons are not shown on every client
+	text += "\n\nBalas 👍 atau \"ya\" untuk konfirmasi, \"batal\" untuk membatalkan."
+
+	return &AgentResponse{
+		Success:         true,
+		Intent:          intent,
+		Negotiation:     negotiation,
+		Message:         text,
+		Replies:         []Reply{ButtonsReply(text, buttons)},
+		PendingActionID: action.ID,
+	}
+}
+
+// BindPendingAction links the outbound message that carried the proposal to the action
+func (o *AgentOrchestrator) BindPendingAction(actionID, messageID string) {
+	if o.pending.BindMessage(actionID, messageID) {
+		log.Printf("🔗 Pending action %s bound to message %s", actionID, messageID)
+	}
+}
+
+// ProcessReaction confirms or cancels the pending action proposed in the reacted message.
+// Returns nil when the reaction is not related to a pending action.
+func (o *AgentOrchestrator) ProcessReaction(ctx context.Context, userPhone, targetMessageID, emoji string) *AgentResponse {
+	action := o.pending.GetByMessage(targetMessageID)
+	if action == nil || action.UserPhone != userPhone {
+		return nil
+	}
+
+	switch {
+	case isConfirmReaction(emoji) && action.Kind != PendingContact:
+		return o.ConfirmAction(ctx, userPhone, action.ID, true)
+	case isCancelReaction(emoji):
+		return o.ConfirmAction(ctx, userPhone, action.ID, false)
+	default:
+		return nil
+	}
+}
+
+// ProcessConfirmationReply handles "ya"/"batal" replies for a pending action.
+// A quoted reply targets that message's action, otherwise the user's latest one.
+// Without one, the reply may answer a recurring run waiting for confirmation.
+func (o *AgentOrchestrator) ProcessConfirmationReply(ctx context.Context, userPhone, quotedMessageID, text string) (*AgentResponse, bool) {
+	var action *PendingAction
+	if quotedMessageID != "" {
+		action = o.pending.GetByMessage(quotedMessageID)
+	}
+	if action == nil {
+		action = o.pending.GetLatest(userPhone)
+	}
+
+	word := normalizeConfirmText(text)
+	if action == nil || action.UserPhone != userPhone {
+		if confirmWords[word] || cancelWords[word] {
+			return o.confirmRecurring(ctx, userPhone, quotedMessageID, confirmWords[word])
+		}
+		return nil, false
+	}
+
+	// Forwarded contacts wait for a type, not a yes/no
+	if action.Kind == PendingContact {
+		if contactType, ok := contactTypeWords[word]; ok {
+			return o.completeContact(ctx, userPhone, action.ID, contactType), true
+		}
+		if cancelWords[word] {
+			return o.ConfirmAction(ctx, userPhone, action.ID, false), true
+		}
+		return nil, false
+	}
+
+	// Entity checks also accept one of the offered values, e.g. "50"
+	if action.Kind == PendingEntityCheck {
+		if value, ok := matchEntityOption(action, text); ok {
+			return o.PickEntityValue(ctx, userPhone, action.ID, value), true
+		}
+	}
+
+	switch {
+	case confirmWords[word]:
+		return o.ConfirmAction(ctx, userPhone, action.ID, true), true
+	case cancelWords[word]:
+		return o.ConfirmAction(ctx, userPhone, action.ID, false), true
+	default:
+		return nil, false
+	}
+}
+
+// ConfirmAction executes or cancels a pending action
+func (o *AgentOrchestrator) ConfirmAction(ctx context.Context, userPhone, actionID string, confirmed bool) *AgentResponse {
+	// Check the owner before taking, so another user cannot discard the action
+	action := o.pending.Get(actionID)
+	if action != nil && action.UserPhone == userPhone {
+		action = o.pending.Take(actionID)
+	}
+	if action == nil || action.UserPhone != userPhone {
+		return &AgentResponse{
+			Success: false,
+			Message: "Tidak ada aksi yang menunggu konfirmasi.",
+		}
+	}
+
+	if action.Expired() {
+		log.Printf("⌛ Pending action %s expired", action.ID)
+		return &AgentResponse{
+			Success: false,
+			Intent:  action.Intent,
+			Message: "⌛ Konfirmasi sudah kedaluwarsa. Silakan kirim ulang permintaannya ya.",
+		}
+	}
+
+	if !confirmed {
+		log.Printf("🚫 Pending action %s cancelled", action.ID)
+		return &AgentResponse{
+			Success: true,
+			Intent:  action.Intent,
+			Message: "🚫 Dibatalkan, tidak ada yang dicatat.",
+		}
+	}
+
+	log.Printf("✅ Pending action %s confirmed", action.ID)
+
+	// Use the original message id so retries of the confirmation stay idempotent
+	ctx = WithIdempotencyKey(ctx, action.IdempotencyKey)
+	if action.Kind == PendingEntityCheck {
+		return o.resumeIntent(ctx, userPhone, action.Intent)
+	}
+
+	userID := o.getUserID(ctx, userPhone)
+	response := &AgentResponse{
+		Success: true,
+		Intent:  action.Intent,
+	}
+
+	switch action.Kind {
+	case PendingSale:
+		o.recordSale(ctx, userID, action.Intent, response)
+	case PendingPurchase:
+		o.recordPurchase(ctx, userID, action.Intent, action.Negotiation, response)
+	case PendingCancel:
+		response = o.completeCancel(ctx, userPhone, userID, action)
+	case PendingCorrection:
+		response = o.completeCorrection(ctx, userPhone, userID, action)
+	case PendingCatalog:
+		response = o.completeCatalog(ctx, userID, action)
+	default:
+		response.Success = false
+		response.Message = fmt.Sprintf("Aksi %s belum didukung.", action.Kind)
+	}
+	if action.Kind != PendingCancel {
+		o.rememberTransaction(userPhone, response, action.Intent)
+	}
+
+	if o.contextMgr != nil {
+		o.contextMgr.AddMessage(userPhone, "assistant", response.Message, action.Intent.Action, nil)
+	}
+
+	return response
+}
+
+func (o *AgentOrchestrator) formatSaleProposal(intent *ai.Intent) string {
+	product := getStringEntity(intent.Entities, "product")
+	qty := getFloatEntity(intent.Entities, "qty")
+	price := getFloatEntity(intent.Entities, "price")
+
+	return fmt.Sprintf("⚠️ Konfirmasi Penjualan Besar\n\n"+
+		"📦 Produk: %s\n"+
+		"📊 Jumlah: %.0f\n"+
+		"💰 Harga: Rp %.0f\n"+
+		"💵 Total: Rp %.0f\n\n"+
+		"Sudah benar?",
+		product, qty, price, qty*price)
+}
+
+func (o *AgentOrchestrator) formatPurchaseProposal(neg *NegotiationResult) string {
+	delivery := ""
+	if neg.DeliveryFee > 0 {
+		delivery = fmt.Sprintf("🚚 Ongkir: ± Rp %.0f (%.1f km)\n", neg.DeliveryFee, neg.DistanceKm)
+	}
+
+	return fmt.Sprintf("🤝 Negosiasi Berhasil, tinggal konfirmasi!\n\n"+
+		"📦 Produk: %s\n"+
+		"📊 Jumlah: %.0f unit\n"+
+		"💰 Harga: Rp %.0f/unit\n"+
+		"💵 Total: Rp %.0f\n"+
+		"🏪 Penjual: %s\n"+
+		"%s\n"+
+		"Jadi pesan?",
+		neg.ProductName, neg.Quantity, neg.FinalPrice, neg.TotalAmount, neg.SellerName, delivery)
+}
+
+// normalizeConfirmText lowercases and strips punctuation, e.g. "Ya!" -> "ya"
+func normalizeConfirmText(text string) string {
+	text = strings.ToLower(strings.TrimSpace(text))
+	text = strings.Trim(text, ".,!?~ ")
+	if isConfirmReaction(text) {
+		return "👍"
+	}
+	if isCancelReaction(text) {
+		return "👎"
+	}
+	return text
+}
+
+// isConfirmReaction accepts 👍 (any skin tone), ✅ and 👌
+func isConfirmReaction(emoji string) bool {
+	return strings.HasPrefix(emoji, "👍") || emoji == "✅" || emoji == "👌"
+}
+
+// isCancelReaction accepts 👎 (any skin tone) and ❌
+func isCancelReaction(emoji string) bool {
+	return strings.HasPrefix(emoji, "👎") || emoji == "❌"
+}
@@ -0,0 +1,139 @@
+package agents
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pasarsuara/backend/internal/ai"
+)
+
+func newConfirmTestOrchestrator(ttl time.Duration) *AgentOrchestrator {
+	return &AgentOrchestrator{
+		finance: NewFinanceAgent(nil),
+		pending: NewPendingActionStore(ttl),
+	}
+}
+
+func largeSaleIntent() *ai.Intent {
+	return &ai.Intent{
+		Action:   "RECORD_SALE",
+		Entities: map[string]any{"product": "beras", "qty": float64(100), "price": float64(12000)},
+		RawText:  "laku beras 100 kg 12 ribu",
+	}
+}
+
+func TestLargeSaleRequiresConfirmation(t *testing.T) {
+	o := newConfirmTestOrchestrator(time.Minute)
+	ctx := context.Background()
+
+	resp := o.processIntent(ctx, "628111", largeSaleIntent())
+	if resp.PendingActionID == "" {
+		t.Fatal("Large sale should be proposed as pending action")
+	}
+	if resp.Transaction != nil {
+		t.Fatal("Large sale must not be recorded before confirmation")
+	}
+	if len(resp.Replies) != 1 || resp.Replies[0].Type != ReplyTypeButtons {
+		t.Fatal("Proposal should include confirm buttons")
+	}
+
+	// Unrelated text does not confirm
+	if _, handled := o.ProcessConfirmationReply(ctx, "628111", "", "stok beras berapa"); handled {
+		t.Error("Unrelated text should not be treated as confirmation")
+	}
+	for _, word := range []string{"gas", "jadi"} {
+		if _, handled := o.ProcessConfirmationReply(ctx, "628111", "", word); handled {
+			t.Errorf("Ambiguous %q should not be treated as confirmation", word)
+		}
+	}
+
+	// Other users cannot confirm
+	if _, handled := o.ProcessConfirmationReply(ctx, "628999", "", "ya"); handled {
+		t.Error("Other user should not confirm the action")
+	}
+	if other := o.ConfirmAction(ctx, "628999", resp.PendingActionID, false); other.Success {
+		t.Error("Other user should not cancel the action by id")
+	}
+
+	resp, handled := o.ProcessConfirmationReply(ctx, "628111", "", "Ya!")
+	if !handled || resp.Transaction == nil {
+		t.Fatalf("\"ya\" should record the sale, got %+v", resp)
+	}
+	if resp.Transaction.TotalAmount != 1200000 {
+		t.Errorf("TotalAmount = %v, want 1200000", resp.Transaction.TotalAmount)
+	}
+
+	// Action can only be confirmed once
+	if _, handled := o.ProcessConfirmationReply(ctx, "628111", "", "ya"); handled {
+		t.Error("Confirmed action should be removed")
+	}
+}
+
+func TestSmallSaleIsRecordedDirectly(t *testing.T) {
+	o := newConfirmTestOrchestrator(time.Minute)
+	intent := &ai.Intent{
+		Action:   "RECORD_SALE",
+		Entities: map[string]any{"product": "es teh", "qty": float64(10), "price": float64(5000)},
+	}
+
+	resp := o.processIntent(context.Background(), "628111", intent)
+	if resp.PendingActionID != "" || resp.Transaction == nil {
+		t.Error("Small sale should be recorded without confirmation")
+	}
+}
+
+func TestReactionConfirmsBoundMessage(t *testing.T) {
+	o := newConfirmTestOrchestrator(time.Minute)
+	ctx := context.Background()
+
+	resp := o.processIntent(ctx, "628111", largeSaleIntent())
+	o.BindPendingAction(resp.PendingActionID, "3EB0BOTMSG")
+
+	if got := o.ProcessReaction(ctx, "628111", "3EB0OTHER", "👍"); got != nil {
+		t.Error("Reaction to another message should be ignored")
+	}
+	if got := o.ProcessReaction(ctx, "628111", "3EB0BOTMSG", "😂"); got != nil {
+		t.Error("Non-confirm emoji should be ignored")
+	}
+
+	got := o.ProcessReaction(ctx, "628111", "3EB0BOTMSG", "👍🏽")
+	if got == nil || got.Transaction == nil {
+		t.Fatal("👍 reaction should record the sale")
+	}
+}
+
+func TestCancelAndExpiry(t *testing.T) {
+	o := newConfirmTestOrchestrator(time.Minute)
+	ctx := context.Background()
+
+	resp := o.processIntent(ctx, "628111", largeSaleIntent())
+	got, handled := o.ProcessConfirmationReply(ctx, "628111", "", "batal")
+	if !handled || got.Transaction != nil {
+		t.Error("\"batal\" should cancel without recording")
+	}
+
+	// Expired actions are rejected
+	resp = o.processIntent(ctx, "628111", largeSaleIntent())
+	o.pending.Get(resp.PendingActionID).ExpiresAt = time.Now().Add(-time.Second)
+	got = o.ConfirmAction(ctx, "628111", resp.PendingActionID, true)
+	if got.Success || got.Transaction != nil {
+		t.Error("Expired action should not be executed")
+	}
+}
+
+func TestContactCardOnlyOwnerCanSave(t *testing.T) {
+	o := newConfirmTestOrchestrator(time.Minute)
+	ctx := context.Background()
+
+	resp := o.ProcessContactCards(ctx, "628111", []ContactCard{{DisplayName: "Pak Budi", VCard: "BEGIN:VCARD\nFN:Pak Budi\nTEL:081234\nEND:VCARD"}})
+	if resp.PendingActionID == "" {
+		t.Fatalf("Contact card should wait for a type, got %+v", resp)
+	}
+	if other := o.completeContact(ctx, "628999", resp.PendingActionID, "SUPPLIER"); other.Success {
+		t.Error("Other user should not save the contact")
+	}
+	if o.pending.Get(resp.PendingActionID) == nil {
+		t.Error("Other user discarded the contact card")
+	}
+}
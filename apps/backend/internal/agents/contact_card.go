@@ -0,0 +1,210 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/pasarsuara/backend/internal/ai"
+)
+
+// PendingContact is a forwarded contact waiting for SUPPLIER/CUSTOMER choice
+const PendingContact = "ADD_CONTACT"
+
+// selectionKindContact marks button ids that pick the type of a forwarded contact
+const selectionKindContact = "contact"
+
+// intentAwaitContactLocation marks the context after a contact is saved,
+// so the next shared location is stored for that contact
+const intentAwaitContactLocation = "AWAIT_CONTACT_LOCATION"
+
+// Words accepted as contact type answers
+var contactTypeWords = map[string]string{
+	"supplier": "SUPPLIER", "suplier": "SUPPLIER", "pemasok": "SUPPLIER", "penjual": "SUPPLIER",
+	"customer": "CUSTOMER", "pelanggan": "CUSTOMER", "pembeli": "CUSTOMER", "langganan": "CUSTOMER",
+}
+
+// ContactCard is a forwarded WhatsApp contact
+type ContactCard struct {
+	DisplayName string `json:"display_name,omitempty"`
+	VCard       string `json:"vcard,omitempty"`
+}
+
+// VCardInfo holds the fields we use from a vCard
+type VCardInfo struct {
+	Name  string
+	Phone string
+	City  string
+}
+
+// ParseVCard extracts name, phone and city from a vCard
+func ParseVCard(vcard string) VCardInfo {
+	var info VCardInfo
+	var structuredName string
+
+	// Unfold continuation lines (RFC 6350 3.2)
+	vcard = strings.ReplaceAll(vcard, "\r\n", "\n")
+	vcard = strings.ReplaceAll(vcard, "\n ", "")
+
+	for _, line := range strings.Split(vcard, "\n") {
+		sep := strings.Index(line, ":")
+		if sep < 0 {
+			continue
+		}
+		key, value := line[:sep], strings.TrimSpace(line[sep+1:])
+
+		// Drop group prefix, e.g. "item1.TEL"
+		if dot := strings.Index(key, "."); dot >= 0 && dot < strings.Index(key+";", ";") {
+			key = key[dot+1:]
+		}
+		params := strings.Split(key, ";")
+		name := strings.ToUpper(params[0])
+
+		switch name {
+		case "FN":
+			info.Name = value
+		case "N":
+			parts := strings.Split(value, ";")
+			if len(parts) > 1 {
+				structuredName = strings.TrimSpace(parts[1] + " " + parts[0])
+			} else {
+				structuredName = value
+			}
+		case "TEL":
+			if info.Phone != "" {
+				continue // Keep the first number
+			}
+			// WhatsApp adds waid=<international number> to TEL
+			for _, p := range params[1:] {
+				if strings.HasPrefix(strings.ToLower(p), "waid=") {
+					info.Phone = p[len("waid="):]
+				}
+			}
+			if info.Phone == "" {
+				info.Phone = normalizeContactPhone(value)
+			}
+		case "ADR":
+			// ADR: PO box;extended;street;locality;region;postal code;country
+			parts := strings.Split(value, ";")
+			if len(parts) > 3 && info.City == "" {
+				info.City = strings.TrimSpace(parts[3])
+			}
+		}
+	}
+
+	if info.Name == "" {
+		info.Name = structuredName
+	}
+	return info
+}
+
+// normalizeContactPhone keeps digits and converts local 08xx numbers to 628xx
+func normalizeContactPhone(phone string) string {
+	var digits strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	result := digits.String()
+	if strings.HasPrefix(result, "0") {
+		result = "62" + result[1:]
+	}
+	return result
+}
+
+// ProcessContactCards proposes forwarded contacts and asks whether each one
+// is a supplier or a customer
+func (o *AgentOrchestrator) ProcessContactCards(ctx context.Context, userPhone string, cards []ContactCard) *AgentResponse {
+	log.Printf("👤 Orchestrator processing %d contact card(s) from %s", len(cards), userPhone)
+
+	response := &AgentResponse{Success: true}
+	var messages []string
+
+	for _, card := range cards {
+		info := ParseVCard(card.VCard)
+		if info.Name == "" {
+			info.Name = card.DisplayName
+		}
+		if info.Name == "" && info.Phone == "" {
+			continue
+		}
+
+		intent := &ai.Intent{
+			Action: PendingContact,
+			Entities: map[string]any{
+				"name":  info.Name,
+				"phone": info.Phone,
+				"city":  info.City,
+			},
+			RawText: card.VCard,
+		}
+		action := o.pending.Create(userPhone, PendingContact, intent, nil, "")
+
+		text := fmt.Sprintf("👤 Kontak diterima: %s", info.Name)
+		if info.Phone != "" {
+			text += fmt.Sprintf(" (%s)", info.Phone)
+		}
+		text += "\n\nSimpan sebagai supplier atau pelanggan?"
+
+		messages = append(messages, text)
+		response.Replies = append(response.Replies, ButtonsReply(text, []ReplyButton{
+			{ID: SelectionID(selectionKindContact, action.ID, "SUPPLIER"), Title: "🏭 Supplier"},
+			{ID: SelectionID(selectionKindContact, action.ID, "CUSTOMER"), Title: "🛒 Pelanggan"},
+			{ID: SelectionID(selectionKindConfirm, action.ID, "no"), Title: "❌ Lewati"},
+		}))
+		response.PendingActionID = action.ID
+	}
+
+	if len(messages) == 0 {
+		return &AgentResponse{
+			Success: false,
+			Message: "👤 Kontak diterima tapi nama dan nomornya kosong.",
+		}
+	}
+
+	response.Message = strings.Join(messages, "\n\n") + "\n\nBalas \"supplier\" atau \"pelanggan\"."
+	return response
+}
+
+// completeContact saves a forwarded contact with the chosen type
+func (o *AgentOrchestrator) completeContact(ctx context.Context, userPhone, actionID, contactType string) *AgentResponse {
+	// Check the owner before taking, so another user cannot discard the card
+	action := o.pending.Get(actionID)
+	if action != nil && action.UserPhone == userPhone && action.Kind == PendingContact {
+		action = o.pending.Take(actionID)
+	}
+	if action == nil || action.UserPhone != userPhone || action.Kind != PendingContact {
+		return &AgentResponse{Success: false, Message: "Tidak ada kontak yang menunggu disimpan."}
+	}
+	if action.Expired() {
+		return &AgentResponse{Success: false, Message: "⌛ Sudah kedaluwarsa. Kirim ulang kontaknya ya."}
+	}
+
+	name := getStringEntity(action.Intent.Entities, "name")
+	phone := getStringEntity(action.Intent.Entities, "phone")
+	city := getStringEntity(action.Intent.Entities, "city")
+
+	userID := o.getUserID(ctx, userPhone)
+	contact, err := o.contact.AddContact(ctx, userID, contactType, name, phone, city)
+	if err != nil {
+		return &AgentResponse{Success: false, Message: "Gagal menyimpan kontak: " + err.Error()}
+	}
+
+	label := "pelanggan"
+	if contactType == "SUPPLIER" {
+		label = "supplier"
+	}
+
+	// Next shared location belongs to this contact
+	if o.contextMgr != nil {
+		o.contextMgr.AddMessage(userPhone, "system", "waiting_for_contact_location", intentAwaitContactLocation,
+			map[string]any{"contact_id": contact.ID, "contact_name": contact.Name})
+	}
+
+	return &AgentResponse{
+		Success: true,
+		Message: fmt.Sprintf("✅ %s disimpan sebagai %s!\n\n📍 Kirim lokasinya (📎 > Lokasi) supaya ongkir bisa dihitung.", contact.Name, label),
+	}
+}
@@ -85,3 +85,35 @@ func TestFormatContactList(t *testing.T) {
 
 	t.Logf("Customer list:\n%s", customerResult)
 }
+
+func TestParseVCard(t *testing.T) {
+	tests := []struct {
+		name  string
+		vcard string
+		want  VCardInfo
+	}{
+		{
+			name:  "whatsapp vcard with waid",
+			vcard: "BEGIN:VCARD\nVERSION:3.0\nN:Santoso;Budi;;;\nFN:Budi Santoso\nTEL;type=CELL;type=VOICE;waid=6281234567890:+62 812-3456-7890\nEND:VCARD",
+			want:  VCardInfo{Name: "Budi Santoso", Phone: "6281234567890"},
+		},
+		{
+			name:  "grouped tel, local number and address",
+			vcard: "BEGIN:VCARD\r\nVERSION:3.0\r\nN:Ani;Bu;;;\r\nitem1.TEL:0812-1111-2222\r\nADR;type=WORK:;;Jl. Pasar Baru 5;Bandung;Jawa Barat;;Indonesia\r\nEND:VCARD",
+			want:  VCardInfo{Name: "Bu Ani", Phone: "6281211112222", City: "Bandung"},
+		},
+		{
+			name:  "empty",
+			vcard: "",
+			want:  VCardInfo{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseVCard(tt.vcard); got != tt.want {
+				t.Errorf("ParseVCard() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
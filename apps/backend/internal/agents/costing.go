@@ -0,0 +1,241 @@
+package agents
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pasarsuara/backend/internal/database"
+)
+
+// Inventory is costed FIFO: every purchase adds a layer at its unit cost and
+// every sale takes from the oldest layers, recording what they cost as the
+// sale's COGS. Costing is best effort in the same way as ledger.Journal.
+
+// layerUse is the qty a sale takes from one cost layer
+type layerUse struct {
+	layer *database.InventoryLayer
+	qty   float64
+}
+
+// costKey is the product name cost layers are kept under
+func costKey(product string) string {
+	return strings.ToLower(strings.TrimSpace(product))
+}
+
+// consumeLayers takes qty from layers oldest first. short is the qty the
+// layers could not cover.
+func consumeLayers(layers []database.InventoryLayer, qty float64) (uses []layerUse, cost, short float64) {
+	short = qty
+	for i := range layers {
+		if short <= 0 {
+			break
+		}
+		take := min(layers[i].QtyRemaining, short)
+		if take <= 0 {
+			continue
+		}
+		uses = append(uses, layerUse{layer: &layers[i], qty: take})
+		cost += take * layers[i].UnitCost
+		short -= take
+	}
+	return uses, cost, max(short, 0)
+}
+
+// addCostLayer puts a recorded purchase into stock at its unit cost
+func (f *FinanceAgent) addCostLayer(ctx context.Context, tx *database.Transaction) {
+	if f.db == nil || tx.Type != "PURCHASE" || tx.ProductName == "" || tx.Qty <= 0 {
+		return
+	}
+	layer := &database.InventoryLayer{
+		UserID:        tx.UserID,
+		ProductName:   costKey(tx.ProductName),
+		TransactionID: tx.ID,
+		QtyReceived:   tx.Qty,
+		QtyRemaining:  tx.Qty,
+		UnitCost:      tx.PricePerUnit,
+		ReceivedAt:    tx.CreatedAt,
+	}
+	if err := f.db.CreateInventoryLayer(ctx, layer); err != nil {
+		log.Printf("⚠️ Failed to add cost layer: %v", err)
+		return
+	}
+	log.Printf("🧮 Cost layer added: %s %.0f @ Rp %.0f", layer.ProductName, layer.QtyReceived, layer.UnitCost)
+}
+
+// recordCOGS takes a recorded sale out of the cost layers and stores its
+// cost of goods sold on tx. Qty sold beyond the layers, e.g. stock bought
+// before costing or never recorded, is costed at the latest purchase price.
+// A product that was never purchased has no known cost and is left at 0.
+func (f *FinanceAgent) recordCOGS(ctx context.Context, tx *database.Transaction) {
+	if f.db == nil || tx.Type != "SALE" || tx.ProductName == "" || tx.Qty <= 0 {
+		return
+	}
+	product := costKey(tx.ProductName)
+	layers, err := f.db.GetOpenInventoryLayers(ctx, tx.UserID, product)
+	if err != nil {
+		log.Printf("⚠️ Failed to get cost layers: %v", err)
+		return
+	}
+
+	uses, cost, short := consumeLayers(layers, tx.Qty)
+	if short > 0 {
+		latest, err := f.db.GetLatestInventoryLayer(ctx, tx.UserID, product)
+		if err != nil {
+			log.Printf("⚠️ Failed to get latest purchase cost: %v", err)
+			return
+		}
+		if latest == nil {
+			log.Printf("🧮 No purchase cost known for %s, COGS not recorded", product)
+			return
+		}
+		cost += short * latest.UnitCost
+	}
+
+	usage := make([]database.InventoryLayerUsage, 0, len(uses))
+	for _, use := range uses {
+		if err := f.db.UpdateInventoryLayer(ctx, use.layer.ID, map[string]any{
+			"qty_remaining": use.layer.QtyRemaining - use.qty,
+		}); err != nil {
+			log.Printf("⚠️ Failed to consume cost layer: %v", err)
+			continue
+		}
+		usage = append(usage, database.InventoryLayerUsage{
+			UserID:        tx.UserID,
+			LayerID:       use.layer.ID,
+			TransactionID: tx.ID,
+			Qty:           use.qty,
+			UnitCost:      use.layer.UnitCost,
+		})
+	}
+	if err := f.db.CreateLayerUsage(ctx, usage); err != nil {
+		log.Printf("⚠️ Failed to record cost layer usage: %v", err)
+	}
+
+	if err := f.db.UpdateTransaction(ctx, tx.ID, map[string]any{"cogs": cost}); err != nil {
+		log.Printf("⚠️ Failed to record COGS: %v", err)
+		return
+	}
+	tx.COGS = cost
+	log.Printf("🧮 COGS recorded: %s x%.0f = Rp %.0f", product, tx.Qty, cost)
+}
+
+// releaseCOGS puts what a cancelled or corrected sale took back into its
+// layers. The books get their COGS back at the cost it was taken at; the
+// difference to the layers' value now is posted as an adjustment.
+func (f *FinanceAgent) releaseCOGS(ctx context.Context, tx *database.Transaction) {
+	if f.db == nil || tx.Type != "SALE" {
+		return
+	}
+	usage, err := f.db.GetLayerUsage(ctx, tx.ID)
+	if err != nil {
+		log.Printf("⚠️ Failed to get cost layer usage: %v", err)
+		return
+	}
+	if len(usage) == 0 {
+		return
+	}
+	adjustment := 0.0
+	for _, use := range usage {
+		if use.Layer == nil || use.Layer.VoidedAt != "" {
+			adjustment -= use.Qty * use.UnitCost // Its purchase was cancelled
+			continue
+		}
+		if err := f.db.UpdateInventoryLayer(ctx, use.LayerID, map[string]any{
+			"qty_remaining": use.Layer.QtyRemaining + use.Qty,
+		}); err != nil {
+			log.Printf("⚠️ Failed to restore cost layer: %v", err)
+			continue
+		}
+		adjustment += use.Qty * (use.Layer.UnitCost - use.UnitCost)
+	}
+	if err := f.db.DeleteLayerUsage(ctx, tx.ID); err != nil {
+		log.Printf("⚠️ Failed to delete cost layer usage: %v", err)
+	}
+	f.journal.PostAdjustment(ctx, tx.UserID, tx.ID, tx.CreatedAt, adjustment, "Penyesuaian persediaan "+tx.ProductName)
+}
+
+// takenFromLayer is the qty sales took from a layer and what it cost them
+func (f *FinanceAgent) takenFromLayer(ctx context.Context, layerID string) (qty, cost float64, err error) {
+	usage, err := f.db.GetLayerUsageByLayer(ctx, layerID)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, use := range usage {
+		qty += use.Qty
+		cost += use.Qty * use.UnitCost
+	}
+	return qty, cost, nil
+}
+
+// layerAdjustment is what the books must add to inventory when a purchase
+// layer becomes newQty at newCost: sales already expensed takenCost of it,
+// and what is left in the layer must match the books
+func layerAdjustment(takenQty, takenCost, newQty, newCost float64) float64 {
+	return takenCost - min(takenQty, newQty)*newCost
+}
+
+// removeCostLayer voids the layer of a cancelled purchase. Sales that already
+// took from it keep their COGS; the books keep that cost as an adjustment.
+func (f *FinanceAgent) removeCostLayer(ctx context.Context, tx *database.Transaction) {
+	if f.db == nil || tx.Type != "PURCHASE" {
+		return
+	}
+	layer, err := f.db.GetInventoryLayerByTransaction(ctx, tx.ID)
+	if err != nil {
+		log.Printf("⚠️ Failed to get cost layer: %v", err)
+		return
+	}
+	if layer == nil {
+		return
+	}
+	takenQty, takenCost, err := f.takenFromLayer(ctx, layer.ID)
+	if err != nil {
+		log.Printf("⚠️ Failed to get cost layer usage: %v", err)
+		return
+	}
+	if err := f.db.UpdateInventoryLayer(ctx, layer.ID, map[string]any{
+		"qty_remaining": 0,
+		"voided_at":     time.Now().UTC().Format(time.RFC3339),
+	}); err != nil {
+		log.Printf("⚠️ Failed to void cost layer: %v", err)
+		return
+	}
+	f.journal.PostAdjustment(ctx, tx.UserID, tx.ID, tx.CreatedAt, layerAdjustment(takenQty, takenCost, 0, 0),
+		"Penyesuaian persediaan "+tx.ProductName)
+}
+
+// moveCostLayer rewrites the layer of a corrected purchase for its
+// replacement. What sales already took from it stays taken.
+func (f *FinanceAgent) moveCostLayer(ctx context.Context, old, updated *database.Transaction) {
+	if f.db == nil || updated.Type != "PURCHASE" {
+		return
+	}
+	layer, err := f.db.GetInventoryLayerByTransaction(ctx, old.ID)
+	if err != nil {
+		log.Printf("⚠️ Failed to get cost layer: %v", err)
+		return
+	}
+	if layer == nil {
+		f.addCostLayer(ctx, updated)
+		return
+	}
+	takenQty, takenCost, err := f.takenFromLayer(ctx, layer.ID)
+	if err != nil {
+		log.Printf("⚠️ Failed to get cost layer usage: %v", err)
+		return
+	}
+	if err := f.db.UpdateInventoryLayer(ctx, layer.ID, map[string]any{
+		"transaction_id": updated.ID,
+		"product_name":   costKey(updated.ProductName),
+		"qty_received":   updated.Qty,
+		"qty_remaining":  max(updated.Qty-takenQty, 0),
+		"unit_cost":      updated.PricePerUnit,
+	}); err != nil {
+		log.Printf("⚠️ Failed to update cost layer: %v", err)
+		return
+	}
+	f.journal.PostAdjustment(ctx, updated.UserID, updated.ID, updated.CreatedAt,
+		layerAdjustment(takenQty, takenCost, updated.Qty, updated.PricePerUnit), "Penyesuaian persediaan "+updated.ProductName)
+}
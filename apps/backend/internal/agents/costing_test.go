@@ -0,0 +1,118 @@
+package agents
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pasarsuara/backend/internal/database"
+)
+
+func TestConsumeLayers(t *testing.T) {
+	tests := []struct {
+		name  string
+		qty   float64
+		taken []float64
+		cost  float64
+		short float64
+	}{
+		{"within oldest", 4, []float64{4}, 40000, 0},
+		{"oldest then next", 12, []float64{10, 2}, 100000 + 24000, 0},
+		{"more than in stock", 20, []float64{10, 5}, 100000 + 60000, 5},
+		{"nothing", 0, nil, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layers := []database.InventoryLayer{
+				{ID: "old", QtyRemaining: 10, UnitCost: 10000},
+				{ID: "empty", QtyRemaining: 0, UnitCost: 9000},
+				{ID: "new", QtyRemaining: 5, UnitCost: 12000},
+			}
+			uses, cost, short := consumeLayers(layers, tt.qty)
+			if len(uses) != len(tt.taken) || cost != tt.cost || short != tt.short {
+				t.Fatalf("consumeLayers(%.0f) = %+v, cost %.0f, short %.0f", tt.qty, uses, cost, short)
+			}
+			for i, use := range uses {
+				if use.qty != tt.taken[i] || use.layer.ID == "empty" {
+					t.Errorf("use %d = %.0f from %s", i, use.qty, use.layer.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestSummarizeTransactions(t *testing.T) {
+	report := summarizeTransactions("2026-10-19", []database.Transaction{
+		{Type: "PURCHASE", ProductName: "Beras", Qty: 50, TotalAmount: 600000},
+		{Type: "SALE", ProductName: "Beras", Qty: 5, TotalAmount: 75000, COGS: 60000},
+		{Type: "SALE", ProductName: "beras", Qty: 5, TotalAmount: 75000, COGS: 60000},
+		{Type: "SALE", ProductName: "Es Teh", Qty: 10, TotalAmount: 30000}, // never purchased
+		{Type: "EXPENSE", ProductName: "Gas", Qty: 1, TotalAmount: 20000},
+	})
+
+	// The 600 ribu of beras bought today is not today's cost, the 10 kg sold is
+	if report.COGS != 120000 || report.GrossProfit != 60000 || report.NetProfit != 40000 ||
+		report.TotalPurchases != 600000 || report.UncostedSales != 30000 {
+		t.Errorf("report = %+v", report)
+	}
+	if margin, ok := report.GrossMargin(); !ok || margin != 20 {
+		t.Errorf("GrossMargin = %.1f, %v, want 20 on costed sales", margin, ok)
+	}
+
+	if len(report.TopProducts) != 2 || report.TopProducts[0].ProductName != "Beras" || report.TopProducts[0].Quantity != 10 {
+		t.Fatalf("TopProducts = %+v", report.TopProducts)
+	}
+	if _, ok := report.TopProducts[1].GrossMargin(); ok {
+		t.Errorf("Es Teh has no known cost, margin should be unknown")
+	}
+
+	msg := NewReportAgent(nil).FormatDailyReport(report)
+	for _, want := range []string{"Modal Terjual (HPP): Rp 120.000", "Laba Kotor: Rp 60.000 (margin 20%)",
+		"1. Beras - 10 unit (Rp 150.000), margin 20%", "Rp 30.000 penjualan belum ada harga modalnya"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("Report missing %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestCalculateOptimalPrice_MaximizesProfit(t *testing.T) {
+	a := NewAnalyticsAgent(nil)
+	points := []pricePoint{{price: 10000, demand: 100}, {price: 12000, demand: 60}}
+
+	// Revenue peaks at 10.000 (1.000.000 vs 720.000)...
+	if got := a.calculateOptimalPrice(points, 10500, 0); got != 10000 {
+		t.Errorf("Without cost = %.0f, want 10000", got)
+	}
+	// ...but at a cost of 9.000 profit peaks at 12.000 (100.000 vs 180.000)
+	if got := a.calculateOptimalPrice(points, 10500, 9000); got != 12000 {
+		t.Errorf("With cost = %.0f, want 12000", got)
+	}
+	if got := a.estimateProfit(points, 12000, 9000); got != 180000 {
+		t.Errorf("estimateProfit = %.0f, want 180000", got)
+	}
+	// Never below cost
+	if got := a.calculateOptimalPrice([]pricePoint{{price: 8000, demand: 10}}, 8000, 8500); got != 8500 {
+		t.Errorf("Below cost = %.0f, want 8500", got)
+	}
+}
+
+func TestLayerAdjustment(t *testing.T) {
+	// 10 bought at 1000, 4 of them sold for COGS 4000
+	tests := []struct {
+		name    string
+		newQty  float64
+		newCost float64
+		want    float64
+	}{
+		{"unchanged", 10, 1000, 0},
+		{"price corrected up", 10, 1200, -800},
+		{"qty corrected below sold", 3, 1000, 1000},
+		{"cancelled", 0, 0, 4000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := layerAdjustment(4, 4000, tt.newQty, tt.newCost); got != tt.want {
+				t.Errorf("layerAdjustment() = %.0f, want %.0f", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,602 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/pasarsuara/backend/internal/ai"
+	"github.com/pasarsuara/backend/internal/database"
+	"github.com/pasarsuara/backend/internal/ledger"
+)
+
+// ErrNoCredit is returned for a repayment from a contact who owes nothing
+var ErrNoCredit = errors.New("no open credit for this contact")
+
+// ErrNoCreditContact is returned for a sale or expense on credit that names
+// no contact
+var ErrNoCreditContact = errors.New("credit without a contact")
+
+const (
+	defaultCreditDays = 30                 // Term of credit without a due date
+	reminderType      = "DEBT_REMINDER"    // notification_queue type of QueueDebtReminder
+	reminderInterval  = 7 * 24 * time.Hour // Between reminders to one contact
+	reminderHour      = 9                  // Reminders are queued at 09:00
+)
+
+// CreditAgent keeps the receivables and payables ledger: sales, expenses and
+// cash kasbon on credit linked to a contact and repaid in parts
+type CreditAgent struct {
+	db           *database.SupabaseClient
+	notification *NotificationAgent
+	journal      *ledger.Journal
+}
+
+func NewCreditAgent(db *database.SupabaseClient, notification *NotificationAgent) *CreditAgent {
+	return &CreditAgent{db: db, notification: notification, journal: ledger.NewJournal(db)}
+}
+
+// CreditEntry is a recorded transaction on credit
+type CreditEntry struct {
+	Transaction *database.Transaction
+	Contact     *database.Contact
+	Outstanding float64 // Everything the contact owes or is owed now
+}
+
+// CreditBalance is what one contact owes the user, or the user owes them
+type CreditBalance struct {
+	Contact     database.Contact
+	Outstanding float64
+	OldestDue   string // YYYY-MM-DD
+	Open        []database.Transaction
+}
+
+// Repayment is a payment spread over a contact's open credit
+type Repayment struct {
+	Contact   *database.Contact
+	Paid      float64 // Applied to open credit
+	Overpaid  float64 // Paid beyond what was owed
+	Remaining float64 // Still owed afterwards
+	Settled   int     // Transactions paid off
+}
+
+// AgingReport sums outstanding credit by how long it is overdue
+type AgingReport struct {
+	Buckets [len(agingBuckets)]float64
+	Total   float64
+}
+
+// agingBuckets are the upper bounds of days overdue; the first is not yet due
+var agingBuckets = [...]struct {
+	label   string
+	maxDays int
+}{
+	{"Belum jatuh tempo", 0},
+	{"Telat 1-30 hari", 30},
+	{"Telat 31-60 hari", 60},
+	{"Telat 61-90 hari", 90},
+	{"Telat > 90 hari", math.MaxInt},
+}
+
+// CreditTerms put a sale or expense on credit with a contact
+type CreditTerms struct {
+	Contact *database.Contact
+	DueDate string // YYYY-MM-DD
+}
+
+// apply puts tx on credit; nil terms leave it paid on the spot
+func (t *CreditTerms) apply(tx *database.Transaction) {
+	if t == nil {
+		return
+	}
+	tx.ContactID = t.Contact.ID
+	tx.DueDate = t.DueDate
+	tx.SettlementStatus = database.SettlementOpen
+}
+
+// creditTxTypes are the transactions a credit type is kept in: customers
+// owe for sales and cash kasbon, the user owes for purchases and expenses
+func creditTxTypes(creditType string) []string {
+	if creditType == ai.CreditPayable {
+		return []string{"PURCHASE", "EXPENSE"}
+	}
+	return []string{"SALE", "LOAN"}
+}
+
+// creditMoneyType is the transaction of credit without goods. A cash kasbon
+// is a loan, not a sale, so it stays out of sales and product reports; the
+// user's own debt is an expense.
+func creditMoneyType(creditType string) string {
+	if creditType == ai.CreditPayable {
+		return "EXPENSE"
+	}
+	return "LOAN"
+}
+
+// isPayable reports whether a transaction on credit is owed by the user
+func isPayable(txType string) bool {
+	return txType == "PURCHASE" || txType == "EXPENSE"
+}
+
+// creditContactType is the contact type on the other side of a credit type
+func creditContactType(creditType string) string {
+	if creditType == ai.CreditPayable {
+		return "SUPPLIER"
+	}
+	return "CUSTOMER"
+}
+
+// creditDueDate is the future end_date of the message ("bayar minggu
+// depan"), else defaultCreditDays from today
+func creditDueDate(intent *ai.Intent, today time.Time) string {
+	if end := getStringEntity(intent.Entities, "end_date"); end >= today.Format("2006-01-02") {
+		return end
+	}
+	return today.AddDate(0, 0, defaultCreditDays).Format("2006-01-02")
+}
+
+// Terms are the credit terms of a sale or expense on credit ("jual bakso 10
+// porsi 15rb ngutang Bu Sari"), nil when it is paid on the spot. The contact
+// is added when new.
+func (c *CreditAgent) Terms(ctx context.Context, userID string, intent *ai.Intent, today time.Time) (*CreditTerms, error) {
+	creditType := getStringEntity(intent.Entities, "credit_type")
+	if creditType == "" {
+		return nil, nil
+	}
+	name := getStringEntity(intent.Entities, "contact")
+	if name == "" {
+		return nil, ErrNoCreditContact
+	}
+	if c.db == nil {
+		return nil, fmt.Errorf("database not configured")
+	}
+	contact, err := c.contactFor(ctx, userID, creditType, name)
+	if err != nil {
+		return nil, err
+	}
+	return &CreditTerms{Contact: contact, DueDate: creditDueDate(intent, today)}, nil
+}
+
+// EntryFor is the credit entry of a transaction recorded on terms, with
+// what the contact owes now
+func (c *CreditAgent) EntryFor(ctx context.Context, tx *database.Transaction, terms *CreditTerms) *CreditEntry {
+	creditType := ai.CreditReceivable
+	if isPayable(tx.Type) {
+		creditType = ai.CreditPayable
+	}
+	return &CreditEntry{Transaction: tx, Contact: terms.Contact, Outstanding: c.outstanding(ctx, tx.UserID, creditType, terms.Contact.ID)}
+}
+
+// RecordCredit records money owed without goods, a cash kasbon or the
+// user's own debt, with the contact, who is added when new
+func (c *CreditAgent) RecordCredit(ctx context.Context, userID string, intent *ai.Intent, today time.Time) (*CreditEntry, error) {
+	creditType := getStringEntity(intent.Entities, "credit_type")
+	name := getStringEntity(intent.Entities, "contact")
+	amount := getFloatEntity(intent.Entities, "amount")
+	log.Printf("📒 Credit Agent: Recording %s %s Rp %.0f for user %s", creditType, name, amount, userID)
+
+	if c.db == nil {
+		return nil, fmt.Errorf("database not configured")
+	}
+
+	contact, err := c.contactFor(ctx, userID, creditType, name)
+	if err != nil {
+		return nil, err
+	}
+
+	product := "Kasbon " + contact.Name
+	if creditType == ai.CreditPayable {
+		product = "Utang ke " + contact.Name
+	}
+	tx := &database.Transaction{
+		UserID:         userID,
+		Type:           creditMoneyType(creditType),
+		ProductName:    product,
+		Qty:            1,
+		PricePerUnit:   amount,
+		TotalAmount:    amount,
+		RawVoiceText:   intent.RawText,
+		IdempotencyKey: IdempotencyKeyFromContext(ctx),
+		CreatedAt:      transactionTime(intent),
+	}
+	(&CreditTerms{Contact: contact, DueDate: creditDueDate(intent, today)}).apply(tx)
+
+	// Skip messages that were already recorded (redelivery/retry)
+	if tx.IdempotencyKey != "" {
+		if existing, err := c.db.GetTransactionByIdempotencyKey(ctx, userID, tx.IdempotencyKey); err == nil && existing != nil {
+			log.Printf("♻️ Credit already recorded for message %s, skipping", tx.IdempotencyKey)
+			return &CreditEntry{Transaction: existing, Contact: contact, Outstanding: c.outstanding(ctx, userID, creditType, contact.ID)},
+				ErrDuplicateTransaction
+		}
+	}
+	if err := c.db.CreateTransaction(ctx, tx); err != nil {
+		log.Printf("❌ Failed to record credit: %v", err)
+		return nil, err
+	}
+	log.Printf("✅ Credit recorded: %s Rp %.0f due %s", product, amount, tx.DueDate)
+	c.journal.PostTransaction(ctx, tx, expenseAccount(tx))
+
+	auditLog := &database.AuditLog{
+		UserID:     userID,
+		Action:     "CREATE_CREDIT",
+		EntityType: "transaction",
+		EntityID:   tx.ID,
+		NewData:    tx,
+	}
+	if err := c.db.LogAudit(ctx, auditLog); err != nil {
+		log.Printf("⚠️ Failed to create audit log: %v", err)
+	}
+
+	return &CreditEntry{Transaction: tx, Contact: contact, Outstanding: c.outstanding(ctx, userID, creditType, contact.ID)}, nil
+}
+
+// RecordRepayment applies a payment to the contact's oldest credit first.
+// Each paid transaction gets a payment row, PARTIAL until it is settled.
+func (c *CreditAgent) RecordRepayment(ctx context.Context, userID string, intent *ai.Intent) (*Repayment, error) {
+	creditType := getStringEntity(intent.Entities, "credit_type")
+	name := getStringEntity(intent.Entities, "contact")
+	amount := getFloatEntity(intent.Entities, "amount")
+	log.Printf("💵 Credit Agent: Repayment %s %s Rp %.0f for user %s", creditType, name, amount, userID)
+
+	if c.db == nil {
+		return nil, fmt.Errorf("database not configured")
+	}
+
+	// Repayments are payments, keyed by the source message id
+	key := IdempotencyKeyFromContext(ctx)
+	if key != "" {
+		if payments, err := c.db.GetPaymentsByReference(ctx, key); err == nil && len(payments) > 0 {
+			log.Printf("♻️ Repayment already recorded for message %s, skipping", key)
+			return nil, ErrDuplicateTransaction
+		}
+	}
+
+	contact, err := c.findContact(ctx, userID, creditContactType(creditType), name)
+	if err != nil {
+		return nil, err
+	}
+	if contact == nil {
+		return nil, ErrNoCredit
+	}
+	open, err := c.db.GetOpenCredit(ctx, userID, creditTxTypes(creditType), contact.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(open) == 0 {
+		return &Repayment{Contact: contact}, ErrNoCredit
+	}
+	if amount <= 0 && intent.Entities["settle"] == true {
+		amount = sumOutstanding(open)
+	}
+
+	allocations, leftover := allocateRepayment(open, amount)
+	result := &Repayment{Contact: contact, Overpaid: leftover}
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, a := range allocations {
+		paid := a.tx.AmountPaid + a.amount
+		status := database.SettlementPartial
+		if paid >= a.tx.TotalAmount {
+			status = database.SettlementPaid
+			result.Settled++
+		}
+
+		payment := &database.Payment{
+			TransactionID:   a.tx.ID,
+			Amount:          a.amount,
+			PaymentMethod:   "CASH",
+			Status:          status, // PARTIAL or PAID, as the transaction
+			ReferenceNumber: key,
+			Notes:           "Pembayaran " + a.tx.ProductName,
+			PaidAt:          now,
+		}
+		if err := c.db.CreatePayment(ctx, payment); err != nil {
+			return nil, err
+		}
+		c.journal.PostPayment(ctx, a.tx, payment)
+		updates := map[string]any{"amount_paid": paid, "settlement_status": status}
+		if err := c.db.UpdateTransaction(ctx, a.tx.ID, updates); err != nil {
+			return nil, err
+		}
+		a.tx.AmountPaid, a.tx.SettlementStatus = paid, status
+		result.Paid += a.amount
+
+		auditLog := &database.AuditLog{
+			UserID:     userID,
+			Action:     "CREDIT_PAYMENT",
+			EntityType: "transaction",
+			EntityID:   a.tx.ID,
+			NewData:    payment,
+		}
+		if err := c.db.LogAudit(ctx, auditLog); err != nil {
+			log.Printf("⚠️ Failed to create audit log: %v", err)
+		}
+	}
+	result.Remaining = sumOutstanding(open)
+	log.Printf("✅ Repayment recorded: %s Rp %.0f, remaining Rp %.0f", contact.Name, result.Paid, result.Remaining)
+	return result, nil
+}
+
+// Balances returns the open credit of a type per contact, largest first
+func (c *CreditAgent) Balances(ctx context.Context, userID, creditType string) ([]CreditBalance, error) {
+	if c.db == nil {
+		return nil, fmt.Errorf("database not configured")
+	}
+	open, err := c.db.GetOpenCredit(ctx, userID, creditTxTypes(creditType), "")
+	if err != nil {
+		return nil, err
+	}
+	contacts, err := c.db.GetContacts(ctx, userID, "")
+	if err != nil {
+		return nil, err
+	}
+	return groupCredit(open, contacts), nil
+}
+
+// findContact finds an active contact by name, ignoring honorifics
+func (c *CreditAgent) findContact(ctx context.Context, userID, contactType, name string) (*database.Contact, error) {
+	contacts, err := c.db.GetContacts(ctx, userID, contactType)
+	if err != nil {
+		return nil, err
+	}
+	key := ai.ContactKey(name)
+	for i := range contacts {
+		if ai.ContactKey(contacts[i].Name) == key {
+			return &contacts[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// contactFor finds the contact of a credit type by name, adding it when new
+func (c *CreditAgent) contactFor(ctx context.Context, userID, creditType, name string) (*database.Contact, error) {
+	contact, err := c.findContact(ctx, userID, creditContactType(creditType), name)
+	if err != nil || contact != nil {
+		return contact, err
+	}
+	contact = &database.Contact{
+		UserID:   userID,
+		Type:     creditContactType(creditType),
+		Name:     name,
+		IsActive: true,
+	}
+	if err := c.db.CreateContact(ctx, contact); err != nil {
+		log.Printf("❌ Failed to add contact: %v", err)
+		return nil, err
+	}
+	log.Printf("✅ Contact added: %s (%s)", contact.Name, contact.Type)
+	return contact, nil
+}
+
+// outstanding is what a contact owes after a change, 0 when unknown
+func (c *CreditAgent) outstanding(ctx context.Context, userID, creditType, contactID string) float64 {
+	open, err := c.db.GetOpenCredit(ctx, userID, creditTxTypes(creditType), contactID)
+	if err != nil {
+		log.Printf("⚠️ Failed to get open credit: %v", err)
+		return 0
+	}
+	return sumOutstanding(open)
+}
+
+func sumOutstanding(open []database.Transaction) float64 {
+	total := 0.0
+	for i := range open {
+		total += open[i].Outstanding()
+	}
+	return total
+}
+
+// creditAllocation is the part of a repayment applied to one transaction
+type creditAllocation struct {
+	tx     *database.Transaction
+	amount float64
+}
+
+// allocateRepayment pays the oldest credit first and returns what is left
+// over once everything is paid
+func allocateRepayment(open []database.Transaction, amount float64) ([]creditAllocation, float64) {
+	var allocations []creditAllocation
+	for i := range open {
+		if amount <= 0 {
+			break
+		}
+		owed := open[i].Outstanding()
+		if owed <= 0 {
+			continue
+		}
+		paid := math.Min(owed, amount)
+		allocations = append(allocations, creditAllocation{tx: &open[i], amount: paid})
+		amount -= paid
+	}
+	return allocations, math.Max(amount, 0)
+}
+
+// groupCredit sums open credit per contact, largest balance first
+func groupCredit(open []database.Transaction, contacts []database.Contact) []CreditBalance {
+	byID := map[string]*CreditBalance{}
+	var order []string
+	for _, tx := range open {
+		balance, ok := byID[tx.ContactID]
+		if !ok {
+			balance = &CreditBalance{Contact: database.Contact{ID: tx.ContactID, Name: "Tanpa nama"}}
+			for _, contact := range contacts {
+				if contact.ID == tx.ContactID {
+					balance.Contact = contact
+				}
+			}
+			byID[tx.ContactID] = balance
+			order = append(order, tx.ContactID)
+		}
+		balance.Outstanding += tx.Outstanding()
+		if tx.DueDate != "" && (balance.OldestDue == "" || tx.DueDate < balance.OldestDue) {
+			balance.OldestDue = tx.DueDate
+		}
+		balance.Open = append(balance.Open, tx)
+	}
+
+	balances := make([]CreditBalance, 0, len(order))
+	for _, id := range order {
+		balances = append(balances, *byID[id])
+	}
+	sort.SliceStable(balances, func(i, j int) bool { return balances[i].Outstanding > balances[j].Outstanding })
+	return balances
+}
+
+// Aging sums open credit by days overdue on today. Credit without a due
+// date is due defaultCreditDays after it was recorded.
+func Aging(open []database.Transaction, today time.Time) *AgingReport {
+	report := &AgingReport{}
+	for i := range open {
+		owed := open[i].Outstanding()
+		if owed <= 0 {
+			continue
+		}
+		days := overdueDays(&open[i], today)
+		for b, bucket := range agingBuckets {
+			if days <= bucket.maxDays {
+				report.Buckets[b] += owed
+				break
+			}
+		}
+		report.Total += owed
+	}
+	return report
+}
+
+// overdueDays is how many days past its due date a transaction is
+func overdueDays(tx *database.Transaction, today time.Time) int {
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
+	due, err := time.ParseInLocation("2006-01-02", tx.DueDate, today.Location())
+	if err != nil {
+		created, err := time.Parse(time.RFC3339, tx.CreatedAt)
+		if err != nil {
+			return 0
+		}
+		created = created.In(today.Location())
+		due = time.Date(created.Year(), created.Month(), created.Day()+defaultCreditDays, 0, 0, 0, 0, today.Location())
+	}
+	return int(math.Round(today.Sub(due).Hours() / 24))
+}
+
+// creditReminder is the credit of one contact due for a reminder
+type creditReminder struct {
+	userID   string
+	txType   string
+	contact  *database.Contact
+	seller   *database.User
+	amount   float64
+	earliest string // Earliest due date, YYYY-MM-DD
+}
+
+// QueueReminders queues a polite reminder for every contact with credit due
+// by tomorrow, at most once per reminderInterval. Customers with a phone
+// number get the reminder themselves; otherwise the user is reminded.
+func (c *CreditAgent) QueueReminders(ctx context.Context, now time.Time) (int, error) {
+	if c.db == nil || c.notification == nil {
+		return 0, fmt.Errorf("database not configured")
+	}
+
+	due, err := c.db.GetDueCredit(ctx, now.AddDate(0, 0, 1).Format("2006-01-02"))
+	if err != nil {
+		return 0, err
+	}
+
+	since := now.Add(-reminderInterval).UTC().Format(time.RFC3339)
+	queued := 0
+	for _, r := range dueReminders(due) {
+		sent, err := c.db.HasNotificationSince(ctx, r.contact.ID, reminderType, since)
+		if err != nil {
+			log.Printf("⚠️ Failed to check reminders of %s: %v", r.contact.Name, err)
+			continue
+		}
+		if sent {
+			continue
+		}
+
+		title, message, recipient := formatReminder(r, now)
+		if err := c.notification.QueueDebtReminder(ctx, r.userID, recipient, r.contact.ID, title, message); err != nil {
+			continue
+		}
+		queued++
+	}
+	return queued, nil
+}
+
+// RunReminders queues reminders every day at reminderHour WIB until ctx ends
+func (c *CreditAgent) RunReminders(ctx context.Context) {
+	loc := ai.UserLocation("")
+	for {
+		now := time.Now().In(loc)
+		next := time.Date(now.Year(), now.Month(), now.Day(), reminderHour, 0, 0, 0, loc)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(next.Sub(now)):
+			queued, err := c.QueueReminders(ctx, time.Now().In(loc))
+			if err != nil {
+				log.Printf("❌ Credit reminders failed: %v", err)
+				continue
+			}
+			log.Printf("📬 Credit reminders queued: %d", queued)
+		}
+	}
+}
+
+// dueReminders groups due credit per user and contact
+func dueReminders(due []database.Transaction) []*creditReminder {
+	byKey := map[string]*creditReminder{}
+	var reminders []*creditReminder
+	for _, tx := range due {
+		if tx.Contact == nil {
+			continue
+		}
+		key := tx.UserID + "|" + tx.ContactID
+		r, ok := byKey[key]
+		if !ok {
+			r = &creditReminder{userID: tx.UserID, txType: tx.Type, contact: tx.Contact, seller: tx.Seller, earliest: tx.DueDate}
+			byKey[key] = r
+			reminders = append(reminders, r)
+		}
+		r.amount += tx.Outstanding()
+		if tx.DueDate < r.earliest {
+			r.earliest = tx.DueDate
+		}
+	}
+	return reminders
+}
+
+// formatReminder words a reminder and picks its recipient, "" for the user
+func formatReminder(r *creditReminder, now time.Time) (title, message, recipient string) {
+	dueText := "sudah jatuh tempo"
+	if due, err := time.ParseInLocation("2006-01-02", r.earliest, now.Location()); err == nil {
+		dueText = "jatuh tempo " + ai.FormatDate(due)
+	}
+	amount := formatCurrency(r.amount)
+
+	if isPayable(r.txType) {
+		return "🔔 Pengingat Utang",
+			fmt.Sprintf("Utang ke %s sebesar Rp %s %s.\n\nKalau sudah dibayar, ketik \"bayar utang ke %s [jumlah]\" ya.",
+				r.contact.Name, amount, dueText, r.contact.Name), ""
+	}
+	if r.contact.Phone == "" {
+		return "🔔 Pengingat Kasbon",
+			fmt.Sprintf("Kasbon %s sebesar Rp %s %s. %s belum punya nomor WA, jadi belum bisa kami ingatkan langsung.\n\n"+
+				"Kalau sudah dibayar, ketik \"%s bayar [jumlah]\" ya.",
+				r.contact.Name, amount, dueText, r.contact.Name, r.contact.Name), ""
+	}
+
+	shop := "toko kami"
+	if r.seller != nil && r.seller.Name != "" {
+		shop = r.seller.Name
+	}
+	return "🙏 Pengingat Kasbon",
+		fmt.Sprintf("Halo %s, semoga sehat selalu.\n\nSekadar mengingatkan, masih ada kasbon di %s sebesar Rp %s (%s).\n\n"+
+			"Kalau sudah dibayar, mohon abaikan pesan ini. Terima kasih banyak 🙏",
+			r.contact.Name, shop, amount, dueText), r.contact.Phone
+}
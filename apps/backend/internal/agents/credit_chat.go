@@ -0,0 +1,207 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pasarsuara/backend/internal/ai"
+	"github.com/pasarsuara/backend/internal/database"
+)
+
+// recordDebt records kasbon ("Bu Sari ngutang 50 ribu") or the user's own
+// debt to a supplier, filling the response
+func (o *AgentOrchestrator) recordDebt(ctx context.Context, userID string, intent *ai.Intent, response *AgentResponse) {
+	today := time.Now().In(o.userLocation(ctx, userID))
+	entry, err := o.credit.RecordCredit(ctx, userID, intent, today)
+	if err != nil && !errors.Is(err, ErrDuplicateTransaction) {
+		response.Success = false
+		response.Message = "Gagal mencatat kasbon: " + err.Error()
+		return
+	}
+	response.Transaction = entry.Transaction
+	response.Message = formatCreditEntry(entry)
+}
+
+// handleDebtPayment records a repayment, e.g. "Bu Sari bayar 20 ribu"
+func (o *AgentOrchestrator) handleDebtPayment(ctx context.Context, userID string, intent *ai.Intent) string {
+	name := getStringEntity(intent.Entities, "contact")
+	payable := getStringEntity(intent.Entities, "credit_type") == ai.CreditPayable
+
+	repayment, err := o.credit.RecordRepayment(ctx, userID, intent)
+	switch {
+	case errors.Is(err, ErrDuplicateTransaction):
+		return "♻️ Pembayaran ini sudah dicatat sebelumnya."
+	case errors.Is(err, ErrNoCredit) && payable:
+		return fmt.Sprintf("🤔 Tidak ada utang ke %s yang belum lunas.", name)
+	case errors.Is(err, ErrNoCredit):
+		return fmt.Sprintf("🤔 %s tidak punya kasbon yang belum lunas.\n\nKalau ini penjualan biasa, ketik misalnya \"laku nasi 2 porsi 15 ribu\".", name)
+	case err != nil:
+		return "Gagal mencatat pembayaran: " + err.Error()
+	}
+	return formatRepayment(repayment, payable)
+}
+
+// handleAskDebts lists who still owes, with an aging summary, or the open
+// credit of one contact ("kasbon Bu Sari berapa")
+func (o *AgentOrchestrator) handleAskDebts(ctx context.Context, userID string, intent *ai.Intent) string {
+	creditType := getStringEntity(intent.Entities, "credit_type")
+	if creditType == "" {
+		creditType = ai.CreditReceivable
+	}
+	balances, err := o.credit.Balances(ctx, userID, creditType)
+	if err != nil {
+		return "Maaf, daftar kasbon belum bisa diambil. Coba lagi nanti ya!"
+	}
+	today := time.Now().In(o.userLocation(ctx, userID))
+
+	if name := getStringEntity(intent.Entities, "contact"); name != "" {
+		for _, balance := range balances {
+			if ai.ContactKey(balance.Contact.Name) == ai.ContactKey(name) {
+				return formatContactCredit(balance, creditType, today)
+			}
+		}
+		if creditType == ai.CreditPayable {
+			return fmt.Sprintf("🎉 Tidak ada utang ke %s.", name)
+		}
+		return fmt.Sprintf("🎉 %s tidak punya kasbon.", name)
+	}
+	return formatCreditBalances(balances, creditType, today)
+}
+
+func formatCreditEntry(entry *CreditEntry) string {
+	tx := entry.Transaction
+	label, emoji, total := "Kasbon", "👤", "Total kasbon "+entry.Contact.Name
+	if isPayable(tx.Type) {
+		label, emoji, total = "Utang", "🏭", "Total utang ke "+entry.Contact.Name
+	}
+
+	message := fmt.Sprintf("📒 %s tercatat!\n\n"+
+		"%s %s\n"+
+		"💰 Rp %s\n",
+		label, emoji, entry.Contact.Name, formatCurrency(tx.TotalAmount))
+	if due, err := time.Parse("2006-01-02", tx.DueDate); err == nil {
+		message += fmt.Sprintf("📅 Jatuh tempo: %s\n", ai.FormatDate(due))
+	}
+	if entry.Outstanding > tx.TotalAmount {
+		message += fmt.Sprintf("\n%s sekarang Rp %s", total, formatCurrency(entry.Outstanding))
+	}
+	return strings.TrimRight(message, "\n")
+}
+
+// formatCreditTerms tells the user a sale or expense was put on credit
+func formatCreditTerms(entry *CreditEntry) string {
+	label, total := "kasbon "+entry.Contact.Name, "Total kasbon "+entry.Contact.Name
+	if isPayable(entry.Transaction.Type) {
+		label, total = "utang ke "+entry.Contact.Name, "Total utang ke "+entry.Contact.Name
+	}
+	message := "\n\n📒 Dicatat sebagai " + label
+	if due, err := time.Parse("2006-01-02", entry.Transaction.DueDate); err == nil {
+		message += ", jatuh tempo " + ai.FormatDate(due)
+	}
+	if entry.Outstanding > 0 {
+		message += fmt.Sprintf("\n💳 %s sekarang Rp %s", total, formatCurrency(entry.Outstanding))
+	}
+	return message
+}
+
+func formatRepayment(r *Repayment, payable bool) string {
+	name := r.Contact.Name
+	var message string
+	if payable {
+		message = fmt.Sprintf("✅ Pembayaran utang ke %s Rp %s tercatat!\n\n", name, formatCurrency(r.Paid))
+	} else {
+		message = fmt.Sprintf("✅ Pembayaran kasbon %s Rp %s diterima!\n\n", name, formatCurrency(r.Paid))
+	}
+
+	switch {
+	case r.Remaining > 0 && payable:
+		message += fmt.Sprintf("💳 Sisa utang: Rp %s", formatCurrency(r.Remaining))
+	case r.Remaining > 0:
+		message += fmt.Sprintf("💳 Sisa kasbon: Rp %s", formatCurrency(r.Remaining))
+	case payable:
+		message += fmt.Sprintf("🎉 Utang ke %s sudah lunas!", name)
+	default:
+		message += fmt.Sprintf("🎉 Kasbon %s sudah lunas!", name)
+	}
+	if r.Overpaid > 0 {
+		message += fmt.Sprintf("\n\n⚠️ Ada kelebihan Rp %s dari total yang harus dibayar, belum dicatat.", formatCurrency(r.Overpaid))
+	}
+	return message
+}
+
+// formatCreditBalances lists contacts by what they owe, then the aging report
+func formatCreditBalances(balances []CreditBalance, creditType string, today time.Time) string {
+	payable := creditType == ai.CreditPayable
+	if len(balances) == 0 {
+		if payable {
+			return "🎉 Tidak ada utang ke supplier. Semua sudah lunas!"
+		}
+		return "🎉 Tidak ada yang ngutang. Semua kasbon sudah lunas!"
+	}
+
+	var sb strings.Builder
+	if payable {
+		sb.WriteString("📒 *Daftar Utang ke Supplier*\n\n")
+	} else {
+		sb.WriteString("📒 *Daftar Kasbon Pelanggan*\n\n")
+	}
+
+	var open []database.Transaction
+	for i, balance := range balances {
+		sb.WriteString(fmt.Sprintf("%d. *%s*: Rp %s%s\n", i+1, balance.Contact.Name,
+			formatCurrency(balance.Outstanding), formatDue(balance.OldestDue, today)))
+		open = append(open, balance.Open...)
+	}
+
+	aging := Aging(open, today)
+	sb.WriteString(fmt.Sprintf("\n💰 Total: Rp %s\n\n", formatCurrency(aging.Total)))
+	if payable {
+		sb.WriteString("⏳ *Umur Utang*\n")
+	} else {
+		sb.WriteString("⏳ *Umur Piutang*\n")
+	}
+	for b, amount := range aging.Buckets {
+		if amount > 0 {
+			sb.WriteString(fmt.Sprintf("• %s: Rp %s\n", agingBuckets[b].label, formatCurrency(amount)))
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// formatContactCredit lists the open credit of one contact
+func formatContactCredit(balance CreditBalance, creditType string, today time.Time) string {
+	var sb strings.Builder
+	if creditType == ai.CreditPayable {
+		sb.WriteString(fmt.Sprintf("📒 *Utang ke %s*\n\n", balance.Contact.Name))
+	} else {
+		sb.WriteString(fmt.Sprintf("📒 *Kasbon %s*\n\n", balance.Contact.Name))
+	}
+	for _, tx := range balance.Open {
+		line := "• Rp " + formatCurrency(tx.Outstanding())
+		if tx.AmountPaid > 0 {
+			line += fmt.Sprintf(" (dari Rp %s)", formatCurrency(tx.TotalAmount))
+		}
+		sb.WriteString(line + formatDue(tx.DueDate, today) + "\n")
+	}
+	sb.WriteString(fmt.Sprintf("\n💰 Sisa: Rp %s", formatCurrency(balance.Outstanding)))
+	return sb.String()
+}
+
+// formatDue describes a due date relative to today, e.g. ", telat 3 hari"
+func formatDue(dueDate string, today time.Time) string {
+	if dueDate == "" {
+		return ""
+	}
+	days := overdueDays(&database.Transaction{DueDate: dueDate}, today)
+	switch {
+	case days > 0:
+		return fmt.Sprintf(" ⚠️ telat %d hari", days)
+	case days == 0:
+		return " (jatuh tempo hari ini)"
+	}
+	due, _ := time.ParseInLocation("2006-01-02", dueDate, today.Location())
+	return fmt.Sprintf(" (jatuh tempo %s)", ai.FormatDate(due))
+}
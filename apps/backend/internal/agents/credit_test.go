@@ -0,0 +1,218 @@
+package agents
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pasarsuara/backend/internal/ai"
+	"github.com/pasarsuara/backend/internal/database"
+)
+
+func openCredit(id, contactID string, total, paid float64, due string) database.Transaction {
+	status := database.SettlementOpen
+	if paid > 0 {
+		status = database.SettlementPartial
+	}
+	return database.Transaction{ID: id, Type: "SALE", ContactID: contactID, TotalAmount: total,
+		AmountPaid: paid, DueDate: due, SettlementStatus: status}
+}
+
+func TestAllocateRepayment(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   float64
+		want     []float64
+		leftover float64
+	}{
+		{"partial on oldest", 20000, []float64{20000}, 0},
+		{"settles oldest then next", 45000, []float64{30000, 15000}, 0},
+		{"everything and more", 150000, []float64{30000, 50000}, 70000},
+		{"nothing", 0, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			open := []database.Transaction{
+				openCredit("a", "sari", 50000, 20000, "2026-10-01"),
+				openCredit("b", "sari", 50000, 0, "2026-10-15"),
+			}
+			allocations, leftover := allocateRepayment(open, tt.amount)
+			if len(allocations) != len(tt.want) || leftover != tt.leftover {
+				t.Fatalf("allocations = %+v, leftover %.0f", allocations, leftover)
+			}
+			for i, a := range allocations {
+				if a.amount != tt.want[i] || a.tx != &open[i] {
+					t.Errorf("allocation %d = %.0f on %s, want %.0f on %s", i, a.amount, a.tx.ID, tt.want[i], open[i].ID)
+				}
+			}
+		})
+	}
+}
+
+func TestAging(t *testing.T) {
+	today := time.Date(2026, 10, 19, 15, 0, 0, 0, ai.UserLocation(""))
+	open := []database.Transaction{
+		openCredit("a", "sari", 50000, 0, "2026-10-25"),     // not yet due
+		openCredit("b", "sari", 40000, 10000, "2026-10-19"), // due today
+		openCredit("c", "joko", 25000, 0, "2026-10-10"),     // 9 days late
+		openCredit("d", "joko", 15000, 0, "2026-08-01"),     // 79 days late
+		openCredit("e", "budi", 10000, 0, "2026-06-01"),     // 140 days late
+		{ID: "f", TotalAmount: 99000, SettlementStatus: database.SettlementPaid, DueDate: "2026-01-01"},
+	}
+
+	report := Aging(open, today)
+	want := [len(agingBuckets)]float64{80000, 25000, 0, 15000, 10000}
+	if report.Buckets != want || report.Total != 130000 {
+		t.Errorf("Aging = %+v, want buckets %v and total 130000", report, want)
+	}
+}
+
+func TestGroupCredit(t *testing.T) {
+	open := []database.Transaction{
+		openCredit("a", "sari", 30000, 0, "2026-10-25"),
+		openCredit("b", "joko", 80000, 0, "2026-10-10"),
+		openCredit("c", "sari", 60000, 0, "2026-10-05"),
+	}
+	contacts := []database.Contact{{ID: "sari", Name: "Bu Sari"}, {ID: "joko", Name: "Pak Joko"}}
+
+	balances := groupCredit(open, contacts)
+	if len(balances) != 2 {
+		t.Fatalf("balances = %+v", balances)
+	}
+	if balances[0].Contact.Name != "Bu Sari" || balances[0].Outstanding != 90000 || balances[0].OldestDue != "2026-10-05" ||
+		len(balances[0].Open) != 2 {
+		t.Errorf("First balance = %+v, want Bu Sari owing 90000 since 2026-10-05", balances[0])
+	}
+	if balances[1].Contact.Name != "Pak Joko" || balances[1].Outstanding != 80000 {
+		t.Errorf("Second balance = %+v", balances[1])
+	}
+}
+
+func TestCreditDueDate(t *testing.T) {
+	today := time.Date(2026, 10, 19, 9, 0, 0, 0, ai.UserLocation(""))
+	tests := []struct {
+		entities map[string]any
+		want     string
+	}{
+		{map[string]any{}, "2026-11-18"},
+		{map[string]any{"end_date": "2026-10-25"}, "2026-10-25"}, // "bayar minggu depan"
+		{map[string]any{"end_date": "2026-10-18"}, "2026-11-18"}, // "kemarin" is when, not until
+	}
+	for _, tt := range tests {
+		if got := creditDueDate(&ai.Intent{Entities: tt.entities}, today); got != tt.want {
+			t.Errorf("creditDueDate(%v) = %s, want %s", tt.entities, got, tt.want)
+		}
+	}
+}
+
+func TestFormatReminder(t *testing.T) {
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, ai.UserLocation(""))
+	tests := []struct {
+		name      string
+		reminder  *creditReminder
+		recipient string
+		contains  []string
+	}{
+		{"customer with phone",
+			&creditReminder{txType: "SALE", contact: &database.Contact{Name: "Bu Sari", Phone: "628123"},
+				seller: &database.User{Name: "Warung Bu Tini"}, amount: 30000, earliest: "2026-10-18"},
+			"628123", []string{"Halo Bu Sari", "Warung Bu Tini", "Rp 30.000", "18 Oktober 2026", "abaikan"}},
+		{"customer without phone",
+			&creditReminder{txType: "SALE", contact: &database.Contact{Name: "Pak Joko"}, amount: 25000, earliest: "2026-10-20"},
+			"", []string{"Kasbon Pak Joko", "Rp 25.000", "Pak Joko bayar"}},
+		{"own debt",
+			&creditReminder{txType: "PURCHASE", contact: &database.Contact{Name: "Pak Budi", Phone: "628999"}, amount: 200000, earliest: "2026-10-20"},
+			"", []string{"Utang ke Pak Budi", "Rp 200.000", "bayar utang ke Pak Budi"}},
+		{"own debt without goods",
+			&creditReminder{txType: "EXPENSE", contact: &database.Contact{Name: "Pak Budi"}, amount: 200000, earliest: "2026-10-20"},
+			"", []string{"Utang ke Pak Budi", "Rp 200.000"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, message, recipient := formatReminder(tt.reminder, now)
+			if recipient != tt.recipient {
+				t.Errorf("recipient = %q, want %q", recipient, tt.recipient)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(message, want) {
+					t.Errorf("Reminder missing %q:\n%s", want, message)
+				}
+			}
+		})
+	}
+}
+
+func TestDueReminders_GroupsPerContact(t *testing.T) {
+	sari := &database.Contact{ID: "sari", Name: "Bu Sari"}
+	due := []database.Transaction{
+		openCredit("a", "sari", 30000, 0, "2026-10-18"),
+		openCredit("b", "sari", 20000, 5000, "2026-10-12"),
+		openCredit("c", "joko", 10000, 0, "2026-10-12"), // contact deleted
+	}
+	due[0].UserID, due[0].Contact = "u1", sari
+	due[1].UserID, due[1].Contact = "u1", sari
+	due[2].UserID = "u1"
+
+	reminders := dueReminders(due)
+	if len(reminders) != 1 || reminders[0].amount != 45000 || reminders[0].earliest != "2026-10-12" {
+		t.Errorf("reminders = %+v, want one for Bu Sari of 45000 since 2026-10-12", reminders)
+	}
+}
+
+func TestFormatCreditBalances(t *testing.T) {
+	today := time.Date(2026, 10, 19, 9, 0, 0, 0, ai.UserLocation(""))
+	balances := groupCredit([]database.Transaction{
+		openCredit("a", "sari", 50000, 20000, "2026-10-16"),
+		openCredit("b", "joko", 25000, 0, "2026-11-18"),
+	}, []database.Contact{{ID: "sari", Name: "Bu Sari"}, {ID: "joko", Name: "Pak Joko"}})
+
+	got := formatCreditBalances(balances, ai.CreditReceivable, today)
+	for _, want := range []string{"1. *Bu Sari*: Rp 30.000 ⚠️ telat 3 hari", "2. *Pak Joko*: Rp 25.000 (jatuh tempo 18 November 2026)",
+		"Total: Rp 55.000", "Belum jatuh tempo: Rp 25.000", "Telat 1-30 hari: Rp 30.000"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Balances missing %q:\n%s", want, got)
+		}
+	}
+
+	if got := formatCreditBalances(nil, ai.CreditReceivable, today); !strings.Contains(got, "Tidak ada yang ngutang") {
+		t.Errorf("Empty balances = %q", got)
+	}
+}
+
+func TestProcessIntent_DebtWithoutAmountAsks(t *testing.T) {
+	o := &AgentOrchestrator{credit: NewCreditAgent(nil, nil), pending: NewPendingActionStore(0), recent: NewRecentTransactions(0)}
+	intent, _ := ai.ParseIntentRules("Bu Sari ngutang")
+
+	resp := o.processIntent(t.Context(), "628111", intent)
+	if resp.Success || !strings.Contains(resp.Message, "Kasbon Bu Sari berapa?") {
+		t.Errorf("Debt without amount should ask for it, got %+v", resp)
+	}
+}
+
+func TestProcessIntent_CreditSaleWithoutContactAsks(t *testing.T) {
+	o := &AgentOrchestrator{credit: NewCreditAgent(nil, nil), pending: NewPendingActionStore(0), recent: NewRecentTransactions(0)}
+	intent, _ := ai.ParseIntentRules("jual bakso 10 porsi 15rb ngutang")
+	if intent.Action != "RECORD_SALE" {
+		t.Fatalf("Action = %s, want RECORD_SALE", intent.Action)
+	}
+
+	resp := o.processIntent(t.Context(), "628111", intent)
+	if resp.Success || resp.Transaction != nil || !strings.Contains(resp.Message, "atas nama siapa") {
+		t.Errorf("Credit sale without a contact should ask for it, got %+v", resp)
+	}
+}
+
+func TestCreditTermsApply(t *testing.T) {
+	tx := &database.Transaction{Type: "SALE", ProductName: "bakso", Qty: 10, TotalAmount: 150000}
+	(*CreditTerms)(nil).apply(tx)
+	if tx.SettlementStatus != "" {
+		t.Errorf("nil terms put the sale on credit: %+v", tx)
+	}
+	(&CreditTerms{Contact: &database.Contact{ID: "c1"}, DueDate: "2026-11-18"}).apply(tx)
+	if tx.ContactID != "c1" || tx.DueDate != "2026-11-18" || tx.SettlementStatus != database.SettlementOpen {
+		t.Errorf("terms not applied: %+v", tx)
+	}
+	if creditMoneyType(ai.CreditReceivable) != "LOAN" || creditMoneyType(ai.CreditPayable) != "EXPENSE" {
+		t.Error("kasbon without goods must not be a sale or purchase")
+	}
+}
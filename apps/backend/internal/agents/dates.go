@@ -0,0 +1,68 @@
+package agents
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/pasarsuara/backend/internal/ai"
+	"github.com/pasarsuara/backend/internal/database"
+)
+
+// addDateEntities resolves "kemarin", "senin lalu" or "tanggal 1 sampai 15"
+// in the user's timezone. Preferences are only read when the message has a
+// date in it.
+func (o *AgentOrchestrator) addDateEntities(ctx context.Context, userID string, intent *ai.Intent) {
+	if ai.ParseDateRange(intent.RawText, time.Now()) == nil {
+		return
+	}
+	r := ai.AddDateEntities(intent, time.Now().In(o.userLocation(ctx, userID)))
+	log.Printf("📅 Date resolved: %s", ai.FormatDateRange(r))
+}
+
+// userLocation returns the user's timezone, WIB by default
+func (o *AgentOrchestrator) userLocation(ctx context.Context, userID string) *time.Location {
+	return userLocation(ctx, o.db, userID)
+}
+
+// userLocation returns the timezone in a user's preferences, WIB by default
+func userLocation(ctx context.Context, db *database.SupabaseClient, userID string) *time.Location {
+	if db == nil {
+		return ai.UserLocation("")
+	}
+	prefs, err := db.GetUserPreferences(ctx, userID)
+	if err != nil {
+		log.Printf("⚠️ Failed to get user preferences: %v", err)
+	}
+	if prefs == nil {
+		return ai.UserLocation("")
+	}
+	return ai.UserLocation(prefs.Timezone)
+}
+
+// transactionTime returns the backdated time for a transaction, or "" to
+// let the database use now. Future dates are ignored.
+func transactionTime(intent *ai.Intent) string {
+	occurred := getStringEntity(intent.Entities, "occurred_at")
+	if occurred == "" {
+		return ""
+	}
+	t, err := time.Parse(time.RFC3339, occurred)
+	if err != nil || t.After(time.Now()) {
+		return ""
+	}
+	return occurred
+}
+
+// formatBackdate tells the user which day a backdated transaction was recorded on
+func formatBackdate(intent *ai.Intent) string {
+	occurred := transactionTime(intent)
+	if occurred == "" {
+		return ""
+	}
+	t, _ := time.Parse(time.RFC3339, occurred)
+	if t.Format("2006-01-02") == time.Now().In(t.Location()).Format("2006-01-02") {
+		return ""
+	}
+	return "\n📅 Dicatat untuk tanggal " + ai.FormatDate(t)
+}
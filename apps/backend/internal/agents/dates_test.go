@@ -0,0 +1,48 @@
+package agents
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pasarsuara/backend/internal/ai"
+)
+
+func TestProcessIntent_Dates(t *testing.T) {
+	o := &AgentOrchestrator{finance: NewFinanceAgent(nil), pending: NewPendingActionStore(0)}
+	ctx := context.Background()
+
+	// Backdated sale
+	sale := &ai.Intent{
+		Action:   "RECORD_SALE",
+		Entities: map[string]any{"product": "bakso", "qty": 5.0, "price": 15000.0},
+		RawText:  "kemarin laku bakso 5 mangkok 15rb",
+	}
+	resp := o.processIntent(ctx, "628111", sale)
+	yesterday := time.Now().In(ai.UserLocation("")).AddDate(0, 0, -1).Format("2006-01-02")
+	if !strings.HasPrefix(resp.Transaction.CreatedAt, yesterday) {
+		t.Errorf("CreatedAt = %q, want %s", resp.Transaction.CreatedAt, yesterday)
+	}
+	if !strings.Contains(resp.Message, "Dicatat untuk tanggal") {
+		t.Errorf("Message should mention the backdate: %s", resp.Message)
+	}
+
+	// Future dates are not backdated
+	future := &ai.Intent{
+		Action:   "RECORD_EXPENSE",
+		Entities: map[string]any{"product": "gas", "price": 22000.0},
+		RawText:  "besok bayar gas 22rb",
+	}
+	resp = o.processIntent(ctx, "628111", future)
+	if resp.Transaction.CreatedAt != "" {
+		t.Errorf("Future expense should not be backdated, got %q", resp.Transaction.CreatedAt)
+	}
+
+	// Custom report range
+	report := &ai.Intent{Action: "REQUEST_REPORT", Entities: map[string]any{}, RawText: "laporan dari tanggal 1 sampai 2"}
+	resp = o.processIntent(ctx, "628111", report)
+	if !strings.Contains(resp.Message, "Laporan 1 - 2 ") {
+		t.Errorf("Expected a range report, got %s", resp.Message)
+	}
+}
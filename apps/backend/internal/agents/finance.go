@@ -4,22 +4,26 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math"
 
 	"github.com/pasarsuara/backend/internal/ai"
 	"github.com/pasarsuara/backend/internal/database"
+	"github.com/pasarsuara/backend/internal/ledger"
 )
 
 // FinanceAgent handles transaction recording
 type FinanceAgent struct {
-	db *database.SupabaseClient
+	db      *database.SupabaseClient
+	journal *ledger.Journal
 }
 
 func NewFinanceAgent(db *database.SupabaseClient) *FinanceAgent {
-	return &FinanceAgent{db: db}
+	return &FinanceAgent{db: db, journal: ledger.NewJournal(db)}
 }
 
-// RecordSale records a sale transaction with payment and audit log
-func (f *FinanceAgent) RecordSale(ctx context.Context, userID string, intent *ai.Intent) (*database.Transaction, error) {
+// RecordSale records a sale transaction with payment and audit log. A sale
+// on credit (credit not nil) is left open for the customer's repayments.
+func (f *FinanceAgent) RecordSale(ctx context.Context, userID string, intent *ai.Intent, credit *CreditTerms) (*database.Transaction, error) {
 	log.Printf("💰 Finance Agent: Recording sale for user %s", userID)
 
 	product := getStringEntity(intent.Entities, "product")
@@ -27,34 +31,41 @@ func (f *FinanceAgent) RecordSale(ctx context.Context, userID string, intent *ai
 	price := getFloatEntity(intent.Entities, "price")
 
 	tx := &database.Transaction{
-		UserID:       userID,
-		Type:         "SALE",
-		ProductName:  product,
-		Qty:          qty,
-		PricePerUnit: price,
-		TotalAmount:  qty * price,
-		RawVoiceText: intent.RawText,
+		UserID:         userID,
+		Type:           "SALE",
+		ProductName:    product,
+		Qty:            qty,
+		PricePerUnit:   price,
+		TotalAmount:    qty * price,
+		RawVoiceText:   intent.RawText,
+		IdempotencyKey: IdempotencyKeyFromContext(ctx),
+		CreatedAt:      transactionTime(intent),
 	}
+	tx.PPNAmount = salePPN(ctx, f.db, userID, tx.TotalAmount)
+	credit.apply(tx)
 
 	if f.db != nil {
+		// Skip messages that were already recorded (redelivery/retry)
+		if existing := f.findRecorded(ctx, userID, tx.IdempotencyKey); existing != nil {
+			log.Printf("♻️ Sale already recorded for message %s, skipping", tx.IdempotencyKey)
+			return existing, ErrDuplicateTransaction
+		}
+
 		// Create transaction
 		if err := f.db.CreateTransaction(ctx, tx); err != nil {
+			// A concurrent request may have won the unique index race
+			if existing := f.findRecorded(ctx, userID, tx.IdempotencyKey); existing != nil {
+				return existing, ErrDuplicateTransaction
+			}
 			log.Printf("❌ Failed to record sale: %v", err)
 			return nil, err
 		}
 		log.Printf("✅ Sale recorded: %s x%.0f = Rp %.0f", product, qty, tx.TotalAmount)
+		f.recordCOGS(ctx, tx)
+		f.journal.PostTransaction(ctx, tx, "")
 
 		// Create payment record (assume cash payment for now)
-		payment := &database.Payment{
-			TransactionID: tx.ID,
-			Amount:        tx.TotalAmount,
-			PaymentMethod: "CASH",
-			Status:        "PAID",
-			PaidAt:        tx.CreatedAt,
-		}
-		if err := f.db.CreatePayment(ctx, payment); err != nil {
-			log.Printf("⚠️ Failed to create payment record: %v", err)
-		}
+		f.payOnTheSpot(ctx, tx)
 
 		// Create audit log
 		auditLog := &database.AuditLog{
@@ -82,22 +93,36 @@ func (f *FinanceAgent) RecordPurchase(ctx context.Context, userID string, intent
 	qty := getFloatEntity(intent.Entities, "qty")
 
 	tx := &database.Transaction{
-		UserID:       userID,
-		Type:         "PURCHASE",
-		ProductName:  product,
-		Qty:          qty,
-		PricePerUnit: finalPrice,
-		TotalAmount:  qty * finalPrice,
-		RawVoiceText: intent.RawText,
+		UserID:         userID,
+		Type:           "PURCHASE",
+		ProductName:    product,
+		Qty:            qty,
+		PricePerUnit:   finalPrice,
+		TotalAmount:    qty * finalPrice,
+		RawVoiceText:   intent.RawText,
+		IdempotencyKey: IdempotencyKeyFromContext(ctx),
+		CreatedAt:      transactionTime(intent),
 	}
 
 	if f.db != nil {
+		// Skip messages that were already recorded (redelivery/retry)
+		if existing := f.findRecorded(ctx, userID, tx.IdempotencyKey); existing != nil {
+			log.Printf("♻️ Purchase already recorded for message %s, skipping", tx.IdempotencyKey)
+			return existing, ErrDuplicateTransaction
+		}
+
 		// Create transaction
 		if err := f.db.CreateTransaction(ctx, tx); err != nil {
+			// A concurrent request may have won the unique index race
+			if existing := f.findRecorded(ctx, userID, tx.IdempotencyKey); existing != nil {
+				return existing, ErrDuplicateTransaction
+			}
 			log.Printf("❌ Failed to record purchase: %v", err)
 			return nil, err
 		}
 		log.Printf("✅ Purchase recorded: %s x%.0f @ Rp %.0f = Rp %.0f", product, qty, finalPrice, tx.TotalAmount)
+		f.addCostLayer(ctx, tx)
+		f.journal.PostTransaction(ctx, tx, "")
 
 		// Create payment record (pending by default for purchases)
 		payment := &database.Payment{
@@ -126,8 +151,9 @@ func (f *FinanceAgent) RecordPurchase(ctx context.Context, userID string, intent
 	return tx, nil
 }
 
-// RecordExpense records an expense transaction with payment and audit log
-func (f *FinanceAgent) RecordExpense(ctx context.Context, userID string, intent *ai.Intent) (*database.Transaction, error) {
+// RecordExpense records an expense transaction with payment and audit log.
+// An expense on credit (credit not nil) is left open until it is paid.
+func (f *FinanceAgent) RecordExpense(ctx context.Context, userID string, intent *ai.Intent, credit *CreditTerms) (*database.Transaction, error) {
 	log.Printf("💸 Finance Agent: Recording expense for user %s", userID)
 
 	product := getStringEntity(intent.Entities, "product")
@@ -139,34 +165,40 @@ func (f *FinanceAgent) RecordExpense(ctx context.Context, userID string, intent
 	}
 
 	tx := &database.Transaction{
-		UserID:       userID,
-		Type:         "EXPENSE",
-		ProductName:  product,
-		Qty:          qty,
-		PricePerUnit: price,
-		TotalAmount:  qty * price,
-		RawVoiceText: intent.RawText,
+		UserID:          userID,
+		Type:            "EXPENSE",
+		ProductName:     product,
+		Qty:             qty,
+		PricePerUnit:    price,
+		TotalAmount:     qty * price,
+		RawVoiceText:    intent.RawText,
+		IdempotencyKey:  IdempotencyKeyFromContext(ctx),
+		ExpenseCategory: string(CategorizeExpense(product)),
+		CreatedAt:       transactionTime(intent),
 	}
+	credit.apply(tx)
 
 	if f.db != nil {
+		// Skip messages that were already recorded (redelivery/retry)
+		if existing := f.findRecorded(ctx, userID, tx.IdempotencyKey); existing != nil {
+			log.Printf("♻️ Expense already recorded for message %s, skipping", tx.IdempotencyKey)
+			return existing, ErrDuplicateTransaction
+		}
+
 		// Create transaction
 		if err := f.db.CreateTransaction(ctx, tx); err != nil {
+			// A concurrent request may have won the unique index race
+			if existing := f.findRecorded(ctx, userID, tx.IdempotencyKey); existing != nil {
+				return existing, ErrDuplicateTransaction
+			}
 			log.Printf("❌ Failed to record expense: %v", err)
 			return nil, err
 		}
 		log.Printf("✅ Expense recorded: %s = Rp %.0f", product, tx.TotalAmount)
+		f.journal.PostTransaction(ctx, tx, expenseAccount(tx))
 
 		// Create payment record
-		payment := &database.Payment{
-			TransactionID: tx.ID,
-			Amount:        tx.TotalAmount,
-			PaymentMethod: "CASH",
-			Status:        "PAID",
-			PaidAt:        tx.CreatedAt,
-		}
-		if err := f.db.CreatePayment(ctx, payment); err != nil {
-			log.Printf("⚠️ Failed to create payment record: %v", err)
-		}
+		f.payOnTheSpot(ctx, tx)
 
 		// Create audit log
 		auditLog := &database.AuditLog{
@@ -184,6 +216,200 @@ func (f *FinanceAgent) RecordExpense(ctx context.Context, userID string, intent
 	return tx, nil
 }
 
+// payOnTheSpot records the cash payment of a sale or expense; transactions
+// on credit are paid later by repayments
+func (f *FinanceAgent) payOnTheSpot(ctx context.Context, tx *database.Transaction) {
+	if tx.SettlementStatus != "" {
+		return
+	}
+	payment := &database.Payment{
+		TransactionID: tx.ID,
+		Amount:        tx.TotalAmount,
+		PaymentMethod: "CASH",
+		Status:        "PAID",
+		PaidAt:        tx.CreatedAt,
+	}
+	if err := f.db.CreatePayment(ctx, payment); err != nil {
+		log.Printf("⚠️ Failed to create payment record: %v", err)
+	} else {
+		f.journal.PostPayment(ctx, tx, payment)
+	}
+}
+
+// correctedCopy applies corrected product, qty or price to a copy of tx
+func correctedCopy(tx *database.Transaction, changes map[string]any) *database.Transaction {
+	updated := *tx
+	if product := getStringEntity(changes, "product"); product != "" {
+		updated.ProductName = product
+		if updated.Type == "EXPENSE" {
+			updated.ExpenseCategory = string(CategorizeExpense(product))
+		}
+	}
+	if qty := getFloatEntity(changes, "qty"); qty > 0 {
+		updated.Qty = qty
+	}
+	if price := getFloatEntity(changes, "price"); price > 0 {
+		updated.PricePerUnit = price
+	} else if price := getFloatEntity(changes, "max_price"); price > 0 {
+		updated.PricePerUnit = price
+	}
+	updated.TotalAmount = updated.Qty * updated.PricePerUnit
+	if tx.PPNAmount > 0 && tx.TotalAmount > 0 {
+		// The PPN stays the same share of the corrected total
+		updated.PPNAmount = math.Round(tx.PPNAmount * updated.TotalAmount / tx.TotalAmount)
+	}
+	return &updated
+}
+
+// CorrectTransaction replaces a recorded transaction with a corrected copy.
+// The original is voided rather than overwritten; the copy keeps its date
+// and points back to it, so reports and the audit trail stay consistent.
+// It returns ErrTransactionNotFound when the original was already void.
+func (f *FinanceAgent) CorrectTransaction(ctx context.Context, userID string, tx *database.Transaction, changes map[string]any) (*database.Transaction, error) {
+	log.Printf("✏️ Finance Agent: Correcting transaction %s for user %s", tx.ID, userID)
+
+	updated := correctedCopy(tx, changes)
+	updated.ID = ""
+	updated.ReplacesID = tx.ID
+	updated.IdempotencyKey = IdempotencyKeyFromContext(ctx)
+	updated.VoidedAt = ""
+	updated.VoidReason = ""
+	updated.COGS = 0
+
+	if f.db == nil {
+		log.Printf("⚠️ Database not configured, correction not persisted")
+		return updated, nil
+	}
+
+	// Skip corrections that were already applied (redelivery/retry)
+	if existing := f.findRecorded(ctx, userID, updated.IdempotencyKey); existing != nil {
+		log.Printf("♻️ Correction already recorded for message %s, skipping", updated.IdempotencyKey)
+		return existing, ErrDuplicateTransaction
+	}
+
+	voided, err := f.db.VoidTransaction(ctx, tx.ID, database.VoidCorrected)
+	if err != nil {
+		log.Printf("❌ Failed to void corrected transaction: %v", err)
+		return nil, err
+	}
+	if !voided {
+		return nil, ErrTransactionNotFound
+	}
+	if err := f.db.CreateTransaction(ctx, updated); err != nil {
+		log.Printf("❌ Failed to record correction: %v", err)
+		if err := f.db.RestoreTransaction(ctx, tx.ID); err != nil {
+			log.Printf("⚠️ Failed to restore transaction %s: %v", tx.ID, err)
+		}
+		return nil, err
+	}
+
+	// The books reverse the original before costing posts its adjustments
+	f.journal.ReverseTransaction(ctx, tx.ID)
+
+	// Costing follows the replacement: a sale is costed again, a purchase
+	// layer takes the corrected qty and price
+	f.releaseCOGS(ctx, tx)
+	f.recordCOGS(ctx, updated)
+	f.moveCostLayer(ctx, tx, updated)
+	f.journal.PostTransaction(ctx, updated, expenseAccount(updated))
+
+	// The payment moves to the replacement with the new total
+	if payments, err := f.db.GetPaymentsByTransaction(ctx, tx.ID); err == nil && len(payments) == 1 {
+		payment := payments[0]
+		if err := f.db.UpdatePayment(ctx, payment.ID, map[string]any{
+			"transaction_id": updated.ID,
+			"amount":         updated.TotalAmount,
+		}); err != nil {
+			log.Printf("⚠️ Failed to update payment: %v", err)
+		} else {
+			payment.TransactionID, payment.Amount = updated.ID, updated.TotalAmount
+			f.journal.PostPayment(ctx, updated, &payment)
+		}
+	}
+
+	auditLog := &database.AuditLog{
+		UserID:     userID,
+		Action:     "CORRECT_TRANSACTION",
+		EntityType: "transaction",
+		EntityID:   updated.ID,
+		OldData:    tx,
+		NewData:    updated,
+	}
+	if err := f.db.LogAudit(ctx, auditLog); err != nil {
+		log.Printf("⚠️ Failed to create audit log: %v", err)
+	}
+
+	return updated, nil
+}
+
+// CancelTransaction voids a recorded transaction and refunds its payments.
+// The row is kept, reports skip it. It returns ErrTransactionNotFound when
+// the transaction was already void.
+func (f *FinanceAgent) CancelTransaction(ctx context.Context, userID string, tx *database.Transaction) error {
+	log.Printf("🗑️ Finance Agent: Cancelling transaction %s for user %s", tx.ID, userID)
+
+	if f.db == nil {
+		log.Printf("⚠️ Database not configured, cancellation not persisted")
+		return nil
+	}
+
+	voided, err := f.db.VoidTransaction(ctx, tx.ID, database.VoidCancelled)
+	if err != nil {
+		log.Printf("❌ Failed to cancel transaction: %v", err)
+		return err
+	}
+	if !voided {
+		return ErrTransactionNotFound // Cancelled or corrected meanwhile
+	}
+	f.journal.ReverseTransaction(ctx, tx.ID)
+	f.releaseCOGS(ctx, tx)
+	f.removeCostLayer(ctx, tx)
+
+	if payments, err := f.db.GetPaymentsByTransaction(ctx, tx.ID); err == nil {
+		for _, payment := range payments {
+			if err := f.db.UpdatePayment(ctx, payment.ID, map[string]any{
+				"status": "REFUNDED",
+				"notes":  "Transaksi dibatalkan",
+			}); err != nil {
+				log.Printf("⚠️ Failed to refund payment: %v", err)
+			}
+		}
+	}
+
+	auditLog := &database.AuditLog{
+		UserID:     userID,
+		Action:     "VOID_TRANSACTION",
+		EntityType: "transaction",
+		EntityID:   tx.ID,
+		OldData:    tx,
+	}
+	if err := f.db.LogAudit(ctx, auditLog); err != nil {
+		log.Printf("⚠️ Failed to create audit log: %v", err)
+	}
+	return nil
+}
+
+// expenseAccount is the ledger account an expense is posted to, by category
+func expenseAccount(tx *database.Transaction) string {
+	if tx.Type != "EXPENSE" {
+		return ""
+	}
+	return ledger.ExpenseAccount(string(TransactionCategory(tx)))
+}
+
+// findRecorded returns the transaction already recorded for an idempotency key
+func (f *FinanceAgent) findRecorded(ctx context.Context, userID, key string) *database.Transaction {
+	if f.db == nil || key == "" {
+		return nil
+	}
+	existing, err := f.db.GetTransactionByIdempotencyKey(ctx, userID, key)
+	if err != nil {
+		log.Printf("⚠️ Failed to check idempotency key: %v", err)
+		return nil
+	}
+	return existing
+}
+
 // GetDailySummary returns today's transaction summary
 func (f *FinanceAgent) GetDailySummary(ctx context.Context, userID string) string {
 	// TODO: Query actual data from database
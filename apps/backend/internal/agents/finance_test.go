@@ -2,9 +2,13 @@ package agents
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/pasarsuara/backend/internal/ai"
+	"github.com/pasarsuara/backend/internal/database"
 )
 
 func TestFinanceAgent_RecordSale(t *testing.T) {
@@ -48,7 +52,7 @@ func TestFinanceAgent_RecordSale(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tx, err := agent.RecordSale(context.Background(), "test-user", tt.intent)
+			tx, err := agent.RecordSale(context.Background(), "test-user", tt.intent, nil)
 			if err != nil {
 				t.Errorf("RecordSale() error = %v", err)
 				return
@@ -76,7 +80,7 @@ func TestFinanceAgent_RecordExpense(t *testing.T) {
 		RawText: "beli gas 2 tabung",
 	}
 
-	tx, err := agent.RecordExpense(context.Background(), "test-user", intent)
+	tx, err := agent.RecordExpense(context.Background(), "test-user", intent, nil)
 	if err != nil {
 		t.Errorf("RecordExpense() error = %v", err)
 		return
@@ -91,3 +95,48 @@ func TestFinanceAgent_RecordExpense(t *testing.T) {
 		t.Errorf("RecordExpense() total = %v, want %v", tx.TotalAmount, expectedTotal)
 	}
 }
+
+func TestFinanceAgent_IdempotencyKey(t *testing.T) {
+	agent := NewFinanceAgent(nil)
+	intent := &ai.Intent{
+		Action:   "RECORD_SALE",
+		Entities: map[string]any{"product": "es teh", "qty": float64(3), "price": float64(5000)},
+	}
+
+	ctx := WithIdempotencyKey(context.Background(), "3EB0A1B2C3D4")
+	tx, err := agent.RecordSale(ctx, "user-123", intent, nil)
+	if err != nil {
+		t.Fatalf("RecordSale() error = %v", err)
+	}
+	if tx.IdempotencyKey != "3EB0A1B2C3D4" {
+		t.Errorf("IdempotencyKey = %q, want message id", tx.IdempotencyKey)
+	}
+
+	// No key in context leaves the field empty
+	tx, _ = agent.RecordSale(context.Background(), "user-123", intent, nil)
+	if tx.IdempotencyKey != "" {
+		t.Errorf("IdempotencyKey = %q, want empty", tx.IdempotencyKey)
+	}
+}
+
+func TestCancelAlreadyVoided(t *testing.T) {
+	// PostgREST updates no row when voided_at is already set
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+	agent := NewFinanceAgent(database.NewSupabaseClient(server.URL, "key"))
+	tx := &database.Transaction{ID: "tx-1", UserID: "user-1", Type: "SALE", ProductName: "bakso", Qty: 2, TotalAmount: 30000}
+
+	if err := agent.CancelTransaction(context.Background(), "user-1", tx); !errors.Is(err, ErrTransactionNotFound) {
+		t.Errorf("CancelTransaction() = %v, want ErrTransactionNotFound", err)
+	}
+	if _, err := agent.CorrectTransaction(context.Background(), "user-1", tx, map[string]any{"qty": 3.0}); !errors.Is(err, ErrTransactionNotFound) {
+		t.Errorf("CorrectTransaction() = %v, want ErrTransactionNotFound", err)
+	}
+	if len(requests) != 2 {
+		t.Errorf("requests = %v, want only the two voids", requests)
+	}
+}
@@ -0,0 +1,464 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/pasarsuara/backend/internal/ai"
+	"github.com/pasarsuara/backend/internal/database"
+)
+
+// Recorded transactions waiting for the user to confirm a cancellation or correction
+const (
+	PendingCancel     = "CANCEL_TRANSACTION"
+	PendingCorrection = "CORRECT_TRANSACTION"
+)
+
+// ErrTransactionNotFound is returned for a transaction the user does not have or already cancelled
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// historyTurns is how many earlier messages the intent extractor sees
+const historyTurns = 6
+
+// followUpFields are the entities a correction or amendment may change
+var followUpFields = []string{"product", "qty", "unit", "price", "max_price"}
+
+// transactionTypes maps intents to the transaction type they record
+var transactionTypes = map[string]string{
+	"RECORD_SALE":    "SALE",
+	"RECORD_EXPENSE": "EXPENSE",
+	"ORDER_RESTOCK":  "PURCHASE",
+}
+
+// recentTransaction is a recorded transaction with the intent that created it
+type recentTransaction struct {
+	tx     *database.Transaction
+	intent *ai.Intent
+}
+
+// RecentTransactions remembers each user's latest transactions so
+// follow-ups like "yang tadi" resolve without a database round trip
+type RecentTransactions struct {
+	byUser map[string][]recentTransaction // newest last
+	mu     sync.Mutex
+	limit  int
+}
+
+// NewRecentTransactions keeps up to limit transactions per user
+func NewRecentTransactions(limit int) *RecentTransactions {
+	if limit == 0 {
+		limit = 10
+	}
+	return &RecentTransactions{byUser: make(map[string][]recentTransaction), limit: limit}
+}
+
+// Add remembers a transaction, replacing an earlier copy with the same id
+func (r *RecentTransactions) Add(userPhone string, tx *database.Transaction, intent *ai.Intent) {
+	if r == nil || tx == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	list := r.without(userPhone, tx.ID)
+	list = append(list, recentTransaction{tx: tx, intent: intent})
+	if len(list) > r.limit {
+		list = list[len(list)-r.limit:]
+	}
+	r.byUser[userPhone] = list
+}
+
+// Remove forgets a transaction
+func (r *RecentTransactions) Remove(userPhone string, tx *database.Transaction) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var list []recentTransaction
+	for _, item := range r.byUser[userPhone] {
+		if item.tx != tx && (tx.ID == "" || item.tx.ID != tx.ID) {
+			list = append(list, item)
+		}
+	}
+	r.byUser[userPhone] = list
+}
+
+// Find returns the newest transaction matching the reference, or nil
+func (r *RecentTransactions) Find(userPhone, targetProduct, targetType string) *recentTransaction {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	list := r.byUser[userPhone]
+	for i := len(list) - 1; i >= 0; i-- {
+		if matchesTransaction(list[i].tx, targetProduct, targetType) {
+			item := list[i]
+			return &item
+		}
+	}
+	return nil
+}
+
+func (r *RecentTransactions) without(userPhone, id string) []recentTransaction {
+	list := r.byUser[userPhone]
+	if id == "" {
+		return list
+	}
+	kept := list[:0]
+	for _, item := range list {
+		if item.tx.ID != id {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// matchesTransaction checks a transaction against a follow-up reference
+func matchesTransaction(tx *database.Transaction, targetProduct, targetType string) bool {
+	if targetType != "" && tx.Type != targetType {
+		return false
+	}
+	return targetProduct == "" || strings.Contains(strings.ToLower(tx.ProductName), strings.ToLower(targetProduct))
+}
+
+// matchesPendingAction checks a pending proposal against a follow-up reference
+func matchesPendingAction(action *PendingAction, targetProduct, targetType string) bool {
+	if action == nil || action.Expired() || action.Intent == nil {
+		return false
+	}
+	if action.Kind != PendingSale && action.Kind != PendingPurchase && action.Kind != PendingEntityCheck {
+		return false
+	}
+	txType, ok := transactionTypes[action.Intent.Action]
+	if !ok || (targetType != "" && txType != targetType) {
+		return false
+	}
+	product := getStringEntity(action.Intent.Entities, "product")
+	return targetProduct == "" || strings.Contains(strings.ToLower(product), strings.ToLower(targetProduct))
+}
+
+// conversationHistory returns the recent turns for the intent extractor
+func (o *AgentOrchestrator) conversationHistory(userPhone string) []ai.Turn {
+	if o.contextMgr == nil {
+		return nil
+	}
+	var turns []ai.Turn
+	for _, msg := range o.contextMgr.GetRecentMessages(userPhone, historyTurns*2) {
+		if msg.Role == "system" {
+			continue
+		}
+		turns = append(turns, ai.Turn{Role: msg.Role, Content: msg.Content, Intent: msg.Intent})
+	}
+	if len(turns) > historyTurns {
+		turns = turns[len(turns)-historyTurns:]
+	}
+	return turns
+}
+
+// rememberTransaction keeps a recorded transaction for later follow-ups
+func (o *AgentOrchestrator) rememberTransaction(userPhone string, response *AgentResponse, intent *ai.Intent) {
+	if response.Success && response.Transaction != nil {
+		o.recent.Add(userPhone, response.Transaction, intent)
+	}
+}
+
+// findRecentTransaction resolves a reference to an earlier transaction,
+// looking at this session first and the database second
+func (o *AgentOrchestrator) findRecentTransaction(ctx context.Context, userPhone, userID string, intent *ai.Intent) *recentTransaction {
+	targetProduct := getStringEntity(intent.Entities, "target_product")
+	targetType := getStringEntity(intent.Entities, "target_type")
+
+	if found := o.recent.Find(userPhone, targetProduct, targetType); found != nil {
+		return found
+	}
+	if o.db == nil {
+		return nil
+	}
+
+	transactions, err := o.db.GetRecentTransactions(ctx, userID, 10)
+	if err != nil {
+		log.Printf("⚠️ Failed to load recent transactions: %v", err)
+		return nil
+	}
+	for i := range transactions {
+		if matchesTransaction(&transactions[i], targetProduct, targetType) {
+			return &recentTransaction{tx: &transactions[i], intent: intentFromTransaction(&transactions[i])}
+		}
+	}
+	return nil
+}
+
+// intentFromTransaction rebuilds the intent of a transaction loaded from the database
+func intentFromTransaction(tx *database.Transaction) *ai.Intent {
+	action := "RECORD_SALE"
+	for intentAction, txType := range transactionTypes {
+		if txType == tx.Type {
+			action = intentAction
+		}
+	}
+	entities := map[string]any{"product": tx.ProductName, "qty": tx.Qty, "price": tx.PricePerUnit}
+	if action == "ORDER_RESTOCK" {
+		entities["max_price"] = tx.PricePerUnit
+	}
+	return &ai.Intent{Action: action, Entities: entities, Language: "id", RawText: tx.RawVoiceText}
+}
+
+// followUpChanges returns the entities a follow-up sets
+func followUpChanges(intent *ai.Intent) map[string]any {
+	changes := map[string]any{}
+	for _, field := range followUpFields {
+		if value, ok := intent.Entities[field]; ok && value != nil && value != "" && value != 0.0 {
+			changes[field] = value
+		}
+	}
+	return changes
+}
+
+// handleFollowUp corrects, amends or cancels the previous operation. A
+// proposal still waiting for confirmation takes precedence over recorded
+// transactions.
+func (o *AgentOrchestrator) handleFollowUp(ctx context.Context, userPhone, userID string, intent *ai.Intent) *AgentResponse {
+	log.Printf("↩️ Follow-up %s from %s: %v", intent.Action, userPhone, intent.Entities)
+	if o.contextMgr != nil {
+		// No intent so the previous one stays the reference for the next follow-up
+		o.contextMgr.AddMessage(userPhone, "user", intent.RawText, "", nil)
+	}
+
+	var response *AgentResponse
+	switch intent.Action {
+	case "CANCEL_PREVIOUS":
+		response = o.cancelPrevious(ctx, userPhone, userID, intent)
+	case "CORRECT_PREVIOUS":
+		response = o.correctPrevious(ctx, userPhone, userID, intent)
+	default:
+		response = o.amendPrevious(ctx, userPhone, userID, intent)
+	}
+
+	// Re-processed intents already stored their reply
+	if o.contextMgr != nil && response.Intent == intent {
+		o.contextMgr.AddMessage(userPhone, "assistant", response.Message, "", nil)
+	}
+	return response
+}
+
+func (o *AgentOrchestrator) cancelPrevious(ctx context.Context, userPhone, userID string, intent *ai.Intent) *AgentResponse {
+	targetProduct := getStringEntity(intent.Entities, "target_product")
+	targetType := getStringEntity(intent.Entities, "target_type")
+
+	if action := o.pending.GetLatest(userPhone); matchesPendingAction(action, targetProduct, targetType) {
+		return o.ConfirmAction(ctx, userPhone, action.ID, false)
+	}
+
+	found := o.findRecentTransaction(ctx, userPhone, userID, intent)
+	if found == nil {
+		return &AgentResponse{
+			Success: false,
+			Intent:  intent,
+			Message: "🤔 Tidak ada transaksi terakhir yang bisa dibatalkan.",
+		}
+	}
+
+	text := "🗑️ Batalkan transaksi ini?\n\n" + describeTransaction(found.tx) +
+		"\n\nBalas \"ya\" untuk membatalkan transaksinya."
+	return o.proposeTransactionChange(ctx, userPhone, PendingCancel, intent, found, "🗑️ Ya, batalkan", text)
+}
+
+// proposeTransactionChange asks to confirm a cancellation or correction of
+// a recorded transaction; action.Intent is the transaction's intent after it
+func (o *AgentOrchestrator) proposeTransactionChange(ctx context.Context, userPhone, kind string, intent *ai.Intent, found *recentTransaction, confirmTitle, text string) *AgentResponse {
+	action := o.pending.Create(userPhone, kind, found.intent, nil, IdempotencyKeyFromContext(ctx))
+	action.Transaction = found.tx
+	buttons := []ReplyButton{
+		{ID: SelectionID(selectionKindConfirm, action.ID, "yes"), Title: confirmTitle},
+		{ID: SelectionID(selectionKindConfirm, action.ID, "no"), Title: "↩️ Jangan"},
+	}
+
+	return &AgentResponse{
+		Success:         true,
+		Intent:          intent,
+		Message:         text,
+		Replies:         []Reply{ButtonsReply(text, buttons)},
+		PendingActionID: action.ID,
+	}
+}
+
+// completeCancel voids the transaction of a confirmed PendingCancel and puts its stock back
+func (o *AgentOrchestrator) completeCancel(ctx context.Context, userPhone, userID string, action *PendingAction) *AgentResponse {
+	err := o.cancelTransaction(ctx, userID, action.Transaction)
+	if errors.Is(err, ErrTransactionNotFound) {
+		o.recent.Remove(userPhone, action.Transaction)
+		return &AgentResponse{Success: true, Intent: action.Intent, Message: "🗑️ Transaksi ini sudah dibatalkan atau diralat."}
+	}
+	if err != nil {
+		return &AgentResponse{Success: false, Intent: action.Intent, Message: "Gagal membatalkan transaksi: " + err.Error()}
+	}
+	o.recent.Remove(userPhone, action.Transaction)
+	return &AgentResponse{
+		Success: true,
+		Intent:  action.Intent,
+		Message: "🗑️ Transaksi dibatalkan:\n" + describeTransaction(action.Transaction),
+	}
+}
+
+// CancelTransactionByID cancels one of the user's transactions from the
+// dashboard the way a confirmed "batal" in chat does
+func (o *AgentOrchestrator) CancelTransactionByID(ctx context.Context, userID, transactionID string) (*database.Transaction, error) {
+	if o.db == nil {
+		return nil, fmt.Errorf("database not configured")
+	}
+	tx, err := o.db.GetTransactionByID(ctx, userID, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil || tx.VoidedAt != "" {
+		return nil, ErrTransactionNotFound
+	}
+	if err := o.cancelTransaction(ctx, userID, tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// cancelTransaction voids a transaction with its costing, journal and
+// payments, and puts its stock back. Nothing is reversed when it was
+// already void (ErrTransactionNotFound).
+func (o *AgentOrchestrator) cancelTransaction(ctx context.Context, userID string, tx *database.Transaction) error {
+	if err := o.finance.CancelTransaction(ctx, userID, tx); err != nil {
+		return err
+	}
+	if o.inventory != nil {
+		if err := o.inventory.ReverseStock(ctx, userID, tx); err != nil {
+			log.Printf("⚠️ Failed to reverse inventory: %v", err)
+		}
+	}
+	return nil
+}
+
+func (o *AgentOrchestrator) correctPrevious(ctx context.Context, userPhone, userID string, intent *ai.Intent) *AgentResponse {
+	changes := followUpChanges(intent)
+	if len(changes) == 0 {
+		return &AgentResponse{
+			Success: false,
+			Intent:  intent,
+			Message: "✏️ Mau ralat apa? Contoh: \"yang tadi jadi 12 ribu\" atau \"ralat 5 porsi\"",
+		}
+	}
+
+	// An unconfirmed proposal is simply proposed again with the new values
+	targetProduct := getStringEntity(intent.Entities, "target_product")
+	targetType := getStringEntity(intent.Entities, "target_type")
+	if action := o.pending.GetLatest(userPhone); matchesPendingAction(action, targetProduct, targetType) {
+		if action = o.pending.Take(action.ID); action != nil {
+			corrected := mergeFollowUp(action.Intent, changes, intent)
+			corrected.ClearConfidence()
+			return o.processIntent(ctx, userPhone, corrected)
+		}
+	}
+
+	found := o.findRecentTransaction(ctx, userPhone, userID, intent)
+	if found == nil {
+		return &AgentResponse{
+			Success: false,
+			Intent:  intent,
+			Message: "🤔 Belum ada transaksi yang bisa diralat.",
+		}
+	}
+
+	corrected := &recentTransaction{tx: found.tx, intent: mergeFollowUp(found.intent, changes, intent)}
+	text := "✏️ Ralat transaksi ini?\n\n" +
+		"Sebelumnya: " + describeTransaction(found.tx) + "\n" +
+		"Menjadi: " + describeTransaction(correctedCopy(found.tx, changes)) +
+		"\n\nBalas \"ya\" untuk menyimpan ralatnya."
+	return o.proposeTransactionChange(ctx, userPhone, PendingCorrection, intent, corrected, "✏️ Ya, ralat", text)
+}
+
+// completeCorrection replaces the transaction of a confirmed PendingCorrection
+// and moves stock by the difference
+func (o *AgentOrchestrator) completeCorrection(ctx context.Context, userPhone, userID string, action *PendingAction) *AgentResponse {
+	updated, err := o.finance.CorrectTransaction(ctx, userID, action.Transaction, followUpChanges(action.Intent))
+	if errors.Is(err, ErrDuplicateTransaction) {
+		return &AgentResponse{Success: true, Intent: action.Intent, Transaction: updated, Message: "✏️ Ralat ini sudah disimpan."}
+	}
+	if errors.Is(err, ErrTransactionNotFound) {
+		o.recent.Remove(userPhone, action.Transaction)
+		return &AgentResponse{Success: false, Intent: action.Intent, Message: "✏️ Transaksi ini sudah dibatalkan atau diralat, tidak bisa diralat lagi."}
+	}
+	if err != nil {
+		return &AgentResponse{Success: false, Intent: action.Intent, Message: "Gagal meralat transaksi: " + err.Error()}
+	}
+	o.recent.Remove(userPhone, action.Transaction)
+	if o.inventory != nil {
+		if err := o.inventory.ReplaceStock(ctx, userID, action.Transaction, updated); err != nil {
+			log.Printf("⚠️ Failed to adjust inventory: %v", err)
+		}
+	}
+
+	return &AgentResponse{
+		Success:     true,
+		Intent:      action.Intent,
+		Transaction: updated,
+		Message: "✏️ Transaksi diralat!\n\n" +
+			"Sebelumnya: " + describeTransaction(action.Transaction) + "\n" +
+			"Sekarang: " + describeTransaction(updated),
+	}
+}
+
+func (o *AgentOrchestrator) amendPrevious(ctx context.Context, userPhone, userID string, intent *ai.Intent) *AgentResponse {
+	changes := followUpChanges(intent)
+
+	var base *ai.Intent
+	if found := o.findRecentTransaction(ctx, userPhone, userID, intent); found != nil {
+		base = found.intent
+	} else if o.contextMgr != nil {
+		if action := o.contextMgr.GetLastIntent(userPhone); transactionTypes[action] != "" {
+			base = &ai.Intent{Action: action, Entities: o.contextMgr.GetLastEntities(userPhone)}
+		}
+	}
+	if base == nil || len(changes) == 0 {
+		return &AgentResponse{
+			Success: false,
+			Intent:  intent,
+			Message: "🤔 Maksudnya ditambahkan ke transaksi yang mana? Coba sebutkan lengkap, contoh: \"laku es teh 5 gelas 3rb\"",
+		}
+	}
+
+	return o.processIntent(ctx, userPhone, mergeFollowUp(base, changes, intent))
+}
+
+// mergeFollowUp copies base with the follow-up's changes applied
+func mergeFollowUp(base *ai.Intent, changes map[string]any, followUp *ai.Intent) *ai.Intent {
+	merged := *base
+	merged.Entities = make(map[string]any, len(base.Entities))
+	for k, v := range base.Entities {
+		merged.Entities[k] = v
+	}
+	// A new product rarely shares the old unit price
+	if _, ok := changes["product"]; ok && followUp.Action == "AMEND_PREVIOUS" {
+		if _, priced := changes["price"]; !priced {
+			delete(merged.Entities, "price")
+		}
+	}
+	for k, v := range changes {
+		merged.Entities[k] = v
+	}
+	merged.RawText = followUp.RawText
+	return &merged
+}
+
+// describeTransaction is a one-line summary, e.g. "Penjualan nasi rames 15 × Rp 12.000 = Rp 180.000"
+func describeTransaction(tx *database.Transaction) string {
+	label := map[string]string{"SALE": "Penjualan", "EXPENSE": "Pengeluaran", "PURCHASE": "Pembelian"}[tx.Type]
+	if label == "" {
+		label = "Transaksi"
+	}
+	return fmt.Sprintf("%s %s %s × Rp %s = Rp %s", label, tx.ProductName,
+		formatEntityValue("qty", tx.Qty, ""), formatCurrency(tx.PricePerUnit), formatCurrency(tx.TotalAmount))
+}
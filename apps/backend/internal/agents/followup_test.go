@@ -0,0 +1,146 @@
+package agents
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pasarsuara/backend/internal/ai"
+	appcontext "github.com/pasarsuara/backend/internal/context"
+	"github.com/pasarsuara/backend/internal/database"
+)
+
+func newFollowUpOrchestrator() *AgentOrchestrator {
+	return &AgentOrchestrator{
+		finance:    NewFinanceAgent(nil),
+		pending:    NewPendingActionStore(0),
+		recent:     NewRecentTransactions(0),
+		contextMgr: appcontext.NewConversationManager(time.Minute),
+	}
+}
+
+func followUp(action string, entities map[string]any) *ai.Intent {
+	return &ai.Intent{Action: action, Entities: entities, Language: "id"}
+}
+
+func TestProcessIntent_FollowUps(t *testing.T) {
+	o := newFollowUpOrchestrator()
+	ctx := context.Background()
+	phone := "628111"
+
+	o.processIntent(ctx, phone, followUp("RECORD_SALE", map[string]any{"product": "nasi rames", "qty": 15.0, "price": 10000.0}))
+	o.processIntent(ctx, phone, followUp("RECORD_EXPENSE", map[string]any{"product": "gas", "price": 22000.0}))
+
+	// "penjualan yang tadi jadi 12 ribu" skips the newer expense and asks first
+	resp := o.processIntent(ctx, phone, followUp("CORRECT_PREVIOUS", map[string]any{"price": 12000.0, "target_type": "SALE"}))
+	if resp.PendingActionID == "" || resp.Transaction != nil {
+		t.Fatalf("Correction should be proposed before it is applied, got %+v", resp)
+	}
+	if !strings.Contains(resp.Message, "Sebelumnya: Penjualan nasi rames 15 × Rp 10.000") ||
+		!strings.Contains(resp.Message, "Menjadi: Penjualan nasi rames 15 × Rp 12.000 = Rp 180.000") {
+		t.Errorf("Correction proposal = %s", resp.Message)
+	}
+	resp, handled := o.ProcessConfirmationReply(ctx, phone, "", "ya")
+	if !handled || resp.Transaction == nil || resp.Transaction.TotalAmount != 180000 {
+		t.Fatalf("Correction = %+v", resp)
+	}
+
+	// "itu juga 5" repeats the corrected sale with a new qty
+	resp = o.processIntent(ctx, phone, followUp("AMEND_PREVIOUS", map[string]any{"qty": 5.0, "target_product": "nasi"}))
+	if resp.Transaction == nil || resp.Transaction.ProductName != "nasi rames" || resp.Transaction.TotalAmount != 60000 {
+		t.Fatalf("Amendment = %+v", resp)
+	}
+
+	// "batal yang tadi" asks first, then removes the latest transaction
+	resp = o.processIntent(ctx, phone, followUp("CANCEL_PREVIOUS", map[string]any{}))
+	if resp.PendingActionID == "" || !strings.Contains(resp.Message, "nasi rames 5 ×") {
+		t.Fatalf("Cancel proposal = %+v", resp)
+	}
+	resp, handled = o.ProcessConfirmationReply(ctx, phone, "", "ya")
+	if !handled || !strings.Contains(resp.Message, "Transaksi dibatalkan") {
+		t.Fatalf("Cancel = %+v", resp)
+	}
+	if found := o.recent.Find(phone, "nasi", ""); found == nil || found.tx.Qty != 15 {
+		t.Errorf("Expected the corrected sale of 15 to remain, got %+v", found)
+	}
+}
+
+func TestProcessIntent_CorrectPendingProposal(t *testing.T) {
+	o := newFollowUpOrchestrator()
+	ctx := context.Background()
+
+	resp := o.processIntent(ctx, "628111", followUp("RECORD_SALE", map[string]any{"product": "beras", "qty": 100.0, "price": 15000.0}))
+	if resp.PendingActionID == "" {
+		t.Fatalf("Expected a large sale proposal, got %+v", resp)
+	}
+
+	// "bukan, 10 karung" fixes the proposal before anything is recorded
+	resp = o.processIntent(ctx, "628111", followUp("CORRECT_PREVIOUS", map[string]any{"qty": 10.0}))
+	if resp.Transaction == nil || resp.Transaction.TotalAmount != 150000 {
+		t.Fatalf("Expected the corrected sale to be recorded, got %+v", resp)
+	}
+	if o.pending.GetLatest("628111") != nil {
+		t.Error("Corrected proposal should be consumed")
+	}
+}
+
+func TestStockDeltas(t *testing.T) {
+	sale := &database.Transaction{Type: "SALE", ProductName: "nasi rames", Qty: 10}
+	purchase := &database.Transaction{Type: "PURCHASE", ProductName: "beras", Qty: 25}
+
+	tests := []struct {
+		name         string
+		old, updated *database.Transaction
+		want         map[string]float64
+	}{
+		{"cancelled sale puts stock back", sale, nil, map[string]float64{"nasi rames": 10}},
+		{"cancelled purchase takes it out", purchase, nil, map[string]float64{"beras": -25}},
+		{"fewer sold", sale, &database.Transaction{Type: "SALE", ProductName: "nasi rames", Qty: 8}, map[string]float64{"nasi rames": 2}},
+		{"other product", sale, &database.Transaction{Type: "SALE", ProductName: "nasi uduk", Qty: 10},
+			map[string]float64{"nasi rames": 10, "nasi uduk": -10}},
+		{"price only", sale, &database.Transaction{Type: "SALE", ProductName: "nasi rames", Qty: 10}, map[string]float64{}},
+		{"expense", &database.Transaction{Type: "EXPENSE", ProductName: "gas", Qty: 1}, nil, map[string]float64{}},
+	}
+	for _, tt := range tests {
+		got := stockDeltas(tt.old, tt.updated)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: stockDeltas = %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for product, delta := range tt.want {
+			if got[product] != delta {
+				t.Errorf("%s: stockDeltas = %v, want %v", tt.name, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestProcessIntent_FollowUpWithoutTarget(t *testing.T) {
+	o := newFollowUpOrchestrator()
+
+	for _, action := range []string{"CORRECT_PREVIOUS", "AMEND_PREVIOUS", "CANCEL_PREVIOUS"} {
+		resp := o.processIntent(context.Background(), "628222", followUp(action, map[string]any{"qty": 5.0}))
+		if resp.Success || resp.Transaction != nil {
+			t.Errorf("%s without history = %+v", action, resp)
+		}
+	}
+}
+
+func TestConversationHistory(t *testing.T) {
+	o := newFollowUpOrchestrator()
+	o.contextMgr.AddMessage("628111", "system", "waiting_for_clarification", "RECORD_SALE", nil)
+	for i := 0; i < historyTurns+2; i++ {
+		o.contextMgr.AddMessage("628111", "user", "laku bakso", "RECORD_SALE", nil)
+	}
+
+	turns := o.conversationHistory("628111")
+	if len(turns) != historyTurns {
+		t.Fatalf("Turns = %d, want %d", len(turns), historyTurns)
+	}
+	for _, turn := range turns {
+		if turn.Role == "system" {
+			t.Error("System messages should not reach the extractor")
+		}
+	}
+}
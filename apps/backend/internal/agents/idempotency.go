@@ -0,0 +1,29 @@
+package agents
+
+import (
+	"context"
+	"errors"
+)
+
+type idempotencyKeyCtx struct{}
+
+// ErrDuplicateTransaction is returned together with the existing transaction
+// when a message with the same idempotency key was already recorded
+var ErrDuplicateTransaction = errors.New("transaction already recorded for this message")
+
+// WithIdempotencyKey attaches the source message id to the context
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	if key == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, idempotencyKeyCtx{}, key)
+}
+
+// IdempotencyKeyFromContext returns the source message id, or "" if not set
+func IdempotencyKeyFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	key, _ := ctx.Value(idempotencyKeyCtx{}).(string)
+	return key
+}
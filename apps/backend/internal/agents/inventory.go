@@ -103,6 +103,64 @@ func (a *InventoryAgent) UpdateStockAfterPurchase(ctx context.Context, userID st
 	return nil
 }
 
+// stockMovement is how much a transaction moved stock: sales take it out,
+// purchases put it in, expenses don't touch it
+func stockMovement(tx *database.Transaction) float64 {
+	switch tx.Type {
+	case "SALE":
+		return -tx.Qty
+	case "PURCHASE":
+		return tx.Qty
+	}
+	return 0
+}
+
+// stockDeltas nets the movements that undo old and apply updated, per product
+func stockDeltas(old, updated *database.Transaction) map[string]float64 {
+	deltas := map[string]float64{}
+	if old != nil && old.ProductName != "" {
+		deltas[old.ProductName] -= stockMovement(old)
+	}
+	if updated != nil && updated.ProductName != "" {
+		deltas[updated.ProductName] += stockMovement(updated)
+	}
+	for product, delta := range deltas {
+		if delta == 0 {
+			delete(deltas, product)
+		}
+	}
+	return deltas
+}
+
+// ReverseStock undoes the stock movement of a cancelled transaction
+func (a *InventoryAgent) ReverseStock(ctx context.Context, userID string, tx *database.Transaction) error {
+	return a.ReplaceStock(ctx, userID, tx, nil)
+}
+
+// ReplaceStock reverses the stock movement of old and applies that of
+// updated, e.g. after a correction from 10 to 8 porsi puts 2 back
+func (a *InventoryAgent) ReplaceStock(ctx context.Context, userID string, old, updated *database.Transaction) error {
+	if a.db == nil {
+		return nil
+	}
+	for product, delta := range stockDeltas(old, updated) {
+		inv, err := a.db.GetInventoryByProductSQL(ctx, userID, product)
+		if err != nil {
+			return err
+		}
+		if inv == nil {
+			log.Printf("⚠️ Product '%s' not in inventory, skipping stock reversal", product)
+			continue
+		}
+		newStock := inv.StockQty + delta
+		if err := a.db.UpdateInventoryStock(ctx, inv.ID, newStock); err != nil {
+			return err
+		}
+		log.Printf("↩️ Stock adjusted: %s %.0f → %.0f %s", product, inv.StockQty, newStock, inv.Unit)
+	}
+	return nil
+}
+
 // checkStockLevel checks if stock is low and returns alert
 func (a *InventoryAgent) checkStockLevel(product string, currentStock float64, unit string) *StockAlert {
 	// Simple reorder point logic (can be made more sophisticated)
@@ -0,0 +1,112 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+)
+
+// GeoPoint is a WGS84 coordinate
+type GeoPoint struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Delivery fee estimate (Rp), similar to local courier tariffs
+const (
+	deliveryBaseFee  = 8000 // Covers the first deliveryBaseKm
+	deliveryBaseKm   = 2.0
+	deliveryFeePerKm = 2500
+)
+
+// HaversineKm returns the great-circle distance between two points in km
+func HaversineKm(a, b GeoPoint) float64 {
+	const earthRadiusKm = 6371.0
+
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := (b.Latitude - a.Latitude) * math.Pi / 180
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// EstimateDeliveryFee returns the delivery quote for a distance, rounded up to Rp 500
+func EstimateDeliveryFee(distanceKm float64) float64 {
+	fee := float64(deliveryBaseFee)
+	if distanceKm > deliveryBaseKm {
+		fee += (distanceKm - deliveryBaseKm) * deliveryFeePerKm
+	}
+	return math.Ceil(fee/500) * 500
+}
+
+// getUserLocation returns the user's business location, or nil if unknown
+func (o *AgentOrchestrator) getUserLocation(ctx context.Context, userID string) *GeoPoint {
+	if o.db == nil {
+		return nil
+	}
+	lat, lon, ok, err := o.db.GetUserLocation(ctx, userID)
+	if err != nil {
+		log.Printf("⚠️ Failed to get user location: %v", err)
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+	return &GeoPoint{Latitude: lat, Longitude: lon}
+}
+
+// ProcessLocation handles a shared location. It sets the coordinates of the
+// contact the user was asked about, otherwise the user's own business location.
+func (o *AgentOrchestrator) ProcessLocation(ctx context.Context, userPhone string, point GeoPoint, name, address string) *AgentResponse {
+	log.Printf("📍 Orchestrator processing location from %s: %.5f,%.5f", userPhone, point.Latitude, point.Longitude)
+
+	userID := o.getUserID(ctx, userPhone)
+
+	// Location for a contact that was just added
+	if o.contextMgr != nil && o.contextMgr.GetLastIntent(userPhone) == intentAwaitContactLocation {
+		entities := o.contextMgr.GetLastEntities(userPhone)
+		contactID := getStringEntity(entities, "contact_id")
+		contactName := getStringEntity(entities, "contact_name")
+		o.contextMgr.AddMessage(userPhone, "user", "shared_location", "LOCATION", map[string]any{})
+
+		if o.db == nil || contactID == "" {
+			return &AgentResponse{Success: false, Message: "Maaf, lokasi kontak belum bisa disimpan."}
+		}
+		if err := o.db.UpdateContactLocation(ctx, contactID, point.Latitude, point.Longitude, address); err != nil {
+			log.Printf("❌ Failed to save contact location: %v", err)
+			return &AgentResponse{Success: false, Message: "Gagal menyimpan lokasi kontak. Coba lagi ya!"}
+		}
+
+		msg := fmt.Sprintf("📍 Lokasi %s tersimpan!", contactName)
+		if own := o.getUserLocation(ctx, userID); own != nil {
+			distance := HaversineKm(*own, point)
+			msg += fmt.Sprintf("\n\n📏 Jarak dari usaha Anda: %.1f km\n🚚 Estimasi ongkir: Rp %.0f", distance, EstimateDeliveryFee(distance))
+		}
+		return &AgentResponse{Success: true, Message: msg}
+	}
+
+	// Otherwise it's the user's business location
+	if o.db == nil {
+		return &AgentResponse{Success: false, Message: "Maaf, lokasi belum bisa disimpan saat ini."}
+	}
+	if err := o.db.UpdateUserLocation(ctx, userID, point.Latitude, point.Longitude); err != nil {
+		log.Printf("❌ Failed to save user location: %v", err)
+		return &AgentResponse{Success: false, Message: "Gagal menyimpan lokasi. Coba lagi ya!"}
+	}
+
+	place := name
+	if place == "" {
+		place = address
+	}
+	msg := "📍 Lokasi usaha tersimpan!"
+	if place != "" {
+		msg += "\n\n🏪 " + place
+	}
+	msg += "\n\nLokasi dipakai untuk mencari penjual terdekat dan menghitung ongkir."
+
+	return &AgentResponse{Success: true, Message: msg}
+}
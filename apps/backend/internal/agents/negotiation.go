@@ -28,12 +28,14 @@ type NegotiationResult struct {
 	TotalAmount  float64              `json:"total_amount,omitempty"`
 	Messages     []NegotiationMessage `json:"messages"`
 	ErrorMessage string               `json:"error_message,omitempty"`
+	DistanceKm   float64              `json:"distance_km,omitempty"`  // Buyer to seller, when both locations are known
+	DeliveryFee  float64              `json:"delivery_fee,omitempty"` // Estimated delivery quote
 }
 
 // BuyerAgent represents the buyer in negotiations
 type BuyerAgent struct {
 	db       *database.SupabaseClient
-	kolosal  *ai.KolosalClient
+	llm      ai.Provider
 	userID   string
 	userName string
 	maxPrice float64
@@ -43,7 +45,7 @@ type BuyerAgent struct {
 // SellerAgent represents a seller in negotiations
 type SellerAgent struct {
 	db          *database.SupabaseClient
-	kolosal     *ai.KolosalClient
+	llm         ai.Provider
 	userID      string
 	userName    string
 	minPrice    float64
@@ -60,19 +62,25 @@ type DatabaseClient interface {
 
 // NegotiationOrchestrator manages the negotiation process
 type NegotiationOrchestrator struct {
-	db      DatabaseClient
-	kolosal *ai.KolosalClient
+	db  DatabaseClient
+	llm ai.Provider
 }
 
-func NewNegotiationOrchestrator(db DatabaseClient, kolosal *ai.KolosalClient) *NegotiationOrchestrator {
+func NewNegotiationOrchestrator(db DatabaseClient, llm ai.Provider) *NegotiationOrchestrator {
 	return &NegotiationOrchestrator{
-		db:      db,
-		kolosal: kolosal,
+		db:  db,
+		llm: llm,
 	}
 }
 
 // StartNegotiation initiates a negotiation based on user intent
 func (n *NegotiationOrchestrator) StartNegotiation(ctx context.Context, buyerID string, intent *ai.Intent) *NegotiationResult {
+	return n.StartNegotiationNear(ctx, buyerID, intent, nil)
+}
+
+// StartNegotiationNear initiates a negotiation, preferring sellers whose price
+// plus delivery to the buyer's location is lowest. buyerLocation may be nil.
+func (n *NegotiationOrchestrator) StartNegotiationNear(ctx context.Context, buyerID string, intent *ai.Intent, buyerLocation *GeoPoint) *NegotiationResult {
 	log.Printf("🤝 Starting negotiation for buyer %s", buyerID)
 
 	product := getStringEntity(intent.Entities, "product")
@@ -116,12 +124,19 @@ func (n *NegotiationOrchestrator) StartNegotiation(ctx context.Context, buyerID
 			log.Printf("⚠️ Failed to find sellers: %v", err)
 		}
 		for _, inv := range inventories {
-			sellers = append(sellers, SellerInfo{
+			seller := SellerInfo{
 				UserID:      inv.UserID,
 				ProductName: inv.ProductName,
 				StockQty:    inv.StockQty,
 				MinPrice:    inv.MinSellPrice,
-			})
+			}
+			if inv.Seller != nil {
+				seller.Name = inv.Seller.Name
+				if inv.Seller.Latitude != 0 || inv.Seller.Longitude != 0 {
+					seller.Location = &GeoPoint{Latitude: inv.Seller.Latitude, Longitude: inv.Seller.Longitude}
+				}
+			}
+			sellers = append(sellers, seller)
 		}
 	}
 
@@ -142,7 +157,7 @@ func (n *NegotiationOrchestrator) StartNegotiation(ctx context.Context, buyerID
 	}
 
 	// Simulate negotiation with best seller
-	bestSeller := n.findBestSeller(sellers, maxPrice, qty)
+	bestSeller := n.findBestSellerNear(sellers, maxPrice, qty, buyerLocation)
 	if bestSeller == nil {
 		result.Messages = append(result.Messages, NegotiationMessage{
 			Role:    "system",
@@ -154,6 +169,17 @@ func (n *NegotiationOrchestrator) StartNegotiation(ctx context.Context, buyerID
 		return result
 	}
 
+	// Delivery quote when both locations are known
+	if buyerLocation != nil && bestSeller.Location != nil {
+		result.DistanceKm = HaversineKm(*buyerLocation, *bestSeller.Location)
+		result.DeliveryFee = EstimateDeliveryFee(result.DistanceKm)
+		result.Messages = append(result.Messages, NegotiationMessage{
+			Role:    "system",
+			Content: fmt.Sprintf("📍 %s berjarak %.1f km, estimasi ongkir Rp %.0f", bestSeller.Name, result.DistanceKm, result.DeliveryFee),
+			Time:    time.Now().Format(time.RFC3339),
+		})
+	}
+
 	// Run negotiation rounds
 	finalPrice := n.runNegotiation(result, bestSeller, maxPrice, qty)
 
@@ -218,20 +244,25 @@ type SellerInfo struct {
 	ProductName string
 	StockQty    float64
 	MinPrice    float64
+	Location    *GeoPoint // nil when unknown
 }
 
 func (n *NegotiationOrchestrator) getDemoSellers(product string) []SellerInfo {
 	// Demo sellers for testing
 	demoSellers := map[string][]SellerInfo{
 		"beras": {
-			{UserID: "22222222-2222-2222-2222-222222222222", Name: "Pak Joyo", ProductName: "Beras Premium", StockQty: 500, MinPrice: 11500},
-			{UserID: "55555555-5555-5555-5555-555555555555", Name: "Pak Budi", ProductName: "Beras Premium", StockQty: 200, MinPrice: 12000},
+			{UserID: "22222222-2222-2222-2222-222222222222", Name: "Pak Joyo", ProductName: "Beras Premium", StockQty: 500, MinPrice: 11500,
+				Location: &GeoPoint{Latitude: -6.2146, Longitude: 106.8841}}, // Pasar Induk Cipinang
+			{UserID: "55555555-5555-5555-5555-555555555555", Name: "Pak Budi", ProductName: "Beras Premium", StockQty: 200, MinPrice: 12000,
+				Location: &GeoPoint{Latitude: -6.2747, Longitude: 106.8666}}, // Pasar Kramat Jati
 		},
 		"cabai": {
-			{UserID: "33333333-3333-3333-3333-333333333333", Name: "Mang Ujang", ProductName: "Cabai Merah", StockQty: 20, MinPrice: 45000},
+			{UserID: "33333333-3333-3333-3333-333333333333", Name: "Mang Ujang", ProductName: "Cabai Merah", StockQty: 20, MinPrice: 45000,
+				Location: &GeoPoint{Latitude: -6.9320, Longitude: 107.5738}}, // Pasar Caringin
 		},
 		"telur": {
-			{UserID: "44444444-4444-4444-4444-444444444444", Name: "Bu Ani", ProductName: "Telur Ayam", StockQty: 100, MinPrice: 2200},
+			{UserID: "44444444-4444-4444-4444-444444444444", Name: "Bu Ani", ProductName: "Telur Ayam", StockQty: 100, MinPrice: 2200,
+				Location: &GeoPoint{Latitude: -6.1745, Longitude: 106.8412}}, // Pasar Senen
 		},
 	}
 
@@ -251,14 +282,29 @@ func (n *NegotiationOrchestrator) getDemoSellers(product string) []SellerInfo {
 }
 
 func (n *NegotiationOrchestrator) findBestSeller(sellers []SellerInfo, maxPrice, qty float64) *SellerInfo {
+	return n.findBestSellerNear(sellers, maxPrice, qty, nil)
+}
+
+// findBestSellerNear picks the seller with the lowest landed cost per unit:
+// min price plus the delivery quote spread over qty when locations are known
+func (n *NegotiationOrchestrator) findBestSellerNear(sellers []SellerInfo, maxPrice, qty float64, buyerLocation *GeoPoint) *SellerInfo {
 	var best *SellerInfo
-	bestPrice := maxPrice + 1
+	bestCost := 0.0
 
 	for i := range sellers {
 		s := &sellers[i]
-		if s.MinPrice <= maxPrice && s.StockQty >= qty && s.MinPrice < bestPrice {
+		if s.MinPrice > maxPrice || s.StockQty < qty {
+			continue
+		}
+
+		cost := s.MinPrice
+		if buyerLocation != nil && s.Location != nil && qty > 0 {
+			cost += EstimateDeliveryFee(HaversineKm(*buyerLocation, *s.Location)) / qty
+		}
+
+		if best == nil || cost < bestCost {
 			best = s
-			bestPrice = s.MinPrice
+			bestCost = cost
 		}
 	}
 
@@ -77,8 +77,8 @@ func TestProperty_SuccessfulNegotiationCreatesTransaction(t *testing.T) {
 	property := func(input validNegotiationInput) bool {
 		mockDb := newMockDB()
 		orchestrator := &NegotiationOrchestrator{
-			db:      mockDb,
-			kolosal: nil,
+			db:  mockDb,
+			llm: nil,
 		}
 
 		intent := &ai.Intent{
@@ -125,8 +125,8 @@ func TestProperty_TransactionDataIntegrity(t *testing.T) {
 	property := func(input validNegotiationInput) bool {
 		mockDb := newMockDB()
 		orchestrator := &NegotiationOrchestrator{
-			db:      mockDb,
-			kolosal: nil,
+			db:  mockDb,
+			llm: nil,
 		}
 
 		intent := &ai.Intent{
@@ -188,8 +188,8 @@ func TestProperty_TransactionTimestampValidity(t *testing.T) {
 	property := func(input validNegotiationInput) bool {
 		mockDb := newMockDB()
 		orchestrator := &NegotiationOrchestrator{
-			db:      mockDb,
-			kolosal: nil,
+			db:  mockDb,
+			llm: nil,
 		}
 
 		intent := &ai.Intent{
@@ -237,8 +237,8 @@ func TestProperty_ErrorHandlingResilience(t *testing.T) {
 		mockDb := newMockDB()
 		mockDb.failTransaction = true // Simulate transaction creation failure
 		orchestrator := &NegotiationOrchestrator{
-			db:      mockDb,
-			kolosal: nil,
+			db:  mockDb,
+			llm: nil,
 		}
 
 		intent := &ai.Intent{
@@ -288,8 +288,8 @@ func TestProperty_ReferentialIntegrity(t *testing.T) {
 	property := func(input validNegotiationInput) bool {
 		mockDb := newMockDB()
 		orchestrator := &NegotiationOrchestrator{
-			db:      mockDb,
-			kolosal: nil,
+			db:  mockDb,
+			llm: nil,
 		}
 
 		intent := &ai.Intent{
@@ -349,8 +349,8 @@ func TestProperty_QueryInclusivity(t *testing.T) {
 	property := func(input validNegotiationInput) bool {
 		mockDb := newMockDB()
 		orchestrator := &NegotiationOrchestrator{
-			db:      mockDb,
-			kolosal: nil,
+			db:  mockDb,
+			llm: nil,
 		}
 
 		// Create a manual transaction
@@ -414,8 +414,8 @@ func TestProperty_ErrorLoggingCompleteness(t *testing.T) {
 		mockDb := newMockDB()
 		mockDb.failTransaction = true
 		orchestrator := &NegotiationOrchestrator{
-			db:      mockDb,
-			kolosal: nil,
+			db:  mockDb,
+			llm: nil,
 		}
 
 		intent := &ai.Intent{
@@ -459,8 +459,8 @@ func TestProperty_ErrorLoggingCompleteness(t *testing.T) {
 func TestEdgeCases_ZeroQuantity(t *testing.T) {
 	mockDb := newMockDB()
 	orchestrator := &NegotiationOrchestrator{
-		db:      mockDb,
-		kolosal: nil,
+		db:  mockDb,
+		llm: nil,
 	}
 
 	intent := &ai.Intent{
@@ -483,8 +483,8 @@ func TestEdgeCases_ZeroQuantity(t *testing.T) {
 func TestEdgeCases_MissingBuyerID(t *testing.T) {
 	mockDb := newMockDB()
 	orchestrator := &NegotiationOrchestrator{
-		db:      mockDb,
-		kolosal: nil,
+		db:  mockDb,
+		llm: nil,
 	}
 
 	intent := &ai.Intent{
@@ -511,8 +511,8 @@ func TestEdgeCases_MissingBuyerID(t *testing.T) {
 func TestEdgeCases_DatabaseConnectionFailure(t *testing.T) {
 	// Test with nil database (simulates connection failure)
 	orchestrator := &NegotiationOrchestrator{
-		db:      nil,
-		kolosal: nil,
+		db:  nil,
+		llm: nil,
 	}
 
 	intent := &ai.Intent{
@@ -535,8 +535,8 @@ func TestEdgeCases_DatabaseConnectionFailure(t *testing.T) {
 func TestEdgeCases_NegativePrice(t *testing.T) {
 	mockDb := newMockDB()
 	orchestrator := &NegotiationOrchestrator{
-		db:      mockDb,
-		kolosal: nil,
+		db:  mockDb,
+		llm: nil,
 	}
 
 	intent := &ai.Intent{
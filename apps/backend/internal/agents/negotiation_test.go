@@ -113,3 +113,48 @@ func TestNegotiationOrchestrator_FindBestSeller(t *testing.T) {
 		t.Errorf("findBestSeller() should return cheapest seller, got price %v", best.MinPrice)
 	}
 }
+
+func TestNegotiationOrchestrator_FindBestSellerNear(t *testing.T) {
+	orchestrator := NewNegotiationOrchestrator(nil, nil)
+
+	buyer := &GeoPoint{Latitude: -6.2000, Longitude: 106.8166} // Jakarta Pusat
+	sellers := []SellerInfo{
+		// Slightly cheaper but in Bandung (~120 km)
+		{UserID: "1", Name: "Far", MinPrice: 11800, StockQty: 100, Location: &GeoPoint{Latitude: -6.9175, Longitude: 107.6191}},
+		// Nearby (~5 km)
+		{UserID: "2", Name: "Near", MinPrice: 12000, StockQty: 100, Location: &GeoPoint{Latitude: -6.2146, Longitude: 106.8841}},
+	}
+
+	// Without location the cheapest wins
+	if best := orchestrator.findBestSeller(sellers, 13000, 10); best == nil || best.Name != "Far" {
+		t.Errorf("findBestSeller() without location should pick cheapest, got %+v", best)
+	}
+
+	// With location, delivery cost makes the nearby seller cheaper overall
+	if best := orchestrator.findBestSellerNear(sellers, 13000, 10, buyer); best == nil || best.Name != "Near" {
+		t.Errorf("findBestSellerNear() should pick nearby seller, got %+v", best)
+	}
+}
+
+func TestHaversineAndDeliveryFee(t *testing.T) {
+	// Monas to Bundaran HI is about 2.3 km
+	d := HaversineKm(GeoPoint{Latitude: -6.1754, Longitude: 106.8272}, GeoPoint{Latitude: -6.1950, Longitude: 106.8230})
+	if d < 2.0 || d > 2.6 {
+		t.Errorf("HaversineKm() = %.2f, want ~2.2", d)
+	}
+
+	tests := []struct {
+		km   float64
+		want float64
+	}{
+		{0, 8000},
+		{2, 8000},
+		{4, 13000},
+		{4.1, 13500},
+	}
+	for _, tt := range tests {
+		if got := EstimateDeliveryFee(tt.km); got != tt.want {
+			t.Errorf("EstimateDeliveryFee(%v) = %v, want %v", tt.km, got, tt.want)
+		}
+	}
+}
@@ -52,6 +52,35 @@ func (n *NotificationAgent) QueueLowStockAlert(ctx context.Context, userID, prod
 	return n.QueueNotification(ctx, userID, "LOW_STOCK", title, message, "whatsapp")
 }
 
+// QueueDebtReminder queues a credit reminder about a contact. An empty
+// recipientPhone sends it to the user.
+func (n *NotificationAgent) QueueDebtReminder(ctx context.Context, userID, recipientPhone, contactID, title, message string) error {
+	if n.db == nil {
+		return fmt.Errorf("database not configured")
+	}
+
+	notif := &database.NotificationQueue{
+		UserID:         userID,
+		Type:           reminderType,
+		Title:          title,
+		Message:        message,
+		Channel:        "whatsapp",
+		Status:         "PENDING",
+		ScheduledAt:    time.Now().Format(time.RFC3339),
+		RecipientPhone: recipientPhone,
+		ReferenceID:    contactID,
+	}
+
+	err := n.db.CreateNotification(ctx, notif)
+	if err != nil {
+		log.Printf("❌ Failed to queue debt reminder: %v", err)
+		return err
+	}
+
+	log.Printf("✅ Debt reminder queued: %s", title)
+	return nil
+}
+
 // QueueDailyReport queues a daily report notification
 func (n *NotificationAgent) QueueDailyReport(ctx context.Context, userID string, scheduledTime time.Time) error {
 	title := "📊 Laporan Harian"
@@ -140,8 +169,9 @@ func (n *NotificationAgent) MarkAsFailed(ctx context.Context, notifID, errorMsg
 }
 
 // ProcessNotificationQueue processes pending notifications
-// This should be called periodically (e.g., every minute)
-func (n *NotificationAgent) ProcessNotificationQueue(ctx context.Context, sendFunc func(userID, message string) error) error {
+// This should be called periodically (e.g., every minute).
+// sendFunc delivers to notif.RecipientPhone when set, otherwise to the user.
+func (n *NotificationAgent) ProcessNotificationQueue(ctx context.Context, sendFunc func(notif database.NotificationQueue, message string) error) error {
 	notifs, err := n.GetPendingNotifications(ctx, 10)
 	if err != nil {
 		return err
@@ -164,7 +194,7 @@ func (n *NotificationAgent) ProcessNotificationQueue(ctx context.Context, sendFu
 		fullMessage := fmt.Sprintf("*%s*\n\n%s", notif.Title, notif.Message)
 
 		// Send notification
-		err = sendFunc(notif.UserID, fullMessage)
+		err = sendFunc(notif, fullMessage)
 		if err != nil {
 			n.MarkAsFailed(ctx, notif.ID, err.Error())
 			continue
@@ -2,13 +2,17 @@ package agents
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/pasarsuara/backend/internal/ai"
 	appcontext "github.com/pasarsuara/backend/internal/context"
 	"github.com/pasarsuara/backend/internal/database"
+	"github.com/pasarsuara/backend/internal/storage"
 )
 
 // AgentOrchestrator coordinates all agents based on intent
@@ -17,12 +21,28 @@ type AgentOrchestrator struct {
 	finance      *FinanceAgent
 	negotiation  *NegotiationOrchestrator
 	promo        *PromoAgent
+	data         *DataAgent
 	inventory    *InventoryAgent
 	catalog      *CatalogAgent
 	contact      *ContactAgent
 	notification *NotificationAgent
+	credit       *CreditAgent
+	budget       *BudgetAgent
+	tax          *TaxAgent
+	recurring    *RecurringAgent
 	intentEngine *ai.IntentEngine
 	contextMgr   *appcontext.ConversationManager
+	pending      *PendingActionStore
+	tts          ai.SpeechSynthesizer
+	store        storage.ObjectStore
+	policy       *ConfidencePolicy
+	recent       *RecentTransactions
+
+	voicePrefs map[string]bool // user phone -> voice reply preference
+	voiceMu    sync.Mutex
+
+	languages map[string]string // user phone -> last detected language, routes STT
+	langMu    sync.Mutex
 }
 
 // AgentResponse represents the response from agent processing
@@ -32,54 +52,153 @@ type AgentResponse struct {
 	Intent      *ai.Intent            `json:"intent,omitempty"`
 	Transaction *database.Transaction `json:"transaction,omitempty"`
 	Negotiation *NegotiationResult    `json:"negotiation,omitempty"`
+	Replies     []Reply               `json:"replies,omitempty"` // Structured replies for the gateway, Message is the text fallback
+
+	// PendingActionID is set when the reply proposes an action awaiting confirmation
+	PendingActionID string `json:"pending_action_id,omitempty"`
 }
 
-func NewAgentOrchestrator(db *database.SupabaseClient, intentEngine *ai.IntentEngine, kolosal *ai.KolosalClient, kolosalKey, kolosalURL, geminiKey string, contextMgr *appcontext.ConversationManager) *AgentOrchestrator {
+// NewAgentOrchestrator wires all agents to one LLM provider, usually the shared ai.Router
+func NewAgentOrchestrator(db *database.SupabaseClient, intentEngine *ai.IntentEngine, llm ai.Provider, contextMgr *appcontext.ConversationManager) *AgentOrchestrator {
+	notification := NewNotificationAgent(db)
+	finance := NewFinanceAgent(db)
+	inventory := NewInventoryAgent(db)
+	budget := NewBudgetAgent(db, notification)
 	return &AgentOrchestrator{
 		db:           db,
-		finance:      NewFinanceAgent(db),
-		negotiation:  NewNegotiationOrchestrator(db, kolosal),
-		promo:        NewPromoAgent(db, kolosalKey, kolosalURL, geminiKey),
-		inventory:    NewInventoryAgent(db),
-		catalog:      NewCatalogAgent(db),
+		finance:      finance,
+		negotiation:  NewNegotiationOrchestrator(db, llm),
+		promo:        NewPromoAgent(db, llm),
+		data:         NewDataAgent(db, llm),
+		inventory:    inventory,
+		catalog:      NewCatalogAgent(db, llm),
 		contact:      NewContactAgent(db),
-		notification: NewNotificationAgent(db),
+		notification: notification,
+		credit:       NewCreditAgent(db, notification),
+		budget:       budget,
+		tax:          NewTaxAgent(db, notification),
+		recurring:    NewRecurringAgent(db, notification, finance, inventory, budget),
 		intentEngine: intentEngine,
 		contextMgr:   contextMgr,
+		pending:      NewPendingActionStore(0),
+		recent:       NewRecentTransactions(0),
+		voicePrefs:   make(map[string]bool),
+		languages:    make(map[string]string),
 	}
 }
 
+// GetCreditAgent returns the credit agent, which also runs the reminders
+func (o *AgentOrchestrator) GetCreditAgent() *CreditAgent {
+	return o.credit
+}
+
+// GetBudgetAgent returns the budget agent, which also runs the month-end summaries
+func (o *AgentOrchestrator) GetBudgetAgent() *BudgetAgent {
+	return o.budget
+}
+
+// GetTaxAgent returns the tax agent, which also runs the summaries and reminders
+func (o *AgentOrchestrator) GetTaxAgent() *TaxAgent {
+	return o.tax
+}
+
+// GetRecurringAgent returns the recurring transactions agent, which also runs the scheduler
+func (o *AgentOrchestrator) GetRecurringAgent() *RecurringAgent {
+	return o.recurring
+}
+
 // GetPromoAgent returns the promo agent for external use
 func (o *AgentOrchestrator) GetPromoAgent() *PromoAgent {
 	return o.promo
 }
 
-// ProcessAudio handles incoming audio message
-func (o *AgentOrchestrator) ProcessAudio(ctx context.Context, userPhone string, audioData []byte, mimeType string) *AgentResponse {
+// ProcessAudio handles incoming audio message. quotedMessageID is the
+// message the voice note replies to, if any.
+func (o *AgentOrchestrator) ProcessAudio(ctx context.Context, userPhone string, audioData []byte, mimeType, quotedMessageID string) *AgentResponse {
 	log.Printf("🎯 Orchestrator processing audio from %s: %d bytes", userPhone, len(audioData))
+	ctx = ai.WithTenant(ctx, userPhone)
+	ctx = ai.WithLanguage(ctx, o.speakerLanguage(ctx, userPhone))
 
 	// Step 1: Transcribe audio to text using Gemini
-	transcript, err := o.intentEngine.ProcessAudio(ctx, audioData, mimeType)
+	transcript, err := o.intentEngine.ProcessAudioWithHistory(ctx, audioData, mimeType, o.conversationHistory(userPhone))
+	if errors.Is(err, ai.ErrQuotaExceeded) {
+		log.Printf("🚫 Voice note from %s skipped, LLM quota used up", userPhone)
+		return &AgentResponse{
+			Success: false,
+			Message: "🙏 Kuota voice note bulan ini sudah habis. Kirim lewat pesan teks dulu ya, contoh: \"laku nasi goreng 10 porsi 15rb\"",
+		}
+	}
 	if err != nil {
 		log.Printf("❌ Audio transcription failed: %v", err)
+		message := ai.AudioErrorMessage(err)
+		if message == "" {
+			message = "Maaf, voice note tidak bisa diproses. Coba kirim pesan teks ya! 🙏"
+		}
 		return &AgentResponse{
 			Success: false,
-			Message: "Maaf, voice note tidak bisa diproses. Coba kirim pesan teks ya! 🙏",
+			Message: message,
 		}
 	}
 
 	log.Printf("📝 Transcript: %s", transcript.RawText)
 
+	// A spoken "ya"/"batal" may answer a pending confirmation
+	if response, handled := o.ProcessConfirmationReply(ctx, userPhone, quotedMessageID, transcript.RawText); handled {
+		return response
+	}
+	if enabled, ok := parseVoiceCommand(transcript.RawText); ok {
+		return o.SetVoiceReplies(ctx, userPhone, enabled)
+	}
+
 	// Step 2: Process the transcript as text
 	return o.processIntent(ctx, userPhone, transcript)
 }
 
+// speakerLanguage is the language last detected from the user's messages,
+// else their preference; Javanese and Sundanese route STT differently
+func (o *AgentOrchestrator) speakerLanguage(ctx context.Context, userPhone string) string {
+	o.langMu.Lock()
+	language := o.languages[userPhone]
+	o.langMu.Unlock()
+	if language != "" || o.db == nil {
+		return language
+	}
+
+	prefs, err := o.db.GetUserPreferences(ctx, o.getUserID(ctx, userPhone))
+	if err != nil {
+		log.Printf("⚠️ Failed to get user preferences: %v", err)
+	}
+	if prefs == nil {
+		return ""
+	}
+	return prefs.Language
+}
+
+// rememberLanguage keeps the language of the user's latest message
+func (o *AgentOrchestrator) rememberLanguage(userPhone, language string) {
+	if language == "" {
+		return
+	}
+	o.langMu.Lock()
+	defer o.langMu.Unlock()
+	if o.languages == nil {
+		o.languages = make(map[string]string)
+	}
+	o.languages[userPhone] = language
+}
+
 // ProcessMessage handles incoming message and routes to appropriate agent
 func (o *AgentOrchestrator) ProcessMessage(ctx context.Context, userPhone, text string) *AgentResponse {
 	log.Printf("🎯 Orchestrator processing message from %s: %s", userPhone, text)
+	ctx = ai.WithTenant(ctx, userPhone)
+
+	// "balas pakai suara" / "balas teks saja" toggle voice replies
+	if enabled, ok := parseVoiceCommand(text); ok {
+		return o.SetVoiceReplies(ctx, userPhone, enabled)
+	}
 
 	// Step 1: Extract intent
-	intent, err := o.intentEngine.ProcessText(ctx, text)
+	intent, err := o.intentEngine.ProcessTextWithHistory(ctx, text, o.conversationHistory(userPhone))
 	if err != nil {
 		log.Printf("❌ Intent extraction failed: %v", err)
 		return &AgentResponse{
@@ -91,11 +210,79 @@ func (o *AgentOrchestrator) ProcessMessage(ctx context.Context, userPhone, text
 	return o.processIntent(ctx, userPhone, intent)
 }
 
+// ProcessSelection handles a button or list selection from the user.
+// Ambiguity answers fill the pending intent from context; anything else is
+// processed as if the user typed the selected text.
+func (o *AgentOrchestrator) ProcessSelection(ctx context.Context, userPhone, selectedID, displayText string) *AgentResponse {
+	log.Printf("🎯 Orchestrator processing selection from %s: %s (%s)", userPhone, selectedID, displayText)
+
+	selection, ok := ParseSelectionID(selectedID)
+	if ok && selection.Kind == selectionKindConfirm {
+		return o.ConfirmAction(ctx, userPhone, selection.Field, selection.Value == "yes")
+	}
+	if ok && selection.Kind == selectionKindPick {
+		return o.PickEntityValue(ctx, userPhone, selection.Field, selection.Value)
+	}
+	if ok && selection.Kind == selectionKindBudget {
+		return o.applyBudgetSuggestions(ctx, userPhone)
+	}
+	if ok && selection.Kind == selectionKindContact {
+		return o.completeContact(ctx, userPhone, selection.Field, selection.Value)
+	}
+	if ok && selection.Kind == selectionKindAmbiguity && o.contextMgr != nil {
+		action := o.contextMgr.GetLastIntent(userPhone)
+		if action != "" {
+			entities := make(map[string]any)
+			for k, v := range o.contextMgr.GetLastEntities(userPhone) {
+				entities[k] = v
+			}
+
+			switch selection.Field {
+			case "qty", "price", "max_price", "amount":
+				var value float64
+				if _, err := fmt.Sscanf(selection.Value, "%f", &value); err == nil {
+					entities[selection.Field] = value
+				}
+			default:
+				entities[selection.Field] = selection.Value
+			}
+
+			return o.processIntent(ctx, userPhone, &ai.Intent{
+				Action:   action,
+				Entities: entities,
+				Language: "id",
+				RawText:  displayText,
+			})
+		}
+	}
+
+	if displayText == "" {
+		return &AgentResponse{
+			Success: false,
+			Message: "Maaf, pilihan tidak dikenali. Coba ketik pesannya ya!",
+		}
+	}
+
+	return o.ProcessMessage(ctx, userPhone, displayText)
+}
+
 // processIntent handles intent routing to agents
 func (o *AgentOrchestrator) processIntent(ctx context.Context, userPhone string, intent *ai.Intent) *AgentResponse {
+	// Prompt experiments bucket WhatsApp users by phone number
+	ctx = ai.WithTenant(ctx, userPhone)
+	o.rememberLanguage(userPhone, intent.Language)
+
 	// Get or create user
 	userID := o.getUserID(ctx, userPhone)
 
+	// Resolve dates for backdating and report periods
+	o.addDateEntities(ctx, userID, intent)
+
+	// "yang tadi jadi 12 ribu", "itu juga 5", "batal yang tadi"
+	if ai.IsFollowUpAction(intent.Action) {
+		return o.handleFollowUp(ctx, userPhone, userID, intent)
+	}
+
 	// Get conversation context
 	if o.contextMgr != nil {
 		lastEntities := o.contextMgr.GetLastEntities(userPhone)
@@ -138,9 +325,15 @@ func (o *AgentOrchestrator) processIntent(ctx context.Context, userPhone string,
 			Success: false,
 			Intent:  intent,
 			Message: FormatAmbiguityResponse(ambiguityCheck),
+			Replies: []Reply{FormatAmbiguityReply(ambiguityCheck)},
 		}
 	}
 
+	// Transactions the extractor is unsure about are confirmed before recording
+	if decision := o.confidencePolicy().Evaluate(intent); decision.Decision != DecisionCommit {
+		return o.handleLowConfidence(ctx, userPhone, intent, decision)
+	}
+
 	// Route to appropriate agent based on intent
 	response := &AgentResponse{
 		Success: true,
@@ -149,52 +342,22 @@ func (o *AgentOrchestrator) processIntent(ctx context.Context, userPhone string,
 
 	switch intent.Action {
 	case "RECORD_SALE":
-		tx, err := o.finance.RecordSale(ctx, userID, intent)
-		if err != nil {
-			response.Success = false
-			response.Message = "Gagal mencatat penjualan: " + err.Error()
-		} else {
-			response.Transaction = tx
-			response.Message = o.formatSaleResponse(tx)
-
-			// Auto-update inventory
-			if o.inventory != nil {
-				alert, err := o.inventory.UpdateStockAfterSale(ctx, userID, intent)
-				if err != nil {
-					log.Printf("⚠️ Failed to update inventory: %v", err)
-				} else if alert != nil {
-					// Append stock alert to response
-					response.Message += "\n\n" + o.inventory.FormatStockAlert(alert)
-				}
-			}
+		// Large sales need explicit confirmation before recording
+		if getFloatEntity(intent.Entities, "qty")*getFloatEntity(intent.Entities, "price") >= LargeSaleThreshold {
+			response = o.proposeAction(ctx, userPhone, PendingSale, intent, nil)
+			break
 		}
+		o.recordSale(ctx, userID, intent, response)
 
 	case "RECORD_EXPENSE":
-		tx, err := o.finance.RecordExpense(ctx, userID, intent)
-		if err != nil {
-			response.Success = false
-			response.Message = "Gagal mencatat pengeluaran: " + err.Error()
-		} else {
-			response.Transaction = tx
-			response.Message = o.formatExpenseResponse(tx)
-		}
+		o.recordExpense(ctx, userID, intent, response)
 
 	case "ORDER_RESTOCK":
-		negResult := o.negotiation.StartNegotiation(ctx, userID, intent)
+		negResult := o.negotiation.StartNegotiationNear(ctx, userID, intent, o.getUserLocation(ctx, userID))
 		response.Negotiation = negResult
 		if negResult.Success {
-			// Record the purchase
-			tx, _ := o.finance.RecordPurchase(ctx, userID, intent, negResult.FinalPrice)
-			response.Transaction = tx
-			response.Message = o.formatNegotiationSuccess(negResult)
-
-			// Auto-update inventory
-			if o.inventory != nil {
-				err := o.inventory.UpdateStockAfterPurchase(ctx, userID, intent, negResult.Quantity)
-				if err != nil {
-					log.Printf("⚠️ Failed to update inventory: %v", err)
-				}
-			}
+			// Purchase is recorded once the user confirms the deal
+			response = o.proposeAction(ctx, userPhone, PendingPurchase, intent, negResult)
 		} else {
 			response.Message = o.formatNegotiationFailed(negResult)
 		}
@@ -211,6 +374,39 @@ func (o *AgentOrchestrator) processIntent(ctx context.Context, userPhone string,
 	case "REQUEST_REPORT":
 		response.Message = o.handleReportRequest(ctx, userID, intent)
 
+	case "ASK_DATA":
+		response.Message = o.data.Answer(ctx, userID, intent, time.Now().In(o.userLocation(ctx, userID)))
+
+	case "RECORD_DEBT":
+		o.recordDebt(ctx, userID, intent, response)
+
+	case "RECORD_DEBT_PAYMENT":
+		response.Message = o.handleDebtPayment(ctx, userID, intent)
+
+	case "ASK_DEBTS":
+		response.Message = o.handleAskDebts(ctx, userID, intent)
+
+	case "SET_BUDGET":
+		response.Message = o.handleSetBudget(ctx, userID, intent)
+
+	case "ASK_BUDGET":
+		o.handleAskBudget(ctx, userID, intent, response)
+
+	case "SET_TAX":
+		response.Message = o.handleSetTax(ctx, userID, intent)
+
+	case "ASK_TAX":
+		response.Message = o.handleAskTax(ctx, userID, intent)
+
+	case "SET_RECURRING":
+		response.Message = o.handleSetRecurring(ctx, userID, intent)
+
+	case "ASK_RECURRING":
+		response.Message = o.handleAskRecurring(ctx, userID)
+
+	case "UPDATE_RECURRING":
+		response.Message = o.handleUpdateRecurring(ctx, userID, intent)
+
 	case "GREETING":
 		response.Message = o.getGreetingResponse(userPhone)
 
@@ -218,6 +414,11 @@ func (o *AgentOrchestrator) processIntent(ctx context.Context, userPhone string,
 		response.Message = o.intentEngine.GenerateResponse(intent)
 	}
 
+	if response.Transaction != nil && response.Success {
+		response.Message += formatBackdate(intent)
+	}
+	o.rememberTransaction(userPhone, response, intent)
+
 	// Store assistant response in context
 	if o.contextMgr != nil {
 		o.contextMgr.AddMessage(userPhone, "assistant", response.Message, intent.Action, nil)
@@ -226,6 +427,107 @@ func (o *AgentOrchestrator) processIntent(ctx context.Context, userPhone string,
 	return response
 }
 
+// recordSale records a sale and updates stock, filling the response
+func (o *AgentOrchestrator) recordSale(ctx context.Context, userID string, intent *ai.Intent, response *AgentResponse) {
+	credit, ok := o.creditTerms(ctx, userID, intent, response)
+	if !ok {
+		return
+	}
+	tx, err := o.finance.RecordSale(ctx, userID, intent, credit)
+	if errors.Is(err, ErrDuplicateTransaction) {
+		// Redelivered message: answer again without touching stock
+		response.Transaction = tx
+		response.Message = o.formatSaleResponse(tx)
+		return
+	}
+	if err != nil {
+		response.Success = false
+		response.Message = "Gagal mencatat penjualan: " + err.Error()
+		return
+	}
+
+	response.Transaction = tx
+	response.Message = o.formatSaleResponse(tx)
+	if credit != nil {
+		response.Message += formatCreditTerms(o.credit.EntryFor(ctx, tx, credit))
+	}
+
+	// Auto-update inventory
+	if o.inventory != nil {
+		alert, err := o.inventory.UpdateStockAfterSale(ctx, userID, intent)
+		if err != nil {
+			log.Printf("⚠️ Failed to update inventory: %v", err)
+		} else if alert != nil {
+			// Append stock alert to response
+			response.Message += "\n\n" + o.inventory.FormatStockAlert(alert)
+		}
+	}
+}
+
+// recordExpense records an expense and tracks it against its budget,
+// filling the response
+func (o *AgentOrchestrator) recordExpense(ctx context.Context, userID string, intent *ai.Intent, response *AgentResponse) {
+	credit, ok := o.creditTerms(ctx, userID, intent, response)
+	if !ok {
+		return
+	}
+	tx, err := o.finance.RecordExpense(ctx, userID, intent, credit)
+	if err != nil && !errors.Is(err, ErrDuplicateTransaction) {
+		response.Success = false
+		response.Message = "Gagal mencatat pengeluaran: " + err.Error()
+		return
+	}
+	response.Transaction = tx
+	response.Message = o.formatExpenseResponse(tx)
+	if err != nil {
+		return
+	}
+	if credit != nil {
+		response.Message += formatCreditTerms(o.credit.EntryFor(ctx, tx, credit))
+	}
+	if alert := o.budget.Track(ctx, tx, time.Now().In(o.userLocation(ctx, userID))); alert != "" {
+		response.Message += "\n\n" + alert
+	}
+}
+
+// creditTerms are the credit terms of a sale or expense, nil when it is paid
+// on the spot. ok is false when they cannot be used, with the response filled.
+func (o *AgentOrchestrator) creditTerms(ctx context.Context, userID string, intent *ai.Intent, response *AgentResponse) (terms *CreditTerms, ok bool) {
+	terms, err := o.credit.Terms(ctx, userID, intent, time.Now().In(o.userLocation(ctx, userID)))
+	switch {
+	case errors.Is(err, ErrNoCreditContact):
+		response.Success = false
+		response.Message = "🤔 Ngutang atas nama siapa? Contoh: \"jual bakso 10 porsi 15rb ngutang Bu Sari\""
+		return nil, false
+	case err != nil:
+		response.Success = false
+		response.Message = "Gagal mencatat kasbon: " + err.Error()
+		return nil, false
+	}
+	return terms, true
+}
+
+// recordPurchase records a negotiated purchase and updates stock, filling the response
+func (o *AgentOrchestrator) recordPurchase(ctx context.Context, userID string, intent *ai.Intent, negResult *NegotiationResult, response *AgentResponse) {
+	tx, err := o.finance.RecordPurchase(ctx, userID, intent, negResult.FinalPrice)
+	if err != nil && !errors.Is(err, ErrDuplicateTransaction) {
+		response.Success = false
+		response.Message = "Gagal mencatat pembelian: " + err.Error()
+		return
+	}
+
+	response.Transaction = tx
+	response.Negotiation = negResult
+	response.Message = o.formatNegotiationSuccess(negResult)
+
+	// Auto-update inventory (skipped for redelivered messages)
+	if o.inventory != nil && !errors.Is(err, ErrDuplicateTransaction) {
+		if err := o.inventory.UpdateStockAfterPurchase(ctx, userID, intent, negResult.Quantity); err != nil {
+			log.Printf("⚠️ Failed to update inventory: %v", err)
+		}
+	}
+}
+
 func (o *AgentOrchestrator) getUserID(ctx context.Context, phone string) string {
 	if o.db == nil {
 		// Return demo user ID based on phone
@@ -241,18 +543,30 @@ func (o *AgentOrchestrator) getUserID(ctx context.Context, phone string) string
 }
 
 func (o *AgentOrchestrator) formatSaleResponse(tx *database.Transaction) string {
+	margin := ""
+	if profit := tx.TotalAmount - tx.COGS; tx.COGS > 0 && profit >= 0 {
+		margin = fmt.Sprintf("📈 Untung: Rp %s (modal Rp %s)\n", formatCurrency(profit), formatCurrency(tx.COGS))
+	} else if tx.COGS > 0 {
+		margin = fmt.Sprintf("📉 Rugi: Rp %s (modal Rp %s)\n", formatCurrency(-profit), formatCurrency(tx.COGS))
+	}
+	ppn := ""
+	if tx.PPNAmount > 0 {
+		ppn = fmt.Sprintf("🧾 DPP: Rp %s\n🧾 PPN: Rp %s (sudah termasuk dalam harga)\n",
+			formatCurrency(tx.TotalAmount-tx.PPNAmount), formatCurrency(tx.PPNAmount))
+	}
 	return fmt.Sprintf("✅ Penjualan tercatat!\n\n"+
 		"📦 Produk: %s\n"+
 		"📊 Jumlah: %.0f\n"+
 		"💰 Harga: Rp %.0f\n"+
-		"💵 Total: Rp %.0f\n\n"+
+		"💵 Total: Rp %.0f\n"+
+		"%s%s\n"+
 		"Terima kasih! Semoga laris manis 🙏",
-		tx.ProductName, tx.Qty, tx.PricePerUnit, tx.TotalAmount)
+		tx.ProductName, tx.Qty, tx.PricePerUnit, tx.TotalAmount, ppn, margin)
 }
 
 func (o *AgentOrchestrator) formatExpenseResponse(tx *database.Transaction) string {
 	// Auto-categorize expense
-	category := CategorizeExpense(tx.ProductName)
+	category := TransactionCategory(tx)
 	categoryInfo := FormatCategoryInfo(category)
 
 	return fmt.Sprintf("💸 Pengeluaran tercatat!\n\n"+
@@ -395,6 +709,26 @@ func (o *AgentOrchestrator) handleReportRequest(ctx context.Context, userID stri
 	var err error
 	var formatted string
 
+	// Dates resolved in the user's timezone take precedence
+	startDate := getStringEntity(intent.Entities, "start_date")
+	endDate := getStringEntity(intent.Entities, "end_date")
+	if startDate != "" && endDate != "" {
+		report, err = reportAgent.GenerateReportForDateRange(ctx, userID, startDate, endDate)
+		if err != nil {
+			return "Maaf, gagal membuat laporan. Coba lagi ya!"
+		}
+		switch {
+		case period == "weekly":
+			return reportAgent.FormatWeeklyReport(report)
+		case period == "monthly":
+			return reportAgent.FormatMonthlyReport(report)
+		case startDate == endDate:
+			return reportAgent.FormatDailyReport(report)
+		default:
+			return reportAgent.FormatRangeReport(report, startDate, endDate)
+		}
+	}
+
 	switch period {
 	case "weekly":
 		report, err = reportAgent.GenerateWeeklyReport(ctx, userID)
@@ -0,0 +1,185 @@
+package agents
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pasarsuara/backend/internal/ai"
+	"github.com/pasarsuara/backend/internal/database"
+)
+
+// Pending action kinds. Bulk imports are not confirmed in chat.
+const (
+	PendingSale     = "RECORD_SALE"
+	PendingPurchase = "ORDER_RESTOCK"
+)
+
+// LargeSaleThreshold is the sale total (Rp) that requires confirmation
+const LargeSaleThreshold = 1000000
+
+// PendingAction is a risky action proposed by the bot, waiting for the user
+// to confirm with a 👍 reaction, a "ya" reply or a button
+type PendingAction struct {
+	ID             string
+	UserPhone      string
+	Kind           string
+	Intent         *ai.Intent
+	Negotiation    *NegotiationResult
+	IdempotencyKey string                // Source message id of the original request
+	MessageID      string                // Bot's outbound message id carrying the proposal
+	Field          string                // Entity being confirmed by an entity check
+	Options        []string              // Values offered for Field
+	Transaction    *database.Transaction // Earlier transaction a cancellation targets
+	Media          *ai.MediaPart         // Photo a catalog entry is created with
+	CreatedAt      time.Time
+	ExpiresAt      time.Time
+}
+
+// Expired reports whether the action can no longer be confirmed
+func (a *PendingAction) Expired() bool {
+	return time.Now().After(a.ExpiresAt)
+}
+
+// PendingActionStore keeps unconfirmed actions in memory until they expire
+type PendingActionStore struct {
+	actions   map[string]*PendingAction
+	byMessage map[string]string // outbound message id -> action id
+	latest    map[string]string // user phone -> latest action id
+	mu        sync.Mutex
+	ttl       time.Duration
+}
+
+// NewPendingActionStore creates a store; unconfirmed actions expire after ttl
+func NewPendingActionStore(ttl time.Duration) *PendingActionStore {
+	if ttl == 0 {
+		ttl = 15 * time.Minute // Default 15 minutes
+	}
+
+	s := &PendingActionStore{
+		actions:   make(map[string]*PendingAction),
+		byMessage: make(map[string]string),
+		latest:    make(map[string]string),
+		ttl:       ttl,
+	}
+
+	// Start cleanup goroutine
+	go s.cleanupExpired()
+
+	return s
+}
+
+// Create stores a new pending action for the user
+func (s *PendingActionStore) Create(userPhone, kind string, intent *ai.Intent, negotiation *NegotiationResult, idempotencyKey string) *PendingAction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	action := &PendingAction{
+		ID:             uuid.New().String(),
+		UserPhone:      userPhone,
+		Kind:           kind,
+		Intent:         intent,
+		Negotiation:    negotiation,
+		IdempotencyKey: idempotencyKey,
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(s.ttl),
+	}
+
+	s.actions[action.ID] = action
+	s.latest[userPhone] = action.ID
+	return action
+}
+
+// BindMessage links the bot's outbound message to the action so reactions
+// and quoted replies to that message can confirm it
+func (s *PendingActionStore) BindMessage(actionID, messageID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	action, ok := s.actions[actionID]
+	if !ok || messageID == "" {
+		return false
+	}
+	action.MessageID = messageID
+	s.byMessage[messageID] = actionID
+	return true
+}
+
+// AttachMedia keeps a photo with the action until it is confirmed
+func (s *PendingActionStore) AttachMedia(actionID string, media *ai.MediaPart) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	action, ok := s.actions[actionID]
+	if !ok {
+		return false
+	}
+	action.Media = media
+	return true
+}
+
+// Get returns an action by id, including expired ones
+func (s *PendingActionStore) Get(actionID string) *PendingAction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.actions[actionID]
+}
+
+// GetByMessage returns the action proposed in the given outbound message
+func (s *PendingActionStore) GetByMessage(messageID string) *PendingAction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.actions[s.byMessage[messageID]]
+}
+
+// GetLatest returns the user's most recent action
+func (s *PendingActionStore) GetLatest(userPhone string) *PendingAction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.actions[s.latest[userPhone]]
+}
+
+// Take removes and returns the action, so it can only be confirmed once
+func (s *PendingActionStore) Take(actionID string) *PendingAction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	action, ok := s.actions[actionID]
+	if !ok {
+		return nil
+	}
+	s.remove(action)
+	return action
+}
+
+// remove deletes the action and its indexes; caller must hold the lock
+func (s *PendingActionStore) remove(action *PendingAction) {
+	delete(s.actions, action.ID)
+	if action.MessageID != "" {
+		delete(s.byMessage, action.MessageID)
+	}
+	if s.latest[action.UserPhone] == action.ID {
+		delete(s.latest, action.UserPhone)
+	}
+}
+
+// cleanupExpired removes expired actions
+func (s *PendingActionStore) cleanupExpired() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.mu.Lock()
+		for _, action := range s.actions {
+			// Keep for a while after expiry so late confirmations get a clear answer
+			if time.Since(action.ExpiresAt) > s.ttl {
+				s.remove(action)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
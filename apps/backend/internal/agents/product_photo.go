@@ -0,0 +1,135 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/pasarsuara/backend/internal/ai"
+	"github.com/pasarsuara/backend/internal/database"
+	"github.com/pasarsuara/backend/internal/storage"
+)
+
+// PendingCatalog is a catalog entry drafted from a product photo
+const PendingCatalog = "ADD_PRODUCT"
+
+// lowPhotoConfidence marks drafts where the photo may not show the product
+const lowPhotoConfidence = 0.5
+
+// SetObjectStore enables saving product photos as catalog images
+func (o *AgentOrchestrator) SetObjectStore(store storage.ObjectStore) {
+	o.store = store
+}
+
+// ProcessImage drafts a catalog entry from a product photo and its caption,
+// and asks the seller to confirm it before anything is saved
+func (o *AgentOrchestrator) ProcessImage(ctx context.Context, userPhone string, image []byte, mimeType, caption string) *AgentResponse {
+	log.Printf("🎯 Orchestrator processing image from %s: %d bytes", userPhone, len(image))
+	ctx = ai.WithTenant(ctx, userPhone)
+
+	draft, err := o.catalog.DraftFromPhoto(ctx, image, mimeType, caption)
+	if err != nil {
+		log.Printf("❌ Product draft failed: %v", err)
+		return &AgentResponse{
+			Success: false,
+			Message: "📷 Gambar diterima, tapi produknya belum kebaca. Kirim ulang dengan caption nama dan harga ya.\n\nContoh: \"Nasi goreng spesial 15 ribu\"",
+		}
+	}
+
+	intent := &ai.Intent{
+		Action: PendingCatalog,
+		Entities: map[string]any{
+			"product":     draft.ProductName,
+			"category":    draft.Category,
+			"description": draft.Description,
+			"price":       draft.DefaultPrice,
+			"unit":        draft.DefaultUnit,
+			"confidence":  draft.Confidence,
+		},
+		RawText: caption,
+	}
+	response := o.proposeAction(ctx, userPhone, PendingCatalog, intent, nil)
+	o.pending.AttachMedia(response.PendingActionID, &ai.MediaPart{MimeType: mimeType, Data: image})
+	return response
+}
+
+// completeCatalog stores the photo and creates the confirmed catalog entry.
+// A failed upload still saves the product, without an image.
+func (o *AgentOrchestrator) completeCatalog(ctx context.Context, userID string, action *PendingAction) *AgentResponse {
+	entities := action.Intent.Entities
+	product := &database.ProductCatalog{
+		ProductName:  getStringEntity(entities, "product"),
+		Category:     getStringEntity(entities, "category"),
+		Description:  getStringEntity(entities, "description"),
+		DefaultPrice: getFloatEntity(entities, "price"),
+		DefaultUnit:  getStringEntity(entities, "unit"),
+	}
+
+	if o.store != nil && action.Media != nil {
+		key := storage.NewKey("products/"+userID, action.Media.MimeType)
+		url, err := o.store.Put(ctx, key, action.Media.Data, action.Media.MimeType)
+		if err != nil {
+			log.Printf("⚠️ Product photo not stored in %s: %v", o.store.Name(), err)
+		} else {
+			product.ImageURL = url
+		}
+	}
+
+	saved, err := o.catalog.AddProduct(ctx, userID, product)
+	if err != nil {
+		return &AgentResponse{
+			Success: false,
+			Intent:  action.Intent,
+			Message: "Gagal menyimpan produk: " + err.Error(),
+		}
+	}
+
+	message := fmt.Sprintf("✅ %s masuk katalog!", saved.ProductName)
+	if saved.DefaultPrice > 0 {
+		message += fmt.Sprintf("\n\n💰 Rp %s", formatCurrency(saved.DefaultPrice))
+		if saved.DefaultUnit != "" {
+			message += "/" + saved.DefaultUnit
+		}
+	}
+	if saved.ImageURL != "" {
+		message += "\n📷 Foto produk tersimpan"
+	}
+	return &AgentResponse{
+		Success: true,
+		Intent:  action.Intent,
+		Message: message,
+	}
+}
+
+func (o *AgentOrchestrator) formatCatalogProposal(intent *ai.Intent) string {
+	product := getStringEntity(intent.Entities, "product")
+	category := getStringEntity(intent.Entities, "category")
+	description := getStringEntity(intent.Entities, "description")
+	price := getFloatEntity(intent.Entities, "price")
+	unit := getStringEntity(intent.Entities, "unit")
+
+	if category == "" {
+		category = "Lainnya"
+	}
+	priceText := "belum diisi"
+	if price > 0 {
+		priceText = "Rp " + formatCurrency(price)
+		if unit != "" {
+			priceText += "/" + unit
+		}
+	}
+
+	text := fmt.Sprintf("📷 Tambah ke Katalog?\n\n"+
+		"📦 Produk: %s\n"+
+		"🏷️ Kategori: %s\n"+
+		"💰 Harga: %s\n",
+		product, category, priceText)
+	if description != "" {
+		text += fmt.Sprintf("📝 %s\n", description)
+	}
+	if confidence := getFloatEntity(intent.Entities, "confidence"); confidence > 0 && confidence < lowPhotoConfidence {
+		text += "\n⚠️ Fotonya kurang jelas, cek lagi datanya ya."
+	}
+	text += "\nSudah benar? Kalau ada yang salah, kirim ulang fotonya dengan caption yang lengkap."
+	return text
+}
@@ -0,0 +1,58 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newPhotoTestOrchestrator(llm *scriptedLLM) *AgentOrchestrator {
+	return &AgentOrchestrator{
+		catalog: NewCatalogAgent(nil, llm),
+		pending: NewPendingActionStore(time.Minute),
+	}
+}
+
+func TestProcessImage_ProposesCatalogEntry(t *testing.T) {
+	llm := &scriptedLLM{text: `{"product_name":"Nasi Goreng Spesial","category":"Makanan","description":"Nasi goreng dengan telur","default_price":15000,"default_unit":"porsi","confidence":0.9}`}
+	o := newPhotoTestOrchestrator(llm)
+	ctx := context.Background()
+	photo := []byte{0xFF, 0xD8, 0xFF}
+
+	resp := o.ProcessImage(ctx, "628111", photo, "image/jpeg", "Nasi goreng spesial 15 ribu")
+	if resp.PendingActionID == "" || !resp.Success {
+		t.Fatalf("Photo should be proposed for confirmation, got %+v", resp)
+	}
+	for _, want := range []string{"Nasi Goreng Spesial", "Makanan", "Rp 15.000/porsi", "Nasi goreng dengan telur"} {
+		if !strings.Contains(resp.Message, want) {
+			t.Errorf("Proposal missing %q:\n%s", want, resp.Message)
+		}
+	}
+
+	action := o.pending.Get(resp.PendingActionID)
+	if action.Kind != PendingCatalog || action.Media == nil || len(action.Media.Data) != len(photo) {
+		t.Fatalf("Pending action = %+v, want the photo kept until confirmation", action)
+	}
+
+	resp, handled := o.ProcessConfirmationReply(ctx, "628111", "", "batal")
+	if !handled || resp.PendingActionID != "" || o.pending.Get(action.ID) != nil {
+		t.Errorf("\"batal\" should drop the draft, got %+v", resp)
+	}
+}
+
+func TestProcessImage_CaptionFallback(t *testing.T) {
+	o := newPhotoTestOrchestrator(&scriptedLLM{err: errors.New("vision down")})
+	ctx := context.Background()
+
+	resp := o.ProcessImage(ctx, "628111", []byte{0xFF}, "image/jpeg", "Es teh 5rb/gelas")
+	if resp.PendingActionID == "" || !strings.Contains(resp.Message, "Es Teh") || !strings.Contains(resp.Message, "Rp 5.000/gelas") {
+		t.Errorf("Caption should still produce a draft, got %+v", resp)
+	}
+
+	resp = o.ProcessImage(ctx, "628111", []byte{0xFF}, "image/jpeg", "")
+	if resp.Success || resp.PendingActionID != "" {
+		t.Errorf("Photo without caption or vision should ask for a caption, got %+v", resp)
+	}
+}
@@ -1,25 +1,26 @@
 package agents
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
-	"time"
+	"strings"
+	"sync"
 
+	"github.com/pasarsuara/backend/internal/ai"
 	"github.com/pasarsuara/backend/internal/database"
 )
 
 // PromoAgent generates promotional content using AI
 type PromoAgent struct {
-	db             *database.SupabaseClient
-	kolosalKey     string
-	kolosalBaseURL string
-	geminiKey      string
-	httpClient     *http.Client
+	db  *database.SupabaseClient
+	llm ai.Provider
+
+	// Last generated promo per product, served when the LLM is unavailable or over quota
+	cache   map[string]*PromoResult
+	cacheMu sync.RWMutex
 }
 
 // PromoResult represents generated promotional content
@@ -43,50 +44,43 @@ type CatalogItem struct {
 	PromoText   string  `json:"promo_text,omitempty"`
 }
 
-func NewPromoAgent(db *database.SupabaseClient, kolosalKey, kolosalBaseURL, geminiKey string) *PromoAgent {
+func NewPromoAgent(db *database.SupabaseClient, llm ai.Provider) *PromoAgent {
 	return &PromoAgent{
-		db:             db,
-		kolosalKey:     kolosalKey,
-		kolosalBaseURL: kolosalBaseURL,
-		geminiKey:      geminiKey,
-		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
-		},
+		db:    db,
+		llm:   llm,
+		cache: make(map[string]*PromoResult),
 	}
 }
 
 // GeneratePromo creates promotional content for a product
 func (p *PromoAgent) GeneratePromo(ctx context.Context, product string, price float64, description string) (*PromoResult, error) {
 	log.Printf("🎨 Promo Agent: Generating promo for %s", product)
+	ctx = ai.WithFeature(ctx, ai.FeaturePromo)
 
-	if p.kolosalKey == "" {
-		// Return demo promo if no API key
+	if !ai.IsAvailable(p.llm) {
+		// Return demo promo if no LLM is configured
 		return p.generateDemoPromo(product, price), nil
 	}
 
-	prompt := fmt.Sprintf(`Buatkan konten promosi untuk produk UMKM Indonesia.
-
-Produk: %s
-Harga: Rp %.0f
-Deskripsi: %s
-
-Buatkan dalam format JSON:
-{
-  "short_caption": "Caption singkat untuk status WhatsApp (max 100 karakter, include emoji)",
-  "long_description": "Deskripsi lengkap untuk marketplace (2-3 paragraf, persuasif)",
-  "hashtags": "5-7 hashtag relevan",
-  "call_to_action": "Ajakan untuk membeli",
-  "price_display": "Format harga yang menarik",
-  "image_prompt": "Prompt untuk generate gambar produk (dalam bahasa Inggris)"
-}
-
-Gunakan bahasa Indonesia yang natural dan menarik untuk UMKM.`, product, price, description)
+	prompt, err := ai.DefaultPrompts().Render(ctx, "promo_generate", map[string]any{
+		"product":     product,
+		"price":       fmt.Sprintf("%.0f", price),
+		"description": description,
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	result, err := p.callKolosal(ctx, prompt)
+	result, err := ai.CompleteJSON(ctx, p.llm, prompt.System, prompt.User)
 	if err != nil {
-		log.Printf("⚠️ Kolosal API failed, using demo: %v", err)
+		if cached := p.cachedPromo(product); cached != nil {
+			log.Printf("⚠️ LLM failed, using cached promo: %v", err)
+			return cached, nil
+		}
+		log.Printf("⚠️ LLM failed, using demo: %v", err)
 		return p.generateDemoPromo(product, price), nil
 	}
+	prompt.RecordOutcome(prompt.Validate(result))
 
 	var promo PromoResult
 	if err := json.Unmarshal([]byte(result), &promo); err != nil {
@@ -95,9 +89,22 @@ Gunakan bahasa Indonesia yang natural dan menarik untuk UMKM.`, product, price,
 	}
 
 	promo.ProductName = product
+	p.cachePromo(product, &promo)
 	return &promo, nil
 }
 
+func (p *PromoAgent) cachedPromo(product string) *PromoResult {
+	p.cacheMu.RLock()
+	defer p.cacheMu.RUnlock()
+	return p.cache[strings.ToLower(product)]
+}
+
+func (p *PromoAgent) cachePromo(product string, promo *PromoResult) {
+	p.cacheMu.Lock()
+	defer p.cacheMu.Unlock()
+	p.cache[strings.ToLower(product)] = promo
+}
+
 // GenerateCatalog creates a catalog from inventory
 func (p *PromoAgent) GenerateCatalog(ctx context.Context, userID string) ([]CatalogItem, error) {
 	log.Printf("📚 Promo Agent: Generating catalog for user %s", userID)
@@ -150,37 +157,34 @@ func (p *PromoAgent) GenerateCatalog(ctx context.Context, userID string) ([]Cata
 // GenerateBundlePromo creates a bundle/combo promotion
 func (p *PromoAgent) GenerateBundlePromo(ctx context.Context, products []string, bundlePrice float64) (*PromoResult, error) {
 	log.Printf("🎁 Promo Agent: Generating bundle promo")
+	ctx = ai.WithFeature(ctx, ai.FeaturePromo)
 
 	productList := ""
 	for _, prod := range products {
 		productList += "- " + prod + "\n"
 	}
 
-	if p.kolosalKey == "" {
-		return &PromoResult{
-			ProductName:     "Paket Hemat",
-			ShortCaption:    fmt.Sprintf("🎁 PAKET HEMAT! Cuma Rp %.0f aja! Buruan sebelum kehabisan! 🔥", bundlePrice),
-			LongDescription: fmt.Sprintf("Paket hemat spesial:\n%s\nHarga normal jauh lebih mahal, sekarang cuma Rp %.0f!\n\nPromo terbatas, jangan sampai kehabisan!", productList, bundlePrice),
-			Hashtags:        "#PaketHemat #Promo #UMKM #MakanEnak #Hemat",
-			CallToAction:    "Chat sekarang untuk pesan!",
-			PriceDisplay:    fmt.Sprintf("Rp %.0f", bundlePrice),
-		}, nil
+	if !ai.IsAvailable(p.llm) {
+		return p.generateDemoBundle(productList, bundlePrice), nil
 	}
 
-	prompt := fmt.Sprintf(`Buatkan promosi paket bundling untuk UMKM:
-
-Produk dalam paket:
-%s
-
-Harga paket: Rp %.0f
-
-Buatkan dalam format JSON dengan field: short_caption, long_description, hashtags, call_to_action, price_display.
-Buat menarik dan persuasif untuk pembeli Indonesia.`, productList, bundlePrice)
+	prompt, err := ai.DefaultPrompts().Render(ctx, "promo_bundle", map[string]any{
+		"products": productList,
+		"price":    fmt.Sprintf("%.0f", bundlePrice),
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	result, err := p.callKolosal(ctx, prompt)
+	result, err := ai.CompleteJSON(ctx, p.llm, prompt.System, prompt.User)
+	if errors.Is(err, ai.ErrQuotaExceeded) {
+		log.Printf("⚠️ LLM quota used up, using demo bundle")
+		return p.generateDemoBundle(productList, bundlePrice), nil
+	}
 	if err != nil {
 		return nil, err
 	}
+	prompt.RecordOutcome(prompt.Validate(result))
 
 	var promo PromoResult
 	if err := json.Unmarshal([]byte(result), &promo); err != nil {
@@ -191,51 +195,15 @@ Buat menarik dan persuasif untuk pembeli Indonesia.`, productList, bundlePrice)
 	return &promo, nil
 }
 
-func (p *PromoAgent) callKolosal(ctx context.Context, prompt string) (string, error) {
-	reqBody := map[string]any{
-		"model": "kolosal-1-full",
-		"messages": []map[string]string{
-			{"role": "system", "content": "Kamu adalah copywriter profesional untuk UMKM Indonesia. Selalu respond dengan JSON valid."},
-			{"role": "user", "content": prompt},
-		},
-	}
-
-	jsonData, _ := json.Marshal(reqBody)
-	url := fmt.Sprintf("%s/chat/completions", p.kolosalBaseURL)
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", p.kolosalKey))
-
-	resp, err := p.httpClient.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-
-	var result struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
-	}
-
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", err
-	}
-
-	if len(result.Choices) == 0 {
-		return "", fmt.Errorf("no response from Kolosal")
+func (p *PromoAgent) generateDemoBundle(productList string, bundlePrice float64) *PromoResult {
+	return &PromoResult{
+		ProductName:     "Paket Hemat",
+		ShortCaption:    fmt.Sprintf("🎁 PAKET HEMAT! Cuma Rp %.0f aja! Buruan sebelum kehabisan! 🔥", bundlePrice),
+		LongDescription: fmt.Sprintf("Paket hemat spesial:\n%s\nHarga normal jauh lebih mahal, sekarang cuma Rp %.0f!\n\nPromo terbatas, jangan sampai kehabisan!", productList, bundlePrice),
+		Hashtags:        "#PaketHemat #Promo #UMKM #MakanEnak #Hemat",
+		CallToAction:    "Chat sekarang untuk pesan!",
+		PriceDisplay:    fmt.Sprintf("Rp %.0f", bundlePrice),
 	}
-
-	return result.Choices[0].Message.Content, nil
 }
 
 func (p *PromoAgent) generateDemoPromo(product string, price float64) *PromoResult {
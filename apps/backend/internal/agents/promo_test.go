@@ -0,0 +1,45 @@
+package agents
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pasarsuara/backend/internal/ai"
+)
+
+// scriptedLLM answers with text until it is told to fail
+type scriptedLLM struct {
+	text string
+	err  error
+}
+
+func (s *scriptedLLM) Name() string { return "scripted" }
+
+func (s *scriptedLLM) Complete(ctx context.Context, req *ai.CompletionRequest) (*ai.CompletionResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &ai.CompletionResponse{Text: s.text, Provider: "scripted"}, nil
+}
+
+func TestGeneratePromo_QuotaUsesCache(t *testing.T) {
+	llm := &scriptedLLM{text: `{"short_caption":"Bakso urat mantap!","long_description":"Enak","hashtags":"#bakso","call_to_action":"Order","price_display":"Rp 15.000"}`}
+	promo := NewPromoAgent(nil, llm)
+	ctx := context.Background()
+
+	first, err := promo.GeneratePromo(ctx, "Bakso Urat", 15000, "")
+	if err != nil || first.ShortCaption != "Bakso urat mantap!" {
+		t.Fatalf("GeneratePromo = %+v, %v", first, err)
+	}
+
+	llm.err = ai.ErrQuotaExceeded
+	cached, err := promo.GeneratePromo(ctx, "bakso urat", 15000, "")
+	if err != nil || cached.ShortCaption != first.ShortCaption {
+		t.Errorf("Over quota = %+v, %v, want the cached promo", cached, err)
+	}
+
+	bundle, err := promo.GenerateBundlePromo(ctx, []string{"Bakso", "Es Teh"}, 20000)
+	if err != nil || bundle.ProductName != "Paket Hemat" {
+		t.Errorf("Bundle over quota = %+v, %v", bundle, err)
+	}
+}
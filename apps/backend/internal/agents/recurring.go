@@ -0,0 +1,532 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pasarsuara/backend/internal/ai"
+	"github.com/pasarsuara/backend/internal/database"
+	"github.com/pasarsuara/backend/internal/schedule"
+)
+
+var (
+	// ErrRecurringNotFound is returned for a rule the user does not have
+	ErrRecurringNotFound = errors.New("recurring rule not found")
+	// ErrInvalidRecurring is returned for a rule with a missing or bad field
+	ErrInvalidRecurring = errors.New("invalid recurring rule")
+)
+
+const (
+	recurringPostedType    = "RECURRING_POSTED" // notification_queue type of auto-posted runs
+	recurringDueType       = "RECURRING_DUE"    // notification_queue type of runs awaiting confirmation
+	recurringPollInterval  = 5 * time.Minute    // Between checks for due rules
+	recurringDefaultHour   = 8                  // Hour of schedules built from a frequency
+	recurringConfirmWindow = 24 * time.Hour     // How long a run waits for the user's "ya" before it is skipped
+)
+
+// RecurringAgent keeps the rules of transactions that repeat, like rent on
+// the 1st or gas every Monday, and posts them when due or asks the user to
+// confirm them first
+type RecurringAgent struct {
+	db           *database.SupabaseClient
+	notification *NotificationAgent
+	finance      *FinanceAgent
+	inventory    *InventoryAgent
+	budget       *BudgetAgent
+}
+
+func NewRecurringAgent(db *database.SupabaseClient, notification *NotificationAgent, finance *FinanceAgent, inventory *InventoryAgent, budget *BudgetAgent) *RecurringAgent {
+	return &RecurringAgent{db: db, notification: notification, finance: finance, inventory: inventory, budget: budget}
+}
+
+// Set creates a rule from a SET_RECURRING intent, or edits the user's rule
+// for the same item. created is false for an edit.
+func (r *RecurringAgent) Set(ctx context.Context, userID string, changes map[string]any, now time.Time) (rule *database.RecurringRule, created bool, err error) {
+	existing, err := r.FindByProduct(ctx, userID, getStringEntity(changes, "product"))
+	if err != nil {
+		return nil, false, err
+	}
+	if existing != nil {
+		rule, err = r.Update(ctx, userID, existing.ID, changes, now)
+		return rule, false, err
+	}
+	rule, err = r.Create(ctx, userID, changes, now)
+	return rule, true, err
+}
+
+// Create adds a rule. changes holds type, product, qty, price, auto_post and
+// either schedule (cron) or frequency (DAILY, WEEKLY, MONTHLY) with hour;
+// without them the rule runs monthly on today's date.
+func (r *RecurringAgent) Create(ctx context.Context, userID string, changes map[string]any, now time.Time) (*database.RecurringRule, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not configured")
+	}
+	now = now.In(userLocation(ctx, r.db, userID))
+	spec, _ := scheduleFor(nil, now)
+	rule := &database.RecurringRule{
+		UserID:   userID,
+		Type:     "EXPENSE",
+		Qty:      1,
+		Schedule: spec,
+		Status:   database.RecurringActive,
+	}
+	if err := applyRecurringChanges(rule, changes, now); err != nil {
+		return nil, err
+	}
+	if rule.ProductName == "" || rule.PricePerUnit <= 0 {
+		return nil, fmt.Errorf("%w: item and amount are required", ErrInvalidRecurring)
+	}
+	next, err := nextRun(rule.Schedule, now)
+	if err != nil {
+		return nil, err
+	}
+	rule.NextRunAt = next
+
+	if err := r.db.CreateRecurringRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	log.Printf("🔁 Recurring rule created: %s Rp %.0f %q for user %s", rule.ProductName, rule.Qty*rule.PricePerUnit, rule.Schedule, userID)
+	return rule, nil
+}
+
+// Update edits a rule with the same changes as Create, plus status to pause
+// or resume it. A new schedule or a resumed rule runs next from now on.
+func (r *RecurringAgent) Update(ctx context.Context, userID, id string, changes map[string]any, now time.Time) (*database.RecurringRule, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not configured")
+	}
+	rule, err := r.db.GetRecurringRule(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, ErrRecurringNotFound
+	}
+	now = now.In(userLocation(ctx, r.db, userID))
+	old := *rule
+	if err := applyRecurringChanges(rule, changes, now); err != nil {
+		return nil, err
+	}
+
+	updates := map[string]any{
+		"type":           rule.Type,
+		"product_name":   rule.ProductName,
+		"qty":            rule.Qty,
+		"price_per_unit": rule.PricePerUnit,
+		"schedule":       rule.Schedule,
+		"auto_post":      rule.AutoPost,
+		"status":         rule.Status,
+		"updated_at":     now.UTC().Format(time.RFC3339),
+	}
+	if rule.Schedule != old.Schedule || rule.Status == database.RecurringActive && old.Status != database.RecurringActive {
+		if rule.NextRunAt, err = nextRun(rule.Schedule, now); err != nil {
+			return nil, err
+		}
+		updates["next_run_at"] = rule.NextRunAt
+	}
+	if rule.Status == database.RecurringPaused && rule.PendingRunAt != "" {
+		rule.PendingRunAt = ""
+		updates["pending_run_at"] = nil
+	}
+
+	if err := r.db.UpdateRecurringRule(ctx, rule.ID, updates); err != nil {
+		return nil, err
+	}
+	log.Printf("🔁 Recurring rule updated: %s %s %q for user %s", rule.ProductName, rule.Status, rule.Schedule, userID)
+	return rule, nil
+}
+
+// Delete removes a rule
+func (r *RecurringAgent) Delete(ctx context.Context, userID, id string) error {
+	if r.db == nil {
+		return fmt.Errorf("database not configured")
+	}
+	rule, err := r.db.GetRecurringRule(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if rule == nil {
+		return ErrRecurringNotFound
+	}
+	log.Printf("🗑️ Recurring rule deleted: %s for user %s", rule.ProductName, userID)
+	return r.db.DeleteRecurringRule(ctx, rule.ID)
+}
+
+// List returns the user's rules, oldest first
+func (r *RecurringAgent) List(ctx context.Context, userID string) ([]database.RecurringRule, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not configured")
+	}
+	return r.db.GetRecurringRules(ctx, userID)
+}
+
+// FindByProduct returns the user's rule for an item, or nil if there is none
+func (r *RecurringAgent) FindByProduct(ctx context.Context, userID, product string) (*database.RecurringRule, error) {
+	product = strings.TrimSpace(product)
+	if product == "" {
+		return nil, nil
+	}
+	rules, err := r.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rules {
+		if strings.EqualFold(rules[i].ProductName, product) {
+			return &rules[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// RunDue posts or asks about every rule due at now and moves it to its next
+// run. Runs missed while the server was down are handled once.
+func (r *RecurringAgent) RunDue(ctx context.Context, now time.Time) (int, error) {
+	if r.db == nil {
+		return 0, fmt.Errorf("database not configured")
+	}
+	rules, err := r.db.GetDueRecurringRules(ctx, now.UTC().Format("2006-01-02T15:04:05Z"))
+	if err != nil {
+		return 0, err
+	}
+
+	handled := 0
+	for i := range rules {
+		if err := r.run(ctx, &rules[i], now); err != nil {
+			log.Printf("❌ Recurring rule %s failed: %v", rules[i].ID, err)
+			continue
+		}
+		handled++
+	}
+	return handled, nil
+}
+
+// RunScheduler checks for due rules every few minutes until ctx is cancelled
+func (r *RecurringAgent) RunScheduler(ctx context.Context) {
+	ticker := time.NewTicker(recurringPollInterval)
+	defer ticker.Stop()
+	for {
+		handled, err := r.RunDue(ctx, time.Now())
+		if err != nil {
+			log.Printf("❌ Recurring transactions failed: %v", err)
+		} else if handled > 0 {
+			log.Printf("🔁 Recurring transactions handled: %d", handled)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// NextAwaiting returns the user's oldest run waiting for confirmation and
+// its due time, or nil. Runs unanswered for recurringConfirmWindow are
+// dropped so a late "ya" cannot post them.
+func (r *RecurringAgent) NextAwaiting(ctx context.Context, userID string, now time.Time) (*database.RecurringRule, time.Time, error) {
+	if r == nil || r.db == nil {
+		return nil, time.Time{}, nil
+	}
+	rules, err := r.db.GetAwaitingRecurringRules(ctx, userID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		due, err := time.Parse(time.RFC3339, rule.PendingRunAt)
+		if err == nil && now.Sub(due) <= recurringConfirmWindow {
+			return rule, due, nil
+		}
+		log.Printf("⌛ Recurring run of rule %s due %s expired unanswered", rule.ID, rule.PendingRunAt)
+		updates := map[string]any{"pending_run_at": nil, "updated_at": now.UTC().Format(time.RFC3339)}
+		if err := r.db.UpdateRecurringRule(ctx, rule.ID, updates); err != nil {
+			log.Printf("⚠️ Failed to update recurring rule: %v", err)
+		}
+	}
+	return nil, time.Time{}, nil
+}
+
+// ConfirmRun posts, or skips when confirmed is false, the run of rule due at
+// due, then asks about the user's next awaiting run if there is one
+func (r *RecurringAgent) ConfirmRun(ctx context.Context, rule *database.RecurringRule, due time.Time, confirmed bool) (string, error) {
+	now := time.Now()
+	updates := map[string]any{
+		"pending_run_at": nil,
+		"updated_at":     now.UTC().Format(time.RFC3339),
+	}
+	msg := fmt.Sprintf("🚫 Transaksi rutin %s dilewati, tidak ada yang dicatat.", rule.ProductName)
+	if confirmed {
+		line, err := r.post(ctx, rule, due)
+		if err != nil {
+			return "", err
+		}
+		msg = "✅ Transaksi rutin dicatat\n\n" + line
+		updates["last_run_at"] = due.UTC().Format(time.RFC3339)
+	}
+	if err := r.db.UpdateRecurringRule(ctx, rule.ID, updates); err != nil {
+		log.Printf("⚠️ Failed to update recurring rule: %v", err)
+	}
+
+	if next, _, err := r.NextAwaiting(ctx, rule.UserID, now); err == nil && next != nil {
+		msg += "\n\n🔁 Berikutnya\n" + formatRecurringDue(next)
+	}
+	return msg, nil
+}
+
+// run handles one due rule and moves it to its next run
+func (r *RecurringAgent) run(ctx context.Context, rule *database.RecurringRule, now time.Time) error {
+	due, err := time.Parse(time.RFC3339, rule.NextRunAt)
+	if err != nil {
+		return err
+	}
+	loc := userLocation(ctx, r.db, rule.UserID)
+	updates := map[string]any{"updated_at": now.UTC().Format(time.RFC3339)}
+	if next, err := nextRun(rule.Schedule, now.In(loc)); err == nil {
+		updates["next_run_at"] = next
+	} else {
+		// The schedule was edited by hand into one that never runs
+		log.Printf("⚠️ Pausing recurring rule %s: %v", rule.ID, err)
+		updates["status"] = database.RecurringPaused
+	}
+
+	notifType, title, msg := recurringDueType, "🔁 Transaksi Rutin Jatuh Tempo", formatRecurringDue(rule)
+	if rule.AutoPost {
+		line, err := r.post(ctx, rule, due)
+		if err != nil {
+			return err // Retried on the next check, the idempotency key stops doubles
+		}
+		notifType, title, msg = recurringPostedType, "🔁 Transaksi Rutin Tercatat", line
+		updates["last_run_at"] = due.UTC().Format(time.RFC3339)
+	} else {
+		// An unanswered earlier run is replaced by this one
+		updates["pending_run_at"] = due.UTC().Format(time.RFC3339)
+	}
+
+	if err := r.db.UpdateRecurringRule(ctx, rule.ID, updates); err != nil {
+		return err
+	}
+	r.notification.QueueNotification(ctx, rule.UserID, notifType, title, msg, "whatsapp")
+	return nil
+}
+
+// post records the transaction of a rule's run at due, with its stock and
+// budget effects, and returns a line describing it
+func (r *RecurringAgent) post(ctx context.Context, rule *database.RecurringRule, due time.Time) (string, error) {
+	intent := recurringIntent(rule, due)
+	ctx = WithIdempotencyKey(ctx, fmt.Sprintf("recurring:%s:%s", rule.ID, due.UTC().Format(time.RFC3339)))
+
+	var tx *database.Transaction
+	var err error
+	alert := ""
+	switch rule.Type {
+	case "SALE":
+		if tx, err = r.finance.RecordSale(ctx, rule.UserID, intent, nil); err == nil && r.inventory != nil {
+			stock, err := r.inventory.UpdateStockAfterSale(ctx, rule.UserID, intent)
+			if err != nil {
+				log.Printf("⚠️ Failed to update inventory: %v", err)
+			} else if stock != nil {
+				alert = r.inventory.FormatStockAlert(stock)
+			}
+		}
+	case "PURCHASE":
+		if tx, err = r.finance.RecordPurchase(ctx, rule.UserID, intent, rule.PricePerUnit); err == nil && r.inventory != nil {
+			if err := r.inventory.UpdateStockAfterPurchase(ctx, rule.UserID, intent, rule.Qty); err != nil {
+				log.Printf("⚠️ Failed to update inventory: %v", err)
+			}
+		}
+	default:
+		if tx, err = r.finance.RecordExpense(ctx, rule.UserID, intent, nil); err == nil {
+			alert = r.budget.Track(ctx, tx, due.In(userLocation(ctx, r.db, rule.UserID)))
+		}
+	}
+	if err != nil && !errors.Is(err, ErrDuplicateTransaction) {
+		return "", err
+	}
+
+	line := formatRecurringPosted(rule, tx)
+	if alert != "" {
+		line += "\n" + alert
+	}
+	return line, nil
+}
+
+// recurringIntent is the intent a rule's run at due is recorded from
+func recurringIntent(rule *database.RecurringRule, due time.Time) *ai.Intent {
+	action := "RECORD_EXPENSE"
+	switch rule.Type {
+	case "SALE":
+		action = "RECORD_SALE"
+	case "PURCHASE":
+		action = "ORDER_RESTOCK"
+	}
+	return &ai.Intent{
+		Action: action,
+		Entities: map[string]any{
+			"product":     rule.ProductName,
+			"qty":         rule.Qty,
+			"price":       rule.PricePerUnit,
+			"occurred_at": due.Format(time.RFC3339),
+		},
+		Language: "id",
+		RawText:  "Transaksi rutin: " + describeSchedule(rule.Schedule),
+	}
+}
+
+// applyRecurringChanges applies the fields of a SET_RECURRING intent or an
+// API request to a rule
+func applyRecurringChanges(rule *database.RecurringRule, changes map[string]any, now time.Time) error {
+	switch txType := strings.ToUpper(getStringEntity(changes, "type")); txType {
+	case "":
+	case "SALE", "PURCHASE", "EXPENSE":
+		rule.Type = txType
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidRecurring, txType)
+	}
+	if product := strings.TrimSpace(getStringEntity(changes, "product")); product != "" {
+		rule.ProductName = product
+	}
+	if qty := getFloatEntity(changes, "qty"); qty > 0 {
+		rule.Qty = qty
+	}
+	if price := getFloatEntity(changes, "price"); price > 0 {
+		rule.PricePerUnit = price
+	}
+	if changes["schedule"] != nil || changes["frequency"] != nil {
+		spec, err := scheduleFor(changes, now)
+		if err != nil {
+			return err
+		}
+		rule.Schedule = spec
+	}
+	if auto, ok := changes["auto_post"].(bool); ok {
+		rule.AutoPost = auto
+	}
+	switch status := strings.ToUpper(getStringEntity(changes, "status")); status {
+	case "":
+	case database.RecurringActive, database.RecurringPaused:
+		rule.Status = status
+	default:
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidRecurring, status)
+	}
+	return nil
+}
+
+// scheduleFor returns the cron of a rule: the schedule entity when given,
+// else one built from frequency and hour on now's weekday or date. Monthly
+// is the default. Monthly days after the 28th, given or built, become the
+// last day of the month so short months are not skipped.
+func scheduleFor(entities map[string]any, now time.Time) (string, error) {
+	if spec := getStringEntity(entities, "schedule"); spec != "" {
+		s, err := schedule.Parse(spec)
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidRecurring, err)
+		}
+		// A monthly day after the 28th would skip short months
+		f := strings.Fields(s.String())
+		if day, err := strconv.Atoi(f[2]); err == nil && day > 28 && f[3] == "*" {
+			f[2] = "L"
+		}
+		return strings.Join(f, " "), nil
+	}
+
+	hour := recurringDefaultHour
+	if h, ok := entities["hour"]; ok && h != nil {
+		hour = int(getFloatEntity(entities, "hour"))
+		if hour < 0 || hour > 23 {
+			return "", fmt.Errorf("%w: hour %d", ErrInvalidRecurring, hour)
+		}
+	}
+	switch strings.ToUpper(getStringEntity(entities, "frequency")) {
+	case "DAILY":
+		return fmt.Sprintf("0 %d * * *", hour), nil
+	case "WEEKLY":
+		return fmt.Sprintf("0 %d * * %d", hour, now.Weekday()), nil
+	}
+	if now.Day() > 28 {
+		return fmt.Sprintf("0 %d L * *", hour), nil
+	}
+	return fmt.Sprintf("0 %d %d * *", hour, now.Day()), nil
+}
+
+// nextRun is the first run of spec after now, in UTC RFC3339
+func nextRun(spec string, now time.Time) (string, error) {
+	s, err := schedule.Parse(spec)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidRecurring, err)
+	}
+	next := s.Next(now)
+	if next.IsZero() {
+		return "", fmt.Errorf("%w: schedule %q never runs", ErrInvalidRecurring, spec)
+	}
+	return next.UTC().Format(time.RFC3339), nil
+}
+
+// describeSchedule says when a rule runs, e.g. "tiap tanggal 1 jam 08:00"
+func describeSchedule(spec string) string {
+	s, err := schedule.Parse(spec)
+	if err != nil {
+		return "jadwal " + spec
+	}
+	return s.Describe()
+}
+
+// recurringTypeLabel names a rule's transaction type
+func recurringTypeLabel(txType string) string {
+	switch txType {
+	case "SALE":
+		return "penjualan"
+	case "PURCHASE":
+		return "pembelian"
+	default:
+		return "pengeluaran"
+	}
+}
+
+// formatRecurringAmount is e.g. "Rp 1.000.000" or "2 x Rp 25.000 = Rp 50.000"
+func formatRecurringAmount(rule *database.RecurringRule) string {
+	total := "Rp " + formatCurrency(rule.Qty*rule.PricePerUnit)
+	if rule.Qty == 1 {
+		return total
+	}
+	return fmt.Sprintf("%s x Rp %s = %s", formatQty(rule.Qty), formatCurrency(rule.PricePerUnit), total)
+}
+
+func formatRecurringRule(rule *database.RecurringRule, loc *time.Location) string {
+	mode := "minta konfirmasi dulu"
+	if rule.AutoPost {
+		mode = "dicatat otomatis"
+	}
+	msg := fmt.Sprintf("🔁 %s: %s (%s)\n   %s, %s",
+		rule.ProductName, formatRecurringAmount(rule), recurringTypeLabel(rule.Type), describeSchedule(rule.Schedule), mode)
+	switch {
+	case rule.Status == database.RecurringPaused:
+		msg += "\n   ⏸️ Dijeda"
+	case rule.PendingRunAt != "":
+		msg += "\n   ⏳ Menunggu konfirmasi"
+	default:
+		if next, err := time.Parse(time.RFC3339, rule.NextRunAt); err == nil {
+			msg += "\n   Berikutnya: " + ai.FormatDate(next.In(loc))
+		}
+	}
+	return msg
+}
+
+func formatRecurringDue(rule *database.RecurringRule) string {
+	return fmt.Sprintf("Hari ini jadwal %s %s: %s.\n\n"+
+		"Catat sekarang? Balas \"ya\" untuk mencatat atau \"tidak\" untuk melewati.",
+		recurringTypeLabel(rule.Type), rule.ProductName, formatRecurringAmount(rule))
+}
+
+func formatRecurringPosted(rule *database.RecurringRule, tx *database.Transaction) string {
+	total := rule.Qty * rule.PricePerUnit
+	if tx != nil {
+		total = tx.TotalAmount
+	}
+	return fmt.Sprintf("🔁 %s: Rp %s tercatat sebagai %s (%s)",
+		rule.ProductName, formatCurrency(total), recurringTypeLabel(rule.Type), describeSchedule(rule.Schedule))
+}
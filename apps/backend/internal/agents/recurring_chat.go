@@ -0,0 +1,134 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pasarsuara/backend/internal/ai"
+	appcontext "github.com/pasarsuara/backend/internal/context"
+	"github.com/pasarsuara/backend/internal/database"
+)
+
+// handleSetRecurring saves a recurring transaction, e.g. "tiap tanggal 1
+// bayar sewa 1 juta", or edits the one for the same item
+func (o *AgentOrchestrator) handleSetRecurring(ctx context.Context, userID string, intent *ai.Intent) string {
+	rule, created, err := o.recurring.Set(ctx, userID, intent.Entities, time.Now())
+	if err != nil {
+		return "Gagal menyimpan transaksi rutin: " + err.Error()
+	}
+	title := "✅ Transaksi rutin tersimpan"
+	if !created {
+		title = "✏️ Transaksi rutin diubah"
+	}
+	msg := title + "\n\n" + formatRecurringRule(rule, o.userLocation(ctx, userID))
+	if !rule.AutoPost {
+		msg += "\n\nSaat jatuh tempo kami tanya dulu sebelum mencatat. " +
+			"Mau langsung dicatat tanpa ditanya? Kirim ulang dengan kata \"otomatis\"."
+	}
+	return msg
+}
+
+// handleAskRecurring lists the user's recurring transactions
+func (o *AgentOrchestrator) handleAskRecurring(ctx context.Context, userID string) string {
+	rules, err := o.recurring.List(ctx, userID)
+	if err != nil {
+		return "Maaf, daftar transaksi rutin belum bisa diambil. Coba lagi nanti ya!"
+	}
+	if len(rules) == 0 {
+		return "Belum ada transaksi rutin. Contoh: \"tiap tanggal 1 bayar sewa 1 juta\" atau \"tiap hari Senin beli gas 2 tabung 25rb\"."
+	}
+
+	loc := o.userLocation(ctx, userID)
+	lines := make([]string, len(rules))
+	for i := range rules {
+		lines[i] = formatRecurringRule(&rules[i], loc)
+	}
+	return "📋 Transaksi Rutin\n\n" + strings.Join(lines, "\n\n") +
+		"\n\nKetik \"jeda jadwal sewa\", \"lanjutkan jadwal sewa\" atau \"hapus jadwal sewa\" untuk mengubahnya."
+}
+
+// handleUpdateRecurring pauses, resumes or deletes a recurring transaction.
+// The item may be left out when the user has only one.
+func (o *AgentOrchestrator) handleUpdateRecurring(ctx context.Context, userID string, intent *ai.Intent) string {
+	product := getStringEntity(intent.Entities, "product")
+	rule, err := o.recurring.FindByProduct(ctx, userID, product)
+	if err == nil && rule == nil && product == "" {
+		var rules []database.RecurringRule
+		if rules, err = o.recurring.List(ctx, userID); len(rules) == 1 {
+			rule = &rules[0]
+		}
+	}
+	if err != nil {
+		return "Maaf, transaksi rutin belum bisa diubah. Coba lagi nanti ya!"
+	}
+	if rule == nil {
+		if product == "" {
+			return "Transaksi rutin yang mana? Sebutkan itemnya, misalnya \"jeda jadwal sewa\"."
+		}
+		return fmt.Sprintf("Transaksi rutin \"%s\" tidak ditemukan. Ketik \"jadwal rutin\" untuk melihat daftarnya.", product)
+	}
+
+	switch getStringEntity(intent.Entities, "op") {
+	case "DELETE":
+		err = o.recurring.Delete(ctx, userID, rule.ID)
+		if err == nil {
+			return fmt.Sprintf("🗑️ Transaksi rutin %s dihapus.", rule.ProductName)
+		}
+	case "RESUME":
+		rule, err = o.recurring.Update(ctx, userID, rule.ID, map[string]any{"status": database.RecurringActive}, time.Now())
+		if err == nil {
+			return "▶️ Transaksi rutin dilanjutkan\n\n" + formatRecurringRule(rule, o.userLocation(ctx, userID))
+		}
+	default:
+		rule, err = o.recurring.Update(ctx, userID, rule.ID, map[string]any{"status": database.RecurringPaused}, time.Now())
+		if err == nil {
+			return fmt.Sprintf("⏸️ Transaksi rutin %s dijeda. Ketik \"lanjutkan jadwal %s\" untuk menjalankannya lagi.",
+				rule.ProductName, strings.ToLower(rule.ProductName))
+		}
+	}
+	if errors.Is(err, ErrRecurringNotFound) {
+		return "Transaksi rutin itu sudah tidak ada."
+	}
+	return "Gagal mengubah transaksi rutin: " + err.Error()
+}
+
+// confirmRecurring answers a "ya"/"tidak" to the oldest recurring run
+// waiting for confirmation. The reply only counts when it quotes nothing and
+// the RECURRING_DUE message, or a reply about recurring runs, was the last
+// thing we sent; notification sends report no message id to match quotes
+// against. handled is false otherwise.
+func (o *AgentOrchestrator) confirmRecurring(ctx context.Context, userPhone, quotedMessageID string, confirmed bool) (*AgentResponse, bool) {
+	if quotedMessageID != "" {
+		return nil, false
+	}
+	rule, due, err := o.recurring.NextAwaiting(ctx, o.getUserID(ctx, userPhone), time.Now())
+	if err != nil || rule == nil {
+		return nil, false
+	}
+	if o.contextMgr != nil && !recurringDueWasLast(o.contextMgr.GetRecentMessages(userPhone, 20), due) {
+		return nil, false
+	}
+
+	msg, err := o.recurring.ConfirmRun(ctx, rule, due, confirmed)
+	if err != nil {
+		return &AgentResponse{Success: false, Message: "Gagal mencatat transaksi rutin: " + err.Error()}, true
+	}
+	if o.contextMgr != nil {
+		o.contextMgr.AddMessage(userPhone, "assistant", msg, recurringDueType, nil)
+	}
+	return &AgentResponse{Success: true, Message: msg}, true
+}
+
+// recurringDueWasLast reports whether no reply was sent after the reminder of
+// a run due at due, other than one about recurring runs
+func recurringDueWasLast(messages []appcontext.ConversationMessage, due time.Time) bool {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "assistant" {
+			return messages[i].Intent == recurringDueType || messages[i].Timestamp.Before(due)
+		}
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package agents
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	appcontext "github.com/pasarsuara/backend/internal/context"
+	"github.com/pasarsuara/backend/internal/database"
+)
+
+func TestScheduleFor(t *testing.T) {
+	loc := time.FixedZone("WIB", 7*3600)
+	monday := time.Date(2026, 10, 19, 10, 0, 0, 0, loc)
+	tests := []struct {
+		name     string
+		entities map[string]any
+		now      time.Time
+		want     string
+	}{
+		{"cron", map[string]any{"schedule": "0 8 1 * *"}, monday, "0 8 1 * *"},
+		{"cron on the 31st", map[string]any{"schedule": "0 8 31 * *"}, monday, "0 8 L * *"},
+		{"cron on 31 December", map[string]any{"schedule": "0 8 31 12 *"}, monday, "0 8 31 12 *"},
+		{"default monthly on today", nil, monday, "0 8 19 * *"},
+		{"monthly late in the month", map[string]any{"frequency": "MONTHLY"}, time.Date(2026, 10, 30, 10, 0, 0, 0, loc), "0 8 L * *"},
+		{"weekly on today", map[string]any{"frequency": "WEEKLY", "hour": 7.0}, monday, "0 7 * * 1"},
+		{"daily", map[string]any{"frequency": "daily", "hour": 18.0}, monday, "0 18 * * *"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := scheduleFor(tt.entities, tt.now)
+			if err != nil || got != tt.want {
+				t.Errorf("scheduleFor() = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	for _, bad := range []map[string]any{{"schedule": "tiap hari"}, {"frequency": "DAILY", "hour": 25.0}} {
+		if _, err := scheduleFor(bad, monday); !errors.Is(err, ErrInvalidRecurring) {
+			t.Errorf("scheduleFor(%v) = %v, want ErrInvalidRecurring", bad, err)
+		}
+	}
+}
+
+func TestApplyRecurringChanges(t *testing.T) {
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	rule := database.RecurringRule{Type: "EXPENSE", Qty: 1, Schedule: "0 8 19 * *", Status: database.RecurringActive}
+	changes := map[string]any{
+		"type": "sale", "product": " Nasi Kotak ", "qty": 20.0, "price": 15000.0,
+		"schedule": "0 6 * * 5", "auto_post": true, "status": "paused",
+	}
+	if err := applyRecurringChanges(&rule, changes, now); err != nil {
+		t.Fatalf("applyRecurringChanges() = %v", err)
+	}
+	want := database.RecurringRule{Type: "SALE", ProductName: "Nasi Kotak", Qty: 20, PricePerUnit: 15000,
+		Schedule: "0 6 * * 5", AutoPost: true, Status: database.RecurringPaused}
+	if rule != want {
+		t.Errorf("rule = %+v, want %+v", rule, want)
+	}
+
+	for _, bad := range []map[string]any{{"type": "REFUND"}, {"status": "DONE"}, {"schedule": "0 8 * *"}} {
+		if err := applyRecurringChanges(&rule, bad, now); !errors.Is(err, ErrInvalidRecurring) {
+			t.Errorf("applyRecurringChanges(%v) = %v, want ErrInvalidRecurring", bad, err)
+		}
+	}
+}
+
+func TestNextRun(t *testing.T) {
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, time.FixedZone("WIB", 7*3600))
+	if got, err := nextRun("0 8 1 * *", now); err != nil || got != "2026-11-01T01:00:00Z" {
+		t.Errorf("nextRun() = %q, %v; want 2026-11-01T01:00:00Z", got, err)
+	}
+	if _, err := nextRun("0 8 31 2 *", now); !errors.Is(err, ErrInvalidRecurring) {
+		t.Errorf("nextRun() of 31 February = %v, want ErrInvalidRecurring", err)
+	}
+}
+
+func TestRecurringIntent(t *testing.T) {
+	due := time.Date(2026, 11, 1, 8, 0, 0, 0, time.FixedZone("WIB", 7*3600))
+	tests := map[string]string{"SALE": "RECORD_SALE", "PURCHASE": "ORDER_RESTOCK", "EXPENSE": "RECORD_EXPENSE"}
+	for txType, action := range tests {
+		rule := &database.RecurringRule{Type: txType, ProductName: "sewa", Qty: 1, PricePerUnit: 1000000, Schedule: "0 8 1 * *"}
+		intent := recurringIntent(rule, due)
+		if intent.Action != action {
+			t.Errorf("%s Action = %s, want %s", txType, intent.Action, action)
+		}
+		if intent.Entities["occurred_at"] != "2026-11-01T08:00:00+07:00" || intent.Entities["price"] != 1000000.0 {
+			t.Errorf("%s Entities = %v", txType, intent.Entities)
+		}
+	}
+}
+
+func TestRecurringDueWasLast(t *testing.T) {
+	due := time.Date(2026, 11, 1, 8, 0, 0, 0, time.UTC)
+	msg := func(role, intent string, at time.Time) appcontext.ConversationMessage {
+		return appcontext.ConversationMessage{Role: role, Intent: intent, Timestamp: at}
+	}
+	tests := []struct {
+		name     string
+		messages []appcontext.ConversationMessage
+		want     bool
+	}{
+		{"no conversation", nil, true},
+		{"chat before the reminder", []appcontext.ConversationMessage{
+			msg("user", "RECORD_SALE", due.Add(-time.Hour)), msg("assistant", "RECORD_SALE", due.Add(-time.Hour)),
+		}, true},
+		{"reply after the reminder", []appcontext.ConversationMessage{
+			msg("user", "CHECK_STOCK", due.Add(time.Hour)), msg("assistant", "CHECK_STOCK", due.Add(time.Hour)),
+		}, false},
+		{"previous recurring answer", []appcontext.ConversationMessage{
+			msg("assistant", "CHECK_STOCK", due.Add(time.Hour)), msg("assistant", recurringDueType, due.Add(2*time.Hour)),
+		}, true},
+	}
+	for _, tt := range tests {
+		if got := recurringDueWasLast(tt.messages, due); got != tt.want {
+			t.Errorf("%s: recurringDueWasLast() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package agents
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ReplyType tells the WA Gateway how to render a reply
+type ReplyType string
+
+const (
+	ReplyTypeText            ReplyType = "text"
+	ReplyTypeImage           ReplyType = "image"
+	ReplyTypeDocument        ReplyType = "document"
+	ReplyTypeButtons         ReplyType = "buttons"
+	ReplyTypeList            ReplyType = "list"
+	ReplyTypeLocationRequest ReplyType = "location_request"
+	ReplyTypeAudio           ReplyType = "audio"
+)
+
+// Reply is a single structured message sent back to the user
+type Reply struct {
+	Type      ReplyType     `json:"type"`
+	Text      string        `json:"text,omitempty"`       // body text or media caption
+	MediaData []byte        `json:"media_data,omitempty"` // image/document/audio bytes
+	MediaURL  string        `json:"media_url,omitempty"`  // alternative to MediaData
+	Filename  string        `json:"filename,omitempty"`
+	MimeType  string        `json:"mime_type,omitempty"`
+	Buttons   []ReplyButton `json:"buttons,omitempty"`
+	List      *ReplyList    `json:"list,omitempty"`
+	Seconds   int           `json:"seconds,omitempty"` // audio duration
+}
+
+// ReplyButton is a quick reply button; ID is sent back when selected
+type ReplyButton struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// ReplyList is a single-select list menu
+type ReplyList struct {
+	Title      string         `json:"title"`
+	ButtonText string         `json:"button_text"`
+	Sections   []ReplySection `json:"sections"`
+}
+
+// ReplySection groups rows in a list menu
+type ReplySection struct {
+	Title string     `json:"title"`
+	Rows  []ReplyRow `json:"rows"`
+}
+
+// ReplyRow is a selectable row; ID is sent back when selected
+type ReplyRow struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+// MaxReplyButtons is the WhatsApp limit for quick reply buttons
+const MaxReplyButtons = 3
+
+// TextReply creates a plain text reply
+func TextReply(text string) Reply {
+	return Reply{Type: ReplyTypeText, Text: text}
+}
+
+// ButtonsReply creates a reply with quick reply buttons
+func ButtonsReply(text string, buttons []ReplyButton) Reply {
+	if len(buttons) > MaxReplyButtons {
+		buttons = buttons[:MaxReplyButtons]
+	}
+	return Reply{Type: ReplyTypeButtons, Text: text, Buttons: buttons}
+}
+
+// ListReply creates a reply with a list menu
+func ListReply(text string, list *ReplyList) Reply {
+	return Reply{Type: ReplyTypeList, Text: text, List: list}
+}
+
+// ImageReply creates an image reply with optional caption
+func ImageReply(data []byte, caption string) Reply {
+	return Reply{Type: ReplyTypeImage, Text: caption, MediaData: data, MimeType: "image/jpeg"}
+}
+
+// DocumentReply creates a document reply
+func DocumentReply(data []byte, filename, mimeType string) Reply {
+	return Reply{Type: ReplyTypeDocument, MediaData: data, Filename: filename, MimeType: mimeType}
+}
+
+// AudioReply creates a voice note (PTT) reply
+func AudioReply(data []byte, mimeType string, seconds int) Reply {
+	return Reply{Type: ReplyTypeAudio, MediaData: data, MimeType: mimeType, Seconds: seconds}
+}
+
+// LocationRequestReply asks the user to share their location
+func LocationRequestReply(text string) Reply {
+	return Reply{Type: ReplyTypeLocationRequest, Text: text}
+}
+
+// Selection is a parsed button/list id sent back by the gateway
+type Selection struct {
+	Kind  string // e.g. "amb" for ambiguity answers
+	Field string // entity name to fill
+	Value string // selected value
+}
+
+// SelectionID builds a button/list id in the form kind:field:value
+func SelectionID(kind, field, value string) string {
+	return fmt.Sprintf("%s:%s:%s", kind, field, value)
+}
+
+// ParseSelectionID parses an id built by SelectionID
+func ParseSelectionID(id string) (*Selection, bool) {
+	parts := strings.SplitN(id, ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+		return nil, false
+	}
+	return &Selection{Kind: parts[0], Field: parts[1], Value: parts[2]}, true
+}
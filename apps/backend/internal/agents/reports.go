@@ -3,8 +3,10 @@ package agents
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
+	"github.com/pasarsuara/backend/internal/ai"
 	"github.com/pasarsuara/backend/internal/database"
 )
 
@@ -19,7 +21,9 @@ type DailyReport struct {
 	TotalSales       float64
 	TotalPurchases   float64
 	TotalExpenses    float64
-	GrossProfit      float64
+	COGS             float64 // Cost of the goods sold, from FIFO cost layers
+	UncostedSales    float64 // Sales with no known purchase cost
+	GrossProfit      float64 // Sales minus COGS
 	NetProfit        float64
 	TransactionCount int
 	TopProducts      []ProductSummary
@@ -30,6 +34,26 @@ type ProductSummary struct {
 	ProductName string
 	Quantity    float64
 	Revenue     float64
+	COGS        float64
+	Uncosted    float64 // Revenue with no known purchase cost
+}
+
+// GrossMargin returns gross profit as a percentage of the sales whose cost
+// is known; ok is false when none is
+func (r *DailyReport) GrossMargin() (margin float64, ok bool) {
+	return grossMargin(r.TotalSales-r.UncostedSales, r.COGS)
+}
+
+// GrossMargin returns the product's gross profit as a percentage of costed revenue
+func (p *ProductSummary) GrossMargin() (margin float64, ok bool) {
+	return grossMargin(p.Revenue-p.Uncosted, p.COGS)
+}
+
+func grossMargin(costedSales, cogs float64) (float64, bool) {
+	if costedSales <= 0 {
+		return 0, false
+	}
+	return (costedSales - cogs) / costedSales * 100, true
 }
 
 func NewReportAgent(db *database.SupabaseClient) *ReportAgent {
@@ -38,14 +62,14 @@ func NewReportAgent(db *database.SupabaseClient) *ReportAgent {
 
 // GenerateDailyReport generates report for today
 func (r *ReportAgent) GenerateDailyReport(ctx context.Context, userID string) (*DailyReport, error) {
-	today := time.Now().Format("2006-01-02")
+	today := time.Now().In(userLocation(ctx, r.db, userID)).Format("2006-01-02")
 	return r.GenerateReportForDate(ctx, userID, today)
 }
 
 // GenerateWeeklyReport generates report for this week
 func (r *ReportAgent) GenerateWeeklyReport(ctx context.Context, userID string) (*DailyReport, error) {
 	// Get start of week (Monday)
-	now := time.Now()
+	now := time.Now().In(userLocation(ctx, r.db, userID))
 	weekday := int(now.Weekday())
 	if weekday == 0 {
 		weekday = 7 // Sunday = 7
@@ -57,7 +81,7 @@ func (r *ReportAgent) GenerateWeeklyReport(ctx context.Context, userID string) (
 
 // GenerateMonthlyReport generates report for this month
 func (r *ReportAgent) GenerateMonthlyReport(ctx context.Context, userID string) (*DailyReport, error) {
-	now := time.Now()
+	now := time.Now().In(userLocation(ctx, r.db, userID))
 	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
 
 	return r.GenerateReportForDateRange(ctx, userID, startOfMonth.Format("2006-01-02"), now.Format("2006-01-02"))
@@ -77,29 +101,107 @@ func (r *ReportAgent) GenerateReportForDateRange(ctx context.Context, userID, st
 			TotalSales:       450000,
 			TotalPurchases:   300000,
 			TotalExpenses:    50000,
+			COGS:             300000,
 			GrossProfit:      150000,
 			NetProfit:        100000,
 			TransactionCount: 15,
 			TopProducts: []ProductSummary{
-				{ProductName: "Nasi Goreng", Quantity: 15, Revenue: 225000},
-				{ProductName: "Ayam Geprek", Quantity: 8, Revenue: 160000},
-				{ProductName: "Es Teh", Quantity: 20, Revenue: 60000},
+				{ProductName: "Nasi Goreng", Quantity: 15, Revenue: 225000, COGS: 135000},
+				{ProductName: "Ayam Geprek", Quantity: 8, Revenue: 160000, COGS: 120000},
+				{ProductName: "Es Teh", Quantity: 20, Revenue: 60000, COGS: 45000},
 			},
 		}, nil
 	}
 
-	// TODO: Query real data from database
-	// For now, return demo data
-	return &DailyReport{
-		Date:             startDate,
-		TotalSales:       0,
-		TotalPurchases:   0,
-		TotalExpenses:    0,
-		GrossProfit:      0,
-		NetProfit:        0,
-		TransactionCount: 0,
-		TopProducts:      []ProductSummary{},
-	}, nil
+	// Dates are calendar days in the user's timezone
+	loc := userLocation(ctx, r.db, userID)
+	start, err := time.ParseInLocation("2006-01-02", startDate, loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start date: %w", err)
+	}
+	end, err := time.ParseInLocation("2006-01-02", endDate, loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end date: %w", err)
+	}
+	const layout = "2006-01-02T15:04:05Z"
+	transactions, err := r.db.GetTransactionsByDateRange(ctx, userID,
+		start.UTC().Format(layout), end.AddDate(0, 0, 1).Add(-time.Second).UTC().Format(layout))
+	if err != nil {
+		return nil, err
+	}
+	return summarizeTransactions(startDate, transactions), nil
+}
+
+// summarizeTransactions totals transactions into a report. Gross profit is
+// sales minus the COGS recorded on each sale, so stock bought today and sold
+// over the week is expensed as it sells rather than on the day it is bought.
+func summarizeTransactions(date string, transactions []database.Transaction) *DailyReport {
+	report := &DailyReport{Date: date, TransactionCount: len(transactions), TopProducts: []ProductSummary{}}
+	products := map[string]*ProductSummary{}
+	var order []string
+	for _, tx := range transactions {
+		switch tx.Type {
+		case "SALE":
+			report.TotalSales += tx.TotalAmount
+			report.COGS += tx.COGS
+
+			key := costKey(tx.ProductName)
+			product := products[key]
+			if product == nil {
+				product = &ProductSummary{ProductName: tx.ProductName}
+				products[key] = product
+				order = append(order, key)
+			}
+			product.Quantity += tx.Qty
+			product.Revenue += tx.TotalAmount
+			product.COGS += tx.COGS
+			if tx.COGS == 0 {
+				report.UncostedSales += tx.TotalAmount
+				product.Uncosted += tx.TotalAmount
+			}
+		case "PURCHASE":
+			report.TotalPurchases += tx.TotalAmount
+		case "EXPENSE":
+			report.TotalExpenses += tx.TotalAmount
+		}
+	}
+	report.GrossProfit = report.TotalSales - report.COGS
+	report.NetProfit = report.GrossProfit - report.TotalExpenses
+
+	for _, key := range order {
+		report.TopProducts = append(report.TopProducts, *products[key])
+	}
+	sort.SliceStable(report.TopProducts, func(i, j int) bool {
+		return report.TopProducts[i].Revenue > report.TopProducts[j].Revenue
+	})
+	return report
+}
+
+// formatSummary formats the money lines shared by every report
+func formatSummary(report *DailyReport) string {
+	msg := "💰 *Ringkasan Keuangan*\n"
+	msg += fmt.Sprintf("├ Penjualan: Rp %s\n", formatCurrency(report.TotalSales))
+	msg += fmt.Sprintf("├ Modal Terjual (HPP): Rp %s\n", formatCurrency(report.COGS))
+	msg += fmt.Sprintf("├ Laba Kotor: Rp %s%s\n", formatCurrency(report.GrossProfit), formatMargin(report.GrossMargin()))
+	msg += fmt.Sprintf("├ Pengeluaran: Rp %s\n", formatCurrency(report.TotalExpenses))
+	msg += "├─────────────────\n"
+	msg += fmt.Sprintf("└ Laba Bersih: Rp %s\n", formatCurrency(report.NetProfit))
+	if report.TotalPurchases > 0 {
+		msg += fmt.Sprintf("🛒 Belanja stok: Rp %s\n", formatCurrency(report.TotalPurchases))
+	}
+	if report.UncostedSales > 0 {
+		msg += fmt.Sprintf("⚠️ Rp %s penjualan belum ada harga modalnya, catat pembelian stoknya supaya labanya pas.\n",
+			formatCurrency(report.UncostedSales))
+	}
+	return msg + "\n"
+}
+
+// formatMargin formats a gross margin as " (margin 40%)", or nothing if unknown
+func formatMargin(margin float64, ok bool) string {
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(" (margin %.0f%%)", margin)
 }
 
 // FormatDailyReport formats report for WhatsApp
@@ -112,13 +214,7 @@ func (r *ReportAgent) FormatDailyReport(report *DailyReport) string {
 	msg := fmt.Sprintf("📊 *Laporan %s*\n", dateStr)
 	msg += fmt.Sprintf("📅 %s\n\n", report.Date)
 
-	msg += "💰 *Ringkasan Keuangan*\n"
-	msg += fmt.Sprintf("├ Penjualan: Rp %s\n", formatCurrency(report.TotalSales))
-	msg += fmt.Sprintf("├ Pembelian: Rp %s\n", formatCurrency(report.TotalPurchases))
-	msg += fmt.Sprintf("├ Pengeluaran: Rp %s\n", formatCurrency(report.TotalExpenses))
-	msg += "├─────────────────\n"
-	msg += fmt.Sprintf("├ Laba Kotor: Rp %s\n", formatCurrency(report.GrossProfit))
-	msg += fmt.Sprintf("└ Laba Bersih: Rp %s\n\n", formatCurrency(report.NetProfit))
+	msg += formatSummary(report)
 
 	if report.NetProfit > 0 {
 		msg += "📈 Profit positif! Pertahankan! 💪\n\n"
@@ -134,8 +230,8 @@ func (r *ReportAgent) FormatDailyReport(report *DailyReport) string {
 			if i >= 5 {
 				break // Max 5 products
 			}
-			msg += fmt.Sprintf("%d. %s - %.0f unit (Rp %s)\n",
-				i+1, product.ProductName, product.Quantity, formatCurrency(product.Revenue))
+			msg += fmt.Sprintf("%d. %s - %.0f unit (Rp %s)%s\n",
+				i+1, product.ProductName, product.Quantity, formatCurrency(product.Revenue), formatProductMargin(product))
 		}
 	}
 
@@ -146,12 +242,7 @@ func (r *ReportAgent) FormatDailyReport(report *DailyReport) string {
 func (r *ReportAgent) FormatWeeklyReport(report *DailyReport) string {
 	msg := "📊 *Laporan Minggu Ini*\n\n"
 
-	msg += "💰 *Ringkasan Keuangan*\n"
-	msg += fmt.Sprintf("├ Penjualan: Rp %s\n", formatCurrency(report.TotalSales))
-	msg += fmt.Sprintf("├ Pembelian: Rp %s\n", formatCurrency(report.TotalPurchases))
-	msg += fmt.Sprintf("├ Pengeluaran: Rp %s\n", formatCurrency(report.TotalExpenses))
-	msg += "├─────────────────\n"
-	msg += fmt.Sprintf("└ Laba Bersih: Rp %s\n\n", formatCurrency(report.NetProfit))
+	msg += formatSummary(report)
 
 	msg += fmt.Sprintf("📦 *Total Transaksi:* %d\n\n", report.TransactionCount)
 
@@ -161,7 +252,7 @@ func (r *ReportAgent) FormatWeeklyReport(report *DailyReport) string {
 			if i >= 5 {
 				break
 			}
-			msg += fmt.Sprintf("%d. %s - %.0f unit\n", i+1, product.ProductName, product.Quantity)
+			msg += fmt.Sprintf("%d. %s - %.0f unit%s\n", i+1, product.ProductName, product.Quantity, formatProductMargin(product))
 		}
 	}
 
@@ -176,12 +267,7 @@ func (r *ReportAgent) FormatMonthlyReport(report *DailyReport) string {
 
 	msg := fmt.Sprintf("📊 *Laporan Bulan %s %d*\n\n", monthName[now.Month()], now.Year())
 
-	msg += "💰 *Ringkasan Keuangan*\n"
-	msg += fmt.Sprintf("├ Penjualan: Rp %s\n", formatCurrency(report.TotalSales))
-	msg += fmt.Sprintf("├ Pembelian: Rp %s\n", formatCurrency(report.TotalPurchases))
-	msg += fmt.Sprintf("├ Pengeluaran: Rp %s\n", formatCurrency(report.TotalExpenses))
-	msg += "├─────────────────\n"
-	msg += fmt.Sprintf("└ Laba Bersih: Rp %s\n\n", formatCurrency(report.NetProfit))
+	msg += formatSummary(report)
 
 	msg += fmt.Sprintf("📦 *Total Transaksi:* %d\n", report.TransactionCount)
 
@@ -196,13 +282,55 @@ func (r *ReportAgent) FormatMonthlyReport(report *DailyReport) string {
 			if i >= 5 {
 				break
 			}
-			msg += fmt.Sprintf("%d. %s - %.0f unit\n", i+1, product.ProductName, product.Quantity)
+			msg += fmt.Sprintf("%d. %s - %.0f unit%s\n", i+1, product.ProductName, product.Quantity, formatProductMargin(product))
+		}
+	}
+
+	return msg
+}
+
+// FormatRangeReport formats a report for a custom date range (YYYY-MM-DD)
+func (r *ReportAgent) FormatRangeReport(report *DailyReport, startDate, endDate string) string {
+	label := startDate + " - " + endDate
+	start, errStart := time.Parse("2006-01-02", startDate)
+	end, errEnd := time.Parse("2006-01-02", endDate)
+	if errStart == nil && errEnd == nil {
+		label = ai.FormatDateRange(&ai.DateRange{Start: start, End: end})
+	}
+
+	msg := fmt.Sprintf("📊 *Laporan %s*\n\n", label)
+
+	msg += formatSummary(report)
+
+	msg += fmt.Sprintf("📦 *Total Transaksi:* %d\n", report.TransactionCount)
+
+	if errStart == nil && errEnd == nil {
+		days := int(end.Sub(start).Hours()/24) + 1
+		msg += fmt.Sprintf("📊 *Rata-rata/hari:* Rp %s\n", formatCurrency(report.NetProfit/float64(days)))
+	}
+
+	if len(report.TopProducts) > 0 {
+		msg += "\n🏆 *Produk Terlaris:*\n"
+		for i, product := range report.TopProducts {
+			if i >= 5 {
+				break
+			}
+			msg += fmt.Sprintf("%d. %s - %.0f unit%s\n", i+1, product.ProductName, product.Quantity, formatProductMargin(product))
 		}
 	}
 
 	return msg
 }
 
+// formatProductMargin formats a product's gross margin as ", margin 40%"
+func formatProductMargin(product ProductSummary) string {
+	margin, ok := product.GrossMargin()
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(", margin %.0f%%", margin)
+}
+
 func formatCurrency(amount float64) string {
 	// Format with thousand separator
 	if amount < 0 {
@@ -0,0 +1,322 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pasarsuara/backend/internal/ai"
+	"github.com/pasarsuara/backend/internal/database"
+	"github.com/pasarsuara/backend/internal/tax"
+)
+
+const (
+	taxSummaryType  = "TAX_SUMMARY"  // notification_queue type of monthly tax summaries
+	taxReminderType = "TAX_REMINDER" // notification_queue type of deadline reminders
+	taxReminderHour = 8              // Summaries and reminders are queued at 08:00
+	taxReminderDays = 3              // Days before a deadline the first reminder comes
+)
+
+// TaxAgent computes the monthly tax of a user from their sales, sends the
+// monthly summary and reminds them before the deadlines
+type TaxAgent struct {
+	db           *database.SupabaseClient
+	notification *NotificationAgent
+}
+
+func NewTaxAgent(db *database.SupabaseClient, notification *NotificationAgent) *TaxAgent {
+	return &TaxAgent{db: db, notification: notification}
+}
+
+// taxProfile is the profile to compute with, the default one when the
+// user has not set theirs
+func taxProfile(p *database.TaxProfile) tax.Profile {
+	if p == nil {
+		return tax.DefaultProfile()
+	}
+	return tax.Profile{
+		Regime:         p.Regime,
+		TaxpayerType:   p.TaxpayerType,
+		FinalRate:      p.FinalRate,
+		ExemptTurnover: p.ExemptTurnover,
+		PKP:            p.IsPKP,
+		PPNRate:        p.PPNRate,
+	}
+}
+
+// salePPN is the PPN included in a sale of total by a PKP, 0 for others
+func salePPN(ctx context.Context, db *database.SupabaseClient, userID string, total float64) float64 {
+	if db == nil {
+		return 0
+	}
+	profile, err := db.GetTaxProfile(ctx, userID)
+	if err != nil {
+		log.Printf("⚠️ Failed to get tax profile: %v", err)
+		return 0
+	}
+	return taxProfile(profile).IncludedPPN(total)
+}
+
+// SetProfile changes a user's tax profile, creating it from the default
+// one. changes holds regime, taxpayer_type, is_pkp and ppn_rate.
+func (t *TaxAgent) SetProfile(ctx context.Context, userID string, changes map[string]any) (*database.TaxProfile, error) {
+	if t.db == nil {
+		return nil, fmt.Errorf("database not configured")
+	}
+	profile, err := t.db.GetTaxProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	existing := profile != nil
+	if !existing {
+		defaults := tax.DefaultProfile()
+		profile = &database.TaxProfile{
+			UserID:         userID,
+			Regime:         defaults.Regime,
+			TaxpayerType:   defaults.TaxpayerType,
+			FinalRate:      defaults.FinalRate,
+			ExemptTurnover: defaults.ExemptTurnover,
+			PPNRate:        defaults.PPNRate,
+		}
+	}
+	applyTaxChanges(profile, changes)
+
+	if existing {
+		updates := map[string]any{
+			"regime":          profile.Regime,
+			"taxpayer_type":   profile.TaxpayerType,
+			"exempt_turnover": profile.ExemptTurnover,
+			"is_pkp":          profile.IsPKP,
+			"ppn_rate":        profile.PPNRate,
+			"updated_at":      time.Now().UTC().Format(time.RFC3339),
+		}
+		if err := t.db.UpdateTaxProfile(ctx, profile.ID, updates); err != nil {
+			return nil, err
+		}
+	} else if err := t.db.CreateTaxProfile(ctx, profile); err != nil {
+		return nil, err
+	}
+	log.Printf("🧾 Tax profile set: %s %s PKP=%t for user %s", profile.Regime, profile.TaxpayerType, profile.IsPKP, userID)
+	return profile, nil
+}
+
+// applyTaxChanges applies the settings of a SET_TAX intent. Entities have
+// no exempt turnover, so becoming an entity drops it.
+func applyTaxChanges(profile *database.TaxProfile, changes map[string]any) {
+	if regime := getStringEntity(changes, "regime"); regime == tax.RegimeFinal || regime == tax.RegimeNormal {
+		profile.Regime = regime
+	}
+	switch getStringEntity(changes, "taxpayer_type") {
+	case tax.TaxpayerEntity:
+		profile.TaxpayerType, profile.ExemptTurnover = tax.TaxpayerEntity, 0
+	case tax.TaxpayerIndividual:
+		profile.TaxpayerType, profile.ExemptTurnover = tax.TaxpayerIndividual, tax.DefaultExemptTurnover
+	}
+	if pkp, ok := changes["pkp"].(bool); ok {
+		profile.IsPKP = pkp
+	}
+	if rate := getFloatEntity(changes, "ppn_rate"); rate > 0 {
+		if rate >= 1 {
+			rate /= 100 // "ppn 12%"
+		}
+		profile.PPNRate, profile.IsPKP = rate, true
+	}
+}
+
+// MonthSummary computes the tax of the month of month, which is in the
+// user's time zone
+func (t *TaxAgent) MonthSummary(ctx context.Context, userID string, month time.Time) (*tax.Summary, *database.TaxProfile, error) {
+	if t.db == nil {
+		return nil, nil, fmt.Errorf("database not configured")
+	}
+	profile, err := t.db.GetTaxProfile(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	summary, err := t.summary(ctx, userID, taxProfile(profile), month)
+	return summary, profile, err
+}
+
+// MarkPaid records that the tax of a month was paid, which stops its
+// reminders
+func (t *TaxAgent) MarkPaid(ctx context.Context, userID string, month time.Time) error {
+	if t.db == nil {
+		return fmt.Errorf("database not configured")
+	}
+	profile, err := t.db.GetTaxProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if profile == nil {
+		if profile, err = t.SetProfile(ctx, userID, nil); err != nil {
+			return err
+		}
+	}
+	paid := month.Format("2006-01")
+	if profile.PaidMonth > paid {
+		return nil
+	}
+	return t.db.UpdateTaxProfile(ctx, profile.ID, map[string]any{
+		"paid_month": paid,
+		"updated_at": time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// QueueNotifications queues, for every user with a tax profile, the
+// summary of last month on the 1st and reminders before its deadlines
+// while it is unpaid
+func (t *TaxAgent) QueueNotifications(ctx context.Context, now time.Time) (int, error) {
+	if t.db == nil {
+		return 0, fmt.Errorf("database not configured")
+	}
+	profiles, err := t.db.GetTaxProfiles(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	month := tax.MonthStart(now).AddDate(0, -1, 0)
+	queued := 0
+	for i := range profiles {
+		profile := &profiles[i]
+		paid := profile.PaidMonth >= month.Format("2006-01")
+		if now.Day() != 1 && (paid || !remindOn(tax.PPhDeadline(month), now) && !remindOn(tax.PPNDeadline(month), now)) {
+			continue
+		}
+		s, err := t.summary(ctx, profile.UserID, taxProfile(profile), month)
+		if err != nil {
+			log.Printf("⚠️ Failed to compute tax of user %s: %v", profile.UserID, err)
+			continue
+		}
+
+		notifType, title, msg := taxSummaryType, "🧾 Pajak "+formatMonth(month), formatTaxSummary(s, paid)
+		if now.Day() != 1 {
+			notifType, title = taxReminderType, "⏰ Pengingat Pajak "+formatMonth(month)
+			if msg = taxReminder(s, now); msg == "" {
+				continue
+			}
+		}
+		if err := t.notification.QueueNotification(ctx, profile.UserID, notifType, title, msg, "whatsapp"); err != nil {
+			continue
+		}
+		queued++
+	}
+	return queued, nil
+}
+
+// RunReminders queues the summaries and reminders every day at 08:00
+// until ctx is cancelled
+func (t *TaxAgent) RunReminders(ctx context.Context) {
+	loc := ai.UserLocation("")
+	for {
+		now := time.Now().In(loc)
+		next := time.Date(now.Year(), now.Month(), now.Day(), taxReminderHour, 0, 0, 0, loc)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(next.Sub(now)):
+			queued, err := t.QueueNotifications(ctx, time.Now().In(loc))
+			if err != nil {
+				log.Printf("❌ Tax notifications failed: %v", err)
+				continue
+			}
+			if queued > 0 {
+				log.Printf("📬 Tax notifications queued: %d", queued)
+			}
+		}
+	}
+}
+
+// summary computes the tax of a month from the sales of its year
+func (t *TaxAgent) summary(ctx context.Context, userID string, profile tax.Profile, month time.Time) (*tax.Summary, error) {
+	const layout = "2006-01-02T15:04:05Z"
+	start := time.Date(month.Year(), 1, 1, 0, 0, 0, 0, month.Location())
+	end := tax.MonthStart(month).AddDate(0, 1, 0)
+	transactions, err := t.db.GetTransactionsByDateRange(ctx, userID,
+		start.UTC().Format(layout), end.Add(-time.Second).UTC().Format(layout))
+	if err != nil {
+		return nil, err
+	}
+	prior, turnover, ppn := tax.Totals(transactions, month)
+	s := tax.Compute(profile, month, prior, turnover, ppn)
+	return &s, nil
+}
+
+// taxReminder is the reminder due today for an unpaid month, "" when no
+// deadline is near or nothing is due
+func taxReminder(s *tax.Summary, today time.Time) string {
+	var lines []string
+	if s.PPh > 0 && remindOn(s.PPhDue, today) {
+		lines = append(lines, fmt.Sprintf("PPh Final Rp %s, paling lambat %s", formatCurrency(s.PPh), ai.FormatDate(s.PPhDue)))
+	}
+	if s.PPN > 0 && remindOn(s.PPNDue, today) {
+		lines = append(lines, fmt.Sprintf("PPN Rp %s, paling lambat %s", formatCurrency(s.PPN), ai.FormatDate(s.PPNDue)))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Pajak %s belum dibayar:\n\n• %s\n\n"+
+		"Sudah bayar? Balas \"sudah bayar pajak\" supaya tidak diingatkan lagi.",
+		formatMonth(s.Month), strings.Join(lines, "\n• "))
+}
+
+// remindOn reports whether today is the day of a deadline or
+// taxReminderDays before it
+func remindOn(deadline, today time.Time) bool {
+	day := today.Format("2006-01-02")
+	return day == deadline.Format("2006-01-02") || day == deadline.AddDate(0, 0, -taxReminderDays).Format("2006-01-02")
+}
+
+// formatRate formats a rate the Indonesian way, e.g. 0.005 as "0,5%"
+func formatRate(rate float64) string {
+	return strings.Replace(strconv.FormatFloat(math.Round(rate*10000)/100, 'f', -1, 64), ".", ",", 1) + "%"
+}
+
+func formatTaxSummary(s *tax.Summary, paid bool) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🧾 Pajak %s\n\n", formatMonth(s.Month)))
+	sb.WriteString(fmt.Sprintf("💰 Omzet: Rp %s\n", formatCurrency(s.Turnover)))
+	sb.WriteString(fmt.Sprintf("📈 Omzet tahun ini: Rp %s\n\n", formatCurrency(s.YearToDate)))
+
+	p := s.Profile
+	switch {
+	case p.Regime != tax.RegimeFinal:
+		sb.WriteString("🏛️ PPh dihitung dari pembukuan dan dibayar lewat SPT Tahunan.\n")
+	case s.PPh > 0:
+		sb.WriteString(fmt.Sprintf("🏛️ PPh Final %s: Rp %s\n", formatRate(p.FinalRate), formatCurrency(s.PPh)))
+		if s.Taxable < s.Turnover {
+			sb.WriteString(fmt.Sprintf("   dari omzet Rp %s di atas Rp %s setahun\n",
+				formatCurrency(s.Taxable), formatCurrency(p.ExemptTurnover)))
+		}
+		sb.WriteString(fmt.Sprintf("   Bayar paling lambat %s\n", ai.FormatDate(s.PPhDue)))
+	case s.Turnover > 0 && p.TaxpayerType == tax.TaxpayerIndividual:
+		sb.WriteString(fmt.Sprintf("🏛️ PPh Final: Rp 0, omzet tahun ini belum lewat Rp %s\n", formatCurrency(p.ExemptTurnover)))
+	default:
+		sb.WriteString("🏛️ PPh Final: Rp 0\n")
+	}
+
+	if p.PKP {
+		sb.WriteString(fmt.Sprintf("🧾 PPN %s dari penjualan: Rp %s\n", formatRate(p.PPNRate), formatCurrency(s.PPN)))
+		if s.PPN > 0 {
+			sb.WriteString(fmt.Sprintf("   Bayar paling lambat %s (belum dikurangi PPN pembelian)\n", ai.FormatDate(s.PPNDue)))
+		}
+	}
+
+	if due := s.AmountDue(); due > 0 {
+		sb.WriteString(fmt.Sprintf("\n💵 Total pajak: Rp %s\n", formatCurrency(due)))
+		if paid {
+			sb.WriteString("✅ Sudah dibayar\n")
+		}
+	}
+	if s.OverPKPThreshold() && (!p.PKP || p.Regime == tax.RegimeFinal) {
+		sb.WriteString(fmt.Sprintf("\n⚠️ Omzet tahun ini sudah lebih dari Rp %s: wajib daftar PKP dan mulai tahun depan PPh tidak bisa pakai tarif final lagi.\n",
+			formatCurrency(tax.PKPThreshold)))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
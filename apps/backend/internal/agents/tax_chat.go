@@ -0,0 +1,74 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pasarsuara/backend/internal/ai"
+	"github.com/pasarsuara/backend/internal/database"
+	"github.com/pasarsuara/backend/internal/tax"
+)
+
+// handleSetTax sets how the user is taxed, e.g. "saya PKP", "pajak badan",
+// "ppn 12%"
+func (o *AgentOrchestrator) handleSetTax(ctx context.Context, userID string, intent *ai.Intent) string {
+	profile, err := o.tax.SetProfile(ctx, userID, intent.Entities)
+	if err != nil {
+		return "Gagal menyimpan data pajak: " + err.Error()
+	}
+	return formatTaxProfile(profile) + "\n\nKami kirim ringkasan pajak tiap tanggal 1 dan ingatkan sebelum jatuh tempo. " +
+		"Ketik \"pajak bulan ini\" untuk melihat hitungannya."
+}
+
+// handleAskTax shows the tax of this month or last month, or records that
+// the user paid last month's tax
+func (o *AgentOrchestrator) handleAskTax(ctx context.Context, userID string, intent *ai.Intent) string {
+	now := time.Now().In(o.userLocation(ctx, userID))
+	month := now
+	if getStringEntity(intent.Entities, "period") == "last_month" || intent.Entities["paid"] == true {
+		month = tax.MonthStart(now).AddDate(0, -1, 0)
+	}
+
+	if intent.Entities["paid"] == true {
+		if err := o.tax.MarkPaid(ctx, userID, month); err != nil {
+			return "Gagal mencatat pembayaran pajak: " + err.Error()
+		}
+		return fmt.Sprintf("✅ Pajak %s tercatat sudah dibayar. Tidak ada pengingat lagi untuk bulan itu.", formatMonth(month))
+	}
+
+	s, profile, err := o.tax.MonthSummary(ctx, userID, month)
+	if err != nil {
+		return "Maaf, hitungan pajak belum bisa diambil. Coba lagi nanti ya!"
+	}
+	paid := profile != nil && profile.PaidMonth >= month.Format("2006-01")
+	msg := formatTaxSummary(s, paid)
+	if profile == nil {
+		msg += "\n\nDihitung sebagai wajib pajak orang pribadi dengan PPh Final 0,5%. " +
+			"Kalau berbeda, kabari kami, misalnya \"saya PKP\" atau \"pajak badan\"."
+	}
+	return msg
+}
+
+func formatTaxProfile(p *database.TaxProfile) string {
+	taxpayer := "orang pribadi"
+	if p.TaxpayerType == tax.TaxpayerEntity {
+		taxpayer = "badan usaha"
+	}
+	msg := fmt.Sprintf("🧾 Data pajak tersimpan\n\n👤 Wajib pajak: %s\n", taxpayer)
+	if p.Regime == tax.RegimeFinal {
+		msg += fmt.Sprintf("🏛️ PPh Final %s dari omzet", formatRate(p.FinalRate))
+		if p.ExemptTurnover > 0 {
+			msg += fmt.Sprintf(", omzet Rp %s pertama setahun bebas pajak", formatCurrency(p.ExemptTurnover))
+		}
+		msg += "\n"
+	} else {
+		msg += "🏛️ PPh dari pembukuan (SPT Tahunan)\n"
+	}
+	if p.IsPKP {
+		msg += fmt.Sprintf("🧾 PKP, PPN %s sudah termasuk dalam harga jual, juga harga di marketplace", formatRate(p.PPNRate))
+	} else {
+		msg += "🧾 Bukan PKP, tidak memungut PPN"
+	}
+	return msg
+}
@@ -0,0 +1,83 @@
+package agents
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pasarsuara/backend/internal/database"
+	"github.com/pasarsuara/backend/internal/tax"
+)
+
+func TestApplyTaxChanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		changes map[string]any
+		want    database.TaxProfile
+	}{
+		{"pkp", map[string]any{"pkp": true},
+			database.TaxProfile{Regime: tax.RegimeFinal, TaxpayerType: tax.TaxpayerIndividual, ExemptTurnover: 500000000, IsPKP: true, PPNRate: 0.11}},
+		{"ppn rate in percent", map[string]any{"ppn_rate": 12.0},
+			database.TaxProfile{Regime: tax.RegimeFinal, TaxpayerType: tax.TaxpayerIndividual, ExemptTurnover: 500000000, IsPKP: true, PPNRate: 0.12}},
+		{"entity", map[string]any{"taxpayer_type": tax.TaxpayerEntity},
+			database.TaxProfile{Regime: tax.RegimeFinal, TaxpayerType: tax.TaxpayerEntity, PPNRate: 0.11}},
+		{"normal regime", map[string]any{"regime": tax.RegimeNormal},
+			database.TaxProfile{Regime: tax.RegimeNormal, TaxpayerType: tax.TaxpayerIndividual, ExemptTurnover: 500000000, PPNRate: 0.11}},
+		{"unknown regime", map[string]any{"regime": "PPH_21"},
+			database.TaxProfile{Regime: tax.RegimeFinal, TaxpayerType: tax.TaxpayerIndividual, ExemptTurnover: 500000000, PPNRate: 0.11}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile := database.TaxProfile{Regime: tax.RegimeFinal, TaxpayerType: tax.TaxpayerIndividual, ExemptTurnover: 500000000, PPNRate: 0.11}
+			applyTaxChanges(&profile, tt.changes)
+			if profile != tt.want {
+				t.Errorf("profile = %+v, want %+v", profile, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaxReminder(t *testing.T) {
+	loc := time.FixedZone("WIB", 7*3600)
+	profile := tax.DefaultProfile()
+	profile.PKP = true
+	s := tax.Compute(profile, time.Date(2026, 9, 1, 0, 0, 0, 0, loc), 600000000, 50000000, 5500000)
+
+	tests := []struct {
+		day  string
+		want []string
+	}{
+		{"2026-10-12", []string{"PPh Final Rp 250.000, paling lambat 15 Oktober 2026"}},
+		{"2026-10-15", []string{"PPh Final Rp 250.000"}},
+		{"2026-10-14", nil},
+		{"2026-10-30", []string{"PPN Rp 5.500.000, paling lambat 2 November 2026"}},
+	}
+	for _, tt := range tests {
+		today, _ := time.ParseInLocation("2006-01-02", tt.day, loc)
+		got := taxReminder(&s, today.Add(taxReminderHour*time.Hour))
+		if tt.want == nil {
+			if got != "" {
+				t.Errorf("taxReminder(%s) = %q, want none", tt.day, got)
+			}
+			continue
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("taxReminder(%s) = %q, want it to contain %q", tt.day, got, want)
+			}
+		}
+	}
+
+	nothingDue := tax.Compute(tax.DefaultProfile(), time.Date(2026, 9, 1, 0, 0, 0, 0, loc), 0, 50000000, 0)
+	if got := taxReminder(&nothingDue, time.Date(2026, 10, 12, 8, 0, 0, 0, loc)); got != "" {
+		t.Errorf("taxReminder() with nothing due = %q", got)
+	}
+}
+
+func TestFormatRate(t *testing.T) {
+	for rate, want := range map[float64]string{0.005: "0,5%", 0.11: "11%", 0.12: "12%", 0.07: "7%"} {
+		if got := formatRate(rate); got != want {
+			t.Errorf("formatRate(%v) = %q, want %q", rate, got, want)
+		}
+	}
+}
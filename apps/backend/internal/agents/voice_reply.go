@@ -0,0 +1,130 @@
+package agents
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/pasarsuara/backend/internal/ai"
+	"github.com/pasarsuara/backend/internal/database"
+)
+
+// Phrases that switch voice note replies on or off
+var (
+	voiceOnPhrases = []string{
+		"balas pakai suara", "balas dengan suara", "balas suara", "pakai voice note",
+		"jawab pakai suara", "mangsuli nganggo swara", "jawab make sora",
+	}
+	voiceOffPhrases = []string{
+		"balas teks saja", "balas pakai teks", "balas tulisan", "jangan pakai suara",
+		"matikan suara", "stop voice note",
+	}
+)
+
+// SetSpeechSynthesizer enables voice note replies for users who opt in
+func (o *AgentOrchestrator) SetSpeechSynthesizer(tts ai.SpeechSynthesizer) {
+	o.tts = tts
+}
+
+// parseVoiceCommand reports whether text toggles voice replies, and to what
+func parseVoiceCommand(text string) (enabled bool, ok bool) {
+	lower := strings.ToLower(strings.TrimSpace(text))
+	for _, phrase := range voiceOffPhrases {
+		if strings.Contains(lower, phrase) {
+			return false, true
+		}
+	}
+	for _, phrase := range voiceOnPhrases {
+		if strings.Contains(lower, phrase) {
+			return true, true
+		}
+	}
+	return false, false
+}
+
+// SetVoiceReplies stores the user's voice reply preference
+func (o *AgentOrchestrator) SetVoiceReplies(ctx context.Context, userPhone string, enabled bool) *AgentResponse {
+	if o.db != nil {
+		userID := o.getUserID(ctx, userPhone)
+		prefs, err := o.db.GetUserPreferences(ctx, userID)
+		if err == nil && prefs == nil {
+			err = o.db.CreateUserPreferences(ctx, &database.UserPreferences{
+				UserID:              userID,
+				Language:            "id",
+				NotificationEnabled: true,
+				VoiceReplies:        &enabled,
+			})
+		} else if err == nil {
+			err = o.db.UpdateUserPreferences(ctx, userID, map[string]any{"voice_replies": enabled})
+		}
+		if err != nil {
+			log.Printf("❌ Failed to save voice preference: %v", err)
+			return &AgentResponse{Success: false, Message: "Gagal menyimpan pengaturan. Coba lagi ya!"}
+		}
+	}
+
+	o.voiceMu.Lock()
+	o.voicePrefs[userPhone] = enabled
+	o.voiceMu.Unlock()
+
+	if !enabled {
+		return &AgentResponse{Success: true, Message: "💬 Oke, mulai sekarang balasan dikirim sebagai teks saja."}
+	}
+	msg := "🔊 Oke, mulai sekarang balasan juga dikirim sebagai voice note."
+	if o.tts == nil {
+		msg += "\n\n⚠️ Fitur suara belum aktif di server, sementara balasan tetap teks."
+	}
+	return &AgentResponse{Success: true, Message: msg}
+}
+
+// WantsVoiceReply reports whether the user opted in to voice note replies
+func (o *AgentOrchestrator) WantsVoiceReply(ctx context.Context, userPhone string) bool {
+	o.voiceMu.Lock()
+	enabled, cached := o.voicePrefs[userPhone]
+	o.voiceMu.Unlock()
+	if cached || o.db == nil {
+		return enabled
+	}
+
+	prefs, err := o.db.GetUserPreferences(ctx, o.getUserID(ctx, userPhone))
+	if err != nil {
+		log.Printf("⚠️ Failed to get user preferences: %v", err)
+		return false
+	}
+	enabled = prefs != nil && prefs.VoiceReplies != nil && *prefs.VoiceReplies
+
+	o.voiceMu.Lock()
+	o.voicePrefs[userPhone] = enabled
+	o.voiceMu.Unlock()
+	return enabled
+}
+
+// AddVoiceReply appends a voice note of the reply for users who opted in.
+// The text reply is kept first so buttons and details stay readable.
+func (o *AgentOrchestrator) AddVoiceReply(ctx context.Context, userPhone string, response *AgentResponse) {
+	if o.tts == nil || response == nil || response.Message == "" || !o.WantsVoiceReply(ctx, userPhone) {
+		return
+	}
+
+	speech := ai.SpeechText(response.Message)
+	if speech == "" {
+		return
+	}
+
+	language := "id"
+	if response.Intent != nil && response.Intent.Language != "" {
+		language = response.Intent.Language
+	}
+
+	audio, err := o.tts.Synthesize(ctx, speech, language)
+	if err != nil {
+		log.Printf("⚠️ Voice reply failed, sending text only: %v", err)
+		return
+	}
+
+	if len(response.Replies) == 0 {
+		response.Replies = []Reply{TextReply(response.Message)}
+	}
+	response.Replies = append(response.Replies, AudioReply(audio.Data, audio.MimeType, audio.Seconds))
+	log.Printf("🔊 Voice reply for %s: %d bytes, %ds", userPhone, len(audio.Data), audio.Seconds)
+}
@@ -0,0 +1,61 @@
+package agents
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pasarsuara/backend/internal/ai"
+)
+
+func TestParseVoiceCommand(t *testing.T) {
+	tests := []struct {
+		text        string
+		wantEnabled bool
+		wantOK      bool
+	}{
+		{"Balas pakai suara ya", true, true},
+		{"balas teks saja", false, true},
+		{"jangan pakai suara lagi", false, true},
+		{"laku beras 10 kg", false, false},
+	}
+
+	for _, tt := range tests {
+		enabled, ok := parseVoiceCommand(tt.text)
+		if enabled != tt.wantEnabled || ok != tt.wantOK {
+			t.Errorf("parseVoiceCommand(%q) = %v, %v; want %v, %v", tt.text, enabled, ok, tt.wantEnabled, tt.wantOK)
+		}
+	}
+}
+
+func TestAddVoiceReply(t *testing.T) {
+	stub := &ai.StubSynthesizer{Audio: []byte("OggS")}
+	o := &AgentOrchestrator{voicePrefs: make(map[string]bool)}
+	o.SetSpeechSynthesizer(stub)
+	ctx := context.Background()
+
+	// Users who did not opt in get text only
+	resp := &AgentResponse{Success: true, Message: "✅ Penjualan tercatat!"}
+	o.AddVoiceReply(ctx, "628111", resp)
+	if len(resp.Replies) != 0 || len(stub.Calls) != 0 {
+		t.Fatal("Voice reply should be opt-in")
+	}
+
+	o.SetVoiceReplies(ctx, "628111", true)
+	o.AddVoiceReply(ctx, "628111", resp)
+	if len(resp.Replies) != 2 {
+		t.Fatalf("Expected text and audio replies, got %d", len(resp.Replies))
+	}
+	if resp.Replies[0].Type != ReplyTypeText || resp.Replies[1].Type != ReplyTypeAudio {
+		t.Errorf("Reply order = %s, %s; want text, audio", resp.Replies[0].Type, resp.Replies[1].Type)
+	}
+	if stub.Calls[0] != "Penjualan tercatat!" {
+		t.Errorf("Spoken text = %q", stub.Calls[0])
+	}
+
+	// Existing structured replies are kept before the voice note
+	resp = &AgentResponse{Message: "Sudah benar?", Replies: []Reply{ButtonsReply("Sudah benar?", nil)}}
+	o.AddVoiceReply(ctx, "628111", resp)
+	if len(resp.Replies) != 2 || resp.Replies[0].Type != ReplyTypeButtons {
+		t.Error("Voice note should follow the existing replies")
+	}
+}
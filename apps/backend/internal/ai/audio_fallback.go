@@ -2,9 +2,14 @@ package ai
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/pasarsuara/backend/internal/audio"
 )
 
 // AudioProcessingResult represents the result of audio processing with fallback
@@ -16,121 +21,157 @@ type AudioProcessingResult struct {
 	RetryCount    int    `json:"retry_count"`
 }
 
-// AudioProcessor handles audio processing with fallback mechanisms
+// AudioProcessor validates, converts and splits voice notes, then
+// transcribes the pieces in parallel
 type AudioProcessor struct {
-	gemini     *GeminiClient
-	maxRetries int
-	retryDelay time.Duration
+	stt  Transcriber
+	opts audio.Options
 }
 
-func NewAudioProcessor(gemini *GeminiClient) *AudioProcessor {
-	return &AudioProcessor{
-		gemini:     gemini,
-		maxRetries: 3,
-		retryDelay: 2 * time.Second,
-	}
+const (
+	// maxAudioBytes guards against oversized uploads
+	maxAudioBytes = 10 * 1024 * 1024
+	// transcribeParallel bounds concurrent chunk transcriptions per voice note
+	transcribeParallel = 3
+)
+
+// NewAudioProcessor transcribes with stt; retries and provider fallback
+// are handled by the routers
+func NewAudioProcessor(stt Transcriber) *AudioProcessor {
+	return &AudioProcessor{stt: stt, opts: audio.DefaultOptions()}
 }
 
 // ProcessAudioWithFallback processes audio with retry and fallback logic
 func (ap *AudioProcessor) ProcessAudioWithFallback(ctx context.Context, audioData []byte, mimeType string) *AudioProcessingResult {
 	result := &AudioProcessingResult{
-		Success: false,
-		Method:  "gemini",
+		Success:    false,
+		Method:     "gemini",
+		RetryCount: 1,
 	}
 
-	// Validate audio data
-	if len(audioData) == 0 {
-		result.Error = "Audio data is empty"
+	transcription, err := ap.Transcribe(ctx, audioData, mimeType)
+	switch {
+	case err == nil:
+		result.Success = true
+		result.Transcription = transcription.Text
+		return result
+	case errors.Is(err, audio.ErrUnsupportedFormat), errors.Is(err, audio.ErrCorrupt):
+		result.Method = "conversion"
+		result.Error = err.Error()
+		return result
+	case errors.Is(err, audio.ErrSilent), errors.Is(err, audio.ErrTooShort):
+		result.Method = "quality"
+		result.Error = err.Error()
 		return result
 	}
 
-	// Validate audio format
-	if !ap.isValidAudioFormat(mimeType) {
-		result.Error = fmt.Sprintf("Unsupported audio format: %s", mimeType)
-		return ap.attemptFormatConversion(ctx, audioData, mimeType)
-	}
-
-	// Validate audio quality
-	if !ap.isValidAudioQuality(audioData) {
-		log.Printf("⚠️ Audio quality check failed, attempting anyway...")
-	}
-
-	// Try primary method (Gemini) with retries
-	for i := 0; i < ap.maxRetries; i++ {
-		result.RetryCount = i + 1
-
-		transcription, err := ap.gemini.TranscribeAudio(ctx, audioData, mimeType)
-		if err == nil && transcription != "" {
-			result.Success = true
-			result.Transcription = transcription
-			result.Method = "gemini"
-			return result
-		}
-
-		log.Printf("⚠️ Gemini transcription attempt %d failed: %v", i+1, err)
-
-		if i < ap.maxRetries-1 {
-			time.Sleep(ap.retryDelay)
-		}
-	}
+	log.Printf("⚠️ Transcription failed: %v", err)
 
 	// If all retries failed, try fallback methods
 	log.Printf("🔄 Attempting fallback transcription methods...")
 	return ap.attemptFallbackTranscription(ctx, audioData, mimeType)
 }
 
-// isValidAudioFormat checks if the audio format is supported
-func (ap *AudioProcessor) isValidAudioFormat(mimeType string) bool {
-	supportedFormats := []string{
-		"audio/ogg",
-		"audio/mpeg",
-		"audio/mp3",
-		"audio/wav",
-		"audio/webm",
-		"audio/m4a",
+// Transcribe converts and splits a voice note, transcribes the chunks in
+// parallel and stitches them back together in order
+func (ap *AudioProcessor) Transcribe(ctx context.Context, audioData []byte, mimeType string) (*Transcription, error) {
+	prepared, err := ap.attemptFormatConversion(audioData, mimeType)
+	if err != nil {
+		return nil, err
 	}
-
-	for _, format := range supportedFormats {
-		if mimeType == format {
-			return true
-		}
+	if err := ap.isValidAudioQuality(prepared.Analysis); err != nil {
+		return nil, err
 	}
 
-	return false
-}
+	language := LanguageFromContext(ctx)
+	clips := prepared.Clips
+	if len(clips) == 1 {
+		return ap.stt.Transcribe(ctx, clips[0].Data, clips[0].MimeType, language)
+	}
 
-// isValidAudioQuality performs basic quality checks
-func (ap *AudioProcessor) isValidAudioQuality(audioData []byte) bool {
-	// Check minimum size (at least 1KB)
-	if len(audioData) < 1024 {
-		return false
+	results := make([]*Transcription, len(clips))
+	errs := make([]error, len(clips))
+	sem := make(chan struct{}, transcribeParallel)
+	var wg sync.WaitGroup
+	for i, clip := range clips {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i], errs[i] = ap.stt.Transcribe(ctx, clip.Data, clip.MimeType, language)
+		}()
 	}
+	wg.Wait()
 
-	// Check maximum size (max 10MB)
-	if len(audioData) > 10*1024*1024 {
-		return false
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("chunk %d/%d at %v: %w", i+1, len(clips), clips[i].Offset, err)
+		}
 	}
+	return stitchTranscriptions(clips, results), nil
+}
 
-	// TODO: Add more sophisticated quality checks
-	// - Check for silence
-	// - Check for noise levels
-	// - Check for corruption
+// stitchTranscriptions joins chunk transcripts; confidence is the
+// duration-weighted mean of the chunks that reported one
+func stitchTranscriptions(clips []audio.Clip, parts []*Transcription) *Transcription {
+	stitched := &Transcription{}
+	var texts []string
+	var weighted, weight float64
+	for i, part := range parts {
+		texts = append(texts, part.Text)
+		stitched.UncertainWords = append(stitched.UncertainWords, part.UncertainWords...)
+		if part.Confidence > 0 {
+			seconds := clips[i].Duration.Seconds()
+			weighted += part.Confidence * seconds
+			weight += seconds
+		}
+	}
+	stitched.Text = strings.Join(texts, " ")
+	if weight > 0 {
+		stitched.Confidence = weighted / weight
+	}
+	return stitched
+}
 
-	return true
+// isValidAudioQuality rejects silent or too short recordings and warns on clipping
+func (ap *AudioProcessor) isValidAudioQuality(analysis *audio.Analysis) error {
+	if err := analysis.Check(); err != nil {
+		return err
+	}
+	if analysis.Clipped() {
+		log.Printf("⚠️ Audio clipped (%.1f%% of samples), transcription may suffer", analysis.ClippingRatio*100)
+	}
+	return nil
 }
 
-// attemptFormatConversion tries to convert unsupported formats
-func (ap *AudioProcessor) attemptFormatConversion(ctx context.Context, audioData []byte, mimeType string) *AudioProcessingResult {
-	result := &AudioProcessingResult{
-		Success: false,
-		Method:  "conversion",
-		Error:   fmt.Sprintf("Format conversion not implemented for %s", mimeType),
+// attemptFormatConversion demuxes the voice note, converts it to a format
+// providers accept and splits it at pauses into chunks within STT limits
+func (ap *AudioProcessor) attemptFormatConversion(audioData []byte, mimeType string) (*audio.Prepared, error) {
+	if len(audioData) == 0 {
+		return nil, fmt.Errorf("%w: audio data is empty", audio.ErrCorrupt)
+	}
+	if len(audioData) > maxAudioBytes {
+		return nil, fmt.Errorf("%w: %d bytes exceeds %d", audio.ErrUnsupportedFormat, len(audioData), maxAudioBytes)
 	}
 
-	// TODO: Implement format conversion using ffmpeg or similar
-	// For now, return error with helpful message
+	prepared, err := audio.Prepare(audioData, mimeType, ap.opts)
+	if errors.Is(err, audio.ErrUnsupportedFormat) && audio.Detect(audioData, mimeType) == "" && audio.Accepted(mimeType) {
+		// Unknown container the provider claims to read: send it untouched
+		log.Printf("⚠️ Audio container not recognised, sending %s as is", mimeType)
+		return &audio.Prepared{
+			Analysis: &audio.Analysis{},
+			Clips:    []audio.Clip{{Data: audioData, MimeType: audio.NormalizeMimeType(mimeType)}},
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	return result
+	a := prepared.Analysis
+	log.Printf("🎚️ Audio %s: %v, %.0f%% silence, %d chunk(s), transcoded=%v",
+		a.Format, a.Duration.Round(100*time.Millisecond), a.SilenceRatio*100, len(prepared.Clips), prepared.Transcoded)
+	return prepared, nil
 }
 
 // attemptFallbackTranscription tries alternative transcription methods
@@ -174,6 +215,8 @@ func (ap *AudioProcessor) GetUserFriendlyError(result *AudioProcessingResult) st
 		return "Maaf, gagal memproses audio. Silakan coba lagi atau ketik pesan Anda."
 	case "conversion":
 		return "Format audio tidak didukung. Silakan gunakan format OGG, MP3, atau WAV."
+	case "quality":
+		return "Voice note-nya kosong atau terlalu pendek. Coba rekam ulang lebih dekat ke HP ya."
 	case "fallback":
 		return "Gagal memproses audio setelah beberapa percobaan. Silakan ketik pesan Anda."
 	default:
@@ -186,3 +229,17 @@ func (ap *AudioProcessor) LogProcessingMetrics(result *AudioProcessingResult, du
 	log.Printf("📊 Audio Processing Metrics: success=%v, method=%s, retries=%d, duration=%v",
 		result.Success, result.Method, result.RetryCount, duration)
 }
+
+// AudioErrorMessage explains to the user why a voice note was not
+// transcribed, or returns "" for provider failures
+func AudioErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, audio.ErrSilent):
+		return "🔇 Voice note-nya tidak ada suaranya. Coba rekam ulang lebih dekat ke HP ya."
+	case errors.Is(err, audio.ErrTooShort):
+		return "⏱️ Voice note-nya terlalu pendek. Coba rekam ulang ya."
+	case errors.Is(err, audio.ErrUnsupportedFormat), errors.Is(err, audio.ErrCorrupt):
+		return "Format audio tidak didukung. Silakan kirim voice note WhatsApp biasa atau ketik pesannya ya. 🙏"
+	}
+	return ""
+}
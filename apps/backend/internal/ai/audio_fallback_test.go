@@ -0,0 +1,110 @@
+package ai
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/pasarsuara/backend/internal/audio"
+)
+
+// clipProvider transcribes each clip as its size in bytes
+type clipProvider struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (p *clipProvider) Name() string { return "fake" }
+
+func (p *clipProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	p.mu.Lock()
+	p.calls++
+	p.mu.Unlock()
+	size := len(req.Media[0].Data)
+	return &CompletionResponse{Text: fmt.Sprintf(`{"text":"%d","confidence":0.8,"uncertain_words":["w%d"]}`, size, size)}, nil
+}
+
+// testWAV writes 16 kHz mono 16-bit PCM: a tone for speech, zeros for pauses,
+// alternating from speech, in seconds
+func testWAV(seconds ...float64) []byte {
+	const rate = 16000
+	var pcm []byte
+	for i, s := range seconds {
+		for n := 0; n < int(s*rate); n++ {
+			var v int16
+			if i%2 == 0 {
+				v = int16(8000 * math.Sin(float64(n)*2*math.Pi*220/rate))
+			}
+			pcm = binary.LittleEndian.AppendUint16(pcm, uint16(v))
+		}
+	}
+	data := []byte("RIFF\x00\x00\x00\x00WAVEfmt ")
+	data = binary.LittleEndian.AppendUint32(data, 16)
+	data = binary.LittleEndian.AppendUint16(data, 1)
+	data = binary.LittleEndian.AppendUint16(data, 1)
+	data = binary.LittleEndian.AppendUint32(data, rate)
+	data = binary.LittleEndian.AppendUint32(data, rate*2)
+	data = binary.LittleEndian.AppendUint16(data, 2)
+	data = binary.LittleEndian.AppendUint16(data, 16)
+	data = append(data, "data"...)
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(pcm)))
+	return append(data, pcm...)
+}
+
+func TestAudioProcessor_TranscribeChunks(t *testing.T) {
+	llm := &clipProvider{}
+	ap := NewAudioProcessor(NewGeminiTranscriber(llm))
+
+	// 40 s of speech, a 1 s pause, 30 s of speech: one cut in the pause
+	got, err := ap.Transcribe(context.Background(), testWAV(40, 1, 30), "audio/wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if llm.calls != 2 {
+		t.Fatalf("calls = %d, want 2", llm.calls)
+	}
+
+	// Clips are 44-byte WAV headers plus 32000 bytes per second
+	first, second := 44+40500*32, 44+30500*32
+	want := fmt.Sprintf("%d %d", first, second)
+	if got.Text != want {
+		t.Errorf("Text = %q, want %q", got.Text, want)
+	}
+	if math.Abs(got.Confidence-0.8) > 1e-9 {
+		t.Errorf("Confidence = %v, want 0.8", got.Confidence)
+	}
+	if len(got.UncertainWords) != 2 || got.UncertainWords[0] != fmt.Sprintf("w%d", first) {
+		t.Errorf("UncertainWords = %v", got.UncertainWords)
+	}
+}
+
+func TestAudioProcessor_Rejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		mimeType string
+		want     error
+	}{
+		{"silent", testWAV(0, 3), "audio/wav", audio.ErrSilent},
+		{"too short", testWAV(0.2), "audio/wav", audio.ErrTooShort},
+		{"amr", []byte("#!AMR\n\x3c"), "audio/amr", audio.ErrUnsupportedFormat},
+		{"empty", nil, "audio/ogg", audio.ErrCorrupt},
+	}
+	for _, tt := range tests {
+		llm := &clipProvider{}
+		_, err := NewAudioProcessor(NewGeminiTranscriber(llm)).Transcribe(context.Background(), tt.data, tt.mimeType)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+		if llm.calls != 0 {
+			t.Errorf("%s: provider called %d times", tt.name, llm.calls)
+		}
+		if AudioErrorMessage(err) == "" {
+			t.Errorf("%s: no user message", tt.name)
+		}
+	}
+}
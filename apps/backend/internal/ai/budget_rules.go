@@ -0,0 +1,73 @@
+package ai
+
+import "strings"
+
+// Words of expense budgets (id, jv, su)
+var (
+	budgetWords = map[string]bool{
+		"budget": true, "bujet": true, "budgetnya": true, "anggaran": true, "anggarane": true, "anggarannya": true,
+		"anggaranna": true,
+	}
+	suggestWords = map[string]bool{
+		"saran": true, "usul": true, "usulan": true, "rekomendasi": true, "rekomendasiin": true, "sarankan": true,
+	}
+	// Words around the category that are not part of it
+	budgetFillers = map[string]bool{
+		"sebulan": true, "bulanan": true, "perbulan": true, "per": true, "tiap": true, "setiap": true,
+		"saben": true, "unggal": true, "sasasih": true, "set": true, "atur": true, "pasang": true, "kasih": true,
+		"kategori": true, "cek": true, "lihat": true, "liat": true, "gimana": true, "piye": true, "kumaha": true,
+		"maksimal": true, "maks": true, "max": true, "rp": true, "beli": true, "bayar": true, "biaya": true,
+		"ongkos": true, "pengeluaran": true, "belanja": true, "sisa": true, "laporan": true, "rekap": true,
+	}
+)
+
+// parseBudgetRules recognises setting a monthly budget ("budget gas 200 ribu
+// sebulan", "anggaran bahan baku 3 juta") and questions about budgets
+// ("budget bulan ini", "saran budget"). "cari beras budget 12 ribu" is a
+// restock with a max price, so callers skip it for ORDER_RESTOCK.
+func parseBudgetRules(tokens []string, intent *Intent) bool {
+	budgetAt := -1
+	suggest := false
+	for i, tok := range tokens {
+		if budgetWords[tok] && budgetAt < 0 {
+			budgetAt = i
+		}
+		suggest = suggest || suggestWords[tok]
+	}
+	if budgetAt < 0 {
+		return false
+	}
+
+	category := budgetCategory(tokens)
+	amount := creditAmount(tokens)
+	if amount > 0 && !suggest {
+		intent.Action = "SET_BUDGET"
+		intent.Entities["amount"] = amount
+		if category != "" {
+			intent.Entities["category"] = category
+		}
+		return true
+	}
+
+	intent.Action = "ASK_BUDGET"
+	if suggest {
+		intent.Entities["suggest"] = true
+	}
+	return true
+}
+
+// budgetCategory collects the words naming the category or an expense in it
+func budgetCategory(tokens []string) string {
+	var words []string
+	for _, tok := range tokens {
+		if budgetWords[tok] || suggestWords[tok] || budgetFillers[tok] || isRuleNumber(tok) || ruleFillers[tok] ||
+			ruleUnits[tok] != "" || questionWords[tok] || perUnitMarkers[tok] || greetingWords[tok] {
+			if len(words) > 0 {
+				break
+			}
+			continue
+		}
+		words = append(words, tok)
+	}
+	return strings.Join(words, " ")
+}
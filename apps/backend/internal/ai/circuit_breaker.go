@@ -0,0 +1,61 @@
+package ai
+
+import (
+	"sync"
+	"time"
+)
+
+// CircuitBreaker stops calling a provider after repeated failures and lets
+// a trial request through once the cooldown has passed
+type CircuitBreaker struct {
+	threshold   int
+	cooldown    time.Duration
+	failures    int
+	lastFailure time.Time
+	mu          sync.Mutex
+}
+
+// NewCircuitBreaker opens after threshold consecutive failures for cooldown
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		threshold = 5
+	}
+	if cooldown <= 0 {
+		cooldown = time.Minute
+	}
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// Allow reports whether a call may be made
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+	// Half-open: allow a trial; a failure keeps the circuit open
+	return time.Since(b.lastFailure) > b.cooldown
+}
+
+// IsOpen reports whether calls are currently blocked
+func (b *CircuitBreaker) IsOpen() bool {
+	return !b.Allow()
+}
+
+// RecordSuccess closes the circuit
+func (b *CircuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+}
+
+// RecordFailure counts a failed call
+func (b *CircuitBreaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.lastFailure = time.Now()
+}
@@ -0,0 +1,252 @@
+package ai
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Credit types of RECORD_DEBT, RECORD_DEBT_PAYMENT and ASK_DEBTS
+const (
+	CreditReceivable = "RECEIVABLE" // A customer owes the user (kasbon, piutang)
+	CreditPayable    = "PAYABLE"    // The user owes a supplier (utang)
+)
+
+// Words of kasbon and utang-piutang (id, jv, su)
+var (
+	debtWords = map[string]bool{
+		"ngutang": true, "utang": true, "hutang": true, "kasbon": true, "ngebon": true, "ngutangin": true,
+		"nganjuk": true, "utange": true, "utangna": true,
+	}
+	receivableWords = map[string]bool{"piutang": true, "piutangnya": true}
+	repayWords      = map[string]bool{
+		"bayar": true, "mbayar": true, "mayar": true, "nyicil": true, "cicil": true, "nyaur": true, "nahur": true,
+		"lunas": true, "lunasin": true, "ngelunasin": true, "melunasi": true, "dibayar": true, "dicicil": true,
+		"dilunasi": true, "dilunasin": true, "disaur": true,
+	}
+	// Repayment words that mean a repayment even after a debt word,
+	// unlike "bayar" in "ngutang 50 ribu bayar minggu depan"
+	passiveRepayWords = map[string]bool{
+		"lunas": true, "dibayar": true, "dicicil": true, "dilunasi": true, "dilunasin": true, "disaur": true,
+	}
+	settleWords = map[string]bool{
+		"lunas": true, "lunasin": true, "ngelunasin": true, "melunasi": true, "dilunasi": true, "dilunasin": true,
+	}
+	creditListWords = map[string]bool{
+		"siapa": true, "sapa": true, "sinten": true, "saha": true, "daftar": true, "list": true, "umur": true,
+	}
+	// Words before the supplier in "utang ke Pak Budi"
+	counterpartyLeads = map[string]bool{
+		"ke": true, "sama": true, "ama": true, "nang": true, "ning": true, "karo": true, "ka": true, "kalih": true,
+	}
+	firstPersonWords = map[string]bool{
+		"saya": true, "aku": true, "kulo": true, "abdi": true, "gue": true, "gua": true, "kita": true,
+	}
+	honorifics = map[string]bool{
+		"bu": true, "ibu": true, "pak": true, "bapak": true, "mas": true, "mbak": true, "mba": true, "bang": true,
+		"kak": true, "kang": true, "teh": true, "mbok": true, "om": true, "tante": true, "cik": true, "koh": true,
+		"bude": true, "pakde": true, "mang": true, "bi": true, "ceu": true, "uda": true, "uni": true, "neng": true,
+	}
+)
+
+// parseCreditRules recognises kasbon, repayments and questions about who
+// still owes, e.g. "Bu Sari ngutang 50 ribu", "Bu Sari bayar 20 ribu",
+// "siapa aja yang masih ngutang". It reports false when the message is not
+// about credit, so "bayar listrik 200 ribu" stays an expense.
+//
+// Credit that names goods ("jual bakso 10 porsi 15rb ngutang Bu Sari",
+// "Pak Joko kasbon rokok 25rb") is the sale itself, RECORD_SALE, or for the
+// user's own debt an expense, RECORD_EXPENSE, with the contact and
+// credit_type of the credit. RECORD_DEBT is money alone.
+func parseCreditRules(tokens []string, intent *Intent) bool {
+	debtAt, repayAt := -1, -1
+	receivable, listing, firstPerson, passive, settle := false, false, false, false, false
+	for i, tok := range tokens {
+		switch {
+		case debtWords[tok] && debtAt < 0:
+			debtAt = i
+		case receivableWords[tok] && debtAt < 0:
+			debtAt, receivable = i, true
+		case repayWords[tok] && repayAt < 0:
+			repayAt = i
+		}
+		listing = listing || creditListWords[tok] || questionWords[tok]
+		firstPerson = firstPerson || firstPersonWords[tok]
+		passive = passive || passiveRepayWords[tok]
+		settle = settle || settleWords[tok]
+	}
+	if debtAt < 0 && repayAt < 0 {
+		return false
+	}
+
+	keywordAt := debtAt
+	if keywordAt < 0 || repayAt >= 0 && repayAt < keywordAt {
+		keywordAt = repayAt
+	}
+	contact, afterLead := creditContact(tokens, keywordAt)
+
+	var action string
+	switch {
+	case listing || receivable && contact == "":
+		action = "ASK_DEBTS"
+	case repayAt >= 0 && (debtAt < 0 || repayAt < debtAt || passive):
+		if debtAt < 0 && (contact == "" || afterLead) {
+			return false // "bayar listrik", "bayar ke tukang"
+		}
+		action = "RECORD_DEBT_PAYMENT"
+	default:
+		action = "RECORD_DEBT"
+	}
+
+	creditType := CreditReceivable
+	if !receivable && (afterLead || contact == "" && firstPerson) {
+		creditType = CreditPayable
+	}
+
+	intent.Action = action
+	intent.Entities["credit_type"] = creditType
+	if contact != "" {
+		intent.Entities["contact"] = contact
+	}
+	if action == "ASK_DEBTS" {
+		return true
+	}
+	if amount := creditAmount(tokens); amount > 0 {
+		intent.Entities["amount"] = amount
+	} else if action == "RECORD_DEBT_PAYMENT" && settle {
+		intent.Entities["settle"] = true // "Bu Sari lunas"
+	}
+	if action == "RECORD_DEBT" && contact == "" && intent.Entities["amount"] == nil {
+		intent.Action = "ASK_DEBTS" // "kasbon", "utang"
+	}
+	if action == "RECORD_DEBT" {
+		if goods := creditGoods(tokens, contact); goods != "" {
+			creditTransaction(tokens, goods, intent)
+		}
+	}
+	return true
+}
+
+// creditGoods is what was sold or bought on credit, "" for money alone: the
+// product left once the contact and the credit words are taken out
+func creditGoods(tokens []string, contact string) string {
+	names := map[string]bool{}
+	for _, word := range strings.Fields(strings.ToLower(contact)) {
+		names[word] = true
+	}
+	var rest []string
+	for _, tok := range tokens {
+		if names[tok] || honorifics[tok] || debtWords[tok] || receivableWords[tok] || repayWords[tok] ||
+			counterpartyLeads[tok] || firstPersonWords[tok] {
+			continue
+		}
+		rest = append(rest, tok)
+	}
+	return extractRuleProduct(rest, -1)
+}
+
+// creditTransaction turns a RECORD_DEBT naming goods into the sale or
+// expense it records. Without a qty the amount is the price of one.
+func creditTransaction(tokens []string, goods string, intent *Intent) {
+	intent.Action = "RECORD_SALE"
+	intent.Sentiment = "positive"
+	if intent.Entities["credit_type"] == CreditPayable {
+		intent.Action = "RECORD_EXPENSE"
+		intent.Sentiment = "neutral"
+	}
+	delete(intent.Entities, "amount")
+	extractRuleNumbers(tokens, intent)
+	intent.Entities["product"] = goods
+	if _, ok := intent.Entities["qty"]; !ok {
+		intent.Entities["qty"] = 1.0
+	}
+}
+
+// creditContact finds the counterparty: after "ke"/"sama" for the user's own
+// debts, else the name before the keyword, else an addressed name after it
+// ("kasbon Bu Sari"). afterLead reports the first case. Words after a sale
+// or purchase verb are its goods, so "jual bakso ... ngutang Bu Sari" looks
+// for the name before "jual" only.
+func creditContact(tokens []string, keywordAt int) (name string, afterLead bool) {
+	for i := keywordAt + 1; i < len(tokens); i++ {
+		if counterpartyLeads[tokens[i]] {
+			if name := contactName(tokens, i+1, false); name != "" {
+				return name, true
+			}
+		}
+	}
+	before := keywordAt
+	for i := 0; i < keywordAt; i++ {
+		switch intentKeywords[tokens[i]] {
+		case "RECORD_SALE", "RECORD_EXPENSE", "ORDER_RESTOCK":
+			before = i
+		}
+	}
+	if name := contactName(tokens[:before], 0, false); name != "" {
+		return name, false
+	}
+	return contactName(tokens, keywordAt+1, true), false
+}
+
+// contactName collects the first run of name words from tokens[from:],
+// skipping leading non-name words. addressed requires an honorific first.
+func contactName(tokens []string, from int, addressed bool) string {
+	var words []string
+	named := false
+	for i := from; i < len(tokens); i++ {
+		tok := tokens[i]
+		isName := honorifics[tok] || !(isRuleNumber(tok) || ruleFillers[tok] || firstPersonWords[tok] ||
+			debtWords[tok] || receivableWords[tok] || repayWords[tok] || creditListWords[tok] ||
+			counterpartyLeads[tok] || questionWords[tok] || intentKeywords[tok] != "" || ruleUnits[tok] != "" ||
+			priceMarkers[tok] || perUnitMarkers[tok] || greetingWords[tok])
+		if !isName {
+			if len(words) > 0 {
+				break
+			}
+			continue
+		}
+		if len(words) == 0 && addressed && !honorifics[tok] {
+			return ""
+		}
+		if !honorifics[tok] {
+			named = true
+		} else if named {
+			break // "Bu Sari Pak Budi"
+		}
+		words = append(words, tok)
+	}
+	if !named {
+		return ""
+	}
+	for i, word := range words {
+		r, size := utf8.DecodeRuneInString(word)
+		words[i] = string(unicode.ToUpper(r)) + word[size:]
+	}
+	return strings.Join(words, " ")
+}
+
+// creditAmount returns the first number that can be an amount of money
+func creditAmount(tokens []string) float64 {
+	for _, tok := range tokens {
+		if !isRuleNumber(tok) {
+			continue
+		}
+		if value, err := strconv.ParseFloat(strings.ReplaceAll(tok, ",", "."), 64); err == nil && value >= 100 {
+			return value
+		}
+	}
+	return 0
+}
+
+// ContactKey returns a name without case and honorifics, so "Bu Sari",
+// "ibu sari" and "Sari" match the same contact
+func ContactKey(name string) string {
+	var words []string
+	for _, word := range strings.Fields(strings.ToLower(name)) {
+		if !honorifics[word] {
+			words = append(words, word)
+		}
+	}
+	return strings.Join(words, " ")
+}
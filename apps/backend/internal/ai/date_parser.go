@@ -2,109 +2,654 @@ package ai
 
 import (
 	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
 
-// ParseRelativeDate parses Indonesian relative date expressions
+// DateRange is an inclusive range of calendar days in the user's timezone
+type DateRange struct {
+	Start  time.Time // Midnight of the first day
+	End    time.Time // Midnight of the last day
+	Period string    // daily, weekly or monthly for today, this week and this month
+}
+
+// SingleDay reports whether the range covers one day
+func (r *DateRange) SingleDay() bool {
+	return r.Start.Equal(r.End)
+}
+
+// Days returns the number of days in the range
+func (r *DateRange) Days() int {
+	return int(r.End.Sub(r.Start).Hours()/24+0.5) + 1
+}
+
+// DefaultTimezone is used when a user has no timezone preference
+const DefaultTimezone = "Asia/Jakarta"
+
+// Indonesian zones work without tzdata in the container
+var indonesianZones = map[string]*time.Location{
+	"Asia/Jakarta":   time.FixedZone("WIB", 7*3600),
+	"Asia/Pontianak": time.FixedZone("WIB", 7*3600),
+	"Asia/Makassar":  time.FixedZone("WITA", 8*3600),
+	"Asia/Jayapura":  time.FixedZone("WIT", 9*3600),
+}
+
+// UserLocation returns the location for a UserPreferences.Timezone value,
+// falling back to WIB
+func UserLocation(tz string) *time.Location {
+	if tz == "" {
+		tz = DefaultTimezone
+	}
+	if loc, err := time.LoadLocation(tz); err == nil {
+		return loc
+	}
+	if loc, ok := indonesianZones[tz]; ok {
+		return loc
+	}
+	return indonesianZones[DefaultTimezone]
+}
+
+// ParseRelativeDate parses Indonesian relative date expressions. It returns
+// the first day of the expression at the current time of day.
 func ParseRelativeDate(text string) *time.Time {
 	now := time.Now()
-	normalized := strings.ToLower(strings.TrimSpace(text))
+	r := ParseDateRange(text, now)
+	if r == nil {
+		return nil
+	}
+	t := time.Date(r.Start.Year(), r.Start.Month(), r.Start.Day(), now.Hour(), now.Minute(), now.Second(), 0, now.Location())
+	return &t
+}
+
+var (
+	rangeConnector   = regexp.MustCompile(`\s(?:sampai|sampe|hingga|s/d|sd|nganti|ngantos|tekan|dugi ka|dugi|nepi ka|nepi|-|–)\s`)
+	betweenConnector = regexp.MustCompile(`\s(?:dan|karo|sareng|jeung)\s`)
+	rangeOpeners     = regexp.MustCompile(`^(?:.*\s)?(?:dari|mulai|saka|wiwit|ti|antara)\s`)
+	dayRangeDash     = regexp.MustCompile(`(?:tanggal|tgl|tanggale|kaping)\s*(\d{1,2})\s*[-–]\s*(\d{1,2})\b`)
+	numericDate      = regexp.MustCompile(`\b(\d{1,2})/(\d{1,2})(?:/(\d{2}|\d{4}))?\b(?:\s+([a-z]+))?`)
+	dayOfMonth       = regexp.MustCompile(`(?:tanggal|tgl|tanggale|kaping|ping)\s*(\d{1,2})\b(?:\s+([a-z]+)(?:\s+(\d{4}))?)?`)
+	dayMonthName     = regexp.MustCompile(`\b(\d{1,2})\s+([a-z]+)(?:\s+(\d{4}))?\b`)
+	monthNameYear    = regexp.MustCompile(`\b(?:bulan|sasi|wulan|sasih)?\s*([a-z]+)\s+(\d{4})\b`)
+	monthAlone       = regexp.MustCompile(`\b(?:bulan|sasi|wulan|sasih)\s+([a-z]+)\b`)
+	daysAgo          = regexp.MustCompile(`\b(\d{1,3})\s+(hari|dina|dinten|poe|minggu|pekan|bulan|sasi|wulan)\s+(?:yang\s+|sing\s+|nu\s+)?(lalu|kemarin|kemaren|kepungkur|kapungkur|ka pengker|terakhir|pungkasan|panungtung)\b`)
+	leadingDay       = regexp.MustCompile(`^\s*(?:tanggal\s+|tgl\s+)?(\d{1,2})(?:\s+([a-z]+))?`)
+	trailingDay      = regexp.MustCompile(`(?:^|\s)(?:tanggal\s+|tgl\s+)?(\d{1,2})\s*$`)
+	dateCleaner      = regexp.MustCompile(`[^\p{L}\d/\-–\s]+`)
+)
+
+var monthNames = map[string]time.Month{
+	"januari": time.January, "februari": time.February, "pebruari": time.February, "maret": time.March,
+	"april": time.April, "mei": time.May, "juni": time.June, "juli": time.July, "agustus": time.August,
+	"september": time.September, "oktober": time.October, "november": time.November, "nopember": time.November,
+	"desember": time.December,
+	"januwari": time.January, "pebruwari": time.February,
+}
+
+// Abbreviations only count next to a number ("5 jan", "des 2025")
+var monthAbbreviations = map[string]time.Month{
+	"jan": time.January, "feb": time.February, "peb": time.February, "mar": time.March, "apr": time.April,
+	"jun": time.June, "jul": time.July, "agu": time.August, "agt": time.August, "agst": time.August,
+	"sep": time.September, "sept": time.September, "okt": time.October, "nov": time.November,
+	"nop": time.November, "des": time.December,
+}
+
+// Lunar holidays follow the government calendar (SKB 3 Menteri); add new years here
+var lunarHolidays = map[string][]string{
+	"idul_fitri": {"2023-04-22", "2024-04-10", "2025-03-31", "2026-03-20", "2027-03-10"},
+	"idul_adha":  {"2023-06-29", "2024-06-17", "2025-06-06", "2026-05-27", "2027-05-17"},
+	"imlek":      {"2023-01-22", "2024-02-10", "2025-01-29", "2026-02-17", "2027-02-06"},
+}
+
+var fixedHolidays = map[string]struct {
+	month time.Month
+	day   int
+}{
+	"natal":      {time.December, 25},
+	"tahun_baru": {time.January, 1},
+	"agustusan":  {time.August, 17},
+}
+
+var holidayPhrases = map[string]string{
+	"lebaran": "idul_fitri", "idul fitri": "idul_fitri", "idulfitri": "idul_fitri", "riyaya": "idul_fitri",
+	"riyoyo": "idul_fitri", "bakda riyaya": "idul_fitri", "boboran": "idul_fitri", "lebaran haji": "idul_adha",
+	"idul adha": "idul_adha", "iduladha": "idul_adha", "riyaya haji": "idul_adha", "rayagung": "idul_adha",
+	"imlek": "imlek", "sincia": "imlek", "natal": "natal", "natalan": "natal", "tahun baru": "tahun_baru",
+	"taun anyar": "tahun_baru", "agustusan": "agustusan", "tujuh belasan": "agustusan", "17an": "agustusan",
+	"17 an": "agustusan",
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"senin": time.Monday, "senen": time.Monday, "selasa": time.Tuesday, "seloso": time.Tuesday,
+	"salasa": time.Tuesday, "rabu": time.Wednesday, "rebo": time.Wednesday, "kamis": time.Thursday,
+	"kemis": time.Thursday, "jumat": time.Friday, "jemuah": time.Friday,
+	"jumaah": time.Friday, "sabtu": time.Saturday, "setu": time.Saturday, "saptu": time.Saturday,
+	"ahad": time.Sunday, "hari minggu": time.Sunday, "dinten minggu": time.Sunday, "dina minggu": time.Sunday,
+}
+
+var (
+	pastModifiers   = []string{"kemarin", "kemaren", "lalu", "kmrn", "wingi", "kepungkur", "kamari", "kapungkur"}
+	futureModifiers = []string{"depan", "besok", "sesuk", "ngarep", "payun", "engke"}
+)
+
+type dateResolver func(now time.Time) *DateRange
+
+type datePhrase struct {
+	phrase  string
+	resolve dateResolver
+}
+
+// datePhrases is sorted longest first so "kemarin dulu" wins over "kemarin"
+var datePhrases = buildDatePhrases()
+
+func buildDatePhrases() []datePhrase {
+	var phrases []datePhrase
+	add := func(resolve dateResolver, words ...string) {
+		for _, w := range words {
+			phrases = append(phrases, datePhrase{w, resolve})
+		}
+	}
+
+	dayOffset := func(offset int) dateResolver {
+		return func(now time.Time) *DateRange {
+			r := singleDay(startOfDay(now).AddDate(0, 0, offset))
+			if offset == 0 {
+				r.Period = "daily"
+			}
+			return r
+		}
+	}
+	add(dayOffset(0), "hari ini", "hr ini", "dina iki", "dinten punika", "dinten ieu", "poe ieu", "today", "tadi pagi", "tadi siang")
+	add(dayOffset(-1), "kemarin", "kemaren", "kmrn", "wingi", "kamari", "semalam", "tadi malam", "kemarin malam", "yesterday")
+	add(dayOffset(-2), "kemarin dulu", "kemarin lusa", "kemaren dulu", "wingenane", "wingi kae", "mangkukna", "kamari ti heula")
+	add(dayOffset(1), "besok", "sesuk", "mbenjang", "isukan", "tomorrow")
+	add(dayOffset(2), "lusa", "sesuk lusa", "pageto")
 
-	// Today
-	if strings.Contains(normalized, "hari ini") || strings.Contains(normalized, "today") {
-		return &now
+	week := func(offset int) dateResolver {
+		return func(now time.Time) *DateRange {
+			start := startOfWeek(now).AddDate(0, 0, 7*offset)
+			if offset == 0 {
+				return &DateRange{Start: start, End: startOfDay(now), Period: "weekly"}
+			}
+			return &DateRange{Start: start, End: start.AddDate(0, 0, 6)}
+		}
 	}
+	add(week(0), "minggu ini", "pekan ini", "minggu iki", "minggon iki", "minggu ieu", "this week")
+	add(week(-1), "minggu lalu", "minggu kemarin", "minggu kemaren", "pekan lalu", "minggu wingi", "minggu kepungkur",
+		"minggu kamari", "minggu kapungkur", "last week")
+	add(week(1), "minggu depan", "pekan depan", "minggu ngarep", "minggu payun", "next week")
+	add(func(now time.Time) *DateRange {
+		saturday := startOfWeek(now).AddDate(0, 0, 5)
+		if saturday.After(startOfDay(now)) {
+			saturday = saturday.AddDate(0, 0, -7)
+		}
+		end := saturday.AddDate(0, 0, 1)
+		if end.After(startOfDay(now)) {
+			end = startOfDay(now)
+		}
+		return &DateRange{Start: saturday, End: end}
+	}, "akhir pekan", "akhir minggu", "weekend", "malam minggu")
 
-	// Yesterday
-	if strings.Contains(normalized, "kemarin") || strings.Contains(normalized, "yesterday") {
-		yesterday := now.AddDate(0, 0, -1)
-		return &yesterday
+	month := func(offset int) dateResolver {
+		return func(now time.Time) *DateRange {
+			start := startOfMonth(now).AddDate(0, offset, 0)
+			if offset == 0 {
+				return &DateRange{Start: start, End: startOfDay(now), Period: "monthly"}
+			}
+			return &DateRange{Start: start, End: start.AddDate(0, 1, -1)}
+		}
 	}
+	add(month(0), "bulan ini", "sasi iki", "wulan iki", "sasih punika", "bulan ieu", "sasih ieu", "this month")
+	add(month(-1), "bulan lalu", "bulan kemarin", "bulan kemaren", "sasi wingi", "sasi kepungkur", "wulan kepungkur",
+		"bulan kamari", "bulan kapungkur", "sasih kapengker", "last month")
+	add(month(1), "bulan depan", "sasi ngarep", "wulan ngarep", "bulan payun", "next month")
 
-	// Tomorrow
-	if strings.Contains(normalized, "besok") || strings.Contains(normalized, "tomorrow") {
-		tomorrow := now.AddDate(0, 0, 1)
-		return &tomorrow
+	// Thirds of the month; without "ini" a part still in the future means last month
+	monthPart := func(from, to int, explicitThisMonth bool) dateResolver {
+		return func(now time.Time) *DateRange {
+			start := startOfMonth(now)
+			if !explicitThisMonth && start.AddDate(0, 0, from-1).After(startOfDay(now)) {
+				start = start.AddDate(0, -1, 0)
+			}
+			end := start.AddDate(0, 0, to-1)
+			if to == 31 {
+				end = start.AddDate(0, 1, -1)
+			}
+			if end.After(startOfDay(now)) && !start.After(startOfDay(now)) {
+				end = startOfDay(now)
+			}
+			return &DateRange{Start: start.AddDate(0, 0, from-1), End: end}
+		}
 	}
+	for _, part := range []struct {
+		from, to int
+		words    []string
+	}{
+		{1, 10, []string{"awal bulan", "awal sasi", "awal wulan", "awal sasih"}},
+		{11, 20, []string{"tengah bulan", "pertengahan bulan", "tengah sasi", "tengah wulan", "pertengahan sasi"}},
+		{21, 31, []string{"akhir bulan", "ahir bulan", "akhir sasi", "akhir wulan", "ahir sasi", "tanggal tua"}},
+	} {
+		add(monthPart(part.from, part.to, false), part.words...)
+		for _, w := range part.words {
+			add(monthPart(part.from, part.to, true), w+" ini", w+" iki", w+" ieu")
+			lastMonth := monthPart(part.from, part.to, true)
+			add(func(now time.Time) *DateRange {
+				return lastMonth(startOfMonth(now).AddDate(0, 0, -1))
+			}, w+" lalu", w+" kemarin", w+" wingi", w+" kepungkur", w+" kamari")
+		}
+	}
+
+	add(func(now time.Time) *DateRange {
+		return &DateRange{Start: time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, now.Location()), End: startOfDay(now)}
+	}, "tahun ini", "taun iki", "taun ieu", "this year")
+	add(func(now time.Time) *DateRange {
+		start := time.Date(now.Year()-1, time.January, 1, 0, 0, 0, 0, now.Location())
+		return &DateRange{Start: start, End: start.AddDate(1, 0, -1)}
+	}, "tahun lalu", "tahun kemarin", "taun wingi", "taun kepungkur", "taun kamari", "last year")
 
-	// Day before yesterday
-	if strings.Contains(normalized, "kemarin dulu") || strings.Contains(normalized, "kemarin lusa") {
-		dayBefore := now.AddDate(0, 0, -2)
-		return &dayBefore
+	for phrase, holiday := range holidayPhrases {
+		add(holidayResolver(holiday, false), phrase)
+		add(holidayResolver(holiday, true), phrase+" depan", phrase+" besok", phrase+" sesuk", phrase+" engke")
 	}
 
-	// Day after tomorrow
-	if strings.Contains(normalized, "lusa") {
-		dayAfter := now.AddDate(0, 0, 2)
-		return &dayAfter
+	for name, weekday := range weekdayNames {
+		add(weekdayResolver(weekday, false), name)
+		for _, m := range pastModifiers {
+			add(weekdayResolver(weekday, false), name+" "+m)
+		}
+		for _, m := range futureModifiers {
+			add(weekdayResolver(weekday, true), name+" "+m)
+		}
 	}
+	sort.SliceStable(phrases, func(i, j int) bool {
+		return len(phrases[i].phrase) > len(phrases[j].phrase)
+	})
+	return phrases
+}
+
+// ParseDateRange finds a date or date range in free text, resolved
+// relative to now and in now's location. Returns nil when there is none.
+func ParseDateRange(text string, now time.Time) *DateRange {
+	text = strings.ToLower(NormalizeText(text))
+	text = " " + strings.Join(strings.Fields(dateCleaner.ReplaceAllString(text, " ")), " ") + " "
 
-	// This week
-	if strings.Contains(normalized, "minggu ini") || strings.Contains(normalized, "this week") {
-		return &now
+	// "tanggal 1-15"
+	if m := dayRangeDash.FindStringSubmatch(text); m != nil {
+		from, _ := strconv.Atoi(m[1])
+		to, _ := strconv.Atoi(m[2])
+		if r := dayRangeInMonth(from, to, now); r != nil {
+			return r
+		}
 	}
 
-	// Last week
-	if strings.Contains(normalized, "minggu lalu") || strings.Contains(normalized, "last week") {
-		lastWeek := now.AddDate(0, 0, -7)
-		return &lastWeek
+	// "dari tanggal 1 sampai 15", "senin sampai rabu", "antara 1 dan 5 maret"
+	loc := rangeConnector.FindStringIndex(text)
+	if loc == nil && strings.Contains(text, " antara ") {
+		loc = betweenConnector.FindStringIndex(text)
+	}
+	if loc != nil {
+		left := rangeOpeners.ReplaceAllString(text[:loc[0]], "")
+		right := text[loc[1]:]
+		if r := parseRange(left, right, now); r != nil {
+			return r
+		}
 	}
 
-	// Next week
-	if strings.Contains(normalized, "minggu depan") || strings.Contains(normalized, "next week") {
-		nextWeek := now.AddDate(0, 0, 7)
-		return &nextWeek
+	return parseDateExpr(text, now)
+}
+
+// parseRange combines both sides of "X sampai Y"; a bare day on one side
+// borrows the month from the other, or the month before or after it when
+// the range crosses a month ("tgl 25 sampai 3 nov")
+func parseRange(left, right string, now time.Time) *DateRange {
+	from := parseDateExpr(left, now)
+	to := parseDateExpr(right, now)
+
+	// "tanggal 1 sampai 15 maret"
+	if m := trailingDay.FindStringSubmatch(left); m != nil && to != nil {
+		day, _ := strconv.Atoi(m[1])
+		month := to.Start
+		if day > month.Day() {
+			month = startOfMonth(month).AddDate(0, -1, 0)
+		}
+		from = singleDayInMonth(month.Year(), month.Month(), day, now.Location())
+	}
+	// "tanggal 1 sampai 15"
+	if m := leadingDay.FindStringSubmatch(right); m != nil && from != nil {
+		if _, isMonth := lookupMonth(m[2], true); !isMonth {
+			day, _ := strconv.Atoi(m[1])
+			month := from.Start
+			if day < month.Day() {
+				month = startOfMonth(month).AddDate(0, 1, 0)
+			}
+			to = singleDayInMonth(month.Year(), month.Month(), day, now.Location())
+		}
+	}
+	if from == nil || to == nil {
+		return nil
 	}
 
-	// This month
-	if strings.Contains(normalized, "bulan ini") || strings.Contains(normalized, "this month") {
-		return &now
+	start, end := from.Start, to.End
+	if end.Before(start) {
+		if to.SingleDay() && start.Sub(end) < 7*24*time.Hour {
+			end = end.AddDate(0, 0, 7) // "kamis sampai senin" wraps the weekend
+		} else {
+			start, end = to.Start, from.End
+		}
 	}
+	return &DateRange{Start: start, End: end}
+}
 
-	// Last month
-	if strings.Contains(normalized, "bulan lalu") || strings.Contains(normalized, "last month") {
-		lastMonth := now.AddDate(0, -1, 0)
-		return &lastMonth
+// numericDateMatches returns the submatch indexes of the N/M dates in text.
+// N/M is a date when it has a year ("5/3/2025"), follows tanggal ("tgl 1/2")
+// or is a valid day and month not followed by a unit or price word; "2 kilo
+// 1/2 harga" and "1/2 kg" are fractions.
+func numericDateMatches(text string) [][]int {
+	var dates [][]int
+	for _, m := range numericDate.FindAllStringSubmatchIndex(text, -1) {
+		day, _ := strconv.Atoi(text[m[2]:m[3]])
+		month, _ := strconv.Atoi(text[m[4]:m[5]])
+		if day < 1 || day > 31 || month < 1 || month > 12 {
+			continue
+		}
+		before := strings.Fields(text[:m[0]])
+		prefixed := len(before) > 0 && dateWords[before[len(before)-1]]
+		next := ""
+		if m[8] >= 0 {
+			next = text[m[8]:m[9]]
+		}
+		if m[6] < 0 && !prefixed && (ruleUnits[next] != "" || priceWords[next]) {
+			continue
+		}
+		dates = append(dates, m)
 	}
+	return dates
+}
 
-	// Next month
-	if strings.Contains(normalized, "bulan depan") || strings.Contains(normalized, "next month") {
-		nextMonth := now.AddDate(0, 1, 0)
-		return &nextMonth
+// withoutNumericDates blanks the N/M dates of text, keeping fractions
+func withoutNumericDates(text string) string {
+	dates := numericDateMatches(text)
+	for i := len(dates) - 1; i >= 0; i-- {
+		m := dates[i]
+		end := m[5]
+		if m[6] >= 0 {
+			end = m[7]
+		}
+		text = text[:m[0]] + text[end:]
 	}
+	return text
+}
 
-	// Specific days of week (Indonesian)
-	dayMap := map[string]time.Weekday{
-		"senin":  time.Monday,
-		"selasa": time.Tuesday,
-		"rabu":   time.Wednesday,
-		"kamis":  time.Thursday,
-		"jumat":  time.Friday,
-		"sabtu":  time.Saturday,
-		"minggu": time.Sunday,
+// parseDateExpr parses a single date expression in cleaned, lowercase text
+func parseDateExpr(text string, now time.Time) *DateRange {
+	loc := now.Location()
+	text = " " + strings.TrimSpace(text) + " "
+
+	// "5/3", "5/3/2025"
+	if dates := numericDateMatches(text); len(dates) > 0 {
+		m := dates[0]
+		day, _ := strconv.Atoi(text[m[2]:m[3]])
+		month, _ := strconv.Atoi(text[m[4]:m[5]])
+		yearText := ""
+		if m[6] >= 0 {
+			yearText = text[m[6]:m[7]]
+		}
+		year := resolveYear(yearText, time.Month(month), day, now)
+		if r := singleDayInMonth(year, time.Month(month), day, loc); r != nil {
+			return r
+		}
 	}
 
-	for dayName, targetDay := range dayMap {
-		if strings.Contains(normalized, dayName) {
-			// Find the most recent occurrence of this day
-			currentDay := now.Weekday()
-			daysAgo := int(currentDay - targetDay)
-			if daysAgo < 0 {
-				daysAgo += 7
+	// "tanggal 5", "tanggal 5 maret", "tgl 5 mar 2025"
+	if m := dayOfMonth.FindStringSubmatch(text); m != nil {
+		day, _ := strconv.Atoi(m[1])
+		if month, ok := lookupMonth(m[2], true); ok {
+			return singleDayInMonth(resolveYear(m[3], month, day, now), month, day, loc)
+		}
+		return dayThisOrLastMonth(day, now)
+	}
+
+	// "5 maret", "17 agustus 2025"
+	for _, m := range dayMonthName.FindAllStringSubmatch(text, -1) {
+		day, _ := strconv.Atoi(m[1])
+		if month, ok := lookupMonth(m[2], true); ok {
+			if r := singleDayInMonth(resolveYear(m[3], month, day, now), month, day, loc); r != nil {
+				return r
 			}
-			if daysAgo == 0 {
-				daysAgo = 7 // Last week's same day
+		}
+	}
+
+	// "3 hari lalu", "2 minggu terakhir"
+	if m := daysAgo.FindStringSubmatch(text); m != nil {
+		if r := relativeAgo(m[1], m[2], m[3], now); r != nil {
+			return r
+		}
+	}
+
+	// Phrases, longest first
+	for _, p := range datePhrases {
+		if strings.Contains(text, " "+p.phrase+" ") {
+			if r := p.resolve(now); r != nil {
+				return r
 			}
-			targetDate := now.AddDate(0, 0, -daysAgo)
-			return &targetDate
 		}
 	}
 
-	// No match found
+	// "maret 2025", "bulan maret"
+	if m := monthNameYear.FindStringSubmatch(text); m != nil {
+		if month, ok := lookupMonth(m[1], true); ok {
+			year, _ := strconv.Atoi(m[2])
+			return wholeMonth(year, month, now)
+		}
+	}
+	if m := monthAlone.FindStringSubmatch(text); m != nil {
+		if month, ok := lookupMonth(m[1], false); ok {
+			return wholeMonth(resolveYear("", month, 1, now), month, now)
+		}
+	}
+
 	return nil
 }
 
+func relativeAgo(nStr, unit, direction string, now time.Time) *DateRange {
+	n, err := strconv.Atoi(nStr)
+	if err != nil || n <= 0 {
+		return nil
+	}
+	today := startOfDay(now)
+	days := n
+	switch unit {
+	case "minggu", "pekan":
+		days = 7 * n
+	case "bulan", "sasi", "wulan":
+		start := today.AddDate(0, -n, 0)
+		if direction == "terakhir" || direction == "pungkasan" || direction == "panungtung" {
+			return &DateRange{Start: start.AddDate(0, 0, 1), End: today}
+		}
+		return singleDay(start)
+	}
+	if direction == "terakhir" || direction == "pungkasan" || direction == "panungtung" {
+		return &DateRange{Start: today.AddDate(0, 0, -days+1), End: today}
+	}
+	return singleDay(today.AddDate(0, 0, -days))
+}
+
+func lookupMonth(word string, allowAbbreviation bool) (time.Month, bool) {
+	if month, ok := monthNames[word]; ok {
+		return month, true
+	}
+	if allowAbbreviation {
+		month, ok := monthAbbreviations[word]
+		return month, ok
+	}
+	return 0, false
+}
+
+// resolveYear uses the explicit year, or the most recent year in which the
+// date is not in the future
+func resolveYear(yearStr string, month time.Month, day int, now time.Time) int {
+	if yearStr != "" {
+		year, _ := strconv.Atoi(yearStr)
+		if year < 100 {
+			year += 2000
+		}
+		return year
+	}
+	if time.Date(now.Year(), month, day, 0, 0, 0, 0, now.Location()).After(startOfDay(now)) {
+		return now.Year() - 1
+	}
+	return now.Year()
+}
+
+func wholeMonth(year int, month time.Month, now time.Time) *DateRange {
+	start := time.Date(year, month, 1, 0, 0, 0, 0, now.Location())
+	end := start.AddDate(0, 1, -1)
+	if end.After(startOfDay(now)) && !start.After(startOfDay(now)) {
+		end = startOfDay(now)
+	}
+	return &DateRange{Start: start, End: end}
+}
+
+// dayThisOrLastMonth resolves "tanggal 20" to this month, or last month when
+// the 20th hasn't come yet
+func dayThisOrLastMonth(day int, now time.Time) *DateRange {
+	r := singleDayInMonth(now.Year(), now.Month(), day, now.Location())
+	if r == nil || r.Start.After(startOfDay(now)) {
+		prev := startOfMonth(now).AddDate(0, -1, 0)
+		return singleDayInMonth(prev.Year(), prev.Month(), day, now.Location())
+	}
+	return r
+}
+
+func dayRangeInMonth(from, to int, now time.Time) *DateRange {
+	start := dayThisOrLastMonth(from, now)
+	if start == nil {
+		return nil
+	}
+	end := singleDayInMonth(start.Start.Year(), start.Start.Month(), to, now.Location())
+	if end == nil || end.Start.Before(start.Start) {
+		return nil
+	}
+	return &DateRange{Start: start.Start, End: end.End}
+}
+
+// singleDayInMonth returns nil for days that don't exist, like 31 Februari
+func singleDayInMonth(year int, month time.Month, day int, loc *time.Location) *DateRange {
+	if day < 1 || day > 31 {
+		return nil
+	}
+	t := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	if t.Month() != month {
+		return nil
+	}
+	return singleDay(t)
+}
+
+func holidayResolver(holiday string, upcoming bool) dateResolver {
+	return func(now time.Time) *DateRange {
+		today := startOfDay(now)
+		var dates []time.Time
+		if fixed, ok := fixedHolidays[holiday]; ok {
+			for year := now.Year() - 1; year <= now.Year()+1; year++ {
+				dates = append(dates, time.Date(year, fixed.month, fixed.day, 0, 0, 0, 0, now.Location()))
+			}
+		}
+		for _, d := range lunarHolidays[holiday] {
+			t, err := time.ParseInLocation("2006-01-02", d, now.Location())
+			if err == nil {
+				dates = append(dates, t)
+			}
+		}
+
+		// Most recent past occurrence, or the next one for "lebaran depan"
+		var best *time.Time
+		for i := range dates {
+			d := dates[i]
+			if upcoming && d.After(today) && (best == nil || d.Before(*best)) {
+				best = &d
+			}
+			if !upcoming && !d.After(today) && (best == nil || d.After(*best)) {
+				best = &d
+			}
+		}
+		if best == nil {
+			return nil
+		}
+		return singleDay(*best)
+	}
+}
+
+func weekdayResolver(target time.Weekday, upcoming bool) dateResolver {
+	return func(now time.Time) *DateRange {
+		today := startOfDay(now)
+		diff := int(today.Weekday() - target)
+		if upcoming {
+			ahead := (7 - diff) % 7
+			if ahead == 0 {
+				ahead = 7
+			}
+			return singleDay(today.AddDate(0, 0, ahead))
+		}
+		if diff <= 0 {
+			diff += 7 // Today's weekday means last week's
+		}
+		return singleDay(today.AddDate(0, 0, -diff))
+	}
+}
+
+func singleDay(t time.Time) *DateRange {
+	return &DateRange{Start: t, End: t}
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// startOfWeek returns Monday of t's week
+func startOfWeek(t time.Time) time.Time {
+	weekday := int(t.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	return startOfDay(t).AddDate(0, 0, -(weekday - 1))
+}
+
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// AddDateEntities resolves date words in the message into date entities:
+// start_date and end_date (YYYY-MM-DD), plus date and occurred_at
+// (RFC3339, at now's time of day) for a single day. Report requests also
+// get the matching period.
+func AddDateEntities(intent *Intent, now time.Time) *DateRange {
+	r := ParseDateRange(intent.RawText, now)
+	if r == nil {
+		return nil
+	}
+	if intent.Entities == nil {
+		intent.Entities = map[string]any{}
+	}
+
+	intent.Entities["start_date"] = r.Start.Format("2006-01-02")
+	intent.Entities["end_date"] = r.End.Format("2006-01-02")
+	if r.SingleDay() {
+		intent.Entities["date"] = r.Start.Format("2006-01-02")
+		occurred := time.Date(r.Start.Year(), r.Start.Month(), r.Start.Day(), now.Hour(), now.Minute(), now.Second(), 0, now.Location())
+		intent.Entities["occurred_at"] = occurred.Format(time.RFC3339)
+	}
+	if intent.Action == "REQUEST_REPORT" {
+		period := r.Period
+		if period == "" {
+			period = "custom"
+		}
+		intent.Entities["period"] = period
+	}
+	return r
+}
+
 // FormatDate formats date for Indonesian display
 func FormatDate(t time.Time) string {
 	months := []string{
@@ -115,6 +660,27 @@ func FormatDate(t time.Time) string {
 	return fmt.Sprintf("%d %s %d", t.Day(), months[t.Month()], t.Year())
 }
 
+// FormatDateRange formats a range compactly: "1 - 15 Oktober 2026",
+// "September 2026", "28 September - 4 Oktober 2026"
+func FormatDateRange(r *DateRange) string {
+	if r.SingleDay() {
+		return FormatDate(r.Start)
+	}
+
+	start, end := FormatDate(r.Start), FormatDate(r.End)
+	sameMonth := r.Start.Year() == r.End.Year() && r.Start.Month() == r.End.Month()
+	if sameMonth && r.Start.Day() == 1 && r.End.AddDate(0, 0, 1).Day() == 1 {
+		return strings.SplitN(start, " ", 2)[1]
+	}
+	if sameMonth {
+		return fmt.Sprintf("%d - %s", r.Start.Day(), end)
+	}
+	if r.Start.Year() == r.End.Year() {
+		return fmt.Sprintf("%s - %s", strings.TrimSuffix(start, fmt.Sprintf(" %d", r.Start.Year())), end)
+	}
+	return fmt.Sprintf("%s - %s", start, end)
+}
+
 // FormatDateShort formats date in short format
 func FormatDateShort(t time.Time) string {
 	return t.Format("2 Jan 2006")
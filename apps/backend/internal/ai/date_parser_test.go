@@ -0,0 +1,152 @@
+package ai
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDateRange(t *testing.T) {
+	wib := UserLocation("Asia/Jakarta")
+	// Wednesday
+	now := time.Date(2026, time.October, 14, 10, 30, 0, 0, wib)
+
+	tests := []struct {
+		text   string
+		start  string
+		end    string
+		period string
+	}{
+		{"laporan hari ini", "2026-10-14", "2026-10-14", "daily"},
+		{"kemarin laku bakso 10 mangkok", "2026-10-13", "2026-10-13", ""},
+		{"kemarin dulu beli gas", "2026-10-12", "2026-10-12", ""},
+		{"wingi payu soto", "2026-10-13", "2026-10-13", ""},
+		{"kamari meuli beas", "2026-10-13", "2026-10-13", ""},
+		{"wingenane", "2026-10-12", "2026-10-12", ""},
+		{"sesuk", "2026-10-15", "2026-10-15", ""},
+		{"lusa", "2026-10-16", "2026-10-16", ""},
+		{"3 hari lalu", "2026-10-11", "2026-10-11", ""},
+		{"7 hari terakhir", "2026-10-08", "2026-10-14", ""},
+		{"laporan minggu ini", "2026-10-12", "2026-10-14", "weekly"},
+		{"minggu lalu", "2026-10-05", "2026-10-11", ""},
+		{"minggu kemarin", "2026-10-05", "2026-10-11", ""},
+		{"rekap sasi iki", "2026-10-01", "2026-10-14", "monthly"},
+		{"bulan lalu", "2026-09-01", "2026-09-30", ""},
+		{"Senin kemarin", "2026-10-12", "2026-10-12", ""},
+		{"rabu", "2026-10-07", "2026-10-07", ""},
+		{"jumat depan", "2026-10-16", "2026-10-16", ""},
+		{"hari minggu", "2026-10-11", "2026-10-11", ""},
+		{"tanggal 5", "2026-10-05", "2026-10-05", ""},
+		{"tgl 20", "2026-09-20", "2026-09-20", ""},
+		{"tanggal 5 maret", "2026-03-05", "2026-03-05", ""},
+		{"17 agustus 2025", "2025-08-17", "2025-08-17", ""},
+		{"5/3", "2026-03-05", "2026-03-05", ""},
+		{"31/12/2025", "2025-12-31", "2025-12-31", ""},
+		{"tgl 1/2", "2026-02-01", "2026-02-01", ""},
+		{"5/3 laku bakso 10 mangkok", "2026-03-05", "2026-03-05", ""},
+		{"beli gula 2 kilo 1/2 harga", "", "", ""},
+		{"beli cabai 1/2 kg", "", "", ""},
+		{"1/2 porsi soto", "", "", ""},
+		{"tanggal 31 februari", "", "", ""},
+		{"awal bulan", "2026-10-01", "2026-10-10", ""},
+		{"tengah bulan", "2026-10-11", "2026-10-14", ""},
+		{"akhir bulan", "2026-09-21", "2026-09-30", ""},
+		{"akhir bulan lalu", "2026-09-21", "2026-09-30", ""},
+		{"pas Lebaran", "2026-03-20", "2026-03-20", ""},
+		{"natal", "2025-12-25", "2025-12-25", ""},
+		{"bulan maret", "2026-03-01", "2026-03-31", ""},
+		{"desember 2025", "2025-12-01", "2025-12-31", ""},
+		{"tahun lalu", "2025-01-01", "2025-12-31", ""},
+		{"laporan dari tanggal 1 sampai 15", "2026-10-01", "2026-10-15", ""},
+		{"laporan tanggal 1-10", "2026-10-01", "2026-10-10", ""},
+		{"dari tanggal satu sampai lima belas", "2026-10-01", "2026-10-15", ""},
+		{"1 sampai 15 maret", "2026-03-01", "2026-03-15", ""},
+		{"dari senin sampai rabu", "2026-10-12", "2026-10-14", ""},
+		{"kamis sampai senin", "2026-10-08", "2026-10-12", ""},
+		{"saka tanggal 1 nganti 10", "2026-10-01", "2026-10-10", ""},
+		{"ti tanggal 1 dugi ka 10", "2026-10-01", "2026-10-10", ""},
+		{"antara 1 dan 5 maret", "2026-03-01", "2026-03-05", ""},
+		{"tgl 25 sampai 3 nov", "2025-10-25", "2025-11-03", ""},
+		{"tanggal 25 sampai 3", "2026-09-25", "2026-10-03", ""},
+		{"bakda magrib laku bakso 10 mangkok", "", "", ""},
+		{"laku nasi rames 15 porsi 12rb", "", "", ""},
+		{"laporan minggu", "", "", ""},
+		{"suara serak", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			r := ParseDateRange(tt.text, now)
+			if tt.start == "" {
+				if r != nil {
+					t.Fatalf("Expected no date, got %s..%s", r.Start.Format("2006-01-02"), r.End.Format("2006-01-02"))
+				}
+				return
+			}
+			if r == nil {
+				t.Fatal("Expected a date range, got nil")
+			}
+			if got := r.Start.Format("2006-01-02"); got != tt.start {
+				t.Errorf("Start = %s, want %s", got, tt.start)
+			}
+			if got := r.End.Format("2006-01-02"); got != tt.end {
+				t.Errorf("End = %s, want %s", got, tt.end)
+			}
+			if r.Period != tt.period {
+				t.Errorf("Period = %q, want %q", r.Period, tt.period)
+			}
+			if r.Start.Location() != wib {
+				t.Errorf("Location = %v, want WIB", r.Start.Location())
+			}
+		})
+	}
+}
+
+func TestParseDateRange_Timezone(t *testing.T) {
+	// 23:30 UTC on the 13th is already the 14th in Jayapura
+	utc := time.Date(2026, time.October, 13, 23, 30, 0, 0, time.UTC)
+	r := ParseDateRange("hari ini", utc.In(UserLocation("Asia/Jayapura")))
+	if got := r.Start.Format("2006-01-02"); got != "2026-10-14" {
+		t.Errorf("Start = %s, want 2026-10-14", got)
+	}
+	if UserLocation("Not/AZone") == nil {
+		t.Error("Unknown timezone should fall back to WIB")
+	}
+}
+
+func TestAddDateEntities(t *testing.T) {
+	now := time.Date(2026, time.October, 14, 10, 30, 0, 0, UserLocation(""))
+
+	sale := &Intent{Action: "RECORD_SALE", Entities: map[string]any{}, RawText: "kemarin laku bakso 5 mangkok"}
+	AddDateEntities(sale, now)
+	if sale.Entities["date"] != "2026-10-13" || sale.Entities["occurred_at"] != "2026-10-13T10:30:00+07:00" {
+		t.Errorf("Unexpected sale dates: %v", sale.Entities)
+	}
+	if sale.Entities["period"] != nil {
+		t.Error("Only reports get a period")
+	}
+
+	report := &Intent{Action: "REQUEST_REPORT", Entities: map[string]any{"period": "daily"}, RawText: "laporan dari tanggal 1 sampai 15"}
+	AddDateEntities(report, now)
+	if report.Entities["start_date"] != "2026-10-01" || report.Entities["end_date"] != "2026-10-15" ||
+		report.Entities["period"] != "custom" || report.Entities["date"] != nil {
+		t.Errorf("Unexpected report dates: %v", report.Entities)
+	}
+}
+
+func TestFormatDateRange(t *testing.T) {
+	day := func(m time.Month, d int) time.Time { return time.Date(2026, m, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		r    DateRange
+		want string
+	}{
+		{DateRange{Start: day(10, 1), End: day(10, 1)}, "1 Oktober 2026"},
+		{DateRange{Start: day(10, 1), End: day(10, 15)}, "1 - 15 Oktober 2026"},
+		{DateRange{Start: day(9, 1), End: day(9, 30)}, "September 2026"},
+		{DateRange{Start: day(9, 28), End: day(10, 4)}, "28 September - 4 Oktober 2026"},
+	}
+	for _, tt := range tests {
+		if got := FormatDateRange(&tt.r); got != tt.want {
+			t.Errorf("FormatDateRange = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,374 @@
+package ai
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// EvalCase is one labelled utterance in the intent corpus
+type EvalCase struct {
+	Text     string   `json:"text"`
+	Expected Intent   `json:"expected"`
+	Tags     []string `json:"tags,omitempty"` // slang, number_words, ...
+}
+
+// LoadEvalCorpus reads a JSONL corpus, one EvalCase per line
+func LoadEvalCorpus(path string) ([]EvalCase, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var cases []EvalCase
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" || strings.HasPrefix(raw, "//") {
+			continue
+		}
+		var c EvalCase
+		if err := json.Unmarshal([]byte(raw), &c); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		cases = append(cases, c)
+	}
+	return cases, scanner.Err()
+}
+
+// IntentExtractor is anything that turns text into an Intent
+type IntentExtractor func(ctx context.Context, text string) (*Intent, error)
+
+// LLMExtractor evaluates the prompt and model only, without the rule parser
+func LLMExtractor(llm Provider) IntentExtractor {
+	return func(ctx context.Context, text string) (*Intent, error) {
+		return ExtractIntent(ctx, llm, NormalizeText(text))
+	}
+}
+
+// RuleExtractor evaluates the offline rule parser
+func RuleExtractor() IntentExtractor {
+	return func(ctx context.Context, text string) (*Intent, error) {
+		intent, _ := ParseIntentRules(NormalizeText(text))
+		return intent, nil
+	}
+}
+
+// IntentScore holds precision and recall for one intent
+type IntentScore struct {
+	Support   int     `json:"support"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+
+	tp, fp, fn int
+}
+
+// EvalMiss records a case the extractor got wrong
+type EvalMiss struct {
+	Text     string
+	Expected string
+	Got      string
+	Entities []string // Entity keys that didn't match
+	Err      error
+}
+
+// EvalReport summarises one run over the corpus
+type EvalReport struct {
+	Cases          int                     `json:"cases"`
+	Accuracy       float64                 `json:"accuracy"`
+	MacroF1        float64                 `json:"macro_f1"`
+	EntityAccuracy float64                 `json:"entity_accuracy"`
+	Intents        map[string]*IntentScore `json:"intents"`
+	Entities       map[string]float64      `json:"entities"` // Accuracy per entity key
+	Misses         []EvalMiss              `json:"-"`
+}
+
+// EvaluateIntents runs every case through extract and scores the results.
+// Extractor errors count as UNKNOWN predictions.
+func EvaluateIntents(ctx context.Context, cases []EvalCase, extract IntentExtractor) *EvalReport {
+	report := &EvalReport{
+		Cases:    len(cases),
+		Intents:  map[string]*IntentScore{},
+		Entities: map[string]float64{},
+	}
+	score := func(action string) *IntentScore {
+		if report.Intents[action] == nil {
+			report.Intents[action] = &IntentScore{}
+		}
+		return report.Intents[action]
+	}
+
+	correct := 0
+	entityHits, entityTotal := map[string]int{}, map[string]int{}
+
+	for _, c := range cases {
+		got, err := extract(ctx, c.Text)
+		if err != nil || got == nil {
+			got = &Intent{Action: "UNKNOWN", Entities: map[string]any{}}
+		}
+
+		want := c.Expected.Action
+		score(want).Support++
+		if got.Action == want {
+			correct++
+			score(want).tp++
+		} else {
+			score(want).fn++
+			score(got.Action).fp++
+		}
+
+		var wrongEntities []string
+		for key, expected := range c.Expected.Entities {
+			entityTotal[key]++
+			if entityMatches(expected, got.Entities[key]) {
+				entityHits[key]++
+			} else {
+				wrongEntities = append(wrongEntities, key)
+			}
+		}
+		sort.Strings(wrongEntities)
+
+		if got.Action != want || len(wrongEntities) > 0 || err != nil {
+			report.Misses = append(report.Misses, EvalMiss{
+				Text: c.Text, Expected: want, Got: got.Action, Entities: wrongEntities, Err: err,
+			})
+		}
+	}
+
+	if len(cases) > 0 {
+		report.Accuracy = round3(float64(correct) / float64(len(cases)))
+	}
+
+	var f1Sum float64
+	labelled := 0
+	for _, s := range report.Intents {
+		if s.tp+s.fp > 0 {
+			s.Precision = round3(float64(s.tp) / float64(s.tp+s.fp))
+		}
+		if s.Support > 0 {
+			s.Recall = round3(float64(s.tp) / float64(s.Support))
+			labelled++
+		}
+		if s.Precision+s.Recall > 0 {
+			s.F1 = round3(2 * s.Precision * s.Recall / (s.Precision + s.Recall))
+		}
+		if s.Support > 0 {
+			f1Sum += s.F1
+		}
+	}
+	if labelled > 0 {
+		report.MacroF1 = round3(f1Sum / float64(labelled))
+	}
+
+	hits, total := 0, 0
+	for key, n := range entityTotal {
+		report.Entities[key] = round3(float64(entityHits[key]) / float64(n))
+		hits += entityHits[key]
+		total += n
+	}
+	if total > 0 {
+		report.EntityAccuracy = round3(float64(hits) / float64(total))
+	}
+	return report
+}
+
+// entityMatches compares numbers by value and strings case-insensitively
+func entityMatches(expected, got any) bool {
+	if got == nil {
+		return false
+	}
+	if e, ok := toFloat(expected); ok {
+		g, ok := toFloat(got)
+		return ok && math.Abs(e-g) < 0.001
+	}
+	return strings.EqualFold(strings.TrimSpace(fmt.Sprint(expected)), strings.TrimSpace(fmt.Sprint(got)))
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func round3(f float64) float64 {
+	return math.Round(f*1000) / 1000
+}
+
+// String formats the report as a table for test logs
+func (r *EvalReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "cases=%d accuracy=%.3f macro_f1=%.3f entity_accuracy=%.3f\n",
+		r.Cases, r.Accuracy, r.MacroF1, r.EntityAccuracy)
+
+	fmt.Fprintf(&b, "%-16s %7s %9s %7s %7s\n", "intent", "support", "precision", "recall", "f1")
+	for _, name := range sortedKeys(r.Intents) {
+		s := r.Intents[name]
+		fmt.Fprintf(&b, "%-16s %7d %9.3f %7.3f %7.3f\n", name, s.Support, s.Precision, s.Recall, s.F1)
+	}
+
+	fmt.Fprintf(&b, "%-16s %8s\n", "entity", "accuracy")
+	for _, key := range sortedKeys(r.Entities) {
+		fmt.Fprintf(&b, "%-16s %8.3f\n", key, r.Entities[key])
+	}
+
+	for _, m := range r.Misses {
+		fmt.Fprintf(&b, "✗ %q: want %s, got %s", m.Text, m.Expected, m.Got)
+		if len(m.Entities) > 0 {
+			fmt.Fprintf(&b, " (entities: %s)", strings.Join(m.Entities, ", "))
+		}
+		if m.Err != nil {
+			fmt.Fprintf(&b, " (error: %v)", m.Err)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Regressions lists the scores that dropped more than tolerance below baseline
+func (r *EvalReport) Regressions(baseline *EvalReport, tolerance float64) []string {
+	var out []string
+	check := func(name string, got, want float64) {
+		if got < want-tolerance {
+			out = append(out, fmt.Sprintf("%s dropped from %.3f to %.3f", name, want, got))
+		}
+	}
+
+	check("accuracy", r.Accuracy, baseline.Accuracy)
+	check("macro_f1", r.MacroF1, baseline.MacroF1)
+	check("entity_accuracy", r.EntityAccuracy, baseline.EntityAccuracy)
+	for _, name := range sortedKeys(baseline.Intents) {
+		got := 0.0
+		if s := r.Intents[name]; s != nil {
+			got = s.F1
+		}
+		check(name+" f1", got, baseline.Intents[name].F1)
+	}
+	for _, key := range sortedKeys(baseline.Entities) {
+		check(key+" accuracy", r.Entities[key], baseline.Entities[key])
+	}
+	return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// RecordedProvider replays LLM responses saved by a previous live run, so
+// the corpus can be evaluated without network access. Wrapping a live
+// provider with Record set saves new responses instead.
+type RecordedProvider struct {
+	path   string
+	live   Provider // Only used when recording
+	mu     sync.Mutex
+	record recordingFile
+}
+
+type recordingFile struct {
+	PromptHash string            `json:"prompt_hash"`
+	Responses  map[string]string `json:"responses"` // User message → raw model output
+}
+
+// NewRecordedProvider loads recordings from path. With a live provider,
+// misses are forwarded to it and stored; call Save to write them out.
+func NewRecordedProvider(path string, live Provider) (*RecordedProvider, error) {
+	p := &RecordedProvider{path: path, live: live, record: recordingFile{Responses: map[string]string{}}}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && live != nil {
+			p.record.PromptHash = PromptHash(intentSystemPrompt())
+			return p, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &p.record); err != nil {
+		return nil, fmt.Errorf("failed to parse recordings %s: %w", path, err)
+	}
+	if p.record.Responses == nil {
+		p.record.Responses = map[string]string{}
+	}
+	return p, nil
+}
+
+func (p *RecordedProvider) Name() string {
+	return "recorded"
+}
+
+// Stale reports whether the recordings were made with a different prompt
+func (p *RecordedProvider) Stale() bool {
+	return p.record.PromptHash != PromptHash(intentSystemPrompt())
+}
+
+func (p *RecordedProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	key := lastUserMessage(req)
+
+	p.mu.Lock()
+	text, ok := p.record.Responses[key]
+	p.mu.Unlock()
+	if ok {
+		return &CompletionResponse{Text: text, Provider: p.Name()}, nil
+	}
+	if p.live == nil {
+		return nil, &ProviderError{Provider: p.Name(), Err: fmt.Errorf("no recording for %q", key)}
+	}
+
+	resp, err := p.live.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.record.Responses[key] = resp.Text
+	p.mu.Unlock()
+	return resp, nil
+}
+
+// Save writes recordings back to disk, stamped with the current prompt
+func (p *RecordedProvider) Save() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.record.PromptHash = PromptHash(intentSystemPrompt())
+	data, err := json.MarshalIndent(p.record, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p.path, append(data, '\n'), 0o644)
+}
+
+func lastUserMessage(req *CompletionRequest) string {
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role == "user" {
+			return req.Messages[i].Content
+		}
+	}
+	return ""
+}
+
+// PromptHash identifies a prompt version in recordings and reports
+func PromptHash(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:6])
+}
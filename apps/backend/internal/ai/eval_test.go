@@ -0,0 +1,163 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/pasarsuara/backend/internal/config"
+)
+
+// Run against real providers with:
+//
+//	go test ./internal/ai -run TestIntentEval -v -args -eval.live [-eval.record] [-eval.update]
+var (
+	evalLive   = flag.Bool("eval.live", false, "evaluate the intent corpus against the configured LLM providers")
+	evalRecord = flag.Bool("eval.record", false, "with -eval.live, save provider responses to the recordings file")
+	evalUpdate = flag.Bool("eval.update", false, "overwrite the baseline with this run's scores")
+)
+
+const (
+	evalCorpusPath     = "testdata/intent_corpus.jsonl"
+	evalBaselinePath   = "testdata/intent_baseline.json"
+	evalRecordingsPath = "testdata/intent_recordings.json"
+	evalTolerance      = 0.02
+)
+
+func TestIntentEval(t *testing.T) {
+	cases, err := LoadEvalCorpus(evalCorpusPath)
+	if err != nil {
+		t.Fatalf("Failed to load corpus: %v", err)
+	}
+
+	t.Run("rules", func(t *testing.T) {
+		checkEvalBaseline(t, "rules", EvaluateIntents(context.Background(), cases, RuleExtractor()))
+	})
+
+	// Recordings replay a local live run offline. They only echo what the
+	// model answered then, so they are reported but not held to a baseline.
+	t.Run("recorded", func(t *testing.T) {
+		if _, err := os.Stat(evalRecordingsPath); os.IsNotExist(err) {
+			t.Skip("No recordings yet, create them with -eval.live -eval.record")
+		}
+		recorded, err := NewRecordedProvider(evalRecordingsPath, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if recorded.Stale() {
+			t.Log("⚠️ Recordings were made with a different intent prompt, re-record with -eval.live -eval.record")
+		}
+		t.Logf("📊 recorded (prompt %s)\n%s", PromptHash(intentSystemPrompt()),
+			EvaluateIntents(context.Background(), cases, LLMExtractor(recorded)))
+	})
+
+	t.Run("live", func(t *testing.T) {
+		if !*evalLive {
+			t.Skip("Live evaluation disabled, pass -eval.live")
+		}
+		cfg := config.Load()
+		var llm Provider = NewDefaultRouter(ProviderSettings{
+			KolosalKey:  cfg.KolosalAPIKey,
+			KolosalURL:  cfg.KolosalBaseURL,
+			GeminiKeys:  cfg.GeminiAPIKey,
+			OpenAIKey:   cfg.OpenAIAPIKey,
+			OpenAIURL:   cfg.OpenAIBaseURL,
+			OpenAIModel: cfg.OpenAIModel,
+			Order:       cfg.LLMProviders,
+		})
+		if !IsAvailable(llm) {
+			t.Skip("No LLM provider keys configured")
+		}
+
+		var recorder *RecordedProvider
+		if *evalRecord {
+			// Start from scratch so responses match the current prompt
+			os.Remove(evalRecordingsPath)
+			if recorder, err = NewRecordedProvider(evalRecordingsPath, llm); err != nil {
+				t.Fatal(err)
+			}
+			llm = recorder
+		}
+
+		checkEvalBaseline(t, "live", EvaluateIntents(context.Background(), cases, LLMExtractor(llm)))
+		if recorder != nil {
+			if err := recorder.Save(); err != nil {
+				t.Fatalf("Failed to save recordings: %v", err)
+			}
+		}
+	})
+}
+
+// checkEvalBaseline fails the test when a score regresses against the
+// stored baseline for mode, or stores it with -eval.update
+func checkEvalBaseline(t *testing.T, mode string, report *EvalReport) {
+	t.Helper()
+	t.Logf("📊 %s (prompt %s)\n%s", mode, PromptHash(intentSystemPrompt()), report)
+
+	baselines := map[string]*EvalReport{}
+	if data, err := os.ReadFile(evalBaselinePath); err == nil {
+		if err := json.Unmarshal(data, &baselines); err != nil {
+			t.Fatalf("Failed to parse baseline: %v", err)
+		}
+	}
+
+	if *evalUpdate {
+		baselines[mode] = report
+		data, _ := json.MarshalIndent(baselines, "", "  ")
+		if err := os.WriteFile(evalBaselinePath, append(data, '\n'), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	baseline, ok := baselines[mode]
+	if !ok {
+		t.Logf("No %s baseline yet, store one with -eval.update", mode)
+		return
+	}
+	for _, regression := range report.Regressions(baseline, evalTolerance) {
+		t.Errorf("Regression: %s", regression)
+	}
+}
+
+func TestEvaluateIntents_Scoring(t *testing.T) {
+	cases := []EvalCase{
+		{Text: "a", Expected: Intent{Action: "RECORD_SALE", Entities: map[string]any{"qty": 5.0, "product": "Bakso"}}},
+		{Text: "b", Expected: Intent{Action: "RECORD_SALE", Entities: map[string]any{"qty": 2.0}}},
+		{Text: "c", Expected: Intent{Action: "GREETING"}},
+		{Text: "d", Expected: Intent{Action: "CHECK_STOCK"}},
+	}
+	recorded := &RecordedProvider{record: recordingFile{Responses: map[string]string{
+		"a": `{"action":"RECORD_SALE","entities":{"qty":5,"product":"bakso"}}`,
+		"b": `{"action":"RECORD_SALE","entities":{"qty":3}}`,
+		"c": `{"action":"RECORD_SALE","entities":{}}`,
+		// "d" has no recording and errors
+	}}}
+
+	report := EvaluateIntents(context.Background(), cases, LLMExtractor(recorded))
+
+	if report.Accuracy != 0.5 {
+		t.Errorf("Accuracy = %v, want 0.5", report.Accuracy)
+	}
+	sale := report.Intents["RECORD_SALE"]
+	if sale.Precision != 0.667 || sale.Recall != 1 {
+		t.Errorf("RECORD_SALE precision/recall = %v/%v, want 0.667/1", sale.Precision, sale.Recall)
+	}
+	if report.Intents["GREETING"].Recall != 0 || report.Intents["UNKNOWN"].Support != 0 {
+		t.Errorf("Unexpected GREETING/UNKNOWN scores: %+v %+v", report.Intents["GREETING"], report.Intents["UNKNOWN"])
+	}
+	if report.Entities["qty"] != 0.5 || report.Entities["product"] != 1 {
+		t.Errorf("Entity accuracy = %v", report.Entities)
+	}
+	if len(report.Misses) != 3 {
+		t.Errorf("Misses = %d, want 3", len(report.Misses))
+	}
+
+	better := *report
+	better.Accuracy = 0.9
+	if regressions := report.Regressions(&better, evalTolerance); len(regressions) != 1 {
+		t.Errorf("Regressions = %v, want accuracy only", regressions)
+	}
+}
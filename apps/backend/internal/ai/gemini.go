@@ -7,26 +7,28 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"strings"
-	"sync"
 	"time"
 )
 
-// GeminiClient handles Speech-to-Text using Gemini API with automatic key rotation
-type GeminiClient struct {
-	apiKeys       []string
-	currentKeyIdx int
-	keyMutex      sync.Mutex
-	httpClient    *http.Client
+// GeminiProvider calls the Gemini generateContent API. It supports audio and
+// image input and rotates through its KeyRing on quota errors.
+type GeminiProvider struct {
+	keys       *KeyRing
+	model      string
+	baseURL    string
+	httpClient *http.Client
 }
 
 type GeminiRequest struct {
-	Contents []GeminiContent `json:"contents"`
+	SystemInstruction *GeminiContent          `json:"system_instruction,omitempty"`
+	Contents          []GeminiContent         `json:"contents"`
+	GenerationConfig  *GeminiGenerationConfig `json:"generationConfig,omitempty"`
 }
 
 type GeminiContent struct {
+	Role  string       `json:"role,omitempty"`
 	Parts []GeminiPart `json:"parts"`
 }
 
@@ -40,6 +42,10 @@ type GeminiInline struct {
 	Data     string `json:"data"` // base64 encoded
 }
 
+type GeminiGenerationConfig struct {
+	ResponseMimeType string `json:"responseMimeType,omitempty"`
+}
+
 type GeminiResponse struct {
 	Candidates []struct {
 		Content struct {
@@ -48,6 +54,10 @@ type GeminiResponse struct {
 			} `json:"parts"`
 		} `json:"content"`
 	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
 	Error *GeminiError `json:"error,omitempty"`
 }
 
@@ -56,304 +66,209 @@ type GeminiError struct {
 	Message string `json:"message"`
 }
 
-func NewGeminiClient(apiKey string) *GeminiClient {
-	// Parse comma-separated API keys
-	keys := parseAPIKeys(apiKey)
-
-	if len(keys) > 1 {
-		log.Printf("✅ Gemini: Loaded %d API keys for rotation", len(keys))
+// NewGeminiProvider creates a Gemini provider; model defaults to gemini-2.0-flash
+func NewGeminiProvider(keys *KeyRing, model string) *GeminiProvider {
+	if model == "" {
+		model = "gemini-2.0-flash"
 	}
-
-	return &GeminiClient{
-		apiKeys:       keys,
-		currentKeyIdx: 0,
+	return &GeminiProvider{
+		keys:    keys,
+		model:   model,
+		baseURL: "https://generativelanguage.googleapis.com/v1beta",
 		httpClient: &http.Client{
 			Timeout: 60 * time.Second,
 		},
 	}
 }
 
-// parseAPIKeys splits comma-separated API keys and trims whitespace
-func parseAPIKeys(apiKey string) []string {
-	if apiKey == "" {
-		return []string{}
-	}
-
-	parts := strings.Split(apiKey, ",")
-	keys := make([]string, 0, len(parts))
-
-	for _, key := range parts {
-		trimmed := strings.TrimSpace(key)
-		if trimmed != "" {
-			keys = append(keys, trimmed)
-		}
-	}
-
-	return keys
+func (g *GeminiProvider) Name() string {
+	return "gemini"
 }
 
-// getCurrentKey returns the current API key (thread-safe)
-func (g *GeminiClient) getCurrentKey() string {
-	g.keyMutex.Lock()
-	defer g.keyMutex.Unlock()
-
-	if len(g.apiKeys) == 0 {
-		return ""
+// Complete calls Gemini, trying each key once on quota/rate limit errors
+func (g *GeminiProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	if g.keys.Len() == 0 {
+		return nil, &ProviderError{Provider: "gemini", Err: fmt.Errorf("Gemini API key not configured")}
 	}
 
-	return g.apiKeys[g.currentKeyIdx]
-}
-
-// rotateKey switches to the next API key (thread-safe)
-func (g *GeminiClient) rotateKey() {
-	g.keyMutex.Lock()
-	defer g.keyMutex.Unlock()
-
-	if len(g.apiKeys) <= 1 {
-		return
-	}
-
-	oldIdx := g.currentKeyIdx
-	g.currentKeyIdx = (g.currentKeyIdx + 1) % len(g.apiKeys)
-
-	log.Printf("🔄 Rotating Gemini API key: %d → %d (total: %d keys)",
-		oldIdx, g.currentKeyIdx, len(g.apiKeys))
-}
-
-// TranscribeAudio converts audio to text using Gemini with automatic key rotation
-func (g *GeminiClient) TranscribeAudio(ctx context.Context, audioData []byte, mimeType string) (string, error) {
-	if len(g.apiKeys) == 0 {
-		return "", fmt.Errorf("Gemini API key not configured")
-	}
-
-	// Encode audio to base64
-	audioBase64 := base64.StdEncoding.EncodeToString(audioData)
-
-	// Build request
-	req := GeminiRequest{
-		Contents: []GeminiContent{
-			{
-				Parts: []GeminiPart{
-					{
-						Text: "Transcribe this audio to text. The audio may contain Indonesian, Javanese, or Sundanese language. Return only the transcription, nothing else.",
-					},
-					{
-						InlineData: &GeminiInline{
-							MimeType: mimeType,
-							Data:     audioBase64,
-						},
-					},
-				},
-			},
-		},
-	}
-
-	jsonData, err := json.Marshal(req)
+	jsonData, err := json.Marshal(g.buildRequest(req))
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Try all API keys with rotation
-	maxRetries := len(g.apiKeys)
 	var lastErr error
-
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		apiKey := g.getCurrentKey()
-
-		// Call Gemini API (using 2.0-flash for better audio transcription)
-		url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/gemini-2.0-flash:generateContent?key=%s", apiKey)
-
-		httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-		if err != nil {
-			return "", fmt.Errorf("failed to create request: %w", err)
-		}
-		httpReq.Header.Set("Content-Type", "application/json")
-
-		resp, err := g.httpClient.Do(httpReq)
-		if err != nil {
-			lastErr = fmt.Errorf("failed to call Gemini API: %w", err)
-			log.Printf("⚠️ Gemini API call failed (attempt %d/%d): %v", attempt+1, maxRetries, err)
-			g.rotateKey()
-			continue
-		}
-
-		body, err := io.ReadAll(resp.Body)
-		resp.Body.Close()
-
-		if err != nil {
-			lastErr = fmt.Errorf("failed to read response: %w", err)
-			log.Printf("⚠️ Failed to read response (attempt %d/%d): %v", attempt+1, maxRetries, err)
-			g.rotateKey()
-			continue
+	for attempt := 0; attempt < g.keys.Len(); attempt++ {
+		apiKey := g.keys.Current()
+		resp, status, err := g.do(ctx, apiKey, jsonData)
+		if err == nil {
+			return resp, nil
 		}
-
-		var geminiResp GeminiResponse
-		if err := json.Unmarshal(body, &geminiResp); err != nil {
-			lastErr = fmt.Errorf("failed to parse response: %w", err)
-			log.Printf("⚠️ Failed to parse response (attempt %d/%d): %v", attempt+1, maxRetries, err)
-			g.rotateKey()
-			continue
+		lastErr = err
+		if !isKeyError(status) {
+			break
 		}
+		g.keys.Rotate(apiKey)
+	}
+	return nil, lastErr
+}
 
-		// Check for rate limit or quota errors
-		if geminiResp.Error != nil {
-			lastErr = fmt.Errorf("Gemini API error: %s", geminiResp.Error.Message)
-
-			// Rotate key on rate limit (429) or quota exceeded (403)
-			if geminiResp.Error.Code == 429 || geminiResp.Error.Code == 403 {
-				log.Printf("⚠️ API key quota/rate limit (code %d, attempt %d/%d): %s",
-					geminiResp.Error.Code, attempt+1, maxRetries, geminiResp.Error.Message)
-				g.rotateKey()
-
-				// Small delay before retry
-				time.Sleep(time.Duration(attempt+1) * 100 * time.Millisecond)
-				continue
-			}
+func (g *GeminiProvider) buildRequest(req *CompletionRequest) GeminiRequest {
+	var gr GeminiRequest
+	if req.System != "" {
+		gr.SystemInstruction = &GeminiContent{Parts: []GeminiPart{{Text: req.System}}}
+	}
+	if req.JSON {
+		gr.GenerationConfig = &GeminiGenerationConfig{ResponseMimeType: "application/json"}
+	}
 
-			// For other errors, don't retry
-			return "", lastErr
+	for _, msg := range req.Messages {
+		role := "user"
+		if msg.Role == "assistant" {
+			role = "model"
 		}
+		gr.Contents = append(gr.Contents, GeminiContent{Role: role, Parts: []GeminiPart{{Text: msg.Content}}})
+	}
 
-		if len(geminiResp.Candidates) == 0 || len(geminiResp.Candidates[0].Content.Parts) == 0 {
-			lastErr = fmt.Errorf("no transcription returned")
-			log.Printf("⚠️ No transcription returned (attempt %d/%d)", attempt+1, maxRetries)
-			g.rotateKey()
-			continue
+	if len(req.Media) > 0 {
+		if len(gr.Contents) == 0 || gr.Contents[len(gr.Contents)-1].Role != "user" {
+			gr.Contents = append(gr.Contents, GeminiContent{Role: "user"})
 		}
-
-		// Success!
-		transcript := geminiResp.Candidates[0].Content.Parts[0].Text
-		if attempt > 0 {
-			log.Printf("✅ Transcription succeeded after %d retries", attempt)
+		last := &gr.Contents[len(gr.Contents)-1]
+		for _, media := range req.Media {
+			last.Parts = append(last.Parts, GeminiPart{InlineData: &GeminiInline{
+				MimeType: media.MimeType,
+				Data:     base64.StdEncoding.EncodeToString(media.Data),
+			}})
 		}
-		return transcript, nil
 	}
-
-	// All keys exhausted
-	log.Printf("❌ All %d API keys exhausted", len(g.apiKeys))
-	return "", fmt.Errorf("all API keys exhausted: %w", lastErr)
+	return gr
 }
 
-// ExtractIntent uses Gemini to extract intent from text with automatic key rotation
-func (g *GeminiClient) ExtractIntent(ctx context.Context, text string) (*Intent, error) {
-	if len(g.apiKeys) == 0 {
-		return nil, fmt.Errorf("Gemini API key not configured")
+func (g *GeminiProvider) do(ctx context.Context, apiKey string, jsonData []byte) (*CompletionResponse, int, error) {
+	url := fmt.Sprintf("%s/models/%s:generateContent", g.baseURL, g.model)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
 	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-goog-api-key", apiKey)
 
-	prompt := intentSystemPrompt + "\n\nUser message: " + text
-
-	req := GeminiRequest{
-		Contents: []GeminiContent{
-			{
-				Parts: []GeminiPart{
-					{Text: prompt},
-				},
-			},
-		},
+	resp, err := g.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, 0, &ProviderError{Provider: "gemini", Retryable: true, Err: fmt.Errorf("failed to call Gemini API: %w", err)}
 	}
+	defer resp.Body.Close()
 
-	jsonData, err := json.Marshal(req)
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, resp.StatusCode, &ProviderError{Provider: "gemini", Retryable: true, Err: fmt.Errorf("failed to read response: %w", err)}
 	}
 
-	// Try all API keys with rotation
-	maxRetries := len(g.apiKeys)
-	var lastErr error
-
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		apiKey := g.getCurrentKey()
-
-		url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/gemini-2.0-flash:generateContent?key=%s", apiKey)
-
-		httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-		if err != nil {
-			return nil, fmt.Errorf("failed to create request: %w", err)
-		}
-		httpReq.Header.Set("Content-Type", "application/json")
-
-		resp, err := g.httpClient.Do(httpReq)
-		if err != nil {
-			lastErr = fmt.Errorf("failed to call Gemini API: %w", err)
-			log.Printf("⚠️ Gemini API call failed (attempt %d/%d): %v", attempt+1, maxRetries, err)
-			g.rotateKey()
-			continue
-		}
-
-		body, err := io.ReadAll(resp.Body)
-		resp.Body.Close()
-
-		if err != nil {
-			lastErr = fmt.Errorf("failed to read response: %w", err)
-			log.Printf("⚠️ Failed to read response (attempt %d/%d): %v", attempt+1, maxRetries, err)
-			g.rotateKey()
-			continue
-		}
-
-		var geminiResp GeminiResponse
-		if err := json.Unmarshal(body, &geminiResp); err != nil {
-			lastErr = fmt.Errorf("failed to parse response: %w", err)
-			log.Printf("⚠️ Failed to parse response (attempt %d/%d): %v", attempt+1, maxRetries, err)
-			g.rotateKey()
-			continue
+	var geminiResp GeminiResponse
+	if err := json.Unmarshal(body, &geminiResp); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, resp.StatusCode, newStatusError("gemini", resp.StatusCode, string(body))
 		}
+		return nil, resp.StatusCode, &ProviderError{Provider: "gemini", Err: fmt.Errorf("failed to parse response: %w", err)}
+	}
 
-		// Check for rate limit or quota errors
-		if geminiResp.Error != nil {
-			lastErr = fmt.Errorf("Gemini API error: %s", geminiResp.Error.Message)
-
-			// Rotate key on rate limit (429) or quota exceeded (403)
-			if geminiResp.Error.Code == 429 || geminiResp.Error.Code == 403 {
-				log.Printf("⚠️ API key quota/rate limit (code %d, attempt %d/%d): %s",
-					geminiResp.Error.Code, attempt+1, maxRetries, geminiResp.Error.Message)
-				g.rotateKey()
-
-				// Small delay before retry
-				time.Sleep(time.Duration(attempt+1) * 100 * time.Millisecond)
-				continue
-			}
-
-			// For other errors, don't retry
-			return nil, lastErr
+	if geminiResp.Error != nil {
+		status := geminiResp.Error.Code
+		if status == 0 {
+			status = resp.StatusCode
 		}
+		return nil, status, newStatusError("gemini", status, geminiResp.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode, newStatusError("gemini", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
 
-		if len(geminiResp.Candidates) == 0 || len(geminiResp.Candidates[0].Content.Parts) == 0 {
-			lastErr = fmt.Errorf("no response from Gemini")
-			log.Printf("⚠️ No response from Gemini (attempt %d/%d)", attempt+1, maxRetries)
-			g.rotateKey()
-			continue
-		}
+	if len(geminiResp.Candidates) == 0 || len(geminiResp.Candidates[0].Content.Parts) == 0 {
+		return nil, resp.StatusCode, &ProviderError{Provider: "gemini", Retryable: true, Err: fmt.Errorf("empty response from API")}
+	}
 
-		// Parse the JSON response
-		content := geminiResp.Candidates[0].Content.Parts[0].Text
+	var text strings.Builder
+	for _, part := range geminiResp.Candidates[0].Content.Parts {
+		text.WriteString(part.Text)
+	}
+	return &CompletionResponse{
+		Text:     text.String(),
+		Provider: "gemini",
+		Model:    g.model,
+		Usage: TokenUsage{
+			InputTokens:  geminiResp.UsageMetadata.PromptTokenCount,
+			OutputTokens: geminiResp.UsageMetadata.CandidatesTokenCount,
+		},
+	}, resp.StatusCode, nil
+}
 
-		// Clean up markdown code blocks if present
-		content = strings.TrimPrefix(content, "```json\n")
-		content = strings.TrimPrefix(content, "```\n")
-		content = strings.TrimSuffix(content, "\n```")
-		content = strings.TrimSpace(content)
+// Transcription is a transcript with the speech recognizer's own confidence
+type Transcription struct {
+	Text           string   `json:"text"`
+	Confidence     float64  `json:"confidence"`      // 0-1, 0 when unknown
+	UncertainWords []string `json:"uncertain_words"` // Words that may be misheard
+}
 
-		var intent Intent
-		if err := json.Unmarshal([]byte(content), &intent); err != nil {
-			log.Printf("⚠️ Failed to parse Gemini response: %s", content)
-			// Return unknown intent as last resort
-			return &Intent{
-				Action:    "UNKNOWN",
-				Entities:  map[string]any{},
-				Sentiment: "neutral",
-				Language:  "id",
-				RawText:   text,
-			}, nil
-		}
+// TranscribeAudio converts audio to text with the first provider that accepts audio
+func TranscribeAudio(ctx context.Context, llm Provider, audioData []byte, mimeType string) (string, error) {
+	if !IsAvailable(llm) {
+		return "", ErrNoProviders
+	}
+	ctx = WithFeature(ctx, FeatureSTT)
+	prompt, err := DefaultPrompts().Render(ctx, "transcribe", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := llm.Complete(ctx, &CompletionRequest{
+		Messages: []Message{{Role: "user", Content: prompt.User}},
+		Media:    []MediaPart{{MimeType: mimeType, Data: audioData}},
+	})
+	if err != nil {
+		return "", err
+	}
+	transcript := strings.TrimSpace(resp.Text)
+	if transcript == "" {
+		return "", fmt.Errorf("no transcription returned")
+	}
+	return transcript, nil
+}
 
-		intent.RawText = text
-		if attempt > 0 {
-			log.Printf("✅ Intent extraction succeeded after %d retries", attempt)
-		}
-		return &intent, nil
+// TranscribeAudioWithConfidence is TranscribeAudio that also asks for a confidence score.
+// Providers that ignore the JSON format still yield a transcript with unknown confidence.
+func TranscribeAudioWithConfidence(ctx context.Context, llm Provider, audioData []byte, mimeType string) (*Transcription, error) {
+	if !IsAvailable(llm) {
+		return nil, ErrNoProviders
 	}
+	ctx = WithFeature(ctx, FeatureSTT)
+	prompt, err := DefaultPrompts().Render(ctx, "transcribe_confidence", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := llm.Complete(ctx, &CompletionRequest{
+		Messages: []Message{{Role: "user", Content: prompt.User}},
+		Media:    []MediaPart{{MimeType: mimeType, Data: audioData}},
+		JSON:     true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	prompt.RecordOutcome(prompt.Validate(StripCodeFence(resp.Text)))
+	return parseTranscription(resp.Text)
+}
 
-	// All keys exhausted
-	log.Printf("❌ All %d API keys exhausted", len(g.apiKeys))
-	return nil, fmt.Errorf("all API keys exhausted: %w", lastErr)
+// parseTranscription reads the JSON transcription, falling back to plain text
+func parseTranscription(content string) (*Transcription, error) {
+	content = strings.TrimSpace(content)
+	var t Transcription
+	if err := json.Unmarshal([]byte(StripCodeFence(content)), &t); err != nil || t.Text == "" {
+		t = Transcription{Text: content}
+	}
+	t.Text = strings.TrimSpace(t.Text)
+	if t.Text == "" {
+		return nil, fmt.Errorf("no transcription returned")
+	}
+	if t.Confidence < 0 || t.Confidence > 1 {
+		t.Confidence = 0
+	}
+	return &t, nil
 }
@@ -55,7 +55,7 @@ func TestProperty13_APIInvocation(t *testing.T) {
 			// Verify it's a valid category
 			validCategories := []string{
 				"BAHAN_BAKU", "OPERASIONAL", "GAJI",
-				"TRANSPORTASI", "PERALATAN", "LAINNYA",
+				"TRANSPORTASI", "PEMASARAN", "LAINNYA",
 			}
 
 			isValid := false
@@ -88,7 +88,7 @@ func TestProperty14_ResponseParsingCompleteness(t *testing.T) {
 		{"OPERASIONAL", "OPERASIONAL", false},
 		{"GAJI", "GAJI", false},
 		{"TRANSPORTASI", "TRANSPORTASI", false},
-		{"PERALATAN", "PERALATAN", false},
+		{"PEMASARAN", "PEMASARAN", false},
 		{"LAINNYA", "LAINNYA", false},
 		{"bahan_baku", "BAHAN_BAKU", false},
 		{"  OPERASIONAL  ", "OPERASIONAL", false},
@@ -185,24 +185,23 @@ func TestProperty16_AmbiguityDetection(t *testing.T) {
 
 // Test circuit breaker functionality
 func TestCircuitBreaker(t *testing.T) {
-	client := NewGeminiCategorizationClient("invalid-key")
+	breaker := NewCircuitBreaker(5, time.Minute)
 
 	// Trigger 5 failures to open circuit
 	for i := 0; i < 5; i++ {
-		client.failures++
+		breaker.RecordFailure()
 	}
-	client.lastFailure = time.Now()
 
 	// Circuit should be open
-	if !client.isCircuitOpen() {
+	if !breaker.IsOpen() {
 		t.Error("Circuit should be open after 5 failures")
 	}
 
 	// Wait for circuit to reset (simulate 1 minute passing)
-	client.lastFailure = time.Now().Add(-2 * time.Minute)
+	breaker.lastFailure = time.Now().Add(-2 * time.Minute)
 
 	// Circuit should be closed now
-	if client.isCircuitOpen() {
+	if breaker.IsOpen() {
 		t.Error("Circuit should be closed after timeout")
 	}
 }
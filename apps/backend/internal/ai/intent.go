@@ -0,0 +1,121 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+)
+
+// Intent represents extracted intent from user message
+type Intent struct {
+	Action    string         `json:"action"`    // ORDER_RESTOCK, RECORD_SALE, REQUEST_PROMO, ASK_MARKET, ASK_DATA, UNKNOWN
+	Entities  map[string]any `json:"entities"`  // product, qty, price, time, etc
+	Sentiment string         `json:"sentiment"` // positive, negative, neutral
+	Language  string         `json:"language"`  // id, jv, su
+	RawText   string         `json:"raw_text"`
+
+	// Confidence scores are 0-1; zero means the extractor gave none
+	Confidence       float64            `json:"confidence,omitempty"`
+	EntityConfidence map[string]float64 `json:"entity_confidence,omitempty"`
+	Alternatives     map[string][]any   `json:"alternatives,omitempty"` // Other plausible values per entity
+	STTConfidence    float64            `json:"stt_confidence,omitempty"`
+}
+
+// FieldConfidence returns how sure the extractor is about one entity,
+// capped by the overall and speech-to-text confidence. Unscored is 1.
+func (i *Intent) FieldConfidence(field string) float64 {
+	confidence := 1.0
+	if c, ok := i.EntityConfidence[field]; ok && c > 0 {
+		confidence = c
+	} else if i.Confidence > 0 {
+		confidence = i.Confidence
+	}
+	if i.STTConfidence > 0 && i.STTConfidence < confidence {
+		confidence = i.STTConfidence
+	}
+	return confidence
+}
+
+// ClearConfidence marks every entity as confirmed by the user
+func (i *Intent) ClearConfidence() {
+	i.Confidence = 0
+	i.EntityConfidence = nil
+	i.Alternatives = nil
+	i.STTConfidence = 0
+}
+
+// intentSystemPrompt is the released intent system prompt, used to stamp eval recordings
+func intentSystemPrompt() string {
+	prompt, err := DefaultPrompts().Render(context.Background(), "intent", map[string]any{"message": ""})
+	if err != nil {
+		return ""
+	}
+	return prompt.System
+}
+
+// Turn is one earlier message of the conversation given to the extractor
+type Turn struct {
+	Role    string // user or assistant
+	Content string
+	Intent  string
+}
+
+// ExtractIntent analyzes text and returns structured intent
+func ExtractIntent(ctx context.Context, llm Provider, text string) (*Intent, error) {
+	return ExtractIntentWithHistory(ctx, llm, text, nil)
+}
+
+// ExtractIntentWithHistory analyzes text with the recent conversation so
+// follow-ups like "yang tadi jadi 12 ribu" can be resolved
+func ExtractIntentWithHistory(ctx context.Context, llm Provider, text string, history []Turn) (*Intent, error) {
+	ctx = WithFeature(ctx, FeatureIntent)
+	prompt, err := DefaultPrompts().Render(ctx, "intent", map[string]any{"message": formatIntentPrompt(text, history)})
+	if err != nil {
+		return nil, err
+	}
+	content, err := CompleteJSON(ctx, llm, prompt.System, prompt.User)
+	if err != nil {
+		return nil, err
+	}
+	prompt.RecordOutcome(prompt.Validate(content))
+
+	// Parse the JSON response from LLM
+	var intent Intent
+	if err := json.Unmarshal([]byte(content), &intent); err != nil {
+		log.Printf("⚠️ Failed to parse intent response: %s", content)
+		// If parsing fails, return unknown intent
+		return &Intent{
+			Action:    "UNKNOWN",
+			Entities:  map[string]any{},
+			Sentiment: "neutral",
+			Language:  "id",
+			RawText:   text,
+		}, nil
+	}
+	if intent.Entities == nil {
+		intent.Entities = map[string]any{}
+	}
+
+	intent.RawText = text
+	return &intent, nil
+}
+
+// formatIntentPrompt puts the recent conversation before the message.
+// Without history the prompt is the message itself.
+func formatIntentPrompt(text string, history []Turn) string {
+	if len(history) == 0 {
+		return text
+	}
+	var b strings.Builder
+	b.WriteString("Recent conversation:\n")
+	for _, turn := range history {
+		b.WriteString(turn.Role + ": " + turn.Content)
+		if turn.Intent != "" {
+			b.WriteString(" [" + turn.Intent + "]")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\nCurrent message: " + text)
+	return b.String()
+}
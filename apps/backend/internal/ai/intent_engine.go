@@ -4,29 +4,46 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 )
 
 // IntentEngine orchestrates STT and intent extraction
 type IntentEngine struct {
-	gemini     *GeminiClient
-	kolosal    *KolosalClient
-	geminiKey  string
-	kolosalKey string
-	kolosalURL string
+	llm   Provider
+	audio *AudioProcessor
 }
 
+// NewIntentEngine builds the default Kolosal > Gemini provider chain
 func NewIntentEngine(geminiKey, kolosalKey, kolosalURL string) *IntentEngine {
-	return &IntentEngine{
-		gemini:     NewGeminiClient(geminiKey),
-		kolosal:    NewKolosalClient(kolosalKey, kolosalURL),
-		geminiKey:  geminiKey,
-		kolosalKey: kolosalKey,
-		kolosalURL: kolosalURL,
-	}
+	return NewIntentEngineWithProvider(NewDefaultRouter(ProviderSettings{
+		KolosalKey: kolosalKey,
+		KolosalURL: kolosalURL,
+		GeminiKeys: geminiKey,
+	}))
+}
+
+// NewIntentEngineWithProvider uses a shared provider, usually a Router
+func NewIntentEngineWithProvider(llm Provider) *IntentEngine {
+	return &IntentEngine{llm: llm, audio: NewAudioProcessor(NewGeminiTranscriber(llm))}
+}
+
+// SetTranscriber replaces the Gemini-only speech-to-text, usually with a TranscriberRouter
+func (e *IntentEngine) SetTranscriber(stt Transcriber) {
+	e.audio = NewAudioProcessor(stt)
+}
+
+// Provider returns the LLM provider used by the engine
+func (e *IntentEngine) Provider() Provider {
+	return e.llm
 }
 
 // ProcessText extracts intent from text message
 func (e *IntentEngine) ProcessText(ctx context.Context, text string) (*Intent, error) {
+	return e.ProcessTextWithHistory(ctx, text, nil)
+}
+
+// ProcessTextWithHistory extracts intent using the recent conversation for follow-ups
+func (e *IntentEngine) ProcessTextWithHistory(ctx context.Context, text string, history []Turn) (*Intent, error) {
 	log.Printf("🧠 Processing text: %s", text)
 
 	// Normalize text first (handle "15rb", "25kg", etc)
@@ -35,18 +52,7 @@ func (e *IntentEngine) ProcessText(ctx context.Context, text string) (*Intent, e
 		log.Printf("📝 Normalized: %s → %s", text, normalizedText)
 	}
 
-	// Try Kolosal first
-	intent, err := e.kolosal.ExtractIntent(ctx, normalizedText)
-	if err != nil {
-		log.Printf("⚠️ Kolosal failed (%v), using Gemini fallback...", err)
-		// Fallback to Gemini
-		intent, err = e.extractIntentWithGemini(ctx, normalizedText)
-		if err != nil {
-			log.Printf("❌ Gemini fallback also failed: %v", err)
-			return nil, err
-		}
-		log.Printf("✅ Gemini fallback success!")
-	}
+	intent := e.extractIntent(ctx, normalizedText, history)
 
 	// Store original text
 	intent.RawText = text
@@ -55,44 +61,53 @@ func (e *IntentEngine) ProcessText(ctx context.Context, text string) (*Intent, e
 	return intent, nil
 }
 
+// extractIntent tries the rule parser first and calls the LLM only for
+// messages it can't parse confidently. When every provider is down the
+// rule parser's best guess is used.
+func (e *IntentEngine) extractIntent(ctx context.Context, text string, history []Turn) *Intent {
+	ruleIntent, confidence := ParseIntentRules(text)
+	ruleIntent.Confidence = confidence
+	if confidence >= RuleConfidenceThreshold {
+		log.Printf("📏 Rule parser matched %s (confidence %.2f), skipping LLM", ruleIntent.Action, confidence)
+		return ruleIntent
+	}
+
+	intent, err := ExtractIntentWithHistory(ctx, e.llm, text, history)
+	if err != nil {
+		log.Printf("⚠️ LLM intent extraction failed (%v), using rule parser: %s", err, ruleIntent.Action)
+		return ruleIntent
+	}
+	return intent
+}
+
 // ProcessAudio transcribes audio then extracts intent
 func (e *IntentEngine) ProcessAudio(ctx context.Context, audioData []byte, mimeType string) (*Intent, error) {
+	return e.ProcessAudioWithHistory(ctx, audioData, mimeType, nil)
+}
+
+// ProcessAudioWithHistory is ProcessAudio with the recent conversation for follow-ups
+func (e *IntentEngine) ProcessAudioWithHistory(ctx context.Context, audioData []byte, mimeType string, history []Turn) (*Intent, error) {
 	log.Printf("🎤 Processing audio (%d bytes, %s)", len(audioData), mimeType)
 
-	// Step 1: Transcribe audio to text
-	transcript, err := e.gemini.TranscribeAudio(ctx, audioData, mimeType)
+	// Step 1: Convert, split at pauses and transcribe audio to text
+	transcription, err := e.audio.Transcribe(ctx, audioData, mimeType)
 	if err != nil {
 		log.Printf("❌ Transcription failed: %v", err)
 		return nil, err
 	}
+	transcript := transcription.Text
 
-	log.Printf("📝 Transcript: %s", transcript)
+	log.Printf("📝 Transcript (confidence %.2f): %s", transcription.Confidence, transcript)
 
-	// Step 2: Extract intent from transcript (with fallback)
-	intent, err := e.kolosal.ExtractIntent(ctx, transcript)
-	if err != nil {
-		log.Printf("⚠️ Kolosal failed (%v), using Gemini fallback...", err)
-		// Fallback to Gemini
-		intent, err = e.extractIntentWithGemini(ctx, transcript)
-		if err != nil {
-			log.Printf("❌ Gemini fallback also failed: %v", err)
-			return nil, err
-		}
-		log.Printf("✅ Gemini fallback success!")
-	}
+	// Step 2: Extract intent from transcript
+	intent := e.extractIntent(ctx, NormalizeText(transcript), history)
+	intent.RawText = transcript
+	applyTranscriptConfidence(intent, transcription)
 
 	log.Printf("✅ Intent: %s, Entities: %v", intent.Action, intent.Entities)
 	return intent, nil
 }
 
-// extractIntentWithGemini uses Gemini as fallback for intent extraction
-// This method delegates to GeminiClient which handles key rotation automatically
-func (e *IntentEngine) extractIntentWithGemini(ctx context.Context, text string) (*Intent, error) {
-	// Use the gemini client's built-in key rotation by making a text-based request
-	// We'll create a helper method in GeminiClient for this
-	return e.gemini.ExtractIntent(ctx, text)
-}
-
 // GenerateResponse creates a response based on intent
 func (e *IntentEngine) GenerateResponse(intent *Intent) string {
 	switch intent.Action {
@@ -212,3 +227,38 @@ func formatNumber(n float64) string {
 	}
 	return fmt.Sprintf("%.2f", n)
 }
+
+// uncertainWordConfidence caps entities that came from a word the recognizer misheard
+const uncertainWordConfidence = 0.5
+
+// applyTranscriptConfidence carries speech recognition doubt over to the entities
+func applyTranscriptConfidence(intent *Intent, t *Transcription) {
+	intent.STTConfidence = t.Confidence
+	for _, word := range t.UncertainWords {
+		normalized := strings.ToLower(NormalizeText(word))
+		for field, value := range intent.Entities {
+			if !transcriptWordMatches(value, normalized) {
+				continue
+			}
+			if intent.EntityConfidence == nil {
+				intent.EntityConfidence = map[string]float64{}
+			}
+			if c, ok := intent.EntityConfidence[field]; !ok || c > uncertainWordConfidence {
+				intent.EntityConfidence[field] = uncertainWordConfidence
+			}
+		}
+	}
+}
+
+// transcriptWordMatches reports whether an entity value came from word.
+// Numbers must match exactly, names may contain the word.
+func transcriptWordMatches(value any, word string) bool {
+	if word == "" {
+		return false
+	}
+	if text, ok := value.(string); ok {
+		text = strings.ToLower(text)
+		return text != "" && (strings.Contains(text, word) || strings.Contains(word, text))
+	}
+	return fmt.Sprint(value) == word
+}
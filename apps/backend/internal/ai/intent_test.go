@@ -0,0 +1,101 @@
+package ai
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExtractIntent_Confidence(t *testing.T) {
+	recorded := &RecordedProvider{record: recordingFile{Responses: map[string]string{
+		"laku nasi rames limolas porsi": `{"action":"RECORD_SALE","entities":{"product":"nasi rames","qty":15},"confidence":0.9,` +
+			`"entity_confidence":{"product":0.95,"qty":0.55},"alternatives":{"qty":[50]}}`,
+	}}}
+
+	intent, err := ExtractIntent(context.Background(), recorded, "laku nasi rames limolas porsi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if intent.Confidence != 0.9 || intent.EntityConfidence["qty"] != 0.55 {
+		t.Errorf("Confidence = %v, entity confidence = %v", intent.Confidence, intent.EntityConfidence)
+	}
+	if alts := intent.Alternatives["qty"]; len(alts) != 1 || alts[0] != 50.0 {
+		t.Errorf("Alternatives = %v, want qty [50]", intent.Alternatives)
+	}
+}
+
+func TestIntent_FieldConfidence(t *testing.T) {
+	tests := []struct {
+		name   string
+		intent Intent
+		field  string
+		want   float64
+	}{
+		{"unscored", Intent{}, "qty", 1},
+		{"overall only", Intent{Confidence: 0.8}, "qty", 0.8},
+		{"entity score wins", Intent{Confidence: 0.9, EntityConfidence: map[string]float64{"qty": 0.4}}, "qty", 0.4},
+		{"other entity scored", Intent{Confidence: 0.9, EntityConfidence: map[string]float64{"qty": 0.4}}, "price", 0.9},
+		{"stt caps", Intent{EntityConfidence: map[string]float64{"qty": 0.9}, STTConfidence: 0.6}, "qty", 0.6},
+		{"stt above entity", Intent{EntityConfidence: map[string]float64{"qty": 0.5}, STTConfidence: 0.9}, "qty", 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.intent.FieldConfidence(tt.field); got != tt.want {
+				t.Errorf("FieldConfidence(%q) = %v, want %v", tt.field, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTranscription(t *testing.T) {
+	tests := []struct {
+		content    string
+		text       string
+		confidence float64
+		uncertain  int
+	}{
+		{`{"text":"laku bakso limolas","confidence":0.7,"uncertain_words":["limolas"]}`, "laku bakso limolas", 0.7, 1},
+		{"```json\n{\"text\":\"halo\",\"confidence\":0.99}\n```", "halo", 0.99, 0},
+		{"laku bakso lima", "laku bakso lima", 0, 0},
+		{`{"text":"halo","confidence":7}`, "halo", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			got, err := parseTranscription(tt.content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Text != tt.text || got.Confidence != tt.confidence || len(got.UncertainWords) != tt.uncertain {
+				t.Errorf("parseTranscription() = %+v", got)
+			}
+		})
+	}
+
+	if _, err := parseTranscription("  "); err == nil {
+		t.Error("Expected error for empty transcription")
+	}
+}
+
+func TestApplyTranscriptConfidence(t *testing.T) {
+	intent := &Intent{
+		Action:           "RECORD_SALE",
+		Entities:         map[string]any{"product": "nasi rames", "qty": 15.0, "price": 12000.0},
+		EntityConfidence: map[string]float64{"product": 0.95, "qty": 0.9},
+	}
+
+	applyTranscriptConfidence(intent, &Transcription{Confidence: 0.8, UncertainWords: []string{"limolas", "siji"}})
+
+	if intent.STTConfidence != 0.8 {
+		t.Errorf("STTConfidence = %v, want 0.8", intent.STTConfidence)
+	}
+	if intent.EntityConfidence["qty"] != uncertainWordConfidence {
+		t.Errorf("qty confidence = %v, want %v", intent.EntityConfidence["qty"], uncertainWordConfidence)
+	}
+	if intent.EntityConfidence["product"] != 0.95 {
+		t.Errorf("product confidence = %v, want unchanged", intent.EntityConfidence["product"])
+	}
+	if _, ok := intent.EntityConfidence["price"]; ok {
+		t.Errorf("price confidence set by partial number match")
+	}
+}
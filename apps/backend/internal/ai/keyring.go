@@ -0,0 +1,82 @@
+package ai
+
+import (
+	"log"
+	"strings"
+	"sync"
+)
+
+// KeyRing rotates through API keys. Providers sharing a KeyRing skip a
+// rate-limited key together instead of each hitting it again.
+type KeyRing struct {
+	name  string
+	keys  []string
+	index int
+	mu    sync.Mutex
+}
+
+// NewKeyRing creates a key ring from comma-separated keys
+func NewKeyRing(name, apiKeys string) *KeyRing {
+	keys := parseAPIKeys(apiKeys)
+	if len(keys) > 1 {
+		log.Printf("✅ %s: Loaded %d API keys for rotation", name, len(keys))
+	}
+	return &KeyRing{name: name, keys: keys}
+}
+
+// parseAPIKeys splits comma-separated API keys and trims whitespace
+func parseAPIKeys(apiKey string) []string {
+	if apiKey == "" {
+		return []string{}
+	}
+
+	parts := strings.Split(apiKey, ",")
+	keys := make([]string, 0, len(parts))
+
+	for _, key := range parts {
+		trimmed := strings.TrimSpace(key)
+		if trimmed != "" {
+			keys = append(keys, trimmed)
+		}
+	}
+
+	return keys
+}
+
+// Len returns the number of keys
+func (k *KeyRing) Len() int {
+	if k == nil {
+		return 0
+	}
+	return len(k.keys)
+}
+
+// Current returns the key in use (thread-safe)
+func (k *KeyRing) Current() string {
+	if k.Len() == 0 {
+		return ""
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return k.keys[k.index]
+}
+
+// Rotate switches to the next key, unless another caller already did
+// since key was read
+func (k *KeyRing) Rotate(key string) {
+	if k.Len() <= 1 {
+		return
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.keys[k.index] != key {
+		return
+	}
+	oldIdx := k.index
+	k.index = (k.index + 1) % len(k.keys)
+
+	log.Printf("🔄 Rotating %s API key: %d → %d (total: %d keys)",
+		k.name, oldIdx, k.index, len(k.keys))
+}
@@ -0,0 +1,169 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// OpenAICompatProvider calls any OpenAI-compatible /chat/completions API
+// (Kolosal, OpenAI, local gateways). Text only.
+type OpenAICompatProvider struct {
+	name       string
+	baseURL    string
+	model      string
+	keys       *KeyRing
+	httpClient *http.Client
+
+	// Some gateways reject response_format, Kolosal among them
+	supportsJSONMode bool
+}
+
+type chatRequest struct {
+	Model          string            `json:"model"`
+	Messages       []Message         `json:"messages"`
+	ResponseFormat map[string]string `json:"response_format,omitempty"`
+}
+
+type chatResponse struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+func NewOpenAICompatProvider(name, baseURL string, keys *KeyRing, model string) *OpenAICompatProvider {
+	if model == "" {
+		model = "gpt-4o-mini"
+	}
+	return &OpenAICompatProvider{
+		name:    name,
+		baseURL: baseURL,
+		model:   model,
+		keys:    keys,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		supportsJSONMode: true,
+	}
+}
+
+// NewKolosalProvider creates the Kolosal provider
+func NewKolosalProvider(apiKey, baseURL string) *OpenAICompatProvider {
+	if baseURL == "" {
+		baseURL = "https://api.kolosal.ai/v1"
+	}
+	p := NewOpenAICompatProvider("kolosal", baseURL, NewKeyRing("Kolosal", apiKey), "kolosal-1-full")
+	p.supportsJSONMode = false
+	return p
+}
+
+func (p *OpenAICompatProvider) Name() string {
+	return p.name
+}
+
+// Complete sends a chat completion, rotating keys on auth/quota errors
+func (p *OpenAICompatProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	if p.keys.Len() == 0 {
+		return nil, &ProviderError{Provider: p.name, Err: fmt.Errorf("API key not configured")}
+	}
+	if len(req.Media) > 0 {
+		return nil, ErrMediaUnsupported
+	}
+
+	chatReq := chatRequest{Model: p.model}
+	if req.System != "" {
+		chatReq.Messages = append(chatReq.Messages, Message{Role: "system", Content: req.System})
+	}
+	chatReq.Messages = append(chatReq.Messages, req.Messages...)
+	if req.JSON && p.supportsJSONMode {
+		chatReq.ResponseFormat = map[string]string{"type": "json_object"}
+	}
+
+	jsonData, err := json.Marshal(chatReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// Try each key once; quota and auth errors move to the next key
+	var lastErr error
+	for attempt := 0; attempt < p.keys.Len(); attempt++ {
+		apiKey := p.keys.Current()
+		resp, status, err := p.do(ctx, apiKey, jsonData)
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+		if !isKeyError(status) {
+			break
+		}
+		p.keys.Rotate(apiKey)
+	}
+	return nil, lastErr
+}
+
+func (p *OpenAICompatProvider) do(ctx context.Context, apiKey string, jsonData []byte) (*CompletionResponse, int, error) {
+	url := fmt.Sprintf("%s/chat/completions", p.baseURL)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, 0, &ProviderError{Provider: p.name, Retryable: true, Err: err}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, &ProviderError{Provider: p.name, Retryable: true, Err: fmt.Errorf("failed to read response: %w", err)}
+	}
+
+	var chatResp chatResponse
+	if err := json.Unmarshal(body, &chatResp); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, resp.StatusCode, newStatusError(p.name, resp.StatusCode, string(body))
+		}
+		return nil, resp.StatusCode, &ProviderError{Provider: p.name, Err: fmt.Errorf("failed to parse response: %w", err)}
+	}
+
+	if resp.StatusCode != http.StatusOK || chatResp.Error != nil {
+		message := http.StatusText(resp.StatusCode)
+		if chatResp.Error != nil {
+			message = chatResp.Error.Message
+		}
+		return nil, resp.StatusCode, newStatusError(p.name, resp.StatusCode, message)
+	}
+
+	if len(chatResp.Choices) == 0 {
+		return nil, resp.StatusCode, &ProviderError{Provider: p.name, Retryable: true, Err: fmt.Errorf("no response from %s", p.name)}
+	}
+
+	model := chatResp.Model
+	if model == "" {
+		model = p.model
+	}
+	return &CompletionResponse{
+		Text:     chatResp.Choices[0].Message.Content,
+		Provider: p.name,
+		Model:    model,
+		Usage:    TokenUsage{InputTokens: chatResp.Usage.PromptTokens, OutputTokens: chatResp.Usage.CompletionTokens},
+	}, resp.StatusCode, nil
+}
@@ -0,0 +1,123 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ProductDraft is a catalog entry proposed from a product photo, waiting
+// for the seller to confirm it
+type ProductDraft struct {
+	ProductName  string  `json:"product_name"`
+	Category     string  `json:"category,omitempty"`
+	Description  string  `json:"description,omitempty"`
+	DefaultPrice float64 `json:"default_price,omitempty"`
+	DefaultUnit  string  `json:"default_unit,omitempty"`
+	Confidence   float64 `json:"confidence,omitempty"`
+}
+
+// DraftProduct asks a vision model for a catalog entry from a photo and its
+// caption. Price and unit stated in the caption win over the model.
+func DraftProduct(ctx context.Context, llm Provider, image []byte, mimeType, caption string) (*ProductDraft, error) {
+	if !IsAvailable(llm) {
+		return nil, ErrNoProviders
+	}
+	ctx = WithFeature(ctx, FeatureCatalog)
+	prompt, err := DefaultPrompts().Render(ctx, "product_photo", map[string]any{"caption": caption})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := llm.Complete(ctx, &CompletionRequest{
+		System:   prompt.System,
+		Messages: []Message{{Role: "user", Content: prompt.User}},
+		Media:    []MediaPart{{MimeType: mimeType, Data: image}},
+		JSON:     true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	content := StripCodeFence(resp.Text)
+	if err := prompt.Validate(content); err != nil {
+		prompt.RecordOutcome(err)
+		return nil, err
+	}
+	var draft ProductDraft
+	if err := json.Unmarshal([]byte(content), &draft); err != nil {
+		prompt.RecordOutcome(err)
+		return nil, fmt.Errorf("invalid product draft: %w", err)
+	}
+	prompt.RecordOutcome(nil)
+
+	draft.merge(CaptionProduct(caption))
+	if draft.ProductName == "" {
+		return nil, fmt.Errorf("no product name in draft")
+	}
+	return &draft, nil
+}
+
+// merge lets what the seller typed override what the model saw
+func (d *ProductDraft) merge(caption *ProductDraft) {
+	d.ProductName = strings.TrimSpace(d.ProductName)
+	if caption.ProductName != "" && d.ProductName == "" {
+		d.ProductName = caption.ProductName
+	}
+	if caption.DefaultPrice > 0 {
+		d.DefaultPrice = caption.DefaultPrice
+	}
+	if caption.DefaultUnit != "" {
+		d.DefaultUnit = caption.DefaultUnit
+	}
+	if d.DefaultPrice < 0 {
+		d.DefaultPrice = 0
+	}
+	if d.Confidence < 0 || d.Confidence > 1 {
+		d.Confidence = 0
+	}
+}
+
+// captionSkipWords lead a caption without being part of the product name
+var captionSkipWords = map[string]bool{
+	"jual": true, "ready": true, "baru": true, "menu": true, "produk": true, "tambah": true, "promo": true,
+}
+
+// CaptionProduct reads a draft from the caption alone, e.g. "Nasi goreng
+// spesial 15 ribu" or "Es teh 5rb/gelas". Used when no vision model answers.
+func CaptionProduct(caption string) *ProductDraft {
+	tokens := ruleTokenRe.FindAllString(NormalizeText(strings.ToLower(caption)), -1)
+	draft := &ProductDraft{}
+
+	var name []string
+	priceAt := -1
+	for i, tok := range tokens {
+		if isRuleNumber(tok) || priceMarkers[tok] || perUnitMarkers[tok] {
+			if value, err := strconv.ParseFloat(strings.ReplaceAll(tok, ",", "."), 64); err == nil && value >= 100 && priceAt < 0 {
+				draft.DefaultPrice = value
+				priceAt = i
+			}
+			continue
+		}
+		if priceAt >= 0 {
+			if unit := ruleUnits[tok]; unit != "" && draft.DefaultUnit == "" {
+				draft.DefaultUnit = unit
+			}
+			continue
+		}
+		if len(name) == 0 && captionSkipWords[tok] {
+			continue
+		}
+		name = append(name, tok)
+	}
+
+	for i, word := range name {
+		r, size := utf8.DecodeRuneInString(word)
+		name[i] = string(unicode.ToUpper(r)) + word[size:]
+	}
+	draft.ProductName = strings.Join(name, " ")
+	return draft
+}
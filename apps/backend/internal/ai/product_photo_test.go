@@ -0,0 +1,69 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// photoProvider records the request and answers with text
+type photoProvider struct {
+	text string
+	req  *CompletionRequest
+}
+
+func (p *photoProvider) Name() string { return "photo" }
+
+func (p *photoProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	p.req = req
+	return &CompletionResponse{Text: p.text, Provider: "photo"}, nil
+}
+
+func TestCaptionProduct(t *testing.T) {
+	tests := []struct {
+		caption string
+		want    ProductDraft
+	}{
+		{"Nasi goreng spesial 15 ribu", ProductDraft{ProductName: "Nasi Goreng Spesial", DefaultPrice: 15000}},
+		{"Es teh 5rb/gelas", ProductDraft{ProductName: "Es Teh", DefaultPrice: 5000, DefaultUnit: "gelas"}},
+		{"jual keripik pedas harga 12.000 per bungkus", ProductDraft{ProductName: "Keripik Pedas", DefaultPrice: 12000, DefaultUnit: "bungkus"}},
+		{"Paket 2 ayam geprek Rp 25rb", ProductDraft{ProductName: "Paket Ayam Geprek", DefaultPrice: 25000}},
+		{"", ProductDraft{}},
+	}
+	for _, tt := range tests {
+		if got := CaptionProduct(tt.caption); *got != tt.want {
+			t.Errorf("CaptionProduct(%q) = %+v, want %+v", tt.caption, *got, tt.want)
+		}
+	}
+}
+
+func TestDraftProduct(t *testing.T) {
+	llm := &photoProvider{text: "```json\n{\"product_name\":\"Nasi Goreng Spesial\",\"category\":\"Makanan\"," +
+		"\"description\":\"Nasi goreng telur dan ayam suwir\",\"default_price\":20000,\"default_unit\":\"porsi\",\"confidence\":0.9}\n```"}
+	image := []byte{0xFF, 0xD8, 0xFF}
+
+	draft, err := DraftProduct(context.Background(), llm, image, "image/jpeg", "Nasi goreng spesial 15 ribu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(llm.req.Media) != 1 || llm.req.Media[0].MimeType != "image/jpeg" || !llm.req.JSON {
+		t.Errorf("Request = %+v, want the photo attached as JSON request", llm.req)
+	}
+	if !strings.Contains(llm.req.Messages[0].Content, "Nasi goreng spesial 15 ribu") {
+		t.Error("Prompt should carry the caption")
+	}
+	// The price typed by the seller beats the model's guess
+	if draft.ProductName != "Nasi Goreng Spesial" || draft.Category != "Makanan" ||
+		draft.DefaultPrice != 15000 || draft.DefaultUnit != "porsi" {
+		t.Errorf("Draft = %+v", draft)
+	}
+
+	llm.text = `{"category":"Makanan"}`
+	if _, err := DraftProduct(context.Background(), llm, image, "image/jpeg", ""); err == nil {
+		t.Error("Draft without product name should fail")
+	}
+	if _, err := DraftProduct(context.Background(), nil, image, "image/jpeg", ""); !errors.Is(err, ErrNoProviders) {
+		t.Errorf("No provider = %v, want ErrNoProviders", err)
+	}
+}
@@ -0,0 +1,410 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// Prompt templates live in prompts/<name>/<version>.tmpl: a JSON header
+// followed by "--- system ---" and "--- user ---" sections in text/template
+// syntax. Variables are referenced as {{.name}}.
+//
+//go:embed prompts
+var embeddedPrompts embed.FS
+
+const (
+	promptSystemMarker = "--- system ---"
+	promptUserMarker   = "--- user ---"
+)
+
+// PromptTemplate is one version of a prompt
+type PromptTemplate struct {
+	Name         string          `json:"-"`
+	Version      string          `json:"-"`
+	Description  string          `json:"description"`
+	Variables    []string        `json:"variables"`
+	OutputSchema json.RawMessage `json:"output_schema,omitempty"`
+	Draft        bool            `json:"draft,omitempty"` // Only served through an experiment
+
+	system *template.Template
+	user   *template.Template
+}
+
+// PromptVariant rolls a version out to listed tenants or a share of all tenants
+type PromptVariant struct {
+	Version string   `json:"version"`
+	Percent int      `json:"percent,omitempty"` // 0-100 of tenants not listed anywhere
+	Tenants []string `json:"tenants,omitempty"` // User ids or phone numbers
+}
+
+// PromptExperiment overrides which versions of a prompt are served.
+// Rolling back is setting Default and removing the variants.
+type PromptExperiment struct {
+	Prompt   string          `json:"prompt"`
+	Default  string          `json:"default,omitempty"` // Control version, latest non-draft when empty
+	Variants []PromptVariant `json:"variants"`
+}
+
+// RenderedPrompt is a prompt ready to send, tagged with the variant that produced it
+type RenderedPrompt struct {
+	Name    string
+	Version string
+	Tenant  string
+	System  string
+	User    string
+	Schema  json.RawMessage
+
+	registry *PromptRegistry
+}
+
+// VariantID identifies the served version in logs, e.g. "promo_generate@v2"
+func (p *RenderedPrompt) VariantID() string {
+	return p.Name + "@" + p.Version
+}
+
+// PromptStats counts outcomes per variant
+type PromptStats struct {
+	VariantID string `json:"variant_id"`
+	Success   int    `json:"success"`
+	Failure   int    `json:"failure"`
+}
+
+// PromptRegistry holds every prompt version and the active experiments
+type PromptRegistry struct {
+	templates   map[string]map[string]*PromptTemplate // name -> version -> template
+	experiments map[string]*PromptExperiment
+	stats       map[string]*PromptStats
+	mu          sync.RWMutex
+}
+
+var (
+	defaultPrompts     *PromptRegistry
+	defaultPromptsOnce sync.Once
+)
+
+// DefaultPrompts returns the registry of embedded templates
+func DefaultPrompts() *PromptRegistry {
+	defaultPromptsOnce.Do(func() {
+		registry, err := LoadPromptRegistry(embeddedPrompts)
+		if err != nil {
+			panic(fmt.Sprintf("invalid embedded prompt templates: %v", err))
+		}
+		defaultPrompts = registry
+	})
+	return defaultPrompts
+}
+
+// LoadPromptRegistry parses all templates under prompts/ in fsys
+func LoadPromptRegistry(fsys fs.FS) (*PromptRegistry, error) {
+	r := &PromptRegistry{
+		templates:   make(map[string]map[string]*PromptTemplate),
+		experiments: make(map[string]*PromptExperiment),
+		stats:       make(map[string]*PromptStats),
+	}
+
+	err := fs.WalkDir(fsys, "prompts", func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(file) != ".tmpl" {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+		name := path.Base(path.Dir(file))
+		version := strings.TrimSuffix(path.Base(file), ".tmpl")
+		tmpl, err := parsePromptTemplate(name, version, data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		if r.templates[name] == nil {
+			r.templates[name] = make(map[string]*PromptTemplate)
+		}
+		r.templates[name][version] = tmpl
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// parsePromptTemplate reads the JSON header and the system and user sections
+func parsePromptTemplate(name, version string, data []byte) (*PromptTemplate, error) {
+	text := string(data)
+	userAt := strings.Index(text, promptUserMarker)
+	if userAt < 0 {
+		return nil, fmt.Errorf("missing %q section", promptUserMarker)
+	}
+	header, system := text[:userAt], ""
+	if systemAt := strings.Index(header, promptSystemMarker); systemAt >= 0 {
+		header, system = header[:systemAt], header[systemAt+len(promptSystemMarker):]
+	}
+	user := text[userAt+len(promptUserMarker):]
+
+	tmpl := &PromptTemplate{Name: name, Version: version}
+	if err := json.Unmarshal([]byte(header), tmpl); err != nil {
+		return nil, fmt.Errorf("invalid header: %w", err)
+	}
+	if len(tmpl.OutputSchema) > 0 && !json.Valid(tmpl.OutputSchema) {
+		return nil, fmt.Errorf("invalid output_schema")
+	}
+
+	var err error
+	if tmpl.system, err = template.New(name + ".system").Option("missingkey=error").Parse(strings.TrimSpace(system)); err != nil {
+		return nil, err
+	}
+	if tmpl.user, err = template.New(name + ".user").Option("missingkey=error").Parse(strings.TrimSpace(user)); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// LoadPromptExperiments reads {"experiments": [...]} from a JSON file
+func (r *PromptRegistry) LoadPromptExperiments(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	var config struct {
+		Experiments []PromptExperiment `json:"experiments"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("invalid prompt experiments: %w", err)
+	}
+	for i := range config.Experiments {
+		if err := r.SetExperiment(&config.Experiments[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetExperiment replaces the experiment of a prompt after checking its versions exist
+func (r *PromptRegistry) SetExperiment(exp *PromptExperiment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions, ok := r.templates[exp.Prompt]
+	if !ok {
+		return fmt.Errorf("unknown prompt %q", exp.Prompt)
+	}
+	if _, ok := versions[exp.Default]; exp.Default != "" && !ok {
+		return fmt.Errorf("unknown version %s@%s", exp.Prompt, exp.Default)
+	}
+	total := 0
+	for _, variant := range exp.Variants {
+		if _, ok := versions[variant.Version]; !ok {
+			return fmt.Errorf("unknown version %s@%s", exp.Prompt, variant.Version)
+		}
+		total += variant.Percent
+	}
+	if total > 100 {
+		return fmt.Errorf("experiment %s rolls out %d%%", exp.Prompt, total)
+	}
+
+	r.experiments[exp.Prompt] = exp
+	log.Printf("🧪 Prompt experiment %s: default %q, %d variant(s)", exp.Prompt, exp.Default, len(exp.Variants))
+	return nil
+}
+
+// ClearExperiment rolls a prompt back to its latest non-draft version
+func (r *PromptRegistry) ClearExperiment(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.experiments, name)
+}
+
+// Select picks the version served to a tenant: listed tenants first, then
+// the percentage buckets, then the experiment default or latest non-draft.
+func (r *PromptRegistry) Select(name, tenant string) (*PromptTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions, ok := r.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown prompt %q", name)
+	}
+
+	exp := r.experiments[name]
+	if exp != nil && tenant != "" {
+		for _, variant := range exp.Variants {
+			for _, t := range variant.Tenants {
+				if t == tenant {
+					return versions[variant.Version], nil
+				}
+			}
+		}
+		bucket := promptBucket(name, tenant)
+		for _, variant := range exp.Variants {
+			if bucket < variant.Percent {
+				return versions[variant.Version], nil
+			}
+			bucket -= variant.Percent
+		}
+	}
+	if exp != nil && exp.Default != "" {
+		return versions[exp.Default], nil
+	}
+	return latestPrompt(versions)
+}
+
+// promptBucket assigns a tenant a stable 0-99 bucket per prompt
+func promptBucket(name, tenant string) int {
+	h := fnv.New32a()
+	h.Write([]byte(name + ":" + tenant))
+	return int(h.Sum32() % 100)
+}
+
+// latestPrompt returns the highest non-draft version, comparing "v10" after "v9"
+func latestPrompt(versions map[string]*PromptTemplate) (*PromptTemplate, error) {
+	var names []string
+	for version, tmpl := range versions {
+		if !tmpl.Draft {
+			names = append(names, version)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no released version")
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, errA := strconv.Atoi(strings.TrimPrefix(names[i], "v"))
+		b, errB := strconv.Atoi(strings.TrimPrefix(names[j], "v"))
+		if errA != nil || errB != nil {
+			return names[i] < names[j]
+		}
+		return a < b
+	})
+	return versions[names[len(names)-1]], nil
+}
+
+// Render fills the version selected for the context's tenant
+func (r *PromptRegistry) Render(ctx context.Context, name string, vars map[string]any) (*RenderedPrompt, error) {
+	tenant := TenantFromContext(ctx)
+	tmpl, err := r.Select(name, tenant)
+	if err != nil {
+		return nil, err
+	}
+	return tmpl.render(r, tenant, vars)
+}
+
+// RenderVersion fills a specific version, e.g. for evaluation
+func (r *PromptRegistry) RenderVersion(name, version string, vars map[string]any) (*RenderedPrompt, error) {
+	r.mu.RLock()
+	tmpl, ok := r.templates[name][version]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown version %s@%s", name, version)
+	}
+	return tmpl.render(r, "", vars)
+}
+
+func (t *PromptTemplate) render(r *PromptRegistry, tenant string, vars map[string]any) (*RenderedPrompt, error) {
+	for _, variable := range t.Variables {
+		if _, ok := vars[variable]; !ok {
+			return nil, fmt.Errorf("prompt %s@%s: missing variable %q", t.Name, t.Version, variable)
+		}
+	}
+
+	var system, user bytes.Buffer
+	if err := t.system.Execute(&system, vars); err != nil {
+		return nil, err
+	}
+	if err := t.user.Execute(&user, vars); err != nil {
+		return nil, err
+	}
+	return &RenderedPrompt{
+		Name:     t.Name,
+		Version:  t.Version,
+		Tenant:   tenant,
+		System:   system.String(),
+		User:     user.String(),
+		Schema:   t.OutputSchema,
+		registry: r,
+	}, nil
+}
+
+// Validate checks a JSON answer against the required fields of the output schema
+func (p *RenderedPrompt) Validate(content string) error {
+	if len(p.Schema) == 0 {
+		return nil
+	}
+	var schema struct {
+		Required []string `json:"required"`
+	}
+	if err := json.Unmarshal(p.Schema, &schema); err != nil {
+		return err
+	}
+	var output map[string]any
+	if err := json.Unmarshal([]byte(content), &output); err != nil {
+		return fmt.Errorf("output is not a JSON object: %w", err)
+	}
+	for _, field := range schema.Required {
+		if _, ok := output[field]; !ok {
+			return fmt.Errorf("output misses %q", field)
+		}
+	}
+	return nil
+}
+
+// RecordOutcome logs the result with the variant id and counts it for the experiment
+func (p *RenderedPrompt) RecordOutcome(err error) {
+	if err != nil {
+		log.Printf("🧪 Prompt %s tenant=%s outcome=failure: %v", p.VariantID(), p.Tenant, err)
+	} else {
+		log.Printf("🧪 Prompt %s tenant=%s outcome=success", p.VariantID(), p.Tenant)
+	}
+	if p.registry == nil {
+		return
+	}
+
+	p.registry.mu.Lock()
+	defer p.registry.mu.Unlock()
+	stats := p.registry.stats[p.VariantID()]
+	if stats == nil {
+		stats = &PromptStats{VariantID: p.VariantID()}
+		p.registry.stats[p.VariantID()] = stats
+	}
+	if err != nil {
+		stats.Failure++
+	} else {
+		stats.Success++
+	}
+}
+
+// Stats returns outcome counts per variant, sorted by variant id
+func (r *PromptRegistry) Stats() []PromptStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var stats []PromptStats
+	for _, s := range r.stats {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].VariantID < stats[j].VariantID })
+	return stats
+}
+
+type tenantKey struct{}
+
+// WithTenant tags ctx with the user prompts are rendered for
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant set by WithTenant, or ""
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
@@ -0,0 +1,140 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDefaultPrompts(t *testing.T) {
+	r := DefaultPrompts()
+	for _, name := range []string{"intent", "transcribe", "transcribe_confidence", "categorize", "promo_generate", "promo_bundle", "social_media"} {
+		if _, err := r.Select(name, ""); err != nil {
+			t.Errorf("Select(%s) error: %v", name, err)
+		}
+	}
+
+	p, err := r.Render(context.Background(), "social_media", map[string]any{
+		"platform": "instagram", "product_name": "Kopi Susu", "price": "15.000", "promotion": "", "tone": "casual",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(p.User, "Buat caption Instagram") || strings.Contains(p.User, "Promo:") {
+		t.Errorf("Rendered social_media prompt = %s", p.User)
+	}
+
+	if _, err := r.Render(context.Background(), "social_media", map[string]any{"platform": "instagram"}); err == nil {
+		t.Error("Render with missing variables should fail")
+	}
+}
+
+func testPromptRegistry(t *testing.T) *PromptRegistry {
+	t.Helper()
+	tmpl := func(header string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(header + "\n--- system ---\nsystem {{.x}}\n--- user ---\nuser {{.x}}")}
+	}
+	r, err := LoadPromptRegistry(fstest.MapFS{
+		"prompts/greet/v1.tmpl":  tmpl(`{"variables":["x"],"output_schema":{"required":["reply"]}}`),
+		"prompts/greet/v2.tmpl":  tmpl(`{"variables":["x"]}`),
+		"prompts/greet/v10.tmpl": tmpl(`{"variables":["x"],"draft":true}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestPromptRegistry_Select(t *testing.T) {
+	r := testPromptRegistry(t)
+
+	selected := func(tenant string) string {
+		tmpl, err := r.Select("greet", tenant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tmpl.Version
+	}
+
+	if got := selected("628111"); got != "v2" {
+		t.Errorf("Without experiment = %s, want latest released v2", got)
+	}
+
+	if err := r.SetExperiment(&PromptExperiment{Prompt: "greet", Default: "v1", Variants: []PromptVariant{
+		{Version: "v10", Tenants: []string{"628999"}},
+		{Version: "v2", Percent: 100},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		tenant string
+		want   string
+	}{
+		{"628999", "v10"},
+		{"628111", "v2"},
+		{"", "v1"},
+	}
+	for _, tt := range tests {
+		if got := selected(tt.tenant); got != tt.want {
+			t.Errorf("Select(%q) = %s, want %s", tt.tenant, got, tt.want)
+		}
+	}
+
+	// Rolling back keeps the control version for everyone
+	if err := r.SetExperiment(&PromptExperiment{Prompt: "greet", Default: "v1"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := selected("628999"); got != "v1" {
+		t.Errorf("After rollback = %s, want v1", got)
+	}
+	r.ClearExperiment("greet")
+	if got := selected("628999"); got != "v2" {
+		t.Errorf("After clear = %s, want v2", got)
+	}
+
+	for _, bad := range []*PromptExperiment{
+		{Prompt: "nope"},
+		{Prompt: "greet", Default: "v9"},
+		{Prompt: "greet", Variants: []PromptVariant{{Version: "v1", Percent: 60}, {Version: "v2", Percent: 60}}},
+	} {
+		if err := r.SetExperiment(bad); err == nil {
+			t.Errorf("SetExperiment(%+v) should fail", bad)
+		}
+	}
+}
+
+func TestPromptRegistry_Outcomes(t *testing.T) {
+	r := testPromptRegistry(t)
+	ctx := WithTenant(context.Background(), "628111")
+
+	p, err := r.Render(ctx, "greet", map[string]any{"x": "halo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.System != "system halo" || p.User != "user halo" || p.Tenant != "628111" {
+		t.Errorf("Rendered = %+v", p)
+	}
+
+	v1, err := r.RenderVersion("greet", "v1", map[string]any{"x": "halo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v1.Validate(`{"reply":"hai"}`); err != nil {
+		t.Errorf("Validate() error: %v", err)
+	}
+	for _, bad := range []string{`{"other":1}`, `not json`} {
+		if err := v1.Validate(bad); err == nil {
+			t.Errorf("Validate(%s) should fail", bad)
+		}
+	}
+
+	p.RecordOutcome(nil)
+	p.RecordOutcome(nil)
+	v1.RecordOutcome(errors.New("bad json"))
+	stats := r.Stats()
+	if len(stats) != 2 || stats[0] != (PromptStats{"greet@v1", 0, 1}) || stats[1] != (PromptStats{"greet@v2", 2, 0}) {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
@@ -0,0 +1,157 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Message is one chat turn sent to an LLM
+type Message struct {
+	Role    string `json:"role"` // user, assistant
+	Content string `json:"content"`
+}
+
+// MediaPart is inline audio or image data for multimodal requests
+type MediaPart struct {
+	MimeType string
+	Data     []byte
+}
+
+// CompletionRequest is a provider-agnostic LLM request
+type CompletionRequest struct {
+	System   string
+	Messages []Message
+	Media    []MediaPart // Attached to the last user message
+	JSON     bool        // Ask for a JSON-only answer
+}
+
+// CompletionResponse is the text answer and who produced it
+type CompletionResponse struct {
+	Text     string
+	Provider string
+	Model    string
+	Usage    TokenUsage
+}
+
+// TokenUsage is the token count reported by the provider, zero when unknown
+type TokenUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// Provider is an LLM backend (Kolosal, Gemini, OpenAI-compatible, or a Router)
+type Provider interface {
+	Name() string
+	Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error)
+}
+
+var (
+	// ErrNoProviders is returned when no provider is configured
+	ErrNoProviders = errors.New("no LLM provider configured")
+	// ErrMediaUnsupported is returned by text-only providers for audio/image requests
+	ErrMediaUnsupported = errors.New("provider does not support media input")
+)
+
+// ProviderError is a failed provider call, classified for the router
+type ProviderError struct {
+	Provider   string
+	StatusCode int
+	Retryable  bool // Transient: network, 429, 5xx
+	Err        error
+}
+
+func (e *ProviderError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("%s API error (%d): %v", e.Provider, e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Provider, e.Err)
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// newStatusError classifies an HTTP error status
+func newStatusError(provider string, status int, message string) *ProviderError {
+	err := &ProviderError{
+		Provider:   provider,
+		StatusCode: status,
+		Retryable:  status == 429 || status >= 500,
+		Err:        errors.New(message),
+	}
+	if status == 401 {
+		err.Err = errors.New("authentication error: invalid API key")
+	}
+	return err
+}
+
+// isKeyError reports whether the status means the current key is unusable
+// (invalid, out of quota or rate limited) so the next key should be tried
+func isKeyError(status int) bool {
+	return status == 401 || status == 403 || status == 429
+}
+
+// IsRetryable reports whether err is worth retrying
+func IsRetryable(err error) bool {
+	var perr *ProviderError
+	if errors.As(err, &perr) {
+		return perr.Retryable
+	}
+	return false
+}
+
+// IsAvailable reports whether p can serve requests at all
+func IsAvailable(p Provider) bool {
+	if p == nil {
+		return false
+	}
+	switch r := p.(type) {
+	case *Router:
+		return r != nil && r.Len() > 0
+	case *MeteredProvider:
+		return r != nil && IsAvailable(r.next)
+	}
+	return true
+}
+
+// CompleteText sends a single prompt and returns the answer text
+func CompleteText(ctx context.Context, p Provider, system, prompt string) (string, error) {
+	if !IsAvailable(p) {
+		return "", ErrNoProviders
+	}
+	resp, err := p.Complete(ctx, &CompletionRequest{
+		System:   system,
+		Messages: []Message{{Role: "user", Content: prompt}},
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.Text, nil
+}
+
+// CompleteJSON sends a single prompt, asks for JSON and strips code fences
+func CompleteJSON(ctx context.Context, p Provider, system, prompt string) (string, error) {
+	if !IsAvailable(p) {
+		return "", ErrNoProviders
+	}
+	resp, err := p.Complete(ctx, &CompletionRequest{
+		System:   system,
+		Messages: []Message{{Role: "user", Content: prompt}},
+		JSON:     true,
+	})
+	if err != nil {
+		return "", err
+	}
+	return StripCodeFence(resp.Text), nil
+}
+
+// StripCodeFence removes ```json fences that models add around JSON
+func StripCodeFence(content string) string {
+	content = strings.TrimSpace(content)
+	content = strings.TrimPrefix(content, "```json")
+	content = strings.TrimPrefix(content, "```")
+	content = strings.TrimSuffix(content, "```")
+	return strings.TrimSpace(content)
+}
@@ -0,0 +1,336 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrInvalidQueryPlan is returned for plans outside the whitelist
+var ErrInvalidQueryPlan = errors.New("invalid query plan")
+
+// QueryPlan is a read-only question over the user's own books. The LLM
+// only picks from whitelisted sources, metrics, filters and groupings; the
+// backend scopes it to the tenant and executes it. It never becomes SQL.
+type QueryPlan struct {
+	Source  string        `json:"source"` // transactions, inventory, contacts
+	Metric  string        `json:"metric"` // sum_amount, sum_qty, count, avg_price, last, profit, list
+	Filters []QueryFilter `json:"filters,omitempty"`
+	GroupBy string        `json:"group_by,omitempty"`
+	Period  string        `json:"period,omitempty"`  // today ... all, empty for the whole history
+	Compare string        `json:"compare,omitempty"` // previous_period
+	Order   string        `json:"order,omitempty"`   // desc, asc
+	Limit   int           `json:"limit,omitempty"`
+}
+
+// QueryFilter narrows the rows of a plan
+type QueryFilter struct {
+	Field string `json:"field"`
+	Op    string `json:"op"` // eq, contains, gt, lt
+	Value any    `json:"value"`
+}
+
+// querySource is what one source allows
+type querySource struct {
+	metrics []string
+	groupBy []string
+	filters map[string][]string // field -> ops
+	enums   map[string][]string // field -> allowed values
+}
+
+var querySources = map[string]querySource{
+	"transactions": {
+		metrics: []string{"sum_amount", "sum_qty", "count", "avg_price", "last", "profit", "list"},
+		groupBy: []string{"product", "type", "day", "week", "month"},
+		filters: map[string][]string{
+			"type":         {"eq"},
+			"product_name": {"eq", "contains"},
+			"qty":          {"gt", "lt"},
+			"total_amount": {"gt", "lt"},
+		},
+		enums: map[string][]string{"type": {"SALE", "PURCHASE", "EXPENSE"}},
+	},
+	"inventory": {
+		metrics: []string{"sum_qty", "count", "list"},
+		groupBy: []string{"product"},
+		filters: map[string][]string{
+			"product_name": {"eq", "contains"},
+			"stock_qty":    {"gt", "lt"},
+		},
+	},
+	"contacts": {
+		metrics: []string{"count", "list"},
+		groupBy: []string{"type", "city"},
+		filters: map[string][]string{
+			"type": {"eq"},
+			"name": {"contains"},
+			"city": {"eq", "contains"},
+		},
+		enums: map[string][]string{"type": {"SUPPLIER", "CUSTOMER"}},
+	},
+}
+
+// QueryPeriods are the periods a plan may name
+var QueryPeriods = []string{
+	"today", "yesterday", "this_week", "last_week", "this_month", "last_month",
+	"last_7_days", "last_30_days", "this_year", "all",
+}
+
+const (
+	defaultQueryLimit = 5
+	maxQueryLimit     = 20
+	maxFilterText     = 60
+)
+
+// Validate checks the plan against the whitelist and normalizes it:
+// lower-case names, upper-case enum values, a bounded limit
+func (p *QueryPlan) Validate() error {
+	p.Source = strings.ToLower(strings.TrimSpace(p.Source))
+	p.Metric = strings.ToLower(strings.TrimSpace(p.Metric))
+	p.GroupBy = strings.ToLower(strings.TrimSpace(p.GroupBy))
+	p.Period = strings.ToLower(strings.TrimSpace(p.Period))
+	p.Compare = strings.ToLower(strings.TrimSpace(p.Compare))
+	p.Order = strings.ToLower(strings.TrimSpace(p.Order))
+
+	src, ok := querySources[p.Source]
+	if !ok {
+		return fmt.Errorf("%w: source %q", ErrInvalidQueryPlan, p.Source)
+	}
+	if !slices.Contains(src.metrics, p.Metric) {
+		return fmt.Errorf("%w: metric %q on %s", ErrInvalidQueryPlan, p.Metric, p.Source)
+	}
+	if p.GroupBy == "none" {
+		p.GroupBy = ""
+	}
+	if p.GroupBy != "" && !slices.Contains(src.groupBy, p.GroupBy) {
+		return fmt.Errorf("%w: group_by %q on %s", ErrInvalidQueryPlan, p.GroupBy, p.Source)
+	}
+	if p.Period != "" && !slices.Contains(QueryPeriods, p.Period) {
+		return fmt.Errorf("%w: period %q", ErrInvalidQueryPlan, p.Period)
+	}
+	if p.Source != "transactions" {
+		p.Period = "" // Stock and contacts are current state
+	}
+	if p.Compare != "" && (p.Compare != "previous_period" || p.Source != "transactions") {
+		return fmt.Errorf("%w: compare %q", ErrInvalidQueryPlan, p.Compare)
+	}
+	if p.Order != "asc" {
+		p.Order = "desc"
+	}
+	if p.Limit <= 0 {
+		p.Limit = defaultQueryLimit
+	}
+	p.Limit = min(p.Limit, maxQueryLimit)
+
+	for i := range p.Filters {
+		f := &p.Filters[i]
+		f.Field = strings.ToLower(strings.TrimSpace(f.Field))
+		f.Op = strings.ToLower(strings.TrimSpace(f.Op))
+		ops, ok := src.filters[f.Field]
+		if !ok || !slices.Contains(ops, f.Op) {
+			return fmt.Errorf("%w: filter %s %s on %s", ErrInvalidQueryPlan, f.Field, f.Op, p.Source)
+		}
+
+		switch f.Op {
+		case "gt", "lt":
+			if _, ok := f.Value.(float64); !ok {
+				return fmt.Errorf("%w: %s %s needs a number", ErrInvalidQueryPlan, f.Field, f.Op)
+			}
+		default:
+			text, ok := f.Value.(string)
+			text = strings.TrimSpace(text)
+			if !ok || text == "" || len(text) > maxFilterText {
+				return fmt.Errorf("%w: %s %s needs a short text", ErrInvalidQueryPlan, f.Field, f.Op)
+			}
+			if allowed, isEnum := src.enums[f.Field]; isEnum {
+				text = strings.ToUpper(text)
+				if !slices.Contains(allowed, text) {
+					return fmt.Errorf("%w: %s %q", ErrInvalidQueryPlan, f.Field, text)
+				}
+			}
+			f.Value = text
+		}
+	}
+	return nil
+}
+
+// TextFilter returns the text value filtering field, or ""
+func (p *QueryPlan) TextFilter(field string) string {
+	for _, f := range p.Filters {
+		if f.Field == field {
+			if s, ok := f.Value.(string); ok {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// Range resolves the plan's period to days in now's location; nil means
+// the whole history
+func (p *QueryPlan) Range(now time.Time) *DateRange {
+	today := startOfDay(now)
+	switch p.Period {
+	case "today":
+		return &DateRange{Start: today, End: today, Period: "daily"}
+	case "yesterday":
+		return singleDay(today.AddDate(0, 0, -1))
+	case "this_week":
+		return &DateRange{Start: startOfWeek(now), End: today, Period: "weekly"}
+	case "last_week":
+		start := startOfWeek(now).AddDate(0, 0, -7)
+		return &DateRange{Start: start, End: start.AddDate(0, 0, 6)}
+	case "this_month":
+		return &DateRange{Start: startOfMonth(now), End: today, Period: "monthly"}
+	case "last_month":
+		start := startOfMonth(now).AddDate(0, -1, 0)
+		return &DateRange{Start: start, End: startOfMonth(now).AddDate(0, 0, -1)}
+	case "last_7_days":
+		return &DateRange{Start: today.AddDate(0, 0, -6), End: today}
+	case "last_30_days":
+		return &DateRange{Start: today.AddDate(0, 0, -29), End: today}
+	case "this_year":
+		return &DateRange{Start: time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location()), End: today}
+	}
+	return nil
+}
+
+// PreviousRange is the period before r to compare with: the whole previous
+// week or month for calendar periods, else as many days right before
+func PreviousRange(r *DateRange) *DateRange {
+	switch r.Period {
+	case "weekly":
+		start := r.Start.AddDate(0, 0, -7)
+		return &DateRange{Start: start, End: start.AddDate(0, 0, 6)}
+	case "monthly":
+		start := r.Start.AddDate(0, -1, 0)
+		return &DateRange{Start: start, End: r.Start.AddDate(0, 0, -1)}
+	}
+	days := r.Days()
+	return &DateRange{Start: r.Start.AddDate(0, 0, -days), End: r.Start.AddDate(0, 0, -1)}
+}
+
+// PlanQuery asks the LLM to translate a question into a validated query plan
+func PlanQuery(ctx context.Context, llm Provider, question string, now time.Time) (*QueryPlan, error) {
+	if !IsAvailable(llm) {
+		return nil, ErrNoProviders
+	}
+	ctx = WithFeature(ctx, FeatureAskData)
+	prompt, err := DefaultPrompts().Render(ctx, "query_plan", map[string]any{
+		"question": question,
+		"today":    now.Format("2006-01-02 (Monday)"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	content, err := CompleteJSON(ctx, llm, prompt.System, prompt.User)
+	if err != nil {
+		return nil, err
+	}
+	if err := prompt.Validate(content); err != nil {
+		prompt.RecordOutcome(err)
+		return nil, err
+	}
+
+	var plan QueryPlan
+	if err := json.Unmarshal([]byte(content), &plan); err != nil {
+		prompt.RecordOutcome(err)
+		return nil, fmt.Errorf("failed to parse query plan: %w", err)
+	}
+	err = plan.Validate()
+	prompt.RecordOutcome(err)
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// Periods named in questions
+var questionPeriods = []struct{ phrase, period string }{
+	{"minggu lalu", "last_week"}, {"minggu kemarin", "last_week"}, {"pekan lalu", "last_week"},
+	{"bulan lalu", "last_month"}, {"bulan kemarin", "last_month"}, {"sasi wingi", "last_month"},
+	{"minggu ini", "this_week"}, {"pekan ini", "this_week"}, {"bulan ini", "this_month"},
+	{"sasi iki", "this_month"}, {"tahun ini", "this_year"}, {"hari ini", "today"}, {"dina iki", "today"},
+	{"kemarin", "yesterday"}, {"wingi", "yesterday"},
+}
+
+// RuleQueryPlan covers the most common questions without the LLM, for when
+// it is unavailable or out of quota. Returns nil when nothing matches.
+func RuleQueryPlan(question string) *QueryPlan {
+	text := " " + strings.Join(strings.Fields(strings.ToLower(NormalizeText(question))), " ") + " "
+	text = strings.NewReplacer("?", " ", "!", " ", ",", " ").Replace(text)
+	has := func(words ...string) bool {
+		for _, w := range words {
+			if strings.Contains(text, " "+w+" ") {
+				return true
+			}
+		}
+		return false
+	}
+	// The first period named is asked about, a later one is compared with
+	period, periodAt := "", len(text)
+	for _, qp := range questionPeriods {
+		if at := strings.Index(text, " "+qp.phrase+" "); at >= 0 && at < periodAt {
+			period, periodAt = qp.period, at
+		}
+	}
+
+	var plan *QueryPlan
+	switch {
+	case has("paling laku", "paling laris", "terlaris", "paling payu", "paling banyak terjual"):
+		plan = &QueryPlan{Source: "transactions", Metric: "sum_qty", GroupBy: "product",
+			Filters: []QueryFilter{{Field: "type", Op: "eq", Value: "SALE"}}}
+	case has("terakhir", "pungkasan"):
+		plan = &QueryPlan{Source: "transactions", Metric: "last", Period: "all"}
+		if product := questionProduct(text); product != "" {
+			plan.Filters = append(plan.Filters, QueryFilter{Field: "product_name", Op: "contains", Value: product})
+		}
+		if has("jual", "laku", "payu") {
+			plan.Filters = append(plan.Filters, QueryFilter{Field: "type", Op: "eq", Value: "SALE"})
+		}
+	case has("untung", "laba", "rugi", "bathi", "kauntungan"):
+		plan = &QueryPlan{Source: "transactions", Metric: "profit"}
+		if has("dibanding", "dibandingkan", "banding", "vs") {
+			plan.Compare = "previous_period"
+		}
+	case has("supplier", "pemasok", "langganan", "pelanggan") && has("berapa", "piro", "sabaraha"):
+		kind := "SUPPLIER"
+		if has("langganan", "pelanggan") {
+			kind = "CUSTOMER"
+		}
+		plan = &QueryPlan{Source: "contacts", Metric: "count",
+			Filters: []QueryFilter{{Field: "type", Op: "eq", Value: kind}}}
+	default:
+		return nil
+	}
+	if plan.Period == "" {
+		plan.Period = period
+	}
+	if plan.Validate() != nil {
+		return nil
+	}
+	return plan
+}
+
+// questionProduct is what follows the verb in "kapan terakhir beli gas"
+func questionProduct(text string) string {
+	tokens := strings.Fields(text)
+	for i, tok := range tokens {
+		if intentKeywords[tok] == "" {
+			continue
+		}
+		var product []string
+		for _, t := range tokens[i+1:] {
+			if ruleFillers[t] || t == "kapan" || t == "terakhir" || t == "kali" {
+				continue
+			}
+			product = append(product, t)
+		}
+		return strings.Join(product, " ")
+	}
+	return ""
+}
@@ -0,0 +1,134 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// scriptedProvider always answers with text
+type scriptedProvider struct {
+	text string
+}
+
+func (s *scriptedProvider) Name() string { return "scripted" }
+
+func (s *scriptedProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	return &CompletionResponse{Text: s.text, Provider: "scripted"}, nil
+}
+
+func TestQueryPlan_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		plan QueryPlan
+		ok   bool
+	}{
+		{"top products", QueryPlan{Source: "transactions", Metric: "sum_qty", GroupBy: "product", Period: "this_month",
+			Filters: []QueryFilter{{Field: "type", Op: "eq", Value: "sale"}}}, true},
+		{"stock below", QueryPlan{Source: "Inventory", Metric: "list",
+			Filters: []QueryFilter{{Field: "stock_qty", Op: "lt", Value: 5.0}}}, true},
+		{"unknown source", QueryPlan{Source: "users", Metric: "count"}, false},
+		{"raw sql", QueryPlan{Source: "transactions; DROP TABLE users", Metric: "count"}, false},
+		{"write metric", QueryPlan{Source: "transactions", Metric: "delete"}, false},
+		{"other tenant", QueryPlan{Source: "transactions", Metric: "count",
+			Filters: []QueryFilter{{Field: "user_id", Op: "eq", Value: "someone-else"}}}, false},
+		{"unknown op", QueryPlan{Source: "transactions", Metric: "count",
+			Filters: []QueryFilter{{Field: "product_name", Op: "regex", Value: ".*"}}}, false},
+		{"bad enum", QueryPlan{Source: "transactions", Metric: "count",
+			Filters: []QueryFilter{{Field: "type", Op: "eq", Value: "REFUND"}}}, false},
+		{"number as text", QueryPlan{Source: "transactions", Metric: "count",
+			Filters: []QueryFilter{{Field: "qty", Op: "gt", Value: "5"}}}, false},
+		{"group not allowed", QueryPlan{Source: "contacts", Metric: "count", GroupBy: "product"}, false},
+		{"unknown period", QueryPlan{Source: "transactions", Metric: "count", Period: "forever"}, false},
+		{"compare stock", QueryPlan{Source: "inventory", Metric: "count", Compare: "previous_period"}, false},
+	}
+	for _, tt := range tests {
+		err := tt.plan.Validate()
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: Validate() = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidQueryPlan) {
+			t.Errorf("%s: err = %v, want ErrInvalidQueryPlan", tt.name, err)
+		}
+	}
+
+	plan := QueryPlan{Source: "transactions", Metric: "list", Limit: 500,
+		Filters: []QueryFilter{{Field: "type", Op: "eq", Value: "sale"}}}
+	if err := plan.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if plan.Limit != maxQueryLimit || plan.Order != "desc" || plan.TextFilter("type") != "SALE" {
+		t.Errorf("Normalized plan = %+v", plan)
+	}
+}
+
+func TestRuleQueryPlan(t *testing.T) {
+	tests := []struct {
+		question string
+		source   string
+		metric   string
+		period   string
+		compare  string
+		product  string
+	}{
+		{"bulan ini paling laku apa?", "transactions", "sum_qty", "this_month", "", ""},
+		{"kapan terakhir beli gas?", "transactions", "last", "all", "", "gas"},
+		{"untung minggu ini dibanding minggu lalu?", "transactions", "profit", "this_week", "previous_period", ""},
+		{"supplier saya ada berapa", "contacts", "count", "", "", ""},
+	}
+	for _, tt := range tests {
+		plan := RuleQueryPlan(tt.question)
+		if plan == nil {
+			t.Errorf("%q: no plan", tt.question)
+			continue
+		}
+		if plan.Source != tt.source || plan.Metric != tt.metric || plan.Period != tt.period || plan.Compare != tt.compare {
+			t.Errorf("%q: plan = %+v", tt.question, plan)
+		}
+		if got := plan.TextFilter("product_name"); got != tt.product {
+			t.Errorf("%q: product = %q, want %q", tt.question, got, tt.product)
+		}
+	}
+	if plan := RuleQueryPlan("cuaca hari ini cerah"); plan != nil {
+		t.Errorf("Unrelated text planned as %+v", plan)
+	}
+}
+
+func TestPlanQuery(t *testing.T) {
+	now := time.Date(2026, time.October, 15, 10, 0, 0, 0, UserLocation(""))
+
+	llm := &scriptedProvider{text: "```json\n{\"source\":\"transactions\",\"metric\":\"sum_amount\",\"group_by\":\"product\",\"period\":\"last_week\"}\n```"}
+	plan, err := PlanQuery(context.Background(), llm, "minggu lalu omzet per produk", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := plan.Range(now)
+	if r.Start.Format("2006-01-02") != "2026-10-05" || r.End.Format("2006-01-02") != "2026-10-11" {
+		t.Errorf("last_week = %s", FormatDateRange(r))
+	}
+
+	llm.text = `{"source":"users","metric":"list"}`
+	if _, err := PlanQuery(context.Background(), llm, "daftar semua user", now); !errors.Is(err, ErrInvalidQueryPlan) {
+		t.Errorf("err = %v, want ErrInvalidQueryPlan", err)
+	}
+}
+
+func TestPreviousRange(t *testing.T) {
+	now := time.Date(2026, time.October, 15, 10, 0, 0, 0, UserLocation(""))
+	tests := []struct {
+		period     string
+		start, end string
+	}{
+		{"this_week", "2026-10-05", "2026-10-11"},
+		{"this_month", "2026-09-01", "2026-09-30"},
+		{"last_7_days", "2026-10-02", "2026-10-08"},
+		{"today", "2026-10-14", "2026-10-14"},
+	}
+	for _, tt := range tests {
+		prev := PreviousRange((&QueryPlan{Period: tt.period}).Range(now))
+		if got := prev.Start.Format("2006-01-02") + ".." + prev.End.Format("2006-01-02"); got != tt.start+".."+tt.end {
+			t.Errorf("%s: previous = %s, want %s..%s", tt.period, got, tt.start, tt.end)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package ai
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Words of recurring transactions (id, jv, su)
+var (
+	recurringMarkers = map[string]bool{"tiap": true, "setiap": true, "saben": true, "unggal": true, "saban": true}
+	scheduleWords    = map[string]bool{
+		"jadwal": true, "jadwalnya": true, "jadwale": true, "rutin": true, "rutinan": true, "berulang": true,
+	}
+	recurringOps = map[string]string{
+		"jeda": "PAUSE", "pause": "PAUSE", "hentikan": "PAUSE", "stop": "PAUSE", "tunda": "PAUSE", "libur": "PAUSE",
+		"lanjutkan": "RESUME", "lanjut": "RESUME", "aktifkan": "RESUME", "jalankan": "RESUME", "resume": "RESUME",
+		"hapus": "DELETE", "busak": "DELETE", "buang": "DELETE",
+	}
+	dayWords     = map[string]bool{"hari": true, "dina": true, "dinten": true, "poe": true}
+	weekWords    = map[string]bool{"minggu": true, "pekan": true, "minggon": true}
+	monthWords   = map[string]bool{"bulan": true, "sasi": true, "wulan": true, "sasih": true}
+	dateWords    = map[string]bool{"tanggal": true, "tgl": true, "tanggale": true, "kaping": true}
+	autoWords    = map[string]bool{"otomatis": true, "langsung": true, "auto": true, "otomatisnya": true}
+	eveningWords = map[string]bool{"sore": true, "malam": true, "sonten": true, "dalu": true, "wengi": true, "peuting": true}
+	// Words around the item of a rule being changed that are not part of it
+	recurringFillers = map[string]bool{
+		"transaksi": true, "pengeluaran": true, "penjualan": true, "pembelian": true, "tagihan": true,
+		"lihat": true, "liat": true, "daftar": true, "cek": true, "semua": true,
+	}
+)
+
+// parseRecurringRules recognises recurring transactions ("tiap tanggal 1
+// bayar sewa 1 juta", "setiap hari Senin beli gas 2 tabung 25rb otomatis"),
+// listing them ("jadwal rutin") and pausing, resuming or deleting one ("jeda
+// jadwal sewa"). action is the keyword intent of the message.
+func parseRecurringRules(tokens []string, action string, intent *Intent) bool {
+	marked, scheduled := false, false
+	for _, tok := range tokens {
+		marked = marked || recurringMarkers[tok]
+		scheduled = scheduled || scheduleWords[tok]
+	}
+	if !marked && !scheduled {
+		return false
+	}
+
+	if marked {
+		if entities, rest := recurringSchedule(tokens); entities != nil && len(rest) > 0 {
+			restAction, at, _ := matchIntentKeyword(rest)
+			if restAction == "" && creditAmount(rest) == 0 {
+				return false
+			}
+			switch restAction {
+			case "", "RECORD_SALE", "RECORD_EXPENSE", "ORDER_RESTOCK":
+			default:
+				return false
+			}
+
+			intent.Action = "SET_RECURRING"
+			for k, v := range entities {
+				intent.Entities[k] = v
+			}
+			switch restAction {
+			case "RECORD_SALE":
+				intent.Entities["type"] = "SALE"
+			case "ORDER_RESTOCK":
+				intent.Entities["type"] = "PURCHASE"
+			default:
+				intent.Entities["type"] = "EXPENSE"
+			}
+			extractRuleNumbers(rest, intent)
+			if product := extractRuleProduct(rest, at); product != "" {
+				intent.Entities["product"] = product
+			}
+			return true
+		}
+	}
+	if !scheduled || action != "" && !IsFollowUpAction(action) {
+		return false
+	}
+
+	intent.Action = "ASK_RECURRING"
+	var words []string
+	for _, tok := range tokens {
+		if op, ok := recurringOps[tok]; ok {
+			intent.Action = "UPDATE_RECURRING"
+			intent.Entities["op"] = op
+			continue
+		}
+		if scheduleWords[tok] || recurringFillers[tok] || ruleFillers[tok] || isRuleNumber(tok) ||
+			intentKeywords[tok] != "" || questionWords[tok] || greetingWords[tok] {
+			if len(words) > 0 {
+				break
+			}
+			continue
+		}
+		words = append(words, tok)
+	}
+	if intent.Action == "UPDATE_RECURRING" && len(words) > 0 {
+		intent.Entities["product"] = strings.Join(words, " ")
+	}
+	return true
+}
+
+// recurringSchedule reads the schedule of a recurring transaction and
+// returns its entities and the tokens left for the transaction itself.
+// entities is nil when the message names no period.
+//
+// A fixed day becomes schedule, a cron: "tanggal 1" is "0 8 1 * *", "hari
+// Senin" "0 8 * * 1", "akhir bulan" and days after the 28th "0 8 L * *". "tiap minggu" and "tiap
+// bulan" without a day become frequency WEEKLY or MONTHLY, which start on
+// the day the rule is made. "jam 7" sets the hour, "otomatis" auto_post.
+func recurringSchedule(tokens []string) (map[string]any, []string) {
+	used := make([]bool, len(tokens))
+	frequency, dom, dow, hour := "", "", -1, -1
+	auto := false
+
+	number := func(i, max int) int {
+		if i >= len(tokens) || !isRuleNumber(tokens[i]) {
+			return -1
+		}
+		n, err := strconv.Atoi(tokens[i])
+		if err != nil || n < 0 || n > max {
+			return -1
+		}
+		return n
+	}
+
+	for i, tok := range tokens {
+		next := ""
+		if i+1 < len(tokens) {
+			next = tokens[i+1]
+		}
+		switch {
+		case recurringMarkers[tok]:
+			used[i] = true
+			switch {
+			case dayWords[next] && i+2 < len(tokens) && (weekDay(tokens[i+2]) >= 0 || weekWords[tokens[i+2]]):
+				dow = max(weekDay(tokens[i+2]), 0) // "hari minggu" is Sunday
+				used[i+1], used[i+2] = true, true
+			case weekDay(next) >= 0:
+				dow = weekDay(next)
+				used[i+1] = true
+			case dayWords[next]:
+				frequency = "DAILY"
+				used[i+1] = true
+			case weekWords[next]:
+				frequency = "WEEKLY"
+				used[i+1] = true
+			case monthWords[next]:
+				frequency = "MONTHLY"
+				used[i+1] = true
+			}
+		case dateWords[tok]:
+			if n := number(i+1, 31); n >= 1 {
+				dom = strconv.Itoa(n)
+				if n > 28 {
+					dom = "L" // Runs in short months too
+				}
+				used[i], used[i+1] = true, true
+			}
+		case (tok == "akhir" || tok == "awal") && monthWords[next]:
+			dom = "1"
+			if tok == "akhir" {
+				dom = "L"
+			}
+			used[i], used[i+1] = true, true
+		case tok == "jam" || tok == "pukul":
+			if n := number(i+1, 23); n >= 0 {
+				hour = n
+				used[i], used[i+1] = true, true
+				if n < 12 && i+2 < len(tokens) && eveningWords[tokens[i+2]] {
+					hour += 12
+					used[i+2] = true
+				}
+			}
+		case autoWords[tok]:
+			auto = true
+			used[i] = true
+		}
+	}
+	if frequency == "" && dom == "" && dow < 0 {
+		return nil, tokens
+	}
+
+	entities := map[string]any{}
+	cronHour := hour
+	if cronHour < 0 {
+		cronHour = 8
+	}
+	switch {
+	case dom != "":
+		entities["schedule"] = fmt.Sprintf("0 %d %s * *", cronHour, dom)
+	case dow >= 0:
+		entities["schedule"] = fmt.Sprintf("0 %d * * %d", cronHour, dow)
+	case frequency == "DAILY":
+		entities["schedule"] = fmt.Sprintf("0 %d * * *", cronHour)
+	default:
+		entities["frequency"] = frequency
+		if hour >= 0 {
+			entities["hour"] = float64(hour)
+		}
+	}
+	if auto {
+		entities["auto_post"] = true
+	}
+
+	var rest []string
+	for i, tok := range tokens {
+		if !used[i] {
+			rest = append(rest, tok)
+		}
+	}
+	return entities, rest
+}
+
+// weekDay is the weekday (0 Sunday) a word names, or -1. "minggu" alone is
+// a week; callers handle "hari minggu".
+func weekDay(word string) int {
+	if day, ok := weekdayNames[word]; ok {
+		return int(day)
+	}
+	return -1
+}
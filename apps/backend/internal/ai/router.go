@@ -0,0 +1,190 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"time"
+)
+
+// RouterConfig sets retry and circuit breaker behaviour
+type RouterConfig struct {
+	MaxAttempts      int           // Attempts per provider before falling back
+	BaseBackoff      time.Duration // First retry delay, doubled per attempt
+	MaxBackoff       time.Duration
+	FailureThreshold int           // Consecutive failures that open a provider's circuit
+	Cooldown         time.Duration // How long an open circuit stays open
+}
+
+// DefaultRouterConfig returns the settings used for user-facing requests
+func DefaultRouterConfig() RouterConfig {
+	return RouterConfig{
+		MaxAttempts:      2,
+		BaseBackoff:      300 * time.Millisecond,
+		MaxBackoff:       4 * time.Second,
+		FailureThreshold: 5,
+		Cooldown:         time.Minute,
+	}
+}
+
+type routedProvider struct {
+	Provider
+	breaker *CircuitBreaker
+}
+
+// Router tries providers in order with retries, jittered backoff and a
+// circuit breaker per provider. It is itself a Provider.
+type Router struct {
+	providers []*routedProvider
+	cfg       RouterConfig
+}
+
+// NewRouter creates a router; nil providers are skipped
+func NewRouter(cfg RouterConfig, providers ...Provider) *Router {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 1
+	}
+	r := &Router{cfg: cfg}
+	for _, p := range providers {
+		if p == nil {
+			continue
+		}
+		r.providers = append(r.providers, &routedProvider{
+			Provider: p,
+			breaker:  NewCircuitBreaker(cfg.FailureThreshold, cfg.Cooldown),
+		})
+	}
+	return r
+}
+
+// Name returns the provider chain, e.g. "kolosal>gemini"
+func (r *Router) Name() string {
+	name := ""
+	for i, p := range r.providers {
+		if i > 0 {
+			name += ">"
+		}
+		name += p.Name()
+	}
+	return name
+}
+
+// Len returns the number of configured providers
+func (r *Router) Len() int {
+	return len(r.providers)
+}
+
+// Complete sends the request to the first healthy provider that answers
+func (r *Router) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	if len(r.providers) == 0 {
+		return nil, ErrNoProviders
+	}
+
+	var lastErr error
+	attempts := 0
+
+	for i, p := range r.providers {
+		if !p.breaker.Allow() {
+			log.Printf("⚡ %s circuit open, skipping", p.Name())
+			lastErr = fmt.Errorf("%s: circuit breaker open: too many failures", p.Name())
+			continue
+		}
+
+		for attempt := 0; attempt < r.cfg.MaxAttempts; attempt++ {
+			if attempt > 0 {
+				if err := r.sleep(ctx, attempt); err != nil {
+					return nil, err
+				}
+			}
+
+			attempts++
+			resp, err := p.Complete(ctx, req)
+			if err == nil {
+				p.breaker.RecordSuccess()
+				if i > 0 || attempt > 0 {
+					log.Printf("✅ %s succeeded (provider %d, attempt %d)", p.Name(), i+1, attempt+1)
+				}
+				return resp, nil
+			}
+
+			lastErr = err
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if errors.Is(err, ErrMediaUnsupported) {
+				break // Not a failure, just the wrong provider
+			}
+
+			p.breaker.RecordFailure()
+			log.Printf("⚠️ %s failed (attempt %d/%d): %v", p.Name(), attempt+1, r.cfg.MaxAttempts, err)
+
+			if !IsRetryable(err) || p.breaker.IsOpen() {
+				break
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("all providers failed after %d retries: %w", attempts, lastErr)
+}
+
+// sleep waits before a retry: exponential backoff with jitter in [d/2, d]
+func (r *Router) sleep(ctx context.Context, attempt int) error {
+	delay := r.cfg.BaseBackoff << (attempt - 1)
+	if r.cfg.MaxBackoff > 0 && delay > r.cfg.MaxBackoff {
+		delay = r.cfg.MaxBackoff
+	}
+	if delay > 0 {
+		delay = delay/2 + time.Duration(rand.Int64N(int64(delay/2)+1))
+	}
+
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ProviderSettings configures the default provider chain
+type ProviderSettings struct {
+	KolosalKey  string
+	KolosalURL  string
+	GeminiKeys  string // Comma-separated for rotation
+	OpenAIKey   string
+	OpenAIURL   string
+	OpenAIModel string
+	Order       []string // e.g. kolosal, gemini, openai
+}
+
+// NewDefaultRouter builds the provider chain from settings; providers
+// without keys are left out
+func NewDefaultRouter(s ProviderSettings) *Router {
+	order := s.Order
+	if len(order) == 0 {
+		order = []string{"kolosal", "gemini", "openai"}
+	}
+
+	var providers []Provider
+	for _, name := range order {
+		switch name {
+		case "kolosal":
+			if s.KolosalKey != "" {
+				providers = append(providers, NewKolosalProvider(s.KolosalKey, s.KolosalURL))
+			}
+		case "gemini":
+			if s.GeminiKeys != "" {
+				providers = append(providers, NewGeminiProvider(NewKeyRing("Gemini", s.GeminiKeys), ""))
+			}
+		case "openai":
+			if s.OpenAIKey != "" && s.OpenAIURL != "" {
+				providers = append(providers, NewOpenAICompatProvider("openai", s.OpenAIURL, NewKeyRing("OpenAI", s.OpenAIKey), s.OpenAIModel))
+			}
+		default:
+			log.Printf("⚠️ Unknown LLM provider %q ignored", name)
+		}
+	}
+
+	return NewRouter(DefaultRouterConfig(), providers...)
+}
@@ -0,0 +1,116 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeProvider fails with errs in order, then answers with its name
+type fakeProvider struct {
+	name  string
+	errs  []error
+	calls int
+}
+
+func (f *fakeProvider) Name() string { return f.name }
+
+func (f *fakeProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	f.calls++
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return nil, err
+	}
+	return &CompletionResponse{Text: "ok from " + f.name, Provider: f.name}, nil
+}
+
+func testRouterConfig() RouterConfig {
+	return RouterConfig{MaxAttempts: 3, FailureThreshold: 2, Cooldown: time.Minute}
+}
+
+var errTransient = &ProviderError{Provider: "fake", StatusCode: 503, Retryable: true, Err: errors.New("unavailable")}
+
+func TestRouter_RetriesThenSucceeds(t *testing.T) {
+	primary := &fakeProvider{name: "primary", errs: []error{errTransient}}
+	backup := &fakeProvider{name: "backup"}
+	cfg := testRouterConfig()
+	cfg.FailureThreshold = 5
+	router := NewRouter(cfg, primary, backup)
+
+	resp, err := router.Complete(context.Background(), &CompletionRequest{})
+	if err != nil || resp.Provider != "primary" {
+		t.Fatalf("Expected primary after retry, got %v, %v", resp, err)
+	}
+	if primary.calls != 2 || backup.calls != 0 {
+		t.Errorf("calls = %d/%d, want 2/0", primary.calls, backup.calls)
+	}
+}
+
+func TestRouter_FallsBackAndOpensCircuit(t *testing.T) {
+	primary := &fakeProvider{name: "primary", errs: []error{errTransient, errTransient, errTransient}}
+	backup := &fakeProvider{name: "backup"}
+	router := NewRouter(testRouterConfig(), primary, backup)
+
+	resp, err := router.Complete(context.Background(), &CompletionRequest{})
+	if err != nil || resp.Provider != "backup" {
+		t.Fatalf("Expected backup, got %v, %v", resp, err)
+	}
+	// Circuit opens after 2 failures, the third attempt is skipped
+	if primary.calls != 2 {
+		t.Errorf("primary calls = %d, want 2", primary.calls)
+	}
+
+	// Open circuit skips the primary entirely
+	if _, err := router.Complete(context.Background(), &CompletionRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if primary.calls != 2 || backup.calls != 2 {
+		t.Errorf("calls = %d/%d, want 2/2", primary.calls, backup.calls)
+	}
+}
+
+func TestRouter_NonRetryableAndMedia(t *testing.T) {
+	badRequest := &ProviderError{Provider: "fake", StatusCode: 400, Err: errors.New("bad request")}
+	textOnly := &fakeProvider{name: "text", errs: []error{ErrMediaUnsupported}}
+	broken := &fakeProvider{name: "broken", errs: []error{badRequest}}
+	gemini := &fakeProvider{name: "gemini"}
+	router := NewRouter(testRouterConfig(), textOnly, broken, gemini)
+
+	resp, err := router.Complete(context.Background(), &CompletionRequest{Media: []MediaPart{{MimeType: "audio/ogg"}}})
+	if err != nil || resp.Provider != "gemini" {
+		t.Fatalf("Expected gemini, got %v, %v", resp, err)
+	}
+	if textOnly.calls != 1 || broken.calls != 1 {
+		t.Errorf("Non-retryable errors should not be retried: %d/%d", textOnly.calls, broken.calls)
+	}
+	if !router.providers[0].breaker.Allow() {
+		t.Error("Unsupported media should not count as a failure")
+	}
+}
+
+func TestRouter_AllFail(t *testing.T) {
+	if _, err := NewRouter(testRouterConfig()).Complete(context.Background(), &CompletionRequest{}); !errors.Is(err, ErrNoProviders) {
+		t.Errorf("Empty router error = %v, want ErrNoProviders", err)
+	}
+
+	only := &fakeProvider{name: "only", errs: []error{errTransient, errTransient}}
+	_, err := NewRouter(testRouterConfig(), only).Complete(context.Background(), &CompletionRequest{})
+	if !errors.Is(err, errTransient) {
+		t.Errorf("Error should wrap the last provider error, got %v", err)
+	}
+}
+
+func TestKeyRing_Rotate(t *testing.T) {
+	keys := NewKeyRing("test", "a, b ,,c")
+	if keys.Len() != 3 || keys.Current() != "a" {
+		t.Fatalf("Unexpected keys: %d, %s", keys.Len(), keys.Current())
+	}
+
+	keys.Rotate("a")
+	keys.Rotate("a") // Stale rotation by a second caller is ignored
+	if keys.Current() != "b" {
+		t.Errorf("Current = %s, want b", keys.Current())
+	}
+}
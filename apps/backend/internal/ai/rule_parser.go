@@ -0,0 +1,577 @@
+package ai
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// RuleConfidenceThreshold is the rule parser confidence at which the LLM is skipped
+const RuleConfidenceThreshold = 0.9
+
+// Rule parser confidence levels
+const (
+	ruleConfidenceComplete = 0.95 // Intent keyword and all required entities
+	ruleConfidencePartial  = 0.7  // Intent keyword, some entities missing
+	ruleConfidenceConflict = 0.5  // Keywords of several intents
+)
+
+// intentKeywords maps single-word keywords to intents (id, jv, su)
+var intentKeywords = map[string]string{
+	// Sales
+	"laku": "RECORD_SALE", "terjual": "RECORD_SALE", "jual": "RECORD_SALE", "kejual": "RECORD_SALE",
+	"laris": "RECORD_SALE", "payu": "RECORD_SALE", "kepayu": "RECORD_SALE", "pajeng": "RECORD_SALE",
+	"kajual": "RECORD_SALE", "kajeng": "RECORD_SALE",
+	// Expenses
+	"beli": "RECORD_EXPENSE", "bayar": "RECORD_EXPENSE", "belanja": "RECORD_EXPENSE", "biaya": "RECORD_EXPENSE",
+	"ongkos": "RECORD_EXPENSE", "tuku": "RECORD_EXPENSE", "mbayar": "RECORD_EXPENSE", "blanja": "RECORD_EXPENSE",
+	"meuli": "RECORD_EXPENSE", "mayar": "RECORD_EXPENSE", "balanja": "RECORD_EXPENSE",
+	// Restock
+	"cari": "ORDER_RESTOCK", "butuh": "ORDER_RESTOCK", "pesan": "ORDER_RESTOCK", "order": "ORDER_RESTOCK",
+	"restock": "ORDER_RESTOCK", "nyari": "ORDER_RESTOCK", "kulakan": "ORDER_RESTOCK", "golek": "ORDER_RESTOCK",
+	"goleki": "ORDER_RESTOCK", "mesen": "ORDER_RESTOCK", "milarian": "ORDER_RESTOCK", "peryogi": "ORDER_RESTOCK",
+	"butuhna": "ORDER_RESTOCK",
+	// Promotion
+	"promosi": "REQUEST_PROMO", "promo": "REQUEST_PROMO", "iklan": "REQUEST_PROMO", "caption": "REQUEST_PROMO",
+	"poster": "REQUEST_PROMO", "brosur": "REQUEST_PROMO",
+	// Reports
+	"laporan": "REQUEST_REPORT", "rekap": "REQUEST_REPORT", "omzet": "REQUEST_REPORT", "omset": "REQUEST_REPORT",
+	"pembukuan": "REQUEST_REPORT",
+	// Questions about the user's own books
+	"terlaris": "ASK_DATA", "untung": "ASK_DATA", "laba": "ASK_DATA",
+	// Stock
+	"stok": "CHECK_STOCK", "stock": "CHECK_STOCK", "sisa": "CHECK_STOCK", "persediaan": "CHECK_STOCK",
+	"turah": "CHECK_STOCK", "sesa": "CHECK_STOCK",
+	// Follow-ups on the previous operation
+	"ralat": "CORRECT_PREVIOUS", "koreksi": "CORRECT_PREVIOUS", "revisi": "CORRECT_PREVIOUS",
+	"batal": "CANCEL_PREVIOUS", "batalin": "CANCEL_PREVIOUS", "batalkan": "CANCEL_PREVIOUS",
+	"cancel": "CANCEL_PREVIOUS", "hapus": "CANCEL_PREVIOUS", "busak": "CANCEL_PREVIOUS",
+}
+
+// Phrases checked before single words
+var intentPhrases = map[string]string{
+	"harga pasar": "ASK_MARKET", "tren harga": "ASK_MARKET", "info harga": "ASK_MARKET",
+	"cek harga": "ASK_MARKET", "rego pasar": "ASK_MARKET", "harga di pasar": "ASK_MARKET",
+	"tadi jadi": "CORRECT_PREVIOUS", "harusnya": "CORRECT_PREVIOUS", "sakjane": "CORRECT_PREVIOUS",
+	"gak jadi": "CANCEL_PREVIOUS", "ga jadi": "CANCEL_PREVIOUS", "nggak jadi": "CANCEL_PREVIOUS",
+	"enggak jadi": "CANCEL_PREVIOUS", "tidak jadi": "CANCEL_PREVIOUS", "ora sido": "CANCEL_PREVIOUS",
+	"ora jadi": "CANCEL_PREVIOUS", "teu jadi": "CANCEL_PREVIOUS",
+	"paling laku": "ASK_DATA", "paling laris": "ASK_DATA", "paling payu": "ASK_DATA", "kapan terakhir": "ASK_DATA",
+	"terakhir kali": "ASK_DATA", "dibanding minggu": "ASK_DATA", "dibanding bulan": "ASK_DATA",
+	"itu juga": "AMEND_PREVIOUS", "ini juga": "AMEND_PREVIOUS", "iku uga": "AMEND_PREVIOUS",
+	"iki uga": "AMEND_PREVIOUS", "eta oge": "AMEND_PREVIOUS", "ieu oge": "AMEND_PREVIOUS",
+}
+
+// correctionLeads start a correction of the previous message, e.g. "bukan, beras yang premium"
+var correctionLeads = map[string]bool{
+	"bukan": true, "dudu": true, "sanes": true, "maksudnya": true, "maksude": true, "salah": true,
+}
+
+// Words naming the kind of transaction a follow-up refers to
+var targetTypeWords = map[string]string{
+	"penjualan": "SALE", "jualan": "SALE", "dodolan": "SALE",
+	"pengeluaran": "EXPENSE", "belanjaan": "EXPENSE",
+	"pembelian": "PURCHASE", "kulakan": "PURCHASE",
+}
+
+// Words joining the days of a range ("tanggal 1 sampai 15") and ending a
+// relative date ("3 hari yang lalu")
+var (
+	dateRangeWords = map[string]bool{"sampai": true, "sampe": true, "hingga": true, "sd": true, "nganti": true, "ngantos": true, "tekan": true}
+	relativeLinks  = map[string]bool{"yang": true, "sing": true, "nu": true}
+	agoWords       = map[string]bool{
+		"lalu": true, "kemarin": true, "kemaren": true, "kepungkur": true, "kapungkur": true,
+		"terakhir": true, "pungkasan": true, "panungtung": true,
+	}
+)
+
+// Price words that make a question an ASK_MARKET
+var (
+	priceWords    = map[string]bool{"harga": true, "rego": true, "hargana": true, "regane": true, "hargane": true}
+	questionWords = map[string]bool{"berapa": true, "brp": true, "piro": true, "pira": true, "pinten": true, "sabaraha": true, "sbrp": true}
+)
+
+// Greeting words; a message made only of these and fillers is a GREETING
+var greetingWords = map[string]bool{
+	"halo": true, "hallo": true, "hai": true, "hi": true, "hello": true, "pagi": true, "siang": true,
+	"sore": true, "malam": true, "selamat": true, "assalamualaikum": true, "salam": true, "permisi": true,
+	"sugeng": true, "enjing": true, "siyang": true, "sonten": true, "dalu": true, "wilujeng": true,
+	"punten": true, "sampurasun": true, "kulonuwun": true,
+}
+
+// Language markers
+var (
+	javaneseWords = map[string]bool{
+		"payu": true, "kepayu": true, "tuku": true, "mbayar": true, "golek": true, "goleki": true, "kulakan": true,
+		"rego": true, "regane": true, "piro": true, "pira": true, "pinten": true, "sugeng": true, "dina": true,
+		"iki": true, "wis": true, "wes": true, "sasi": true, "wulan": true, "turah": true, "kulonuwun": true,
+		"ewu": true, "blanja": true, "kajeng": true, "siyang": true, "sonten": true, "dalu": true,
+	}
+	sundaneseWords = map[string]bool{
+		"pajeng": true, "kajual": true, "meuli": true, "mayar": true, "milarian": true, "peryogi": true,
+		"sabaraha": true, "hargana": true, "wilujeng": true, "dinten": true, "ieu": true, "punten": true,
+		"sesa": true, "sampurasun": true, "rebu": true, "balanja": true, "butuhna": true,
+	}
+)
+
+// Units recognised after a quantity
+var ruleUnits = map[string]string{
+	"kg": "kg", "kilo": "kg", "kilogram": "kg", "gram": "gram", "gr": "gram", "ons": "ons",
+	"liter": "liter", "ltr": "liter", "l": "liter", "ml": "ml",
+	"porsi": "porsi", "pcs": "pcs", "butir": "butir", "biji": "biji", "buah": "buah", "pack": "pack",
+	"dus": "dus", "karton": "karton", "box": "box", "tabung": "tabung", "bungkus": "bungkus",
+	"gelas": "gelas", "mangkok": "mangkok", "mangkuk": "mangkok", "piring": "piring", "ikat": "ikat",
+	"sak": "sak", "karung": "karung", "botol": "botol", "lusin": "lusin", "ekor": "ekor", "lembar": "lembar",
+	"bks": "bungkus", "btl": "botol", "pax": "pack", "cup": "cup", "potong": "potong", "tusuk": "tusuk",
+}
+
+// Words around a number that mark it as a unit price or a budget
+var (
+	priceMarkers = map[string]bool{
+		"rp": true, "@": true, "harga": true, "seharga": true, "rego": true, "hargane": true,
+		"regane": true, "hargana": true, "satunya": true, "sijine": true,
+	}
+	perUnitMarkers = map[string]bool{
+		"rupiah": true, "perak": true, "per": true, "siji": true, "satu": true, "sahiji": true, "sebiji": true,
+	}
+	budgetMarkers = map[string]bool{
+		"maksimal": true, "maks": true, "max": true, "budget": true, "bujet": true, "paling": true,
+		"maksimum": true, "sampai": true, "mentok": true,
+	}
+)
+
+// Filler words dropped from product names
+var ruleFillers = map[string]bool{
+	"tadi": true, "barusan": true, "sudah": true, "udah": true, "sdh": true, "wis": true, "wes": true, "tos": true,
+	"mas": true, "mbak": true, "pak": true, "bu": true, "bang": true, "kak": true, "kang": true, "teh": true,
+	"ya": true, "yo": true, "dong": true, "nih": true, "aja": true, "saja": true, "wae": true, "bae": true,
+	"mau": true, "ingin": true, "pengen": true, "pingin": true, "tolong": true, "buatkan": true, "bikin": true,
+	"bikinin": true, "buat": true, "gawe": true, "damel": true, "ada": true, "berapa": true, "brp": true,
+	"piro": true, "pinten": true, "sabaraha": true, "yang": true, "sing": true, "nu": true, "lagi": true,
+	"hari": true, "ini": true, "iki": true, "ieu": true, "dina": true, "dinten": true, "kemarin": true,
+	"wingi": true, "kamari": true, "minggu": true, "bulan": true, "sasi": true, "wulan": true, "pekan": true,
+	"untuk": true, "kanggo": true, "keur": true, "di": true, "ing": true, "dari": true, "sekarang": true,
+	"saiki": true, "ayeuna": true, "total": true, "semua": true, "kabeh": true, "sadayana": true, "masih": true,
+	"isih": true, "tinggal": true, "kari": true, "nggih": true, "euy": true, "atuh": true, "mah": true,
+	"kok": true, "sih": true, "lho": true, "deh": true, "kah": true, "apa": true, "opo": true, "naon": true,
+	"kulo": true, "aku": true, "saya": true, "abdi": true, "tah": true, "kiye": true, "niki": true,
+	"rupiah": true, "perak": true, "ewu": true, "rebu": true, "mangga": true, "punten": true,
+	"jadi": true, "dadi": true, "sido": true, "juga": true, "uga": true, "oge": true, "itu": true, "iku": true,
+	"eta": true, "terakhir": true, "pungkasan": true, "panungtung": true,
+}
+
+// Fillers that are also part of product names once a name has started, e.g.
+// "teh" is "sister" in Sundanese but a drink in "es teh"
+var productFillers = map[string]bool{"teh": true}
+
+var ruleTokenRe = regexp.MustCompile(`@|\d+(?:[.,]\d+)?|[\p{L}]+`)
+
+// ParseIntentRules extracts an intent with a deterministic grammar and
+// returns how confident the match is. UNKNOWN with 0 means no rule matched.
+func ParseIntentRules(text string) (*Intent, float64) {
+	normalized := NormalizeText(strings.ToLower(text))
+	tokens := ruleTokenRe.FindAllString(normalized, -1)
+	// Dates are read by AddDateEntities; their numbers are not qty or price
+	plain := withoutDateTokens(ruleTokenRe.FindAllString(withoutNumericDates(normalized), -1))
+
+	intent := &Intent{
+		Action:    "UNKNOWN",
+		Entities:  map[string]any{},
+		Sentiment: "neutral",
+		Language:  detectRuleLanguage(tokens),
+		RawText:   text,
+	}
+	if len(plain) == 0 {
+		return intent, 0
+	}
+
+	action, keywordAt, conflict := matchIntentKeyword(plain)
+	if !IsFollowUpAction(action) && action != "ORDER_RESTOCK" && parseBudgetRules(plain, intent) {
+		if hasRequiredEntities(intent) {
+			return intent, ruleConfidenceComplete
+		}
+		return intent, ruleConfidencePartial
+	}
+	if !IsFollowUpAction(action) && !(action == "RECORD_EXPENSE" && creditAmount(plain) > 0) && parseTaxRules(plain, intent) {
+		return intent, ruleConfidenceComplete
+	}
+	if parseRecurringRules(tokens, action, intent) {
+		if hasRequiredEntities(intent) {
+			return intent, ruleConfidenceComplete
+		}
+		return intent, ruleConfidencePartial
+	}
+	if !IsFollowUpAction(action) && parseCreditRules(plain, intent) {
+		if hasRequiredEntities(intent) {
+			return intent, ruleConfidenceComplete
+		}
+		return intent, ruleConfidencePartial
+	}
+	if action == "" {
+		if isGreeting(plain) {
+			intent.Action = "GREETING"
+			intent.Sentiment = "positive"
+			return intent, ruleConfidenceComplete
+		}
+		return intent, 0
+	}
+	intent.Action = action
+
+	extractRuleNumbers(plain, intent)
+	if product := extractRuleProduct(plain, keywordAt); product != "" {
+		intent.Entities["product"] = product
+	}
+	if action == "REQUEST_REPORT" {
+		intent.Entities["period"] = detectReportPeriod(normalized)
+	}
+	if action == "CANCEL_PREVIOUS" {
+		addCancelTarget(plain, intent)
+	}
+	if action == "RECORD_SALE" {
+		intent.Sentiment = "positive"
+	}
+
+	if conflict {
+		return intent, ruleConfidenceConflict
+	}
+	if hasRequiredEntities(intent) {
+		return intent, ruleConfidenceComplete
+	}
+	return intent, ruleConfidencePartial
+}
+
+// matchIntentKeyword finds the intent keyword and its token index.
+// conflict is set when keywords of different intents appear.
+func matchIntentKeyword(tokens []string) (action string, at int, conflict bool) {
+	if correctionLeads[tokens[0]] {
+		return "CORRECT_PREVIOUS", 0, false
+	}
+
+	padded := " " + strings.Join(tokens, " ") + " "
+	for phrase, phraseAction := range intentPhrases {
+		if strings.Contains(padded, " "+phrase+" ") {
+			first := strings.Fields(phrase)[0]
+			for i, tok := range tokens {
+				if tok == first {
+					return phraseAction, i, false
+				}
+			}
+		}
+	}
+
+	at = -1
+	for i, tok := range tokens {
+		found, ok := intentKeywords[tok]
+		if !ok && len(tok) > 5 && strings.HasSuffix(tok, "nya") {
+			found, ok = intentKeywords[strings.TrimSuffix(tok, "nya")] // "stoknya"
+		}
+		if !ok {
+			continue
+		}
+		switch {
+		case action == "":
+			action, at = found, i
+		case found != action && !compatibleIntents(action, found):
+			conflict = true
+		}
+	}
+
+	// Price questions: "harga cabai berapa", "sabaraha hargana beas"
+	if action == "" {
+		for i, tok := range tokens {
+			if priceWords[tok] && hasAnyWord(tokens, questionWords) {
+				return "ASK_MARKET", i, false
+			}
+		}
+	}
+	return action, at, conflict
+}
+
+// compatibleIntents allows keyword pairs that commonly occur together,
+// e.g. "laporan omzet" or "cari stok"
+func compatibleIntents(a, b string) bool {
+	if IsFollowUpAction(a) {
+		return true // "batal jual bakso", "ralat laku 6"
+	}
+	pair := a + "|" + b
+	switch pair {
+	case "ORDER_RESTOCK|CHECK_STOCK", "CHECK_STOCK|ORDER_RESTOCK",
+		"REQUEST_REPORT|RECORD_SALE", "RECORD_SALE|REQUEST_REPORT",
+		"REQUEST_PROMO|RECORD_SALE":
+		return true
+	}
+	return false
+}
+
+// withoutDateTokens drops the tokens of the dates ParseDateRange reads:
+// "tanggal 5", "tgl 1 sampai 15", "5 maret 2025", "3 hari lalu" and weekdays
+// such as "senin kemarin". Recurring schedules keep theirs.
+func withoutDateTokens(tokens []string) []string {
+	used := make([]bool, len(tokens))
+	day := func(i int) bool {
+		if i >= len(tokens) {
+			return false
+		}
+		n, err := strconv.Atoi(tokens[i])
+		return err == nil && n >= 1 && n <= 31
+	}
+	// monthAndYear extends a day at i over "maret" and "2025"
+	monthAndYear := func(i int) int {
+		if i+1 < len(tokens) {
+			if _, ok := lookupMonth(tokens[i+1], true); ok {
+				i++
+				if i+1 < len(tokens) && len(tokens[i+1]) == 4 && isRuleNumber(tokens[i+1]) {
+					i++
+				}
+			}
+		}
+		return i
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		tok, end := tokens[i], -1
+		switch {
+		case dateWords[tok] && day(i+1):
+			end = i + 1
+			if end+2 < len(tokens) && dateRangeWords[tokens[end+1]] && day(end+2) {
+				end += 2
+			}
+			end = monthAndYear(end)
+		case day(i) && monthAndYear(i) > i:
+			end = monthAndYear(i)
+		case isRuleNumber(tok) && i+2 < len(tokens) && (dayWords[tokens[i+1]] || weekWords[tokens[i+1]] || monthWords[tokens[i+1]]):
+			j := i + 2
+			if relativeLinks[tokens[j]] && j+1 < len(tokens) {
+				j++
+			}
+			if agoWords[tokens[j]] {
+				end = j
+			}
+		case weekDay(tok) >= 0:
+			end = i
+			if i+1 < len(tokens) && (slices.Contains(pastModifiers, tokens[i+1]) || slices.Contains(futureModifiers, tokens[i+1])) {
+				end++
+			}
+		}
+		for ; end >= i; end-- {
+			used[end] = true
+		}
+	}
+
+	plain := make([]string, 0, len(tokens))
+	for i, tok := range tokens {
+		if !used[i] {
+			plain = append(plain, tok)
+		}
+	}
+	return plain
+}
+
+// extractRuleNumbers assigns numbers to qty, price or max_price
+func extractRuleNumbers(tokens []string, intent *Intent) {
+	var loose []float64
+	for i, tok := range tokens {
+		value, err := strconv.ParseFloat(strings.ReplaceAll(tok, ",", "."), 64)
+		if err != nil {
+			continue
+		}
+
+		prev := ""
+		if i > 0 {
+			prev = tokens[i-1]
+		}
+		next := ""
+		if i+1 < len(tokens) {
+			next = tokens[i+1]
+		}
+
+		switch {
+		case budgetMarkers[prev] || prev == "harga" && i > 1 && budgetMarkers[tokens[i-2]]:
+			intent.Entities["max_price"] = value
+		case priceMarkers[prev] || perUnitMarkers[next]:
+			intent.Entities["price"] = value
+		case ruleUnits[next] != "" && intent.Entities["qty"] == nil:
+			intent.Entities["qty"] = value
+			intent.Entities["unit"] = ruleUnits[next]
+		default:
+			loose = append(loose, value)
+		}
+	}
+
+	// Numbers without markers: small ones are quantities, big ones prices
+	for _, value := range loose {
+		if value < 1000 && intent.Entities["qty"] == nil {
+			intent.Entities["qty"] = value
+		} else if intent.Entities["price"] == nil && intent.Entities["max_price"] == nil {
+			if intent.Action == "ORDER_RESTOCK" {
+				intent.Entities["max_price"] = value
+			} else {
+				intent.Entities["price"] = value
+			}
+		}
+	}
+}
+
+// extractRuleProduct takes the words after the keyword up to the first
+// number, falling back to the words before the keyword
+func extractRuleProduct(tokens []string, keywordAt int) string {
+	collect := func(from, to int) string {
+		var words []string
+		for i := from; i < to; i++ {
+			tok := tokens[i]
+			if isRuleNumber(tok) || priceMarkers[tok] || perUnitMarkers[tok] || budgetMarkers[tok] {
+				if len(words) > 0 {
+					break
+				}
+				continue
+			}
+			if ruleFillers[tok] && !(len(words) > 0 && productFillers[tok]) || ruleUnits[tok] != "" || intentKeywords[tok] != "" || priceWords[tok] ||
+				questionWords[tok] || greetingWords[tok] {
+				if len(words) > 0 && ruleUnits[tok] != "" {
+					break
+				}
+				continue
+			}
+			words = append(words, tok)
+		}
+		return strings.Join(words, " ")
+	}
+
+	if product := collect(keywordAt+1, len(tokens)); product != "" {
+		return product
+	}
+	return collect(0, keywordAt)
+}
+
+// hasRequiredEntities reports whether the intent can run without asking back
+func hasRequiredEntities(intent *Intent) bool {
+	has := func(key string) bool {
+		switch v := intent.Entities[key].(type) {
+		case string:
+			return v != ""
+		case float64:
+			return v > 0
+		}
+		return false
+	}
+
+	switch intent.Action {
+	case "RECORD_SALE":
+		return has("product") && has("qty") && has("price")
+	case "RECORD_EXPENSE":
+		return has("product") && has("price")
+	case "ORDER_RESTOCK":
+		return has("product") && has("qty")
+	case "CHECK_STOCK", "ASK_MARKET":
+		return has("product")
+	case "RECORD_DEBT":
+		return has("contact") && has("amount")
+	case "RECORD_DEBT_PAYMENT":
+		return has("contact") && (has("amount") || intent.Entities["settle"] == true)
+	case "SET_BUDGET":
+		return has("category") && has("amount")
+	case "SET_RECURRING":
+		return has("product") && has("price")
+	case "UPDATE_RECURRING":
+		return has("op")
+	case "CORRECT_PREVIOUS", "AMEND_PREVIOUS":
+		return has("product") || has("qty") || has("price") || has("max_price")
+	default:
+		return true
+	}
+}
+
+// detectReportPeriod returns daily, weekly or monthly
+func detectReportPeriod(text string) string {
+	for _, w := range []string{"minggu", "pekan", "minggon", "seminggu", "saminggu"} {
+		if strings.Contains(text, w) {
+			return "weekly"
+		}
+	}
+	for _, w := range []string{"bulan", "sasi", "wulan", "sebulan", "sabulan"} {
+		if strings.Contains(text, w) {
+			return "monthly"
+		}
+	}
+	return "daily"
+}
+
+func isGreeting(tokens []string) bool {
+	greeting := false
+	for _, tok := range tokens {
+		switch {
+		case greetingWords[tok]:
+			greeting = true
+		case ruleFillers[tok]:
+		default:
+			return false
+		}
+	}
+	return greeting
+}
+
+func detectRuleLanguage(tokens []string) string {
+	jv, su := 0, 0
+	for _, tok := range tokens {
+		if javaneseWords[tok] {
+			jv++
+		}
+		if sundaneseWords[tok] {
+			su++
+		}
+	}
+	switch {
+	case jv > su:
+		return "jv"
+	case su > jv:
+		return "su"
+	default:
+		return "id"
+	}
+}
+
+func hasAnyWord(tokens []string, words map[string]bool) bool {
+	for _, tok := range tokens {
+		if words[tok] {
+			return true
+		}
+	}
+	return false
+}
+
+func isRuleNumber(tok string) bool {
+	return tok != "" && tok[0] >= '0' && tok[0] <= '9'
+}
+
+// IsFollowUpAction reports whether an action refers to the previous operation
+func IsFollowUpAction(action string) bool {
+	switch action {
+	case "CORRECT_PREVIOUS", "AMEND_PREVIOUS", "CANCEL_PREVIOUS":
+		return true
+	}
+	return false
+}
+
+// addCancelTarget turns the product of a cancellation into the reference
+// to the cancelled transaction, e.g. "hapus penjualan es teh tadi"
+func addCancelTarget(tokens []string, intent *Intent) {
+	for _, tok := range tokens {
+		if targetType, ok := targetTypeWords[tok]; ok {
+			intent.Entities["target_type"] = targetType
+			break
+		}
+	}
+	if product, ok := intent.Entities["product"].(string); ok {
+		var words []string
+		for _, word := range strings.Fields(product) {
+			if targetTypeWords[word] == "" {
+				words = append(words, word)
+			}
+		}
+		delete(intent.Entities, "product")
+		if len(words) > 0 {
+			intent.Entities["target_product"] = strings.Join(words, " ")
+		}
+	}
+}
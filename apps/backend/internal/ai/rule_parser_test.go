@@ -0,0 +1,177 @@
+package ai
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseIntentRules(t *testing.T) {
+	tests := []struct {
+		text      string
+		action    string
+		entities  map[string]any
+		language  string
+		confident bool
+	}{
+		{"Tadi laku nasi rames 15 porsi 12rb", "RECORD_SALE",
+			map[string]any{"product": "nasi rames", "qty": 15.0, "unit": "porsi", "price": 12000.0}, "id", true},
+		{"payu bakso 5 mangkok 15000 siji", "RECORD_SALE",
+			map[string]any{"product": "bakso", "qty": 5.0, "price": 15000.0}, "jv", true},
+		{"kajual cilok 20 bungkus @2000", "RECORD_SALE",
+			map[string]any{"product": "cilok", "qty": 20.0, "price": 2000.0}, "su", true},
+		{"beli gas 2 tabung", "RECORD_EXPENSE",
+			map[string]any{"product": "gas", "qty": 2.0, "unit": "tabung"}, "id", false},
+		{"bayar listrik 350.000", "RECORD_EXPENSE",
+			map[string]any{"product": "listrik", "price": 350000.0}, "id", true},
+		{"Mas, cari beras 25 kilo maksimal 12 ribu ya", "ORDER_RESTOCK",
+			map[string]any{"product": "beras", "qty": 25.0, "unit": "kg", "max_price": 12000.0}, "id", true},
+		{"tgl 5 jual nasi 3 porsi 15rb", "RECORD_SALE",
+			map[string]any{"product": "nasi", "qty": 3.0, "unit": "porsi", "price": 15000.0}, "id", true},
+		{"jual nasi 3 porsi 15rb tanggal 5", "RECORD_SALE",
+			map[string]any{"product": "nasi", "qty": 3.0, "unit": "porsi", "price": 15000.0}, "id", true},
+		{"tanggal 5 beli beras 10 kilo 12rb", "RECORD_EXPENSE",
+			map[string]any{"product": "beras", "qty": 10.0, "unit": "kg", "price": 12000.0}, "id", true},
+		{"3 hari lalu laku bakso 5 mangkok 15000", "RECORD_SALE",
+			map[string]any{"product": "bakso", "qty": 5.0, "price": 15000.0}, "id", true},
+		{"jual soto 4 porsi 10rb 17 agustus 2025", "RECORD_SALE",
+			map[string]any{"product": "soto", "qty": 4.0, "price": 10000.0}, "id", true},
+		{"5/3 laku bakso 10 mangkok 12rb", "RECORD_SALE",
+			map[string]any{"product": "bakso", "qty": 10.0, "price": 12000.0}, "id", true},
+		{"senin kemarin jual es teh 20 gelas 5rb", "RECORD_SALE",
+			map[string]any{"product": "es teh", "qty": 20.0, "price": 5000.0}, "id", true},
+		{"stok beras berapa", "CHECK_STOCK", map[string]any{"product": "beras"}, "id", true},
+		{"sisa telur ada berapa", "CHECK_STOCK", map[string]any{"product": "telur"}, "id", true},
+		{"laporan hari ini", "REQUEST_REPORT", map[string]any{"period": "daily"}, "id", true},
+		{"rekap sasi iki", "REQUEST_REPORT", map[string]any{"period": "monthly"}, "jv", true},
+		{"buatkan promosi nasi goreng", "REQUEST_PROMO", map[string]any{"product": "nasi goreng"}, "id", true},
+		{"harga cabai berapa", "ASK_MARKET", map[string]any{"product": "cabai"}, "id", true},
+		{"sabaraha hargana beas", "ASK_MARKET", map[string]any{"product": "beas"}, "su", true},
+		{"Halo mas", "GREETING", map[string]any{}, "id", true},
+		{"sugeng enjing", "GREETING", map[string]any{}, "jv", true},
+		{"jual beli motor bekas", "RECORD_SALE", nil, "id", false},
+		{"cuaca hari ini cerah", "UNKNOWN", map[string]any{}, "id", false},
+		{"yang tadi jadi 12 ribu", "CORRECT_PREVIOUS", map[string]any{"price": 12000.0}, "id", true},
+		{"ralat 5 porsi", "CORRECT_PREVIOUS", map[string]any{"qty": 5.0, "unit": "porsi"}, "id", true},
+		{"bukan, beras yang premium", "CORRECT_PREVIOUS", map[string]any{"product": "beras premium"}, "id", true},
+		{"ralat", "CORRECT_PREVIOUS", map[string]any{}, "id", false},
+		{"itu juga 5", "AMEND_PREVIOUS", map[string]any{"qty": 5.0}, "id", true},
+		{"gak jadi", "CANCEL_PREVIOUS", map[string]any{}, "id", true},
+		{"hapus penjualan bakso tadi", "CANCEL_PREVIOUS",
+			map[string]any{"target_type": "SALE", "target_product": "bakso", "product": nil}, "id", true},
+		{"batal jual bakso", "CANCEL_PREVIOUS", map[string]any{"target_product": "bakso"}, "id", true},
+		{"batal yang terakhir", "CANCEL_PREVIOUS", map[string]any{"target_product": nil}, "id", true},
+		{"ralat harga jadi 12 ribu", "CORRECT_PREVIOUS", map[string]any{"price": 12000.0, "product": nil}, "id", true},
+		{"hapus penjualan es teh tadi", "CANCEL_PREVIOUS",
+			map[string]any{"target_type": "SALE", "target_product": "es teh"}, "id", true},
+		{"bulan ini paling laku apa?", "ASK_DATA", map[string]any{}, "id", true},
+		{"kapan terakhir beli gas?", "ASK_DATA", map[string]any{}, "id", true},
+		{"untung minggu ini dibanding minggu lalu?", "ASK_DATA", map[string]any{}, "id", true},
+		{"Bu Sari ngutang 50 ribu", "RECORD_DEBT",
+			map[string]any{"contact": "Bu Sari", "amount": 50000.0, "credit_type": "RECEIVABLE"}, "id", true},
+		{"Bu Sari bayar 20 ribu", "RECORD_DEBT_PAYMENT",
+			map[string]any{"contact": "Bu Sari", "amount": 20000.0, "credit_type": "RECEIVABLE"}, "id", true},
+		{"siapa aja yang masih ngutang", "ASK_DEBTS", map[string]any{"credit_type": "RECEIVABLE", "contact": nil}, "id", true},
+		{"tadi Pak Joko kasbon rokok 25rb bayar minggu depan", "RECORD_SALE",
+			map[string]any{"contact": "Pak Joko", "product": "rokok", "qty": 1.0, "price": 25000.0, "amount": nil}, "id", true},
+		{"jual bakso 10 porsi 15rb ngutang Bu Sari", "RECORD_SALE",
+			map[string]any{"contact": "Bu Sari", "credit_type": "RECEIVABLE", "product": "bakso", "qty": 10.0, "price": 15000.0}, "id", true},
+		{"beli beras 10 kg 12rb utang ke Pak Budi", "RECORD_EXPENSE",
+			map[string]any{"contact": "Pak Budi", "credit_type": "PAYABLE", "product": "beras", "qty": 10.0}, "id", true},
+		{"saya ngutang ke Pak Budi 200 ribu", "RECORD_DEBT",
+			map[string]any{"contact": "Pak Budi", "amount": 200000.0, "credit_type": "PAYABLE"}, "id", true},
+		{"bayar utang ke Pak Budi 100rb", "RECORD_DEBT_PAYMENT",
+			map[string]any{"contact": "Pak Budi", "amount": 100000.0, "credit_type": "PAYABLE"}, "id", true},
+		{"lunasin kasbon Bu Sari", "RECORD_DEBT_PAYMENT", map[string]any{"contact": "Bu Sari", "settle": true}, "id", true},
+		{"utang saya ke siapa aja", "ASK_DEBTS", map[string]any{"credit_type": "PAYABLE"}, "id", true},
+		{"Bu Sari ngutang", "RECORD_DEBT", map[string]any{"contact": "Bu Sari"}, "id", false},
+		{"bayar ke tukang 50 ribu", "RECORD_EXPENSE", map[string]any{}, "id", true},
+		{"budget gas 200 ribu sebulan", "SET_BUDGET", map[string]any{"category": "gas", "amount": 200000.0}, "id", true},
+		{"anggaran bahan baku 3 juta per bulan", "SET_BUDGET",
+			map[string]any{"category": "bahan baku", "amount": 3000000.0}, "id", true},
+		{"budget iklan sebulan 500rb", "SET_BUDGET", map[string]any{"category": "iklan", "amount": 500000.0}, "id", true},
+		{"budget 300 ribu", "SET_BUDGET", map[string]any{"category": nil, "amount": 300000.0}, "id", false},
+		{"cek budget bulan ini", "ASK_BUDGET", map[string]any{"suggest": nil}, "id", true},
+		{"saran budget dong", "ASK_BUDGET", map[string]any{"suggest": true}, "id", true},
+		{"cari beras 25 kilo budget 12 ribu", "ORDER_RESTOCK", map[string]any{"max_price": 12000.0}, "id", true},
+		{"saya sudah PKP", "SET_TAX", map[string]any{"pkp": true}, "id", true},
+		{"saya bukan pkp", "SET_TAX", map[string]any{"pkp": false}, "id", true},
+		{"ppn 12%", "SET_TAX", map[string]any{"ppn_rate": 12.0}, "id", true},
+		{"pajak saya badan, pph final", "SET_TAX", map[string]any{"taxpayer_type": "BADAN", "regime": "PPH_FINAL"}, "id", true},
+		{"pajak bulan ini berapa", "ASK_TAX", map[string]any{"period": nil, "paid": nil}, "id", true},
+		{"laporan pajak bulan lalu", "ASK_TAX", map[string]any{"period": "last_month"}, "id", true},
+		{"pajak sudah dibayar", "ASK_TAX", map[string]any{"paid": true}, "id", true},
+		{"pajak belum bayar", "ASK_TAX", map[string]any{"paid": nil}, "id", true},
+		{"bayar pajak 500 ribu", "RECORD_EXPENSE", map[string]any{"price": 500000.0}, "id", true},
+		{"tiap tanggal 1 bayar sewa 1 juta", "SET_RECURRING", map[string]any{"schedule": "0 8 1 * *", "product": "sewa", "price": 1000000.0, "type": "EXPENSE"}, "id", true},
+		{"setiap tanggal 31 bayar gaji 2 juta", "SET_RECURRING", map[string]any{"schedule": "0 8 L * *", "product": "gaji", "price": 2000000.0}, "id", true},
+		{"setiap hari senin beli gas 2 tabung 25rb otomatis", "SET_RECURRING", map[string]any{"schedule": "0 8 * * 1", "product": "gas", "qty": 2.0, "price": 25000.0, "auto_post": true}, "id", true},
+		{"tiap hari minggu gaji karyawan 500rb", "SET_RECURRING", map[string]any{"schedule": "0 8 * * 0", "product": "gaji karyawan", "type": "EXPENSE"}, "id", true},
+		{"bayar listrik 300rb tiap akhir bulan jam 7 malam", "SET_RECURRING", map[string]any{"schedule": "0 19 L * *", "product": "listrik"}, "id", true},
+		{"tiap hari laku nasi goreng 10 porsi 15rb", "SET_RECURRING", map[string]any{"schedule": "0 8 * * *", "type": "SALE", "qty": 10.0}, "id", true},
+		{"tiap minggu pesan telur 5 kg 28rb", "SET_RECURRING", map[string]any{"frequency": "WEEKLY", "type": "PURCHASE", "schedule": nil}, "id", true},
+		{"jadwal rutin", "ASK_RECURRING", nil, "id", true},
+		{"jeda jadwal sewa", "UPDATE_RECURRING", map[string]any{"op": "PAUSE", "product": "sewa"}, "id", true},
+		{"hapus jadwal gaji karyawan", "UPDATE_RECURRING", map[string]any{"op": "DELETE", "product": "gaji karyawan"}, "id", true},
+		{"laku bakso 10 porsi tiap", "RECORD_SALE", map[string]any{"qty": 10.0}, "id", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			intent, confidence := ParseIntentRules(tt.text)
+			if intent.Action != tt.action {
+				t.Fatalf("Action = %s, want %s", intent.Action, tt.action)
+			}
+			if got := confidence >= RuleConfidenceThreshold; got != tt.confident {
+				t.Errorf("confidence = %.2f, want confident=%v", confidence, tt.confident)
+			}
+			if intent.Language != tt.language {
+				t.Errorf("Language = %s, want %s", intent.Language, tt.language)
+			}
+			for key, want := range tt.entities {
+				if got := intent.Entities[key]; got != want {
+					t.Errorf("%s = %v, want %v", key, got, want)
+				}
+			}
+			if intent.RawText != tt.text {
+				t.Errorf("RawText = %q", intent.RawText)
+			}
+		})
+	}
+}
+
+func TestFormatIntentPrompt(t *testing.T) {
+	if got := formatIntentPrompt("halo", nil); got != "halo" {
+		t.Errorf("Without history = %q, want the message only", got)
+	}
+
+	got := formatIntentPrompt("yang tadi jadi 12000", []Turn{
+		{Role: "user", Content: "laku nasi rames 15 porsi 10000", Intent: "RECORD_SALE"},
+		{Role: "assistant", Content: "✅ Penjualan tercatat!"},
+	})
+	want := "Recent conversation:\nuser: laku nasi rames 15 porsi 10000 [RECORD_SALE]\nassistant: ✅ Penjualan tercatat!\n\n" +
+		"Current message: yang tadi jadi 12000"
+	if got != want {
+		t.Errorf("formatIntentPrompt() = %q, want %q", got, want)
+	}
+}
+
+func TestIntentEngine_RuleParserFirstAndFallback(t *testing.T) {
+	llm := &fakeProvider{name: "llm", errs: []error{errTransient}}
+	engine := NewIntentEngineWithProvider(NewRouter(RouterConfig{MaxAttempts: 1}, llm))
+	ctx := context.Background()
+
+	// Confident rule match skips the LLM
+	intent, err := engine.ProcessText(ctx, "laku es teh 10 gelas 5rb")
+	if err != nil || intent.Action != "RECORD_SALE" || llm.calls != 0 {
+		t.Fatalf("Expected rule match without LLM, got %v (calls %d, err %v)", intent, llm.calls, err)
+	}
+
+	// Partial match asks the LLM, and falls back to the rules when it fails
+	intent, err = engine.ProcessText(ctx, "beli gas 2 tabung")
+	if err != nil || intent.Action != "RECORD_EXPENSE" || llm.calls != 1 {
+		t.Fatalf("Expected rule fallback after LLM failure, got %v (calls %d, err %v)", intent, llm.calls, err)
+	}
+	if intent.RawText != "beli gas 2 tabung" {
+		t.Errorf("RawText = %q", intent.RawText)
+	}
+}
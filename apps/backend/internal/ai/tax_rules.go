@@ -0,0 +1,86 @@
+package ai
+
+import (
+	"slices"
+	"strconv"
+)
+
+// Words of taxes (id, jv, su)
+var (
+	taxWords = map[string]bool{
+		"pajak": true, "pajake": true, "pajaknya": true, "pajakna": true, "ppn": true, "pph": true, "pkp": true,
+		"spt": true,
+	}
+	taxDoneWords = map[string]bool{
+		"sudah": true, "udah": true, "dah": true, "wis": true, "wes": true, "sampun": true, "atos": true,
+		"lunas": true, "beres": true,
+	}
+	taxNegations = map[string]bool{
+		"bukan": true, "belum": true, "tidak": true, "tak": true, "non": true, "nggak": true, "gak": true,
+		"ora": true, "dereng": true, "sanes": true, "teu": true, "acan": true,
+	}
+	taxEntityWords     = map[string]bool{"badan": true, "pt": true, "cv": true, "koperasi": true}
+	taxIndividualWords = map[string]bool{"pribadi": true, "perorangan": true, "perseorangan": true}
+	taxNormalWords     = map[string]bool{"pembukuan": true, "normal": true}
+	taxMonthWords      = map[string]bool{"bulan": true, "sasi": true, "sasih": true, "wulan": true}
+)
+
+// parseTaxRules recognises tax settings ("saya PKP", "pajak badan", "ppn
+// 12%", "pph final"), questions about this or last month's tax ("pajak bulan
+// ini", "pajak bulan lalu berapa") and paid tax ("sudah bayar pajak"). A tax
+// paid with an amount ("bayar pajak 500 ribu") is an expense, so callers
+// skip it for RECORD_EXPENSE with an amount.
+func parseTaxRules(tokens []string, intent *Intent) bool {
+	taxAt := -1
+	question, done, negated := false, false, false
+	for i, tok := range tokens {
+		if taxWords[tok] && taxAt < 0 {
+			taxAt = i
+		}
+		question = question || questionWords[tok]
+		done = done || taxDoneWords[tok]
+		negated = negated || taxNegations[tok]
+	}
+	if taxAt < 0 {
+		return false
+	}
+
+	settings := map[string]any{}
+	for i, tok := range tokens {
+		switch {
+		case tok == "pkp":
+			settings["pkp"] = i == 0 || !taxNegations[tokens[i-1]]
+		case tok == "ppn" && i+1 < len(tokens) && isRuleNumber(tokens[i+1]):
+			if rate, err := strconv.ParseFloat(tokens[i+1], 64); err == nil && rate > 0 && rate < 100 {
+				settings["ppn_rate"] = rate
+			}
+		case tok == "final":
+			settings["regime"] = "PPH_FINAL"
+		case taxNormalWords[tok]:
+			settings["regime"] = "NORMAL"
+		case taxEntityWords[tok]:
+			settings["taxpayer_type"] = "BADAN"
+		case taxIndividualWords[tok]:
+			settings["taxpayer_type"] = "ORANG_PRIBADI"
+		}
+	}
+	if len(settings) > 0 && !question {
+		intent.Action = "SET_TAX"
+		for k, v := range settings {
+			intent.Entities[k] = v
+		}
+		return true
+	}
+
+	intent.Action = "ASK_TAX"
+	if done && !negated {
+		intent.Entities["paid"] = true
+		return true
+	}
+	for i, tok := range tokens {
+		if i > 0 && taxMonthWords[tokens[i-1]] && slices.Contains(pastModifiers, tok) {
+			intent.Entities["period"] = "last_month"
+		}
+	}
+	return true
+}
@@ -1,81 +1,407 @@
 package ai
 
 import (
+	"math"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
-// NormalizeText normalizes Indonesian text for better intent extraction
+// NormalizeText normalizes Indonesian, Javanese and Sundanese text for
+// better intent extraction: "15rb" → "15000", "limolas porsi" → "15 porsi",
+// "setengah kilo" → "0.5 kg", "goceng" → "5000"
 func NormalizeText(text string) string {
 	text = strings.TrimSpace(text)
 
 	// Normalize numbers
 	text = normalizeNumbers(text)
 
+	// Spelled-out numbers, fractions and slang money
+	text = normalizeNumberWords(text)
+
 	// Normalize units
 	text = normalizeUnits(text)
 
 	return text
 }
 
+var (
+	thousandPattern  = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)?)\s*(?:(?:rb|ribu|rebu|ewu)(?:an)?|k)\b`)
+	millionPattern   = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)?)\s*(?:jt|juta|yuta)(?:an)?\b`)
+	separatorPattern = regexp.MustCompile(`(\d{1,3})\.(\d{3})`)
+	leadingNumber    = regexp.MustCompile(`\d+(?:[.,]\d+)?`)
+)
+
 // normalizeNumbers converts Indonesian number formats to standard format
 func normalizeNumbers(text string) string {
-	// "15rb" → "15000"
-	// "15ribu" → "15000"
-	// "2,5jt" → "2500000"
-	// "2,5juta" → "2500000"
 	// "12.000" → "12000"
 	// "1.250.000" → "1250000"
+	for separatorPattern.MatchString(text) {
+		text = separatorPattern.ReplaceAllString(text, "$1$2")
+	}
+
+	// "15rb", "15ribu", "12 ewu", "300 rebu", "15k" → "15000"
+	text = thousandPattern.ReplaceAllStringFunc(text, func(match string) string {
+		return scaleNumber(leadingNumber.FindString(match), 1000)
+	})
+
+	// "2,5jt", "2,5juta" → "2500000"
+	text = millionPattern.ReplaceAllStringFunc(text, func(match string) string {
+		return scaleNumber(leadingNumber.FindString(match), 1000000)
+	})
+
+	return text
+}
+
+// scaleNumber multiplies a number that may use a decimal comma
+func scaleNumber(numStr string, factor float64) string {
+	value, err := strconv.ParseFloat(strings.ReplaceAll(numStr, ",", "."), 64)
+	if err != nil {
+		return numStr
+	}
+	return formatDigits(value * factor)
+}
 
-	// Handle "rb" and "ribu" (thousands)
-	rbPattern := regexp.MustCompile(`(\d+(?:[.,]\d+)?)\s*(?:rb|ribu)`)
-	text = rbPattern.ReplaceAllStringFunc(text, func(match string) string {
-		// Extract number part
-		numPattern := regexp.MustCompile(`(\d+(?:[.,]\d+)?)`)
-		numStr := numPattern.FindString(match)
-
-		// Replace comma with dot for decimal
-		numStr = strings.ReplaceAll(numStr, ",", ".")
-
-		// Convert to float, multiply by 1000
-		// For simplicity, just append "000" if no decimal
-		if strings.Contains(numStr, ".") {
-			// "2.5rb" → "2500"
-			parts := strings.Split(numStr, ".")
-			if len(parts) == 2 {
-				return parts[0] + parts[1] + "00"
+func formatDigits(value float64) string {
+	return strconv.FormatFloat(math.Round(value*1000)/1000, 'f', -1, 64)
+}
+
+type numberKind int
+
+const (
+	numDigit       numberKind = iota // 1-9, may be followed by puluh/belas/ratus
+	numValue                         // Complete values: sepuluh, rolas, selawe, seratus
+	numSlang                         // Slang money: goceng, ceban; a number of its own
+	numTeen                          // belas: digit + 10
+	numLikur                         // likur: digit + 20
+	numTens                          // puluh: digit × 10
+	numHundred                       // ratus: digit × 100
+	numMultiplier                    // ribu, juta: everything so far × multiplier
+	numFraction                      // setengah, seprapat: added to the digit before
+	numDenominator                   // prapat: digit × ¼ ("telung prapat")
+)
+
+type numberWord struct {
+	kind  numberKind
+	value float64
+}
+
+// numberWords covers Indonesian, Javanese (ngoko and krama) and Sundanese
+var numberWords = map[string]numberWord{
+	// Indonesian
+	"satu": {numDigit, 1}, "dua": {numDigit, 2}, "tiga": {numDigit, 3}, "empat": {numDigit, 4},
+	"lima": {numDigit, 5}, "enam": {numDigit, 6}, "tujuh": {numDigit, 7}, "delapan": {numDigit, 8},
+	"sembilan": {numDigit, 9}, "sepuluh": {numValue, 10}, "sebelas": {numValue, 11},
+	"seratus": {numValue, 100}, "seribu": {numValue, 1000}, "sejuta": {numValue, 1000000},
+	"semiliar": {numValue, 1000000000},
+
+	// Javanese
+	"siji": {numDigit, 1}, "setunggal": {numDigit, 1}, "loro": {numDigit, 2}, "kalih": {numDigit, 2},
+	"rong": {numDigit, 2}, "telu": {numDigit, 3}, "telung": {numDigit, 3}, "papat": {numDigit, 4},
+	"patang": {numDigit, 4}, "limang": {numDigit, 5}, "gangsal": {numDigit, 5}, "enem": {numDigit, 6},
+	"nem": {numDigit, 6}, "pitu": {numDigit, 7}, "pitung": {numDigit, 7}, "wolu": {numDigit, 8},
+	"wolung": {numDigit, 8}, "sanga": {numDigit, 9}, "sangang": {numDigit, 9},
+	"sedasa": {numValue, 10}, "sewelas": {numValue, 11}, "rolas": {numValue, 12}, "telulas": {numValue, 13},
+	"patbelas": {numValue, 14}, "limolas": {numValue, 15}, "nembelas": {numValue, 16}, "pitulas": {numValue, 17},
+	"wolulas": {numValue, 18}, "sangalas": {numValue, 19}, "selikur": {numValue, 21}, "rolikur": {numValue, 22},
+	"telulikur": {numValue, 23}, "patlikur": {numValue, 24}, "selawe": {numValue, 25}, "nemlikur": {numValue, 26},
+	"pitulikur": {numValue, 27}, "wolulikur": {numValue, 28}, "sangalikur": {numValue, 29},
+	"seket": {numValue, 50}, "sewidak": {numValue, 60}, "suwidak": {numValue, 60}, "satus": {numValue, 100},
+	"sewu": {numValue, 1000}, "sayuta": {numValue, 1000000},
+
+	// Sundanese
+	"hiji": {numDigit, 1}, "sahiji": {numDigit, 1}, "tilu": {numDigit, 3}, "opat": {numDigit, 4},
+	"genep": {numDigit, 6}, "dalapan": {numDigit, 8}, "salapan": {numDigit, 9},
+	"sapuluh": {numValue, 10}, "sabelas": {numValue, 11}, "salawe": {numValue, 25}, "saratus": {numValue, 100},
+	"sarebu": {numValue, 1000}, "sajuta": {numValue, 1000000},
+
+	// Colloquial money
+	"gocap": {numSlang, 50}, "cepek": {numSlang, 100}, "gopek": {numSlang, 500}, "seceng": {numSlang, 1000},
+	"ceceng": {numSlang, 1000}, "noceng": {numSlang, 2000}, "goceng": {numSlang, 5000}, "ceban": {numSlang, 10000},
+	"noban": {numSlang, 20000}, "goban": {numSlang, 50000},
+
+	// Building blocks
+	"belas": {numTeen, 10}, "welas": {numTeen, 10}, "likur": {numLikur, 20},
+	"puluh": {numTens, 10}, "dasa": {numTens, 10}, "ratus": {numHundred, 100}, "atus": {numHundred, 100},
+	"ribu": {numMultiplier, 1000}, "rebu": {numMultiplier, 1000}, "ewu": {numMultiplier, 1000},
+	"rb": {numMultiplier, 1000}, "juta": {numMultiplier, 1000000}, "yuta": {numMultiplier, 1000000},
+	"jt": {numMultiplier, 1000000}, "miliar": {numMultiplier, 1000000000}, "milyar": {numMultiplier, 1000000000},
+
+	// Fractions
+	"setengah": {numFraction, 0.5}, "satengah": {numFraction, 0.5}, "separuh": {numFraction, 0.5},
+	"sapalih": {numFraction, 0.5}, "seprapat": {numFraction, 0.25}, "seperempat": {numFraction, 0.25},
+	"saparapat": {numFraction, 0.25}, "prapat": {numDenominator, 0.25}, "perempat": {numDenominator, 0.25},
+	"parapat": {numDenominator, 0.25},
+}
+
+// numberSuffixes split run-together forms like "limaratus" or "rongewu"
+var numberSuffixes = []string{
+	"puluh", "belas", "welas", "likur", "ratus", "atus", "ribu", "rebu", "ewu", "juta", "yuta", "perempat", "prapat",
+}
+
+// oneWords mean "each" rather than "one" right after a price ("12 ewu siji")
+var oneWords = map[string]bool{
+	"satu": true, "siji": true, "setunggal": true, "hiji": true, "sahiji": true,
+}
+
+// seUnits can take the se-/sa- prefix meaning "one": "sekilo", "se-rak"
+var seUnits = map[string]bool{
+	"kilo": true, "ons": true, "liter": true, "lusin": true, "kodi": true, "rak": true, "karung": true,
+	"dus": true, "kardus": true, "bungkus": true, "ikat": true, "iket": true, "sisir": true, "tabung": true,
+	"porsi": true, "piring": true, "mangkok": true, "gelas": true, "botol": true, "biji": true, "butir": true,
+	"buah": true, "pack": true, "renteng": true, "galon": true, "krat": true, "karton": true, "box": true,
+	"kotak": true, "kantong": true, "kuintal": true, "kwintal": true, "ekor": true,
+}
+
+// articleUnits after se- are usually "a" ("sebuah toko", "seekor kucing"),
+// so they only count one when a price or unit follows ("sebuah 5000")
+var articleUnits = map[string]bool{"buah": true, "ekor": true}
+
+var numberTokenPattern = regexp.MustCompile(`[\p{L}\d]+(?:-[\p{L}\d]+)*`)
+
+// normalizeNumberWords replaces spelled-out numbers, fractions, slang money
+// and se-prefixed units with digits: "nem puluh ewu" → "60000",
+// "dua setengah juta" → "2500000", "sekilo" → "1 kilo"
+func normalizeNumberWords(text string) string {
+	locs := numberTokenPattern.FindAllStringIndex(text, -1)
+	words := make([]string, len(locs))
+	for i, loc := range locs {
+		words[i] = strings.ToLower(text[loc[0]:loc[1]])
+	}
+
+	var b strings.Builder
+	last := 0
+	afterNumber := false
+	for i := 0; i < len(locs); {
+		b.WriteString(text[last:locs[i][0]])
+
+		n, value := parseNumberSpan(text, locs[i:], words[i:])
+		if n > 0 && !(n == 1 && afterNumber && oneWords[words[i]]) {
+			b.WriteString(formatDigits(value))
+			last = locs[i+n-1][1]
+			i += n
+			afterNumber = true
+			continue
+		}
+
+		word := text[locs[i][0]:locs[i][1]]
+		if unit := seUnit(words[i]); unit != "" && !afterNumber && (!articleUnits[unit] || countFollows(text, locs[i+1:], words[i+1:])) {
+			word = "1 " + unit
+		}
+		b.WriteString(word)
+		last = locs[i][1]
+		afterNumber = words[i][0] >= '0' && words[i][0] <= '9'
+		i++
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// countFollows reports whether the next word is a price, a number or a unit
+func countFollows(text string, locs [][]int, words []string) bool {
+	if len(words) == 0 {
+		return false
+	}
+	if c := words[0][0]; c >= '0' && c <= '9' {
+		return true
+	}
+	if n, _ := parseNumberSpan(text, locs, words); n > 0 {
+		return true
+	}
+	return seUnits[words[0]] || unitAliases[words[0]] != ""
+}
+
+// parseNumberSpan reads the longest run of number words separated by spaces
+// and returns how many tokens it used and their value
+func parseNumberSpan(text string, locs [][]int, words []string) (int, float64) {
+	var p numberParser
+	used := 0
+	for i, word := range words {
+		if i > 0 && strings.TrimSpace(text[locs[i-1][1]:locs[i][0]]) != "" {
+			break
+		}
+		// "rolas ewu siji" is 12000 each, not 12001
+		if i > 0 && oneWords[word] {
+			break
+		}
+		parts := splitNumberWord(word)
+		if parts == nil {
+			break
+		}
+		saved := p
+		ok := true
+		for _, part := range parts {
+			if !p.feed(part) {
+				ok = false
+				break
 			}
 		}
-		return numStr + "000"
-	})
+		if !ok {
+			p = saved
+			break
+		}
+		used = i + 1
+	}
 
-	// Handle "jt" and "juta" (millions)
-	jtPattern := regexp.MustCompile(`(\d+(?:[.,]\d+)?)\s*(?:jt|juta)`)
-	text = jtPattern.ReplaceAllStringFunc(text, func(match string) string {
-		numPattern := regexp.MustCompile(`(\d+(?:[.,]\d+)?)`)
-		numStr := numPattern.FindString(match)
+	if used == 0 || !p.complete() {
+		return 0, 0
+	}
+	return used, p.value()
+}
 
-		numStr = strings.ReplaceAll(numStr, ",", ".")
+// splitNumberWord looks a word up, allowing an -an suffix on money words
+// ("ribuan", "gocengan") and run-together compounds ("limaratus")
+func splitNumberWord(word string) []numberWord {
+	if w, ok := numberWords[word]; ok {
+		return []numberWord{w}
+	}
+	if stem := strings.TrimSuffix(word, "an"); stem != word {
+		if w, ok := numberWords[stem]; ok && (w.kind == numMultiplier || (w.kind == numValue || w.kind == numSlang) && w.value >= 1000) {
+			return []numberWord{w}
+		}
+	}
+	for _, suffix := range numberSuffixes {
+		prefix, found := strings.CutSuffix(word, suffix)
+		if !found || prefix == "" {
+			continue
+		}
+		if head, ok := numberWords[prefix]; ok && (head.kind == numDigit || head.kind == numValue) {
+			return []numberWord{head, numberWords[suffix]}
+		}
+	}
+	return nil
+}
 
-		if strings.Contains(numStr, ".") {
-			parts := strings.Split(numStr, ".")
-			if len(parts) == 2 {
-				return parts[0] + parts[1] + "00000"
+// seUnit returns the unit for "sekilo", "sabungkus" or "se-rak"
+func seUnit(word string) string {
+	for _, prefix := range []string{"se-", "sa-", "se", "sa"} {
+		unit, found := strings.CutPrefix(word, prefix)
+		if !found || !seUnits[unit] {
+			continue
+		}
+		// "serak" means hoarse, only the hyphenated form is a unit
+		if unit == "rak" && !strings.HasSuffix(prefix, "-") {
+			return ""
+		}
+		return unit
+	}
+	return ""
+}
+
+// numberParser accumulates number words left to right: total holds
+// completed thousands/millions, group the part below a thousand and pending
+// a digit waiting for puluh/belas/ratus. closed ends the number after slang
+// money, so "ceban lima porsi" is 10000 and 5.
+type numberParser struct {
+	total, group, pending float64
+	hasPending, closed    bool
+	lastMultiplier        float64
+	words                 int
+}
+
+func (p *numberParser) feed(w numberWord) bool {
+	if p.closed {
+		return false
+	}
+	switch w.kind {
+	case numDigit:
+		if p.hasPending {
+			return false // "dua tiga" is two numbers
+		}
+		p.pending, p.hasPending = w.value, true
+
+	case numValue:
+		if p.hasPending || p.group > 0 && w.value >= p.group {
+			return false
+		}
+		if w.value >= 1000 {
+			if p.group > 0 || p.lastMultiplier > 0 && w.value >= p.lastMultiplier {
+				return false
 			}
+			p.total += w.value
+			p.lastMultiplier = w.value
+		} else {
+			p.group += w.value
 		}
-		return numStr + "000000"
-	})
 
-	// Remove dots from numbers (Indonesian thousand separator)
-	// But keep dots that are part of decimal numbers
-	// "12.000" → "12000"
-	// "1.250.000" → "1250000"
-	dotPattern := regexp.MustCompile(`(\d{1,3})\.(\d{3})`)
-	for dotPattern.MatchString(text) {
-		text = dotPattern.ReplaceAllString(text, "$1$2")
+	case numSlang:
+		if p.words > 0 {
+			return false
+		}
+		p.total, p.closed = w.value, true
+
+	case numTeen, numLikur:
+		if !p.hasPending || p.pending != math.Trunc(p.pending) {
+			return false
+		}
+		p.group += p.pending + w.value
+		p.pending, p.hasPending = 0, false
+
+	case numTens, numHundred:
+		if !p.hasPending || p.pending != math.Trunc(p.pending) || w.kind == numHundred && p.group >= 100 {
+			return false
+		}
+		p.group += p.pending * w.value
+		p.pending, p.hasPending = 0, false
+
+	case numMultiplier:
+		g := p.group + p.pending
+		if g == 0 || p.lastMultiplier > 0 && w.value >= p.lastMultiplier {
+			return false
+		}
+		p.total += g * w.value
+		p.lastMultiplier = w.value
+		p.group, p.pending, p.hasPending = 0, 0, false
+
+	case numFraction:
+		switch {
+		case p.hasPending && p.pending == math.Trunc(p.pending):
+			p.pending += w.value // "dua setengah"
+		case p.words == 0:
+			p.pending, p.hasPending = w.value, true
+		case p.group == 0 && p.lastMultiplier > 0 && p.total == p.lastMultiplier:
+			p.total += p.lastMultiplier * w.value // "sejuta setengah"
+			p.lastMultiplier = p.lastMultiplier * w.value
+		default:
+			return false
+		}
+
+	case numDenominator:
+		if !p.hasPending || p.pending >= 4 {
+			return false
+		}
+		p.pending *= w.value
 	}
 
-	return text
+	p.words++
+	return true
+}
+
+// complete rejects spans that are only building blocks, like a lone "ewu"
+func (p *numberParser) complete() bool {
+	return p.words > 0 && p.value() > 0
+}
+
+func (p *numberParser) value() float64 {
+	return p.total + p.group + p.pending
+}
+
+var (
+	unitPattern      = regexp.MustCompile(`(?i)(\d)\s*(kg|gram|liter|ml|porsi|pcs|butir|biji|buah|pack|dus|karton|box|tabung|bungkus|gelas|botol|lusin|kodi|ons)\b`)
+	unitAliasPattern = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)?)\s*(kilogram|kilo|kgs|grm|gr|ltr|lt)\b`)
+	unitScalePattern = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)?)\s*(lusin|kodi|ons|kuintal|kwintal)\b`)
+)
+
+var unitAliases = map[string]string{
+	"kilogram": "kg", "kilo": "kg", "kgs": "kg", "grm": "gram", "gr": "gram", "ltr": "liter", "lt": "liter",
+}
+
+// Counting units converted to a base unit: "2 lusin" → "24 pcs"
+var unitScales = map[string]struct {
+	factor float64
+	unit   string
+}{
+	"lusin": {12, "pcs"}, "kodi": {20, "pcs"}, "ons": {100, "gram"}, "kuintal": {100, "kg"}, "kwintal": {100, "kg"},
 }
 
 // normalizeUnits normalizes unit formats
@@ -83,10 +409,21 @@ func normalizeUnits(text string) string {
 	// "25kg" → "25 kg"
 	// "2liter" → "2 liter"
 	// "10porsi" → "10 porsi"
-
-	unitPattern := regexp.MustCompile(`(\d+)\s*(kg|gram|liter|ml|porsi|pcs|butir|biji|buah|pack|dus|karton|box)`)
 	text = unitPattern.ReplaceAllString(text, "$1 $2")
 
+	// "25 kilo" → "25 kg"
+	text = unitAliasPattern.ReplaceAllStringFunc(text, func(match string) string {
+		m := unitAliasPattern.FindStringSubmatch(match)
+		return m[1] + " " + unitAliases[strings.ToLower(m[2])]
+	})
+
+	// "selusin" → "1 lusin" → "12 pcs", "2 ons" → "200 gram"
+	text = unitScalePattern.ReplaceAllStringFunc(text, func(match string) string {
+		m := unitScalePattern.FindStringSubmatch(match)
+		scale := unitScales[strings.ToLower(m[2])]
+		return scaleNumber(m[1], scale.factor) + " " + scale.unit
+	})
+
 	return text
 }
 
@@ -0,0 +1,153 @@
+package ai
+
+import "testing"
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		// Digits with suffixes
+		{"ribu short", "15rb", "15000"},
+		{"ribu spaced", "15 ribu", "15000"},
+		{"k slang", "harga 15k", "harga 15000"},
+		{"ewu digits", "12 ewu", "12000"},
+		{"rebu digits", "300 rebu", "300000"},
+		{"decimal ribu", "2,5rb", "2500"},
+		{"two decimals", "1,25jt", "1250000"},
+		{"juta", "2,5 juta", "2500000"},
+		{"yuta", "3 yuta", "3000000"},
+		{"separator", "1.250.000", "1250000"},
+		{"ribuan", "5 ribuan", "5000"},
+
+		// Indonesian words
+		{"unit", "beli dua kilo", "beli 2 kg"},
+		{"sebelas", "sebelas porsi", "11 porsi"},
+		{"belas", "dua belas gelas", "12 gelas"},
+		{"puluh", "dua puluh lima kg", "25 kg"},
+		{"ratus ribu", "tiga ratus ribu", "300000"},
+		{"compound", "seratus dua puluh lima ribu", "125000"},
+		{"ribu ratus", "sebelas ribu lima ratus", "11500"},
+		{"juta ribu", "satu juta dua ratus ribu", "1200000"},
+		{"sejuta", "sewa lapak sejuta", "sewa lapak 1000000"},
+		{"seribuan", "gorengan seribuan", "gorengan 1000"},
+		{"run together", "limaratus", "500"},
+		{"run together ribu", "sepuluhribu", "10000"},
+		{"case", "Dua Puluh kilo", "20 kg"},
+
+		// Javanese
+		{"limolas", "limolas porsi", "15 porsi"},
+		{"rolas ewu siji", "rolas ewu siji", "12000 siji"},
+		{"prompt example", "Tadi laku nasi rames limolas porsi, rolas ewu siji", "Tadi laku nasi rames 15 porsi, 12000 siji"},
+		{"selawe", "golek beras selawe kilo", "golek beras 25 kg"},
+		{"likur", "telulikur", "23"},
+		{"likur split", "telu likur", "23"},
+		{"seket ewu", "seket ewu", "50000"},
+		{"sewidak", "sewidak", "60"},
+		{"nem puluh ewu", "nem puluh ewu", "60000"},
+		{"rong puluh", "rong puluh ewu", "20000"},
+		{"rongewu", "rongewu", "2000"},
+		{"telung atus", "telung atus ewu", "300000"},
+		{"satus", "satus seket", "150"},
+		{"sewu", "sewu limang atus", "1500"},
+		{"telung mangkok", "soto telung mangkok", "soto 3 mangkok"},
+		{"krama", "kalih dasa", "20"},
+		{"loro", "payu loro", "payu 2"},
+
+		// Sundanese
+		{"tilu ratus rebu", "mayar listrik tilu ratus rebu", "mayar listrik 300000"},
+		{"sapuluh", "milari beas sapuluh kilo", "milari beas 10 kg"},
+		{"sarebu", "sarebu", "1000"},
+		{"genep", "genep bungkus", "6 bungkus"},
+		{"salawe rebu", "salawe rebu", "25000"},
+
+		// Fractions
+		{"setengah kilo", "setengah kilo", "0.5 kg"},
+		{"seprapat", "seprapat kilo", "0.25 kg"},
+		{"seperempat", "seperempat", "0.25"},
+		{"tiga perempat", "tiga perempat kilo", "0.75 kg"},
+		{"telung prapat", "telung prapat kilo", "0.75 kg"},
+		{"dua setengah juta", "dua setengah juta", "2500000"},
+		{"setengah juta", "setengah juta", "500000"},
+		{"sejuta setengah", "sejuta setengah", "1500000"},
+		{"satengah", "satengah kilo", "0.5 kg"},
+		{"tiga setengah", "tiga setengah liter", "3.5 liter"},
+
+		// se- units
+		{"sekilo", "sekilo gula", "1 kg gula"},
+		{"sakilo", "sakilo beas", "1 kg beas"},
+		{"selusin", "selusin telur", "12 pcs telur"},
+		{"se-rak", "se-rak telur", "1 rak telur"},
+		{"serak", "suara serak", "suara serak"},
+		{"seliter", "seliter minyak", "1 liter minyak"},
+		{"seons", "seons", "100 gram"},
+		{"per unit price", "15rb seporsi", "15000 seporsi"},
+		{"sebuah as article", "sebuah toko", "sebuah toko"},
+		{"seekor as article", "ada seekor kucing", "ada seekor kucing"},
+		{"sebuah with price", "semangka sebuah 15rb", "semangka 1 buah 15000"},
+		{"seekor with price", "ayam seekor lima puluh ribu", "ayam 1 ekor 50000"},
+
+		// Units
+		{"attached", "25kg", "25 kg"},
+		{"kilo", "25 kilo", "25 kg"},
+		{"gr", "500gr", "500 gram"},
+		{"ltr", "2 ltr", "2 liter"},
+		{"lusin", "2 lusin", "24 pcs"},
+		{"kodi", "sekodi", "20 pcs"},
+		{"ons", "2 ons", "200 gram"},
+		{"setengah ons", "setengah ons", "50 gram"},
+		{"kuintal", "sekuintal", "100 kg"},
+
+		// k suffix
+		{"k", "gorengan 15k", "gorengan 15000"},
+		{"k spaced", "15 k", "15000"},
+		{"k word", "5 kan", "5 kan"},
+		{"k prefix", "3 kali", "3 kali"},
+
+		// Slang money
+		{"gopek", "gopek", "500"},
+		{"goceng", "goceng", "5000"},
+		{"gocengan", "gocengan", "5000"},
+		{"ceban", "ceban", "10000"},
+		{"goban", "goban", "50000"},
+		{"noceng", "noceng satu", "2000 satu"},
+		{"slang then digit", "gopek lima", "500 5"},
+		{"slang then digit 2", "ceban dua", "10000 2"},
+		{"slang before qty", "jual ceban lima porsi", "jual 10000 5 porsi"},
+		{"digit then slang", "dua goceng", "2 5000"},
+		{"slang then value", "ceceng sepuluh", "1000 10"},
+
+		// Left alone
+		{"two numbers", "dua tiga", "2 3"},
+		{"lone multiplier", "ewu", "ewu"},
+		{"jutaan", "jutaan orang", "jutaan orang"},
+		{"satuan", "harga satuan", "harga satuan"},
+		{"no numbers", "halo mas", "halo mas"},
+		{"trim", "  stok beras  ", "stok beras"},
+		{"kantong", "10 kantong", "10 kantong"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeText(tt.in); got != tt.want {
+				t.Errorf("NormalizeText(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizePrice(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"telur @2500", "telur  2500"},
+		{"@15rb", " 15000"},
+		{"1.500", "1500"},
+	}
+	for _, tt := range tests {
+		if got := NormalizePrice(tt.in); got != tt.want {
+			t.Errorf("NormalizePrice(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
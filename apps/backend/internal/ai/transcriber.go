@@ -0,0 +1,215 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Transcriber converts speech to text. language is a hint (id, jv, su) and
+// may be empty when the speaker's language is unknown.
+type Transcriber interface {
+	Name() string
+	Transcribe(ctx context.Context, audioData []byte, mimeType, language string) (*Transcription, error)
+}
+
+// GeminiTranscriber transcribes through the LLM provider chain; of our
+// providers only Gemini accepts audio
+type GeminiTranscriber struct {
+	llm Provider
+}
+
+func NewGeminiTranscriber(llm Provider) *GeminiTranscriber {
+	return &GeminiTranscriber{llm: llm}
+}
+
+func (g *GeminiTranscriber) Name() string {
+	return "gemini"
+}
+
+// Transcribe asks for a transcript with confidence; the prompt already
+// covers Indonesian, Javanese and Sundanese so language is not needed
+func (g *GeminiTranscriber) Transcribe(ctx context.Context, audioData []byte, mimeType, language string) (*Transcription, error) {
+	return TranscribeAudioWithConfidence(ctx, g.llm, audioData, mimeType)
+}
+
+// DefaultSTTCosts are rough USD per audio minute: Gemini Flash audio input
+// against a small self-hosted whisper server
+var DefaultSTTCosts = map[string]float64{
+	"whisper": 0.0002,
+	"gemini":  0.0015,
+}
+
+type transcriberRoute struct {
+	Transcriber
+	cost    float64
+	breaker *CircuitBreaker
+}
+
+// TranscriberRouter tries transcribers cheapest first and falls back on
+// error. Languages can prefer transcribers that recognise them better.
+type TranscriberRouter struct {
+	routes      []*transcriberRoute
+	preferences map[string][]string
+}
+
+// NewTranscriberRouter creates an empty router; add transcribers with Add
+func NewTranscriberRouter() *TranscriberRouter {
+	return &TranscriberRouter{preferences: make(map[string][]string)}
+}
+
+// Add registers a transcriber at its cost per audio minute; nil is skipped
+func (r *TranscriberRouter) Add(t Transcriber, costPerMinute float64) {
+	if t == nil {
+		return
+	}
+	cfg := DefaultRouterConfig()
+	r.routes = append(r.routes, &transcriberRoute{
+		Transcriber: t,
+		cost:        costPerMinute,
+		breaker:     NewCircuitBreaker(cfg.FailureThreshold, cfg.Cooldown),
+	})
+	sort.SliceStable(r.routes, func(i, j int) bool {
+		return r.routes[i].cost < r.routes[j].cost
+	})
+}
+
+// Prefer makes language try the named transcribers first, in order
+func (r *TranscriberRouter) Prefer(language string, names ...string) {
+	r.preferences[language] = names
+}
+
+// Name returns the default order, e.g. "whisper>gemini"
+func (r *TranscriberRouter) Name() string {
+	names := make([]string, len(r.routes))
+	for i, route := range r.routes {
+		names[i] = route.Name()
+	}
+	return strings.Join(names, ">")
+}
+
+// Len returns the number of configured transcribers
+func (r *TranscriberRouter) Len() int {
+	return len(r.routes)
+}
+
+// order returns the routes for language: preferred ones, then by cost
+func (r *TranscriberRouter) order(language string) []*transcriberRoute {
+	preferred := r.preferences[language]
+	if len(preferred) == 0 {
+		return r.routes
+	}
+	rank := func(route *transcriberRoute) int {
+		for i, name := range preferred {
+			if route.Name() == name {
+				return i
+			}
+		}
+		return len(preferred)
+	}
+	routes := append([]*transcriberRoute(nil), r.routes...)
+	sort.SliceStable(routes, func(i, j int) bool {
+		return rank(routes[i]) < rank(routes[j])
+	})
+	return routes
+}
+
+// Transcribe uses the first transcriber in order that answers
+func (r *TranscriberRouter) Transcribe(ctx context.Context, audioData []byte, mimeType, language string) (*Transcription, error) {
+	if len(r.routes) == 0 {
+		return nil, ErrNoProviders
+	}
+
+	var lastErr error
+	for i, route := range r.order(language) {
+		if !route.breaker.Allow() {
+			log.Printf("⚡ %s circuit open, skipping", route.Name())
+			lastErr = fmt.Errorf("%s: circuit breaker open: too many failures", route.Name())
+			continue
+		}
+
+		start := time.Now()
+		transcription, err := route.Transcribe(ctx, audioData, mimeType, language)
+		if err == nil {
+			route.breaker.RecordSuccess()
+			if i > 0 {
+				log.Printf("✅ STT fell back to %s (%v)", route.Name(), time.Since(start).Round(time.Millisecond))
+			}
+			return transcription, nil
+		}
+
+		lastErr = err
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		// Quota and missing keys are not the transcriber's fault
+		if !errors.Is(err, ErrQuotaExceeded) && !errors.Is(err, ErrNoProviders) {
+			route.breaker.RecordFailure()
+		}
+		log.Printf("⚠️ STT %s failed, trying next: %v", route.Name(), err)
+	}
+
+	return nil, fmt.Errorf("all transcribers failed: %w", lastErr)
+}
+
+// STTSettings configures speech-to-text routing
+type STTSettings struct {
+	WhisperURL   string // OpenAI-compatible base URL, e.g. http://localhost:8000/v1
+	WhisperKey   string
+	WhisperModel string
+	Providers    []string            // Transcribers to use, e.g. whisper, gemini
+	Costs        map[string]float64  // USD per minute, overriding DefaultSTTCosts
+	Prefer       map[string][]string // Transcribers tried first per language
+}
+
+// NewDefaultTranscriber builds the STT router from settings; whisper is
+// left out without a URL and gemini shares the LLM provider chain
+func NewDefaultTranscriber(llm Provider, s STTSettings) *TranscriberRouter {
+	providers := s.Providers
+	if len(providers) == 0 {
+		providers = []string{"whisper", "gemini"}
+	}
+	cost := func(name string) float64 {
+		if c, ok := s.Costs[name]; ok {
+			return c
+		}
+		return DefaultSTTCosts[name]
+	}
+
+	r := NewTranscriberRouter()
+	for _, name := range providers {
+		switch name {
+		case "whisper":
+			if s.WhisperURL != "" {
+				r.Add(NewWhisperClient(s.WhisperURL, s.WhisperKey, s.WhisperModel), cost(name))
+			}
+		case "gemini":
+			if IsAvailable(llm) {
+				r.Add(NewGeminiTranscriber(llm), cost(name))
+			}
+		default:
+			log.Printf("⚠️ Unknown STT provider %q ignored", name)
+		}
+	}
+	for language, names := range s.Prefer {
+		r.Prefer(language, names...)
+	}
+	return r
+}
+
+type languageKey struct{}
+
+// WithLanguage tags ctx with the speaker's language (id, jv, su) for STT routing
+func WithLanguage(ctx context.Context, language string) context.Context {
+	return context.WithValue(ctx, languageKey{}, language)
+}
+
+// LanguageFromContext returns the language set by WithLanguage, or ""
+func LanguageFromContext(ctx context.Context) string {
+	language, _ := ctx.Value(languageKey{}).(string)
+	return language
+}
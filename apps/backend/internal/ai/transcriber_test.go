@@ -0,0 +1,172 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// stubTranscriber answers with its name or fails with err
+type stubTranscriber struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (s *stubTranscriber) Name() string { return s.name }
+
+func (s *stubTranscriber) Transcribe(ctx context.Context, audioData []byte, mimeType, language string) (*Transcription, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &Transcription{Text: s.name}, nil
+}
+
+func TestTranscriberRouter_Order(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		failing  string
+		want     string
+	}{
+		{"cheapest first", "id", "", "whisper"},
+		{"no language", "", "", "whisper"},
+		{"javanese prefers gemini", "jv", "", "gemini"},
+		{"fallback on error", "id", "whisper", "gemini"},
+		{"preferred falls back to cheapest", "jv", "gemini", "whisper"},
+	}
+	for _, tt := range tests {
+		gemini := &stubTranscriber{name: "gemini"}
+		whisper := &stubTranscriber{name: "whisper"}
+		for _, s := range []*stubTranscriber{gemini, whisper} {
+			if s.name == tt.failing {
+				s.err = &ProviderError{Provider: s.name, StatusCode: 503, Retryable: true, Err: errors.New("down")}
+			}
+		}
+
+		r := NewTranscriberRouter()
+		r.Add(gemini, 0.0015)
+		r.Add(whisper, 0.0002)
+		r.Prefer("jv", "gemini")
+
+		got, err := r.Transcribe(context.Background(), []byte("audio"), "audio/ogg", tt.language)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got.Text != tt.want {
+			t.Errorf("%s: transcribed by %s, want %s", tt.name, got.Text, tt.want)
+		}
+	}
+}
+
+func TestTranscriberRouter_AllFail(t *testing.T) {
+	r := NewTranscriberRouter()
+	if _, err := r.Transcribe(context.Background(), nil, "audio/ogg", ""); !errors.Is(err, ErrNoProviders) {
+		t.Errorf("empty router err = %v, want ErrNoProviders", err)
+	}
+
+	r.Add(&stubTranscriber{name: "whisper", err: errors.New("connection refused")}, 0)
+	r.Add(&stubTranscriber{name: "gemini", err: ErrQuotaExceeded}, 1)
+	if _, err := r.Transcribe(context.Background(), nil, "audio/ogg", ""); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("err = %v, want the last error wrapped", err)
+	}
+	if r.Name() != "whisper>gemini" {
+		t.Errorf("Name() = %q", r.Name())
+	}
+}
+
+func TestWhisperClient_Transcribe(t *testing.T) {
+	var got map[string][]string
+	var file []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/transcriptions" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		got = r.MultipartForm.Value
+		f, header, err := r.FormFile("file")
+		if err != nil || header.Filename != "audio.ogg" {
+			http.Error(w, "bad file", http.StatusBadRequest)
+			return
+		}
+		file, _ = io.ReadAll(f)
+		w.Write([]byte(`{
+			"text": " Aku adol bakso sepuluh mangkok ",
+			"language": "javanese",
+			"segments": [
+				{"start": 0, "end": 2, "text": " Aku adol bakso", "avg_logprob": -0.1,
+				 "words": [{"word": " Aku", "probability": 0.95}, {"word": " adol", "probability": 0.9}, {"word": " bakso", "probability": 0.3}]},
+				{"start": 2, "end": 3, "text": " sepuluh mangkok", "avg_logprob": -0.9}
+			]
+		}`))
+	}))
+	defer server.Close()
+
+	c := NewWhisperClient(server.URL+"/v1/", "", "small")
+	tr, err := c.Transcribe(context.Background(), []byte("OggS..."), "audio/ogg; codecs=opus", "jv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(file) != "OggS..." {
+		t.Errorf("uploaded %q", file)
+	}
+	if got["model"][0] != "small" || got["language"][0] != "jw" || got["response_format"][0] != "verbose_json" {
+		t.Errorf("form = %v", got)
+	}
+
+	if tr.Text != "Aku adol bakso sepuluh mangkok" {
+		t.Errorf("Text = %q", tr.Text)
+	}
+	want := (math.Exp(-0.1)*2 + math.Exp(-0.9)) / 3
+	if math.Abs(tr.Confidence-want) > 1e-9 {
+		t.Errorf("Confidence = %v, want %v", tr.Confidence, want)
+	}
+	// Word probabilities where present, the doubtful segment otherwise
+	wantWords := []string{"bakso", "sepuluh", "mangkok"}
+	if len(tr.UncertainWords) != len(wantWords) {
+		t.Fatalf("UncertainWords = %v, want %v", tr.UncertainWords, wantWords)
+	}
+	for i, w := range wantWords {
+		if tr.UncertainWords[i] != w {
+			t.Errorf("UncertainWords = %v, want %v", tr.UncertainWords, wantWords)
+		}
+	}
+}
+
+func TestWhisperClient_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		retryable bool
+	}{
+		{"overloaded", http.StatusServiceUnavailable, `{"error":{"message":"model loading"}}`, true},
+		{"bad audio", http.StatusBadRequest, `{"error":{"message":"invalid file"}}`, false},
+		{"plain text error", http.StatusBadGateway, `Bad Gateway`, true},
+		{"empty transcript", http.StatusOK, `{"text":"  "}`, false},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+		_, err := NewWhisperClient(server.URL, "", "").Transcribe(context.Background(), []byte("x"), "audio/wav", "")
+		server.Close()
+		if err == nil {
+			t.Errorf("%s: expected error", tt.name)
+			continue
+		}
+		if IsRetryable(err) != tt.retryable {
+			t.Errorf("%s: retryable = %v, want %v (%v)", tt.name, IsRetryable(err), tt.retryable, err)
+		}
+	}
+}
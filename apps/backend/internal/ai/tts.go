@@ -0,0 +1,225 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxSpeechChars is the longest text rendered as a voice note; longer
+// replies are cut to their first lines so the note stays short
+const MaxSpeechChars = 600
+
+// SynthesizedAudio is a rendered voice note
+type SynthesizedAudio struct {
+	Data     []byte
+	MimeType string
+	Seconds  int
+}
+
+// SpeechSynthesizer converts reply text to speech
+type SpeechSynthesizer interface {
+	Synthesize(ctx context.Context, text, language string) (*SynthesizedAudio, error)
+}
+
+// GoogleTTSClient synthesizes OGG/Opus speech with Google Cloud Text-to-Speech
+type GoogleTTSClient struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewGoogleTTSClient(apiKey string) *GoogleTTSClient {
+	return &GoogleTTSClient{
+		apiKey:  apiKey,
+		baseURL: "https://texttospeech.googleapis.com/v1",
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+type ttsRequest struct {
+	Input struct {
+		Text string `json:"text"`
+	} `json:"input"`
+	Voice struct {
+		LanguageCode string `json:"languageCode"`
+	} `json:"voice"`
+	AudioConfig struct {
+		AudioEncoding   string `json:"audioEncoding"`
+		SampleRateHertz int    `json:"sampleRateHertz"`
+	} `json:"audioConfig"`
+}
+
+type ttsResponse struct {
+	AudioContent string       `json:"audioContent"`
+	Error        *GeminiError `json:"error,omitempty"`
+}
+
+// ttsLanguageCode maps our language codes to TTS voices. Javanese and
+// Sundanese have voices; other languages fall back to Indonesian.
+func ttsLanguageCode(language string) string {
+	switch language {
+	case "jv":
+		return "jv-ID"
+	case "su":
+		return "su-ID"
+	default:
+		return "id-ID"
+	}
+}
+
+// Synthesize renders text as an OGG/Opus voice note
+func (c *GoogleTTSClient) Synthesize(ctx context.Context, text, language string) (*SynthesizedAudio, error) {
+	if c.apiKey == "" {
+		return nil, fmt.Errorf("TTS API key not configured")
+	}
+
+	var req ttsRequest
+	req.Input.Text = text
+	req.Voice.LanguageCode = ttsLanguageCode(language)
+	req.AudioConfig.AudioEncoding = "OGG_OPUS"
+	req.AudioConfig.SampleRateHertz = 48000
+
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/text:synthesize?key=%s", c.baseURL, c.apiKey)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var ttsResp ttsResponse
+	if err := json.Unmarshal(body, &ttsResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if ttsResp.Error != nil {
+		return nil, fmt.Errorf("TTS API error %d: %s", ttsResp.Error.Code, ttsResp.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("TTS API returned status %d", resp.StatusCode)
+	}
+
+	audio, err := base64.StdEncoding.DecodeString(ttsResp.AudioContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode audio: %w", err)
+	}
+
+	return &SynthesizedAudio{
+		Data:     audio,
+		MimeType: "audio/ogg; codecs=opus",
+		Seconds:  OggDurationSeconds(audio),
+	}, nil
+}
+
+// StubSynthesizer returns a fixed clip, for tests and local development
+type StubSynthesizer struct {
+	Audio []byte
+	Calls []string // Texts passed to Synthesize
+}
+
+// Synthesize records the text and returns the stub clip
+func (s *StubSynthesizer) Synthesize(ctx context.Context, text, language string) (*SynthesizedAudio, error) {
+	s.Calls = append(s.Calls, text)
+	return &SynthesizedAudio{
+		Data:     s.Audio,
+		MimeType: "audio/ogg; codecs=opus",
+		Seconds:  OggDurationSeconds(s.Audio),
+	}, nil
+}
+
+// OggDurationSeconds reads the duration of an Ogg/Opus stream from the
+// granule position of its last page. Opus granules always count 48 kHz samples.
+func OggDurationSeconds(data []byte) int {
+	idx := bytes.LastIndex(data, []byte("OggS"))
+	if idx < 0 || idx+14 > len(data) {
+		return 0
+	}
+	granule := binary.LittleEndian.Uint64(data[idx+6 : idx+14])
+	if granule == 0 || granule == ^uint64(0) {
+		return 0
+	}
+	return int((granule + 47999) / 48000)
+}
+
+var (
+	speechRupiahRe   = regexp.MustCompile(`Rp\.?\s*([0-9](?:[0-9.,]*[0-9])?)`)
+	speechMarkdownRe = regexp.MustCompile("[*_~`#]+")
+	speechSpacesRe   = regexp.MustCompile(`[ \t]+`)
+)
+
+// SpeechText turns a chat reply into text suited for TTS: emoji and markdown
+// are dropped, "Rp 15.000" is read as "15.000 rupiah", and long replies keep
+// only their first lines.
+func SpeechText(text string) string {
+	text = speechRupiahRe.ReplaceAllString(text, "$1 rupiah")
+	text = speechMarkdownRe.ReplaceAllString(text, "")
+
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Map(func(r rune) rune {
+			if r > unicode.MaxLatin1 && !unicode.IsLetter(r) && !unicode.IsNumber(r) {
+				return -1 // Emoji and symbols
+			}
+			return r
+		}, line)
+		line = strings.Trim(speechSpacesRe.ReplaceAllString(line, " "), " -•:")
+		if line == "" {
+			continue
+		}
+		if !strings.ContainsAny(line[len(line)-1:], ".!?,") {
+			line += "."
+		}
+		lines = append(lines, line)
+	}
+
+	var spoken strings.Builder
+	chars := 0
+	for _, line := range lines {
+		if chars+utf8.RuneCountInString(line) > MaxSpeechChars {
+			if chars == 0 {
+				// A single long line: cut at the last word that fits, on a rune boundary
+				fits := string([]rune(line)[:MaxSpeechChars])
+				if cut := strings.LastIndex(fits, " "); cut > 0 {
+					fits = fits[:cut]
+				}
+				spoken.WriteString(fits + ".")
+			}
+			spoken.WriteString(" Rincian lengkapnya ada di pesan teks.")
+			break
+		}
+		if chars > 0 {
+			spoken.WriteString(" ")
+			chars++
+		}
+		spoken.WriteString(line)
+		chars += utf8.RuneCountInString(line)
+	}
+	return spoken.String()
+}
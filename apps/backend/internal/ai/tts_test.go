@@ -0,0 +1,113 @@
+package ai
+
+import (
+	"context"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// oggPage builds a minimal Ogg page header with the given granule position
+func oggPage(granule uint64) []byte {
+	page := make([]byte, 27)
+	copy(page, "OggS")
+	binary.LittleEndian.PutUint64(page[6:14], granule)
+	return page
+}
+
+func TestOggDurationSeconds(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"empty", nil, 0},
+		{"not ogg", []byte("RIFF....WAVE"), 0},
+		{"header only", oggPage(0), 0},
+		{"3 seconds", append(oggPage(0), oggPage(3*48000)...), 3},
+		{"rounds up", append(oggPage(0), oggPage(48000+1)...), 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OggDurationSeconds(tt.data); got != tt.want {
+				t.Errorf("OggDurationSeconds() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpeechText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "strips emoji and reads rupiah",
+			text: "✅ Penjualan tercatat!\n\n📦 Produk: beras\n💵 Total: Rp 15.000",
+			want: "Penjualan tercatat! Produk: beras. Total: 15.000 rupiah.",
+		},
+		{
+			name: "strips markdown",
+			text: "*Laporan* _hari ini_",
+			want: "Laporan hari ini.",
+		},
+		{
+			name: "only emoji",
+			text: "🙏",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SpeechText(tt.text); got != tt.want {
+				t.Errorf("SpeechText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpeechText_LongReportIsSummarized(t *testing.T) {
+	var report strings.Builder
+	for i := 0; i < 50; i++ {
+		report.WriteString("📦 Beras premium: 25 kg terjual, total Rp 300.000\n")
+	}
+
+	got := SpeechText(report.String())
+	if len(got) > MaxSpeechChars+50 {
+		t.Errorf("Speech text too long: %d chars", len(got))
+	}
+	if !strings.HasSuffix(got, "Rincian lengkapnya ada di pesan teks.") {
+		t.Errorf("Long report should point to the text message, got %q", got)
+	}
+}
+
+func TestSpeechText_LongLineCutOnRunes(t *testing.T) {
+	// One line with no spaces, in a script of multi-byte letters
+	got := SpeechText(strings.Repeat("ꦱꦸꦫ", MaxSpeechChars))
+	spoken := strings.TrimSuffix(got, " Rincian lengkapnya ada di pesan teks.")
+	if !utf8.ValidString(got) {
+		t.Fatalf("Speech text cut inside a rune: %q", got)
+	}
+	if n := utf8.RuneCountInString(spoken); n != MaxSpeechChars+1 {
+		t.Errorf("Spoken part is %d runes, want %d and a full stop", n, MaxSpeechChars)
+	}
+}
+
+func TestStubSynthesizer(t *testing.T) {
+	stub := &StubSynthesizer{Audio: oggPage(2 * 48000)}
+
+	audio, err := stub.Synthesize(context.Background(), "halo", "id")
+	if err != nil {
+		t.Fatalf("Synthesize() error = %v", err)
+	}
+	if audio.Seconds != 2 || audio.MimeType != "audio/ogg; codecs=opus" {
+		t.Errorf("Unexpected audio: %d s, %s", audio.Seconds, audio.MimeType)
+	}
+	if len(stub.Calls) != 1 || stub.Calls[0] != "halo" {
+		t.Errorf("Calls = %v, want [halo]", stub.Calls)
+	}
+}
@@ -0,0 +1,518 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Features metered separately on the LLM bill
+const (
+	FeatureIntent         = "intent"
+	FeatureSTT            = "stt"
+	FeaturePromo          = "promo"
+	FeatureCategorization = "categorization"
+	FeatureSocial         = "social"
+	FeatureAskData        = "ask_data"
+	FeatureCatalog        = "catalog"
+	FeatureOther          = "other"
+)
+
+// Usage outcomes besides success
+const (
+	usageFailed  = "failed"
+	usageBlocked = "quota"
+)
+
+// usageRetentionDays keeps this and last month in memory for quotas and reports
+const usageRetentionDays = 62
+
+// ErrQuotaExceeded is returned instead of calling a provider once a tenant
+// used up its monthly plan; callers degrade to rules, caches or templates
+var ErrQuotaExceeded = errors.New("monthly LLM quota exceeded")
+
+// ModelPrice is the list price in USD per million tokens
+type ModelPrice struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// PlanQuota limits a tenant per calendar month, zero means unlimited
+type PlanQuota struct {
+	MonthlyTokens  int64   `json:"monthly_tokens"`
+	MonthlyCostUSD float64 `json:"monthly_cost_usd"`
+}
+
+// UsagePolicy prices models and assigns tenants to plans. Tenants without
+// a plan, and calls without a tenant, are never limited.
+type UsagePolicy struct {
+	Prices      map[string]ModelPrice `json:"prices"` // By model name
+	Plans       map[string]PlanQuota  `json:"plans"`
+	DefaultPlan string                `json:"default_plan,omitempty"`
+	Tenants     map[string]string     `json:"tenants,omitempty"` // Tenant -> plan
+}
+
+// DefaultUsagePolicy prices the default models and sets no quotas
+func DefaultUsagePolicy() *UsagePolicy {
+	return &UsagePolicy{Prices: map[string]ModelPrice{
+		"gemini-2.0-flash": {Input: 0.10, Output: 0.40},
+		"gpt-4o-mini":      {Input: 0.15, Output: 0.60},
+		"kolosal-1-full":   {Input: 0.10, Output: 0.40},
+	}}
+}
+
+// ParseUsagePolicy reads a policy from JSON; prices missing from it keep their defaults
+func ParseUsagePolicy(data []byte) (*UsagePolicy, error) {
+	policy := DefaultUsagePolicy()
+	if err := json.Unmarshal(data, policy); err != nil {
+		return nil, fmt.Errorf("invalid usage policy: %w", err)
+	}
+	if _, ok := policy.Plans[policy.DefaultPlan]; policy.DefaultPlan != "" && !ok {
+		return nil, fmt.Errorf("unknown default plan %q", policy.DefaultPlan)
+	}
+	for tenant, plan := range policy.Tenants {
+		if _, ok := policy.Plans[plan]; !ok {
+			return nil, fmt.Errorf("tenant %s has unknown plan %q", tenant, plan)
+		}
+	}
+	return policy, nil
+}
+
+// LoadUsagePolicy reads a policy file
+func LoadUsagePolicy(path string) (*UsagePolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseUsagePolicy(data)
+}
+
+// plan returns the quota of a tenant
+func (p *UsagePolicy) plan(tenant string) (string, PlanQuota, bool) {
+	if tenant == "" {
+		return "", PlanQuota{}, false
+	}
+	name, ok := p.Tenants[tenant]
+	if !ok {
+		name = p.DefaultPlan
+	}
+	quota, ok := p.Plans[name]
+	return name, quota, ok
+}
+
+// Cost prices a call, unknown models cost nothing
+func (p *UsagePolicy) Cost(model string, usage TokenUsage) float64 {
+	price := p.Prices[model]
+	return (float64(usage.InputTokens)*price.Input + float64(usage.OutputTokens)*price.Output) / 1e6
+}
+
+// UsageRecord is one metered LLM call
+type UsageRecord struct {
+	Time         time.Time `json:"time"`
+	Tenant       string    `json:"tenant,omitempty"`
+	Feature      string    `json:"feature"`
+	Provider     string    `json:"provider,omitempty"`
+	Model        string    `json:"model,omitempty"`
+	InputTokens  int       `json:"input_tokens"`
+	OutputTokens int       `json:"output_tokens"`
+	Estimated    bool      `json:"estimated,omitempty"` // Provider reported no usage
+	LatencyMs    int64     `json:"latency_ms"`
+	CostUSD      float64   `json:"cost_usd"`
+	Outcome      string    `json:"outcome,omitempty"` // "", failed or quota
+}
+
+// UsageTotals aggregates calls under one key, e.g. a tenant or a feature
+type UsageTotals struct {
+	Key          string  `json:"key"`
+	Calls        int     `json:"calls"`
+	Failed       int     `json:"failed"`
+	Blocked      int     `json:"blocked"` // Refused by quota
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+	AvgLatencyMs int64   `json:"avg_latency_ms"`
+
+	latencyMs int64
+}
+
+// Tokens is input plus output tokens
+func (t *UsageTotals) Tokens() int64 {
+	return t.InputTokens + t.OutputTokens
+}
+
+func (t *UsageTotals) add(o *UsageTotals) {
+	t.Calls += o.Calls
+	t.Failed += o.Failed
+	t.Blocked += o.Blocked
+	t.InputTokens += o.InputTokens
+	t.OutputTokens += o.OutputTokens
+	t.CostUSD += o.CostUSD
+	t.latencyMs += o.latencyMs
+	if answered := t.Calls - t.Blocked; answered > 0 {
+		t.AvgLatencyMs = t.latencyMs / int64(answered)
+	}
+}
+
+// usageKey is the granularity kept in memory
+type usageKey struct {
+	Day      string
+	Tenant   string
+	Feature  string
+	Provider string
+	Model    string
+}
+
+// tenantMonth keys the running monthly totals quotas are checked against
+type tenantMonth struct {
+	Tenant string
+	Month  string // "2006-01"
+}
+
+// UsageSink persists records, e.g. to the llm_usage table
+type UsageSink func(ctx context.Context, rec UsageRecord) error
+
+// UsageMeter aggregates LLM calls per day, tenant, feature and model and
+// enforces the monthly plan quotas
+type UsageMeter struct {
+	policy  *UsagePolicy
+	buckets map[usageKey]*UsageTotals
+	monthly map[tenantMonth]*UsageTotals // Per tenant, so quota checks don't scan the buckets
+	sink    UsageSink
+	loc     *time.Location
+	now     func() time.Time
+	mu      sync.RWMutex
+}
+
+// NewUsageMeter creates a meter; nil policy uses the defaults
+func NewUsageMeter(policy *UsagePolicy) *UsageMeter {
+	if policy == nil {
+		policy = DefaultUsagePolicy()
+	}
+	return &UsageMeter{
+		policy:  policy,
+		buckets: make(map[usageKey]*UsageTotals),
+		monthly: make(map[tenantMonth]*UsageTotals),
+		loc:     UserLocation(DefaultTimezone),
+		now:     time.Now,
+	}
+}
+
+// SetPolicy replaces prices and quotas
+func (m *UsageMeter) SetPolicy(policy *UsagePolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.policy = policy
+}
+
+// SetSink persists every record from now on
+func (m *UsageMeter) SetSink(sink UsageSink) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sink = sink
+}
+
+// Record prices and aggregates a call, then hands it to the sink in the background
+func (m *UsageMeter) Record(rec UsageRecord) {
+	m.mu.Lock()
+	if rec.Time.IsZero() {
+		rec.Time = m.now()
+	}
+	if rec.CostUSD == 0 {
+		rec.CostUSD = m.policy.Cost(rec.Model, TokenUsage{InputTokens: rec.InputTokens, OutputTokens: rec.OutputTokens})
+	}
+	m.add(rec)
+	sink := m.sink
+	m.mu.Unlock()
+
+	if sink != nil {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := sink(ctx, rec); err != nil {
+				log.Printf("⚠️ LLM usage not persisted: %v", err)
+			}
+		}()
+	}
+}
+
+// Restore loads persisted records after a restart so quotas and reports survive it
+func (m *UsageMeter) Restore(records []UsageRecord) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, rec := range records {
+		m.add(rec)
+	}
+}
+
+func (m *UsageMeter) add(rec UsageRecord) {
+	day := rec.Time.In(m.loc).Format("2006-01-02")
+	key := usageKey{Day: day, Tenant: rec.Tenant, Feature: rec.Feature, Provider: rec.Provider, Model: rec.Model}
+	bucket := m.buckets[key]
+	if bucket == nil {
+		bucket = &UsageTotals{}
+		m.buckets[key] = bucket
+		m.prune()
+	}
+
+	call := &UsageTotals{Calls: 1, CostUSD: rec.CostUSD}
+	switch rec.Outcome {
+	case usageBlocked:
+		call.Blocked = 1
+	case usageFailed:
+		call.Failed = 1
+		call.latencyMs = rec.LatencyMs
+	default:
+		call.InputTokens = int64(rec.InputTokens)
+		call.OutputTokens = int64(rec.OutputTokens)
+		call.latencyMs = rec.LatencyMs
+	}
+	bucket.add(call)
+
+	month := tenantMonth{Tenant: rec.Tenant, Month: day[:len("2006-01")]}
+	if m.monthly[month] == nil {
+		m.monthly[month] = &UsageTotals{Key: rec.Tenant}
+	}
+	m.monthly[month].add(call)
+}
+
+// prune drops days past the retention window
+func (m *UsageMeter) prune() {
+	oldest := m.now().In(m.loc).AddDate(0, 0, -usageRetentionDays).Format("2006-01-02")
+	for key := range m.buckets {
+		if key.Day < oldest {
+			delete(m.buckets, key)
+		}
+	}
+	for key := range m.monthly {
+		if key.Month < oldest[:len("2006-01")] {
+			delete(m.monthly, key)
+		}
+	}
+}
+
+// Summary totals the calls between two days (inclusive, "2006-01-02"),
+// grouped by tenant, feature, provider, model or day
+func (m *UsageMeter) Summary(from, to, groupBy string) ([]UsageTotals, error) {
+	group, ok := usageGroups[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown group %q", groupBy)
+	}
+
+	m.mu.RLock()
+	totals := make(map[string]*UsageTotals)
+	for key, bucket := range m.buckets {
+		if (from != "" && key.Day < from) || (to != "" && key.Day > to) {
+			continue
+		}
+		name := group(key)
+		if totals[name] == nil {
+			totals[name] = &UsageTotals{Key: name}
+		}
+		totals[name].add(bucket)
+	}
+	m.mu.RUnlock()
+
+	result := make([]UsageTotals, 0, len(totals))
+	for _, t := range totals {
+		result = append(result, *t)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].CostUSD != result[j].CostUSD {
+			return result[i].CostUSD > result[j].CostUSD
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result, nil
+}
+
+var usageGroups = map[string]func(usageKey) string{
+	"tenant":   func(k usageKey) string { return k.Tenant },
+	"feature":  func(k usageKey) string { return k.Feature },
+	"provider": func(k usageKey) string { return k.Provider },
+	"model":    func(k usageKey) string { return k.Model },
+	"day":      func(k usageKey) string { return k.Day },
+}
+
+// QuotaStatus is a tenant's usage against its plan this month
+type QuotaStatus struct {
+	Tenant         string      `json:"tenant"`
+	Plan           string      `json:"plan,omitempty"`
+	Month          string      `json:"month"`
+	Usage          UsageTotals `json:"usage"`
+	MonthlyTokens  int64       `json:"monthly_tokens,omitempty"`
+	MonthlyCostUSD float64     `json:"monthly_cost_usd,omitempty"`
+	Exceeded       bool        `json:"exceeded"`
+}
+
+// Quota returns the tenant's usage this month against its plan
+func (m *UsageMeter) Quota(tenant string) *QuotaStatus {
+	now := m.now().In(m.loc)
+	month := now.Format("2006-01")
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	status := &QuotaStatus{Tenant: tenant, Month: month, Usage: UsageTotals{Key: tenant}}
+	if totals := m.monthly[tenantMonth{Tenant: tenant, Month: month}]; totals != nil {
+		status.Usage = *totals
+	}
+
+	name, quota, ok := m.policy.plan(tenant)
+	if !ok {
+		return status
+	}
+	status.Plan = name
+	status.MonthlyTokens = quota.MonthlyTokens
+	status.MonthlyCostUSD = quota.MonthlyCostUSD
+	status.Exceeded = (quota.MonthlyTokens > 0 && status.Usage.Tokens() >= quota.MonthlyTokens) ||
+		(quota.MonthlyCostUSD > 0 && status.Usage.CostUSD >= quota.MonthlyCostUSD)
+	return status
+}
+
+// CheckQuota returns ErrQuotaExceeded when the tenant used up its plan
+func (m *UsageMeter) CheckQuota(tenant string) error {
+	if status := m.Quota(tenant); status.Exceeded {
+		return fmt.Errorf("%w: tenant %s on plan %s", ErrQuotaExceeded, tenant, status.Plan)
+	}
+	return nil
+}
+
+// UsageReport is the cost of one day broken down for the daily report
+type UsageReport struct {
+	Date      string        `json:"date"`
+	Total     UsageTotals   `json:"total"`
+	ByFeature []UsageTotals `json:"by_feature"`
+	ByModel   []UsageTotals `json:"by_model"`
+	ByTenant  []UsageTotals `json:"by_tenant"` // Most expensive first
+}
+
+// DailyReport summarizes one day ("2006-01-02")
+func (m *UsageMeter) DailyReport(day string) *UsageReport {
+	report := &UsageReport{Date: day, Total: UsageTotals{Key: day}}
+	report.ByFeature, _ = m.Summary(day, day, "feature")
+	report.ByModel, _ = m.Summary(day, day, "model")
+	report.ByTenant, _ = m.Summary(day, day, "tenant")
+	for i := range report.ByFeature {
+		report.Total.add(&report.ByFeature[i])
+	}
+	return report
+}
+
+// String formats the report for the log
+func (r *UsageReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📊 LLM usage %s: %d calls, %d tokens, $%.4f, %d failed, %d over quota",
+		r.Date, r.Total.Calls, r.Total.Tokens(), r.Total.CostUSD, r.Total.Failed, r.Total.Blocked)
+	for _, t := range r.ByFeature {
+		fmt.Fprintf(&b, "\n   %-15s %5d calls %9d tokens $%.4f", t.Key, t.Calls, t.Tokens(), t.CostUSD)
+	}
+	for i, t := range r.ByTenant {
+		if i == 5 {
+			break
+		}
+		tenant := t.Key
+		if tenant == "" {
+			tenant = "(system)"
+		}
+		fmt.Fprintf(&b, "\n   top %-11s %5d calls %9d tokens $%.4f", tenant, t.Calls, t.Tokens(), t.CostUSD)
+	}
+	return b.String()
+}
+
+// RunDailyReports logs yesterday's report after every midnight until ctx ends
+func (m *UsageMeter) RunDailyReports(ctx context.Context) {
+	for {
+		now := m.now().In(m.loc)
+		midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, m.loc)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(midnight.Sub(now)):
+			log.Println(m.DailyReport(midnight.AddDate(0, 0, -1).Format("2006-01-02")))
+		}
+	}
+}
+
+// MeteredProvider records every call of the wrapped provider and refuses
+// calls of tenants over quota
+type MeteredProvider struct {
+	next  Provider
+	meter *UsageMeter
+}
+
+// NewMeteredProvider wraps next, usually the Router
+func NewMeteredProvider(next Provider, meter *UsageMeter) *MeteredProvider {
+	return &MeteredProvider{next: next, meter: meter}
+}
+
+// Meter returns the meter calls are recorded in
+func (p *MeteredProvider) Meter() *UsageMeter {
+	return p.meter
+}
+
+func (p *MeteredProvider) Name() string {
+	return p.next.Name()
+}
+
+// Complete checks the tenant's quota, calls the provider and records the call
+func (p *MeteredProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	rec := UsageRecord{Tenant: TenantFromContext(ctx), Feature: FeatureFromContext(ctx)}
+
+	if err := p.meter.CheckQuota(rec.Tenant); err != nil {
+		log.Printf("🚫 %s skipped for %s: %v", rec.Feature, rec.Tenant, err)
+		rec.Outcome = usageBlocked
+		p.meter.Record(rec)
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := p.next.Complete(ctx, req)
+	rec.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		rec.Outcome = usageFailed
+		p.meter.Record(rec)
+		return nil, err
+	}
+
+	rec.Provider = resp.Provider
+	rec.Model = resp.Model
+	rec.InputTokens = resp.Usage.InputTokens
+	rec.OutputTokens = resp.Usage.OutputTokens
+	if rec.InputTokens == 0 && rec.OutputTokens == 0 {
+		rec.InputTokens, rec.OutputTokens = estimateTokens(req, resp.Text)
+		rec.Estimated = true
+	}
+	p.meter.Record(rec)
+	return resp, nil
+}
+
+// estimateTokens approximates usage at four characters per token
+func estimateTokens(req *CompletionRequest, answer string) (int, int) {
+	chars := len(req.System)
+	for _, msg := range req.Messages {
+		chars += len(msg.Content)
+	}
+	return (chars + 3) / 4, (len(answer) + 3) / 4
+}
+
+type featureKey struct{}
+
+// WithFeature tags ctx with the product feature an LLM call serves
+func WithFeature(ctx context.Context, feature string) context.Context {
+	return context.WithValue(ctx, featureKey{}, feature)
+}
+
+// FeatureFromContext returns the feature set by WithFeature, or FeatureOther
+func FeatureFromContext(ctx context.Context) string {
+	if feature, ok := ctx.Value(featureKey{}).(string); ok && feature != "" {
+		return feature
+	}
+	return FeatureOther
+}
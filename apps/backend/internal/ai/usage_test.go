@@ -0,0 +1,161 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// usageProvider answers with fixed token usage
+type usageProvider struct {
+	usage TokenUsage
+	calls int
+}
+
+func (p *usageProvider) Name() string { return "fake" }
+
+func (p *usageProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	p.calls++
+	return &CompletionResponse{Text: `{"action":"UNKNOWN"}`, Provider: "fake", Model: "gemini-2.0-flash", Usage: p.usage}, nil
+}
+
+func testUsageMeter(t *testing.T, policy string) *UsageMeter {
+	t.Helper()
+	p, err := ParseUsagePolicy([]byte(policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewUsageMeter(p)
+	now := time.Date(2026, time.October, 14, 10, 0, 0, 0, m.loc)
+	m.now = func() time.Time { return now }
+	return m
+}
+
+func TestMeteredProvider_Records(t *testing.T) {
+	m := testUsageMeter(t, `{}`)
+	llm := NewMeteredProvider(&usageProvider{usage: TokenUsage{InputTokens: 1000, OutputTokens: 500}}, m)
+
+	ctx := WithFeature(WithTenant(context.Background(), "628111"), FeatureIntent)
+	if _, err := llm.Complete(ctx, &CompletionRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CompleteText(WithFeature(context.Background(), FeaturePromo), llm, "", "halo"); err != nil {
+		t.Fatal(err)
+	}
+
+	byFeature, err := m.Summary("2026-10-14", "2026-10-14", "feature")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byFeature) != 2 || byFeature[0].InputTokens != 1000 || byFeature[0].OutputTokens != 500 {
+		t.Fatalf("Summary(feature) = %+v", byFeature)
+	}
+	// 1000 × $0.10 + 500 × $0.40 per million
+	if cost := byFeature[0].CostUSD; cost < 0.00029 || cost > 0.00031 {
+		t.Errorf("Cost = %v, want 0.0003", cost)
+	}
+
+	byTenant, _ := m.Summary("", "", "tenant")
+	if len(byTenant) != 2 {
+		t.Errorf("Summary(tenant) = %+v", byTenant)
+	}
+	if _, err := m.Summary("", "", "color"); err == nil {
+		t.Error("Unknown group should fail")
+	}
+	if report := m.DailyReport("2026-10-14"); report.Total.Calls != 2 || !strings.Contains(report.String(), "intent") {
+		t.Errorf("DailyReport = %s", report)
+	}
+}
+
+func TestMeteredProvider_EstimatesMissingUsage(t *testing.T) {
+	m := testUsageMeter(t, `{}`)
+	llm := NewMeteredProvider(&usageProvider{}, m)
+
+	if _, err := CompleteText(context.Background(), llm, "", strings.Repeat("a", 400)); err != nil {
+		t.Fatal(err)
+	}
+	totals, _ := m.Summary("", "", "feature")
+	if len(totals) != 1 || totals[0].Key != FeatureOther || totals[0].InputTokens != 100 {
+		t.Errorf("Estimated usage = %+v", totals)
+	}
+}
+
+func TestMeteredProvider_Quota(t *testing.T) {
+	m := testUsageMeter(t, `{"plans":{"free":{"monthly_tokens":2000},"pro":{}},"default_plan":"free","tenants":{"628999":"pro"}}`)
+	inner := &usageProvider{usage: TokenUsage{InputTokens: 1500, OutputTokens: 500}}
+	llm := NewMeteredProvider(inner, m)
+
+	free := WithTenant(context.Background(), "628111")
+	if _, err := llm.Complete(free, &CompletionRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := llm.Complete(free, &CompletionRequest{}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("Second call = %v, want quota exceeded", err)
+	}
+	if inner.calls != 1 {
+		t.Errorf("Provider called %d times, want 1", inner.calls)
+	}
+
+	// Intent extraction degrades to the rule parser
+	engine := NewIntentEngineWithProvider(llm)
+	intent, err := engine.ProcessText(free, "stok beras berapa")
+	if err != nil || intent.Action != "CHECK_STOCK" {
+		t.Errorf("Degraded intent = %+v, %v", intent, err)
+	}
+
+	status := m.Quota("628111")
+	if !status.Exceeded || status.Plan != "free" || status.Usage.Blocked == 0 {
+		t.Errorf("Quota = %+v", status)
+	}
+
+	// Unlimited plans and system calls are never refused
+	for _, ctx := range []context.Context{WithTenant(context.Background(), "628999"), context.Background()} {
+		for i := 0; i < 3; i++ {
+			if _, err := llm.Complete(ctx, &CompletionRequest{}); err != nil {
+				t.Errorf("Unlimited call %d = %v", i, err)
+			}
+		}
+	}
+}
+
+func TestUsageMeter_RestoreKeepsMonthsApart(t *testing.T) {
+	m := testUsageMeter(t, `{"plans":{"free":{"monthly_tokens":2000}},"default_plan":"free"}`)
+	at := func(month time.Month, day int) time.Time { return time.Date(2026, month, day, 9, 0, 0, 0, m.loc) }
+	m.Restore([]UsageRecord{
+		{Time: at(time.September, 30), Tenant: "628111", Feature: FeatureIntent, InputTokens: 5000},
+		{Time: at(time.October, 1), Tenant: "628111", Feature: FeatureIntent, InputTokens: 800, OutputTokens: 200},
+		{Time: at(time.October, 2), Tenant: "628222", Feature: FeatureIntent, InputTokens: 3000},
+	})
+
+	status := m.Quota("628111")
+	if status.Exceeded || status.Usage.Tokens() != 1000 || status.Usage.Calls != 1 {
+		t.Errorf("Quota = %+v, want this month's 1000 tokens only", status)
+	}
+	if !m.Quota("628222").Exceeded {
+		t.Error("Other tenant should be over quota")
+	}
+	if lastMonth, _ := m.Summary("2026-09-01", "2026-09-30", "tenant"); len(lastMonth) != 1 || lastMonth[0].InputTokens != 5000 {
+		t.Errorf("Last month = %+v", lastMonth)
+	}
+}
+
+func TestParseUsagePolicy(t *testing.T) {
+	p, err := ParseUsagePolicy([]byte(`{"prices":{"my-model":{"input":1,"output":2}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.Prices["gemini-2.0-flash"]; !ok {
+		t.Error("Default prices should be kept")
+	}
+	if cost := p.Cost("my-model", TokenUsage{InputTokens: 1e6, OutputTokens: 1e6}); cost != 3 {
+		t.Errorf("Cost = %v, want 3", cost)
+	}
+
+	for _, bad := range []string{`{"default_plan":"gold"}`, `{"plans":{"free":{}},"tenants":{"628":"gold"}}`, `nope`} {
+		if _, err := ParseUsagePolicy([]byte(bad)); err == nil {
+			t.Errorf("ParseUsagePolicy(%s) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pasarsuara/backend/internal/audio"
+)
+
+// whisperUncertain is the word or segment probability below which the
+// transcript is flagged as possibly misheard
+const whisperUncertain = 0.5
+
+// WhisperClient calls a self-hosted server exposing the OpenAI-compatible
+// /audio/transcriptions endpoint (faster-whisper-server, LocalAI, whisper.cpp)
+type WhisperClient struct {
+	baseURL    string
+	apiKey     string // Optional, most local servers have none
+	model      string
+	httpClient *http.Client
+}
+
+type whisperResponse struct {
+	Text     string `json:"text"`
+	Language string `json:"language"`
+	Segments []struct {
+		Start      float64 `json:"start"`
+		End        float64 `json:"end"`
+		Text       string  `json:"text"`
+		AvgLogprob float64 `json:"avg_logprob"`
+		Words      []struct {
+			Word        string   `json:"word"`
+			Probability *float64 `json:"probability"`
+		} `json:"words"`
+	} `json:"segments"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+func NewWhisperClient(baseURL, apiKey, model string) *WhisperClient {
+	if model == "" {
+		model = "whisper-1"
+	}
+	return &WhisperClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		httpClient: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}
+}
+
+func (w *WhisperClient) Name() string {
+	return "whisper"
+}
+
+// whisperLanguage maps our language codes to whisper's; Javanese is "jw"
+func whisperLanguage(language string) string {
+	switch language {
+	case "jv":
+		return "jw"
+	case "id", "su":
+		return language
+	default:
+		return "" // Let whisper detect it
+	}
+}
+
+// whisperFilename names the upload; servers pick the decoder by extension
+func whisperFilename(mimeType string) string {
+	switch audio.NormalizeMimeType(mimeType) {
+	case "audio/wav":
+		return "audio.wav"
+	case "audio/mpeg":
+		return "audio.mp3"
+	case "audio/webm":
+		return "audio.webm"
+	case "audio/mp4":
+		return "audio.m4a"
+	default:
+		return "audio.ogg"
+	}
+}
+
+// Transcribe uploads the audio and reads the verbose transcript with per
+// segment log probabilities
+func (w *WhisperClient) Transcribe(ctx context.Context, audioData []byte, mimeType, language string) (*Transcription, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, err := form.CreateFormFile("file", whisperFilename(mimeType))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create form: %w", err)
+	}
+	file.Write(audioData)
+	form.WriteField("model", w.model)
+	form.WriteField("response_format", "verbose_json")
+	form.WriteField("timestamp_granularities[]", "segment")
+	form.WriteField("timestamp_granularities[]", "word")
+	form.WriteField("temperature", "0")
+	if lang := whisperLanguage(language); lang != "" {
+		form.WriteField("language", lang)
+	}
+	if err := form.Close(); err != nil {
+		return nil, fmt.Errorf("failed to create form: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/audio/transcriptions", w.baseURL)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, &body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", form.FormDataContentType())
+	if w.apiKey != "" {
+		httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", w.apiKey))
+	}
+
+	resp, err := w.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, &ProviderError{Provider: "whisper", Retryable: true, Err: err}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &ProviderError{Provider: "whisper", Retryable: true, Err: fmt.Errorf("failed to read response: %w", err)}
+	}
+
+	var whisperResp whisperResponse
+	if err := json.Unmarshal(respBody, &whisperResp); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, newStatusError("whisper", resp.StatusCode, http.StatusText(resp.StatusCode))
+		}
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if whisperResp.Error != nil {
+		return nil, newStatusError("whisper", resp.StatusCode, whisperResp.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError("whisper", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	return whisperTranscription(&whisperResp)
+}
+
+// whisperTranscription scores the transcript: confidence is the duration
+// weighted segment probability, doubtful words come from word probabilities
+// or, when the server has none, from doubtful segments
+func whisperTranscription(r *whisperResponse) (*Transcription, error) {
+	t := &Transcription{Text: strings.TrimSpace(r.Text)}
+	if t.Text == "" {
+		return nil, fmt.Errorf("no transcription returned")
+	}
+
+	var weighted, weight float64
+	for _, seg := range r.Segments {
+		p := math.Exp(seg.AvgLogprob)
+		if seconds := seg.End - seg.Start; seconds > 0 {
+			weighted += p * seconds
+			weight += seconds
+		}
+
+		scored := false
+		for _, word := range seg.Words {
+			if word.Probability == nil {
+				continue
+			}
+			scored = true
+			if *word.Probability < whisperUncertain {
+				t.UncertainWords = append(t.UncertainWords, strings.TrimSpace(word.Word))
+			}
+		}
+		if !scored && p < whisperUncertain {
+			t.UncertainWords = append(t.UncertainWords, strings.Fields(seg.Text)...)
+		}
+	}
+	if weight > 0 {
+		t.Confidence = weighted / weight
+	}
+	return t, nil
+}
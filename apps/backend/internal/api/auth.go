@@ -181,6 +181,9 @@ func ValidateToken(tokenString string) (*Claims, error) {
 	}
 
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		if claims.UserID == "" {
+			claims.UserID = claims.Subject // Supabase session tokens of the web dashboard
+		}
 		return claims, nil
 	}
 
@@ -69,6 +69,23 @@ func TestTokenValidity(t *testing.T) {
 	}
 }
 
+// Supabase session tokens carry the user in sub
+func TestSupabaseSessionToken(t *testing.T) {
+	session := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   "supabase-user-id",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	})
+	token, _ := session.SignedString([]byte("your-secret-key-change-in-production"))
+
+	claims, err := ValidateToken(token)
+	if err != nil {
+		t.Fatalf("Session token was rejected: %v", err)
+	}
+	if claims.UserID != "supabase-user-id" {
+		t.Errorf("Expected UserID supabase-user-id, got %s", claims.UserID)
+	}
+}
+
 // Test token expiration time
 func TestTokenExpirationTime(t *testing.T) {
 	handler := &AuthHandler{}
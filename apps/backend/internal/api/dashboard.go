@@ -20,7 +20,8 @@ type DashboardMetrics struct {
 	TodaySales       float64 `json:"today_sales"`
 	TodayPurchases   float64 `json:"today_purchases"`
 	TodayExpenses    float64 `json:"today_expenses"`
-	GrossProfit      float64 `json:"gross_profit"`
+	TodayCOGS        float64 `json:"today_cogs"`
+	GrossProfit      float64 `json:"gross_profit"` // Sales minus cost of goods sold
 	TransactionCount int     `json:"transaction_count"`
 	SalesChange      float64 `json:"sales_change"`
 	ProfitChange     float64 `json:"profit_change"`
@@ -144,13 +145,14 @@ func (d *DashboardHandler) calculateMetrics(ctx context.Context, userID, date st
 	}
 
 	// Calculate totals
-	var todaySales, todayPurchases, todayExpenses float64
+	var todaySales, todayPurchases, todayExpenses, todayCOGS float64
 	transactionCount := len(todayTxns)
 
 	for _, tx := range todayTxns {
 		switch tx.Type {
 		case "SALE":
 			todaySales += tx.TotalAmount
+			todayCOGS += tx.COGS
 		case "PURCHASE":
 			todayPurchases += tx.TotalAmount
 		case "EXPENSE":
@@ -158,7 +160,8 @@ func (d *DashboardHandler) calculateMetrics(ctx context.Context, userID, date st
 		}
 	}
 
-	grossProfit := todaySales - todayPurchases - todayExpenses
+	// Stock is expensed as it sells (FIFO COGS), not on the day it is bought
+	grossProfit := todaySales - todayCOGS
 
 	// Calculate yesterday's metrics for comparison
 	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
@@ -169,6 +172,7 @@ func (d *DashboardHandler) calculateMetrics(ctx context.Context, userID, date st
 			TodaySales:       todaySales,
 			TodayPurchases:   todayPurchases,
 			TodayExpenses:    todayExpenses,
+			TodayCOGS:        todayCOGS,
 			GrossProfit:      grossProfit,
 			TransactionCount: transactionCount,
 			SalesChange:      0,
@@ -181,9 +185,9 @@ func (d *DashboardHandler) calculateMetrics(ctx context.Context, userID, date st
 	for _, tx := range yesterdayTxns {
 		if tx.Type == "SALE" {
 			yesterdaySales += tx.TotalAmount
+			yesterdayProfit += tx.TotalAmount - tx.COGS
 		}
 	}
-	yesterdayProfit = yesterdaySales // Simplified
 
 	// Calculate percentage changes
 	salesChange := calculatePercentageChange(yesterdaySales, todaySales)
@@ -193,6 +197,7 @@ func (d *DashboardHandler) calculateMetrics(ctx context.Context, userID, date st
 		TodaySales:       todaySales,
 		TodayPurchases:   todayPurchases,
 		TodayExpenses:    todayExpenses,
+		TodayCOGS:        todayCOGS,
 		GrossProfit:      grossProfit,
 		TransactionCount: transactionCount,
 		SalesChange:      salesChange,
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pasarsuara/backend/internal/agents"
+)
+
+// RecurringAPI lets signed-in users list, create, edit, pause and delete
+// their recurring transactions
+type RecurringAPI struct {
+	recurring *agents.RecurringAgent
+}
+
+func NewRecurringAPI(recurring *agents.RecurringAgent) *RecurringAPI {
+	return &RecurringAPI{recurring: recurring}
+}
+
+// HandleList returns the user's rules
+func (api *RecurringAPI) HandleList(w http.ResponseWriter, r *http.Request) {
+	claims, _ := GetUserFromContext(r)
+	rules, err := api.recurring.List(r.Context(), claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"rules": rules})
+}
+
+// HandleCreate adds a rule from type, product, qty, price, auto_post and
+// schedule (cron) or frequency (DAILY, WEEKLY, MONTHLY) with hour
+func (api *RecurringAPI) HandleCreate(w http.ResponseWriter, r *http.Request) {
+	claims, _ := GetUserFromContext(r)
+	var req map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	rule, err := api.recurring.Create(r.Context(), claims.UserID, req, time.Now())
+	if err != nil {
+		writeRecurringError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rule)
+}
+
+// HandleUpdate edits a rule with the fields of HandleCreate; status ACTIVE
+// or PAUSED resumes or pauses it
+func (api *RecurringAPI) HandleUpdate(w http.ResponseWriter, r *http.Request) {
+	claims, _ := GetUserFromContext(r)
+	var req map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	rule, err := api.recurring.Update(r.Context(), claims.UserID, chi.URLParam(r, "id"), req, time.Now())
+	if err != nil {
+		writeRecurringError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+// HandleDelete removes a rule
+func (api *RecurringAPI) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	claims, _ := GetUserFromContext(r)
+	if err := api.recurring.Delete(r.Context(), claims.UserID, chi.URLParam(r, "id")); err != nil {
+		writeRecurringError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeRecurringError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, agents.ErrRecurringNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, agents.ErrInvalidRecurring):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
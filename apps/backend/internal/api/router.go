@@ -10,7 +10,7 @@ import (
 	"github.com/pasarsuara/backend/internal/database"
 )
 
-func NewRouter(orchestrator *agents.AgentOrchestrator, catalogHandler *CatalogHandler, db *database.SupabaseClient, integrationsHandler interface{}) http.Handler {
+func NewRouter(orchestrator *agents.AgentOrchestrator, catalogHandler *CatalogHandler, db *database.SupabaseClient, integrationsHandler interface{}, usageAPI *UsageAPI, media http.Handler) http.Handler {
 	r := chi.NewRouter()
 
 	// Middleware
@@ -31,6 +31,11 @@ func NewRouter(orchestrator *agents.AgentOrchestrator, catalogHandler *CatalogHa
 		w.Write([]byte("OK"))
 	})
 
+	// Stored product photos
+	if media != nil {
+		r.Handle("/media/*", http.StripPrefix("/media", media))
+	}
+
 	// Internal webhooks (from WA Gateway)
 	webhook := NewWhatsAppWebhook(orchestrator)
 	r.Post("/internal/webhook/whatsapp", webhook.Handle)
@@ -93,6 +98,34 @@ func NewRouter(orchestrator *agents.AgentOrchestrator, catalogHandler *CatalogHa
 			r.Post("/integrations/social-content/bulk", ih.HandleGenerateBulkSocialContent)
 		}
 
+		// LLM usage and cost (admin only)
+		if usageAPI != nil {
+			r.Route("/admin/usage", func(r chi.Router) {
+				r.Use(AuthMiddleware)
+				r.Use(RequireRole("admin"))
+				r.Get("/", usageAPI.HandleGetUsage)
+				r.Get("/report", usageAPI.HandleGetDailyReport)
+				r.Get("/quota", usageAPI.HandleGetQuota)
+			})
+		}
+
+		// Recurring transactions of the signed-in user
+		recurringAPI := NewRecurringAPI(orchestrator.GetRecurringAgent())
+		r.Route("/recurring", func(r chi.Router) {
+			r.Use(AuthMiddleware)
+			r.Get("/", recurringAPI.HandleList)
+			r.Post("/", recurringAPI.HandleCreate)
+			r.Put("/{id}", recurringAPI.HandleUpdate)
+			r.Delete("/{id}", recurringAPI.HandleDelete)
+		})
+
+		// Cancelling transactions of the signed-in user
+		transactionsAPI := NewTransactionsAPI(orchestrator)
+		r.Route("/transactions", func(r chi.Router) {
+			r.Use(AuthMiddleware)
+			r.Delete("/{id}", transactionsAPI.HandleCancel)
+		})
+
 		// Intent/Agent test endpoint (for debugging)
 		r.Post("/intent/test", webhook.Handle)
 	})
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pasarsuara/backend/internal/agents"
+)
+
+// TransactionsAPI lets signed-in users cancel their transactions from the
+// dashboard with the same stock, costing, journal and payment effects as in
+// chat
+type TransactionsAPI struct {
+	orchestrator *agents.AgentOrchestrator
+}
+
+func NewTransactionsAPI(orchestrator *agents.AgentOrchestrator) *TransactionsAPI {
+	return &TransactionsAPI{orchestrator: orchestrator}
+}
+
+// HandleCancel voids a transaction; the row is kept and reports skip it
+func (api *TransactionsAPI) HandleCancel(w http.ResponseWriter, r *http.Request) {
+	claims, _ := GetUserFromContext(r)
+	tx, err := api.orchestrator.CancelTransactionByID(r.Context(), claims.UserID, chi.URLParam(r, "id"))
+	if errors.Is(err, agents.ErrTransactionNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tx)
+}
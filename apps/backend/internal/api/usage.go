@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pasarsuara/backend/internal/ai"
+	"github.com/pasarsuara/backend/internal/database"
+)
+
+// UsageAPI exposes LLM usage and cost to admins
+type UsageAPI struct {
+	meter *ai.UsageMeter
+}
+
+func NewUsageAPI(meter *ai.UsageMeter) *UsageAPI {
+	return &UsageAPI{meter: meter}
+}
+
+// HandleGetUsage totals usage between from and to (YYYY-MM-DD, default this
+// month) grouped by tenant, feature, provider, model or day
+func (api *UsageAPI) HandleGetUsage(w http.ResponseWriter, r *http.Request) {
+	now := time.Now().In(ai.UserLocation(ai.DefaultTimezone))
+	from := r.URL.Query().Get("from")
+	if from == "" {
+		from = now.Format("2006-01") + "-01"
+	}
+	to := r.URL.Query().Get("to")
+	if to == "" {
+		to = now.Format("2006-01-02")
+	}
+	groupBy := r.URL.Query().Get("group_by")
+	if groupBy == "" {
+		groupBy = "feature"
+	}
+
+	totals, err := api.meter.Summary(from, to, groupBy)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"from":     from,
+		"to":       to,
+		"group_by": groupBy,
+		"usage":    totals,
+	})
+}
+
+// HandleGetDailyReport returns the cost report of one day (default yesterday)
+func (api *UsageAPI) HandleGetDailyReport(w http.ResponseWriter, r *http.Request) {
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		date = time.Now().In(ai.UserLocation(ai.DefaultTimezone)).AddDate(0, 0, -1).Format("2006-01-02")
+	}
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		http.Error(w, "Invalid date, use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.meter.DailyReport(date))
+}
+
+// HandleGetQuota returns a tenant's usage this month against its plan
+func (api *UsageAPI) HandleGetQuota(w http.ResponseWriter, r *http.Request) {
+	tenant := r.URL.Query().Get("tenant")
+	if tenant == "" {
+		http.Error(w, "tenant is required", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.meter.Quota(tenant))
+}
+
+// UsageSink persists metered calls to the llm_usage table
+func UsageSink(db *database.SupabaseClient) ai.UsageSink {
+	return func(ctx context.Context, rec ai.UsageRecord) error {
+		return db.CreateLLMUsage(ctx, &database.LLMUsage{
+			Tenant:       rec.Tenant,
+			Feature:      rec.Feature,
+			Provider:     rec.Provider,
+			Model:        rec.Model,
+			InputTokens:  rec.InputTokens,
+			OutputTokens: rec.OutputTokens,
+			Estimated:    rec.Estimated,
+			LatencyMs:    rec.LatencyMs,
+			CostUSD:      rec.CostUSD,
+			Outcome:      rec.Outcome,
+			CreatedAt:    rec.Time.UTC().Format(time.RFC3339),
+		})
+	}
+}
+
+// RestoreUsage reloads this and last month's calls so quotas and the usage
+// reports survive a restart
+func RestoreUsage(ctx context.Context, db *database.SupabaseClient, meter *ai.UsageMeter) (int, error) {
+	now := time.Now().In(ai.UserLocation(ai.DefaultTimezone))
+	lastMonth := time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, now.Location())
+
+	rows, err := db.GetLLMUsageSince(ctx, lastMonth.UTC().Format(time.RFC3339))
+	if err != nil {
+		return 0, err
+	}
+	records := make([]ai.UsageRecord, 0, len(rows))
+	for _, row := range rows {
+		created, err := time.Parse(time.RFC3339, row.CreatedAt)
+		if err != nil {
+			continue
+		}
+		records = append(records, ai.UsageRecord{
+			Time:         created,
+			Tenant:       row.Tenant,
+			Feature:      row.Feature,
+			Provider:     row.Provider,
+			Model:        row.Model,
+			InputTokens:  row.InputTokens,
+			OutputTokens: row.OutputTokens,
+			Estimated:    row.Estimated,
+			LatencyMs:    row.LatencyMs,
+			CostUSD:      row.CostUSD,
+			Outcome:      row.Outcome,
+		})
+	}
+	meter.Restore(records)
+	return len(records), nil
+}
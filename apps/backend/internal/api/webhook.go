@@ -22,10 +22,11 @@ type MessageRouterInterface interface {
 
 // WebhookPayload matches the payload from WA Gateway
 type WebhookPayload struct {
-	Event   string         `json:"event"`
-	From    string         `json:"from"`
-	Type    string         `json:"type"`
-	Payload MessagePayload `json:"payload"`
+	Event     string         `json:"event"`
+	MessageID string         `json:"message_id,omitempty"` // WhatsApp message id, used as idempotency key
+	From      string         `json:"from"`
+	Type      string         `json:"type"`
+	Payload   MessagePayload `json:"payload"`
 }
 
 type MessagePayload struct {
@@ -35,6 +36,25 @@ type MessagePayload struct {
 	MimeType  string `json:"mime_type,omitempty"`
 	IsVoice   bool   `json:"is_voice,omitempty"`
 	Duration  uint32 `json:"duration,omitempty"`
+
+	// Interactive selections (type "interactive")
+	SelectedID      string `json:"selected_id,omitempty"`
+	InteractiveType string `json:"interactive_type,omitempty"` // button, list
+
+	// Message being replied or reacted to (reaction emoji is in Text)
+	QuotedMessageID string `json:"quoted_message_id,omitempty"`
+
+	// Shared location (type "location"), place name is in Text
+	Latitude  float64 `json:"latitude,omitempty"`
+	Longitude float64 `json:"longitude,omitempty"`
+	Address   string  `json:"address,omitempty"`
+
+	// Forwarded contact cards (type "contact")
+	Contacts []agents.ContactCard `json:"contacts,omitempty"`
+
+	// Outbound message report (type "sent")
+	PendingActionID string `json:"pending_action_id,omitempty"`
+	SentMessageID   string `json:"sent_message_id,omitempty"`
 }
 
 type WebhookResponse struct {
@@ -42,6 +62,10 @@ type WebhookResponse struct {
 	Message     string                `json:"message"`
 	Reply       string                `json:"reply,omitempty"`
 	AgentResult *agents.AgentResponse `json:"agent_result,omitempty"`
+	Replies     []agents.Reply        `json:"replies,omitempty"` // Structured replies, Reply is kept as text fallback
+
+	// PendingActionID asks the gateway to report the id of the sent reply
+	PendingActionID string `json:"pending_action_id,omitempty"`
 }
 
 func NewWhatsAppWebhook(orchestrator *agents.AgentOrchestrator) *WhatsAppWebhook {
@@ -69,13 +93,22 @@ func (w *WhatsAppWebhook) Handle(rw http.ResponseWriter, r *http.Request) {
 	var response WebhookResponse
 	response.Success = true
 
-	ctx := r.Context()
+	// Message id makes transaction writes idempotent across redeliveries
+	ctx := agents.WithIdempotencyKey(r.Context(), payload.MessageID)
 
 	switch payload.Type {
 	case "text":
 		text := payload.Payload.Text
 		log.Printf("💬 Processing text: %s", text)
 
+		// "ya"/"batal" answers to a pending confirmation
+		if agentResult, handled := w.orchestrator.ProcessConfirmationReply(ctx, payload.From, payload.Payload.QuotedMessageID, text); handled {
+			response.AgentResult = agentResult
+			response.Reply = agentResult.Message
+			response.Message = "Confirmation processed"
+			break
+		}
+
 		// Try message router first (for registration, ambiguity, categorization)
 		if w.messageRouter != nil {
 			routerResponse, err := w.messageRouter.RouteMessage(payload.From, text)
@@ -95,6 +128,49 @@ func (w *WhatsAppWebhook) Handle(rw http.ResponseWriter, r *http.Request) {
 		response.Reply = agentResult.Message
 		response.Message = "Processed by Agent Orchestrator"
 
+	case "interactive":
+		log.Printf("🔘 Interactive %s selected: %s (%s)",
+			payload.Payload.InteractiveType, payload.Payload.SelectedID, payload.Payload.Text)
+
+		agentResult := w.orchestrator.ProcessSelection(ctx, payload.From, payload.Payload.SelectedID, payload.Payload.Text)
+		response.AgentResult = agentResult
+		response.Reply = agentResult.Message
+		response.Message = "Selection processed"
+
+	case "reaction":
+		log.Printf("💟 Reaction %s to %s", payload.Payload.Text, payload.Payload.QuotedMessageID)
+
+		agentResult := w.orchestrator.ProcessReaction(ctx, payload.From, payload.Payload.QuotedMessageID, payload.Payload.Text)
+		if agentResult != nil {
+			response.AgentResult = agentResult
+			response.Reply = agentResult.Message
+			response.Message = "Reaction processed"
+		} else {
+			response.Message = "Reaction ignored"
+		}
+
+	case "location":
+		log.Printf("📍 Location from %s: %.5f,%.5f", payload.From, payload.Payload.Latitude, payload.Payload.Longitude)
+
+		point := agents.GeoPoint{Latitude: payload.Payload.Latitude, Longitude: payload.Payload.Longitude}
+		agentResult := w.orchestrator.ProcessLocation(ctx, payload.From, point, payload.Payload.Text, payload.Payload.Address)
+		response.AgentResult = agentResult
+		response.Reply = agentResult.Message
+		response.Message = "Location processed"
+
+	case "contact":
+		log.Printf("👤 %d contact card(s) from %s", len(payload.Payload.Contacts), payload.From)
+
+		agentResult := w.orchestrator.ProcessContactCards(ctx, payload.From, payload.Payload.Contacts)
+		response.AgentResult = agentResult
+		response.Reply = agentResult.Message
+		response.Message = "Contacts processed"
+
+	case "sent":
+		// Gateway reports the outbound message id of a confirmation prompt
+		w.orchestrator.BindPendingAction(payload.Payload.PendingActionID, payload.Payload.SentMessageID)
+		response.Message = "Sent message recorded"
+
 	case "audio":
 		log.Printf("🎤 Audio message: %d seconds, voice: %v",
 			payload.Payload.Duration, payload.Payload.IsVoice)
@@ -109,7 +185,7 @@ func (w *WhatsAppWebhook) Handle(rw http.ResponseWriter, r *http.Request) {
 			log.Printf("🎙️ Processing audio: %d bytes, %s", len(payload.Payload.AudioData), mimeType)
 
 			// Process through Agent Orchestrator (will use Gemini STT + Kolosal)
-			agentResult := w.orchestrator.ProcessAudio(ctx, payload.From, payload.Payload.AudioData, mimeType)
+			agentResult := w.orchestrator.ProcessAudio(ctx, payload.From, payload.Payload.AudioData, mimeType, payload.Payload.QuotedMessageID)
 			response.AgentResult = agentResult
 			response.Reply = agentResult.Message
 			response.Message = "Audio processed successfully"
@@ -125,19 +201,17 @@ func (w *WhatsAppWebhook) Handle(rw http.ResponseWriter, r *http.Request) {
 		imageData := payload.Payload.AudioData // Reused field
 
 		if len(imageData) > 0 {
+			mimeType := payload.Payload.MimeType
+			if mimeType == "" {
+				mimeType = "image/jpeg" // WhatsApp recompresses photos to JPEG
+			}
 			log.Printf("🖼️ Processing image: %d bytes, caption: %s", len(imageData), caption)
 
-			// For now, just acknowledge receipt
-			// TODO: Image recognition, product catalog, etc.
-			response.Message = "Image received"
-			if caption != "" {
-				// Process caption as text
-				agentResult := w.orchestrator.ProcessMessage(ctx, payload.From, caption)
-				response.AgentResult = agentResult
-				response.Reply = "📷 Gambar diterima!\n\n" + agentResult.Message
-			} else {
-				response.Reply = "📷 Gambar diterima! Kirim caption untuk deskripsi produk ya.\n\nContoh: \"Nasi goreng spesial 15 ribu\""
-			}
+			// Vision model drafts a catalog entry for the seller to confirm
+			agentResult := w.orchestrator.ProcessImage(ctx, payload.From, imageData, mimeType, caption)
+			response.AgentResult = agentResult
+			response.Reply = agentResult.Message
+			response.Message = "Image processed"
 		} else {
 			response.Reply = "📷 Gambar diterima tapi data kosong. Coba kirim lagi ya!"
 		}
@@ -165,6 +239,19 @@ func (w *WhatsAppWebhook) Handle(rw http.ResponseWriter, r *http.Request) {
 		response.Reply = "Maaf, jenis pesan ini belum didukung."
 	}
 
+	// Voice note for users who prefer spoken replies
+	if response.AgentResult != nil {
+		w.orchestrator.AddVoiceReply(ctx, payload.From, response.AgentResult)
+	}
+
+	// Pass structured replies through when the agent produced them
+	if response.AgentResult != nil && len(response.AgentResult.Replies) > 0 {
+		response.Replies = response.AgentResult.Replies
+	}
+	if response.AgentResult != nil {
+		response.PendingActionID = response.AgentResult.PendingActionID
+	}
+
 	rw.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(rw).Encode(response)
 }
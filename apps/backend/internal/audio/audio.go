@@ -0,0 +1,329 @@
+// Package audio demuxes, measures, transcodes and splits voice notes in pure
+// Go so they can be sent to speech-to-text providers.
+package audio
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Formats recognised by Detect
+const (
+	FormatOggOpus   = "ogg_opus"
+	FormatOggVorbis = "ogg_vorbis"
+	FormatWebMOpus  = "webm_opus"
+	FormatWAV       = "wav"
+	FormatPCM       = "pcm" // Raw 16-bit little-endian samples, e.g. audio/l16
+	FormatMP3       = "mp3"
+	FormatMP4       = "mp4" // AAC in an MP4/M4A container
+	FormatAMR       = "amr"
+)
+
+var (
+	// ErrUnsupportedFormat is returned for containers or codecs that can't be read or converted
+	ErrUnsupportedFormat = errors.New("unsupported audio format")
+	// ErrCorrupt is returned when the container is truncated or damaged
+	ErrCorrupt = errors.New("corrupt audio data")
+	// ErrTooShort is returned for recordings too short to hold a message
+	ErrTooShort = errors.New("audio too short")
+	// ErrSilent is returned when the recording is (almost) only silence
+	ErrSilent = errors.New("audio is silent")
+)
+
+const (
+	// MinDuration is the shortest recording worth transcribing
+	MinDuration = 500 * time.Millisecond
+	// maxSilenceRatio above which a recording counts as silent
+	maxSilenceRatio = 0.97
+	// maxClippingRatio above which a recording is logged as distorted
+	maxClippingRatio = 0.02
+)
+
+// acceptedMimeTypes are the formats speech-to-text providers take as is
+var acceptedMimeTypes = map[string]bool{
+	"audio/ogg":  true,
+	"audio/wav":  true,
+	"audio/mpeg": true,
+	"audio/mp4":  true,
+	"audio/aac":  true,
+	"audio/flac": true,
+}
+
+// NormalizeMimeType drops parameters and maps aliases, e.g.
+// "audio/ogg; codecs=opus" to "audio/ogg" and "audio/x-wav" to "audio/wav"
+func NormalizeMimeType(mimeType string) string {
+	base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(mimeType)), ";")
+	base = strings.TrimSpace(base)
+	switch base {
+	case "audio/opus":
+		return "audio/ogg"
+	case "audio/x-wav", "audio/wave", "audio/vnd.wave":
+		return "audio/wav"
+	case "audio/mp3", "audio/mpeg3", "audio/x-mpeg":
+		return "audio/mpeg"
+	case "audio/m4a", "audio/x-m4a":
+		return "audio/mp4"
+	}
+	return base
+}
+
+// Accepted reports whether providers take mimeType without conversion
+func Accepted(mimeType string) bool {
+	return acceptedMimeTypes[NormalizeMimeType(mimeType)]
+}
+
+// Detect identifies the format from magic bytes, falling back to the mime type
+func Detect(data []byte, mimeType string) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("OggS")):
+		if bytes.Contains(data[:min(len(data), 512)], []byte("\x01vorbis")) {
+			return FormatOggVorbis
+		}
+		return FormatOggOpus
+	case bytes.HasPrefix(data, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		return FormatWebMOpus
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WAVE":
+		return FormatWAV
+	case bytes.HasPrefix(data, []byte("#!AMR")):
+		return FormatAMR
+	case len(data) >= 8 && string(data[4:8]) == "ftyp":
+		return FormatMP4
+	case bytes.HasPrefix(data, []byte("ID3")) || (len(data) >= 2 && data[0] == 0xFF && data[1]&0xE0 == 0xE0):
+		return FormatMP3
+	}
+
+	switch NormalizeMimeType(mimeType) {
+	case "audio/l16", "audio/pcm":
+		return FormatPCM
+	}
+	return ""
+}
+
+// Analysis describes a recording
+type Analysis struct {
+	Format     string        `json:"format"`
+	Duration   time.Duration `json:"duration"`
+	SampleRate int           `json:"sample_rate,omitempty"`
+	Channels   int           `json:"channels,omitempty"`
+	// Levels is false when the codec gives no cheap loudness signal (MP3, AAC);
+	// the ratios below are then zero
+	Levels        bool    `json:"levels"`
+	SilenceRatio  float64 `json:"silence_ratio"`  // Share of the duration that is silent
+	ClippingRatio float64 `json:"clipping_ratio"` // Share of clipped samples, PCM only
+}
+
+// Check rejects recordings that can't hold a message
+func (a *Analysis) Check() error {
+	if a.Duration > 0 && a.Duration < MinDuration {
+		return fmt.Errorf("%w: %v", ErrTooShort, a.Duration)
+	}
+	if a.Levels && a.SilenceRatio >= maxSilenceRatio {
+		return fmt.Errorf("%w: %.0f%% silence", ErrSilent, a.SilenceRatio*100)
+	}
+	return nil
+}
+
+// Clipped reports whether enough samples hit full scale to distort speech
+func (a *Analysis) Clipped() bool {
+	return a.ClippingRatio > maxClippingRatio
+}
+
+// Options bound the chunks sent to speech-to-text
+type Options struct {
+	MaxChunk   time.Duration // Longest chunk, cut at a pause where possible
+	MinSilence time.Duration // Shortest pause a chunk is cut at
+}
+
+// DefaultOptions keeps chunks to a minute
+func DefaultOptions() Options {
+	return Options{MaxChunk: time.Minute, MinSilence: 300 * time.Millisecond}
+}
+
+// Clip is a piece of audio in a provider-accepted format
+type Clip struct {
+	Data     []byte
+	MimeType string
+	Offset   time.Duration // Start within the original recording
+	Duration time.Duration
+}
+
+// Prepared is a recording analysed and cut into provider-ready clips
+type Prepared struct {
+	Analysis   *Analysis
+	Clips      []Clip
+	Transcoded bool // Container or encoding was changed
+}
+
+// frame is the unit a recording is measured and cut in
+type frame struct {
+	dur    time.Duration
+	silent bool
+}
+
+// source is a decoded recording that can re-encode a range of its frames
+type source interface {
+	frames() []frame
+	encode(from, to int) []byte
+	mimeType() string
+}
+
+// Prepare analyses a recording, converts it to a provider-accepted format
+// when needed and splits it at pauses into clips no longer than MaxChunk
+func Prepare(data []byte, mimeType string, opts Options) (*Prepared, error) {
+	if opts.MaxChunk <= 0 {
+		opts = DefaultOptions()
+	}
+	format := Detect(data, mimeType)
+	analysis := &Analysis{Format: format}
+	prepared := &Prepared{Analysis: analysis}
+
+	var src source
+	switch format {
+	case FormatOggOpus:
+		stream, err := parseOgg(data)
+		if err != nil {
+			return nil, err
+		}
+		opus, err := newOpusSource(stream)
+		if err != nil {
+			return nil, err
+		}
+		analysis.SampleRate, analysis.Channels, analysis.Levels = 48000, opus.channels(), true
+		src = opus
+	case FormatWebMOpus:
+		opus, err := parseWebM(data)
+		if err != nil {
+			return nil, err
+		}
+		analysis.SampleRate, analysis.Channels, analysis.Levels = 48000, opus.channels(), true
+		src = opus
+		prepared.Transcoded = true
+	case FormatWAV, FormatPCM:
+		var pcm *pcmSource
+		var err error
+		if format == FormatWAV {
+			pcm, err = parseWAV(data)
+		} else {
+			pcm, err = parseRawPCM(data, mimeType)
+		}
+		if err != nil {
+			return nil, err
+		}
+		analysis.SampleRate, analysis.Channels, analysis.Levels = pcm.sourceRate, pcm.sourceChannels, true
+		analysis.ClippingRatio = pcm.clipping
+		prepared.Transcoded = pcm.transcoded
+		src = pcm
+	case FormatMP3:
+		mp3, err := parseMP3(data)
+		if err != nil {
+			return nil, err
+		}
+		analysis.SampleRate = mp3.rate
+		src = mp3
+	case FormatOggVorbis, FormatMP4:
+		// Accepted as is; only the duration is read
+		analysis.Duration = passthroughDuration(format, data)
+		prepared.Clips = []Clip{{Data: data, MimeType: passthroughMimeTypes[format], Duration: analysis.Duration}}
+		return prepared, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, mimeType)
+	}
+
+	frames := src.frames()
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("%w: no audio frames", ErrCorrupt)
+	}
+	var silent time.Duration
+	for _, f := range frames {
+		analysis.Duration += f.dur
+		if f.silent {
+			silent += f.dur
+		}
+	}
+	if analysis.Levels {
+		analysis.SilenceRatio = float64(silent) / float64(analysis.Duration)
+	}
+
+	var offset time.Duration
+	for _, span := range splitFrames(frames, opts) {
+		var dur time.Duration
+		for _, f := range frames[span[0]:span[1]] {
+			dur += f.dur
+		}
+		prepared.Clips = append(prepared.Clips, Clip{
+			Data:     src.encode(span[0], span[1]),
+			MimeType: src.mimeType(),
+			Offset:   offset,
+			Duration: dur,
+		})
+		offset += dur
+	}
+	return prepared, nil
+}
+
+var passthroughMimeTypes = map[string]string{
+	FormatOggVorbis: "audio/ogg",
+	FormatMP4:       "audio/mp4",
+}
+
+func passthroughDuration(format string, data []byte) time.Duration {
+	if format == FormatMP4 {
+		return mp4Duration(data)
+	}
+	return vorbisDuration(data)
+}
+
+// splitFrames returns [from, to) frame ranges no longer than MaxChunk,
+// cut in the middle of the longest pause in the second half of each chunk
+func splitFrames(frames []frame, opts Options) [][2]int {
+	var spans [][2]int
+	start := 0
+	var elapsed time.Duration
+	for i := range frames {
+		elapsed += frames[i].dur
+		if elapsed <= opts.MaxChunk {
+			continue
+		}
+		cut := silenceCut(frames, start, i, opts)
+		spans = append(spans, [2]int{start, cut})
+		start = cut
+		elapsed = 0
+		for _, f := range frames[start : i+1] {
+			elapsed += f.dur
+		}
+	}
+	return append(spans, [2]int{start, len(frames)})
+}
+
+// silenceCut picks a cut in (start, end]: inside a pause when there is one
+// of at least MinSilence after the chunk's midpoint, else right before end
+func silenceCut(frames []frame, start, end int, opts Options) int {
+	bestCut := end
+	var bestLen, elapsed time.Duration
+	for i := start; i < end; {
+		if !frames[i].silent {
+			elapsed += frames[i].dur
+			i++
+			continue
+		}
+		runStart := i
+		runElapsed := elapsed
+		var runLen time.Duration
+		for i < end && frames[i].silent {
+			runLen += frames[i].dur
+			i++
+		}
+		elapsed += runLen
+		if runElapsed >= opts.MaxChunk/2 && runLen >= opts.MinSilence && runLen > bestLen {
+			bestLen = runLen
+			bestCut = runStart + (i-runStart)/2
+		}
+	}
+	if bestCut <= start {
+		return start + 1
+	}
+	return bestCut
+}
@@ -0,0 +1,265 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// Opus packets of 20 ms (SILK NB, one frame): speech is large, silence tiny
+var (
+	speechPacket  = append([]byte{0x08}, bytes.Repeat([]byte{0x55}, 59)...)
+	silencePacket = []byte{0x08, 0x00}
+)
+
+// opusPackets lays out speech and silence, in seconds, alternating from speech
+func opusPackets(seconds ...float64) [][]byte {
+	var packets [][]byte
+	for i, s := range seconds {
+		packet := speechPacket
+		if i%2 == 1 {
+			packet = silencePacket
+		}
+		for n := 0; n < int(s*50); n++ {
+			packets = append(packets, packet)
+		}
+	}
+	return packets
+}
+
+func oggOpus(packets [][]byte) []byte {
+	src := &opusSource{head: opusHead(1), packets: packets}
+	return src.encode(0, len(packets))
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		mimeType string
+		want     string
+	}{
+		{"ogg opus", oggOpus(opusPackets(1)), "audio/ogg; codecs=opus", FormatOggOpus},
+		{"webm", []byte{0x1A, 0x45, 0xDF, 0xA3, 0x00}, "audio/webm", FormatWebMOpus},
+		{"wav", encodeWAV([]float32{0}, 16000), "", FormatWAV},
+		{"mp3", []byte("ID3\x04\x00\x00\x00\x00\x00\x00"), "audio/mpeg", FormatMP3},
+		{"m4a", []byte("\x00\x00\x00\x18ftypM4A "), "audio/mp4", FormatMP4},
+		{"amr", []byte("#!AMR\n"), "audio/amr", FormatAMR},
+		{"raw pcm", []byte{0, 0}, "audio/L16;rate=8000", FormatPCM},
+		{"unknown", []byte("hello"), "audio/x-foo", ""},
+	}
+	for _, tt := range tests {
+		if got := Detect(tt.data, tt.mimeType); got != tt.want {
+			t.Errorf("Detect(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	for mimeType, want := range map[string]bool{
+		"audio/ogg; codecs=opus": true,
+		"audio/x-wav":            true,
+		"audio/mp3":              true,
+		"audio/webm":             false,
+		"audio/amr":              false,
+	} {
+		if got := Accepted(mimeType); got != want {
+			t.Errorf("Accepted(%s) = %v, want %v", mimeType, got, want)
+		}
+	}
+}
+
+func TestPrepare_OggOpusSplitsAtPause(t *testing.T) {
+	// 40 s speech, 1 s pause, 40 s speech, 0.2 s pause, 15 s speech
+	packets := opusPackets(40, 1, 40, 0.2, 15)
+	prepared, err := Prepare(oggOpus(packets), "audio/ogg; codecs=opus", DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := prepared.Analysis
+	if a.Format != FormatOggOpus || a.Duration != 96200*time.Millisecond || !a.Levels {
+		t.Errorf("Analysis = %+v", a)
+	}
+	if a.SilenceRatio < 0.01 || a.SilenceRatio > 0.02 {
+		t.Errorf("SilenceRatio = %.3f, want about 1.2/96.2", a.SilenceRatio)
+	}
+	if prepared.Transcoded {
+		t.Error("Ogg/Opus should pass through")
+	}
+
+	if len(prepared.Clips) != 2 {
+		t.Fatalf("Clips = %d, want 2", len(prepared.Clips))
+	}
+	// Cut in the middle of the one-second pause, not at the 0.2 s one
+	if first := prepared.Clips[0].Duration; first != 40500*time.Millisecond {
+		t.Errorf("First clip = %v, want 40.5s", first)
+	}
+	total := 0
+	for _, clip := range prepared.Clips {
+		stream, err := parseOgg(clip.Data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		src, err := newOpusSource(stream)
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += len(src.packets)
+		if want := uint64(len(src.packets) * 960); stream.lastGranule != want {
+			t.Errorf("Clip granule = %d, want %d", stream.lastGranule, want)
+		}
+	}
+	if total != len(packets) {
+		t.Errorf("Clips hold %d packets, want %d", total, len(packets))
+	}
+}
+
+func TestPrepare_Quality(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"speech", oggOpus(opusPackets(3, 1)), nil},
+		{"silent", oggOpus(opusPackets(0, 5)), ErrSilent},
+		{"too short", oggOpus(opusPackets(0.2)), ErrTooShort},
+	}
+	for _, tt := range tests {
+		prepared, err := Prepare(tt.data, "audio/ogg", DefaultOptions())
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if err := prepared.Analysis.Check(); !errors.Is(err, tt.want) {
+			t.Errorf("%s: Check() = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	damaged := oggOpus(opusPackets(1))
+	damaged[30] ^= 0xFF
+	if _, err := Prepare(damaged[:40], "audio/ogg", DefaultOptions()); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Damaged Ogg = %v, want ErrCorrupt", err)
+	}
+	if _, err := Prepare([]byte("#!AMR\n\x3c"), "audio/amr", DefaultOptions()); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("AMR = %v, want ErrUnsupportedFormat", err)
+	}
+}
+
+// ebml encodes an element with an 8-byte size, or unknown size when payload is nil
+func ebml(id uint32, payload []byte) []byte {
+	var out []byte
+	for shift := 24; shift >= 0; shift -= 8 {
+		if b := byte(id >> shift); b != 0 || len(out) > 0 {
+			out = append(out, b)
+		}
+	}
+	if payload == nil {
+		return append(out, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF)
+	}
+	size := binary.BigEndian.AppendUint64(nil, uint64(len(payload)))
+	size[0] = 0x01 // 8-byte length marker
+	return append(append(out, size...), payload...)
+}
+
+func TestPrepare_WebMRemux(t *testing.T) {
+	track := ebml(ebmlTrackEntry, bytes.Join([][]byte{
+		ebml(ebmlTrackNumber, []byte{1}),
+		ebml(ebmlCodecID, []byte("A_OPUS")),
+		ebml(ebmlCodecPrivate, opusHead(1)),
+	}, nil))
+	var cluster []byte
+	for _, p := range opusPackets(2, 1) {
+		cluster = append(cluster, ebml(ebmlSimpleBlock, append([]byte{0x81, 0, 0, 0x80}, p...))...)
+	}
+
+	// Browsers write the segment and clusters with unknown sizes
+	data := ebml(0x1A45DFA3, []byte{0x42, 0x82, 0x84, 'w', 'e', 'b', 'm'})
+	data = append(data, ebml(ebmlSegment, nil)...)
+	data = append(data, ebml(ebmlTracks, track)...)
+	data = append(data, ebml(ebmlCluster, nil)...)
+	data = append(data, cluster...)
+
+	prepared, err := Prepare(data, "audio/webm;codecs=opus", DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !prepared.Transcoded || len(prepared.Clips) != 1 || prepared.Clips[0].MimeType != "audio/ogg" {
+		t.Fatalf("Prepared = %+v", prepared)
+	}
+	if prepared.Analysis.Duration != 3*time.Second {
+		t.Errorf("Duration = %v, want 3s", prepared.Analysis.Duration)
+	}
+	stream, err := parseOgg(prepared.Clips[0].Data)
+	if err != nil || len(stream.packets) != 2+150 {
+		t.Errorf("Remuxed Ogg = %d packets, %v", len(stream.packets), err)
+	}
+}
+
+func TestPrepare_WAVTranscode(t *testing.T) {
+	// Two seconds of stereo 44.1 kHz 16-bit: a clipped tone, then silence
+	const rate = 44100
+	data := []byte("RIFF\x00\x00\x00\x00WAVEfmt ")
+	data = binary.LittleEndian.AppendUint32(data, 16)
+	data = binary.LittleEndian.AppendUint16(data, 1)
+	data = binary.LittleEndian.AppendUint16(data, 2)
+	data = binary.LittleEndian.AppendUint32(data, rate)
+	data = binary.LittleEndian.AppendUint32(data, rate*4)
+	data = binary.LittleEndian.AppendUint16(data, 4)
+	data = binary.LittleEndian.AppendUint16(data, 16)
+	data = append(data, "data"...)
+	data = binary.LittleEndian.AppendUint32(data, 2*rate*4)
+	for i := 0; i < 2*rate; i++ {
+		var v int16
+		if i < rate {
+			v = int16(max(-32767, min(32767, 50000*math.Sin(float64(i)*2*math.Pi*440/rate))))
+		}
+		data = binary.LittleEndian.AppendUint16(data, uint16(v))
+		data = binary.LittleEndian.AppendUint16(data, uint16(v))
+	}
+
+	prepared, err := Prepare(data, "audio/wav", DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := prepared.Analysis
+	if a.SampleRate != rate || a.Channels != 2 || a.Duration != 2*time.Second {
+		t.Errorf("Analysis = %+v", a)
+	}
+	if a.SilenceRatio < 0.45 || a.SilenceRatio > 0.55 || !a.Clipped() {
+		t.Errorf("SilenceRatio = %.2f, ClippingRatio = %.3f", a.SilenceRatio, a.ClippingRatio)
+	}
+
+	if !prepared.Transcoded || len(prepared.Clips) != 1 {
+		t.Fatalf("Prepared = %+v", prepared)
+	}
+	clip, err := parseWAV(prepared.Clips[0].Data)
+	if err != nil || clip.sourceRate != targetRate || clip.sourceChannels != 1 || len(clip.samples) != 2*targetRate {
+		t.Errorf("Transcoded WAV = %d Hz, %d channels, %d samples, %v", clip.sourceRate, clip.sourceChannels, len(clip.samples), err)
+	}
+}
+
+func TestPrepare_MP3(t *testing.T) {
+	// MPEG 1 Layer III, 128 kbps, 44.1 kHz: 417-byte frames of 1152 samples
+	frame := append([]byte{0xFF, 0xFB, 0x90, 0x64}, make([]byte, 413)...)
+	data := append([]byte("ID3\x03\x00\x00\x00\x00\x00\x02xx"), bytes.Repeat(frame, 4000)...)
+
+	prepared, err := Prepare(data, "audio/mpeg", DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Duration(4000*1152) * time.Second / 44100
+	if d := prepared.Analysis.Duration; d < want-time.Millisecond || d > want+time.Millisecond {
+		t.Errorf("Duration = %v, want %v", d, want)
+	}
+	if prepared.Analysis.Levels {
+		t.Error("MP3 has no level measurement")
+	}
+	// 104 s without pause information is hard cut at the limit
+	if len(prepared.Clips) != 2 || prepared.Clips[0].Duration > time.Minute {
+		t.Errorf("Clips = %d, first %v", len(prepared.Clips), prepared.Clips[0].Duration)
+	}
+	if len(prepared.Clips[0].Data)%417 != 0 {
+		t.Error("Clips should end on frame boundaries")
+	}
+}
@@ -0,0 +1,142 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// Layer III bitrates in kbps by MPEG version, indexed by the header's bitrate bits
+var (
+	mp3BitratesV1 = [16]int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0}
+	mp3BitratesV2 = [16]int{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0}
+	mp3Rates      = map[byte][3]int{
+		3: {44100, 48000, 32000}, // MPEG 1
+		2: {22050, 24000, 16000}, // MPEG 2
+		0: {11025, 12000, 8000},  // MPEG 2.5
+	}
+)
+
+// mp3Source is an MP3 stream cut at frame boundaries. Frames carry no cheap
+// loudness signal, so chunks are cut at the length limit.
+type mp3Source struct {
+	frameData [][]byte
+	levels    []frame
+	rate      int
+}
+
+// parseMP3 walks the Layer III frames after any ID3v2 tag
+func parseMP3(data []byte) (*mp3Source, error) {
+	pos := 0
+	if bytes.HasPrefix(data, []byte("ID3")) && len(data) >= 10 {
+		size := int(data[6])<<21 | int(data[7])<<14 | int(data[8])<<7 | int(data[9])
+		pos = 10 + size
+		if data[5]&0x10 != 0 {
+			pos += 10 // Footer
+		}
+	}
+
+	src := &mp3Source{}
+	for pos+4 <= len(data) {
+		h := data[pos : pos+4]
+		if h[0] != 0xFF || h[1]&0xE0 != 0xE0 {
+			pos++ // Resync after junk
+			continue
+		}
+		version := (h[1] >> 3) & 0x03
+		layer := (h[1] >> 1) & 0x03
+		rates, ok := mp3Rates[version]
+		rateIdx := (h[2] >> 2) & 0x03
+		if !ok || layer != 1 || rateIdx == 3 {
+			pos++
+			continue
+		}
+		bitrates, samples, coef := mp3BitratesV1, 1152, 144
+		if version != 3 {
+			bitrates, samples, coef = mp3BitratesV2, 576, 72
+		}
+		bitrate := bitrates[h[2]>>4] * 1000
+		rate := rates[rateIdx]
+		if bitrate == 0 {
+			pos++
+			continue
+		}
+		size := coef*bitrate/rate + int((h[2]>>1)&0x01)
+		if pos+size > len(data) {
+			break
+		}
+
+		src.rate = rate
+		src.frameData = append(src.frameData, data[pos:pos+size])
+		src.levels = append(src.levels, frame{dur: time.Duration(samples) * time.Second / time.Duration(rate)})
+		pos += size
+	}
+
+	if len(src.frameData) == 0 {
+		return nil, fmt.Errorf("%w: no MP3 frames", ErrCorrupt)
+	}
+	return src, nil
+}
+
+func (s *mp3Source) frames() []frame {
+	return s.levels
+}
+
+func (s *mp3Source) mimeType() string {
+	return "audio/mpeg"
+}
+
+// encode concatenates frames [from, to); MP3 frames decode on their own
+func (s *mp3Source) encode(from, to int) []byte {
+	return bytes.Join(s.frameData[from:to], nil)
+}
+
+// mp4Duration reads the movie header (mvhd) of an MP4/M4A file
+func mp4Duration(data []byte) time.Duration {
+	moov := mp4Box(data, "moov")
+	if moov == nil {
+		return 0
+	}
+	mvhd := mp4Box(moov, "mvhd")
+	if len(mvhd) < 20 {
+		return 0
+	}
+	var timescale, duration uint64
+	if mvhd[0] == 1 {
+		if len(mvhd) < 32 {
+			return 0
+		}
+		timescale = uint64(binary.BigEndian.Uint32(mvhd[20:24]))
+		duration = binary.BigEndian.Uint64(mvhd[24:32])
+	} else {
+		timescale = uint64(binary.BigEndian.Uint32(mvhd[12:16]))
+		duration = uint64(binary.BigEndian.Uint32(mvhd[16:20]))
+	}
+	if timescale == 0 {
+		return 0
+	}
+	return time.Duration(duration) * time.Second / time.Duration(timescale)
+}
+
+// mp4Box returns the payload of the first box of a type among siblings
+func mp4Box(data []byte, boxType string) []byte {
+	for pos := 0; pos+8 <= len(data); {
+		size := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		header := 8
+		if size == 1 && pos+16 <= len(data) { // 64-bit size
+			size = int(binary.BigEndian.Uint64(data[pos+8 : pos+16]))
+			header = 16
+		} else if size == 0 { // Box runs to the end
+			size = len(data) - pos
+		}
+		if size < header || pos+size > len(data) {
+			return nil
+		}
+		if string(data[pos+4:pos+8]) == boxType {
+			return data[pos+header : pos+size]
+		}
+		pos += size
+	}
+	return nil
+}
@@ -0,0 +1,300 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// oggStream is the first logical stream of an Ogg file split into packets
+type oggStream struct {
+	packets     [][]byte
+	lastGranule uint64
+}
+
+// parseOgg reads the pages of the first logical stream. Pages with a bad
+// checksum or from other streams are skipped; a truncated tail is dropped.
+func parseOgg(data []byte) (*oggStream, error) {
+	stream := &oggStream{}
+	var serial uint32
+	var partial []byte
+	pages := 0
+
+	for pos := 0; pos+27 <= len(data); {
+		if !bytes.Equal(data[pos:pos+4], []byte("OggS")) {
+			next := bytes.Index(data[pos+1:], []byte("OggS"))
+			if next < 0 {
+				break
+			}
+			pos += next + 1
+			continue
+		}
+		segments := int(data[pos+26])
+		bodyStart := pos + 27 + segments
+		if bodyStart > len(data) {
+			break
+		}
+		table := data[pos+27 : bodyStart]
+		bodySize := 0
+		for _, s := range table {
+			bodySize += int(s)
+		}
+		pageEnd := bodyStart + bodySize
+		if pageEnd > len(data) {
+			break
+		}
+		page := data[pos:pageEnd]
+		pos = pageEnd
+
+		pageSerial := binary.LittleEndian.Uint32(page[14:18])
+		if pages == 0 {
+			serial = pageSerial
+		}
+		if pageSerial != serial || !validOggCRC(page) {
+			continue
+		}
+		pages++
+
+		if page[5]&0x01 == 0 {
+			partial = nil // A packet continued from a lost page can't be completed
+		}
+		if granule := binary.LittleEndian.Uint64(page[6:14]); granule != ^uint64(0) {
+			stream.lastGranule = granule
+		}
+		body := page[27+segments:]
+		for _, s := range table {
+			partial = append(partial, body[:s]...)
+			body = body[s:]
+			if s < 255 {
+				stream.packets = append(stream.packets, partial)
+				partial = nil
+			}
+		}
+	}
+
+	if pages == 0 {
+		return nil, fmt.Errorf("%w: no valid Ogg pages", ErrCorrupt)
+	}
+	return stream, nil
+}
+
+// opusSource is an Opus stream cut at packet boundaries
+type opusSource struct {
+	head    []byte // OpusHead packet
+	tags    []byte // OpusTags packet
+	packets [][]byte
+	levels  []frame
+}
+
+func newOpusSource(stream *oggStream) (*opusSource, error) {
+	if len(stream.packets) == 0 || !bytes.HasPrefix(stream.packets[0], []byte("OpusHead")) || len(stream.packets[0]) < 19 {
+		return nil, fmt.Errorf("%w: Ogg stream is not Opus", ErrUnsupportedFormat)
+	}
+	src := &opusSource{head: stream.packets[0]}
+	packets := stream.packets[1:]
+	if len(packets) > 0 && bytes.HasPrefix(packets[0], []byte("OpusTags")) {
+		src.tags = packets[0]
+		packets = packets[1:]
+	}
+	src.packets = packets
+	src.levels = opusFrames(packets)
+	return src, nil
+}
+
+func (s *opusSource) channels() int {
+	return int(s.head[9])
+}
+
+func (s *opusSource) preSkip() uint64 {
+	return uint64(binary.LittleEndian.Uint16(s.head[10:12]))
+}
+
+func (s *opusSource) frames() []frame {
+	return s.levels
+}
+
+func (s *opusSource) mimeType() string {
+	return "audio/ogg"
+}
+
+// encode muxes packets [from, to) into a standalone Ogg/Opus file
+func (s *opusSource) encode(from, to int) []byte {
+	w := &oggWriter{serial: 0x50535031}
+	w.writePage([][]byte{s.head}, 0, 0x02)
+	tags := s.tags
+	if tags == nil || len(tags) >= 255*255 { // Cover art would need several pages
+		tags = opusTags("pasarsuara")
+	}
+	w.writePage([][]byte{tags}, 0, 0)
+
+	granule := s.preSkip()
+	var page [][]byte
+	segments := 0
+	for i := from; i < to; i++ {
+		packet := s.packets[i]
+		need := len(packet)/255 + 1
+		if segments+need > 255 || len(page) >= 50 {
+			w.writePage(page, granule, 0)
+			page, segments = nil, 0
+		}
+		page = append(page, packet)
+		segments += need
+		granule += uint64(opusPacketSamples(packet))
+	}
+	w.writePage(page, granule, 0x04)
+	return w.buf.Bytes()
+}
+
+// opusFrames times every packet and estimates which are silent. Opus spends
+// far fewer bytes on silence, so a packet is silent when its bytes per 20 ms
+// are tiny or well below the recording's median.
+func opusFrames(packets [][]byte) []frame {
+	if len(packets) == 0 {
+		return nil
+	}
+	frames := make([]frame, 0, len(packets))
+	rates := make([]float64, 0, len(packets))
+	for _, p := range packets {
+		samples := opusPacketSamples(p)
+		if samples == 0 {
+			frames = append(frames, frame{})
+			rates = append(rates, 0)
+			continue
+		}
+		frames = append(frames, frame{dur: time.Duration(samples) * time.Second / 48000})
+		rates = append(rates, float64(len(p))*960/float64(samples))
+	}
+
+	sorted := append([]float64(nil), rates...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+	for i, rate := range rates {
+		frames[i].silent = rate <= opusSilentBytes || rate < median*opusSilentShare
+	}
+	return frames
+}
+
+const (
+	opusSilentBytes = 6    // Bytes per 20 ms of DTX or digital silence
+	opusSilentShare = 0.35 // Share of the median packet size below which speech is unlikely
+)
+
+// opusPacketSamples returns the 48 kHz samples in a packet from its TOC byte (RFC 6716 3.1)
+func opusPacketSamples(p []byte) int {
+	if len(p) == 0 {
+		return 0
+	}
+	config := p[0] >> 3
+	var size int
+	switch {
+	case config < 12: // SILK: 10, 20, 40, 60 ms
+		size = []int{480, 960, 1920, 2880}[config%4]
+	case config < 16: // Hybrid: 10, 20 ms
+		size = []int{480, 960}[config%2]
+	default: // CELT: 2.5, 5, 10, 20 ms
+		size = []int{120, 240, 480, 960}[config%4]
+	}
+	switch p[0] & 0x03 {
+	case 0:
+		return size
+	case 1, 2:
+		return 2 * size
+	default:
+		if len(p) < 2 {
+			return 0
+		}
+		return int(p[1]&0x3F) * size
+	}
+}
+
+// opusTags is a minimal OpusTags header without comments
+func opusTags(vendor string) []byte {
+	tags := []byte("OpusTags")
+	tags = binary.LittleEndian.AppendUint32(tags, uint32(len(vendor)))
+	tags = append(tags, vendor...)
+	return binary.LittleEndian.AppendUint32(tags, 0)
+}
+
+// vorbisDuration reads an Ogg/Vorbis duration from the last granule and the sample rate
+func vorbisDuration(data []byte) time.Duration {
+	stream, err := parseOgg(data)
+	if err != nil || len(stream.packets) == 0 || len(stream.packets[0]) < 16 {
+		return 0
+	}
+	rate := binary.LittleEndian.Uint32(stream.packets[0][12:16])
+	if rate == 0 {
+		return 0
+	}
+	return time.Duration(stream.lastGranule) * time.Second / time.Duration(rate)
+}
+
+// oggWriter writes the pages of one logical stream
+type oggWriter struct {
+	buf    bytes.Buffer
+	serial uint32
+	seq    uint32
+}
+
+// writePage writes packets that fit in one page (at most 255 segments)
+func (w *oggWriter) writePage(packets [][]byte, granule uint64, flags byte) {
+	var table, body []byte
+	for _, p := range packets {
+		n := len(p)
+		for ; n >= 255; n -= 255 {
+			table = append(table, 255)
+		}
+		table = append(table, byte(n))
+		body = append(body, p...)
+	}
+
+	page := make([]byte, 27, 27+len(table)+len(body))
+	copy(page, "OggS")
+	page[5] = flags
+	binary.LittleEndian.PutUint64(page[6:14], granule)
+	binary.LittleEndian.PutUint32(page[14:18], w.serial)
+	binary.LittleEndian.PutUint32(page[18:22], w.seq)
+	page[26] = byte(len(table))
+	page = append(append(page, table...), body...)
+	binary.LittleEndian.PutUint32(page[22:26], oggCRC(page))
+
+	w.buf.Write(page)
+	w.seq++
+}
+
+// oggCRCTable is CRC-32 with polynomial 0x04c11db7, unreflected, as Ogg uses
+var oggCRCTable = func() (table [256]uint32) {
+	for i := range table {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if r&0x80000000 != 0 {
+				r = r<<1 ^ 0x04C11DB7
+			} else {
+				r <<= 1
+			}
+		}
+		table[i] = r
+	}
+	return table
+}()
+
+// oggCRC checksums a page whose CRC field is zero
+func oggCRC(page []byte) uint32 {
+	return oggCRCUpdate(0, page)
+}
+
+func oggCRCUpdate(crc uint32, data []byte) uint32 {
+	for _, b := range data {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	return crc
+}
+
+// validOggCRC checks a page's checksum, computed with the CRC field zeroed
+func validOggCRC(page []byte) bool {
+	crc := oggCRCUpdate(0, page[:22])
+	crc = oggCRCUpdate(crc, []byte{0, 0, 0, 0})
+	crc = oggCRCUpdate(crc, page[26:])
+	return crc == binary.LittleEndian.Uint32(page[22:26])
+}
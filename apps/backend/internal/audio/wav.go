@@ -0,0 +1,225 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// targetRate is the sample rate PCM is sent at; speech needs no more
+	targetRate = 16000
+	// pcmWindow is the window silence is measured over
+	pcmWindow = 20 * time.Millisecond
+	// pcmSilenceRMS is about -40 dBFS
+	pcmSilenceRMS = 0.01
+	// pcmClipLevel counts a sample as clipped
+	pcmClipLevel = 0.999
+)
+
+// pcmSource is mono PCM at no more than targetRate, re-encoded as 16-bit WAV
+type pcmSource struct {
+	samples        []float32
+	rate           int
+	sourceRate     int
+	sourceChannels int
+	clipping       float64
+	transcoded     bool
+	levels         []frame
+}
+
+// parseWAV decodes 8/16/24/32-bit integer or 32-bit float WAV
+func parseWAV(data []byte) (*pcmSource, error) {
+	var format, channels, bits int
+	var rate int
+	var body []byte
+
+	for pos := 12; pos+8 <= len(data); {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		pos += 8
+		end := min(pos+size, len(data)) // Recorders often write a wrong data size
+		chunk := data[pos:end]
+
+		switch id {
+		case "fmt ":
+			if len(chunk) < 16 {
+				return nil, fmt.Errorf("%w: short fmt chunk", ErrCorrupt)
+			}
+			format = int(binary.LittleEndian.Uint16(chunk[0:2]))
+			channels = int(binary.LittleEndian.Uint16(chunk[2:4]))
+			rate = int(binary.LittleEndian.Uint32(chunk[4:8]))
+			bits = int(binary.LittleEndian.Uint16(chunk[14:16]))
+			if format == 0xFFFE && len(chunk) >= 26 { // WAVE_FORMAT_EXTENSIBLE
+				format = int(binary.LittleEndian.Uint16(chunk[24:26]))
+			}
+		case "data":
+			body = chunk
+		}
+		pos = end + size%2 // Chunks are word aligned
+	}
+
+	if format == 0 || body == nil {
+		return nil, fmt.Errorf("%w: WAV without fmt or data", ErrCorrupt)
+	}
+	if channels == 0 || rate == 0 {
+		return nil, fmt.Errorf("%w: WAV with %d channels at %d Hz", ErrCorrupt, channels, rate)
+	}
+
+	var decode func([]byte) float32
+	switch {
+	case format == 1 && bits == 8:
+		decode = func(b []byte) float32 { return (float32(b[0]) - 128) / 128 }
+	case format == 1 && bits == 16:
+		decode = func(b []byte) float32 { return float32(int16(binary.LittleEndian.Uint16(b))) / 32768 }
+	case format == 1 && bits == 24:
+		decode = func(b []byte) float32 {
+			return float32(int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24)>>8) / 8388608
+		}
+	case format == 1 && bits == 32:
+		decode = func(b []byte) float32 { return float32(int32(binary.LittleEndian.Uint32(b))) / 2147483648 }
+	case format == 3 && bits == 32:
+		decode = func(b []byte) float32 { return math.Float32frombits(binary.LittleEndian.Uint32(b)) }
+	default:
+		return nil, fmt.Errorf("%w: WAV format %d with %d bits", ErrUnsupportedFormat, format, bits)
+	}
+
+	src := newPCMSource(decodePCM(body, channels, bits/8, decode), rate, channels)
+	src.transcoded = src.transcoded || format != 1 || bits != 16
+	return src, nil
+}
+
+// parseRawPCM wraps raw 16-bit little-endian samples, e.g. "audio/l16;rate=8000;channels=1"
+func parseRawPCM(data []byte, mimeType string) (*pcmSource, error) {
+	rate, channels := targetRate, 1
+	for _, param := range strings.Split(mimeType, ";")[1:] {
+		key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "rate":
+			rate = n
+		case "channels":
+			channels = n
+		}
+	}
+	src := newPCMSource(decodePCM(data, channels, 2, func(b []byte) float32 {
+		return float32(int16(binary.LittleEndian.Uint16(b))) / 32768
+	}), rate, channels)
+	src.transcoded = true
+	return src, nil
+}
+
+// decodePCM mixes interleaved frames down to mono
+func decodePCM(body []byte, channels, width int, decode func([]byte) float32) []float32 {
+	frameSize := channels * width
+	samples := make([]float32, len(body)/frameSize)
+	for i := range samples {
+		var sum float32
+		for c := 0; c < channels; c++ {
+			off := i*frameSize + c*width
+			sum += decode(body[off : off+width])
+		}
+		samples[i] = sum / float32(channels)
+	}
+	return samples
+}
+
+// newPCMSource measures clipping on the original samples, then downsamples
+// to targetRate and measures silence per window
+func newPCMSource(samples []float32, rate, channels int) *pcmSource {
+	src := &pcmSource{rate: rate, sourceRate: rate, sourceChannels: channels, transcoded: channels != 1}
+
+	clipped := 0
+	for _, s := range samples {
+		if s >= pcmClipLevel || s <= -pcmClipLevel {
+			clipped++
+		}
+	}
+	if len(samples) > 0 {
+		src.clipping = float64(clipped) / float64(len(samples))
+	}
+
+	if rate > targetRate {
+		samples = resample(samples, rate, targetRate)
+		src.rate = targetRate
+		src.transcoded = true
+	}
+	src.samples = samples
+
+	window := int(int64(src.rate) * int64(pcmWindow) / int64(time.Second))
+	for start := 0; start < len(samples); start += window {
+		end := min(start+window, len(samples))
+		var sum float64
+		for _, s := range samples[start:end] {
+			sum += float64(s) * float64(s)
+		}
+		rms := math.Sqrt(sum / float64(end-start))
+		src.levels = append(src.levels, frame{
+			dur:    time.Duration(end-start) * time.Second / time.Duration(src.rate),
+			silent: rms < pcmSilenceRMS,
+		})
+	}
+	return src
+}
+
+// resample converts by linear interpolation, enough for speech recognition
+func resample(samples []float32, from, to int) []float32 {
+	out := make([]float32, int(int64(len(samples))*int64(to)/int64(from)))
+	step := float64(from) / float64(to)
+	for i := range out {
+		pos := float64(i) * step
+		j := int(pos)
+		if j+1 >= len(samples) {
+			out[i] = samples[len(samples)-1]
+			continue
+		}
+		frac := float32(pos - float64(j))
+		out[i] = samples[j]*(1-frac) + samples[j+1]*frac
+	}
+	return out
+}
+
+func (s *pcmSource) frames() []frame {
+	return s.levels
+}
+
+func (s *pcmSource) mimeType() string {
+	return "audio/wav"
+}
+
+// encode writes windows [from, to) as 16-bit mono WAV
+func (s *pcmSource) encode(from, to int) []byte {
+	window := int(int64(s.rate) * int64(pcmWindow) / int64(time.Second))
+	start := min(from*window, len(s.samples))
+	end := min(to*window, len(s.samples))
+	return encodeWAV(s.samples[start:end], s.rate)
+}
+
+// encodeWAV writes 16-bit mono PCM with a canonical 44-byte header
+func encodeWAV(samples []float32, rate int) []byte {
+	dataSize := len(samples) * 2
+	out := make([]byte, 0, 44+dataSize)
+	out = append(out, "RIFF"...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(36+dataSize))
+	out = append(out, "WAVEfmt "...)
+	out = binary.LittleEndian.AppendUint32(out, 16)
+	out = binary.LittleEndian.AppendUint16(out, 1) // PCM
+	out = binary.LittleEndian.AppendUint16(out, 1) // Mono
+	out = binary.LittleEndian.AppendUint32(out, uint32(rate))
+	out = binary.LittleEndian.AppendUint32(out, uint32(rate*2))
+	out = binary.LittleEndian.AppendUint16(out, 2)
+	out = binary.LittleEndian.AppendUint16(out, 16)
+	out = append(out, "data"...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(dataSize))
+	for _, s := range samples {
+		v := max(-1, min(1, s))
+		out = binary.LittleEndian.AppendUint16(out, uint16(int16(v*32767)))
+	}
+	return out
+}
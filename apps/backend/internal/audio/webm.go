@@ -0,0 +1,201 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Matroska element ids used to pull Opus packets out of WebM
+const (
+	ebmlSegment      = 0x18538067
+	ebmlCluster      = 0x1F43B675
+	ebmlTracks       = 0x1654AE6B
+	ebmlTrackEntry   = 0xAE
+	ebmlTrackNumber  = 0xD7
+	ebmlCodecID      = 0x86
+	ebmlCodecPrivate = 0x63A2
+	ebmlAudio        = 0xE1
+	ebmlChannels     = 0x9F
+	ebmlBlockGroup   = 0xA0
+	ebmlBlock        = 0xA1
+	ebmlSimpleBlock  = 0xA3
+)
+
+// ebmlMasters are entered rather than skipped. Walking them flat also copes
+// with the unknown sizes browsers write for live recordings.
+var ebmlMasters = map[uint64]bool{
+	ebmlSegment:    true,
+	ebmlCluster:    true,
+	ebmlTracks:     true,
+	ebmlTrackEntry: true,
+	ebmlAudio:      true,
+	ebmlBlockGroup: true,
+}
+
+type webmTrack struct {
+	number   uint64
+	codec    string
+	private  []byte
+	channels int
+}
+
+type webmBlock struct {
+	track  uint64
+	frames [][]byte
+}
+
+// parseWebM remuxes the first Opus track of a WebM file, as recorded by browsers
+func parseWebM(data []byte) (*opusSource, error) {
+	var tracks []*webmTrack
+	var blocks []webmBlock
+	var track *webmTrack
+
+	for pos := 0; pos < len(data); {
+		id, idLen := readVint(data[pos:], true)
+		if idLen == 0 {
+			break
+		}
+		size, sizeLen := readVint(data[pos+idLen:], false)
+		if sizeLen == 0 {
+			break
+		}
+		pos += idLen + sizeLen
+
+		if ebmlMasters[id] {
+			if id == ebmlTrackEntry {
+				track = &webmTrack{}
+				tracks = append(tracks, track)
+			}
+			continue
+		}
+		if size == vintUnknown(sizeLen) || pos+int(size) > len(data) {
+			break // Truncated recording: keep what was read
+		}
+		payload := data[pos : pos+int(size)]
+		pos += int(size)
+
+		switch id {
+		case ebmlTrackNumber, ebmlCodecID, ebmlCodecPrivate, ebmlChannels:
+			if track == nil {
+				continue
+			}
+			switch id {
+			case ebmlTrackNumber:
+				track.number = readUint(payload)
+			case ebmlCodecID:
+				track.codec = string(payload)
+			case ebmlCodecPrivate:
+				track.private = payload
+			case ebmlChannels:
+				track.channels = int(readUint(payload))
+			}
+		case ebmlSimpleBlock, ebmlBlock:
+			block, err := parseWebMBlock(payload)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, block)
+		}
+	}
+
+	var opus *webmTrack
+	for _, t := range tracks {
+		if t.codec == "A_OPUS" {
+			opus = t
+			break
+		}
+	}
+	if opus == nil {
+		return nil, fmt.Errorf("%w: WebM without an Opus track", ErrUnsupportedFormat)
+	}
+
+	src := &opusSource{head: opus.private}
+	if len(src.head) < 19 {
+		src.head = opusHead(max(opus.channels, 1))
+	}
+	for _, block := range blocks {
+		if block.track == opus.number {
+			src.packets = append(src.packets, block.frames...)
+		}
+	}
+	src.levels = opusFrames(src.packets)
+	return src, nil
+}
+
+// parseWebMBlock reads a (Simple)Block: track, timecode, flags and frames
+func parseWebMBlock(payload []byte) (webmBlock, error) {
+	track, n := readVint(payload, false)
+	if n == 0 || len(payload) < n+3 {
+		return webmBlock{}, fmt.Errorf("%w: short WebM block", ErrCorrupt)
+	}
+	flags := payload[n+2]
+	body := payload[n+3:]
+
+	switch (flags >> 1) & 0x03 {
+	case 0: // No lacing
+		return webmBlock{track: track, frames: [][]byte{body}}, nil
+	case 2: // Fixed-size lacing
+		if len(body) == 0 {
+			return webmBlock{}, fmt.Errorf("%w: empty laced block", ErrCorrupt)
+		}
+		count := int(body[0]) + 1
+		body = body[1:]
+		if len(body)%count != 0 {
+			return webmBlock{}, fmt.Errorf("%w: uneven fixed lacing", ErrCorrupt)
+		}
+		size := len(body) / count
+		block := webmBlock{track: track}
+		for i := 0; i < count; i++ {
+			block.frames = append(block.frames, body[i*size:(i+1)*size])
+		}
+		return block, nil
+	default:
+		return webmBlock{}, fmt.Errorf("%w: Xiph/EBML lacing", ErrUnsupportedFormat)
+	}
+}
+
+// readVint reads an EBML variable-length integer and its length, 0 when
+// invalid. Element ids keep their length marker, sizes don't.
+func readVint(data []byte, keepMarker bool) (uint64, int) {
+	if len(data) == 0 || data[0] == 0 {
+		return 0, 0
+	}
+	length := 1
+	for mask := byte(0x80); data[0]&mask == 0; mask >>= 1 {
+		length++
+	}
+	if len(data) < length {
+		return 0, 0
+	}
+	value := uint64(data[0])
+	if !keepMarker {
+		value &= uint64(0xFF >> length)
+	}
+	for _, b := range data[1:length] {
+		value = value<<8 | uint64(b)
+	}
+	return value, length
+}
+
+// vintUnknown is the all-ones size meaning "unknown", per size length
+func vintUnknown(length int) uint64 {
+	return 1<<(7*length) - 1
+}
+
+func readUint(data []byte) uint64 {
+	var v uint64
+	for _, b := range data {
+		v = v<<8 | uint64(b)
+	}
+	return v
+}
+
+// opusHead builds an OpusHead for streams that don't carry one
+func opusHead(channels int) []byte {
+	head := []byte("OpusHead")
+	head = append(head, 1, byte(channels))
+	head = binary.LittleEndian.AppendUint16(head, 0)
+	head = binary.LittleEndian.AppendUint32(head, 48000)
+	head = binary.LittleEndian.AppendUint16(head, 0)
+	return append(head, 0)
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
 )
 
 type Config struct {
@@ -11,6 +13,29 @@ type Config struct {
 	KolosalAPIKey  string
 	KolosalBaseURL string
 	GeminiAPIKey   string
+	TTSAPIKey      string // Optional, enables voice note replies
+
+	ConfidencePolicyFile  string // Optional JSON confirm-before-commit policy
+	PromptExperimentsFile string // Optional JSON prompt variant rollout
+	LLMUsagePolicyFile    string // Optional JSON model prices and plan quotas
+
+	// Optional OpenAI-compatible provider and LLM fallback order
+	OpenAIAPIKey  string
+	OpenAIBaseURL string
+	OpenAIModel   string
+	LLMProviders  []string
+
+	// Optional self-hosted whisper server and speech-to-text routing
+	WhisperURL   string
+	WhisperKey   string
+	WhisperModel string
+	STTProviders []string
+	STTCosts     map[string]float64  // USD per audio minute by transcriber
+	STTPrefer    map[string][]string // Transcribers tried first per language
+
+	// Local object store for product photos, served at /media
+	MediaDir     string
+	MediaBaseURL string
 }
 
 func Load() *Config {
@@ -21,7 +46,54 @@ func Load() *Config {
 		KolosalAPIKey:  getEnv("KOLOSAL_API_KEY", ""),
 		KolosalBaseURL: getEnv("KOLOSAL_BASE_URL", "https://api.kolosal.ai/v1"),
 		GeminiAPIKey:   getEnv("GEMINI_API_KEY", ""),
+		TTSAPIKey:      getEnv("GOOGLE_TTS_API_KEY", ""),
+		OpenAIAPIKey:   getEnv("OPENAI_API_KEY", ""),
+		OpenAIBaseURL:  getEnv("OPENAI_BASE_URL", "https://api.openai.com/v1"),
+		OpenAIModel:    getEnv("OPENAI_MODEL", "gpt-4o-mini"),
+		LLMProviders:   getEnvList("LLM_PROVIDERS", "kolosal,gemini,openai"),
+
+		ConfidencePolicyFile:  getEnv("CONFIDENCE_POLICY_FILE", ""),
+		PromptExperimentsFile: getEnv("PROMPT_EXPERIMENTS_FILE", ""),
+		LLMUsagePolicyFile:    getEnv("LLM_USAGE_POLICY_FILE", ""),
+
+		WhisperURL:   getEnv("WHISPER_URL", ""),
+		WhisperKey:   getEnv("WHISPER_API_KEY", ""),
+		WhisperModel: getEnv("WHISPER_MODEL", "whisper-1"),
+		STTProviders: getEnvList("STT_PROVIDERS", "whisper,gemini"),
+		STTCosts:     getEnvCosts("STT_COSTS"),
+		STTPrefer: map[string][]string{
+			"jv": getEnvList("STT_PREFER_JV", "gemini"),
+			"su": getEnvList("STT_PREFER_SU", "gemini"),
+		},
+
+		MediaDir:     getEnv("MEDIA_DIR", "data/media"),
+		MediaBaseURL: getEnv("MEDIA_BASE_URL", "http://localhost:8080/media"),
+	}
+}
+
+// getEnvCosts reads "name=cost" pairs, e.g. "whisper=0.0002,gemini=0.0015"
+func getEnvCosts(key string) map[string]float64 {
+	costs := make(map[string]float64)
+	for _, item := range getEnvList(key, "") {
+		name, value, _ := strings.Cut(item, "=")
+		cost, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			continue
+		}
+		costs[strings.TrimSpace(name)] = cost
+	}
+	return costs
+}
+
+// getEnvList reads a comma-separated list
+func getEnvList(key, fallback string) []string {
+	var list []string
+	for _, item := range strings.Split(getEnv(key, fallback), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
 	}
+	return list
 }
 
 func getEnv(key, fallback string) string {
@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// ExpenseBudget is a user's monthly budget for one expense category
+type ExpenseBudget struct {
+	ID            string  `json:"id,omitempty"`
+	UserID        string  `json:"user_id"`
+	Category      string  `json:"category"` // agents.ExpenseCategory
+	MonthlyAmount float64 `json:"monthly_amount"`
+	AlertMonth    string  `json:"alert_month,omitempty"` // YYYY-MM of AlertLevel
+	AlertLevel    int     `json:"alert_level"`           // Highest alert sent in AlertMonth: 0, 80 or 100
+	CreatedAt     string  `json:"created_at,omitempty"`
+	UpdatedAt     string  `json:"updated_at,omitempty"`
+}
+
+// CreateExpenseBudget inserts a budget
+func (s *SupabaseClient) CreateExpenseBudget(ctx context.Context, budget *ExpenseBudget) error {
+	var result []ExpenseBudget
+	if err := s.request(ctx, "POST", "expense_budgets", budget, &result); err != nil {
+		return err
+	}
+	if len(result) > 0 {
+		budget.ID = result[0].ID
+		budget.CreatedAt = result[0].CreatedAt
+	}
+	return nil
+}
+
+// UpdateExpenseBudget patches a budget
+func (s *SupabaseClient) UpdateExpenseBudget(ctx context.Context, id string, updates map[string]any) error {
+	endpoint := fmt.Sprintf("expense_budgets?id=eq.%s", id)
+	return s.request(ctx, "PATCH", endpoint, updates, nil)
+}
+
+// GetExpenseBudget gets the budget of one category, or nil if none is set
+func (s *SupabaseClient) GetExpenseBudget(ctx context.Context, userID, category string) (*ExpenseBudget, error) {
+	var budgets []ExpenseBudget
+	endpoint := fmt.Sprintf("expense_budgets?user_id=eq.%s&category=eq.%s&limit=1", userID, category)
+	if err := s.request(ctx, "GET", endpoint, nil, &budgets); err != nil {
+		return nil, err
+	}
+	if len(budgets) == 0 {
+		return nil, nil
+	}
+	return &budgets[0], nil
+}
+
+// GetExpenseBudgets gets a user's budgets
+func (s *SupabaseClient) GetExpenseBudgets(ctx context.Context, userID string) ([]ExpenseBudget, error) {
+	var budgets []ExpenseBudget
+	endpoint := fmt.Sprintf("expense_budgets?user_id=eq.%s&order=category.asc", userID)
+	err := s.request(ctx, "GET", endpoint, nil, &budgets)
+	return budgets, err
+}
+
+// GetAllExpenseBudgets gets every user's budgets, grouped by user, for the
+// month-end summaries
+func (s *SupabaseClient) GetAllExpenseBudgets(ctx context.Context) ([]ExpenseBudget, error) {
+	return getPages[ExpenseBudget](ctx, s, "expense_budgets?order=user_id.asc,category.asc")
+}
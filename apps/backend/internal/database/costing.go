@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// InventoryLayer is stock brought in by one purchase at one unit cost.
+// Sales consume layers oldest first (FIFO).
+type InventoryLayer struct {
+	ID            string  `json:"id,omitempty"`
+	UserID        string  `json:"user_id"`
+	ProductName   string  `json:"product_name"` // Lowercase, trimmed
+	TransactionID string  `json:"transaction_id,omitempty"`
+	QtyReceived   float64 `json:"qty_received"`
+	QtyRemaining  float64 `json:"qty_remaining"`
+	UnitCost      float64 `json:"unit_cost"`
+	ReceivedAt    string  `json:"received_at,omitempty"`
+	VoidedAt      string  `json:"voided_at,omitempty"`
+	CreatedAt     string  `json:"created_at,omitempty"`
+}
+
+// InventoryLayerUsage is the qty a sale took from a layer
+type InventoryLayerUsage struct {
+	ID            string          `json:"id,omitempty"`
+	UserID        string          `json:"user_id"`
+	LayerID       string          `json:"layer_id"`
+	TransactionID string          `json:"transaction_id"`
+	Qty           float64         `json:"qty"`
+	UnitCost      float64         `json:"unit_cost"`
+	CreatedAt     string          `json:"created_at,omitempty"`
+	Layer         *InventoryLayer `json:"inventory_layers,omitempty"` // Embedded by GetLayerUsage
+}
+
+// CreateInventoryLayer inserts a cost layer
+func (s *SupabaseClient) CreateInventoryLayer(ctx context.Context, layer *InventoryLayer) error {
+	var result []InventoryLayer
+	if err := s.request(ctx, "POST", "inventory_layers", layer, &result); err != nil {
+		return err
+	}
+	if len(result) > 0 {
+		layer.ID = result[0].ID
+		layer.CreatedAt = result[0].CreatedAt
+	}
+	return nil
+}
+
+// UpdateInventoryLayer patches a cost layer
+func (s *SupabaseClient) UpdateInventoryLayer(ctx context.Context, id string, updates map[string]any) error {
+	endpoint := fmt.Sprintf("inventory_layers?id=eq.%s", id)
+	return s.request(ctx, "PATCH", endpoint, updates, nil)
+}
+
+// GetOpenInventoryLayers gets the layers of a product with stock left, oldest first
+func (s *SupabaseClient) GetOpenInventoryLayers(ctx context.Context, userID, productName string) ([]InventoryLayer, error) {
+	var layers []InventoryLayer
+	endpoint := fmt.Sprintf("inventory_layers?user_id=eq.%s&product_name=eq.%s&qty_remaining=gt.0&voided_at=is.null&order=received_at.asc,created_at.asc",
+		userID, url.QueryEscape(productName))
+	err := s.request(ctx, "GET", endpoint, nil, &layers)
+	return layers, err
+}
+
+// GetLatestInventoryLayer gets the most recent layer of a product, used up
+// or not, or nil if it was never purchased
+func (s *SupabaseClient) GetLatestInventoryLayer(ctx context.Context, userID, productName string) (*InventoryLayer, error) {
+	var layers []InventoryLayer
+	endpoint := fmt.Sprintf("inventory_layers?user_id=eq.%s&product_name=eq.%s&voided_at=is.null&order=received_at.desc,created_at.desc&limit=1",
+		userID, url.QueryEscape(productName))
+	if err := s.request(ctx, "GET", endpoint, nil, &layers); err != nil {
+		return nil, err
+	}
+	if len(layers) == 0 {
+		return nil, nil
+	}
+	return &layers[0], nil
+}
+
+// GetInventoryLayerByTransaction gets the layer a purchase added, or nil
+func (s *SupabaseClient) GetInventoryLayerByTransaction(ctx context.Context, transactionID string) (*InventoryLayer, error) {
+	var layers []InventoryLayer
+	endpoint := fmt.Sprintf("inventory_layers?transaction_id=eq.%s&voided_at=is.null&limit=1", transactionID)
+	if err := s.request(ctx, "GET", endpoint, nil, &layers); err != nil {
+		return nil, err
+	}
+	if len(layers) == 0 {
+		return nil, nil
+	}
+	return &layers[0], nil
+}
+
+// CreateLayerUsage records the layers a sale consumed
+func (s *SupabaseClient) CreateLayerUsage(ctx context.Context, usage []InventoryLayerUsage) error {
+	if len(usage) == 0 {
+		return nil
+	}
+	return s.request(ctx, "POST", "inventory_layer_usage", usage, nil)
+}
+
+// GetLayerUsage gets what a sale consumed, with each layer embedded
+func (s *SupabaseClient) GetLayerUsage(ctx context.Context, transactionID string) ([]InventoryLayerUsage, error) {
+	var usage []InventoryLayerUsage
+	endpoint := fmt.Sprintf("inventory_layer_usage?select=*,inventory_layers(*)&transaction_id=eq.%s", transactionID)
+	err := s.request(ctx, "GET", endpoint, nil, &usage)
+	return usage, err
+}
+
+// DeleteLayerUsage forgets what a sale consumed, once it is put back
+func (s *SupabaseClient) DeleteLayerUsage(ctx context.Context, transactionID string) error {
+	endpoint := fmt.Sprintf("inventory_layer_usage?transaction_id=eq.%s", transactionID)
+	return s.request(ctx, "DELETE", endpoint, nil, nil)
+}
+
+// GetLayerUsageByLayer gets what sales took from a layer
+func (s *SupabaseClient) GetLayerUsageByLayer(ctx context.Context, layerID string) ([]InventoryLayerUsage, error) {
+	var usage []InventoryLayerUsage
+	endpoint := fmt.Sprintf("inventory_layer_usage?layer_id=eq.%s", layerID)
+	err := s.request(ctx, "GET", endpoint, nil, &usage)
+	return usage, err
+}
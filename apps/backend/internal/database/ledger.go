@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// JournalEntry is one balanced double-entry posting
+type JournalEntry struct {
+	ID            string        `json:"id,omitempty"`
+	UserID        string        `json:"user_id"`
+	EntryDate     string        `json:"entry_date"` // YYYY-MM-DD
+	Description   string        `json:"description,omitempty"`
+	SourceType    string        `json:"source_type"` // SALE, PURCHASE, EXPENSE, PAYMENT, ADJUSTMENT, REVERSAL
+	TransactionID string        `json:"transaction_id,omitempty"`
+	PaymentID     string        `json:"payment_id,omitempty"`
+	ReversesID    string        `json:"reverses_id,omitempty"`
+	ReversedAt    string        `json:"reversed_at,omitempty"`
+	Lines         []JournalLine `json:"lines"`
+	CreatedAt     string        `json:"created_at,omitempty"`
+}
+
+// JournalLine debits or credits one account
+type JournalLine struct {
+	Account string  `json:"account"` // Chart of accounts code, e.g. 1-1100
+	Debit   float64 `json:"debit,omitempty"`
+	Credit  float64 `json:"credit,omitempty"`
+}
+
+// CreateJournalEntry inserts a journal entry; the database rejects unbalanced lines
+func (s *SupabaseClient) CreateJournalEntry(ctx context.Context, entry *JournalEntry) error {
+	var result []JournalEntry
+	if err := s.request(ctx, "POST", "journal_entries", entry, &result); err != nil {
+		return err
+	}
+	if len(result) > 0 {
+		entry.ID = result[0].ID
+		entry.CreatedAt = result[0].CreatedAt
+	}
+	return nil
+}
+
+// GetJournalEntriesByTransaction gets the entries of a transaction that are not reversed yet
+func (s *SupabaseClient) GetJournalEntriesByTransaction(ctx context.Context, transactionID string) ([]JournalEntry, error) {
+	var entries []JournalEntry
+	endpoint := fmt.Sprintf("journal_entries?transaction_id=eq.%s&reversed_at=is.null&reverses_id=is.null&order=created_at.asc",
+		transactionID)
+	err := s.request(ctx, "GET", endpoint, nil, &entries)
+	return entries, err
+}
+
+// MarkJournalEntryReversed records that an entry has been reversed
+func (s *SupabaseClient) MarkJournalEntryReversed(ctx context.Context, id string) error {
+	endpoint := fmt.Sprintf("journal_entries?id=eq.%s", id)
+	return s.request(ctx, "PATCH", endpoint, map[string]any{
+		"reversed_at": time.Now().UTC().Format(time.RFC3339),
+	}, nil)
+}
+
+// GetJournalEntries gets a user's entries dated from startDate to endDate
+// (YYYY-MM-DD, inclusive), a page at a time. An empty startDate starts from
+// the first entry.
+func (s *SupabaseClient) GetJournalEntries(ctx context.Context, userID, startDate, endDate string) ([]JournalEntry, error) {
+	endpoint := fmt.Sprintf("journal_entries?user_id=eq.%s&entry_date=lte.%s", userID, endDate)
+	if startDate != "" {
+		endpoint += "&entry_date=gte." + startDate
+	}
+	endpoint += "&order=entry_date.asc,created_at.asc,id.asc"
+	return getPages[JournalEntry](ctx, s, endpoint)
+}
@@ -0,0 +1,44 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// UpdateUserLocation sets the business coordinates of a user
+func (s *SupabaseClient) UpdateUserLocation(ctx context.Context, userID string, lat, lon float64) error {
+	update := map[string]any{
+		"latitude":            lat,
+		"longitude":           lon,
+		"location_updated_at": time.Now().Format(time.RFC3339),
+	}
+	endpoint := fmt.Sprintf("users?id=eq.%s", userID)
+	return s.request(ctx, "PATCH", endpoint, update, nil)
+}
+
+// GetUserLocation returns the business coordinates of a user, ok is false if not set
+func (s *SupabaseClient) GetUserLocation(ctx context.Context, userID string) (lat, lon float64, ok bool, err error) {
+	var users []User
+	endpoint := fmt.Sprintf("users?id=eq.%s&select=id,latitude,longitude", userID)
+	if err := s.request(ctx, "GET", endpoint, nil, &users); err != nil {
+		return 0, 0, false, err
+	}
+	if len(users) == 0 || (users[0].Latitude == 0 && users[0].Longitude == 0) {
+		return 0, 0, false, nil
+	}
+	return users[0].Latitude, users[0].Longitude, true, nil
+}
+
+// UpdateContactLocation sets the coordinates (and optional address) of a contact
+func (s *SupabaseClient) UpdateContactLocation(ctx context.Context, contactID string, lat, lon float64, address string) error {
+	update := map[string]any{
+		"latitude":   lat,
+		"longitude":  lon,
+		"updated_at": time.Now().Format(time.RFC3339),
+	}
+	if address != "" {
+		update["address"] = address
+	}
+	return s.UpdateContact(ctx, contactID, update)
+}
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// Statuses of a recurring rule
+const (
+	RecurringActive = "ACTIVE"
+	RecurringPaused = "PAUSED"
+)
+
+// RecurringRule posts a transaction on a schedule, e.g. rent on the 1st of
+// every month. Rules that are not auto-posted ask the user on the due date.
+type RecurringRule struct {
+	ID           string  `json:"id,omitempty"`
+	UserID       string  `json:"user_id"`
+	Type         string  `json:"type"` // SALE, PURCHASE, EXPENSE
+	ProductName  string  `json:"product_name"`
+	Qty          float64 `json:"qty"`
+	PricePerUnit float64 `json:"price_per_unit"`
+	Schedule     string  `json:"schedule"` // Cron in the user's time zone, see package schedule
+	AutoPost     bool    `json:"auto_post"`
+	Status       string  `json:"status"`
+	NextRunAt    string  `json:"next_run_at"`
+	PendingRunAt string  `json:"pending_run_at,omitempty"` // Due run waiting for the user to confirm
+	LastRunAt    string  `json:"last_run_at,omitempty"`
+	CreatedAt    string  `json:"created_at,omitempty"`
+	UpdatedAt    string  `json:"updated_at,omitempty"`
+}
+
+// CreateRecurringRule inserts a rule
+func (s *SupabaseClient) CreateRecurringRule(ctx context.Context, rule *RecurringRule) error {
+	var result []RecurringRule
+	if err := s.request(ctx, "POST", "recurring_rules", rule, &result); err != nil {
+		return err
+	}
+	if len(result) > 0 {
+		rule.ID = result[0].ID
+		rule.CreatedAt = result[0].CreatedAt
+	}
+	return nil
+}
+
+// UpdateRecurringRule patches a rule
+func (s *SupabaseClient) UpdateRecurringRule(ctx context.Context, id string, updates map[string]any) error {
+	endpoint := fmt.Sprintf("recurring_rules?id=eq.%s", id)
+	return s.request(ctx, "PATCH", endpoint, updates, nil)
+}
+
+// DeleteRecurringRule deletes a rule
+func (s *SupabaseClient) DeleteRecurringRule(ctx context.Context, id string) error {
+	endpoint := fmt.Sprintf("recurring_rules?id=eq.%s", id)
+	return s.request(ctx, "DELETE", endpoint, nil, nil)
+}
+
+// GetRecurringRule gets one of a user's rules, or nil if there is none
+func (s *SupabaseClient) GetRecurringRule(ctx context.Context, userID, id string) (*RecurringRule, error) {
+	var rules []RecurringRule
+	endpoint := fmt.Sprintf("recurring_rules?id=eq.%s&user_id=eq.%s&limit=1", id, userID)
+	if err := s.request(ctx, "GET", endpoint, nil, &rules); err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	return &rules[0], nil
+}
+
+// GetRecurringRules gets a user's rules
+func (s *SupabaseClient) GetRecurringRules(ctx context.Context, userID string) ([]RecurringRule, error) {
+	var rules []RecurringRule
+	endpoint := fmt.Sprintf("recurring_rules?user_id=eq.%s&order=created_at.asc", userID)
+	err := s.request(ctx, "GET", endpoint, nil, &rules)
+	return rules, err
+}
+
+// GetDueRecurringRules gets the active rules of every user due at or before
+// now (UTC, 2006-01-02T15:04:05Z)
+func (s *SupabaseClient) GetDueRecurringRules(ctx context.Context, now string) ([]RecurringRule, error) {
+	var rules []RecurringRule
+	endpoint := fmt.Sprintf("recurring_rules?status=eq.%s&next_run_at=lte.%s&order=next_run_at.asc",
+		RecurringActive, now)
+	err := s.request(ctx, "GET", endpoint, nil, &rules)
+	return rules, err
+}
+
+// GetAwaitingRecurringRules gets a user's rules with a run waiting for
+// confirmation
+func (s *SupabaseClient) GetAwaitingRecurringRules(ctx context.Context, userID string) ([]RecurringRule, error) {
+	var rules []RecurringRule
+	endpoint := fmt.Sprintf("recurring_rules?user_id=eq.%s&pending_run_at=not.is.null&order=pending_run_at.asc", userID)
+	err := s.request(ctx, "GET", endpoint, nil, &rules)
+	return rules, err
+}
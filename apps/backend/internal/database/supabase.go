@@ -8,6 +8,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 )
@@ -75,19 +76,77 @@ func (s *SupabaseClient) request(ctx context.Context, method, endpoint string, b
 	return nil
 }
 
+// pageSize is the number of rows fetched per request. It is at or below
+// PostgREST's max-rows (1000), which would cut a longer result short.
+const pageSize = 1000
+
+// getPages gets every row of a GET a page at a time. endpoint must order
+// the rows by a unique key so pages do not overlap.
+func getPages[T any](ctx context.Context, s *SupabaseClient, endpoint string) ([]T, error) {
+	var rows []T
+	for offset := 0; ; offset += pageSize {
+		var page []T
+		if err := s.request(ctx, "GET", fmt.Sprintf("%s&limit=%d&offset=%d", endpoint, pageSize, offset), nil, &page); err != nil {
+			return nil, err
+		}
+		rows = append(rows, page...)
+		if len(page) < pageSize {
+			return rows, nil
+		}
+	}
+}
+
 // Transaction types
 type Transaction struct {
-	ID           string  `json:"id,omitempty"`
-	UserID       string  `json:"user_id"`
-	Type         string  `json:"type"` // SALE, PURCHASE, EXPENSE
-	ProductName  string  `json:"product_name,omitempty"`
-	Qty          float64 `json:"qty,omitempty"`
-	PricePerUnit float64 `json:"price_per_unit,omitempty"`
-	TotalAmount  float64 `json:"total_amount,omitempty"`
-	RawVoiceText string  `json:"raw_voice_text,omitempty"`
-	CreatedAt    string  `json:"created_at,omitempty"`
+	ID              string  `json:"id,omitempty"`
+	UserID          string  `json:"user_id"`
+	Type            string  `json:"type"` // SALE, PURCHASE, EXPENSE, LOAN
+	ProductName     string  `json:"product_name,omitempty"`
+	Qty             float64 `json:"qty,omitempty"`
+	PricePerUnit    float64 `json:"price_per_unit,omitempty"`
+	TotalAmount     float64 `json:"total_amount,omitempty"`
+	RawVoiceText    string  `json:"raw_voice_text,omitempty"`
+	IdempotencyKey  string  `json:"idempotency_key,omitempty"`  // Source message id, unique per user
+	COGS            float64 `json:"cogs,omitempty"`             // Cost of goods sold of a sale, 0 when unknown
+	ExpenseCategory string  `json:"expense_category,omitempty"` // Category an expense is budgeted under
+	PPNAmount       float64 `json:"ppn_amount,omitempty"`       // PPN included in TotalAmount of a PKP's sale
+	CreatedAt       string  `json:"created_at,omitempty"`
+
+	// Cancelled and corrected rows are voided, never deleted or overwritten
+	VoidedAt   string `json:"voided_at,omitempty"`
+	VoidReason string `json:"void_reason,omitempty"`
+	ReplacesID string `json:"replaces_id,omitempty"` // Voided transaction a correction replaces
+
+	// Sales, expenses and loans on credit
+	ContactID        string   `json:"contact_id,omitempty"`
+	DueDate          string   `json:"due_date,omitempty"` // YYYY-MM-DD
+	AmountPaid       float64  `json:"amount_paid,omitempty"`
+	SettlementStatus string   `json:"settlement_status,omitempty"` // OPEN, PARTIAL, PAID; empty when paid on the spot
+	Contact          *Contact `json:"contacts,omitempty"`          // Embedded by GetDueCredit (select=*,contacts(*))
+	Seller           *User    `json:"users,omitempty"`             // Embedded by GetDueCredit (select=*,users(*))
+}
+
+// Settlement states of a transaction on credit
+const (
+	SettlementOpen    = "OPEN"
+	SettlementPartial = "PARTIAL"
+	SettlementPaid    = "PAID"
+)
+
+// Outstanding returns what is still owed on a transaction on credit
+func (t *Transaction) Outstanding() float64 {
+	if t.SettlementStatus != SettlementOpen && t.SettlementStatus != SettlementPartial {
+		return 0
+	}
+	return t.TotalAmount - t.AmountPaid
 }
 
+// Reasons a transaction was voided
+const (
+	VoidCancelled = "CANCELLED"
+	VoidCorrected = "CORRECTED"
+)
+
 // Inventory types
 type Inventory struct {
 	ID           string  `json:"id,omitempty"`
@@ -98,6 +157,7 @@ type Inventory struct {
 	MinSellPrice float64 `json:"min_sell_price,omitempty"`
 	MaxBuyPrice  float64 `json:"max_buy_price,omitempty"`
 	Description  string  `json:"description,omitempty"`
+	Seller       *User   `json:"users,omitempty"` // Embedded by FindSellers (select=*,users(*))
 }
 
 // NegotiationLog types
@@ -116,14 +176,16 @@ type NegotiationLog struct {
 
 // User types
 type User struct {
-	ID               string `json:"id,omitempty"`
-	Email            string `json:"email,omitempty"`
-	Phone            string `json:"phone,omitempty"`
-	Name             string `json:"name,omitempty"`
-	Role             string `json:"role,omitempty"`
-	PreferredDialect string `json:"preferred_dialect,omitempty"`
-	PasswordHash     string `json:"password_hash,omitempty"`
-	CreatedAt        string `json:"created_at,omitempty"`
+	ID               string  `json:"id,omitempty"`
+	Email            string  `json:"email,omitempty"`
+	Phone            string  `json:"phone,omitempty"`
+	Name             string  `json:"name,omitempty"`
+	Role             string  `json:"role,omitempty"`
+	PreferredDialect string  `json:"preferred_dialect,omitempty"`
+	PasswordHash     string  `json:"password_hash,omitempty"`
+	Latitude         float64 `json:"latitude,omitempty"`  // Business location
+	Longitude        float64 `json:"longitude,omitempty"` // Business location
+	CreatedAt        string  `json:"created_at,omitempty"`
 }
 
 // CreateTransaction inserts a new transaction
@@ -140,6 +202,58 @@ func (s *SupabaseClient) CreateTransaction(ctx context.Context, tx *Transaction)
 	return nil
 }
 
+// UpdateTransaction patches a transaction
+func (s *SupabaseClient) UpdateTransaction(ctx context.Context, id string, updates map[string]any) error {
+	endpoint := fmt.Sprintf("transactions?id=eq.%s", id)
+	return s.request(ctx, "PATCH", endpoint, updates, nil)
+}
+
+// VoidTransaction marks a transaction as cancelled or corrected; reports
+// skip it. voided is false when it was already void, so of two concurrent
+// voids only one goes on to reverse it.
+func (s *SupabaseClient) VoidTransaction(ctx context.Context, id, reason string) (voided bool, err error) {
+	var result []Transaction
+	endpoint := fmt.Sprintf("transactions?id=eq.%s&voided_at=is.null", id)
+	err = s.request(ctx, "PATCH", endpoint, map[string]any{
+		"voided_at":   time.Now().UTC().Format(time.RFC3339),
+		"void_reason": reason,
+	}, &result)
+	return len(result) > 0, err
+}
+
+// RestoreTransaction undoes VoidTransaction
+func (s *SupabaseClient) RestoreTransaction(ctx context.Context, id string) error {
+	return s.UpdateTransaction(ctx, id, map[string]any{"voided_at": nil, "void_reason": nil})
+}
+
+// GetTransactionByID returns one of the user's transactions, or nil
+func (s *SupabaseClient) GetTransactionByID(ctx context.Context, userID, id string) (*Transaction, error) {
+	var transactions []Transaction
+	endpoint := fmt.Sprintf("transactions?id=eq.%s&user_id=eq.%s&limit=1", id, userID)
+	err := s.request(ctx, "GET", endpoint, nil, &transactions)
+	if err != nil {
+		return nil, err
+	}
+	if len(transactions) == 0 {
+		return nil, nil
+	}
+	return &transactions[0], nil
+}
+
+// GetTransactionByIdempotencyKey returns the transaction created from a source message, or nil
+func (s *SupabaseClient) GetTransactionByIdempotencyKey(ctx context.Context, userID, key string) (*Transaction, error) {
+	var transactions []Transaction
+	endpoint := fmt.Sprintf("transactions?user_id=eq.%s&idempotency_key=eq.%s&limit=1", userID, url.QueryEscape(key))
+	err := s.request(ctx, "GET", endpoint, nil, &transactions)
+	if err != nil {
+		return nil, err
+	}
+	if len(transactions) == 0 {
+		return nil, nil
+	}
+	return &transactions[0], nil
+}
+
 // GetUserByPhone finds user by phone number from auth metadata
 func (s *SupabaseClient) GetUserByPhone(ctx context.Context, phone string) (*User, error) {
 	// Try to get from cache first
@@ -282,6 +396,8 @@ type Contact struct {
 	Email             string  `json:"email,omitempty"`
 	Address           string  `json:"address,omitempty"`
 	City              string  `json:"city,omitempty"`
+	Latitude          float64 `json:"latitude,omitempty"`
+	Longitude         float64 `json:"longitude,omitempty"`
 	Notes             string  `json:"notes,omitempty"`
 	Rating            float64 `json:"rating,omitempty"`
 	TotalTransactions int     `json:"total_transactions,omitempty"`
@@ -330,6 +446,7 @@ type UserPreferences struct {
 	LowStockThreshold    int      `json:"low_stock_threshold,omitempty"`
 	ReportFrequency      string   `json:"report_frequency,omitempty"`
 	Theme                string   `json:"theme,omitempty"`
+	VoiceReplies         *bool    `json:"voice_replies,omitempty"` // nil leaves the stored choice alone
 	CreatedAt            string   `json:"created_at,omitempty"`
 	UpdatedAt            string   `json:"updated_at,omitempty"`
 }
@@ -348,6 +465,9 @@ type NotificationQueue struct {
 	ErrorMessage string `json:"error_message,omitempty"`
 	RetryCount   int    `json:"retry_count,omitempty"`
 	CreatedAt    string `json:"created_at,omitempty"`
+
+	RecipientPhone string `json:"recipient_phone,omitempty"` // Sent here instead of to the user, e.g. customer reminders
+	ReferenceID    string `json:"reference_id,omitempty"`    // Record the notification is about
 }
 
 // CreateProductCatalog creates a new product in catalog
@@ -433,6 +553,14 @@ func (s *SupabaseClient) GetPaymentsByTransaction(ctx context.Context, transacti
 	return payments, err
 }
 
+// GetPaymentsByReference gets payments recorded with a reference number
+func (s *SupabaseClient) GetPaymentsByReference(ctx context.Context, reference string) ([]Payment, error) {
+	var payments []Payment
+	endpoint := fmt.Sprintf("payments?reference_number=eq.%s", url.QueryEscape(reference))
+	err := s.request(ctx, "GET", endpoint, nil, &payments)
+	return payments, err
+}
+
 // UpdatePayment updates a payment record
 func (s *SupabaseClient) UpdatePayment(ctx context.Context, id string, updates map[string]any) error {
 	endpoint := fmt.Sprintf("payments?id=eq.%s", id)
@@ -520,6 +648,16 @@ func (s *SupabaseClient) GetPendingNotifications(ctx context.Context, limit int)
 	return notifs, err
 }
 
+// HasNotificationSince reports whether a notification of a type about a
+// record was queued at or after since (RFC3339)
+func (s *SupabaseClient) HasNotificationSince(ctx context.Context, referenceID, notifType, since string) (bool, error) {
+	var notifs []NotificationQueue
+	endpoint := fmt.Sprintf("notification_queue?reference_id=eq.%s&type=eq.%s&created_at=gte.%s&select=id&limit=1",
+		referenceID, notifType, url.QueryEscape(since))
+	err := s.request(ctx, "GET", endpoint, nil, &notifs)
+	return len(notifs) > 0, err
+}
+
 // UpdateNotification updates a notification status
 func (s *SupabaseClient) UpdateNotification(ctx context.Context, id string, updates map[string]any) error {
 	endpoint := fmt.Sprintf("notification_queue?id=eq.%s", id)
@@ -550,6 +688,7 @@ type Order struct {
 	Status          string  `json:"status"` // PENDING, CONFIRMED, PROCESSING, SHIPPED, DELIVERED, CANCELLED, REFUNDED
 	Subtotal        float64 `json:"subtotal"`
 	DeliveryFee     float64 `json:"delivery_fee"`
+	PPNAmount       float64 `json:"ppn_amount,omitempty"` // Included in the subtotal of sellers who are PKP
 	TotalAmount     float64 `json:"total_amount"`
 	DeliveryAddress string  `json:"delivery_address,omitempty"`
 	DeliveryNotes   string  `json:"delivery_notes,omitempty"`
@@ -630,7 +769,7 @@ func (s *SupabaseClient) GetTransactionsByDate(ctx context.Context, userID, date
 	// Format: 2006-01-02
 	startDate := date + "T00:00:00Z"
 	endDate := date + "T23:59:59Z"
-	endpoint := fmt.Sprintf("transactions?user_id=eq.%s&created_at=gte.%s&created_at=lte.%s&order=created_at.desc",
+	endpoint := fmt.Sprintf("transactions?user_id=eq.%s&voided_at=is.null&created_at=gte.%s&created_at=lte.%s&order=created_at.desc",
 		userID, startDate, endDate)
 	err := s.request(ctx, "GET", endpoint, nil, &transactions)
 	return transactions, err
@@ -639,7 +778,7 @@ func (s *SupabaseClient) GetTransactionsByDate(ctx context.Context, userID, date
 // GetRecentTransactions gets recent transactions for a user
 func (s *SupabaseClient) GetRecentTransactions(ctx context.Context, userID string, limit int) ([]Transaction, error) {
 	var transactions []Transaction
-	endpoint := fmt.Sprintf("transactions?user_id=eq.%s&order=created_at.desc&limit=%d", userID, limit)
+	endpoint := fmt.Sprintf("transactions?user_id=eq.%s&voided_at=is.null&order=created_at.desc&limit=%d", userID, limit)
 	err := s.request(ctx, "GET", endpoint, nil, &transactions)
 	return transactions, err
 }
@@ -657,20 +796,70 @@ func (s *SupabaseClient) GetInventory(ctx context.Context, userID string) ([]Inv
 	return s.GetInventoryByUser(ctx, userID)
 }
 
-// GetTransactionsByDateRange gets transactions within a date range
+// GetTransactionsByDateRange gets transactions within a date range, a page
+// at a time
 func (s *SupabaseClient) GetTransactionsByDateRange(ctx context.Context, userID, startDate, endDate string) ([]Transaction, error) {
-	var transactions []Transaction
-	endpoint := fmt.Sprintf("transactions?user_id=eq.%s&created_at=gte.%s&created_at=lte.%s&order=created_at.desc",
+	endpoint := fmt.Sprintf("transactions?user_id=eq.%s&voided_at=is.null&created_at=gte.%s&created_at=lte.%s&order=created_at.desc,id.desc",
 		userID, startDate, endDate)
-	err := s.request(ctx, "GET", endpoint, nil, &transactions)
-	return transactions, err
+	return getPages[Transaction](ctx, s, endpoint)
 }
 
 // GetTransactionsByProduct gets transactions for a specific product
 func (s *SupabaseClient) GetTransactionsByProduct(ctx context.Context, userID, productName, startDate, endDate string) ([]Transaction, error) {
 	var transactions []Transaction
-	endpoint := fmt.Sprintf("transactions?user_id=eq.%s&product_name=ilike.*%s*&created_at=gte.%s&created_at=lte.%s&order=created_at.desc",
+	endpoint := fmt.Sprintf("transactions?user_id=eq.%s&voided_at=is.null&product_name=ilike.*%s*&created_at=gte.%s&created_at=lte.%s&order=created_at.desc",
 		userID, productName, startDate, endDate)
 	err := s.request(ctx, "GET", endpoint, nil, &transactions)
 	return transactions, err
 }
+
+// GetOpenCredit gets unsettled transactions on credit of the given types,
+// oldest first. An empty contactID returns every contact's.
+func (s *SupabaseClient) GetOpenCredit(ctx context.Context, userID string, txTypes []string, contactID string) ([]Transaction, error) {
+	var transactions []Transaction
+	endpoint := fmt.Sprintf("transactions?user_id=eq.%s&type=in.(%s)&settlement_status=in.(OPEN,PARTIAL)&voided_at=is.null",
+		userID, strings.Join(txTypes, ","))
+	if contactID != "" {
+		endpoint += fmt.Sprintf("&contact_id=eq.%s", contactID)
+	}
+	endpoint += "&order=created_at.asc"
+	err := s.request(ctx, "GET", endpoint, nil, &transactions)
+	return transactions, err
+}
+
+// GetDueCredit gets every user's unsettled credit due on or before a date
+// (YYYY-MM-DD), with the contact and seller embedded
+func (s *SupabaseClient) GetDueCredit(ctx context.Context, date string) ([]Transaction, error) {
+	var transactions []Transaction
+	endpoint := fmt.Sprintf("transactions?select=*,contacts(*),users(*)&settlement_status=in.(OPEN,PARTIAL)&voided_at=is.null&due_date=lte.%s&order=due_date.asc",
+		date)
+	err := s.request(ctx, "GET", endpoint, nil, &transactions)
+	return transactions, err
+}
+
+// LLMUsage is one metered LLM call
+type LLMUsage struct {
+	ID           string  `json:"id,omitempty"`
+	Tenant       string  `json:"tenant,omitempty"`
+	Feature      string  `json:"feature"`
+	Provider     string  `json:"provider,omitempty"`
+	Model        string  `json:"model,omitempty"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	Estimated    bool    `json:"estimated"`
+	LatencyMs    int64   `json:"latency_ms"`
+	CostUSD      float64 `json:"cost_usd"`
+	Outcome      string  `json:"outcome,omitempty"`
+	CreatedAt    string  `json:"created_at,omitempty"`
+}
+
+// CreateLLMUsage records an LLM call
+func (s *SupabaseClient) CreateLLMUsage(ctx context.Context, usage *LLMUsage) error {
+	return s.request(ctx, "POST", "llm_usage", usage, nil)
+}
+
+// GetLLMUsageSince lists LLM calls from a timestamp (RFC 3339) on, a page at a time
+func (s *SupabaseClient) GetLLMUsageSince(ctx context.Context, since string) ([]LLMUsage, error) {
+	endpoint := fmt.Sprintf("llm_usage?created_at=gte.%s&order=created_at.asc,id.asc", url.QueryEscape(since))
+	return getPages[LLMUsage](ctx, s, endpoint)
+}
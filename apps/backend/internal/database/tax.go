@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// TaxProfile is how a user is taxed. Users without one are treated as an
+// individual on PPh Final who does not charge PPN.
+type TaxProfile struct {
+	ID             string  `json:"id,omitempty"`
+	UserID         string  `json:"user_id"`
+	Regime         string  `json:"regime"`        // PPH_FINAL, NORMAL
+	TaxpayerType   string  `json:"taxpayer_type"` // ORANG_PRIBADI, BADAN
+	FinalRate      float64 `json:"final_rate"`
+	ExemptTurnover float64 `json:"exempt_turnover"` // Yearly turnover free of PPh Final
+	IsPKP          bool    `json:"is_pkp"`          // Charges PPN on sales
+	PPNRate        float64 `json:"ppn_rate"`
+	PaidMonth      string  `json:"paid_month,omitempty"` // YYYY-MM of the last month whose tax was paid
+	CreatedAt      string  `json:"created_at,omitempty"`
+	UpdatedAt      string  `json:"updated_at,omitempty"`
+}
+
+// CreateTaxProfile inserts a tax profile
+func (s *SupabaseClient) CreateTaxProfile(ctx context.Context, profile *TaxProfile) error {
+	var result []TaxProfile
+	if err := s.request(ctx, "POST", "tax_profiles", profile, &result); err != nil {
+		return err
+	}
+	if len(result) > 0 {
+		profile.ID = result[0].ID
+		profile.CreatedAt = result[0].CreatedAt
+	}
+	return nil
+}
+
+// UpdateTaxProfile patches a tax profile
+func (s *SupabaseClient) UpdateTaxProfile(ctx context.Context, id string, updates map[string]any) error {
+	endpoint := fmt.Sprintf("tax_profiles?id=eq.%s", id)
+	return s.request(ctx, "PATCH", endpoint, updates, nil)
+}
+
+// GetTaxProfile gets a user's tax profile, or nil if none is set
+func (s *SupabaseClient) GetTaxProfile(ctx context.Context, userID string) (*TaxProfile, error) {
+	var profiles []TaxProfile
+	endpoint := fmt.Sprintf("tax_profiles?user_id=eq.%s&limit=1", userID)
+	if err := s.request(ctx, "GET", endpoint, nil, &profiles); err != nil {
+		return nil, err
+	}
+	if len(profiles) == 0 {
+		return nil, nil
+	}
+	return &profiles[0], nil
+}
+
+// GetTaxProfiles gets every tax profile, for the monthly summaries and
+// reminders
+func (s *SupabaseClient) GetTaxProfiles(ctx context.Context) ([]TaxProfile, error) {
+	return getPages[TaxProfile](ctx, s, "tax_profiles?order=user_id.asc")
+}
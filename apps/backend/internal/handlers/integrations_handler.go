@@ -10,23 +10,27 @@ import (
 
 type IntegrationsHandler struct {
 	excelExporter  *integrations.ExcelExporter
+	pdfExporter    *integrations.PDFExporter
 	whatsappBcast  *integrations.WhatsAppBroadcaster
 	socialMediaGen *integrations.SocialMediaGenerator
 }
 
 func NewIntegrationsHandler(
 	excelExporter *integrations.ExcelExporter,
+	pdfExporter *integrations.PDFExporter,
 	whatsappBcast *integrations.WhatsAppBroadcaster,
 	socialMediaGen *integrations.SocialMediaGenerator,
 ) *IntegrationsHandler {
 	return &IntegrationsHandler{
 		excelExporter:  excelExporter,
+		pdfExporter:    pdfExporter,
 		whatsappBcast:  whatsappBcast,
 		socialMediaGen: socialMediaGen,
 	}
 }
 
-// HandleExportTransactions exports transactions to Excel
+// HandleExportTransactions exports transactions to Excel, or the financial
+// statements to Excel or PDF for type "financial_report"
 func (h *IntegrationsHandler) HandleExportTransactions(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -41,7 +45,16 @@ func (h *IntegrationsHandler) HandleExportTransactions(w http.ResponseWriter, r
 
 	log.Printf("📊 Export request: %+v", req)
 
-	resp, err := h.excelExporter.ExportTransactions(r.Context(), &req)
+	var resp *integrations.ExportResponse
+	var err error
+	switch {
+	case req.Type == "financial_report" && req.Format == "pdf":
+		resp, err = h.pdfExporter.ExportFinancialStatements(r.Context(), &req)
+	case req.Type == "financial_report":
+		resp, err = h.excelExporter.ExportFinancialStatements(r.Context(), &req)
+	default:
+		resp, err = h.excelExporter.ExportTransactions(r.Context(), &req)
+	}
 	if err != nil {
 		log.Printf("❌ Export failed: %v", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -6,7 +6,9 @@ import (
 	"log"
 	"time"
 
+	"github.com/pasarsuara/backend/internal/ai"
 	"github.com/pasarsuara/backend/internal/database"
+	"github.com/pasarsuara/backend/internal/ledger"
 	"github.com/xuri/excelize/v2"
 )
 
@@ -25,6 +27,7 @@ type ExportRequest struct {
 	Type      string `json:"type"` // "transactions", "inventory", "financial_report"
 	StartDate string `json:"start_date,omitempty"`
 	EndDate   string `json:"end_date,omitempty"`
+	Format    string `json:"format,omitempty"` // "xlsx" (default) or "pdf", for financial_report
 }
 
 // ExportResponse represents export result
@@ -203,3 +206,82 @@ func (e *ExcelExporter) createAnalyticsSheet(f *excelize.File, transactions []da
 		row++
 	}
 }
+
+// ExportFinancialStatements exports the laporan laba rugi, neraca and arus
+// kas of the requested period, month to date by default
+func (e *ExcelExporter) ExportFinancialStatements(ctx context.Context, req *ExportRequest) (*ExportResponse, error) {
+	log.Printf("📊 Exporting financial statements for user %s", req.UserID)
+
+	startDate, endDate := statementPeriod(req)
+	statements, err := ledger.NewJournal(e.db).Statements(ctx, req.UserID, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build statements: %w", err)
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	f.NewSheet("Laba Rugi")
+	f.NewSheet("Neraca")
+	f.NewSheet("Arus Kas")
+	f.DeleteSheet("Sheet1")
+
+	titleStyle, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true, Size: 14}})
+	totalStyle, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	sections := statementSections(statements)
+	for _, sheet := range []string{"Laba Rugi", "Neraca", "Arus Kas"} {
+		f.SetColWidth(sheet, "A", "A", 12)
+		f.SetColWidth(sheet, "B", "B", 40)
+		f.SetColWidth(sheet, "C", "C", 20)
+		row := 1
+		for _, line := range sections[sheet] {
+			switch line.kind {
+			case lineTitle:
+				f.SetCellValue(sheet, fmt.Sprintf("A%d", row), line.name)
+				f.SetCellStyle(sheet, fmt.Sprintf("A%d", row), fmt.Sprintf("A%d", row), titleStyle)
+			case lineHeading:
+				f.SetCellValue(sheet, fmt.Sprintf("A%d", row), line.name)
+				f.SetCellStyle(sheet, fmt.Sprintf("A%d", row), fmt.Sprintf("A%d", row), totalStyle)
+			case lineTotal:
+				f.SetCellValue(sheet, fmt.Sprintf("B%d", row), line.name)
+				f.SetCellValue(sheet, fmt.Sprintf("C%d", row), line.amount)
+				f.SetCellStyle(sheet, fmt.Sprintf("B%d", row), fmt.Sprintf("C%d", row), totalStyle)
+			case lineAccount:
+				f.SetCellValue(sheet, fmt.Sprintf("A%d", row), line.code)
+				f.SetCellValue(sheet, fmt.Sprintf("B%d", row), line.name)
+				f.SetCellValue(sheet, fmt.Sprintf("C%d", row), line.amount)
+			}
+			row++
+		}
+	}
+
+	fileName := fmt.Sprintf("laporan_keuangan_%s_%s.xlsx", req.UserID[:8], time.Now().Format("20060102_150405"))
+	filePath := fmt.Sprintf("/tmp/%s", fileName)
+	if err := f.SaveAs(filePath); err != nil {
+		return nil, fmt.Errorf("failed to save file: %w", err)
+	}
+
+	log.Printf("✅ Financial statements exported: %s", fileName)
+
+	return &ExportResponse{
+		FileName:    fileName,
+		DownloadURL: fmt.Sprintf("/api/exports/download/%s", fileName),
+		RecordCount: 3,
+		GeneratedAt: time.Now().Format(time.RFC3339),
+	}, nil
+}
+
+// statementPeriod returns the requested period as YYYY-MM-DD, month to date
+// in the user's time zone by default
+func statementPeriod(req *ExportRequest) (startDate, endDate string) {
+	now := time.Now().In(ai.UserLocation(""))
+	startDate = now.Format("2006-01") + "-01"
+	endDate = now.Format("2006-01-02")
+	if len(req.StartDate) >= 10 {
+		startDate = req.StartDate[:10]
+	}
+	if len(req.EndDate) >= 10 {
+		endDate = req.EndDate[:10]
+	}
+	return startDate, endDate
+}
@@ -0,0 +1,104 @@
+package integrations
+
+import (
+	"fmt"
+
+	"github.com/pasarsuara/backend/internal/ledger"
+)
+
+// Kinds of rows in an exported statement
+const (
+	lineTitle = iota
+	lineHeading
+	lineAccount
+	lineTotal
+)
+
+// statementRow is one row of an exported statement, shared by the Excel and
+// PDF exports so both lay the statements out the same
+type statementRow struct {
+	kind   int
+	code   string
+	name   string
+	amount float64
+}
+
+// statementSections lays out the statements by sheet: Laba Rugi, Neraca
+// and Arus Kas
+func statementSections(s *ledger.Statements) map[string][]statementRow {
+	period := fmt.Sprintf("Periode %s s/d %s", s.StartDate, s.EndDate)
+
+	pl := s.ProfitAndLoss
+	profitAndLoss := []statementRow{
+		{kind: lineTitle, name: "LAPORAN LABA RUGI"},
+		{kind: lineHeading, name: period},
+		{kind: lineHeading, name: "Pendapatan"},
+	}
+	profitAndLoss = append(profitAndLoss, accountRows(pl.Revenue)...)
+	profitAndLoss = append(profitAndLoss,
+		statementRow{kind: lineTotal, name: "Total Pendapatan", amount: pl.TotalRevenue},
+		statementRow{kind: lineHeading, name: "Harga Pokok Penjualan"})
+	profitAndLoss = append(profitAndLoss, accountRows(pl.CostOfSales)...)
+	profitAndLoss = append(profitAndLoss,
+		statementRow{kind: lineTotal, name: "Total Harga Pokok Penjualan", amount: pl.TotalCostOfSales},
+		statementRow{kind: lineTotal, name: "Laba Kotor", amount: pl.GrossProfit},
+		statementRow{kind: lineHeading, name: "Beban Usaha"})
+	profitAndLoss = append(profitAndLoss, accountRows(pl.Expenses)...)
+	profitAndLoss = append(profitAndLoss,
+		statementRow{kind: lineTotal, name: "Total Beban Usaha", amount: pl.TotalExpenses},
+		statementRow{kind: lineTotal, name: "Laba (Rugi) Bersih", amount: pl.NetProfit})
+
+	bs := s.BalanceSheet
+	balanceSheet := []statementRow{
+		{kind: lineTitle, name: "NERACA"},
+		{kind: lineHeading, name: "Per " + s.EndDate},
+		{kind: lineHeading, name: "Aset"},
+	}
+	balanceSheet = append(balanceSheet, accountRows(bs.Assets)...)
+	balanceSheet = append(balanceSheet,
+		statementRow{kind: lineTotal, name: "Total Aset", amount: bs.TotalAssets},
+		statementRow{kind: lineHeading, name: "Liabilitas"})
+	balanceSheet = append(balanceSheet, accountRows(bs.Liabilities)...)
+	balanceSheet = append(balanceSheet,
+		statementRow{kind: lineTotal, name: "Total Liabilitas", amount: bs.TotalLiabilities},
+		statementRow{kind: lineHeading, name: "Ekuitas"})
+	balanceSheet = append(balanceSheet, accountRows(bs.Equity)...)
+	balanceSheet = append(balanceSheet,
+		statementRow{kind: lineTotal, name: "Total Ekuitas", amount: bs.TotalEquity},
+		statementRow{kind: lineTotal, name: "Total Liabilitas dan Ekuitas", amount: bs.TotalLiabilities + bs.TotalEquity})
+
+	cf := s.CashFlow
+	cashFlow := []statementRow{
+		{kind: lineTitle, name: "LAPORAN ARUS KAS"},
+		{kind: lineHeading, name: period},
+		{kind: lineTotal, name: "Kas Awal Periode", amount: cf.Opening},
+		{kind: lineHeading, name: "Arus Kas dari Aktivitas Operasi"},
+	}
+	cashFlow = append(cashFlow, accountRows(cf.Operating)...)
+	cashFlow = append(cashFlow,
+		statementRow{kind: lineTotal, name: "Kas Bersih dari Aktivitas Operasi", amount: cf.TotalOperating},
+		statementRow{kind: lineHeading, name: "Arus Kas dari Aktivitas Investasi"})
+	cashFlow = append(cashFlow, accountRows(cf.Investing)...)
+	cashFlow = append(cashFlow,
+		statementRow{kind: lineTotal, name: "Kas Bersih dari Aktivitas Investasi", amount: cf.TotalInvesting},
+		statementRow{kind: lineHeading, name: "Arus Kas dari Aktivitas Pendanaan"})
+	cashFlow = append(cashFlow, accountRows(cf.Financing)...)
+	cashFlow = append(cashFlow,
+		statementRow{kind: lineTotal, name: "Kas Bersih dari Aktivitas Pendanaan", amount: cf.TotalFinancing},
+		statementRow{kind: lineTotal, name: "Kenaikan (Penurunan) Kas", amount: cf.NetChange},
+		statementRow{kind: lineTotal, name: "Kas Akhir Periode", amount: cf.Closing})
+
+	return map[string][]statementRow{
+		"Laba Rugi": profitAndLoss,
+		"Neraca":    balanceSheet,
+		"Arus Kas":  cashFlow,
+	}
+}
+
+func accountRows(lines []ledger.StatementLine) []statementRow {
+	rows := make([]statementRow, 0, len(lines))
+	for _, line := range lines {
+		rows = append(rows, statementRow{kind: lineAccount, code: line.Code, name: line.Name, amount: line.Amount})
+	}
+	return rows
+}
@@ -0,0 +1,85 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/pasarsuara/backend/internal/database"
+	"github.com/pasarsuara/backend/internal/ledger"
+)
+
+// PDFExporter exports reports as PDF for banks, KUR programs and
+// cooperatives that do not take spreadsheets
+type PDFExporter struct {
+	db *database.SupabaseClient
+}
+
+func NewPDFExporter(db *database.SupabaseClient) *PDFExporter {
+	return &PDFExporter{db: db}
+}
+
+// ExportFinancialStatements exports the laporan laba rugi, neraca and arus
+// kas of the requested period, one page each
+func (e *PDFExporter) ExportFinancialStatements(ctx context.Context, req *ExportRequest) (*ExportResponse, error) {
+	log.Printf("📄 Exporting financial statements PDF for user %s", req.UserID)
+
+	startDate, endDate := statementPeriod(req)
+	statements, err := ledger.NewJournal(e.db).Statements(ctx, req.UserID, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build statements: %w", err)
+	}
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	sections := statementSections(statements)
+	for _, sheet := range []string{"Laba Rugi", "Neraca", "Arus Kas"} {
+		pdf.AddPage()
+		for _, line := range sections[sheet] {
+			switch line.kind {
+			case lineTitle:
+				pdf.SetFont("Helvetica", "B", 14)
+				pdf.CellFormat(0, 10, tr(line.name), "", 1, "C", false, 0, "")
+			case lineHeading:
+				pdf.SetFont("Helvetica", "B", 10)
+				pdf.Ln(2)
+				pdf.CellFormat(0, 7, tr(line.name), "", 1, "L", false, 0, "")
+			case lineTotal:
+				pdf.SetFont("Helvetica", "B", 10)
+				pdf.CellFormat(25, 7, "", "", 0, "L", false, 0, "")
+				pdf.CellFormat(105, 7, tr(line.name), "T", 0, "L", false, 0, "")
+				pdf.CellFormat(50, 7, formatAmount(line.amount), "T", 1, "R", false, 0, "")
+			case lineAccount:
+				pdf.SetFont("Helvetica", "", 10)
+				pdf.CellFormat(25, 6, line.code, "", 0, "L", false, 0, "")
+				pdf.CellFormat(105, 6, tr(line.name), "", 0, "L", false, 0, "")
+				pdf.CellFormat(50, 6, formatAmount(line.amount), "", 1, "R", false, 0, "")
+			}
+		}
+	}
+
+	fileName := fmt.Sprintf("laporan_keuangan_%s_%s.pdf", req.UserID[:8], time.Now().Format("20060102_150405"))
+	filePath := fmt.Sprintf("/tmp/%s", fileName)
+	if err := pdf.OutputFileAndClose(filePath); err != nil {
+		return nil, fmt.Errorf("failed to save file: %w", err)
+	}
+
+	log.Printf("✅ Financial statements PDF exported: %s", fileName)
+
+	return &ExportResponse{
+		FileName:    fileName,
+		DownloadURL: fmt.Sprintf("/api/exports/download/%s", fileName),
+		RecordCount: 3,
+		GeneratedAt: time.Now().Format(time.RFC3339),
+	}, nil
+}
+
+// formatAmount formats rupiah, negative amounts in parentheses
+func formatAmount(amount float64) string {
+	if amount < 0 {
+		return fmt.Sprintf("(Rp %.0f)", -amount)
+	}
+	return fmt.Sprintf("Rp %.0f", amount)
+}
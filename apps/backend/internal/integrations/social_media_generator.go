@@ -4,89 +4,23 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 
-	"github.com/google/generative-ai-go/genai"
-	"google.golang.org/api/option"
+	"github.com/pasarsuara/backend/internal/ai"
 )
 
 // SocialMediaGenerator creates AI-powered social media content
 type SocialMediaGenerator struct {
-	geminiKeys   []string
-	currentIndex int
+	llm ai.Provider
 }
 
-func NewSocialMediaGenerator(geminiKeys string) *SocialMediaGenerator {
-	// Split comma-separated keys
-	keys := []string{}
-	for _, key := range splitAndTrim(geminiKeys, ",") {
-		if key != "" {
-			keys = append(keys, key)
-		}
-	}
-
-	if len(keys) == 0 {
-		log.Println("⚠️ No Gemini API keys provided")
-	} else {
-		log.Printf("✅ Loaded %d Gemini API key(s) for rotation", len(keys))
-	}
-
-	return &SocialMediaGenerator{
-		geminiKeys:   keys,
-		currentIndex: 0,
-	}
-}
-
-// splitAndTrim splits string by delimiter and trims whitespace
-func splitAndTrim(s, delimiter string) []string {
-	parts := []string{}
-	for _, part := range splitString(s, delimiter) {
-		trimmed := trimSpace(part)
-		if trimmed != "" {
-			parts = append(parts, trimmed)
-		}
-	}
-	return parts
-}
-
-func splitString(s, delimiter string) []string {
-	if s == "" {
-		return []string{}
-	}
-	result := []string{}
-	current := ""
-	for i := 0; i < len(s); i++ {
-		if i+len(delimiter) <= len(s) && s[i:i+len(delimiter)] == delimiter {
-			result = append(result, current)
-			current = ""
-			i += len(delimiter) - 1
-		} else {
-			current += string(s[i])
-		}
+// NewSocialMediaGenerator uses the shared LLM provider; key rotation and
+// fallback between providers are handled there
+func NewSocialMediaGenerator(llm ai.Provider) *SocialMediaGenerator {
+	if !ai.IsAvailable(llm) {
+		log.Println("⚠️ No LLM provider configured, social media content will use demo templates")
 	}
-	result = append(result, current)
-	return result
-}
-
-func trimSpace(s string) string {
-	start := 0
-	end := len(s)
-	for start < end && (s[start] == ' ' || s[start] == '\t' || s[start] == '\n' || s[start] == '\r') {
-		start++
-	}
-	for end > start && (s[end-1] == ' ' || s[end-1] == '\t' || s[end-1] == '\n' || s[end-1] == '\r') {
-		end--
-	}
-	return s[start:end]
-}
-
-// getNextKey returns next API key in rotation
-func (s *SocialMediaGenerator) getNextKey() string {
-	if len(s.geminiKeys) == 0 {
-		return ""
-	}
-	key := s.geminiKeys[s.currentIndex]
-	s.currentIndex = (s.currentIndex + 1) % len(s.geminiKeys)
-	return key
+	return &SocialMediaGenerator{llm: llm}
 }
 
 // ContentRequest represents content generation request
@@ -106,141 +40,49 @@ type ContentResponse struct {
 	Tips     string   `json:"tips"`
 }
 
-// GenerateContent creates social media content using AI with key rotation
+// GenerateContent creates social media content using AI
 func (s *SocialMediaGenerator) GenerateContent(ctx context.Context, req *ContentRequest) (*ContentResponse, error) {
 	log.Printf("🎨 Generating %s content for %s", req.Platform, req.ProductName)
+	ctx = ai.WithFeature(ctx, ai.FeatureSocial)
 
-	if len(s.geminiKeys) == 0 {
-		return nil, fmt.Errorf("no Gemini API keys configured")
+	if !ai.IsAvailable(s.llm) {
+		return nil, fmt.Errorf("no LLM provider configured")
 	}
 
-	// Try each API key in rotation
-	for i := 0; i < len(s.geminiKeys); i++ {
-		apiKey := s.getNextKey()
-		log.Printf("🔑 Trying API key %d/%d", i+1, len(s.geminiKeys))
-
-		client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
-		if err != nil {
-			log.Printf("⚠️ Failed to create client with key %d: %v", i+1, err)
-			continue
-		}
-
-		model := client.GenerativeModel("gemini-2.5-flash")
-		prompt := s.buildPrompt(req)
-
-		resp, err := model.GenerateContent(ctx, genai.Text(prompt))
-		client.Close()
-
-		if err != nil {
-			log.Printf("⚠️ API key %d failed (quota/error), trying next...", i+1)
-			continue
-		}
-
-		// Success!
-		log.Printf("✅ Content generated successfully with key %d", i+1)
-		content := s.parseResponse(resp, req.Platform)
-		return content, nil
+	prompt, err := s.buildPrompt(ctx, req)
+	if err != nil {
+		return nil, err
 	}
-
-	// All keys failed - return mock data for demo
-	log.Printf("⚠️ All API keys exhausted, returning demo content")
-	return s.generateMockContent(req), nil
-}
-
-// buildPrompt creates platform-specific prompt
-func (s *SocialMediaGenerator) buildPrompt(req *ContentRequest) string {
-	basePrompt := fmt.Sprintf(`Kamu adalah expert social media marketer untuk UMKM di Indonesia.
-
-Buat konten untuk platform: %s
-Produk: %s
-Harga: Rp %d
-`, req.Platform, req.ProductName, req.Price)
-
-	if req.Promotion != "" {
-		basePrompt += fmt.Sprintf("Promo: %s\n", req.Promotion)
+	text, err := ai.CompleteText(ctx, s.llm, prompt.System, prompt.User)
+	if err != nil {
+		// All providers failed - return mock data for demo
+		log.Printf("⚠️ Content generation failed (%v), returning demo content", err)
+		return s.generateMockContent(req), nil
 	}
-
-	basePrompt += fmt.Sprintf("Tone: %s\n\n", req.Tone)
-
-	switch req.Platform {
-	case "instagram":
-		basePrompt += `Buat caption Instagram yang:
-- Menarik perhatian di 3 kata pertama
-- Panjang 100-150 kata
-- Include emoji yang relevan
-- Call-to-action yang jelas
-- 10-15 hashtags populer dan relevan
-
-Format response:
-CAPTION:
-[caption text]
-
-HASHTAGS:
-[hashtag1] [hashtag2] ...
-
-TIPS:
-[tips untuk posting]`
-
-	case "facebook":
-		basePrompt += `Buat post Facebook yang:
-- Storytelling yang engaging
-- Panjang 150-200 kata
-- Include emoji
-- Call-to-action yang jelas
-- 5-8 hashtags
-
-Format response:
-CAPTION:
-[caption text]
-
-HASHTAGS:
-[hashtag1] [hashtag2] ...
-
-TIPS:
-[tips untuk posting]`
-
-	case "twitter":
-		basePrompt += `Buat tweet yang:
-- Maksimal 280 karakter
-- Catchy dan to the point
-- Include emoji
-- 3-5 hashtags trending
-
-Format response:
-CAPTION:
-[tweet text]
-
-HASHTAGS:
-[hashtag1] [hashtag2] ...
-
-TIPS:
-[tips untuk posting]`
-
-	case "tiktok":
-		basePrompt += `Buat caption TikTok yang:
-- Short dan catchy (50-100 kata)
-- Trendy dan fun
-- Include emoji
-- Call-to-action untuk engagement
-- 5-10 hashtags trending
-
-Format response:
-CAPTION:
-[caption text]
-
-HASHTAGS:
-[hashtag1] [hashtag2] ...
-
-TIPS:
-[tips untuk video content]`
+	if !strings.Contains(text, "CAPTION:") {
+		prompt.RecordOutcome(fmt.Errorf("response without CAPTION section"))
+	} else {
+		prompt.RecordOutcome(nil)
 	}
 
-	return basePrompt
+	log.Printf("✅ Content generated successfully")
+	return s.parseResponse(text, req.Platform), nil
+}
+
+// buildPrompt renders the platform-specific social_media prompt
+func (s *SocialMediaGenerator) buildPrompt(ctx context.Context, req *ContentRequest) (*ai.RenderedPrompt, error) {
+	return ai.DefaultPrompts().Render(ctx, "social_media", map[string]any{
+		"platform":     req.Platform,
+		"product_name": req.ProductName,
+		"price":        req.Price,
+		"promotion":    req.Promotion,
+		"tone":         req.Tone,
+	})
 }
 
 // parseResponse extracts caption, hashtags, and tips from AI response
-func (s *SocialMediaGenerator) parseResponse(resp *genai.GenerateContentResponse, platform string) *ContentResponse {
-	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
+func (s *SocialMediaGenerator) parseResponse(text string, platform string) *ContentResponse {
+	if text == "" {
 		return &ContentResponse{
 			Platform: platform,
 			Caption:  "Error generating content",
@@ -249,8 +91,6 @@ func (s *SocialMediaGenerator) parseResponse(resp *genai.GenerateContentResponse
 		}
 	}
 
-	text := fmt.Sprintf("%v", resp.Candidates[0].Content.Parts[0])
-
 	// Simple parsing - in production, use more robust parsing
 	caption := ""
 	hashtags := []string{}
@@ -0,0 +1,134 @@
+// Package ledger keeps the double-entry books of a user: a default chart of
+// accounts for UMKM, journal entries for every transaction and payment, and
+// the profit and loss, balance sheet and cash flow statements built from them.
+package ledger
+
+import "sort"
+
+// Account types, in statement order
+const (
+	TypeAsset       = "ASSET"
+	TypeLiability   = "LIABILITY"
+	TypeEquity      = "EQUITY"
+	TypeRevenue     = "REVENUE"
+	TypeCostOfSales = "COST_OF_SALES"
+	TypeExpense     = "EXPENSE"
+)
+
+// Cash flow activities
+const (
+	ActivityOperating = "OPERATING"
+	ActivityInvesting = "INVESTING"
+	ActivityFinancing = "FINANCING"
+)
+
+// Account is one account of the chart
+type Account struct {
+	Code     string
+	Name     string
+	Type     string
+	Activity string // Cash flow activity of cash moving against this account
+	Cash     bool   // Cash and bank accounts
+}
+
+// DebitNormal reports whether the account grows with debits
+func (a Account) DebitNormal() bool {
+	return a.Type == TypeAsset || a.Type == TypeCostOfSales || a.Type == TypeExpense
+}
+
+// Account codes the backend posts to
+const (
+	AccountCash             = "1-1100"
+	AccountBank             = "1-1200"
+	AccountReceivable       = "1-1300"
+	AccountInventory        = "1-1400"
+	AccountEquipment        = "1-2100"
+	AccountPayable          = "2-1100"
+	AccountTaxPayable       = "2-1200"
+	AccountBankLoan         = "2-2100"
+	AccountOwnerCapital     = "3-1100"
+	AccountOwnerDrawings    = "3-1200"
+	AccountRetainedEarnings = "3-1300"
+	AccountSales            = "4-1100"
+	AccountOtherIncome      = "4-2100"
+	AccountCOGS             = "5-1100"
+	AccountInventoryAdjust  = "5-1200"
+	AccountRawMaterials     = "6-1100"
+	AccountOperatingExpense = "6-1200"
+	AccountSalaries         = "6-1300"
+	AccountTransport        = "6-1400"
+	AccountMarketing        = "6-1500"
+	AccountTaxExpense       = "6-1600"
+	AccountOtherExpense     = "6-1900"
+)
+
+// DefaultChart is the chart of accounts every user starts with, following
+// the SAK EMKM layout that banks and KUR programs expect
+var DefaultChart = []Account{
+	{Code: AccountCash, Name: "Kas", Type: TypeAsset, Activity: ActivityOperating, Cash: true},
+	{Code: AccountBank, Name: "Bank", Type: TypeAsset, Activity: ActivityOperating, Cash: true},
+	{Code: AccountReceivable, Name: "Piutang Usaha", Type: TypeAsset, Activity: ActivityOperating},
+	{Code: AccountInventory, Name: "Persediaan Barang Dagang", Type: TypeAsset, Activity: ActivityOperating},
+	{Code: AccountEquipment, Name: "Peralatan", Type: TypeAsset, Activity: ActivityInvesting},
+	{Code: AccountPayable, Name: "Utang Usaha", Type: TypeLiability, Activity: ActivityOperating},
+	{Code: AccountTaxPayable, Name: "Utang Pajak", Type: TypeLiability, Activity: ActivityOperating},
+	{Code: AccountBankLoan, Name: "Utang Bank (KUR)", Type: TypeLiability, Activity: ActivityFinancing},
+	{Code: AccountOwnerCapital, Name: "Modal Pemilik", Type: TypeEquity, Activity: ActivityFinancing},
+	{Code: AccountOwnerDrawings, Name: "Prive", Type: TypeEquity, Activity: ActivityFinancing},
+	{Code: AccountRetainedEarnings, Name: "Saldo Laba", Type: TypeEquity, Activity: ActivityFinancing},
+	{Code: AccountSales, Name: "Penjualan", Type: TypeRevenue, Activity: ActivityOperating},
+	{Code: AccountOtherIncome, Name: "Pendapatan Lain-lain", Type: TypeRevenue, Activity: ActivityOperating},
+	{Code: AccountCOGS, Name: "Harga Pokok Penjualan", Type: TypeCostOfSales, Activity: ActivityOperating},
+	{Code: AccountInventoryAdjust, Name: "Penyesuaian Persediaan", Type: TypeCostOfSales, Activity: ActivityOperating},
+	{Code: AccountRawMaterials, Name: "Beban Bahan Baku", Type: TypeExpense, Activity: ActivityOperating},
+	{Code: AccountOperatingExpense, Name: "Beban Operasional", Type: TypeExpense, Activity: ActivityOperating},
+	{Code: AccountSalaries, Name: "Beban Gaji", Type: TypeExpense, Activity: ActivityOperating},
+	{Code: AccountTransport, Name: "Beban Transportasi", Type: TypeExpense, Activity: ActivityOperating},
+	{Code: AccountMarketing, Name: "Beban Pemasaran", Type: TypeExpense, Activity: ActivityOperating},
+	{Code: AccountTaxExpense, Name: "Beban Pajak", Type: TypeExpense, Activity: ActivityOperating},
+	{Code: AccountOtherExpense, Name: "Beban Lain-lain", Type: TypeExpense, Activity: ActivityOperating},
+}
+
+var chartByCode = func() map[string]Account {
+	accounts := make(map[string]Account, len(DefaultChart))
+	for _, account := range DefaultChart {
+		accounts[account.Code] = account
+	}
+	return accounts
+}()
+
+// LookupAccount returns the account of a code; unknown codes are treated as
+// other expenses so a stray line still lands in the statements
+func LookupAccount(code string) Account {
+	if account, ok := chartByCode[code]; ok {
+		return account
+	}
+	return Account{Code: code, Name: code, Type: TypeExpense, Activity: ActivityOperating}
+}
+
+// expenseAccounts maps expense categories (agents.CategorizeExpense) to accounts
+var expenseAccounts = map[string]string{
+	"BAHAN_BAKU":   AccountRawMaterials,
+	"OPERASIONAL":  AccountOperatingExpense,
+	"GAJI":         AccountSalaries,
+	"TRANSPORTASI": AccountTransport,
+	"PEMASARAN":    AccountMarketing,
+}
+
+// ExpenseAccount returns the account an expense category is posted to
+func ExpenseAccount(category string) string {
+	if code, ok := expenseAccounts[category]; ok {
+		return code
+	}
+	return AccountOtherExpense
+}
+
+// sortedCodes returns account codes in chart order
+func sortedCodes(balances map[string]float64) []string {
+	codes := make([]string, 0, len(balances))
+	for code := range balances {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
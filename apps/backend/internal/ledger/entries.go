@@ -0,0 +1,213 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/pasarsuara/backend/internal/ai"
+	"github.com/pasarsuara/backend/internal/database"
+)
+
+// Source types of journal entries
+const (
+	SourceSale       = "SALE"
+	SourcePurchase   = "PURCHASE"
+	SourceExpense    = "EXPENSE"
+	SourceLoan       = "LOAN"
+	SourcePayment    = "PAYMENT"
+	SourceAdjustment = "ADJUSTMENT"
+	SourceReversal   = "REVERSAL"
+)
+
+// ErrUnbalanced is returned for entries whose debits and credits differ
+var ErrUnbalanced = errors.New("journal entry is not balanced")
+
+// Transactions are posted on accrual: a sale is owed by the customer
+// (piutang) and a purchase or expense is owed to the supplier (utang) until
+// its payment is posted, so paid-on-the-spot and kasbon flow the same way.
+
+// TransactionEntry builds the entry of a recorded sale, purchase, expense or
+// loan. A sale with COGS also moves that cost out of inventory, and the PPN
+// of a sale is owed as tax. expenseAccount is used for expenses. A loan (cash
+// kasbon) is money lent from the till, owed back without being earned. It
+// returns nil for transactions with nothing to post.
+func TransactionEntry(tx *database.Transaction, expenseAccount string) *database.JournalEntry {
+	if tx.TotalAmount <= 0 {
+		return nil
+	}
+	entry := &database.JournalEntry{
+		UserID:        tx.UserID,
+		EntryDate:     EntryDate(tx.CreatedAt),
+		Description:   tx.ProductName,
+		TransactionID: tx.ID,
+	}
+	switch tx.Type {
+	case "SALE":
+		entry.SourceType = SourceSale
+		entry.Description = "Penjualan " + tx.ProductName
+		entry.Lines = []database.JournalLine{
+			{Account: AccountReceivable, Debit: tx.TotalAmount},
+			{Account: AccountSales, Credit: tx.TotalAmount - tx.PPNAmount},
+		}
+		if tx.PPNAmount > 0 {
+			// PPN collected is owed to the state, not earned
+			entry.Lines = append(entry.Lines, database.JournalLine{Account: AccountTaxPayable, Credit: tx.PPNAmount})
+		}
+		if tx.COGS > 0 {
+			entry.Lines = append(entry.Lines,
+				database.JournalLine{Account: AccountCOGS, Debit: tx.COGS},
+				database.JournalLine{Account: AccountInventory, Credit: tx.COGS})
+		}
+	case "PURCHASE":
+		entry.SourceType = SourcePurchase
+		entry.Description = "Pembelian " + tx.ProductName
+		entry.Lines = []database.JournalLine{
+			{Account: AccountInventory, Debit: tx.TotalAmount},
+			{Account: AccountPayable, Credit: tx.TotalAmount},
+		}
+	case "EXPENSE":
+		if expenseAccount == "" {
+			expenseAccount = AccountOtherExpense
+		}
+		entry.SourceType = SourceExpense
+		entry.Description = "Pengeluaran " + tx.ProductName
+		entry.Lines = []database.JournalLine{
+			{Account: expenseAccount, Debit: tx.TotalAmount},
+			{Account: AccountPayable, Credit: tx.TotalAmount},
+		}
+	case "LOAN":
+		entry.SourceType = SourceLoan
+		entry.Lines = []database.JournalLine{
+			{Account: AccountReceivable, Debit: tx.TotalAmount},
+			{Account: AccountCash, Credit: tx.TotalAmount},
+		}
+	default:
+		return nil
+	}
+	return entry
+}
+
+// PaymentEntry builds the entry of money received for a sale or loan or paid
+// for a purchase or expense. Only payments that moved money (PAID, PARTIAL) post.
+func PaymentEntry(tx *database.Transaction, payment *database.Payment) *database.JournalEntry {
+	if payment.Amount <= 0 || payment.Status != "PAID" && payment.Status != "PARTIAL" {
+		return nil
+	}
+	cash := AccountCash
+	if payment.PaymentMethod != "" && payment.PaymentMethod != "CASH" {
+		cash = AccountBank
+	}
+	date := payment.PaidAt
+	if date == "" {
+		date = tx.CreatedAt
+	}
+	entry := &database.JournalEntry{
+		UserID:        tx.UserID,
+		EntryDate:     EntryDate(date),
+		SourceType:    SourcePayment,
+		TransactionID: tx.ID,
+		PaymentID:     payment.ID,
+	}
+	switch tx.Type {
+	case "SALE", "LOAN":
+		entry.Description = "Terima pembayaran " + tx.ProductName
+		entry.Lines = []database.JournalLine{
+			{Account: cash, Debit: payment.Amount},
+			{Account: AccountReceivable, Credit: payment.Amount},
+		}
+	case "PURCHASE", "EXPENSE":
+		entry.Description = "Bayar " + tx.ProductName
+		entry.Lines = []database.JournalLine{
+			{Account: AccountPayable, Debit: payment.Amount},
+			{Account: cash, Credit: payment.Amount},
+		}
+	default:
+		return nil
+	}
+	return entry
+}
+
+// AdjustmentEntry builds an inventory adjustment: a positive amount raises
+// the value of inventory, a negative one lowers it, against cost of sales
+func AdjustmentEntry(userID, transactionID, date string, amount float64, memo string) *database.JournalEntry {
+	amount = round(amount)
+	if amount == 0 {
+		return nil
+	}
+	entry := &database.JournalEntry{
+		UserID:        userID,
+		EntryDate:     EntryDate(date),
+		Description:   memo,
+		SourceType:    SourceAdjustment,
+		TransactionID: transactionID,
+	}
+	if amount > 0 {
+		entry.Lines = []database.JournalLine{
+			{Account: AccountInventory, Debit: amount},
+			{Account: AccountInventoryAdjust, Credit: amount},
+		}
+	} else {
+		entry.Lines = []database.JournalLine{
+			{Account: AccountInventoryAdjust, Debit: -amount},
+			{Account: AccountInventory, Credit: -amount},
+		}
+	}
+	return entry
+}
+
+// Reversal builds the entry that undoes entry. It keeps the original date so
+// a cancelled transaction disappears from its period, like in reports.
+func Reversal(entry *database.JournalEntry) *database.JournalEntry {
+	reversal := &database.JournalEntry{
+		UserID:        entry.UserID,
+		EntryDate:     entry.EntryDate,
+		Description:   "Batal: " + entry.Description,
+		SourceType:    SourceReversal,
+		TransactionID: entry.TransactionID,
+		PaymentID:     entry.PaymentID,
+		ReversesID:    entry.ID,
+	}
+	for _, line := range entry.Lines {
+		reversal.Lines = append(reversal.Lines, database.JournalLine{Account: line.Account, Debit: line.Credit, Credit: line.Debit})
+	}
+	return reversal
+}
+
+// Validate checks that an entry has lines and its debits equal its credits
+func Validate(entry *database.JournalEntry) error {
+	if len(entry.Lines) < 2 {
+		return fmt.Errorf("%w: %d lines", ErrUnbalanced, len(entry.Lines))
+	}
+	debit, credit := 0.0, 0.0
+	for _, line := range entry.Lines {
+		if line.Debit < 0 || line.Credit < 0 {
+			return fmt.Errorf("%w: negative amount on %s", ErrUnbalanced, line.Account)
+		}
+		debit += line.Debit
+		credit += line.Credit
+	}
+	if round(debit) != round(credit) {
+		return fmt.Errorf("%w: debit %.2f, credit %.2f", ErrUnbalanced, debit, credit)
+	}
+	return nil
+}
+
+// EntryDate returns the user's calendar day of a timestamp, today if empty
+func EntryDate(timestamp string) string {
+	loc := ai.UserLocation("")
+	if t, err := time.Parse(time.RFC3339, timestamp); err == nil {
+		return t.In(loc).Format("2006-01-02")
+	}
+	if len(timestamp) >= 10 {
+		if _, err := time.Parse("2006-01-02", timestamp[:10]); err == nil {
+			return timestamp[:10]
+		}
+	}
+	return time.Now().In(loc).Format("2006-01-02")
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,86 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/pasarsuara/backend/internal/database"
+)
+
+// Journal posts entries to the user's books. Posting is best effort like
+// payments and audit logs: a failure is logged and the transaction stays
+// recorded.
+type Journal struct {
+	db *database.SupabaseClient
+}
+
+func NewJournal(db *database.SupabaseClient) *Journal {
+	return &Journal{db: db}
+}
+
+// PostTransaction posts a recorded sale, purchase or expense
+func (j *Journal) PostTransaction(ctx context.Context, tx *database.Transaction, expenseAccount string) {
+	j.post(ctx, TransactionEntry(tx, expenseAccount))
+}
+
+// PostPayment posts money received or paid for a transaction
+func (j *Journal) PostPayment(ctx context.Context, tx *database.Transaction, payment *database.Payment) {
+	j.post(ctx, PaymentEntry(tx, payment))
+}
+
+// PostAdjustment posts a change in the value of inventory that no sale or
+// purchase accounts for, e.g. a purchase cancelled after part of it was sold
+func (j *Journal) PostAdjustment(ctx context.Context, userID, transactionID, date string, amount float64, memo string) {
+	j.post(ctx, AdjustmentEntry(userID, transactionID, date, amount, memo))
+}
+
+// ReverseTransaction posts reversals of every entry of a cancelled or
+// corrected transaction, its payments included
+func (j *Journal) ReverseTransaction(ctx context.Context, transactionID string) {
+	if j == nil || j.db == nil || transactionID == "" {
+		return
+	}
+	entries, err := j.db.GetJournalEntriesByTransaction(ctx, transactionID)
+	if err != nil {
+		log.Printf("⚠️ Failed to get journal entries: %v", err)
+		return
+	}
+	for i := range entries {
+		if !j.post(ctx, Reversal(&entries[i])) {
+			continue
+		}
+		if err := j.db.MarkJournalEntryReversed(ctx, entries[i].ID); err != nil {
+			log.Printf("⚠️ Failed to mark journal entry reversed: %v", err)
+		}
+	}
+}
+
+// Statements builds the financial statements of a period (YYYY-MM-DD, inclusive)
+func (j *Journal) Statements(ctx context.Context, userID, startDate, endDate string) (*Statements, error) {
+	if j == nil || j.db == nil {
+		return nil, fmt.Errorf("database not configured")
+	}
+	// The balance sheet and opening cash need every entry up to the end
+	entries, err := j.db.GetJournalEntries(ctx, userID, "", endDate)
+	if err != nil {
+		return nil, err
+	}
+	return BuildStatements(entries, startDate, endDate), nil
+}
+
+func (j *Journal) post(ctx context.Context, entry *database.JournalEntry) bool {
+	if j == nil || j.db == nil || entry == nil {
+		return false
+	}
+	if err := Validate(entry); err != nil {
+		log.Printf("❌ Journal entry rejected (%s): %v", entry.Description, err)
+		return false
+	}
+	if err := j.db.CreateJournalEntry(ctx, entry); err != nil {
+		log.Printf("⚠️ Failed to post journal entry: %v", err)
+		return false
+	}
+	log.Printf("📒 Journal: %s %s (%d lines)", entry.EntryDate, entry.Description, len(entry.Lines))
+	return true
+}
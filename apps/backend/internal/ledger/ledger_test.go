@@ -0,0 +1,142 @@
+package ledger
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pasarsuara/backend/internal/database"
+)
+
+func TestEntriesBalanced(t *testing.T) {
+	sale := &database.Transaction{ID: "s1", Type: "SALE", ProductName: "Beras", TotalAmount: 75000, COGS: 60000, CreatedAt: "2026-10-05T03:00:00Z"}
+	withPPN := &database.Transaction{ID: "s2", Type: "SALE", ProductName: "Kopi", TotalAmount: 111000, PPNAmount: 11000, CreatedAt: "2026-10-05T03:00:00Z"}
+	purchase := &database.Transaction{ID: "p1", Type: "PURCHASE", ProductName: "Beras", TotalAmount: 600000, CreatedAt: "2026-10-01T03:00:00Z"}
+	expense := &database.Transaction{ID: "e1", Type: "EXPENSE", ProductName: "Gas", TotalAmount: 20000, CreatedAt: "2026-10-02T03:00:00Z"}
+	tests := []struct {
+		name  string
+		entry *database.JournalEntry
+		lines int
+	}{
+		{"sale with cogs", TransactionEntry(sale, ""), 4},
+		{"sale with ppn", TransactionEntry(withPPN, ""), 3},
+		{"purchase", TransactionEntry(purchase, ""), 2},
+		{"expense", TransactionEntry(expense, AccountOperatingExpense), 2},
+		{"sale paid", PaymentEntry(sale, &database.Payment{Amount: 75000, Status: "PAID", PaymentMethod: "CASH"}), 2},
+		{"purchase paid by transfer", PaymentEntry(purchase, &database.Payment{Amount: 100000, Status: "PARTIAL", PaymentMethod: "TRANSFER"}), 2},
+		{"inventory written down", AdjustmentEntry("u1", "p1", "2026-10-03", -5000, "Penyesuaian"), 2},
+		{"reversal", Reversal(TransactionEntry(sale, "")), 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.entry == nil {
+				t.Fatal("entry is nil")
+			}
+			if len(tt.entry.Lines) != tt.lines {
+				t.Errorf("lines = %d, want %d", len(tt.entry.Lines), tt.lines)
+			}
+			if err := Validate(tt.entry); err != nil {
+				t.Errorf("Validate() = %v", err)
+			}
+		})
+	}
+}
+
+func TestLoanEntries(t *testing.T) {
+	// A cash kasbon is owed back, not earned
+	loan := &database.Transaction{Type: "LOAN", ProductName: "Kasbon Bu Sari", TotalAmount: 50000, CreatedAt: "2026-10-01T03:00:00Z"}
+	entry := TransactionEntry(loan, "")
+	if entry.Lines[0].Account != AccountReceivable || entry.Lines[1].Account != AccountCash {
+		t.Errorf("loan lines = %+v, want receivable against cash", entry.Lines)
+	}
+	repaid := PaymentEntry(loan, &database.Payment{Amount: 20000, Status: "PARTIAL", PaymentMethod: "CASH"})
+	if repaid.Lines[0].Account != AccountCash || repaid.Lines[1].Account != AccountReceivable {
+		t.Errorf("repayment lines = %+v, want cash against receivable", repaid.Lines)
+	}
+}
+
+func TestEntriesNothingToPost(t *testing.T) {
+	tx := &database.Transaction{Type: "SALE", TotalAmount: 10000}
+	if entry := PaymentEntry(tx, &database.Payment{Amount: 10000, Status: "PENDING"}); entry != nil {
+		t.Errorf("pending payment posted: %+v", entry)
+	}
+	if entry := AdjustmentEntry("u1", "t1", "2026-10-01", 0.001, ""); entry != nil {
+		t.Errorf("zero adjustment posted: %+v", entry)
+	}
+	if entry := TransactionEntry(&database.Transaction{Type: "SALE"}, ""); entry != nil {
+		t.Errorf("zero sale posted: %+v", entry)
+	}
+	unbalanced := &database.JournalEntry{Lines: []database.JournalLine{
+		{Account: AccountCash, Debit: 100},
+		{Account: AccountSales, Credit: 90},
+	}}
+	if err := Validate(unbalanced); !errors.Is(err, ErrUnbalanced) {
+		t.Errorf("Validate(unbalanced) = %v", err)
+	}
+}
+
+func TestBuildStatements(t *testing.T) {
+	capital := &database.JournalEntry{EntryDate: "2026-09-01", Lines: []database.JournalLine{
+		{Account: AccountCash, Debit: 1000000},
+		{Account: AccountOwnerCapital, Credit: 1000000},
+	}}
+	purchase := &database.Transaction{Type: "PURCHASE", ProductName: "Beras", TotalAmount: 600000, CreatedAt: "2026-09-20"}
+	sale := &database.Transaction{Type: "SALE", ProductName: "Beras", TotalAmount: 300000, COGS: 240000, CreatedAt: "2026-10-05"}
+	kasbon := &database.Transaction{Type: "SALE", ProductName: "Beras", TotalAmount: 150000, COGS: 120000, CreatedAt: "2026-10-06"}
+	expense := &database.Transaction{Type: "EXPENSE", ProductName: "Gas", TotalAmount: 20000, CreatedAt: "2026-10-07"}
+	cancelled := TransactionEntry(&database.Transaction{Type: "SALE", ProductName: "Beras", TotalAmount: 50000, CreatedAt: "2026-10-08"}, "")
+	entries := []database.JournalEntry{
+		*capital,
+		*TransactionEntry(purchase, ""),
+		*PaymentEntry(purchase, &database.Payment{Amount: 600000, Status: "PAID", PaymentMethod: "CASH", PaidAt: "2026-09-20"}),
+		*TransactionEntry(sale, ""),
+		*PaymentEntry(sale, &database.Payment{Amount: 300000, Status: "PAID", PaymentMethod: "QRIS", PaidAt: "2026-10-05"}),
+		*TransactionEntry(kasbon, ""),
+		*TransactionEntry(expense, AccountOperatingExpense),
+		*PaymentEntry(expense, &database.Payment{Amount: 20000, Status: "PAID", PaymentMethod: "CASH", PaidAt: "2026-10-07"}),
+		*cancelled,
+		*Reversal(cancelled),
+		*TransactionEntry(&database.Transaction{Type: "SALE", TotalAmount: 99000, CreatedAt: "2026-11-01"}, ""), // after the period
+	}
+
+	s := BuildStatements(entries, "2026-10-01", "2026-10-31")
+
+	pl := s.ProfitAndLoss
+	if pl.TotalRevenue != 450000 || pl.TotalCostOfSales != 360000 || pl.GrossProfit != 90000 ||
+		pl.TotalExpenses != 20000 || pl.NetProfit != 70000 {
+		t.Errorf("profit and loss = %+v", pl)
+	}
+
+	bs := s.BalanceSheet
+	if !bs.Balanced() {
+		t.Errorf("balance sheet not balanced: assets %.0f, liabilities %.0f, equity %.0f",
+			bs.TotalAssets, bs.TotalLiabilities, bs.TotalEquity)
+	}
+	// Kas 380.000 + Bank 300.000 + Piutang 150.000 + Persediaan 240.000
+	if bs.TotalAssets != 1070000 || bs.TotalLiabilities != 0 || bs.TotalEquity != 1070000 {
+		t.Errorf("balance sheet = %+v", bs)
+	}
+
+	cf := s.CashFlow
+	if cf.Opening != 400000 || cf.TotalOperating != 280000 || cf.Closing != 680000 {
+		t.Errorf("cash flow = %+v", cf)
+	}
+	if cf.TotalFinancing != 0 || cf.TotalInvesting != 0 {
+		t.Errorf("cash flow outside operations = %+v", cf)
+	}
+}
+
+func TestEntryDate(t *testing.T) {
+	tests := []struct {
+		timestamp string
+		want      string
+	}{
+		{"2026-10-05T18:30:00Z", "2026-10-06"}, // Past midnight in Jakarta
+		{"2026-10-05T03:00:00Z", "2026-10-05"},
+		{"2026-10-05", "2026-10-05"},
+	}
+	for _, tt := range tests {
+		if got := EntryDate(tt.timestamp); got != tt.want {
+			t.Errorf("EntryDate(%q) = %q, want %q", tt.timestamp, got, tt.want)
+		}
+	}
+}
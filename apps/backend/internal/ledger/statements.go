@@ -0,0 +1,241 @@
+package ledger
+
+import (
+	"math"
+
+	"github.com/pasarsuara/backend/internal/database"
+)
+
+// StatementLine is one account or item of a statement
+type StatementLine struct {
+	Code   string  `json:"code,omitempty"`
+	Name   string  `json:"name"`
+	Amount float64 `json:"amount"`
+}
+
+// ProfitAndLoss is the laporan laba rugi of a period
+type ProfitAndLoss struct {
+	Revenue          []StatementLine `json:"revenue"`
+	TotalRevenue     float64         `json:"total_revenue"`
+	CostOfSales      []StatementLine `json:"cost_of_sales"`
+	TotalCostOfSales float64         `json:"total_cost_of_sales"`
+	GrossProfit      float64         `json:"gross_profit"`
+	Expenses         []StatementLine `json:"expenses"`
+	TotalExpenses    float64         `json:"total_expenses"`
+	NetProfit        float64         `json:"net_profit"`
+}
+
+// BalanceSheet is the neraca at the end of a period
+type BalanceSheet struct {
+	Assets           []StatementLine `json:"assets"`
+	TotalAssets      float64         `json:"total_assets"`
+	Liabilities      []StatementLine `json:"liabilities"`
+	TotalLiabilities float64         `json:"total_liabilities"`
+	Equity           []StatementLine `json:"equity"` // Includes retained and current earnings
+	TotalEquity      float64         `json:"total_equity"`
+}
+
+// Balanced reports whether assets equal liabilities plus equity
+func (b *BalanceSheet) Balanced() bool {
+	return round(b.TotalAssets) == round(b.TotalLiabilities+b.TotalEquity)
+}
+
+// CashFlow is the laporan arus kas of a period, direct method
+type CashFlow struct {
+	Opening        float64         `json:"opening"`
+	Operating      []StatementLine `json:"operating"`
+	TotalOperating float64         `json:"total_operating"`
+	Investing      []StatementLine `json:"investing"`
+	TotalInvesting float64         `json:"total_investing"`
+	Financing      []StatementLine `json:"financing"`
+	TotalFinancing float64         `json:"total_financing"`
+	NetChange      float64         `json:"net_change"`
+	Closing        float64         `json:"closing"`
+}
+
+// Statements are the financial statements of a period
+type Statements struct {
+	StartDate     string        `json:"start_date"`
+	EndDate       string        `json:"end_date"`
+	ProfitAndLoss ProfitAndLoss `json:"profit_and_loss"`
+	BalanceSheet  BalanceSheet  `json:"balance_sheet"`
+	CashFlow      CashFlow      `json:"cash_flow"`
+}
+
+// cashFlowLabels names cash moving against an account
+var cashFlowLabels = map[string]string{
+	AccountReceivable:    "Penerimaan dari pelanggan",
+	AccountSales:         "Penjualan tunai",
+	AccountPayable:       "Pembayaran ke pemasok dan beban",
+	AccountInventory:     "Pembelian persediaan tunai",
+	AccountTaxPayable:    "Pembayaran pajak",
+	AccountEquipment:     "Pembelian peralatan",
+	AccountBankLoan:      "Pinjaman bank (KUR)",
+	AccountOwnerCapital:  "Setoran modal pemilik",
+	AccountOwnerDrawings: "Pengambilan prive",
+}
+
+// BuildStatements builds the statements of startDate to endDate
+// (YYYY-MM-DD, inclusive) from every entry up to endDate. Books are never
+// closed, so earnings before startDate show as retained earnings.
+func BuildStatements(entries []database.JournalEntry, startDate, endDate string) *Statements {
+	cumulative := map[string]float64{} // debit minus credit up to endDate
+	period := map[string]float64{}     // debit minus credit within the period
+	flows := map[string]float64{}      // cash in (+) or out (-) against each account
+	opening := 0.0
+
+	for _, entry := range entries {
+		if entry.EntryDate > endDate {
+			continue
+		}
+		inPeriod := entry.EntryDate >= startDate
+		for _, line := range entry.Lines {
+			net := line.Debit - line.Credit
+			cumulative[line.Account] += net
+			if inPeriod {
+				period[line.Account] += net
+			} else if LookupAccount(line.Account).Cash {
+				opening += net
+			}
+		}
+		if inPeriod {
+			addCashFlows(flows, entry.Lines)
+		}
+	}
+
+	s := &Statements{StartDate: startDate, EndDate: endDate}
+	s.ProfitAndLoss = profitAndLoss(period)
+	s.BalanceSheet = balanceSheet(cumulative, s.ProfitAndLoss.NetProfit)
+	s.CashFlow = cashFlow(flows, opening)
+	return s
+}
+
+// addCashFlows attributes the cash an entry moved to its other accounts:
+// cash received is what those accounts were credited
+func addCashFlows(flows map[string]float64, lines []database.JournalLine) {
+	moved := 0.0
+	for _, line := range lines {
+		if LookupAccount(line.Account).Cash {
+			moved += line.Debit - line.Credit
+		}
+	}
+	if round(moved) == 0 {
+		return // No cash, or cash moved between cash accounts
+	}
+	for _, line := range lines {
+		if !LookupAccount(line.Account).Cash {
+			flows[line.Account] += line.Credit - line.Debit
+		}
+	}
+}
+
+func profitAndLoss(period map[string]float64) ProfitAndLoss {
+	var pl ProfitAndLoss
+	for _, code := range sortedCodes(period) {
+		account := LookupAccount(code)
+		amount := balance(account, period[code])
+		if amount == 0 {
+			continue
+		}
+		line := StatementLine{Code: code, Name: account.Name, Amount: amount}
+		switch account.Type {
+		case TypeRevenue:
+			pl.Revenue = append(pl.Revenue, line)
+			pl.TotalRevenue += amount
+		case TypeCostOfSales:
+			pl.CostOfSales = append(pl.CostOfSales, line)
+			pl.TotalCostOfSales += amount
+		case TypeExpense:
+			pl.Expenses = append(pl.Expenses, line)
+			pl.TotalExpenses += amount
+		}
+	}
+	pl.GrossProfit = pl.TotalRevenue - pl.TotalCostOfSales
+	pl.NetProfit = pl.GrossProfit - pl.TotalExpenses
+	return pl
+}
+
+func balanceSheet(cumulative map[string]float64, periodProfit float64) BalanceSheet {
+	var bs BalanceSheet
+	earnings := 0.0
+	for _, code := range sortedCodes(cumulative) {
+		account := LookupAccount(code)
+		amount := balance(account, cumulative[code])
+		switch account.Type {
+		case TypeRevenue:
+			earnings += amount
+			continue
+		case TypeCostOfSales, TypeExpense:
+			earnings -= amount
+			continue
+		}
+		if amount == 0 {
+			continue
+		}
+		line := StatementLine{Code: code, Name: account.Name, Amount: amount}
+		switch account.Type {
+		case TypeAsset:
+			bs.Assets = append(bs.Assets, line)
+			bs.TotalAssets += amount
+		case TypeLiability:
+			bs.Liabilities = append(bs.Liabilities, line)
+			bs.TotalLiabilities += amount
+		case TypeEquity:
+			bs.Equity = append(bs.Equity, line)
+			bs.TotalEquity += amount
+		}
+	}
+
+	// Profit of earlier periods is retained, this period's is shown apart
+	if retained := round(earnings - periodProfit); retained != 0 {
+		bs.Equity = append(bs.Equity, StatementLine{Name: "Saldo Laba Periode Lalu", Amount: retained})
+		bs.TotalEquity += retained
+	}
+	if current := round(periodProfit); current != 0 {
+		bs.Equity = append(bs.Equity, StatementLine{Name: "Laba (Rugi) Periode Berjalan", Amount: current})
+		bs.TotalEquity += current
+	}
+	return bs
+}
+
+func cashFlow(flows map[string]float64, opening float64) CashFlow {
+	cf := CashFlow{Opening: round(opening)}
+	for _, code := range sortedCodes(flows) {
+		amount := round(flows[code])
+		if amount == 0 {
+			continue
+		}
+		account := LookupAccount(code)
+		name := account.Name
+		if label, ok := cashFlowLabels[code]; ok {
+			name = label
+		}
+		line := StatementLine{Code: code, Name: name, Amount: amount}
+		switch account.Activity {
+		case ActivityInvesting:
+			cf.Investing = append(cf.Investing, line)
+			cf.TotalInvesting += amount
+		case ActivityFinancing:
+			cf.Financing = append(cf.Financing, line)
+			cf.TotalFinancing += amount
+		default:
+			cf.Operating = append(cf.Operating, line)
+			cf.TotalOperating += amount
+		}
+	}
+	cf.NetChange = cf.TotalOperating + cf.TotalInvesting + cf.TotalFinancing
+	cf.Closing = cf.Opening + cf.NetChange
+	return cf
+}
+
+// balance turns debit minus credit into the account's normal balance
+func balance(account Account, net float64) float64 {
+	if !account.DebitNormal() {
+		net = -net
+	}
+	amount := round(net)
+	if math.Abs(amount) < 0.01 {
+		return 0
+	}
+	return amount
+}
@@ -0,0 +1,193 @@
+// Package schedule parses cron schedules of recurring transactions and
+// finds when they are next due. A schedule has the five standard fields,
+// minute hour day-of-month month day-of-week, in the user's time zone.
+// Fields take *, numbers, ranges, lists and steps; the day of month also
+// takes L for the last day of the month.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Common schedules
+const (
+	Daily   = "0 8 * * *"
+	Monthly = "0 8 1 * *"
+)
+
+// maxSearchDays bounds the search for the next run; every valid schedule
+// runs within a few years (29 February on a given weekday)
+const maxSearchDays = 366 * 8
+
+// Schedule is a parsed cron schedule
+type Schedule struct {
+	spec       string
+	minutes    []bool
+	hours      []bool
+	days       []bool
+	months     []bool
+	weekdays   []bool
+	lastDay    bool // Day of month L
+	anyDay     bool // Day of month *
+	anyWeekday bool // Day of week *
+}
+
+// Parse parses a five-field cron schedule
+func Parse(spec string) (*Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("schedule %q needs 5 fields: minute hour day month weekday", spec)
+	}
+	s := &Schedule{spec: strings.Join(fields, " ")}
+	var err error
+	if s.minutes, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if s.hours, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	switch fields[2] {
+	case "L":
+		s.lastDay = true
+		s.days = make([]bool, 32)
+	default:
+		if s.days, err = parseField(fields[2], 1, 31); err != nil {
+			return nil, fmt.Errorf("day of month: %w", err)
+		}
+	}
+	if s.months, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if s.weekdays, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+	s.weekdays[0] = s.weekdays[0] || s.weekdays[7] // 7 is Sunday too
+	s.anyDay = fields[2] == "*"
+	s.anyWeekday = fields[4] == "*"
+	return s, nil
+}
+
+// String returns the normalized schedule
+func (s *Schedule) String() string {
+	return s.spec
+}
+
+// Describe says when the schedule runs, in Indonesian, e.g. "tiap tanggal
+// 1 jam 08:00"
+func (s *Schedule) Describe() string {
+	f := strings.Fields(s.spec)
+	minute, errM := strconv.Atoi(f[0])
+	hour, errH := strconv.Atoi(f[1])
+	if errM != nil || errH != nil || f[3] != "*" {
+		return "jadwal " + s.spec
+	}
+	at := fmt.Sprintf(" jam %02d:%02d", hour, minute)
+	day, errD := strconv.Atoi(f[2])
+	weekday, errW := strconv.Atoi(f[4])
+	switch {
+	case f[2] == "*" && f[4] == "*":
+		return "tiap hari" + at
+	case f[2] == "*" && errW == nil:
+		return "tiap hari " + weekdayNames[weekday%7] + at
+	case f[2] == "L" && f[4] == "*":
+		return "tiap akhir bulan" + at
+	case errD == nil && f[4] == "*":
+		return fmt.Sprintf("tiap tanggal %d%s", day, at)
+	}
+	return "jadwal " + s.spec
+}
+
+var weekdayNames = []string{"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"}
+
+// Next returns the first run after t, in t's location, or the zero time if
+// the schedule never runs (e.g. 31 February)
+func (s *Schedule) Next(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	for i := 0; i < maxSearchDays; i, day = i+1, day.AddDate(0, 0, 1) {
+		if !s.matchesDay(day) {
+			continue
+		}
+		for hour := 0; hour < 24; hour++ {
+			if !s.hours[hour] {
+				continue
+			}
+			for minute := 0; minute < 60; minute++ {
+				if !s.minutes[minute] {
+					continue
+				}
+				run := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, t.Location())
+				if run.After(t) {
+					return run
+				}
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// matchesDay reports whether the schedule runs on day. Like cron, a
+// restricted day of month and day of week match either one.
+func (s *Schedule) matchesDay(day time.Time) bool {
+	if !s.months[day.Month()] {
+		return false
+	}
+	dom := s.days[day.Day()] || s.lastDay && day.AddDate(0, 0, 1).Day() == 1
+	dow := s.weekdays[day.Weekday()]
+	switch {
+	case s.anyDay && s.anyWeekday:
+		return true
+	case s.anyDay:
+		return dow
+	case s.anyWeekday:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// parseField parses one field into the set of values between min and max
+func parseField(field string, min, max int) ([]bool, error) {
+	set := make([]bool, max+1)
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			rangePart, step = part[:i], n
+		}
+
+		lo, hi := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(bounds[0])
+			hi, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("invalid range %q", rangePart)
+			}
+		default:
+			n, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", rangePart)
+			}
+			lo, hi = n, n
+			if step > 1 {
+				hi = max // "5/10" is 5, 15, 25, ...
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("%q is outside %d-%d", rangePart, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
@@ -0,0 +1,86 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+var jakarta = time.FixedZone("WIB", 7*3600)
+
+func TestParse(t *testing.T) {
+	valid := []string{"0 8 1 * *", "*/15 * * * *", "30 7 L * *", "0 8 * * 1-5", "0 8 1,15 * *", "0 8 * * 7", " 0  8 * * * "}
+	for _, spec := range valid {
+		if _, err := Parse(spec); err != nil {
+			t.Errorf("Parse(%q) = %v", spec, err)
+		}
+	}
+
+	invalid := []string{"", "0 8 * *", "60 8 * * *", "0 24 * * *", "0 8 0 * *", "0 8 32 * *", "0 8 * 13 *",
+		"0 8 * * 8", "0 8 5-1 * *", "0 8 */0 * *", "a 8 * * *", "0 8 1-x * *"}
+	for _, spec := range invalid {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", spec)
+		}
+	}
+
+	if s, _ := Parse(" 0  8 * * * "); s.String() != "0 8 * * *" {
+		t.Errorf("String() = %q", s.String())
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		spec string
+		from string
+		want string
+	}{
+		{"0 8 1 * *", "2026-10-19 10:00", "2026-11-01 08:00"},
+		{"0 8 1 * *", "2026-11-01 07:59", "2026-11-01 08:00"},
+		{"0 8 1 * *", "2026-11-01 08:00", "2026-12-01 08:00"},
+		{"0 8 L * *", "2027-02-10 00:00", "2027-02-28 08:00"},
+		{"0 8 L * *", "2028-02-10 00:00", "2028-02-29 08:00"},
+		{"0 8 31 * *", "2026-11-01 00:00", "2026-12-31 08:00"},
+		{"0 8 * * 1", "2026-10-19 09:00", "2026-10-26 08:00"}, // 19 October 2026 is a Monday
+		{"0 8 * * 7", "2026-10-19 09:00", "2026-10-25 08:00"},
+		{"30 17 * * *", "2026-10-19 18:00", "2026-10-20 17:30"},
+		{"*/15 * * * *", "2026-10-19 10:01", "2026-10-19 10:15"},
+		{"0 8 1 * 1", "2026-10-19 09:00", "2026-10-26 08:00"}, // Day of month or day of week
+		{"0 8 29 2 *", "2026-03-01 00:00", "2028-02-29 08:00"},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatalf("Parse(%q) = %v", tt.spec, err)
+		}
+		from, _ := time.ParseInLocation("2006-01-02 15:04", tt.from, jakarta)
+		if got := s.Next(from).Format("2006-01-02 15:04"); got != tt.want {
+			t.Errorf("%q Next(%s) = %s, want %s", tt.spec, tt.from, got, tt.want)
+		}
+	}
+
+	never, _ := Parse("0 8 31 2 *")
+	if got := never.Next(time.Date(2026, 1, 1, 0, 0, 0, 0, jakarta)); !got.IsZero() {
+		t.Errorf("Next() of 31 February = %v, want zero", got)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := map[string]string{
+		"0 8 * * *":    "tiap hari jam 08:00",
+		"0 8 * * 1":    "tiap hari Senin jam 08:00",
+		"0 19 * * 0":   "tiap hari Minggu jam 19:00",
+		"0 8 1 * *":    "tiap tanggal 1 jam 08:00",
+		"30 7 L * *":   "tiap akhir bulan jam 07:30",
+		"0 8 1 1 *":    "jadwal 0 8 1 1 *",
+		"*/15 * * * *": "jadwal */15 * * * *",
+	}
+	for spec, want := range tests {
+		s, err := Parse(spec)
+		if err != nil {
+			t.Fatalf("Parse(%q) = %v", spec, err)
+		}
+		if got := s.Describe(); got != want {
+			t.Errorf("Describe(%q) = %q, want %q", spec, got, want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Package storage keeps uploaded media, such as product photos, behind a
+// pluggable object store
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidKey is returned for keys that are empty or escape the store
+var ErrInvalidKey = errors.New("invalid object key")
+
+// ObjectStore saves an object and returns the URL it is served from
+type ObjectStore interface {
+	Name() string
+	Put(ctx context.Context, key string, data []byte, contentType string) (string, error)
+}
+
+// extensions by content type; anything else is stored as .bin
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+	"image/gif":  ".gif",
+}
+
+// NewKey builds a unique key under prefix, e.g. "products/<user>/<uuid>.jpg"
+func NewKey(prefix, contentType string) string {
+	ext, ok := extensions[strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))]
+	if !ok {
+		ext = ".bin"
+	}
+	return path.Join(prefix, uuid.New().String()+ext)
+}
+
+// cleanKey rejects absolute keys and keys climbing out with ".."
+func cleanKey(key string) (string, error) {
+	clean := path.Clean("/" + key)[1:]
+	if key == "" || clean == "" || clean != strings.TrimPrefix(key, "./") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return clean, nil
+}
+
+// LocalStore writes objects below a directory, served by Handler at baseURL
+type LocalStore struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocalStore creates the directory if needed. baseURL is where Handler
+// is mounted, e.g. "https://api.pasarsuara.id/media".
+func NewLocalStore(dir, baseURL string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create media directory: %w", err)
+	}
+	return &LocalStore{dir: dir, baseURL: strings.TrimRight(baseURL, "/")}, nil
+}
+
+func (s *LocalStore) Name() string {
+	return "local"
+}
+
+// Put writes through a temporary file so a crash never leaves half an image
+func (s *LocalStore) Put(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	target := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return "", err
+	}
+	return s.baseURL + "/" + key, nil
+}
+
+// Handler serves stored objects without directory listings; mount it with
+// the base URL's path stripped
+func (s *LocalStore) Handler() http.Handler {
+	files := http.FileServer(http.Dir(s.dir))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/") || strings.Contains(r.URL.Path, "/.") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		files.ServeHTTP(w, r)
+	})
+}
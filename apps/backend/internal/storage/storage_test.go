@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLocalStore_PutAndServe(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir(), "http://localhost:8080/media/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := NewKey("products/user-1", "image/jpeg")
+	if !strings.HasPrefix(key, "products/user-1/") || !strings.HasSuffix(key, ".jpg") {
+		t.Fatalf("NewKey = %q", key)
+	}
+	url, err := store.Put(context.Background(), key, []byte("jpeg bytes"), "image/jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "http://localhost:8080/media/"+key {
+		t.Errorf("URL = %q", url)
+	}
+
+	server := httptest.NewServer(http.StripPrefix("/media", store.Handler()))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/media/" + key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "jpeg bytes" {
+		t.Errorf("GET = %d %q", resp.StatusCode, body)
+	}
+
+	resp, err = http.Get(server.URL + "/media/products/user-1/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Directory listing = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestLocalStore_RejectsKeys(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir(), "/media")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"", "../secret.jpg", "products/../../x.jpg", "/etc/passwd"} {
+		if _, err := store.Put(context.Background(), key, []byte("x"), "image/jpeg"); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q) = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
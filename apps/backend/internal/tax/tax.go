@@ -0,0 +1,169 @@
+// Package tax computes the monthly taxes of an UMKM: PPh Final 0,5% of
+// gross turnover (PP 55/2022) with the yearly exempt turnover of individual
+// taxpayers, and the PPN a PKP collects on its sales.
+package tax
+
+import (
+	"math"
+	"time"
+
+	"github.com/pasarsuara/backend/internal/database"
+)
+
+// Regimes of income tax
+const (
+	RegimeFinal  = "PPH_FINAL" // 0,5% of gross turnover, paid monthly
+	RegimeNormal = "NORMAL"    // Income tax from bookkeeping, settled in the yearly return
+)
+
+// Taxpayer types
+const (
+	TaxpayerIndividual = "ORANG_PRIBADI"
+	TaxpayerEntity     = "BADAN"
+)
+
+const (
+	DefaultFinalRate      = 0.005      // PPh Final UMKM
+	DefaultPPNRate        = 0.11       // Effective PPN rate on goods of UMKM
+	DefaultExemptTurnover = 500000000  // Yearly turnover of an individual free of PPh Final
+	PKPThreshold          = 4800000000 // Yearly turnover above which a business must charge PPN and leaves PPh Final
+	PPhDueDay             = 15         // PPh Final is paid by the 15th of the next month
+)
+
+// Profile is how a user is taxed
+type Profile struct {
+	Regime         string
+	TaxpayerType   string
+	FinalRate      float64
+	ExemptTurnover float64 // Yearly turnover free of PPh Final, 0 for entities
+	PKP            bool    // Pengusaha Kena Pajak, charges PPN on sales
+	PPNRate        float64
+}
+
+// DefaultProfile is the profile of users who have not set theirs: an
+// individual on PPh Final who does not charge PPN
+func DefaultProfile() Profile {
+	return Profile{
+		Regime:         RegimeFinal,
+		TaxpayerType:   TaxpayerIndividual,
+		FinalRate:      DefaultFinalRate,
+		ExemptTurnover: DefaultExemptTurnover,
+		PPNRate:        DefaultPPNRate,
+	}
+}
+
+// IncludedPPN is the PPN inside a price. Prices of a PKP include PPN, in
+// chat sales and marketplace orders alike.
+func (p Profile) IncludedPPN(total float64) float64 {
+	if !p.PKP || p.PPNRate <= 0 || total <= 0 {
+		return 0
+	}
+	return math.Round(total * p.PPNRate / (1 + p.PPNRate))
+}
+
+// Summary is the tax of one month
+type Summary struct {
+	Month      time.Time // First day of the month
+	Profile    Profile
+	Turnover   float64 // Gross turnover of the month, PPN excluded
+	YearToDate float64 // Turnover from January up to the end of the month
+	Taxable    float64 // Turnover of the month above the exempt turnover
+	PPh        float64 // PPh Final due
+	PPN        float64 // PPN collected on sales
+	PPhDue     time.Time
+	PPNDue     time.Time
+}
+
+// AmountDue is the tax to pay for the month
+func (s Summary) AmountDue() float64 {
+	return s.PPh + s.PPN
+}
+
+// OverPKPThreshold reports whether the year's turnover passed the PKP
+// threshold, so a business not yet charging PPN has to register
+func (s Summary) OverPKPThreshold() bool {
+	return s.YearToDate > PKPThreshold
+}
+
+// Compute applies a profile to a month's turnover. priorTurnover is the
+// turnover of the year before the month; only the part of the year's
+// turnover above the exempt turnover is taxed.
+func Compute(p Profile, month time.Time, priorTurnover, turnover, ppn float64) Summary {
+	s := Summary{
+		Month:      MonthStart(month),
+		Profile:    p,
+		Turnover:   turnover,
+		YearToDate: priorTurnover + turnover,
+		PPN:        ppn,
+	}
+	s.PPhDue = PPhDeadline(s.Month)
+	s.PPNDue = PPNDeadline(s.Month)
+
+	if p.Regime == RegimeFinal {
+		exempt := p.ExemptTurnover
+		if p.TaxpayerType == TaxpayerEntity {
+			exempt = 0
+		}
+		s.Taxable = math.Max(0, s.YearToDate-exempt) - math.Max(0, priorTurnover-exempt)
+		s.PPh = math.Round(s.Taxable * p.FinalRate)
+	}
+	if !p.PKP {
+		s.PPN = 0
+	}
+	return s
+}
+
+// Totals sums the sales of the year of month: the turnover before the
+// month, the turnover of the month and the PPN collected in it. Sales
+// after the month are left out.
+func Totals(transactions []database.Transaction, month time.Time) (prior, turnover, ppn float64) {
+	start := MonthStart(month)
+	end := start.AddDate(0, 1, 0)
+	for _, tx := range transactions {
+		if tx.Type != "SALE" || tx.VoidedAt != "" {
+			continue
+		}
+		created, err := time.Parse(time.RFC3339, tx.CreatedAt)
+		if err != nil {
+			continue
+		}
+		created = created.In(start.Location())
+		switch {
+		case created.Year() != start.Year():
+		case created.Before(start):
+			prior += tx.TotalAmount - tx.PPNAmount
+		case created.Before(end):
+			turnover += tx.TotalAmount - tx.PPNAmount
+			ppn += tx.PPNAmount
+		}
+	}
+	return prior, turnover, ppn
+}
+
+// PPhDeadline is when the PPh Final of a month has to be paid
+func PPhDeadline(month time.Time) time.Time {
+	next := MonthStart(month).AddDate(0, 1, 0)
+	return nextWorkday(time.Date(next.Year(), next.Month(), PPhDueDay, 0, 0, 0, 0, next.Location()))
+}
+
+// PPNDeadline is when the PPN of a month has to be paid, the end of the
+// next month
+func PPNDeadline(month time.Time) time.Time {
+	return nextWorkday(MonthStart(month).AddDate(0, 2, -1))
+}
+
+// MonthStart is the first day of the month of t
+func MonthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// nextWorkday moves a deadline on a weekend to the Monday after
+func nextWorkday(t time.Time) time.Time {
+	switch t.Weekday() {
+	case time.Saturday:
+		return t.AddDate(0, 0, 2)
+	case time.Sunday:
+		return t.AddDate(0, 0, 1)
+	}
+	return t
+}
@@ -0,0 +1,105 @@
+package tax
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pasarsuara/backend/internal/database"
+)
+
+var jakarta = time.FixedZone("WIB", 7*3600)
+
+func TestCompute(t *testing.T) {
+	october := time.Date(2026, 10, 1, 0, 0, 0, 0, jakarta)
+	entity := DefaultProfile()
+	entity.TaxpayerType = TaxpayerEntity
+	normal := DefaultProfile()
+	normal.Regime = RegimeNormal
+	pkp := DefaultProfile()
+	pkp.PKP = true
+
+	tests := []struct {
+		name     string
+		profile  Profile
+		prior    float64
+		turnover float64
+		ppn      float64
+		taxable  float64
+		pph      float64
+		due      float64
+	}{
+		{"under exempt turnover", DefaultProfile(), 300000000, 50000000, 0, 0, 0, 0},
+		{"crosses exempt turnover", DefaultProfile(), 480000000, 50000000, 0, 30000000, 150000, 150000},
+		{"above exempt turnover", DefaultProfile(), 600000000, 50000000, 0, 50000000, 250000, 250000},
+		{"entity has no exempt turnover", entity, 0, 50000000, 0, 50000000, 250000, 250000},
+		{"normal regime", normal, 600000000, 50000000, 0, 0, 0, 0},
+		{"ppn of a pkp", pkp, 600000000, 50000000, 5500000, 50000000, 250000, 5750000},
+		{"ppn ignored when not pkp", DefaultProfile(), 0, 50000000, 5500000, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Compute(tt.profile, october, tt.prior, tt.turnover, tt.ppn)
+			if s.Taxable != tt.taxable || s.PPh != tt.pph || s.AmountDue() != tt.due {
+				t.Errorf("taxable %.0f, pph %.0f, due %.0f; want %.0f, %.0f, %.0f",
+					s.Taxable, s.PPh, s.AmountDue(), tt.taxable, tt.pph, tt.due)
+			}
+			if s.YearToDate != tt.prior+tt.turnover {
+				t.Errorf("YearToDate = %.0f", s.YearToDate)
+			}
+		})
+	}
+
+	if s := Compute(DefaultProfile(), october, 4790000000, 20000000, 0); !s.OverPKPThreshold() {
+		t.Error("OverPKPThreshold() = false past Rp 4,8 miliar")
+	}
+}
+
+func TestTotals(t *testing.T) {
+	october := time.Date(2026, 10, 20, 0, 0, 0, 0, jakarta)
+	transactions := []database.Transaction{
+		{Type: "SALE", TotalAmount: 100000, CreatedAt: "2026-10-05T03:00:00Z"},
+		{Type: "SALE", TotalAmount: 111000, PPNAmount: 11000, CreatedAt: "2026-10-31T17:30:00Z"}, // 1 November in Jakarta
+		{Type: "SALE", TotalAmount: 50000, CreatedAt: "2026-09-30T18:00:00Z"},                    // 1 October in Jakarta
+		{Type: "SALE", TotalAmount: 70000, CreatedAt: "2026-03-02T03:00:00Z"},
+		{Type: "SALE", TotalAmount: 90000, CreatedAt: "2025-12-31T03:00:00Z"}, // last year
+		{Type: "SALE", TotalAmount: 80000, CreatedAt: "2026-10-06T03:00:00Z", VoidedAt: "2026-10-06T04:00:00Z"},
+		{Type: "EXPENSE", TotalAmount: 20000, CreatedAt: "2026-10-07T03:00:00Z"},
+		{Type: "SALE", TotalAmount: 222000, PPNAmount: 22000, CreatedAt: "2026-10-08T03:00:00Z"},
+	}
+	prior, turnover, ppn := Totals(transactions, october)
+	if prior != 70000 || turnover != 350000 || ppn != 22000 {
+		t.Errorf("Totals() = %.0f, %.0f, %.0f; want 70000, 350000, 22000", prior, turnover, ppn)
+	}
+}
+
+func TestDeadlines(t *testing.T) {
+	tests := []struct {
+		month string
+		pph   string
+		ppn   string
+	}{
+		{"2026-09", "2026-10-15", "2026-11-02"}, // 31 October is a Saturday
+		{"2026-10", "2026-11-16", "2026-11-30"}, // 15 November is a Sunday
+		{"2026-12", "2027-01-15", "2027-02-01"}, // 31 January is a Sunday
+	}
+	for _, tt := range tests {
+		month, _ := time.ParseInLocation("2006-01", tt.month, jakarta)
+		if got := PPhDeadline(month).Format("2006-01-02"); got != tt.pph {
+			t.Errorf("PPhDeadline(%s) = %s, want %s", tt.month, got, tt.pph)
+		}
+		if got := PPNDeadline(month).Format("2006-01-02"); got != tt.ppn {
+			t.Errorf("PPNDeadline(%s) = %s, want %s", tt.month, got, tt.ppn)
+		}
+	}
+}
+
+func TestPPN(t *testing.T) {
+	p := DefaultProfile()
+	if got := p.IncludedPPN(111000); got != 0 {
+		t.Errorf("IncludedPPN() when not PKP = %.0f", got)
+	}
+	p.PKP = true
+	if got := p.IncludedPPN(111000); got != 11000 {
+		t.Errorf("IncludedPPN(111000) = %.0f, want 11000", got)
+	}
+}
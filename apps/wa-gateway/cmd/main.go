@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 
 	"github.com/joho/godotenv"
@@ -36,8 +37,14 @@ func main() {
 		log.Fatalf("❌ Failed to create WhatsApp client: %v", err)
 	}
 
+	// Create dedup store next to the session so it survives restarts
+	dedup, err := handler.NewDeduplicator(filepath.Join(cfg.SessionPath, "dedup.db"), 0)
+	if err != nil {
+		log.Fatalf("❌ Failed to create dedup store: %v", err)
+	}
+
 	// Create message handler with waClient for replies
-	msgHandler := handler.NewMessageHandler(cfg.BackendURL, waClient)
+	msgHandler := handler.NewMessageHandler(cfg.BackendURL, waClient, dedup, cfg.MaxConcurrency)
 	waClient.SetMessageHandler(msgHandler.Handle)
 
 	// Connect to WhatsApp
@@ -54,6 +61,7 @@ func main() {
 	<-sigChan
 
 	log.Println("\n👋 Shutting down...")
+	msgHandler.Close() // Let queued replies go out before disconnecting
 	waClient.Disconnect()
 	log.Println("✅ Disconnected from WhatsApp")
 }
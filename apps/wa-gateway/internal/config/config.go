@@ -2,19 +2,22 @@ package config
 
 import (
 	"os"
+	"strconv"
 )
 
 type Config struct {
-	Port        string
-	SessionPath string
-	BackendURL  string
+	Port           string
+	SessionPath    string
+	BackendURL     string
+	MaxConcurrency int // Max messages processed at once across all chats
 }
 
 func Load() *Config {
 	return &Config{
-		Port:        getEnv("WA_GATEWAY_PORT", "8081"),
-		SessionPath: getEnv("WA_SESSION_PATH", "./session"),
-		BackendURL:  getEnv("BACKEND_URL", "http://localhost:8080"),
+		Port:           getEnv("WA_GATEWAY_PORT", "8081"),
+		SessionPath:    getEnv("WA_SESSION_PATH", "./session"),
+		BackendURL:     getEnv("BACKEND_URL", "http://localhost:8080"),
+		MaxConcurrency: getEnvInt("WA_MAX_CONCURRENCY", 8),
 	}
 }
 
@@ -24,3 +27,12 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvInt(key string, fallback int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			return n
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Deduplicator remembers processed message ids so messages redelivered by
+// WhatsApp (e.g. after a reconnect) are skipped. Ids are persisted in SQLite
+// so they survive gateway restarts.
+type Deduplicator struct {
+	db  *sql.DB
+	ttl time.Duration
+}
+
+// NewDeduplicator opens (or creates) the dedup store at dbPath
+func NewDeduplicator(dbPath string, ttl time.Duration) (*Deduplicator, error) {
+	if ttl == 0 {
+		ttl = 7 * 24 * time.Hour // Default 7 days
+	}
+
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", dbPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dedup store: %w", err)
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS processed_messages (
+		sender     TEXT    NOT NULL,
+		message_id TEXT    NOT NULL,
+		seen_at    INTEGER NOT NULL,
+		PRIMARY KEY (sender, message_id)
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create dedup table: %w", err)
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_processed_messages_seen_at ON processed_messages(seen_at)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create dedup index: %w", err)
+	}
+
+	d := &Deduplicator{db: db, ttl: ttl}
+	d.prune()
+
+	// Start cleanup goroutine
+	go d.cleanupLoop()
+
+	return d, nil
+}
+
+// MarkSeen records the message and reports whether it is seen for the first time.
+// Messages are marked before processing, so a crash mid-processing drops the
+// message instead of recording it twice.
+func (d *Deduplicator) MarkSeen(sender, messageID string) (bool, error) {
+	res, err := d.db.Exec(
+		`INSERT OR IGNORE INTO processed_messages (sender, message_id, seen_at) VALUES (?, ?, ?)`,
+		sender, messageID, time.Now().Unix(),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// Close closes the dedup store
+func (d *Deduplicator) Close() error {
+	return d.db.Close()
+}
+
+func (d *Deduplicator) prune() {
+	cutoff := time.Now().Add(-d.ttl).Unix()
+	if _, err := d.db.Exec(`DELETE FROM processed_messages WHERE seen_at < ?`, cutoff); err != nil {
+		log.Printf("⚠️ Failed to prune dedup store: %v", err)
+	}
+}
+
+func (d *Deduplicator) cleanupLoop() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		d.prune()
+	}
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestDeduplicator(t *testing.T, path string) *Deduplicator {
+	t.Helper()
+	d, err := NewDeduplicator(path, time.Hour)
+	if err != nil {
+		t.Fatalf("NewDeduplicator() error = %v", err)
+	}
+	return d
+}
+
+func TestDeduplicator_MarkSeen(t *testing.T) {
+	d := newTestDeduplicator(t, filepath.Join(t.TempDir(), "dedup.db"))
+	defer d.Close()
+
+	tests := []struct {
+		name      string
+		sender    string
+		messageID string
+		want      bool
+	}{
+		{"first delivery", "628111@s.whatsapp.net", "MSG1", true},
+		{"redelivered", "628111@s.whatsapp.net", "MSG1", false},
+		{"next message", "628111@s.whatsapp.net", "MSG2", true},
+		{"same id from another sender", "628222@s.whatsapp.net", "MSG1", true},
+		{"redelivered again", "628111@s.whatsapp.net", "MSG2", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := d.MarkSeen(tt.sender, tt.messageID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("MarkSeen(%s, %s) = %v, want %v", tt.sender, tt.messageID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeduplicator_SurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dedup.db")
+
+	d := newTestDeduplicator(t, path)
+	if first, err := d.MarkSeen("628111@s.whatsapp.net", "MSG1"); err != nil || !first {
+		t.Fatalf("MarkSeen() = %v, %v, want first delivery", first, err)
+	}
+	d.Close()
+
+	d = newTestDeduplicator(t, path)
+	defer d.Close()
+	if first, err := d.MarkSeen("628111@s.whatsapp.net", "MSG1"); err != nil || first {
+		t.Errorf("MarkSeen() after restart = %v, %v, want redelivery dropped", first, err)
+	}
+}
+
+func TestDeduplicator_Expiry(t *testing.T) {
+	d := newTestDeduplicator(t, filepath.Join(t.TempDir(), "dedup.db"))
+	defer d.Close()
+
+	tests := []struct {
+		name      string
+		messageID string
+		age       time.Duration
+		want      bool // Seen for the first time after pruning
+	}{
+		{"expired", "OLD", 2 * time.Hour, true},
+		{"within ttl", "RECENT", 30 * time.Minute, false},
+	}
+	for _, tt := range tests {
+		if _, err := d.MarkSeen("628111@s.whatsapp.net", tt.messageID); err != nil {
+			t.Fatal(err)
+		}
+		seenAt := time.Now().Add(-tt.age).Unix()
+		if _, err := d.db.Exec(`UPDATE processed_messages SET seen_at = ? WHERE message_id = ?`, seenAt, tt.messageID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d.prune()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := d.MarkSeen("628111@s.whatsapp.net", tt.messageID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("MarkSeen(%s) after prune = %v, want %v", tt.messageID, got, tt.want)
+			}
+		})
+	}
+}
@@ -16,10 +16,11 @@ import (
 
 // WebhookPayload is the payload sent to backend
 type WebhookPayload struct {
-	Event   string         `json:"event"`
-	From    string         `json:"from"`
-	Type    string         `json:"type"`
-	Payload MessagePayload `json:"payload"`
+	Event     string         `json:"event"`
+	MessageID string         `json:"message_id,omitempty"` // Idempotency key for backend writes
+	From      string         `json:"from"`
+	Type      string         `json:"type"`
+	Payload   MessagePayload `json:"payload"`
 }
 
 type MessagePayload struct {
@@ -29,31 +30,101 @@ type MessagePayload struct {
 	MimeType  string `json:"mime_type,omitempty"`
 	IsVoice   bool   `json:"is_voice,omitempty"`
 	Duration  uint32 `json:"duration,omitempty"`
+
+	// Interactive selections (type "interactive")
+	SelectedID      string `json:"selected_id,omitempty"`
+	InteractiveType string `json:"interactive_type,omitempty"` // button, list
+
+	// Message being replied or reacted to (reaction emoji is in Text)
+	QuotedMessageID string `json:"quoted_message_id,omitempty"`
+
+	// Shared location (type "location"), place name is in Text
+	Latitude  float64 `json:"latitude,omitempty"`
+	Longitude float64 `json:"longitude,omitempty"`
+	Address   string  `json:"address,omitempty"`
+
+	// Forwarded contact cards (type "contact")
+	Contacts []ContactCard `json:"contacts,omitempty"`
+
+	// Outbound message report (type "sent")
+	PendingActionID string `json:"pending_action_id,omitempty"`
+	SentMessageID   string `json:"sent_message_id,omitempty"`
+}
+
+// ContactCard is a forwarded contact
+type ContactCard struct {
+	DisplayName string `json:"display_name,omitempty"`
+	VCard       string `json:"vcard,omitempty"`
 }
 
 // WebhookResponse is the response from backend
 type WebhookResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	Reply   string `json:"reply,omitempty"`
+	Success bool    `json:"success"`
+	Message string  `json:"message"`
+	Reply   string  `json:"reply,omitempty"`
+	Replies []Reply `json:"replies,omitempty"` // Structured replies, takes precedence over Reply
+
+	// PendingActionID asks us to report the id of the sent reply
+	PendingActionID string `json:"pending_action_id,omitempty"`
+}
+
+// Reply is a structured reply from backend
+type Reply struct {
+	Type      string        `json:"type"` // text, image, document, audio, buttons, list, location_request
+	Text      string        `json:"text,omitempty"`
+	MediaData []byte        `json:"media_data,omitempty"`
+	MediaURL  string        `json:"media_url,omitempty"`
+	Filename  string        `json:"filename,omitempty"`
+	MimeType  string        `json:"mime_type,omitempty"`
+	Buttons   []ReplyButton `json:"buttons,omitempty"`
+	List      *ReplyList    `json:"list,omitempty"`
+	Seconds   int           `json:"seconds,omitempty"`
+}
+
+type ReplyButton struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+type ReplyList struct {
+	Title      string         `json:"title"`
+	ButtonText string         `json:"button_text"`
+	Sections   []ReplySection `json:"sections"`
+}
+
+type ReplySection struct {
+	Title string     `json:"title"`
+	Rows  []ReplyRow `json:"rows"`
+}
+
+type ReplyRow struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
 }
 
 type MessageHandler struct {
 	backendURL string
 	waClient   *whatsapp.Client
 	httpClient *http.Client
+	dedup      *Deduplicator // optional, nil disables deduplication
+	queue      *ChatQueue
 }
 
-func NewMessageHandler(backendURL string, waClient *whatsapp.Client) *MessageHandler {
+func NewMessageHandler(backendURL string, waClient *whatsapp.Client, dedup *Deduplicator, maxConcurrency int) *MessageHandler {
 	return &MessageHandler{
 		backendURL: backendURL,
 		waClient:   waClient,
 		httpClient: &http.Client{
 			Timeout: 60 * time.Second, // Longer timeout for AI processing
 		},
+		dedup: dedup,
+		queue: NewChatQueue(maxConcurrency),
 	}
 }
 
+// Handle filters and deduplicates incoming messages, then queues them so each
+// chat is processed serially in arrival order
 func (h *MessageHandler) Handle(evt *events.Message) {
 	// Skip messages from self
 	if evt.Info.IsFromMe {
@@ -65,6 +136,36 @@ func (h *MessageHandler) Handle(evt *events.Message) {
 		return
 	}
 
+	sender := evt.Info.Sender.User
+
+	// Skip messages redelivered after reconnects/restarts
+	if h.dedup != nil {
+		first, err := h.dedup.MarkSeen(sender, evt.Info.ID)
+		if err != nil {
+			log.Printf("⚠️ Dedup check failed for %s: %v", evt.Info.ID, err)
+		} else if !first {
+			log.Printf("♻️ Duplicate message %s from %s, skipping", evt.Info.ID, sender)
+			return
+		}
+	}
+
+	h.queue.Enqueue(sender, func() {
+		h.handleMessage(evt)
+	})
+}
+
+// Close waits for queued messages to finish and closes the dedup store
+func (h *MessageHandler) Close() {
+	h.queue.Wait()
+	if h.dedup != nil {
+		if err := h.dedup.Close(); err != nil {
+			log.Printf("⚠️ Failed to close dedup store: %v", err)
+		}
+	}
+}
+
+// handleMessage downloads media, forwards the message to backend and replies
+func (h *MessageHandler) handleMessage(evt *events.Message) {
 	msg := evt.Message
 	sender := evt.Info.Sender.User
 	senderJID := evt.Info.Sender
@@ -74,9 +175,61 @@ func (h *MessageHandler) Handle(evt *events.Message) {
 	// Determine message type and extract content
 	var payload WebhookPayload
 	payload.Event = "message"
+	payload.MessageID = evt.Info.ID
 	payload.From = sender
 
-	if whatsapp.IsAudioMessage(msg) {
+	if emoji, targetID, ok := whatsapp.ExtractReaction(msg); ok {
+		// Reaction to one of our messages (e.g. 👍 to confirm)
+		if emoji == "" {
+			return // Reaction removed
+		}
+
+		log.Printf("💟 Reaction %s to %s", emoji, targetID)
+
+		payload.Type = "reaction"
+		payload.Payload = MessagePayload{
+			Text:            emoji,
+			QuotedMessageID: targetID,
+		}
+
+	} else if kind, selectedID, displayText := whatsapp.ExtractInteractiveResponse(msg); kind != "" {
+		// Button or list selection
+		log.Printf("🔘 %s selected: %s (%s)", kind, selectedID, displayText)
+
+		payload.Type = "interactive"
+		payload.Payload = MessagePayload{
+			Text:            displayText,
+			SelectedID:      selectedID,
+			InteractiveType: kind,
+		}
+
+	} else if loc := whatsapp.GetLocation(msg); loc != nil {
+		// Shared location
+		log.Printf("📍 Location: %.5f,%.5f %s", loc.Latitude, loc.Longitude, loc.Name)
+
+		payload.Type = "location"
+		payload.Payload = MessagePayload{
+			Text:      loc.Name,
+			Latitude:  loc.Latitude,
+			Longitude: loc.Longitude,
+			Address:   loc.Address,
+		}
+
+	} else if cards := whatsapp.GetContactCards(msg); len(cards) > 0 {
+		// Forwarded contact(s)
+		log.Printf("👤 %d contact card(s) received", len(cards))
+
+		contacts := make([]ContactCard, 0, len(cards))
+		for _, card := range cards {
+			contacts = append(contacts, ContactCard{DisplayName: card.DisplayName, VCard: card.VCard})
+		}
+
+		payload.Type = "contact"
+		payload.Payload = MessagePayload{
+			Contacts: contacts,
+		}
+
+	} else if whatsapp.IsAudioMessage(msg) {
 		// Audio/Voice message
 		mimetype, seconds, isPTT := whatsapp.GetAudioInfo(msg)
 
@@ -86,7 +239,7 @@ func (h *MessageHandler) Handle(evt *events.Message) {
 		audioData, err := h.waClient.DownloadAudio(context.Background(), msg)
 		if err != nil {
 			log.Printf("❌ Failed to download audio: %v", err)
-			go h.sendReply(senderJID.String(), "Maaf, gagal mengunduh voice note. Coba kirim lagi atau kirim pesan teks ya!")
+			h.sendReply(senderJID.String(), "Maaf, gagal mengunduh voice note. Coba kirim lagi atau kirim pesan teks ya!")
 			return
 		}
 
@@ -94,10 +247,11 @@ func (h *MessageHandler) Handle(evt *events.Message) {
 
 		payload.Type = "audio"
 		payload.Payload = MessagePayload{
-			AudioData: audioData,
-			MimeType:  mimetype,
-			IsVoice:   isPTT,
-			Duration:  seconds,
+			AudioData:       audioData,
+			MimeType:        mimetype,
+			IsVoice:         isPTT,
+			Duration:        seconds,
+			QuotedMessageID: whatsapp.GetQuotedMessageID(msg),
 		}
 
 	} else if msg.ImageMessage != nil {
@@ -108,7 +262,7 @@ func (h *MessageHandler) Handle(evt *events.Message) {
 		imageData, err := h.waClient.DownloadImage(context.Background(), msg)
 		if err != nil {
 			log.Printf("❌ Failed to download image: %v", err)
-			go h.sendReply(senderJID.String(), "Maaf, gagal mengunduh gambar. Coba kirim lagi ya!")
+			h.sendReply(senderJID.String(), "Maaf, gagal mengunduh gambar. Coba kirim lagi ya!")
 			return
 		}
 
@@ -124,7 +278,7 @@ func (h *MessageHandler) Handle(evt *events.Message) {
 		payload.Payload = MessagePayload{
 			Text:      caption,
 			AudioData: imageData, // Reuse field for image data
-			MimeType:  "image/jpeg",
+			MimeType:  msg.ImageMessage.GetMimetype(),
 		}
 
 	} else if msg.DocumentMessage != nil {
@@ -134,7 +288,7 @@ func (h *MessageHandler) Handle(evt *events.Message) {
 		docData, filename, err := h.waClient.DownloadDocument(context.Background(), msg)
 		if err != nil {
 			log.Printf("❌ Failed to download document: %v", err)
-			go h.sendReply(senderJID.String(), "Maaf, gagal mengunduh dokumen. Coba kirim lagi ya!")
+			h.sendReply(senderJID.String(), "Maaf, gagal mengunduh dokumen. Coba kirim lagi ya!")
 			return
 		}
 
@@ -156,14 +310,15 @@ func (h *MessageHandler) Handle(evt *events.Message) {
 
 		payload.Type = "text"
 		payload.Payload = MessagePayload{
-			Text: text,
+			Text:            text,
+			QuotedMessageID: whatsapp.GetQuotedMessageID(msg),
 		}
 
 		log.Printf("💬 Text: %s", text)
 	}
 
 	// Send to backend webhook and get reply
-	go h.processAndReply(payload, senderJID.String())
+	h.processAndReply(payload, senderJID.String())
 }
 
 func (h *MessageHandler) processAndReply(payload WebhookPayload, senderJID string) {
@@ -174,23 +329,57 @@ func (h *MessageHandler) processAndReply(payload WebhookPayload, senderJID strin
 	}
 
 	// Send to backend
-	reply, err := h.sendToBackend(payload)
+	resp, err := h.sendToBackend(payload)
 	if err != nil {
 		log.Printf("❌ Backend error: %v", err)
 		h.sendReply(senderJID, "Maaf, ada kendala teknis. Coba lagi ya! 🙏")
 		return
 	}
+	if resp == nil {
+		return
+	}
 
-	// Send reply to user
-	if reply != "" {
-		h.sendReply(senderJID, reply)
+	// Structured replies take precedence over the plain text reply
+	var sentID string
+	if len(resp.Replies) > 0 {
+		for _, reply := range resp.Replies {
+			// The voice note repeats the text, reactions belong on the text message
+			if id := h.sendStructuredReply(senderJID, reply); id != "" && reply.Type != "audio" {
+				sentID = id
+			}
+		}
+	} else if resp.Reply != "" {
+		// Send reply to user
+		sentID = h.sendReply(senderJID, resp.Reply)
+	}
+
+	// Let backend link the confirmation prompt to our message id,
+	// so reactions and quoted replies to it can confirm the action
+	if resp.PendingActionID != "" && sentID != "" {
+		h.reportSent(payload.From, resp.PendingActionID, sentID)
 	}
 }
 
-func (h *MessageHandler) sendToBackend(payload WebhookPayload) (string, error) {
+// reportSent tells backend which outbound message carried a pending action
+func (h *MessageHandler) reportSent(from, pendingActionID, sentMessageID string) {
+	_, err := h.sendToBackend(WebhookPayload{
+		Event: "sent",
+		From:  from,
+		Type:  "sent",
+		Payload: MessagePayload{
+			PendingActionID: pendingActionID,
+			SentMessageID:   sentMessageID,
+		},
+	})
+	if err != nil {
+		log.Printf("⚠️ Failed to report sent message: %v", err)
+	}
+}
+
+func (h *MessageHandler) sendToBackend(payload WebhookPayload) (*WebhookResponse, error) {
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal payload: %w", err)
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
 	url := fmt.Sprintf("%s/internal/webhook/whatsapp", h.backendURL)
@@ -200,51 +389,179 @@ func (h *MessageHandler) sendToBackend(payload WebhookPayload) (string, error) {
 
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := h.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send to backend: %w", err)
+		return nil, fmt.Errorf("failed to send to backend: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	log.Printf("✅ Backend response: %s", resp.Status)
 
 	if resp.StatusCode >= 400 {
-		return "", fmt.Errorf("backend error: %s", string(body))
+		return nil, fmt.Errorf("backend error: %s", string(body))
 	}
 
 	// Parse response
 	var webhookResp WebhookResponse
 	if err := json.Unmarshal(body, &webhookResp); err != nil {
 		log.Printf("⚠️ Failed to parse response: %v", err)
-		return "", nil
+		return nil, nil
 	}
 
-	return webhookResp.Reply, nil
+	return &webhookResp, nil
 }
 
-func (h *MessageHandler) sendReply(jid string, text string) {
+// sendReply sends a text reply and returns its message id ("" on failure)
+func (h *MessageHandler) sendReply(jid string, text string) string {
 	if h.waClient == nil {
 		log.Printf("⚠️ WA client not set, cannot send reply")
-		return
+		return ""
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	err := h.waClient.SendText(ctx, jid, text)
+	msgID, err := h.waClient.SendText(ctx, jid, text)
 	if err != nil {
 		log.Printf("❌ Failed to send reply: %v", err)
-	} else {
-		log.Printf("📤 Reply sent to %s", jid)
+		return ""
+	}
+
+	log.Printf("📤 Reply sent to %s", jid)
+	return msgID
+}
+
+// sendStructuredReply renders a backend reply as the matching WhatsApp message.
+// Interactive messages fall back to plain text when sending fails.
+// Returns the sent message id ("" on failure).
+func (h *MessageHandler) sendStructuredReply(jid string, reply Reply) string {
+	if h.waClient == nil {
+		log.Printf("⚠️ WA client not set, cannot send reply")
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var msgID string
+	var err error
+	switch reply.Type {
+	case "buttons":
+		buttons := make([]whatsapp.Button, 0, len(reply.Buttons))
+		for _, btn := range reply.Buttons {
+			buttons = append(buttons, whatsapp.Button{ID: btn.ID, Text: btn.Title})
+		}
+		msgID, err = h.waClient.SendButtonMessage(ctx, jid, reply.Text, buttons)
+
+	case "list":
+		if reply.List == nil {
+			err = fmt.Errorf("list reply without list")
+			break
+		}
+		sections := make([]whatsapp.ListSection, 0, len(reply.List.Sections))
+		for _, section := range reply.List.Sections {
+			items := make([]whatsapp.ListItem, 0, len(section.Rows))
+			for _, row := range section.Rows {
+				items = append(items, whatsapp.ListItem{ID: row.ID, Title: row.Title, Description: row.Description})
+			}
+			sections = append(sections, whatsapp.ListSection{Title: section.Title, Items: items})
+		}
+		msgID, err = h.waClient.SendListMessage(ctx, jid, reply.List.Title, reply.Text, reply.List.ButtonText, sections)
+
+	case "image":
+		var data []byte
+		data, err = h.replyMedia(ctx, reply)
+		if err == nil {
+			msgID, err = h.waClient.SendImage(ctx, jid, data, reply.Text)
+		}
+
+	case "document":
+		var data []byte
+		data, err = h.replyMedia(ctx, reply)
+		if err == nil {
+			msgID, err = h.waClient.SendDocument(ctx, jid, data, reply.Filename, reply.MimeType)
+		}
+
+	case "audio":
+		var data []byte
+		data, err = h.replyMedia(ctx, reply)
+		if err == nil {
+			msgID, err = h.waClient.SendAudio(ctx, jid, data, reply.MimeType, reply.Seconds, true)
+		}
+
+	case "location_request":
+		msgID, err = h.waClient.SendLocationRequest(ctx, jid, reply.Text)
+
+	default:
+		if reply.Text != "" {
+			msgID, err = h.waClient.SendText(ctx, jid, reply.Text)
+		}
+	}
+
+	if err != nil {
+		log.Printf("❌ Failed to send %s reply: %v", reply.Type, err)
+		if reply.Type != "text" && reply.Text != "" {
+			return h.sendReply(jid, formatReplyAsText(reply))
+		}
+		return ""
+	}
+
+	log.Printf("📤 %s reply sent to %s", reply.Type, jid)
+	return msgID
+}
+
+// replyMedia returns inline media data or downloads it from MediaURL
+func (h *MessageHandler) replyMedia(ctx context.Context, reply Reply) ([]byte, error) {
+	if len(reply.MediaData) > 0 {
+		return reply.MediaData, nil
+	}
+	if reply.MediaURL == "" {
+		return nil, fmt.Errorf("reply has no media")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", reply.MediaURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create media request: %w", err)
+	}
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download media: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("media download failed: %s", resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// formatReplyAsText renders button/list options as numbered text
+func formatReplyAsText(reply Reply) string {
+	text := reply.Text
+	n := 1
+	for _, btn := range reply.Buttons {
+		text += fmt.Sprintf("\n%d. %s", n, btn.Title)
+		n++
+	}
+	if reply.List != nil {
+		for _, section := range reply.List.Sections {
+			for _, row := range section.Rows {
+				text += fmt.Sprintf("\n%d. %s", n, row.Title)
+				n++
+			}
+		}
 	}
+	return text
 }
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"log"
+	"runtime/debug"
+	"sync"
+)
+
+// ChatQueue runs jobs one at a time per chat, in arrival order, while
+// limiting how many chats are processed concurrently overall.
+type ChatQueue struct {
+	mu      sync.Mutex
+	pending map[string][]func() // key present while the chat's worker runs
+	sem     chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewChatQueue creates a queue processing at most maxConcurrency jobs at once
+func NewChatQueue(maxConcurrency int) *ChatQueue {
+	if maxConcurrency <= 0 {
+		maxConcurrency = 8
+	}
+
+	return &ChatQueue{
+		pending: make(map[string][]func()),
+		sem:     make(chan struct{}, maxConcurrency),
+	}
+}
+
+// Enqueue adds a job for the chat, starting its worker if needed
+func (q *ChatQueue) Enqueue(chat string, job func()) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	jobs, running := q.pending[chat]
+	q.pending[chat] = append(jobs, job)
+
+	if !running {
+		q.wg.Add(1)
+		go q.run(chat)
+	}
+}
+
+// Wait blocks until all queued jobs are done
+func (q *ChatQueue) Wait() {
+	q.wg.Wait()
+}
+
+func (q *ChatQueue) run(chat string) {
+	defer q.wg.Done()
+
+	for {
+		q.mu.Lock()
+		jobs := q.pending[chat]
+		if len(jobs) == 0 {
+			delete(q.pending, chat)
+			q.mu.Unlock()
+			return
+		}
+		job := jobs[0]
+		q.pending[chat] = jobs[1:]
+		q.mu.Unlock()
+
+		q.sem <- struct{}{}
+		q.runJob(chat, job)
+		<-q.sem
+	}
+}
+
+// runJob runs a job, recovering panics so the chat worker keeps going
+func (q *ChatQueue) runJob(chat string, job func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("❌ Panic while processing message from %s: %v\n%s", chat, r, debug.Stack())
+		}
+	}()
+	job()
+}
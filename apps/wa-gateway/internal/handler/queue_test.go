@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestChatQueue_KeepsOrderPerChat(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency int
+		chats       int
+		jobs        int
+	}{
+		{"one chat", 4, 1, 50},
+		{"many chats", 4, 10, 20},
+		{"serial", 1, 5, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewChatQueue(tt.concurrency)
+
+			var mu sync.Mutex
+			done := map[string][]int{}
+			var running, peak int32
+
+			for i := 0; i < tt.jobs; i++ {
+				for c := 0; c < tt.chats; c++ {
+					chat, i := fmt.Sprintf("chat-%d", c), i
+					q.Enqueue(chat, func() {
+						n := atomic.AddInt32(&running, 1)
+						for p := atomic.LoadInt32(&peak); n > p && !atomic.CompareAndSwapInt32(&peak, p, n); p = atomic.LoadInt32(&peak) {
+						}
+						time.Sleep(100 * time.Microsecond)
+						atomic.AddInt32(&running, -1)
+
+						mu.Lock()
+						done[chat] = append(done[chat], i)
+						mu.Unlock()
+					})
+				}
+			}
+			q.Wait()
+
+			if len(done) != tt.chats {
+				t.Fatalf("Processed %d chats, want %d", len(done), tt.chats)
+			}
+			for chat, order := range done {
+				if len(order) != tt.jobs {
+					t.Errorf("%s processed %d jobs, want %d", chat, len(order), tt.jobs)
+				}
+				for i, job := range order {
+					if job != i {
+						t.Errorf("%s ran job %d at position %d", chat, job, i)
+						break
+					}
+				}
+			}
+			if peak > int32(tt.concurrency) {
+				t.Errorf("%d jobs ran at once, want at most %d", peak, tt.concurrency)
+			}
+		})
+	}
+}
+
+func TestChatQueue_RecoversPanics(t *testing.T) {
+	q := NewChatQueue(2)
+
+	var ran []string
+	q.Enqueue("chat", func() { panic("boom") })
+	q.Enqueue("chat", func() { ran = append(ran, "after panic") })
+	q.Wait()
+
+	if len(ran) != 1 {
+		t.Errorf("Jobs after a panic = %v, want the next job to run", ran)
+	}
+}
@@ -95,16 +95,20 @@ func (c *Client) eventHandler(evt interface{}) {
 	}
 }
 
-func (c *Client) SendText(ctx context.Context, jid string, text string) error {
+// SendText sends a text message and returns its message id
+func (c *Client) SendText(ctx context.Context, jid string, text string) (string, error) {
 	targetJID, err := parseJID(jid)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	_, err = c.wa.SendMessage(ctx, targetJID, &waE2E.Message{
+	resp, err := c.wa.SendMessage(ctx, targetJID, &waE2E.Message{
 		Conversation: &text,
 	})
-	return err
+	if err != nil {
+		return "", err
+	}
+	return string(resp.ID), nil
 }
 
 func (c *Client) Disconnect() {
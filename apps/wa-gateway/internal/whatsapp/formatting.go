@@ -10,33 +10,45 @@ import (
 )
 
 // SendFormattedText sends text with WhatsApp formatting
-func (c *Client) SendFormattedText(ctx context.Context, jid string, text string) error {
+func (c *Client) SendFormattedText(ctx context.Context, jid string, text string) (string, error) {
 	targetJID, err := parseJID(jid)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	// WhatsApp formatting:
 	// *bold* _italic_ ~strikethrough~ ```monospace```
 
-	_, err = c.wa.SendMessage(ctx, targetJID, &waE2E.Message{
+	resp, err := c.wa.SendMessage(ctx, targetJID, &waE2E.Message{
 		Conversation: &text,
 	})
-	return err
+	if err != nil {
+		return "", err
+	}
+	return string(resp.ID), nil
+}
+
+// Button represents a quick reply button
+type Button struct {
+	ID   string
+	Text string
 }
 
 // SendButtonMessage sends message with buttons (Quick Reply)
-func (c *Client) SendButtonMessage(ctx context.Context, jid string, text string, buttons []string) error {
+func (c *Client) SendButtonMessage(ctx context.Context, jid string, text string, buttons []Button) (string, error) {
 	targetJID, err := parseJID(jid)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	// Create button messages
 	var buttonMessages []*waE2E.ButtonsMessage_Button
-	for i, btnText := range buttons {
-		btnID := fmt.Sprintf("btn_%d", i)
-		btnTextCopy := btnText
+	for i, btn := range buttons {
+		btnID := btn.ID
+		if btnID == "" {
+			btnID = fmt.Sprintf("btn_%d", i)
+		}
+		btnTextCopy := btn.Text
 		buttonMessages = append(buttonMessages, &waE2E.ButtonsMessage_Button{
 			ButtonID: &btnID,
 			ButtonText: &waE2E.ButtonsMessage_Button_ButtonText{
@@ -46,27 +58,33 @@ func (c *Client) SendButtonMessage(ctx context.Context, jid string, text string,
 		})
 	}
 
-	_, err = c.wa.SendMessage(ctx, targetJID, &waE2E.Message{
+	resp, err := c.wa.SendMessage(ctx, targetJID, &waE2E.Message{
 		ButtonsMessage: &waE2E.ButtonsMessage{
 			ContentText: &text,
 			Buttons:     buttonMessages,
 		},
 	})
-	return err
+	if err != nil {
+		return "", err
+	}
+	return string(resp.ID), nil
 }
 
 // SendListMessage sends message with list menu
-func (c *Client) SendListMessage(ctx context.Context, jid string, title, description, buttonText string, sections []ListSection) error {
+func (c *Client) SendListMessage(ctx context.Context, jid string, title, description, buttonText string, sections []ListSection) (string, error) {
 	targetJID, err := parseJID(jid)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	var listSections []*waE2E.ListMessage_Section
 	for _, section := range sections {
 		var rows []*waE2E.ListMessage_Row
 		for i, item := range section.Items {
-			rowID := fmt.Sprintf("row_%d", i)
+			rowID := item.ID
+			if rowID == "" {
+				rowID = fmt.Sprintf("row_%d", i)
+			}
 			rows = append(rows, &waE2E.ListMessage_Row{
 				RowID:       &rowID,
 				Title:       &item.Title,
@@ -79,7 +97,7 @@ func (c *Client) SendListMessage(ctx context.Context, jid string, title, descrip
 		})
 	}
 
-	_, err = c.wa.SendMessage(ctx, targetJID, &waE2E.Message{
+	resp, err := c.wa.SendMessage(ctx, targetJID, &waE2E.Message{
 		ListMessage: &waE2E.ListMessage{
 			Title:       &title,
 			Description: &description,
@@ -88,7 +106,10 @@ func (c *Client) SendListMessage(ctx context.Context, jid string, title, descrip
 			Sections:    listSections,
 		},
 	})
-	return err
+	if err != nil {
+		return "", err
+	}
+	return string(resp.ID), nil
 }
 
 // ListSection represents a section in list message
@@ -104,17 +125,45 @@ type ListItem struct {
 	Description string
 }
 
+// SendLocationRequest asks the user to share their location
+func (c *Client) SendLocationRequest(ctx context.Context, jid string, text string) (string, error) {
+	targetJID, err := parseJID(jid)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.wa.SendMessage(ctx, targetJID, &waE2E.Message{
+		InteractiveMessage: &waE2E.InteractiveMessage{
+			Body: &waE2E.InteractiveMessage_Body{Text: &text},
+			InteractiveMessage: &waE2E.InteractiveMessage_NativeFlowMessage_{
+				NativeFlowMessage: &waE2E.InteractiveMessage_NativeFlowMessage{
+					Buttons: []*waE2E.InteractiveMessage_NativeFlowMessage_NativeFlowButton{
+						{
+							Name:             stringPtr("send_location"),
+							ButtonParamsJSON: stringPtr("{}"),
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(resp.ID), nil
+}
+
 // SendImage sends an image with optional caption
-func (c *Client) SendImage(ctx context.Context, jid string, imageData []byte, caption string) error {
+func (c *Client) SendImage(ctx context.Context, jid string, imageData []byte, caption string) (string, error) {
 	targetJID, err := parseJID(jid)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	// Upload image to WhatsApp servers
 	uploaded, err := c.wa.Upload(ctx, imageData, whatsmeow.MediaImage)
 	if err != nil {
-		return fmt.Errorf("failed to upload image: %w", err)
+		return "", fmt.Errorf("failed to upload image: %w", err)
 	}
 
 	imageMsg := &waE2E.ImageMessage{
@@ -131,26 +180,29 @@ func (c *Client) SendImage(ctx context.Context, jid string, imageData []byte, ca
 		imageMsg.Caption = &caption
 	}
 
-	_, err = c.wa.SendMessage(ctx, targetJID, &waE2E.Message{
+	resp, err := c.wa.SendMessage(ctx, targetJID, &waE2E.Message{
 		ImageMessage: imageMsg,
 	})
-	return err
+	if err != nil {
+		return "", err
+	}
+	return string(resp.ID), nil
 }
 
 // SendDocument sends a document file
-func (c *Client) SendDocument(ctx context.Context, jid string, docData []byte, filename, mimetype string) error {
+func (c *Client) SendDocument(ctx context.Context, jid string, docData []byte, filename, mimetype string) (string, error) {
 	targetJID, err := parseJID(jid)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	// Upload document to WhatsApp servers
 	uploaded, err := c.wa.Upload(ctx, docData, whatsmeow.MediaDocument)
 	if err != nil {
-		return fmt.Errorf("failed to upload document: %w", err)
+		return "", fmt.Errorf("failed to upload document: %w", err)
 	}
 
-	_, err = c.wa.SendMessage(ctx, targetJID, &waE2E.Message{
+	resp, err := c.wa.SendMessage(ctx, targetJID, &waE2E.Message{
 		DocumentMessage: &waE2E.DocumentMessage{
 			URL:           &uploaded.URL,
 			DirectPath:    &uploaded.DirectPath,
@@ -162,7 +214,47 @@ func (c *Client) SendDocument(ctx context.Context, jid string, docData []byte, f
 			FileName:      &filename,
 		},
 	})
-	return err
+	if err != nil {
+		return "", err
+	}
+	return string(resp.ID), nil
+}
+
+// SendAudio sends an audio file; ptt sends it as a voice note (OGG/Opus)
+func (c *Client) SendAudio(ctx context.Context, jid string, audioData []byte, mimetype string, seconds int, ptt bool) (string, error) {
+	targetJID, err := parseJID(jid)
+	if err != nil {
+		return "", err
+	}
+
+	if mimetype == "" {
+		mimetype = "audio/ogg; codecs=opus"
+	}
+
+	// Upload audio to WhatsApp servers
+	uploaded, err := c.wa.Upload(ctx, audioData, whatsmeow.MediaAudio)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload audio: %w", err)
+	}
+
+	duration := uint32(seconds)
+	resp, err := c.wa.SendMessage(ctx, targetJID, &waE2E.Message{
+		AudioMessage: &waE2E.AudioMessage{
+			URL:           &uploaded.URL,
+			DirectPath:    &uploaded.DirectPath,
+			MediaKey:      uploaded.MediaKey,
+			Mimetype:      &mimetype,
+			FileEncSHA256: uploaded.FileEncSHA256,
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    &uploaded.FileLength,
+			Seconds:       &duration,
+			PTT:           &ptt,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(resp.ID), nil
 }
 
 // SendTyping sends typing indicator
@@ -1,6 +1,7 @@
 package whatsapp
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -57,6 +58,120 @@ func ExtractTextFromMessage(msg *waE2E.Message) string {
 	return ""
 }
 
+// ExtractInteractiveResponse extracts the selected id and display text from
+// button, list and native flow responses. kind is "button" or "list", or ""
+// when the message is not an interactive response.
+func ExtractInteractiveResponse(msg *waE2E.Message) (kind, selectedID, displayText string) {
+	if msg == nil {
+		return "", "", ""
+	}
+
+	// Quick reply button
+	if btn := msg.GetButtonsResponseMessage(); btn != nil {
+		return "button", btn.GetSelectedButtonID(), btn.GetSelectedDisplayText()
+	}
+
+	// Template button
+	if tpl := msg.GetTemplateButtonReplyMessage(); tpl != nil {
+		return "button", tpl.GetSelectedID(), tpl.GetSelectedDisplayText()
+	}
+
+	// List menu row
+	if list := msg.GetListResponseMessage(); list != nil {
+		return "list", list.GetSingleSelectReply().GetSelectedRowID(), list.GetTitle()
+	}
+
+	// Native flow (newer interactive messages), id is in params JSON
+	if resp := msg.GetInteractiveResponseMessage(); resp != nil {
+		flow := resp.GetNativeFlowResponseMessage()
+		var params struct {
+			ID string `json:"id"`
+		}
+		if flow != nil {
+			_ = json.Unmarshal([]byte(flow.GetParamsJSON()), &params)
+		}
+		return "button", params.ID, resp.GetBody().GetText()
+	}
+
+	return "", "", ""
+}
+
+// ExtractReaction returns the reaction emoji and the id of the reacted message.
+// An empty emoji means the reaction was removed.
+func ExtractReaction(msg *waE2E.Message) (emoji, targetMessageID string, ok bool) {
+	reaction := msg.GetReactionMessage()
+	if reaction == nil {
+		return "", "", false
+	}
+	return reaction.GetText(), reaction.GetKey().GetID(), true
+}
+
+// GetQuotedMessageID returns the id of the message being replied to, if any
+func GetQuotedMessageID(msg *waE2E.Message) string {
+	if msg == nil {
+		return ""
+	}
+
+	if ext := msg.GetExtendedTextMessage(); ext != nil {
+		return ext.GetContextInfo().GetStanzaID()
+	}
+	if img := msg.GetImageMessage(); img != nil {
+		return img.GetContextInfo().GetStanzaID()
+	}
+	if audio := msg.GetAudioMessage(); audio != nil {
+		return audio.GetContextInfo().GetStanzaID()
+	}
+
+	return ""
+}
+
+// LocationInfo is a shared (or live) location
+type LocationInfo struct {
+	Latitude  float64
+	Longitude float64
+	Name      string
+	Address   string
+}
+
+// GetLocation returns the shared location, or nil if the message has none
+func GetLocation(msg *waE2E.Message) *LocationInfo {
+	if loc := msg.GetLocationMessage(); loc != nil {
+		return &LocationInfo{
+			Latitude:  loc.GetDegreesLatitude(),
+			Longitude: loc.GetDegreesLongitude(),
+			Name:      loc.GetName(),
+			Address:   loc.GetAddress(),
+		}
+	}
+	if live := msg.GetLiveLocationMessage(); live != nil {
+		return &LocationInfo{
+			Latitude:  live.GetDegreesLatitude(),
+			Longitude: live.GetDegreesLongitude(),
+		}
+	}
+	return nil
+}
+
+// ContactCard is a forwarded contact
+type ContactCard struct {
+	DisplayName string
+	VCard       string
+}
+
+// GetContactCards returns forwarded contacts (single or multiple)
+func GetContactCards(msg *waE2E.Message) []ContactCard {
+	var cards []ContactCard
+	if contact := msg.GetContactMessage(); contact != nil {
+		cards = append(cards, ContactCard{DisplayName: contact.GetDisplayName(), VCard: contact.GetVcard()})
+	}
+	if array := msg.GetContactsArrayMessage(); array != nil {
+		for _, contact := range array.GetContacts() {
+			cards = append(cards, ContactCard{DisplayName: contact.GetDisplayName(), VCard: contact.GetVcard()})
+		}
+	}
+	return cards
+}
+
 // IsAudioMessage checks if message contains audio/voice note
 func IsAudioMessage(msg *waE2E.Message) bool {
 	if msg == nil {
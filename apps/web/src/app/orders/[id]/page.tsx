@@ -15,6 +15,7 @@ type Order = {
   payment_method: string | null
   subtotal: number
   delivery_fee: number
+  ppn_amount: number | null
   total_amount: number
   delivery_address: string
   delivery_notes: string
@@ -260,6 +261,12 @@ function OrderDetailContent() {
                 <span>Ongkir</span>
                 <span>{order.delivery_fee === 0 ? 'Gratis' : formatCurrency(order.delivery_fee)}</span>
               </div>
+              {!!order.ppn_amount && (
+                <div className="flex justify-between text-gray-600">
+                  <span>PPN (termasuk dalam subtotal)</span>
+                  <span>{formatCurrency(order.ppn_amount)}</span>
+                </div>
+              )}
               <div className="flex justify-between text-xl font-bold pt-2 border-t">
                 <span>Total</span>
                 <span className="text-green-600">{formatCurrency(order.total_amount)}</span>
@@ -17,7 +17,7 @@ interface Transaction {
 }
 
 function TransactionsContent() {
-  const { user } = useAuth()
+  const { user, session } = useAuth()
   const [transactions, setTransactions] = useState<Transaction[]>([])
   const [filteredTransactions, setFilteredTransactions] = useState<Transaction[]>([])
   const [loading, setLoading] = useState(true)
@@ -48,6 +48,7 @@ function TransactionsContent() {
         .from('transactions')
         .select('*')
         .eq('user_id', user?.id)
+        .is('voided_at', null)
         .order('created_at', { ascending: false })
 
       if (error) throw error
@@ -135,12 +136,14 @@ function TransactionsContent() {
     if (!confirm('Yakin ingin menghapus transaksi ini?')) return
 
     try {
-      const { error } = await supabase
-        .from('transactions')
-        .delete()
-        .eq('id', id)
-
-      if (error) throw error
+      // The backend also puts stock back, reverses costing and the journal
+      // and refunds payments
+      const response = await fetch(`${process.env.NEXT_PUBLIC_BACKEND_URL}/api/transactions/${id}`, {
+        method: 'DELETE',
+        headers: { Authorization: `Bearer ${session?.access_token}` }
+      })
+
+      if (!response.ok) throw new Error(await response.text())
 
       await fetchTransactions()
     } catch (error) {
@@ -36,6 +36,7 @@ export function CategoryBreakdownChart({ dateRange }: CategoryBreakdownChartProp
         .from('transactions')
         .select('*')
         .eq('user_id', user?.id)
+        .is('voided_at', null)
         .in('type', ['PURCHASE', 'EXPENSE'])
         .gte('created_at', dateRange.from.toISOString())
         .lte('created_at', dateRange.to.toISOString())
@@ -35,6 +35,7 @@ export function ProfitAnalysisChart({ dateRange }: ProfitAnalysisChartProps) {
         .from('transactions')
         .select('*')
         .eq('user_id', user?.id)
+        .is('voided_at', null)
         .gte('created_at', dateRange.from.toISOString())
         .lte('created_at', dateRange.to.toISOString())
         .order('created_at', { ascending: true })
@@ -34,6 +34,7 @@ export function SalesTrendChart({ dateRange }: SalesTrendChartProps) {
         .from('transactions')
         .select('*')
         .eq('user_id', user?.id)
+        .is('voided_at', null)
         .eq('type', 'SALE')
         .gte('created_at', dateRange.from.toISOString())
         .lte('created_at', dateRange.to.toISOString())
@@ -30,6 +30,7 @@ export function RecentTransactions({ limit = 10 }: { limit?: number }) {
         .from('transactions')
         .select('*')
         .eq('user_id', user?.id)
+        .is('voided_at', null)
         .order('created_at', { ascending: false })
         .limit(limit)
 
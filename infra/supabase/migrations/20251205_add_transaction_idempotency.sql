@@ -0,0 +1,13 @@
+-- Migration: Add idempotency key to transactions
+-- Created: 2025-12-05
+-- Description: WhatsApp message id used as idempotency key so redelivered
+-- messages never create duplicate transactions
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS idempotency_key TEXT;
+
+-- One transaction per user per source message
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_idempotency_key
+  ON transactions(user_id, idempotency_key)
+  WHERE idempotency_key IS NOT NULL;
+
+COMMENT ON COLUMN transactions.idempotency_key IS 'Source message id (e.g. WhatsApp message id), unique per user';
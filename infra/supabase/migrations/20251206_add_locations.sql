@@ -0,0 +1,16 @@
+-- Migration: Add coordinates to users and contacts
+-- Created: 2025-12-06
+-- Description: Locations shared over WhatsApp feed seller discovery and delivery quotes
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS location_updated_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION;
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
+
+-- Bounding-box lookups for nearby sellers
+CREATE INDEX IF NOT EXISTS idx_users_location ON users(latitude, longitude) WHERE latitude IS NOT NULL;
+
+COMMENT ON COLUMN users.latitude IS 'Business location latitude (WGS84), shared via WhatsApp';
+COMMENT ON COLUMN users.longitude IS 'Business location longitude (WGS84), shared via WhatsApp';
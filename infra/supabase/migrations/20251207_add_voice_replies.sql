@@ -0,0 +1,7 @@
+-- Migration: Add voice reply preference
+-- Created: 2025-12-07
+-- Description: Users can ask for replies as WhatsApp voice notes ("balas pakai suara")
+
+ALTER TABLE user_preferences ADD COLUMN IF NOT EXISTS voice_replies BOOLEAN DEFAULT FALSE;
+
+COMMENT ON COLUMN user_preferences.voice_replies IS 'Send replies as text-to-speech voice notes in addition to text';
@@ -0,0 +1,27 @@
+-- Migration: Add LLM usage metering
+-- Created: 2025-12-08
+-- Description: One row per LLM call (tokens, latency, model, feature, tenant)
+-- for cost reports and monthly plan quotas
+
+CREATE TABLE IF NOT EXISTS llm_usage (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  tenant TEXT,                        -- WhatsApp number or user id, NULL for system calls
+  feature VARCHAR(30) NOT NULL,       -- intent, stt, promo, categorization, social, other
+  provider VARCHAR(30),
+  model VARCHAR(60),
+  input_tokens INTEGER NOT NULL DEFAULT 0,
+  output_tokens INTEGER NOT NULL DEFAULT 0,
+  estimated BOOLEAN NOT NULL DEFAULT FALSE,
+  latency_ms INTEGER NOT NULL DEFAULT 0,
+  cost_usd NUMERIC(12, 6) NOT NULL DEFAULT 0,
+  outcome VARCHAR(10),                -- NULL on success, failed or quota
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_llm_usage_created_at ON llm_usage(created_at);
+CREATE INDEX IF NOT EXISTS idx_llm_usage_tenant ON llm_usage(tenant, created_at);
+
+-- Written by the backend service key only
+ALTER TABLE llm_usage ENABLE ROW LEVEL SECURITY;
+
+COMMENT ON TABLE llm_usage IS 'Metered LLM calls for cost accounting and per-tenant quotas';
@@ -0,0 +1,21 @@
+-- Migration: Non-destructive transaction corrections
+-- Created: 2025-12-09
+-- Description: Cancelled and corrected transactions are voided instead of
+-- deleted or overwritten. A correction is a new row pointing at the row it
+-- replaces, so reports skip voided rows and the history stays complete.
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS voided_at TIMESTAMPTZ;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS void_reason VARCHAR(20)
+  CHECK (void_reason IN ('CANCELLED', 'CORRECTED'));
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS replaces_id UUID REFERENCES transactions(id);
+
+-- Reports only read live rows
+CREATE INDEX IF NOT EXISTS idx_transactions_live
+  ON transactions(user_id, created_at DESC)
+  WHERE voided_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_transactions_replaces ON transactions(replaces_id)
+  WHERE replaces_id IS NOT NULL;
+
+COMMENT ON COLUMN transactions.voided_at IS 'Set when the transaction was cancelled or replaced by a correction';
+COMMENT ON COLUMN transactions.void_reason IS 'CANCELLED or CORRECTED';
+COMMENT ON COLUMN transactions.replaces_id IS 'Voided transaction this correction replaces';
@@ -0,0 +1,34 @@
+-- Migration: Receivables and payables (kasbon and utang-piutang)
+-- Created: 2025-12-10
+-- Description: Sales and purchases on credit point at a contact and track
+-- what is still owed. Repayments are rows in payments (PARTIAL until the
+-- transaction is settled). Reminders to customers go through
+-- notification_queue with the customer's phone as recipient.
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS contact_id UUID REFERENCES contacts(id) ON DELETE SET NULL;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS due_date DATE;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS amount_paid DECIMAL(15,2) DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS settlement_status VARCHAR(10)
+  CHECK (settlement_status IN ('OPEN', 'PARTIAL', 'PAID'));
+
+-- Open credit per contact, oldest first
+CREATE INDEX IF NOT EXISTS idx_transactions_open_credit
+  ON transactions(user_id, contact_id, created_at)
+  WHERE settlement_status IN ('OPEN', 'PARTIAL') AND voided_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_transactions_due
+  ON transactions(due_date)
+  WHERE settlement_status IN ('OPEN', 'PARTIAL') AND voided_at IS NULL;
+
+ALTER TABLE notification_queue ADD COLUMN IF NOT EXISTS recipient_phone TEXT;
+ALTER TABLE notification_queue ADD COLUMN IF NOT EXISTS reference_id UUID;
+
+CREATE INDEX IF NOT EXISTS idx_notification_queue_reference
+  ON notification_queue(reference_id, type, created_at DESC)
+  WHERE reference_id IS NOT NULL;
+
+COMMENT ON COLUMN transactions.contact_id IS 'Customer or supplier of a sale or purchase on credit';
+COMMENT ON COLUMN transactions.due_date IS 'When the credit should be paid';
+COMMENT ON COLUMN transactions.amount_paid IS 'Sum of repayments so far';
+COMMENT ON COLUMN transactions.settlement_status IS 'OPEN, PARTIAL or PAID for credit; NULL when paid on the spot';
+COMMENT ON COLUMN notification_queue.recipient_phone IS 'Send to this number instead of the user, e.g. a customer reminder';
+COMMENT ON COLUMN notification_queue.reference_id IS 'Record the notification is about, used to space out reminders';
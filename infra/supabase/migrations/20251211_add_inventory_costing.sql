@@ -0,0 +1,54 @@
+-- Migration: FIFO inventory costing
+-- Created: 2025-12-11
+-- Description: Every purchase adds a cost layer (qty at unit cost). Sales
+-- consume the oldest layers first and record their cost of goods sold on the
+-- transaction, so gross profit no longer swings with the days stock is bought.
+-- Usage rows remember which layers a sale consumed, so cancelling or
+-- correcting the sale puts the qty back.
+
+CREATE TABLE IF NOT EXISTS inventory_layers (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  product_name VARCHAR(255) NOT NULL,
+  transaction_id UUID REFERENCES transactions(id) ON DELETE SET NULL, -- Purchase that brought the stock in
+  qty_received DECIMAL(15,3) NOT NULL,
+  qty_remaining DECIMAL(15,3) NOT NULL,
+  unit_cost DECIMAL(15,2) NOT NULL,
+  received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  voided_at TIMESTAMPTZ,                                               -- Purchase was cancelled
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS inventory_layer_usage (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  layer_id UUID NOT NULL REFERENCES inventory_layers(id) ON DELETE CASCADE,
+  transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE, -- Sale that consumed it
+  qty DECIMAL(15,3) NOT NULL,
+  unit_cost DECIMAL(15,2) NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Open layers per product, oldest first
+CREATE INDEX IF NOT EXISTS idx_inventory_layers_open
+  ON inventory_layers(user_id, product_name, received_at)
+  WHERE qty_remaining > 0 AND voided_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_inventory_layers_transaction ON inventory_layers(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_inventory_layer_usage_transaction ON inventory_layer_usage(transaction_id);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS cogs DECIMAL(15,2);
+
+ALTER TABLE inventory_layers ENABLE ROW LEVEL SECURITY;
+ALTER TABLE inventory_layer_usage ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY "Users can view their own inventory layers"
+    ON inventory_layers FOR SELECT
+    USING (auth.uid() = user_id);
+
+CREATE POLICY "Users can view their own inventory layer usage"
+    ON inventory_layer_usage FOR SELECT
+    USING (auth.uid() = user_id);
+
+COMMENT ON TABLE inventory_layers IS 'FIFO cost layers, one per purchase';
+COMMENT ON TABLE inventory_layer_usage IS 'Qty each sale took from each layer, for reversals';
+COMMENT ON COLUMN transactions.cogs IS 'Cost of goods sold of a sale; NULL when no purchase cost is known';
@@ -0,0 +1,48 @@
+-- Migration: Double-entry general ledger
+-- Created: 2025-12-12
+-- Description: Every sale, purchase, expense, payment and inventory
+-- adjustment is posted as a balanced journal entry against the UMKM chart of
+-- accounts (kept in the backend, see internal/ledger). Entries are never
+-- edited: cancelling or correcting a transaction posts a reversal. Profit and
+-- loss, balance sheet and cash flow statements are built from these entries.
+
+-- Lines are [{"account": "1-1100", "debit": 15000}, {"account": "4-1100", "credit": 15000}]
+CREATE OR REPLACE FUNCTION journal_lines_balanced(lines JSONB)
+RETURNS BOOLEAN AS $$
+  SELECT jsonb_typeof(lines) = 'array'
+     AND jsonb_array_length(lines) >= 2
+     AND ROUND(COALESCE(SUM(COALESCE((line->>'debit')::NUMERIC, 0)), 0), 2)
+       = ROUND(COALESCE(SUM(COALESCE((line->>'credit')::NUMERIC, 0)), 0), 2)
+  FROM jsonb_array_elements(CASE WHEN jsonb_typeof(lines) = 'array' THEN lines ELSE '[]'::JSONB END) AS line;
+$$ LANGUAGE SQL IMMUTABLE;
+
+CREATE TABLE IF NOT EXISTS journal_entries (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  entry_date DATE NOT NULL,
+  description TEXT,
+  source_type VARCHAR(20) NOT NULL
+    CHECK (source_type IN ('SALE', 'PURCHASE', 'EXPENSE', 'PAYMENT', 'ADJUSTMENT', 'REVERSAL')),
+  transaction_id UUID REFERENCES transactions(id) ON DELETE SET NULL,
+  payment_id UUID,
+  reverses_id UUID REFERENCES journal_entries(id) ON DELETE SET NULL,
+  reversed_at TIMESTAMPTZ,
+  lines JSONB NOT NULL CHECK (journal_lines_balanced(lines)),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_journal_entries_user_date ON journal_entries(user_id, entry_date);
+CREATE INDEX IF NOT EXISTS idx_journal_entries_transaction
+  ON journal_entries(transaction_id)
+  WHERE reversed_at IS NULL;
+
+ALTER TABLE journal_entries ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY "Users can view their own journal entries"
+    ON journal_entries FOR SELECT
+    USING (auth.uid() = user_id);
+
+COMMENT ON TABLE journal_entries IS 'Double-entry journal; corrections are reversals, never edits';
+COMMENT ON COLUMN journal_entries.lines IS 'Debit and credit lines per account code; debits equal credits';
+COMMENT ON COLUMN journal_entries.reverses_id IS 'Entry this reversal undoes';
+COMMENT ON COLUMN journal_entries.reversed_at IS 'Set once a reversal of this entry is posted';
@@ -0,0 +1,36 @@
+-- Migration: Monthly expense budgets
+-- Created: 2025-12-13
+-- Description: One monthly budget per expense category (BAHAN_BAKU,
+-- OPERASIONAL, GAJI, TRANSPORTASI, PEMASARAN, LAINNYA). Expenses keep the
+-- category they were recorded under so spending is tracked against the
+-- budget it was counted in. alert_month/alert_level remember the highest
+-- alert (80 or 100 percent) already sent, so each fires once a month.
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS expense_category VARCHAR(20);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_expense_category
+  ON transactions(user_id, expense_category, created_at)
+  WHERE type = 'EXPENSE' AND voided_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS expense_budgets (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  category VARCHAR(20) NOT NULL,
+  monthly_amount DECIMAL(15,2) NOT NULL CHECK (monthly_amount > 0),
+  alert_month VARCHAR(7),
+  alert_level INTEGER NOT NULL DEFAULT 0 CHECK (alert_level IN (0, 80, 100)),
+  created_at TIMESTAMPTZ DEFAULT NOW(),
+  updated_at TIMESTAMPTZ DEFAULT NOW(),
+  UNIQUE (user_id, category)
+);
+
+ALTER TABLE expense_budgets ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY "Users can view their own expense budgets"
+    ON expense_budgets FOR SELECT
+    USING (auth.uid() = user_id);
+
+COMMENT ON COLUMN transactions.expense_category IS 'Expense category the expense was recorded and budgeted under';
+COMMENT ON COLUMN expense_budgets.monthly_amount IS 'Budget per calendar month in the user''s time zone';
+COMMENT ON COLUMN expense_budgets.alert_month IS 'YYYY-MM of alert_level';
+COMMENT ON COLUMN expense_budgets.alert_level IS 'Highest alert sent in alert_month: 0, 80 or 100 percent';
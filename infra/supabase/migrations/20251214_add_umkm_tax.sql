@@ -0,0 +1,57 @@
+-- Migration: UMKM tax
+-- Created: 2025-12-14
+-- Description: Tax profile per user (PPh Final 0,5% or normal regime,
+-- individual or entity, PKP charging PPN). Prices of a PKP include PPN:
+-- sales keep it in their total and marketplace orders in their subtotal.
+
+CREATE TABLE IF NOT EXISTS tax_profiles (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE UNIQUE,
+  regime VARCHAR(20) NOT NULL DEFAULT 'PPH_FINAL' CHECK (regime IN ('PPH_FINAL', 'NORMAL')),
+  taxpayer_type VARCHAR(20) NOT NULL DEFAULT 'ORANG_PRIBADI' CHECK (taxpayer_type IN ('ORANG_PRIBADI', 'BADAN')),
+  final_rate DECIMAL(6,4) NOT NULL DEFAULT 0.005 CHECK (final_rate >= 0),
+  exempt_turnover DECIMAL(15,2) NOT NULL DEFAULT 500000000 CHECK (exempt_turnover >= 0),
+  is_pkp BOOLEAN NOT NULL DEFAULT false,
+  ppn_rate DECIMAL(6,4) NOT NULL DEFAULT 0.11 CHECK (ppn_rate >= 0),
+  paid_month VARCHAR(7),
+  created_at TIMESTAMPTZ DEFAULT NOW(),
+  updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+ALTER TABLE tax_profiles ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY "Users can view their own tax profile"
+    ON tax_profiles FOR SELECT
+    USING (auth.uid() = user_id);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS ppn_amount DECIMAL(15,2) DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS ppn_amount DECIMAL(15,2) DEFAULT 0 CHECK (ppn_amount >= 0);
+
+-- Orders are placed by the buyer, who cannot read the seller's profile, so
+-- the PPN inside the subtotal is recorded here
+CREATE OR REPLACE FUNCTION add_order_ppn()
+RETURNS TRIGGER AS $$
+DECLARE
+  rate DECIMAL(6,4);
+BEGIN
+  SELECT tp.ppn_rate INTO rate
+  FROM tax_profiles tp
+  JOIN seller_profiles sp ON sp.user_id = tp.user_id
+  WHERE sp.id = NEW.seller_id AND tp.is_pkp;
+
+  IF rate IS NOT NULL THEN
+    NEW.ppn_amount := ROUND(NEW.subtotal * rate / (1 + rate));
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER;
+
+DROP TRIGGER IF EXISTS add_order_ppn ON orders;
+CREATE TRIGGER add_order_ppn
+  BEFORE INSERT ON orders
+  FOR EACH ROW EXECUTE FUNCTION add_order_ppn();
+
+COMMENT ON COLUMN tax_profiles.exempt_turnover IS 'Yearly turnover of an individual free of PPh Final (PP 55/2022)';
+COMMENT ON COLUMN tax_profiles.paid_month IS 'YYYY-MM of the last month whose tax the user reported paid';
+COMMENT ON COLUMN transactions.ppn_amount IS 'PPN included in total_amount of a sale by a PKP';
+COMMENT ON COLUMN orders.ppn_amount IS 'PPN included in subtotal for sellers who are PKP';
@@ -0,0 +1,41 @@
+-- Migration: Recurring transactions
+-- Created: 2025-12-15
+-- Description: Rules that post a transaction on a schedule, e.g. rent on
+-- the 1st of every month or gas every Monday. schedule is a five-field cron
+-- in the user's time zone and next_run_at the next time it is due. Rules
+-- that are not auto-posted keep the due run in pending_run_at until the
+-- user confirms or skips it.
+
+CREATE TABLE IF NOT EXISTS recurring_rules (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  type VARCHAR(10) NOT NULL DEFAULT 'EXPENSE' CHECK (type IN ('SALE', 'PURCHASE', 'EXPENSE')),
+  product_name TEXT NOT NULL,
+  qty DECIMAL(10,2) NOT NULL DEFAULT 1 CHECK (qty > 0),
+  price_per_unit DECIMAL(15,2) NOT NULL CHECK (price_per_unit > 0),
+  schedule VARCHAR(100) NOT NULL,
+  auto_post BOOLEAN NOT NULL DEFAULT false,
+  status VARCHAR(10) NOT NULL DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'PAUSED')),
+  next_run_at TIMESTAMPTZ NOT NULL,
+  pending_run_at TIMESTAMPTZ,
+  last_run_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ DEFAULT NOW(),
+  updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_recurring_rules_due
+  ON recurring_rules(next_run_at)
+  WHERE status = 'ACTIVE';
+
+CREATE INDEX IF NOT EXISTS idx_recurring_rules_user
+  ON recurring_rules(user_id, created_at);
+
+ALTER TABLE recurring_rules ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY "Users can view their own recurring rules"
+    ON recurring_rules FOR SELECT
+    USING (auth.uid() = user_id);
+
+COMMENT ON COLUMN recurring_rules.schedule IS 'Cron (minute hour day month weekday) in the user''s time zone; day L is the last day of the month';
+COMMENT ON COLUMN recurring_rules.auto_post IS 'Post on the due date without asking the user';
+COMMENT ON COLUMN recurring_rules.pending_run_at IS 'Due run waiting for the user to confirm or skip';
@@ -0,0 +1,15 @@
+-- Migration: Cash kasbon
+-- Created: 2025-12-16
+-- Description: A kasbon of money alone is a LOAN transaction, owed back by
+-- the customer but not a sale, so it stays out of turnover, product reports
+-- and profit. Kasbon of goods is the sale itself, on credit.
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_type_check
+  CHECK (type IN ('SALE', 'PURCHASE', 'EXPENSE', 'LOAN'));
+
+ALTER TABLE journal_entries DROP CONSTRAINT IF EXISTS journal_entries_source_type_check;
+ALTER TABLE journal_entries ADD CONSTRAINT journal_entries_source_type_check
+  CHECK (source_type IN ('SALE', 'PURCHASE', 'EXPENSE', 'LOAN', 'PAYMENT', 'ADJUSTMENT', 'REVERSAL'));
+
+COMMENT ON COLUMN transactions.contact_id IS 'Customer or supplier of a transaction on credit';